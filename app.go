@@ -1,44 +1,112 @@
 package main
 
 import (
+	"bytes"
 	"context"
-	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"net/http"
 	"os"
 	"path/filepath"
-	"regexp"
+	"slices"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+
+	"seemud-gui/internal/artpack"
+	"seemud-gui/internal/chat"
+	"seemud-gui/internal/cloudsync"
+	"seemud-gui/internal/combat"
+	"seemud-gui/internal/cooldown"
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/events"
+	"seemud-gui/internal/friends"
+	"seemud-gui/internal/highlight"
+	"seemud-gui/internal/history"
+	"seemud-gui/internal/i18n"
+	"seemud-gui/internal/idle"
+	"seemud-gui/internal/imagequeue"
+	"seemud-gui/internal/inventory"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/mapexport"
 	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/metrics"
+	"seemud-gui/internal/mssp"
+	"seemud-gui/internal/notify"
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/pacing"
 	"seemud-gui/internal/parser"
+	"seemud-gui/internal/party"
+	"seemud-gui/internal/profile"
+	"seemud-gui/internal/remote"
 	"seemud-gui/internal/renderer"
+	"seemud-gui/internal/schedule"
+	"seemud-gui/internal/script"
+	"seemud-gui/internal/scripting"
+	"seemud-gui/internal/session"
+	"seemud-gui/internal/sound"
+	"seemud-gui/internal/speech"
+	"seemud-gui/internal/speedwalk"
+	"seemud-gui/internal/statedb"
+	"seemud-gui/internal/stats"
 	"seemud-gui/internal/telnet"
+	"seemud-gui/internal/tick"
+	"seemud-gui/internal/timeline"
+	"seemud-gui/internal/transcript"
+	"seemud-gui/internal/trigger"
+	"seemud-gui/internal/vault"
+	"seemud-gui/internal/vitals"
 )
 
-// App struct
+var logger = logging.For("App")
+
+// App binds the headless engine to the Wails frontend, adding the
+// desktop-only subsystems (speech, sound, notifications) on top
 type App struct {
-	ctx            context.Context
-	mudClient      *telnet.Client
-	mudParser      *parser.WolfMUDParser
-	mudMapper      *mapper.Mapper
-	sdClient       *renderer.StableDiffusionClient
-	outputBuf      []string
-	outputMux      sync.RWMutex
-	connected      bool
-	currentRoom    *parser.ParsedOutput
-	roomMux        sync.RWMutex
-	roomImageCache map[string]string // Map of room name to image file path
-	imageCacheMux  sync.RWMutex
-	currentItems   []string // Items in current room
-	currentMobs    []string // Mobs/NPCs in current room
-	entityMux      sync.RWMutex
-	serverName     string   // Current MUD server name for map persistence
-}
-
-const defaultSDEndpoint = "http://127.0.0.1:7860"
+	ctx           context.Context
+	engine        *engine.Engine
+	outputCursor  int64 // Read position for the legacy GetOutput API
+	parsedCursor  int64 // Read position for GetParsedOutput
+	outputMux     sync.Mutex
+	framer        *output.Framer // Batches output into frames for the UI
+	scripts       *scripting.Scripts
+	chatCapture   *chat.Capture
+	inventory     *inventory.Tracker
+	narrator      *speech.Narrator
+	sounds        *sound.Engine
+	notifications *notify.Manager
+	idleMonitor   *idle.Monitor
+	dataDir       datadir.Dir
+	speedwalks    *speedwalk.Store
+	cooldowns     *cooldown.Tracker
+	vitals        *vitals.Tracker
+	friends       *friends.Tracker
+	servers       *mssp.Directory
+	dialectMux    sync.RWMutex
+	dialect       mapper.Dialect // Movement commands for the current server
+	remote        *remote.Server
+	party         *party.Client
+	metrics       *metrics.Server
+	vault         *vault.Vault
+	syncer        *cloudsync.Syncer
+	walkMux       sync.Mutex
+	walkBatch     int64 // Queue batch of the running speedwalk, 0 if none
+	walker        *speedwalk.Walker
+	pasteMux      sync.RWMutex
+	pasteDelay    time.Duration // Pause between pasted or file lines
+	variationMux  sync.RWMutex
+	variation     float64 // Denoising strength when regenerating, 0 for a new composition
+	imageQueue    *imagequeue.Queue
+	roomMux       sync.Mutex
+	lastRoom      engine.Room     // The room last pushed to the frontend
+	lastEntities  engine.Entities // What was in it
+}
+
+const defaultSDEndpoint = renderer.DefaultSDEndpoint
 
 func resolveSDEndpoint() string {
 	if value := strings.TrimSpace(os.Getenv("SEEMUD_SD_ENDPOINT")); value != "" {
@@ -48,484 +116,2500 @@ func resolveSDEndpoint() string {
 		return "http://" + value
 	}
 
-	return defaultSDEndpoint
+	return defaultSDEndpoint
+}
+
+// NewApp creates a new App application struct
+func NewApp() *App {
+	sdEndpoint := resolveSDEndpoint()
+	logger.Info("stable diffusion endpoint", "url", sdEndpoint)
+
+	dataDir := datadir.Resolve()
+	if err := datadir.Prepare(dataDir); err != nil {
+		logger.Warn("failed to prepare data directory", "error", err)
+	}
+	logger.Info("data directory", "path", dataDir.Root)
+
+	// Users can drop extra or corrected translations into <data>/locales
+	if err := i18n.Default().LoadDir(dataDir.Join("locales")); err != nil {
+		logger.Warn("failed to load locales", "error", err)
+	}
+	if err := i18n.Default().SetLocale(i18n.Detect()); err != nil {
+		logger.Debug("using default locale", "reason", err)
+	}
+	// Likewise parsers for MUDs SeeMUD doesn't know, in <data>/parsers
+	if err := parser.LoadDir(dataDir.Join("parsers")); err != nil {
+		logger.Warn("failed to load parser definitions", "error", err)
+	}
+
+	cfg := engine.ConfigFor(dataDir)
+	cfg.ImageBackend.SDEndpoint = sdEndpoint
+
+	soundSettings := sound.DefaultSettings()
+	soundSettings.MSPDirectory = dataDir.Sounds()
+
+	app := &App{
+		engine:        engine.New(cfg),
+		dataDir:       dataDir,
+		dialect:       mapper.Dialects[mapper.DefaultDialect],
+		pasteDelay:    pacing.DefaultPasteDelay,
+		variation:     renderer.DefaultDenoisingStrength,
+		imageQueue:    imagequeue.New(),
+		cooldowns:     cooldown.NewTracker(dataDir.Join("cooldowns")),
+		vitals:        vitals.NewTracker(dataDir.Join("vitals")),
+		vault:         vault.New(dataDir.Join("vault.json")),
+		syncer:        cloudsync.NewSyncer(),
+		chatCapture:   chat.NewCapture(chat.DefaultBufferSize),
+		inventory:     inventory.NewTracker(),
+		narrator:      speech.NewNarrator(speech.NewSystemSpeaker(), speech.DefaultSettings()),
+		sounds:        sound.NewEngine(sound.NewSystemPlayer(), soundSettings),
+		notifications: notify.NewManager(notify.NewSystemNotifier("SeeMUD"), notify.DefaultSettings()),
+	}
+
+	app.engine.OnLine(app.handleLine)
+	app.imageQueue.OnUpdate(func(update imagequeue.Update) {
+		app.emitEvent("image:queue", update)
+	})
+	if images, ok := app.engine.Images.(*engine.SDImageService); ok {
+		images.OnProgress(func(progress engine.ImageProgress) {
+			app.emitEvent("image:progress", progress)
+		})
+	}
+	app.narrator.OnNarrate(func(text string) {
+		app.emitEvent("speech:narration", text)
+	})
+	app.metrics = metrics.NewServer(app.engine.Metrics.Registry)
+	app.engine.Session.MSDP().OnUpdate(func(updated map[string]any) {
+		app.emitEvent("msdp:update", app.engine.Session.MSDP().Status())
+	})
+
+	// Mirror output, events and rooms to any remote clients
+	app.remote = remote.NewServer(app.SendCommand)
+	app.engine.Output.Subscribe(func(entry output.Entry) {
+		app.remote.Broadcast("output", entry)
+	})
+
+	// Deliver output to the UI a frame at a time rather than a line at a time
+	app.framer = output.NewFramer(output.DefaultFrameInterval, func(frame output.Frame) {
+		app.emitEvent("output:frame", frame)
+		app.pushRoom()
+	})
+	app.engine.Output.Subscribe(app.framer.Add)
+
+	// Users' Lua scripts, from <data>/scripts
+	app.scripts = scripting.New(app.engine, dataDir.Join("scripts"))
+	app.scripts.OnPrint(func(name, text string) {
+		app.emitEvent("script:print", map[string]string{"script": name, "text": text})
+	})
+	app.scripts.OnGenerateImage(app.scriptImage)
+	if _, err := app.scripts.Load(); err != nil {
+		logger.Warn("failed to load scripts", "error", err)
+	}
+
+	speedwalks, err := speedwalk.NewStore(dataDir.Join("speedwalks.json"))
+	if err != nil {
+		logger.Warn("failed to load speedwalks", "error", err)
+	}
+	app.speedwalks = speedwalks
+	app.walker = speedwalk.NewWalker(app.engine.Send)
+	app.walker.OnProgress(func(progress pacing.Progress) {
+		app.emitEvent("speedwalk:progress", progress)
+	})
+
+	friendList, err := friends.NewTracker(dataDir.Join("friends.json"))
+	if err != nil {
+		logger.Warn("failed to load friends", "error", err)
+	}
+	app.friends = friendList
+
+	servers, err := mssp.NewDirectory(dataDir.Join("servers.json"))
+	if err != nil {
+		logger.Warn("failed to load server directory", "error", err)
+	}
+	app.servers = servers
+	app.engine.Queue.OnProgress(app.handleQueueProgress)
+
+	app.engine.Highlights.OnMatch(app.handleHighlight)
+	app.engine.Schedule.OnFire(func(fired schedule.Fired) {
+		app.emitEvent("schedule:fired", fired)
+	})
+	app.engine.Ticks.OnChange(func(status tick.Status) {
+		app.emitEvent("tick:changed", status)
+	})
+	app.engine.Combat.OnChange(func(summary combat.Summary) {
+		app.emitEvent("combat:changed", summary)
+	})
+
+	bus := app.engine.Events
+	bus.Subscribe(app.sounds.HandleEvent)
+	bus.Subscribe(app.notifications.HandleEvent)
+
+	// The idle monitor sends raw so its keepalives and replies don't count
+	// as user input
+	app.idleMonitor = idle.NewMonitor(idle.DefaultSettings(), app.engine.SendRaw)
+	app.idleMonitor.OnChange(func(status idle.Status) {
+		app.emitEvent("idle:status", status)
+	})
+	bus.Subscribe(app.idleMonitor.HandleEvent)
+	app.engine.Triggers.SetPauseCheck(app.idleMonitor.AutomationPaused)
+	bus.Subscribe(func(event events.Event) {
+		app.emitEvent("game:event", event)
+		app.remote.Broadcast("event", event)
+
+		if event.Kind == events.KindDisconnect {
+			app.imageQueue.CancelAll()
+			app.emitEvent("session:summary", app.engine.Stats.Snapshot())
+			app.friends.Reset()
+			app.emitEvent("friends:changed", app.friends.List())
+		}
+	})
+
+	// Share the map with a party exploring together
+	app.party = party.NewClient(party.Handler{
+		Rooms: app.handlePartyRooms,
+		Members: func(members []party.Member) {
+			app.emitEvent("party:members", members)
+		},
+		Joined: func(string) {
+			app.sharePartyMap()
+		},
+	})
+
+	app.engine.Rooms.OnRevise(app.pushRoom)
+
+	app.engine.Mapper.OnRoomChange(func(roomID string, isNew bool) {
+		app.walker.Arrived(roomID)
+		app.imageQueue.CancelOthers(roomID)
+		app.emitEvent("map:updated", roomID)
+		app.sharePartyRoom(roomID)
+		if visit, ok := app.engine.Timeline.Last(); ok && visit.RoomID == roomID {
+			app.emitEvent("timeline:visit", visit)
+		}
+		if room, ok := app.engine.Rooms.Current(); ok {
+			app.remote.Broadcast("room", room)
+		}
+	})
+
+	app.engine.OnStateChange(func(from, to session.State) {
+		app.emitEvent("connection:state", map[string]string{
+			"state":    string(to),
+			"previous": string(from),
+		})
+	})
+
+	return app
+}
+
+// startup is called when the app starts. The context is saved
+// so we can call the runtime methods
+func (a *App) startup(ctx context.Context) {
+	a.ctx = ctx
+	go a.idleMonitor.Run(ctx)
+	go a.pollFriends(ctx)
+	go a.maintainCache(ctx)
+}
+
+// Cache maintenance runs a little after startup, then occasionally
+const (
+	cacheScanDelay    = 2 * time.Minute
+	cacheScanInterval = 6 * time.Hour
+)
+
+// maintainCache scans the image cache in the background, dropping entries
+// whose files vanished and deleting bad or orphan files, and tells the
+// frontend if it fixed anything
+func (a *App) maintainCache(ctx context.Context) {
+	timer := time.NewTimer(cacheScanDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if images, err := a.imageCache(); err == nil {
+				if report := images.Scan(); report.Problems() > 0 {
+					a.emitEvent("cache:scanned", report)
+				}
+			}
+			timer.Reset(cacheScanInterval)
+		}
+	}
+}
+
+// pollFriends periodically sends the who command while in game so friends'
+// presence stays current even without login messages
+func (a *App) pollFriends(ctx context.Context) {
+	var last time.Time
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			settings := a.friends.Settings()
+			if !settings.PollWho || a.friends.Len() == 0 || a.engine.State() != session.StateInGame {
+				continue
+			}
+			if now.Sub(last) < time.Duration(settings.IntervalMinutes)*time.Minute {
+				continue
+			}
+			if a.idleMonitor.AutomationPaused() {
+				continue
+			}
+
+			last = now
+			if err := a.engine.SendRaw(settings.WhoCommand); err != nil {
+				logger.Warn("failed to poll who", "error", err)
+			}
+		}
+	}
+}
+
+// shutdown is called when the app is closing. It logs out of the MUD and
+// flushes anything still held in memory so closing the window loses nothing.
+func (a *App) shutdown(ctx context.Context) {
+	logger.Info("shutting down")
+
+	a.framer.Stop()
+	a.scripts.Close()
+	a.narrator.Stop()
+	a.remote.Stop()
+	a.party.Stop()
+	a.metrics.Stop()
+	a.vault.Lock()
+
+	// Close sends QUIT and saves the map
+	if err := a.engine.Close(); err != nil {
+		logger.Error("failed to close session", "error", err)
+	}
+
+	logger.Info("shutdown complete")
+}
+
+// emitEvent forwards an event to the frontend once the Wails runtime is available
+func (a *App) emitEvent(name string, data ...interface{}) {
+	if a.ctx == nil {
+		return
+	}
+	runtime.EventsEmit(a.ctx, name, data...)
+}
+
+// ConnectToMUD connects to the WolfMUD server, or any other, using the
+// parser and TLS settings its profile gives
+func (a *App) ConnectToMUD(host, port string) error {
+	return a.connect(a.serverProfile(host, port))
+}
+
+// ConnectToProfile connects to a saved profile's server, drawing rooms in
+// its style, keeping its map and playing its login script
+func (a *App) ConnectToProfile(name string) error {
+	cfg, err := profile.Load(a.dataDir.Join(profile.FileName))
+	if err != nil {
+		return i18n.Wrap(err, "error.profiles")
+	}
+	server, err := cfg.Get(name)
+	if err != nil {
+		return i18n.Error("error.unknown_profile", "name", name)
+	}
+	return a.connect(server)
+}
+
+// connect connects to a profile's server with everything it chooses
+func (a *App) connect(server profile.Profile) error {
+	a.engine.Session.SetOptions(a.connectOptions(server))
+	a.useDialect(server.Dialect)
+	a.useImageBackend(server)
+	a.useStyle(server.Style)
+
+	// The runner must be listening before the login banner arrives
+	login := a.loadLogin(server)
+	var runner *script.Runner
+	if login != nil {
+		runner = script.NewRunner(a.engine, nil)
+		// Stopped here unless it goes on to play the script, which stops it
+		defer func() {
+			if runner != nil {
+				runner.Stop()
+			}
+		}()
+	}
+	if err := a.engine.ConnectAs(server.Host, server.Port, server.ServerName()); err != nil {
+		return err
+	}
+	if runner != nil {
+		playing := runner
+		runner = nil
+		go func() {
+			defer playing.Stop()
+			if err := playing.Run(login); err != nil {
+				logger.Warn("login script stopped", "error", err)
+			}
+		}()
+	}
+
+	a.inventory.Reset()
+	a.narrator.Forget()
+	if err := a.cooldowns.Load(a.engine.ServerName()); err != nil {
+		logger.Warn("failed to load cooldowns", "error", err)
+	}
+	if err := a.vitals.Load(a.engine.ServerName()); err != nil {
+		logger.Warn("failed to load vitals settings", "error", err)
+	}
+	if a.syncer.Enabled() {
+		go a.syncInBackground()
+	}
+	// Maps exchanged before connecting were for no server in particular
+	if a.party.Connected() {
+		a.party.Hello()
+		go a.sharePartyMap()
+	}
+	return nil
+}
+
+// serverProfile returns the saved profile for a server, or an empty one if
+// it has none
+func (a *App) serverProfile(host, port string) profile.Profile {
+	cfg, err := profile.Load(a.dataDir.Join(profile.FileName))
+	if err != nil {
+		logger.Warn("failed to load profiles", "error", err)
+		return profile.Profile{Host: host, Port: port}
+	}
+	if name, exists := cfg.Find(host, port); exists {
+		return cfg.Profiles[name]
+	}
+	return profile.Profile{Host: host, Port: port}
+}
+
+// useDialect switches to a profile's parser, empty for the default, and to
+// its movement commands if SeeMUD has some for that family
+func (a *App) useDialect(name string) {
+	if err := a.engine.SetDialect(name); err != nil {
+		logger.Warn("using default parser", "error", err)
+		a.engine.SetDialect(parser.DefaultDialect)
+	}
+	if dialect, err := mapper.LookupDialect(name); err == nil {
+		a.dialectMux.Lock()
+		a.dialect = dialect
+		a.dialectMux.Unlock()
+	}
+}
+
+// useStyle draws rooms in the image style a profile chooses, if it chooses
+// one; otherwise the one chosen in the app carries on
+func (a *App) useStyle(name string) {
+	if name == "" {
+		return
+	}
+	if err := a.engine.Styles.SetSession(name); err != nil {
+		logger.Warn("keeping image style", "error", err)
+	}
+}
+
+// loadLogin loads a profile's login script, nil if it has none or it can't
+// be read
+func (a *App) loadLogin(server profile.Profile) *script.Script {
+	path := server.LoginScript()
+	if path == "" {
+		return nil
+	}
+	login, err := script.Load(path, server.ScriptVars())
+	if err != nil {
+		logger.Warn("failed to load login script", "error", err)
+		return nil
+	}
+	return login
+}
+
+// useImageBackend switches to the image backend a profile chooses, or the
+// configured one if it doesn't. The vault's credentials carry over.
+func (a *App) useImageBackend(server profile.Profile) {
+	if err := a.engine.UseImageBackend(server.ImageBackend, server.ImageEndpoint); err != nil {
+		logger.Warn("keeping image backend", "error", err)
+	}
+}
+
+// connectOptions returns the connection options from a server's profile.
+// A proxy that needs a password and doesn't have one gets it from the
+// vault.
+func (a *App) connectOptions(server profile.Profile) telnet.Options {
+	opts := server.Options()
+	if opts.Proxy == nil {
+		opts.Proxy = telnet.ProxyFromEnvironment()
+	}
+	if opts.Proxy != nil && opts.Proxy.Password == "" {
+		if password, err := a.vault.Get(vault.SecretProxyPassword); err == nil {
+			proxy := *opts.Proxy
+			proxy.Password = password
+			opts.Proxy = &proxy
+		}
+	}
+	return opts
+}
+
+// GetServerDirectory lists the servers to browse, with what each reported
+// when last probed
+func (a *App) GetServerDirectory() []mssp.Info {
+	return a.servers.Results()
+}
+
+// BrowseServers probes every listed server over MSSP for its name,
+// codebase, players online and uptime
+func (a *App) BrowseServers() []mssp.Info {
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return a.servers.Browse(ctx)
+}
+
+// AddDirectoryServer adds a server to the directory
+func (a *App) AddDirectoryServer(entry mssp.Entry) error {
+	if err := a.servers.Add(entry); err != nil {
+		return i18n.Wrap(err, "error.server_directory")
+	}
+	return nil
+}
+
+// RemoveDirectoryServer removes a server the user added to the directory
+func (a *App) RemoveDirectoryServer(host, port string) error {
+	if err := a.servers.Remove(host, port); err != nil {
+		return i18n.Wrap(err, "error.server_directory")
+	}
+	return nil
+}
+
+// GetIncludeBundledServers reports whether the servers shipped with SeeMUD
+// are listed alongside the user's
+func (a *App) GetIncludeBundledServers() bool {
+	return a.servers.IncludeBundled()
+}
+
+// SetIncludeBundledServers shows or hides the servers shipped with SeeMUD
+func (a *App) SetIncludeBundledServers(include bool) error {
+	if err := a.servers.SetIncludeBundled(include); err != nil {
+		return i18n.Wrap(err, "error.server_directory")
+	}
+	return nil
+}
+
+// ConnectToListedServer connects to a server from the directory, first
+// saving a profile for it, named after what it reported, so the
+// command-line clients can use it too
+func (a *App) ConnectToListedServer(host, port string) error {
+	path := a.dataDir.Join(profile.FileName)
+	cfg, err := profile.Load(path)
+	if err != nil {
+		logger.Warn("failed to load profiles", "error", err)
+	} else if _, exists := cfg.Find(host, port); !exists {
+		title := host
+		for _, info := range a.servers.Results() {
+			if info.Host == host && info.Port == port && info.Name != "" {
+				title = info.Name
+			}
+		}
+		name := cfg.Add(title, profile.Profile{Host: host, Port: port})
+		if err := cfg.Save(path); err != nil {
+			logger.Warn("failed to save profile", "error", err)
+		} else {
+			logger.Info("saved profile for listed server", "profile", name, "host", host, "port", port)
+		}
+	}
+	return a.ConnectToMUD(host, port)
+}
+
+// GetProfiles returns the saved connection profiles, and which is the
+// default
+func (a *App) GetProfiles() (*profile.Config, error) {
+	cfg, err := profile.Load(a.dataDir.Join(profile.FileName))
+	if err != nil {
+		return nil, i18n.Wrap(err, "error.profiles")
+	}
+	return cfg, nil
+}
+
+// SaveProfile creates a profile, or replaces the one with that name
+func (a *App) SaveProfile(name string, server profile.Profile) error {
+	if server.Style != "" {
+		if _, exists := a.engine.Styles.Get(server.Style); !exists {
+			return i18n.Wrap(fmt.Errorf("unknown style %q", server.Style), "error.profiles")
+		}
+	}
+	return a.updateProfiles(func(cfg *profile.Config) error {
+		return cfg.Set(name, server)
+	})
+}
+
+// DeleteProfile removes a profile
+func (a *App) DeleteProfile(name string) error {
+	return a.updateProfiles(func(cfg *profile.Config) error {
+		return cfg.Delete(name)
+	})
+}
+
+// SetDefaultProfile chooses the profile the command-line clients use when
+// none is named; empty goes back to localhost:4001
+func (a *App) SetDefaultProfile(name string) error {
+	return a.updateProfiles(func(cfg *profile.Config) error {
+		if _, exists := cfg.Profiles[name]; name != "" && !exists {
+			return fmt.Errorf("unknown profile %q", name)
+		}
+		cfg.Default = name
+		return nil
+	})
+}
+
+// updateProfiles loads the profile file, changes it and saves it again
+func (a *App) updateProfiles(change func(*profile.Config) error) error {
+	path := a.dataDir.Join(profile.FileName)
+	cfg, err := profile.Load(path)
+	if err == nil {
+		err = change(cfg)
+	}
+	if err == nil {
+		err = cfg.Save(path)
+	}
+	if err != nil {
+		return i18n.Wrap(err, "error.profiles")
+	}
+	a.emitEvent("profiles:changed")
+	return nil
+}
+
+// ReconnectToMUD resumes a link-dead session, keeping the map position,
+// scrollback and inventory and replaying the previous login
+func (a *App) ReconnectToMUD() error {
+	return a.engine.Resume()
+}
+
+// DisconnectFromMUD disconnects from the MUD server
+func (a *App) DisconnectFromMUD() error {
+	if err := a.engine.Disconnect(); err != nil {
+		return err
+	}
+	if a.syncer.Enabled() {
+		go a.syncInBackground()
+	}
+	return nil
+}
+
+// SendCommand sends a command to the MUD
+func (a *App) SendCommand(command string) error {
+	if !a.engine.Session.IsConnected() {
+		return i18n.Error("error.not_connected")
+	}
+
+	a.idleMonitor.UserInput()
+	a.engine.RememberCommand(command)
+
+	return a.engine.Input(command)
+}
+
+// Complete returns words seen this session that complete the last word of
+// input, e.g. "get rust" offers "rusty". The frontend replaces the last word.
+func (a *App) Complete(input string) []string {
+	return a.engine.Completions.Complete(input)
+}
+
+// GetOutput returns raw lines received since the last call. Kept for
+// compatibility; new consumers should track their own cursor with
+// GetOutputSince.
+//
+// Deprecated: the cursor is shared, so two callers each miss the lines the
+// other took. Listen for output:frame events instead, which carry every
+// line with its sequence number, and backfill with GetLinesSince.
+func (a *App) GetOutput() []string {
+	a.outputMux.Lock()
+	defer a.outputMux.Unlock()
+
+	batch := a.engine.Output.Since(a.outputCursor)
+	a.outputCursor = batch.LastSeq
+
+	result := make([]string, len(batch.Entries))
+	for i, entry := range batch.Entries {
+		result[i] = entry.Line
+	}
+	return result
+}
+
+// GetParsedOutput returns typed events for output received since the last
+// call. It is independent of GetOutput.
+func (a *App) GetParsedOutput() []output.Event {
+	a.outputMux.Lock()
+	defer a.outputMux.Unlock()
+
+	batch := a.engine.Output.Since(a.parsedCursor)
+	a.parsedCursor = batch.LastSeq
+
+	result := make([]output.Event, len(batch.Entries))
+	for i, entry := range batch.Entries {
+		result[i] = entry.Event
+	}
+	return result
+}
+
+// GetOutputSince returns buffered output with a sequence number greater than
+// seq. Each consumer keeps its own cursor (the returned LastSeq), and passing
+// 0 backfills the whole scrollback.
+func (a *App) GetOutputSince(seq int64) output.Batch {
+	return a.engine.Output.Since(seq)
+}
+
+// GetLinesSince returns buffered output after seq as a frame of structured
+// lines, as output:frame events carry them, for backfilling scrollback.
+// The frame's LastSeq is the cursor to pass next time.
+func (a *App) GetLinesSince(seq int64) output.Frame {
+	return output.FrameOf(a.engine.Output.Since(seq))
+}
+
+// GetConnectionStatus returns whether we're connected to MUD
+func (a *App) GetConnectionStatus() bool {
+	return a.engine.Session.IsConnected()
+}
+
+// ExportTranscript renders scrollback between two sequence numbers
+// (inclusive, toSeq 0 for the latest) as "text", "html" or "markdown"
+func (a *App) ExportTranscript(fromSeq, toSeq int64, format string) (string, error) {
+	transcriptFormat, err := transcript.ParseFormat(format)
+	if err != nil {
+		return "", err
+	}
+	return transcript.Render(a.engine.Output.Range(fromSeq, toSeq), transcriptFormat), nil
+}
+
+// CopyTranscript copies a range of scrollback to the clipboard, for the
+// GUI's copy-selection action
+func (a *App) CopyTranscript(fromSeq, toSeq int64, format string) error {
+	text, err := a.ExportTranscript(fromSeq, toSeq, format)
+	if err != nil {
+		return err
+	}
+	if a.ctx == nil {
+		return i18n.Error("error.clipboard_unavailable")
+	}
+	return runtime.ClipboardSetText(a.ctx, text)
+}
+
+// SaveTranscript asks the user for a file and writes the transcript to it.
+// It returns the chosen path, or an empty string if the dialog was cancelled.
+func (a *App) SaveTranscript(fromSeq, toSeq int64, format string) (string, error) {
+	transcriptFormat, err := transcript.ParseFormat(format)
+	if err != nil {
+		return "", err
+	}
+	if a.ctx == nil {
+		return "", i18n.Error("error.dialog_unavailable")
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export transcript",
+		DefaultFilename: "seemud-transcript" + transcriptFormat.Extension(),
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	text := transcript.Render(a.engine.Output.Range(fromSeq, toSeq), transcriptFormat)
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		return "", i18n.Wrap(err, "error.write_transcript")
+	}
+	return path, nil
+}
+
+// GetConnectionState returns the connection state machine's current state
+func (a *App) GetConnectionState() string {
+	return string(a.engine.State())
+}
+
+// handleLine feeds each line of output to the App's own subsystems
+func (a *App) handleLine(entry output.Entry, parsed *parser.ParsedOutput) {
+	// Chat goes to its own buffers so the GUI can show a chat pane
+	if msg, isChat := a.chatCapture.Route(parsed); isChat {
+		a.emitEvent("chat:message", msg)
+	}
+
+	if a.inventory.ProcessLine(parsed.CleanText) {
+		a.emitEvent("inventory:changed", a.inventory.Snapshot())
+	}
+
+	if cameOnline, changed := a.friends.ProcessLine(parsed.CleanText); changed {
+		for _, name := range cameOnline {
+			a.engine.Events.Publish(events.New(events.KindFriendOnline, name+" is online", map[string]string{"name": name}))
+		}
+		a.emitEvent("friends:changed", a.friends.List())
+	}
+
+	if a.cooldowns.ProcessLine(parsed.CleanText) {
+		a.emitEvent("cooldowns:changed", a.cooldowns.Timers())
+	}
+	if reading, changed := a.vitals.ProcessLine(parsed.CleanText, parsed.Type == parser.TypePrompt); changed {
+		a.emitEvent("vitals:changed", reading)
+	}
+
+	a.narrator.Handle(parsed)
+}
+
+// GetVitals returns the character's latest health, mana and stamina from
+// the prompt, or nil before the first prompt is read
+func (a *App) GetVitals() *vitals.Vitals {
+	reading, ok := a.vitals.Current()
+	if !ok {
+		return nil
+	}
+	return &reading
+}
+
+// GetVitalsHistory returns the readings from the last few minutes, oldest
+// first, for graphs. 0 minutes returns all that are kept.
+func (a *App) GetVitalsHistory(minutes int) []vitals.Vitals {
+	var since time.Time
+	if minutes > 0 {
+		since = time.Now().Add(-time.Duration(minutes) * time.Minute)
+	}
+	return a.vitals.History(since)
+}
+
+// GetVitalsSettings returns how the current server's prompt is read
+func (a *App) GetVitalsSettings() vitals.Settings {
+	return a.vitals.Settings()
+}
+
+// SetVitalsSettings changes how the current server's prompt is read
+func (a *App) SetVitalsSettings(settings vitals.Settings) error {
+	if err := a.vitals.SetSettings(settings); err != nil {
+		return i18n.Wrap(err, "error.vitals")
+	}
+	return nil
+}
+
+// GetChatChannels returns the names of the chat buffers that have messages
+func (a *App) GetChatChannels() []string {
+	return a.chatCapture.Channels()
+}
+
+// GetChatMessages returns messages newer than sinceSeq from a chat buffer.
+// An empty channel returns all chat. Buffers, "say" and "tell" included,
+// are made by their first message, so until then they return none.
+func (a *App) GetChatMessages(channel string, sinceSeq int64) []chat.Message {
+	return a.chatCapture.Since(channel, sinceSeq)
+}
+
+// GetInventory returns what the character is carrying and wearing
+func (a *App) GetInventory() inventory.Snapshot {
+	return a.inventory.Snapshot()
+}
+
+// SetInventoryCapacity sets the carrying capacity used for the weight bar
+func (a *App) SetInventoryCapacity(capacity float64) {
+	a.inventory.SetCapacity(capacity)
+	a.emitEvent("inventory:changed", a.inventory.Snapshot())
+}
+
+// SetItemWeight records an item's weight for MUDs that don't report it
+func (a *App) SetItemWeight(name string, weight float64) {
+	a.inventory.SetItemWeight(name, weight)
+	a.emitEvent("inventory:changed", a.inventory.Snapshot())
+}
+
+// GetCooldownTimers returns running skill and spell timers for countdown badges
+func (a *App) GetCooldownTimers() []cooldown.Timer {
+	return a.cooldowns.Timers()
+}
+
+// GetCooldowns returns the cooldown definitions for the current server
+func (a *App) GetCooldowns() []cooldown.Definition {
+	return a.cooldowns.Definitions()
+}
+
+// SetCooldown adds or replaces a cooldown definition for the current server
+func (a *App) SetCooldown(definition cooldown.Definition) error {
+	return a.cooldowns.SetDefinition(definition)
+}
+
+// DeleteCooldown removes a cooldown definition
+func (a *App) DeleteCooldown(name string) error {
+	return a.cooldowns.DeleteDefinition(name)
+}
+
+// GetFriends returns the friends list with online presence
+func (a *App) GetFriends() []friends.Friend {
+	return a.friends.List()
+}
+
+// AddFriend marks a player as a friend
+func (a *App) AddFriend(name string) error {
+	if err := a.friends.Add(name); err != nil {
+		return err
+	}
+	a.emitEvent("friends:changed", a.friends.List())
+	return nil
+}
+
+// RemoveFriend unmarks a friend
+func (a *App) RemoveFriend(name string) error {
+	if err := a.friends.Remove(name); err != nil {
+		return err
+	}
+	a.emitEvent("friends:changed", a.friends.List())
+	return nil
+}
+
+// GetFriendSettings returns how friend presence is polled
+func (a *App) GetFriendSettings() friends.Settings {
+	return a.friends.Settings()
+}
+
+// SetFriendSettings changes how friend presence is polled
+func (a *App) SetFriendSettings(settings friends.Settings) {
+	a.friends.SetSettings(settings)
+}
+
+// GetSpeechSettings returns the text-to-speech narration settings
+func (a *App) GetSpeechSettings() speech.Settings {
+	return a.narrator.Settings()
+}
+
+// SetSpeechSettings updates narration settings (enabled, verbosity, voice,
+// rate, summaries and spoken templates)
+func (a *App) SetSpeechSettings(settings speech.Settings) {
+	a.narrator.SetSettings(settings)
+}
+
+// SpeakText narrates arbitrary text, e.g. for a "read this line" action
+func (a *App) SpeakText(text string) {
+	a.narrator.Say(text)
+}
+
+// GetSpeechTemplates returns what each event says unless overridden
+func (a *App) GetSpeechTemplates() map[string]string {
+	return speech.DefaultTemplates()
+}
+
+// StopSpeech interrupts narration and discards anything queued
+func (a *App) StopSpeech() {
+	a.narrator.Stop()
+}
+
+// GetSoundSettings returns the sound engine configuration
+func (a *App) GetSoundSettings() sound.Settings {
+	return a.sounds.Settings()
+}
+
+// SetSoundSettings replaces the sound engine configuration
+func (a *App) SetSoundSettings(settings sound.Settings) {
+	a.sounds.SetSettings(settings)
+}
+
+// SetSoundCue sets the audio file, volume and mute state for an event kind
+// such as "tell", "combat_start", "death" or "level_up"
+func (a *App) SetSoundCue(kind string, cue sound.Cue) {
+	a.sounds.SetCue(events.Kind(kind), cue)
+}
+
+// SetSoundsMuted mutes or unmutes all sound effects
+func (a *App) SetSoundsMuted(muted bool) {
+	a.sounds.SetMuted(muted)
+}
+
+// PreviewSoundCue plays the cue for an event kind so the user can test it
+func (a *App) PreviewSoundCue(kind string) {
+	a.sounds.Preview(events.Kind(kind))
+}
+
+// SetWindowFocused is called by the frontend on window focus and blur, so
+// notifications are only shown when the user isn't looking
+func (a *App) SetWindowFocused(focused bool) {
+	a.notifications.SetFocused(focused)
+}
+
+// GetNotificationSettings returns the desktop notification settings
+func (a *App) GetNotificationSettings() notify.Settings {
+	return a.notifications.Settings()
+}
+
+// SetNotificationSettings replaces the desktop notification settings
+func (a *App) SetNotificationSettings(settings notify.Settings) {
+	a.notifications.SetSettings(settings)
+}
+
+// GetHighlights returns the watch-list of words and patterns picked out of
+// the output
+func (a *App) GetHighlights() []highlight.Watch {
+	return a.engine.Highlights.List()
+}
+
+// SetHighlight adds a watch, or replaces the one with the same pattern
+func (a *App) SetHighlight(watch highlight.Watch) error {
+	if err := a.engine.Highlights.Set(watch); err != nil {
+		return i18n.Wrap(err, "error.highlights")
+	}
+	return nil
+}
+
+// DeleteHighlight removes the watch with a pattern
+func (a *App) DeleteHighlight(pattern string) error {
+	if err := a.engine.Highlights.Delete(pattern); err != nil {
+		return i18n.Wrap(err, "error.highlights")
+	}
+	return nil
+}
+
+// handleHighlight tells the frontend a watch matched, and the desktop too
+// if the watch asks; the notification shows even with the window in the
+// background, as that's when it matters
+func (a *App) handleHighlight(watch highlight.Watch, text string) {
+	a.emitEvent("highlight:matched", map[string]string{"pattern": watch.Pattern, "text": text})
+	if watch.Notify {
+		a.notifications.SendThrottled("highlight:"+watch.Pattern, i18n.T("notify.highlight", "pattern", watch.Pattern), text)
+	}
+}
+
+// GetCombatSummary returns the current or last fight, with damage per
+// opponent, and the session's kills and time in combat
+func (a *App) GetCombatSummary() combat.Summary {
+	return a.engine.Combat.Summary()
+}
+
+// GetTick returns where the server's tick is, for the countdown
+func (a *App) GetTick() tick.Status {
+	return a.engine.Ticks.Status()
+}
+
+// SyncTick marks a tick as happening now, for when the player saw one the
+// tick pattern missed
+func (a *App) SyncTick() {
+	a.engine.Ticks.Sync()
+}
+
+// GetTickSettings returns the current server's tick length and message
+func (a *App) GetTickSettings() tick.Settings {
+	return a.engine.Ticks.Settings()
+}
+
+// SetTickSettings changes the current server's tick length and message
+func (a *App) SetTickSettings(settings tick.Settings) error {
+	if err := a.engine.Ticks.SetSettings(settings); err != nil {
+		return i18n.Wrap(err, "error.ticks")
+	}
+	return nil
+}
+
+// GetIdleSettings returns the keepalive, AFK reply and automation pause settings
+func (a *App) GetIdleSettings() idle.Settings {
+	return a.idleMonitor.Settings()
+}
+
+// SetIdleSettings replaces the idle monitor settings
+func (a *App) SetIdleSettings(settings idle.Settings) {
+	a.idleMonitor.SetSettings(settings)
+}
+
+// GetIdleStatus returns how long the player has been idle and whether they
+// are AFK or automations are paused
+func (a *App) GetIdleStatus() idle.Status {
+	return a.idleMonitor.Status()
+}
+
+// GenerateRoomImage generates an image for the current room (uses cache if
+// available), returning the URL to show it from. Generations wait their
+// turn in the image queue; one cancelled while waiting returns "".
+func (a *App) GenerateRoomImage() (string, error) {
+	return a.roomImage(imagequeue.PriorityUser)
+}
+
+// AutoGenerateRoomImage is GenerateRoomImage for images drawn on arriving
+// in a room, which wait behind ones the player asks for and are cancelled
+// on moving on
+func (a *App) AutoGenerateRoomImage() (string, error) {
+	return a.roomImage(imagequeue.PriorityAuto)
+}
+
+// roomImage returns the current room's cached image, or queues a new one
+func (a *App) roomImage(priority imagequeue.Priority) (string, error) {
+	currentRoom, ok := a.engine.Rooms.Current()
+	if !ok {
+		return "", i18n.Error("error.no_room")
+	}
+
+	// Check cache first
+	if url, exists := a.imageURL(currentRoom); exists {
+		logger.Debug("returning cached image", "room", currentRoom.Name)
+		a.checkStale(currentRoom)
+		return url, nil
+	}
+
+	// No cached image, generate new one
+	return a.generateImage(currentRoom, "", priority)
+}
+
+// RegenerateRoomImage draws the current room's image again: a variation of
+// the cached one at the variation strength, or a new one if that's 0 or
+// nothing is cached
+func (a *App) RegenerateRoomImage() (string, error) {
+	currentRoom, ok := a.engine.Rooms.Current()
+	if !ok {
+		return "", i18n.Error("error.no_room")
+	}
+	return a.regenerateImage(currentRoom, "")
+}
+
+// RegenerateRoomImageWithPrompt regenerates with custom user prompt additions
+func (a *App) RegenerateRoomImageWithPrompt(customPrompt string) (string, error) {
+	currentRoom, ok := a.engine.Rooms.Current()
+	if !ok {
+		return "", i18n.Error("error.no_room")
+	}
+	return a.regenerateImage(currentRoom, customPrompt)
+}
+
+// RegenerateRoomImageWithSeed draws the current room's image again from the
+// cached image's seed, with custom prompt additions, so tweaks keep its
+// style. A room without an image gets a random seed.
+func (a *App) RegenerateRoomImageWithSeed(customPrompt string) (string, error) {
+	currentRoom, ok := a.engine.Rooms.Current()
+	if !ok {
+		return "", i18n.Error("error.no_room")
+	}
+	return a.drawImage(currentRoom, imagequeue.PriorityUser, func(ctx context.Context) (string, error) {
+		return a.engine.Images.Redraw(ctx, currentRoom, customPrompt)
+	})
+}
+
+// StyleSettings are the image style presets and which is chosen where
+type StyleSettings struct {
+	Presets   []renderer.StylePreset `json:"presets"`
+	Session   string                 `json:"session"`              // Chosen for this session
+	Zone      string                 `json:"zone"`                 // The zone the player is in, empty if the server doesn't say
+	ZoneStyle string                 `json:"zone_style,omitempty"` // Chosen for that zone
+	Active    string                 `json:"active"`               // What rooms here are drawn in
+}
+
+// GetStyles returns the image style presets, which is chosen for the
+// session and the current zone, and which rooms here are drawn in
+func (a *App) GetStyles() StyleSettings {
+	zone := a.engine.Zone()
+	return StyleSettings{
+		Presets:   a.engine.Styles.List(),
+		Session:   a.engine.Styles.Session(),
+		Zone:      zone,
+		ZoneStyle: a.engine.Styles.Zones()[strings.ToLower(zone)],
+		Active:    a.engine.Style().Name,
+	}
+}
+
+// SetSessionStyle chooses the style rooms are drawn in until the app is
+// closed, where their zone hasn't one of its own; empty goes back to the
+// default. Images drawn in another style are offered for regeneration.
+func (a *App) SetSessionStyle(name string) error {
+	if err := a.engine.Styles.SetSession(name); err != nil {
+		return err
+	}
+	if room, ok := a.engine.Rooms.Current(); ok {
+		a.checkStale(room)
+	}
+	return nil
+}
+
+// SetZoneStyle chooses the style rooms in the current zone are always drawn
+// in; empty goes back to the session's
+func (a *App) SetZoneStyle(name string) error {
+	zone := a.engine.Zone()
+	if zone == "" {
+		return i18n.Error("error.no_zone")
+	}
+	if err := a.engine.Styles.SetZone(zone, name); err != nil {
+		return err
+	}
+	if room, ok := a.engine.Rooms.Current(); ok {
+		a.checkStale(room)
+	}
+	return nil
+}
+
+// SaveStyle adds or replaces a style preset of the player's own
+func (a *App) SaveStyle(preset renderer.StylePreset) error {
+	return a.engine.Styles.Save(preset)
+}
+
+// DeleteStyle removes one of the player's style presets
+func (a *App) DeleteStyle(name string) error {
+	return a.engine.Styles.Delete(name)
+}
+
+// GetVariationStrength returns how far regenerating strays from the cached
+// image, from 0 for a new composition to 1
+func (a *App) GetVariationStrength() float64 {
+	a.variationMux.RLock()
+	defer a.variationMux.RUnlock()
+	return a.variation
+}
+
+// SetVariationStrength sets how far regenerating strays from the cached
+// image: lower keeps more of it, 0 draws a new composition every time
+func (a *App) SetVariationStrength(strength float64) {
+	strength = min(max(strength, 0), 1)
+	a.variationMux.Lock()
+	a.variation = strength
+	a.variationMux.Unlock()
+}
+
+// regenerateImage replaces a room's image, as a variation of the cached one
+// unless variations are off
+func (a *App) regenerateImage(room engine.Room, customPrompt string) (string, error) {
+	if strength := a.GetVariationStrength(); strength > 0 {
+		return a.drawImage(room, imagequeue.PriorityUser, func(ctx context.Context) (string, error) {
+			return a.engine.Images.Vary(ctx, room, customPrompt, strength)
+		})
+	}
+	return a.generateImage(room, customPrompt, imagequeue.PriorityUser)
+}
+
+// generateImage draws a new image for a room
+func (a *App) generateImage(room engine.Room, customPrompt string, priority imagequeue.Priority) (string, error) {
+	return a.drawImage(room, priority, func(ctx context.Context) (string, error) {
+		return a.engine.Images.Generate(ctx, room, customPrompt)
+	})
+}
+
+// drawImage queues a generation and waits for it, counting it in the
+// session stats, and returns the URL to show the image from, or "" if it
+// was cancelled. Every drawn image is also announced as an image:ready
+// event, so it shows whoever asked for it.
+func (a *App) drawImage(room engine.Room, priority imagequeue.Priority, generate func(ctx context.Context) (string, error)) (string, error) {
+	ticket := a.imageQueue.Submit(room.ID(), room.Name, priority, func(ctx context.Context) (string, error) {
+		image, err := generate(ctx)
+		if err != nil {
+			return "", err
+		}
+
+		a.engine.Stats.ImageGenerated()
+		a.remote.Broadcast("image", map[string]string{"room": room.Name, "image": image})
+		url, exists := a.imageURL(room)
+		if !exists {
+			// Not cached, so it can only be sent inline
+			url = "data:image/png;base64," + image
+		}
+		a.emitEvent("image:ready", map[string]string{"room": room.Name, "room_id": room.ID(), "url": url})
+		return url, nil
+	})
+
+	url, err := ticket.Wait()
+	if errors.Is(err, imagequeue.ErrCancelled) {
+		return "", nil
+	}
+	return url, err
+}
+
+// GetImageQueue returns the image generation running, if any, then those
+// waiting. Changes arrive as image:queue events.
+func (a *App) GetImageQueue() []imagequeue.Update {
+	return a.imageQueue.Jobs()
+}
+
+// CancelImageGeneration cancels every queued and running image generation,
+// returning how many
+func (a *App) CancelImageGeneration() int {
+	return a.imageQueue.CancelAll()
+}
+
+// scriptImage generates the current room's image for a script, which the
+// frontend shows from its image:ready event as if the player had asked
+func (a *App) scriptImage(prompt string) {
+	currentRoom, ok := a.engine.Rooms.Current()
+	if !ok {
+		return
+	}
+	if _, err := a.generateImage(currentRoom, prompt, imagequeue.PriorityScript); err != nil {
+		logger.Warn("script image generation failed", "room", currentRoom.Name, "error", err)
+	}
+}
+
+// imageURL returns the URL the asset handler serves a room's cached image
+// from
+func (a *App) imageURL(room engine.Room) (string, bool) {
+	images, err := a.imageCache()
+	if err != nil {
+		return "", false
+	}
+	return images.ImageURL(room)
+}
+
+// assetHandler serves what isn't in the embedded frontend: the cached room
+// images, streamed from disk rather than passed through bindings as base64
+func (a *App) assetHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		images, err := a.imageCache()
+		if err != nil || !strings.HasPrefix(r.URL.Path, engine.ImageURLPrefix) {
+			http.NotFound(w, r)
+			return
+		}
+		images.ServeHTTP(w, r)
+	})
+}
+
+// GetSessionStats returns counters for the current (or last) play session
+func (a *App) GetSessionStats() stats.Stats {
+	return a.engine.Stats.Snapshot()
+}
+
+// GetRoomPrompt returns the prompt additions saved for the current room
+func (a *App) GetRoomPrompt() string {
+	currentRoom, ok := a.engine.Rooms.Current()
+	if !ok {
+		return ""
+	}
+	return a.engine.Mapper.RoomPrompt(currentRoom.ID())
+}
+
+// SetRoomPrompt saves prompt additions for the current room, appended to
+// every future generation of it. An empty prompt clears them.
+func (a *App) SetRoomPrompt(prompt string) error {
+	currentRoom, ok := a.engine.Rooms.Current()
+	if !ok {
+		return i18n.Error("error.no_room")
+	}
+	if err := a.engine.Mapper.SetRoomPrompt(currentRoom.ID(), prompt); err != nil {
+		return err
+	}
+
+	// Save straight away so the prompt survives a crash
+	return a.engine.SaveMap()
+}
+
+// GetCurrentRoom returns the current room information
+func (a *App) GetCurrentRoom() map[string]string {
+	currentRoom, ok := a.engine.Rooms.Current()
+	if !ok {
+		return map[string]string{}
+	}
+
+	// Only return room info if we have a valid room title
+	return map[string]string{
+		"name":        currentRoom.Name,
+		"description": currentRoom.Description,
+	}
+}
+
+// pushRoom tells the frontend when the room or what's in it has changed,
+// as room:changed and entities:changed events shaped like GetCurrentRoom
+// and GetCurrentEntities. It's checked once a frame of output is out, by
+// when a room's title, description and contents have usually all arrived.
+func (a *App) pushRoom() {
+	room, _ := a.engine.Rooms.Current()
+	entities := a.engine.Rooms.Entities()
+
+	a.roomMux.Lock()
+	roomChanged := room.Name != a.lastRoom.Name || room.Description != a.lastRoom.Description
+	entitiesChanged := !slices.Equal(entities.Items, a.lastEntities.Items) || !slices.Equal(entities.Mobs, a.lastEntities.Mobs)
+	a.lastRoom = room
+	a.lastEntities = engine.Entities{Items: slices.Clone(entities.Items), Mobs: slices.Clone(entities.Mobs)}
+	a.roomMux.Unlock()
+
+	if roomChanged && room.Name != "" {
+		a.emitEvent("room:changed", map[string]string{"name": room.Name, "description": room.Description})
+	}
+	if entitiesChanged {
+		a.emitEvent("entities:changed", map[string][]string{"items": entities.Items, "mobs": entities.Mobs})
+	}
+}
+
+// GetCurrentEntities returns items and mobs in the current room
+func (a *App) GetCurrentEntities() map[string][]string {
+	entities := a.engine.Rooms.Entities()
+
+	return map[string][]string{
+		"items": entities.Items,
+		"mobs":  entities.Mobs,
+	}
+}
+
+// GetMSDP returns what the server has reported over MSDP, such as the room
+// vnum, health and opponent. Enabled is false for servers without MSDP.
+func (a *App) GetMSDP() telnet.MSDPStatus {
+	return a.engine.Session.MSDP().Status()
+}
+
+// ReportMSDP asks the server to keep MSDP variables up to date
+func (a *App) ReportMSDP(names []string) error {
+	return a.msdpCommand(a.engine.Session.MSDP().Report, names)
+}
+
+// SendMSDP asks the server for the current value of MSDP variables
+func (a *App) SendMSDP(names []string) error {
+	return a.msdpCommand(a.engine.Session.MSDP().Send, names)
+}
+
+// msdpCommand sends an MSDP REPORT or SEND, with the app's errors
+func (a *App) msdpCommand(command func(names ...string) error, names []string) error {
+	if !a.engine.Session.IsConnected() {
+		return i18n.Error("error.not_connected")
+	}
+	if !a.engine.Session.MSDP().Enabled() {
+		return i18n.Error("error.msdp_unavailable")
+	}
+	if err := command(names...); err != nil {
+		return i18n.Wrap(err, "error.msdp_failed")
+	}
+	return nil
+}
+
+// CheckSDStatus checks if the image backend is available
+func (a *App) CheckSDStatus() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return a.engine.Images.Available(ctx)
+}
+
+// GetImageBackend returns which backend draws room images, e.g.
+// "stable-diffusion" or "comfyui"
+func (a *App) GetImageBackend() string {
+	if images, ok := a.engine.Images.(*engine.SDImageService); ok {
+		if backend := images.Backend(); backend != nil {
+			return backend.Name()
+		}
+	}
+	return ""
+}
+
+// Greet returns a greeting for the given name (keeping for now)
+func (a *App) Greet(name string) string {
+	return fmt.Sprintf("Hello %s, Welcome to SeeMUD!", name)
+}
+
+// GetRoomImage returns the URL of the current room's cached image, or an
+// empty string if none exists
+func (a *App) GetRoomImage() string {
+	currentRoom, ok := a.engine.Rooms.Current()
+	if !ok {
+		return ""
+	}
+
+	// Try to load from cache
+	if url, exists := a.imageURL(currentRoom); exists {
+		logger.Debug("returning cached image", "room", currentRoom.Name)
+		a.checkStale(currentRoom)
+		return url
+	}
+
+	return ""
+}
+
+// checkStale tells the frontend when a cached image no longer fits its room,
+// so the player is offered a new one rather than seeing outdated art
+func (a *App) checkStale(room engine.Room) {
+	images, err := a.imageCache()
+	if err != nil {
+		return
+	}
+	if record, exists := images.Record(room); exists && record.Stale(room, a.engine.Style().Name) {
+		a.emitEvent("image:stale", RoomImageInfo{Record: record, Stale: true})
+	}
+}
+
+// imageCache returns the Stable Diffusion image service, which owns the
+// image cache
+func (a *App) imageCache() (*engine.SDImageService, error) {
+	images, ok := a.engine.Images.(*engine.SDImageService)
+	if !ok {
+		return nil, i18n.Error("error.no_image_cache")
+	}
+	return images, nil
+}
+
+// GetCondenserSettings returns the settings for condensing room
+// descriptions into image prompts with a language model
+func (a *App) GetCondenserSettings() (renderer.CondenserSettings, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return renderer.CondenserSettings{}, err
+	}
+	return images.Condenser().Settings(), nil
+}
+
+// SetCondenserSettings changes the language model endpoint and model, or
+// turns condensing on or off. The API key, if one is needed, is kept in the
+// vault.
+func (a *App) SetCondenserSettings(settings renderer.CondenserSettings) error {
+	images, err := a.imageCache()
+	if err != nil {
+		return err
+	}
+	images.Condenser().SetSettings(settings)
+	return nil
+}
+
+// GetExtractorSettings returns the settings for asking a language model
+// about items and mobs the parser can't tell apart
+func (a *App) GetExtractorSettings() parser.ExtractorSettings {
+	return a.engine.Extractor.Settings()
+}
+
+// SetExtractorSettings changes the language model asked about items and
+// mobs, or turns it on or off
+func (a *App) SetExtractorSettings(settings parser.ExtractorSettings) {
+	a.engine.Extractor.SetSettings(settings)
+}
+
+// GetCacheStats returns the size of the room image cache and its limits
+func (a *App) GetCacheStats() (engine.CacheStats, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return engine.CacheStats{}, err
+	}
+	return images.Stats(), nil
+}
+
+// SetCacheLimits changes the image cache limits, evicting the least
+// recently used images if it's over them
+func (a *App) SetCacheLimits(limits engine.CacheLimits) error {
+	images, err := a.imageCache()
+	if err != nil {
+		return err
+	}
+	images.SetLimits(limits)
+	return nil
+}
+
+// ClearCache deletes every cached room image, returning how many went
+func (a *App) ClearCache() (int, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return 0, err
+	}
+	return images.Clear()
+}
+
+// GetImageEncoding returns how new room images are stored
+func (a *App) GetImageEncoding() (engine.ImageEncoding, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return engine.ImageEncoding{}, err
+	}
+	return images.Encoding(), nil
+}
+
+// SetImageEncoding stores new room images as PNG, WebP or AVIF. It fails if
+// the encoder for the format isn't installed.
+func (a *App) SetImageEncoding(encoding engine.ImageEncoding) error {
+	images, err := a.imageCache()
+	if err != nil {
+		return err
+	}
+	return images.SetEncoding(encoding)
+}
+
+// RecodeCache converts cached room images to the current encoding,
+// returning how many were converted
+func (a *App) RecodeCache() (int, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return 0, err
+	}
+	return images.Recode()
+}
+
+// ScanCache checks the image cache now rather than waiting for the
+// background scan, returning what it found and fixed
+func (a *App) ScanCache() (engine.ScanReport, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return engine.ScanReport{}, err
+	}
+	return images.Scan(), nil
+}
+
+// GetLastCacheScan returns the most recent cache scan's report, or nil if
+// the cache hasn't been scanned yet
+func (a *App) GetLastCacheScan() (*engine.ScanReport, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return nil, err
+	}
+	report, scanned := images.LastScan()
+	if !scanned {
+		return nil, nil
+	}
+	return &report, nil
+}
+
+// FindDuplicateImages groups rooms whose cached images look the same, so
+// the player can share one file or regenerate them with better prompts
+func (a *App) FindDuplicateImages() ([]engine.DuplicateGroup, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return nil, err
+	}
+	return images.FindDuplicates(engine.DuplicateThreshold), nil
+}
+
+// ShareDuplicateImage replaces one room's image with another's, keeping a
+// single file for both where possible
+func (a *App) ShareDuplicateImage(fromRoomID, toRoomID string) error {
+	images, err := a.imageCache()
+	if err != nil {
+		return err
+	}
+	return images.ShareImage(fromRoomID, toRoomID)
+}
+
+// RoomImageInfo is how the current room's cached image was made
+type RoomImageInfo struct {
+	Record engine.ImageRecord `json:"record"`
+	Stale  bool               `json:"stale"` // The room or style has changed since
+}
+
+// GetRoomImageInfo returns the index entry for the current room's cached
+// image, or nil if it hasn't been drawn
+func (a *App) GetRoomImageInfo() (*RoomImageInfo, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return nil, err
+	}
+	room, ok := a.engine.Rooms.Current()
+	if !ok {
+		return nil, i18n.Error("error.no_room")
+	}
+	record, exists := images.Record(room)
+	if !exists {
+		return nil, nil
+	}
+	return &RoomImageInfo{Record: record, Stale: record.Stale(room, a.engine.Style().Name)}, nil
+}
+
+// ExportArtPack asks the user for a file and saves the current server's map
+// with its cached room images, for sharing with players who can't generate
+// their own. It returns the chosen path, or an empty string if the dialog
+// was cancelled.
+func (a *App) ExportArtPack() (string, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return "", err
+	}
+	serverName := a.engine.ServerName()
+	if serverName == "" {
+		return "", i18n.Error("error.no_server")
+	}
+	if a.ctx == nil {
+		return "", i18n.Error("error.dialog_unavailable")
+	}
+
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export art pack",
+		DefaultFilename: serverName + artpack.Extension,
+		Filters:         []runtime.FileFilter{{DisplayName: "SeeMUD art packs", Pattern: "*" + artpack.Extension}},
+	})
+	if err != nil || path == "" {
+		return "", err
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return "", i18n.Wrap(err, "error.write_art_pack")
+	}
+	count, err := artpack.Export(file, a.engine.Mapper, serverName, images)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+		return "", i18n.Wrap(err, "error.write_art_pack")
+	}
+	logger.Info("exported art pack", "path", path, "images", count)
+	return path, nil
+}
+
+// ImportArtPackFile adds an art pack's rooms to the map of the server it
+// was made on, and its images to the cache
+func (a *App) ImportArtPackFile(path string) (*artpack.Result, error) {
+	images, err := a.imageCache()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, i18n.Wrap(err, "error.read_file")
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, i18n.Wrap(err, "error.read_file")
+	}
+	archive, manifest, data, err := artpack.Open(file, info.Size())
+	if err != nil {
+		return nil, err
+	}
+
+	// The pack's server may not be the one we're on, so its saved map and
+	// image cache are used instead
+	if manifest.ServerName == a.engine.ServerName() {
+		result := artpack.Import(archive, manifest, data, a.engine.Mapper, images)
+		return result, a.engine.SaveMap()
+	}
+	m := mapper.NewMapper()
+	m.SetDirectory(a.dataDir.Maps())
+	if err := m.LoadMap(manifest.ServerName); err != nil {
+		return nil, err
+	}
+	cache := engine.NewSDImageService(nil, m, a.dataDir.RoomImages())
+	cache.UseServer(manifest.ServerName)
+	result := artpack.Import(archive, manifest, data, m, cache)
+	return result, m.SaveMap(manifest.ServerName)
+}
+
+// ImportArtPack asks the user for an art pack and imports it. It returns
+// nil if the dialog was cancelled.
+func (a *App) ImportArtPack() (*artpack.Result, error) {
+	if a.ctx == nil {
+		return nil, i18n.Error("error.dialog_unavailable")
+	}
+
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import art pack",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "SeeMUD art packs", Pattern: "*" + artpack.Extension + ";*.zip"},
+			{DisplayName: "All files", Pattern: "*"},
+		},
+	})
+	if err != nil || path == "" {
+		return nil, err
+	}
+	return a.ImportArtPackFile(path)
+}
+
+// Mapper API methods
+
+// GetDataDir returns the directory holding caches, maps and settings
+func (a *App) GetDataDir() string {
+	return a.dataDir.Root
+}
+
+// SetDataDir changes the data directory from the next launch. An empty
+// path returns to the platform default.
+func (a *App) SetDataDir(dir string) error {
+	if dir != "" {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return i18n.Wrap(err, "error.create_data_dir")
+		}
+	}
+	return datadir.SetOverride(dir)
+}
+
+// GetSpeedwalks returns the stored speedwalk routes
+func (a *App) GetSpeedwalks() []speedwalk.Route {
+	return a.speedwalks.List()
+}
+
+// SetSpeedwalk adds or replaces a speedwalk route
+func (a *App) SetSpeedwalk(route speedwalk.Route) error {
+	return a.speedwalks.Set(route)
+}
+
+// DeleteSpeedwalk removes a speedwalk route
+func (a *App) DeleteSpeedwalk(name string) error {
+	return a.speedwalks.Delete(name)
+}
+
+// RunSpeedwalk walks a stored route through the pacing queue, replacing
+// any speedwalk already running. Progress arrives as speedwalk:progress events.
+func (a *App) RunSpeedwalk(name string) error {
+	route, exists := a.speedwalks.Get(name)
+	if !exists {
+		return i18n.Error("error.unknown_speedwalk", "name", name)
+	}
+	return a.runRoute(route)
+}
+
+// RunSpeedwalkKey walks the route bound to a hotkey. It returns false if
+// no route uses the key, so the frontend can let the key through.
+func (a *App) RunSpeedwalkKey(key string) (bool, error) {
+	route, exists := a.speedwalks.ByKey(key)
+	if !exists {
+		return false, nil
+	}
+	return true, a.runRoute(route)
+}
+
+// AbortSpeedwalk stops the running speedwalk or walk to a room
+func (a *App) AbortSpeedwalk() bool {
+	a.walkMux.Lock()
+	batch := a.walkBatch
+	a.walkMux.Unlock()
+
+	walking := a.walker.Abort()
+	if batch == 0 {
+		return walking
+	}
+	return a.engine.Queue.Cancel(batch) || walking
+}
+
+// WalkTo walks to a mapped room by the shortest known route, one step at a
+// time, stopping if a step leads anywhere the map didn't expect. Progress
+// arrives as speedwalk:progress events, like a speedwalk's.
+func (a *App) WalkTo(roomID string) error {
+	if !a.engine.Session.IsConnected() {
+		return i18n.Error("error.not_connected")
+	}
+
+	route, err := a.engine.Mapper.WalkTo(roomID)
+	if err != nil {
+		return i18n.Wrap(err, "error.no_route")
+	}
+	if len(route.Directions) == 0 {
+		return nil
+	}
+
+	a.AbortSpeedwalk()
+	a.idleMonitor.UserInput()
+
+	label := roomID
+	if room := a.engine.Mapper.GetGraph().GetRoom(roomID); room != nil {
+		label = room.Name
+	}
+	a.walker.Start(label, route, a.engine.Queue.Delay())
+	return nil
+}
+
+// runRoute expands a route and queues it
+func (a *App) runRoute(route speedwalk.Route) error {
+	if !a.engine.Session.IsConnected() {
+		return i18n.Error("error.not_connected")
+	}
+
+	commands, err := speedwalk.Expand(route.Path)
+	if err != nil {
+		return err
+	}
+
+	a.AbortSpeedwalk()
+	a.idleMonitor.UserInput()
+
+	a.walkMux.Lock()
+	a.walkBatch = a.engine.Queue.Add(route.Name, commands)
+	a.walkMux.Unlock()
+	return nil
+}
+
+// GetQueue returns the commands waiting in the send queue
+func (a *App) GetQueue() []pacing.Pending {
+	return a.engine.Queue.Pending()
+}
+
+// CancelPending drops everything waiting in the send queue, e.g. a mistyped
+// speedwalk or a runaway trigger. It returns how many commands were dropped.
+func (a *App) CancelPending() int {
+	return a.engine.Queue.CancelAll()
+}
+
+// GetQueueDelay returns the pause between queued commands in milliseconds
+func (a *App) GetQueueDelay() int {
+	return int(a.engine.Queue.Delay() / time.Millisecond)
+}
+
+// SetQueueDelay sets the pause between queued commands in milliseconds
+func (a *App) SetQueueDelay(ms int) {
+	a.engine.Queue.SetDelay(time.Duration(ms) * time.Millisecond)
+}
+
+// GetPasteDelay returns the pause between pasted lines in milliseconds
+func (a *App) GetPasteDelay() int {
+	a.pasteMux.RLock()
+	defer a.pasteMux.RUnlock()
+	return int(a.pasteDelay / time.Millisecond)
+}
+
+// SetPasteDelay sets the pause between pasted lines in milliseconds
+func (a *App) SetPasteDelay(ms int) {
+	if ms < 0 {
+		ms = 0
+	}
+	a.pasteMux.Lock()
+	a.pasteDelay = time.Duration(ms) * time.Millisecond
+	a.pasteMux.Unlock()
+}
+
+// PreviewPaste returns the commands a multi-line paste would send, so the
+// frontend can confirm before sending
+func (a *App) PreviewPaste(text string) ([]string, error) {
+	return pacing.SplitLines(text)
+}
+
+// SendPaste queues each line of a multi-line paste with the paste delay
+// between them. It returns the queue batch, which can be cancelled.
+func (a *App) SendPaste(text string) (int64, error) {
+	return a.sendLines("paste", text)
+}
+
+// SendFile queues each line of a text file, like a paste
+func (a *App) SendFile(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, i18n.Wrap(err, "error.read_file")
+	}
+	return a.sendLines("file: "+filepath.Base(path), string(data))
+}
+
+// ChooseFileToSend asks the user for a text file and sends it. It returns 0
+// if the dialog was cancelled.
+func (a *App) ChooseFileToSend() (int64, error) {
+	if a.ctx == nil {
+		return 0, i18n.Error("error.dialog_unavailable")
+	}
+
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Send file",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Text files", Pattern: "*.txt;*.md"},
+			{DisplayName: "All files", Pattern: "*"},
+		},
+	})
+	if err != nil || path == "" {
+		return 0, err
+	}
+	return a.SendFile(path)
+}
+
+// sendLines splits text into lines and queues them as one batch
+func (a *App) sendLines(label, text string) (int64, error) {
+	if !a.engine.Session.IsConnected() {
+		return 0, i18n.Error("error.not_connected")
+	}
+
+	lines, err := pacing.SplitLines(text)
+	if err != nil {
+		return 0, err
+	}
+	if len(lines) == 0 {
+		return 0, nil
+	}
+
+	a.pasteMux.RLock()
+	delay := a.pasteDelay
+	a.pasteMux.RUnlock()
+
+	a.idleMonitor.UserInput()
+	logger.Info("sending lines", "label", label, "count", len(lines), "delay", delay)
+	return a.engine.Queue.AddWithDelay(label, lines, delay), nil
 }
 
-// NewApp creates a new App application struct
-func NewApp() *App {
-	sdEndpoint := resolveSDEndpoint()
-	log.Printf("Stable Diffusion endpoint: %s", sdEndpoint)
+// handleQueueProgress forwards queue and speedwalk progress to the frontend
+func (a *App) handleQueueProgress(progress pacing.Progress) {
+	a.emitEvent("queue:changed", a.engine.Queue.Len())
 
-	// Ensure cache directory exists
-	cacheDir := filepath.Join("cache", "room_images")
-	if err := os.MkdirAll(cacheDir, 0755); err != nil {
-		log.Printf("Warning: Failed to create cache directory: %v", err)
+	a.walkMux.Lock()
+	isWalk := progress.BatchID == a.walkBatch
+	if isWalk && progress.Done {
+		a.walkBatch = 0
 	}
+	a.walkMux.Unlock()
 
-	// Load existing image cache
-	imageCache := loadImageCache(cacheDir)
-
-	return &App{
-		mudParser:      parser.NewWolfMUDParser(),
-		mudMapper:      mapper.NewMapper(),
-		sdClient:       renderer.NewStableDiffusionClient(sdEndpoint),
-		outputBuf:      make([]string, 0, 1000), // Buffer last 1000 lines
-		roomImageCache: imageCache,
+	if isWalk {
+		a.emitEvent("speedwalk:progress", progress)
 	}
 }
 
-// startup is called when the app starts. The context is saved
-// so we can call the runtime methods
-func (a *App) startup(ctx context.Context) {
-	a.ctx = ctx
+// GetTriggers returns all triggers and event hooks
+func (a *App) GetTriggers() []trigger.Trigger {
+	return a.engine.Triggers.List()
 }
 
-// ConnectToMUD connects to the WolfMUD server
-func (a *App) ConnectToMUD(host, port string) error {
-	if a.mudClient != nil && a.mudClient.IsConnected() {
-		return fmt.Errorf("already connected")
-	}
+// GetTriggerHooks returns the lifecycle hooks triggers can attach to
+func (a *App) GetTriggerHooks() []trigger.Hook {
+	return trigger.Hooks
+}
 
-	a.mudClient = telnet.NewClient(host, port)
-	err := a.mudClient.Connect()
-	if err != nil {
-		return err
-	}
+// SetTrigger adds or replaces a trigger
+func (a *App) SetTrigger(t trigger.Trigger) error {
+	return a.engine.Triggers.Set(t)
+}
 
-	a.connected = true
-	a.serverName = fmt.Sprintf("%s_%s", host, port)
+// DeleteTrigger removes a trigger
+func (a *App) DeleteTrigger(name string) error {
+	return a.engine.Triggers.Delete(name)
+}
 
-	// Load existing map for this server
-	if err := a.mudMapper.LoadMap(a.serverName); err != nil {
-		log.Printf("Warning: Failed to load map: %v", err)
-		// Continue anyway - we'll start a new map
-	}
+// SetTriggerEnabled turns a trigger on or off
+func (a *App) SetTriggerEnabled(name string, enabled bool) error {
+	return a.engine.Triggers.SetEnabled(name, enabled)
+}
 
-	// Start processing output
-	go a.processOutput()
+// GetAliases returns all aliases
+func (a *App) GetAliases() []trigger.Alias {
+	return a.engine.Aliases.List()
+}
 
-	return nil
+// SetAlias adds or replaces an alias
+func (a *App) SetAlias(alias trigger.Alias) error {
+	return a.engine.Aliases.Set(alias)
 }
 
-// DisconnectFromMUD disconnects from the MUD server
-func (a *App) DisconnectFromMUD() error {
-	if a.mudClient == nil {
-		return nil
+// AddAlias adds or replaces an alias written as at other MUD clients, such
+// as "k %1" for "kill %1", rather than as a regex
+func (a *App) AddAlias(name, commands string) (trigger.Alias, error) {
+	alias, err := trigger.SimpleAlias(name, commands)
+	if err != nil {
+		return alias, err
 	}
+	return alias, a.engine.Aliases.Set(alias)
+}
 
-	a.connected = false
+// DeleteAlias removes an alias
+func (a *App) DeleteAlias(name string) error {
+	return a.engine.Aliases.Delete(name)
+}
 
-	// Save map before disconnecting
-	if a.serverName != "" {
-		if err := a.mudMapper.SaveMap(a.serverName); err != nil {
-			log.Printf("Warning: Failed to save map: %v", err)
-		}
+// ImportTriggerFile imports the triggers and aliases from a Mudlet package
+// or TinTin++ script, reporting anything that couldn't be converted
+func (a *App) ImportTriggerFile(path string) (*trigger.ImportResult, error) {
+	result, err := trigger.ImportFile(path)
+	if err != nil {
+		return nil, err
 	}
-
-	return a.mudClient.Disconnect()
+	result.Apply(a.engine.Triggers, a.engine.Aliases)
+	logger.Info("imported triggers", "source", result.Source, "triggers", len(result.Triggers),
+		"aliases", len(result.Aliases), "skipped", len(result.Skipped))
+	return result, nil
 }
 
-// SendCommand sends a command to the MUD
-func (a *App) SendCommand(command string) error {
-	if a.mudClient == nil || !a.mudClient.IsConnected() {
-		return fmt.Errorf("not connected to MUD")
+// ImportTriggerPackage asks the user for a Mudlet package or TinTin++ script
+// and imports it. It returns nil if the dialog was cancelled.
+func (a *App) ImportTriggerPackage() (*trigger.ImportResult, error) {
+	if a.ctx == nil {
+		return nil, i18n.Error("error.dialog_unavailable")
 	}
 
-	// Check if this is a movement command and notify mapper
-	if isMovement, direction := mapper.IsMovementCommand(command); isMovement {
-		a.mudMapper.OnMovement(direction)
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import triggers",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Mudlet packages and TinTin++ scripts", Pattern: "*.xml;*.mpackage;*.zip;*.tin;*.tt;*.txt"},
+			{DisplayName: "All files", Pattern: "*"},
+		},
+	})
+	if err != nil || path == "" {
+		return nil, err
 	}
-
-	return a.mudClient.SendCommand(command)
+	return a.ImportTriggerFile(path)
 }
 
-// GetOutput returns new output since last call and clears the buffer
-func (a *App) GetOutput() []string {
-	a.outputMux.Lock()
-	defer a.outputMux.Unlock()
-
-	if len(a.outputBuf) == 0 {
-		return []string{}
+// GetTimeline returns the rooms visited between two times, as Unix
+// milliseconds; 0 leaves that end open
+func (a *App) GetTimeline(fromMs, toMs int64) []timeline.Visit {
+	var from, to time.Time
+	if fromMs > 0 {
+		from = time.UnixMilli(fromMs)
+	}
+	if toMs > 0 {
+		to = time.UnixMilli(toMs)
 	}
+	return a.engine.Timeline.Range(from, to)
+}
 
-	// Return current buffer and clear it
-	result := make([]string, len(a.outputBuf))
-	copy(result, a.outputBuf)
-	a.outputBuf = a.outputBuf[:0] // Clear the buffer
+// GetLocationAt returns the room the player was in at a time in Unix
+// milliseconds, or nil if the timeline doesn't go back that far
+func (a *App) GetLocationAt(ms int64) *timeline.Visit {
+	visit, ok := a.engine.Timeline.At(time.UnixMilli(ms))
+	if !ok {
+		return nil
+	}
+	return &visit
+}
 
-	return result
+// GetLocale returns the locale used for the client's own messages
+func (a *App) GetLocale() string {
+	return i18n.Default().Locale()
 }
 
-// GetConnectionStatus returns whether we're connected to MUD
-func (a *App) GetConnectionStatus() bool {
-	return a.connected && a.mudClient != nil && a.mudClient.IsConnected()
+// GetLocales returns the locales with translations
+func (a *App) GetLocales() []string {
+	return i18n.Default().Locales()
 }
 
-// processOutput handles incoming MUD output
-func (a *App) processOutput() {
-	if a.mudClient == nil {
-		return
+// SetLocale changes the language of the client's own messages. Text from
+// the MUD isn't translated.
+func (a *App) SetLocale(locale string) error {
+	if err := i18n.Default().SetLocale(locale); err != nil {
+		return err
 	}
+	a.emitEvent("locale:changed", i18n.Default().Messages())
+	return nil
+}
 
-	outputChan := a.mudClient.GetOutput()
-	for {
-		select {
-		case <-a.ctx.Done():
-			return
-		case line, ok := <-outputChan:
-			if !ok {
-				a.connected = false
-				return
-			}
-
-			// Parse the line
-			parsed := a.mudParser.ParseLine(line)
+// GetMessages returns the current locale's messages for the frontend
+func (a *App) GetMessages() map[string]string {
+	return i18n.Default().Messages()
+}
 
-			// Add to output buffer
-			a.outputMux.Lock()
-			a.outputBuf = append(a.outputBuf, line)
+// GetScripts returns the loaded Lua scripts and any errors they've had
+func (a *App) GetScripts() []scripting.Info {
+	return a.scripts.List()
+}
 
-			// Keep buffer size manageable
-			if len(a.outputBuf) > 1000 {
-				a.outputBuf = a.outputBuf[1:]
-			}
-			a.outputMux.Unlock()
-
-			// Log parsed content for debugging
-			log.Printf("Parsed: Type=%d, Content=%s", parsed.Type, parsed.CleanText)
-
-			// Trigger image generation for room content
-			if parsed.Type == parser.TypeRoomTitle {
-				a.roomMux.Lock()
-				a.currentRoom = parsed
-				a.roomMux.Unlock()
-
-				// Clear entities when entering new room
-				a.entityMux.Lock()
-				a.currentItems = []string{}
-				a.currentMobs = []string{}
-				a.entityMux.Unlock()
-
-				log.Printf("Room title detected: %s", parsed.RoomName)
-
-				// Notify mapper of room entry (will be updated with description and exits later)
-			} else if parsed.Type == parser.TypeRoomDescription {
-				a.roomMux.Lock()
-				if a.currentRoom != nil && a.currentRoom.Type == parser.TypeRoomTitle {
-					// Only add description if we have a valid room title
-					a.currentRoom.Content += " " + parsed.Content
-					log.Printf("Room description added: %s", parsed.Content)
-				}
-				a.roomMux.Unlock()
-			} else if parsed.Type == parser.TypeExits && len(parsed.Exits) > 0 {
-				// When we get exits, we have enough info to notify mapper
-				a.roomMux.RLock()
-				if a.currentRoom != nil && a.currentRoom.RoomName != "" {
-					roomName := a.currentRoom.RoomName
-					roomDesc := a.currentRoom.Content
-					exits := parsed.Exits
-					a.roomMux.RUnlock()
-
-					// Notify mapper in background to not block
-					go func() {
-						a.mudMapper.OnRoomEntered(roomName, roomDesc, exits)
-					}()
-				} else {
-					a.roomMux.RUnlock()
-				}
-			} else if parsed.Type == parser.TypeInventory && len(parsed.Items) > 0 {
-				// Add items to current room inventory
-				a.entityMux.Lock()
-				a.currentItems = append(a.currentItems, parsed.Items...)
-				a.entityMux.Unlock()
-				log.Printf("Items detected: %v", parsed.Items)
-			} else if parsed.Type == parser.TypeMobs && len(parsed.Mobs) > 0 {
-				// Add mobs to current room
-				a.entityMux.Lock()
-				a.currentMobs = append(a.currentMobs, parsed.Mobs...)
-				a.entityMux.Unlock()
-				log.Printf("Mobs detected: %v", parsed.Mobs)
-			}
-		}
-	}
+// ReloadScripts stops the Lua scripts and loads them again from the data
+// directory, picking up edits
+func (a *App) ReloadScripts() ([]scripting.Info, error) {
+	return a.scripts.Load()
 }
 
-// GenerateRoomImage generates an image for the current room (uses cache if available)
-func (a *App) GenerateRoomImage() (string, error) {
-	a.roomMux.RLock()
-	currentRoom := a.currentRoom
-	a.roomMux.RUnlock()
+// GetParserDialects returns the names of the families of servers whose
+// output SeeMUD can parse
+func (a *App) GetParserDialects() []string {
+	return parser.DialectNames()
+}
 
-	if currentRoom == nil || currentRoom.RoomName == "" {
-		return "", fmt.Errorf("no room data available")
-	}
+// GetParserDefinitions returns the parsers defined in JSON, bundled and from
+// the data directory, as examples for writing another
+func (a *App) GetParserDefinitions() []parser.Definition {
+	return parser.Definitions()
+}
 
-	// Check cache first
-	if base64Image, exists := a.loadImageFromCache(currentRoom.RoomName); exists {
-		log.Printf("Returning cached image for room: %s", currentRoom.RoomName)
-		return base64Image, nil
-	}
+// ValidateParserDefinition checks a parser definition before it's saved to
+// the data directory, returning what's wrong with it
+func (a *App) ValidateParserDefinition(text string) []string {
+	return parser.ValidateDefinition([]byte(text))
+}
 
-	// No cached image, generate new one
-	return a.generateNewRoomImage(currentRoom, "")
+// GetMovementDialects returns the names of the built-in movement dialects
+func (a *App) GetMovementDialects() []string {
+	return mapper.DialectNames()
 }
 
-// RegenerateRoomImage forces generation of a new image for the current room
-func (a *App) RegenerateRoomImage() (string, error) {
-	a.roomMux.RLock()
-	currentRoom := a.currentRoom
-	a.roomMux.RUnlock()
+// GetMovementDialect returns the current movement dialect
+func (a *App) GetMovementDialect() mapper.Dialect {
+	a.dialectMux.RLock()
+	defer a.dialectMux.RUnlock()
+	return a.dialect
+}
 
-	if currentRoom == nil || currentRoom.RoomName == "" {
-		return "", fmt.Errorf("no room data available")
+// SetMovementDialect chooses which movement commands the server expects
+func (a *App) SetMovementDialect(name string) error {
+	dialect, err := mapper.LookupDialect(name)
+	if err != nil {
+		return err
 	}
 
-	// Always generate new image, ignoring cache
-	return a.generateNewRoomImage(currentRoom, "")
+	a.dialectMux.Lock()
+	a.dialect = dialect
+	a.dialectMux.Unlock()
+	return nil
 }
 
-// RegenerateRoomImageWithPrompt regenerates with custom user prompt additions
-func (a *App) RegenerateRoomImageWithPrompt(customPrompt string) (string, error) {
-	a.roomMux.RLock()
-	currentRoom := a.currentRoom
-	a.roomMux.RUnlock()
+// GetMovementCommand returns the server command for a movement intent
+// ("north", "ne" or a numpad digit)
+func (a *App) GetMovementCommand(intent string) (string, error) {
+	return a.GetMovementDialect().Command(intent)
+}
 
-	if currentRoom == nil || currentRoom.RoomName == "" {
-		return "", fmt.Errorf("no room data available")
+// Move sends the command for a movement intent, as if the player typed it
+func (a *App) Move(intent string) error {
+	command, err := a.GetMovementCommand(intent)
+	if err != nil {
+		return err
 	}
+	return a.SendCommand(command)
+}
 
-	// Always generate new image with custom prompt, ignoring cache
-	return a.generateNewRoomImage(currentRoom, customPrompt)
+// RemoteStatus describes the mirroring bridge for the settings panel
+type RemoteStatus struct {
+	Running bool `json:"running"`
+	Clients int  `json:"clients"`
 }
 
-// generateNewRoomImage is a helper that actually generates a new image
-func (a *App) generateNewRoomImage(currentRoom *parser.ParsedOutput, customPrompt string) (string, error) {
-	// Check if SD is available
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
+// GetRemoteSettings returns the WebSocket mirroring settings
+func (a *App) GetRemoteSettings() remote.Settings {
+	return a.remote.Settings()
+}
 
-	if err := a.sdClient.CheckHealth(ctx); err != nil {
-		return "", fmt.Errorf("Stable Diffusion not available: %w", err)
+// SetRemoteSettings applies WebSocket mirroring settings, starting or
+// stopping the bridge. The returned settings include any generated token.
+func (a *App) SetRemoteSettings(settings remote.Settings) (remote.Settings, error) {
+	applied, err := a.remote.Apply(settings)
+	if err != nil {
+		return applied, i18n.Wrap(err, "error.remote")
 	}
+	return applied, nil
+}
+
+// GetSyncSettings returns the map and image sync settings
+func (a *App) GetSyncSettings() cloudsync.Settings {
+	return a.syncer.Settings()
+}
 
-	// Get neighbour context from mapper
-	neighbours := a.mudMapper.GetNeighbours()
-	neighbourMap := make(map[string]map[string]string)
+// SetSyncSettings changes where maps and images are synced to
+func (a *App) SetSyncSettings(settings cloudsync.Settings) error {
+	return a.syncer.Apply(settings)
+}
 
-	if neighbours != nil {
-		for direction, room := range neighbours {
-			neighbourMap[direction] = map[string]string{
-				"name":        room.Name,
-				"description": room.Description,
-			}
-		}
+// SyncNow pulls the current server's map and images from the sync server,
+// merges them in and pushes the result back
+func (a *App) SyncNow() (*cloudsync.Result, error) {
+	if !a.syncer.Enabled() {
+		return nil, i18n.Error("error.sync_disabled")
 	}
-
-	// Generate new image with neighbour context
-	log.Printf("Generating new image for room: %s (with %d neighbours)", currentRoom.RoomName, len(neighbourMap))
-	var prompt string
-	if customPrompt != "" {
-		log.Printf("Using custom prompt additions: %s", customPrompt)
-		prompt = renderer.RoomImagePromptWithNeighboursAndCustom(currentRoom.RoomName, currentRoom.Content, neighbourMap, customPrompt)
-	} else if len(neighbourMap) > 0 {
-		prompt = renderer.RoomImagePromptWithNeighbours(currentRoom.RoomName, currentRoom.Content, neighbourMap)
-	} else {
-		prompt = renderer.RoomImagePrompt(currentRoom.RoomName, currentRoom.Content)
+	serverName := a.engine.ServerName()
+	if serverName == "" {
+		return nil, i18n.Error("error.no_server")
 	}
-	req := &renderer.Txt2ImgRequest{
-		Prompt:         prompt,
-		NegativePrompt: renderer.GetNegativePrompt(),
-		Width:          512,
-		Height:         512,
-		Steps:          20,
-		CFGScale:       7.0,
+	images, err := a.imageCache()
+	if err != nil {
+		return nil, err
 	}
 
-	ctx, cancel = context.WithTimeout(context.Background(), 120*time.Second)
-	defer cancel()
-
-	resp, err := a.sdClient.GenerateImage(ctx, req)
+	result, err := a.syncer.Sync(serverName, a.engine.Mapper, images)
 	if err != nil {
-		return "", fmt.Errorf("failed to generate image: %w", err)
+		return nil, i18n.Wrap(err, "error.sync_failed")
 	}
+	if err := a.engine.SaveMap(); err != nil {
+		logger.Warn("failed to save map", "error", err)
+	}
+	return result, nil
+}
 
-	if len(resp.Images) == 0 {
-		return "", fmt.Errorf("no images generated")
+// GetLastSync returns the result of the most recent sync, or nil if there
+// hasn't been one
+func (a *App) GetLastSync() *cloudsync.Result {
+	result, ok := a.syncer.Last()
+	if !ok {
+		return nil
 	}
+	return &result
+}
 
-	base64Image := resp.Images[0]
+// syncInBackground syncs after connecting or disconnecting, so the map
+// follows the player between machines without them asking
+func (a *App) syncInBackground() {
+	result, err := a.SyncNow()
+	if err != nil {
+		logger.Warn("background sync failed", "error", err)
+		a.emitEvent("sync:failed", err.Error())
+		return
+	}
+	a.emitEvent("sync:finished", result)
+}
 
-	// Save to cache (overwrites existing)
-	if err := a.saveImageToCache(currentRoom.RoomName, base64Image); err != nil {
-		log.Printf("Warning: Failed to save image to cache: %v", err)
-		// Don't fail the operation, just warn
+// GetRemoteStatus reports whether the bridge is running and who is connected
+func (a *App) GetRemoteStatus() RemoteStatus {
+	return RemoteStatus{
+		Running: a.remote.Running(),
+		Clients: a.remote.Clients(),
 	}
+}
 
-	// Return base64 encoded image
-	return base64Image, nil
+// PartyStatus describes the party for the settings panel
+type PartyStatus struct {
+	Connected bool           `json:"connected"`
+	Members   []party.Member `json:"members"`
 }
 
-// GetCurrentRoom returns the current room information
-func (a *App) GetCurrentRoom() map[string]string {
-	a.roomMux.RLock()
-	defer a.roomMux.RUnlock()
+// GetPartySettings returns the party map sync settings
+func (a *App) GetPartySettings() party.Settings {
+	return a.party.Settings()
+}
 
-	if a.currentRoom == nil || a.currentRoom.Type != parser.TypeRoomTitle {
-		return map[string]string{}
+// SetPartySettings joins, hosts or leaves a party. The settings are returned
+// with any random party name filled in, to pass on to the others.
+func (a *App) SetPartySettings(settings party.Settings) (party.Settings, error) {
+	applied, err := a.party.Apply(settings)
+	if err != nil {
+		return applied, i18n.Wrap(err, "error.party")
 	}
+	return applied, nil
+}
 
-	// Only return room info if we have a valid room title
-	return map[string]string{
-		"name":        a.currentRoom.RoomName,
-		"description": a.currentRoom.Content,
+// GetPartyStatus reports whether this client is in a party and where the
+// others are
+func (a *App) GetPartyStatus() PartyStatus {
+	return PartyStatus{
+		Connected: a.party.Connected(),
+		Members:   a.party.Members(),
 	}
 }
 
-// GetCurrentEntities returns items and mobs in the current room
-func (a *App) GetCurrentEntities() map[string][]string {
-	a.entityMux.RLock()
-	defer a.entityMux.RUnlock()
+// sharePartyRoom tells the party where the player is, along with the room so
+// anyone who hasn't mapped it can add it
+func (a *App) sharePartyRoom(roomID string) {
+	if !a.party.Connected() {
+		return
+	}
+	room, exits, ok := a.engine.Mapper.SharedRoom(roomID)
+	if !ok {
+		return
+	}
+	serverName := a.engine.ServerName()
+	a.party.SendRooms(serverName, party.Rooms{Rooms: []*mapper.Room{room}, Exits: exits})
+	a.party.SendPosition(serverName, room.ID, room.Name)
+}
 
-	return map[string][]string{
-		"items": a.currentItems,
-		"mobs":  a.currentMobs,
+// sharePartyMap sends the whole map to the party, when someone joins
+func (a *App) sharePartyMap() {
+	serverName := a.engine.ServerName()
+	if serverName == "" {
+		return
+	}
+	raw, err := a.engine.Mapper.MarshalMap(serverName)
+	if err != nil {
+		logger.Warn("failed to share map", "error", err)
+		return
+	}
+	var data mapper.MapData
+	if err := json.Unmarshal(raw, &data); err != nil || data.Graph == nil {
+		return
+	}
+
+	rooms := party.Rooms{Exits: data.Graph.Exits}
+	for _, room := range data.Graph.Rooms {
+		// Images and notes are the player's own
+		room.ImagePath, room.Notes, room.PromptAdditions = "", "", ""
+		rooms.Rooms = append(rooms.Rooms, room)
+	}
+	a.party.SendRooms(serverName, rooms)
+	if data.CurrentRoomID != "" {
+		a.sharePartyRoom(data.CurrentRoomID)
 	}
 }
 
-// CheckSDStatus checks if Stable Diffusion is available
-func (a *App) CheckSDStatus() bool {
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
+// handlePartyRooms adds rooms another party member mapped on this server
+func (a *App) handlePartyRooms(serverName string, rooms party.Rooms) {
+	if serverName == "" || serverName != a.engine.ServerName() {
+		return
+	}
+	if added := a.engine.Mapper.AddSharedRooms(rooms.Rooms, rooms.Exits); len(added) > 0 {
+		a.emitEvent("map:updated", "")
+	}
+}
 
-	return a.sdClient.CheckHealth(ctx) == nil
+// GetMetricsSettings returns the Prometheus endpoint settings
+func (a *App) GetMetricsSettings() metrics.Settings {
+	return a.metrics.Settings()
 }
 
-// Greet returns a greeting for the given name (keeping for now)
-func (a *App) Greet(name string) string {
-	return fmt.Sprintf("Hello %s, Welcome to SeeMUD!", name)
+// SetMetricsSettings applies Prometheus endpoint settings, starting or
+// stopping the /metrics listener
+func (a *App) SetMetricsSettings(settings metrics.Settings) (metrics.Settings, error) {
+	return a.metrics.Apply(settings)
 }
 
-// Helper functions for image caching
+// GetVaultStatus reports whether the secret vault exists and is unlocked,
+// and the names (never values) of the secrets in it
+func (a *App) GetVaultStatus() vault.Status {
+	return a.vault.Status()
+}
 
-// sanitizeRoomName converts a room name to a safe filename
-func sanitizeRoomName(roomName string) string {
-	// Remove or replace characters that aren't safe for filenames
-	reg := regexp.MustCompile(`[^a-zA-Z0-9_\-]`)
-	sanitized := reg.ReplaceAllString(strings.ToLower(roomName), "_")
-	// Remove multiple underscores
-	reg = regexp.MustCompile(`_+`)
-	sanitized = reg.ReplaceAllString(sanitized, "_")
-	// Trim underscores from ends
-	sanitized = strings.Trim(sanitized, "_")
+// UnlockVault opens the vault with a passphrase, creating it on first use
+func (a *App) UnlockVault(passphrase string) error {
+	if err := a.vault.Unlock(passphrase); err != nil {
+		return err
+	}
+	a.applySecrets()
+	return nil
+}
 
-	if sanitized == "" {
-		sanitized = "unknown_room"
+// UnlockVaultWithKeychain opens the vault with a key kept in the OS
+// keychain, creating it on first use
+func (a *App) UnlockVaultWithKeychain() error {
+	if err := a.vault.UnlockWithKeychain(); err != nil {
+		return err
 	}
+	a.applySecrets()
+	return nil
+}
 
-	return sanitized
+// LockVault forgets the decrypted secrets until the vault is unlocked again
+func (a *App) LockVault() {
+	a.vault.Lock()
+	a.applySecrets()
 }
 
-// loadImageCache scans the cache directory and builds the cache map
-func loadImageCache(cacheDir string) map[string]string {
-	cache := make(map[string]string)
+// ChangeVaultPassphrase re-encrypts the unlocked vault under a new
+// passphrase
+func (a *App) ChangeVaultPassphrase(passphrase string) error {
+	return a.vault.ChangePassphrase(passphrase)
+}
 
-	entries, err := os.ReadDir(cacheDir)
-	if err != nil {
-		log.Printf("Could not read cache directory: %v", err)
-		return cache
+// SetSecret stores a secret in the unlocked vault
+func (a *App) SetSecret(name, value string) error {
+	if err := a.vault.Set(name, value); err != nil {
+		return err
 	}
+	a.applySecrets()
+	return nil
+}
 
-	for _, entry := range entries {
-		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".png") {
-			// Store the full path in the cache
-			cache[entry.Name()] = filepath.Join(cacheDir, entry.Name())
-			log.Printf("Loaded cached image: %s", entry.Name())
-		}
+// DeleteSecret removes a secret from the unlocked vault
+func (a *App) DeleteSecret(name string) error {
+	if err := a.vault.Delete(name); err != nil {
+		return err
 	}
-
-	return cache
+	a.applySecrets()
+	return nil
 }
 
-// saveImageToCache saves a base64 image to the cache directory
-func (a *App) saveImageToCache(roomName string, base64Image string) error {
-	sanitized := sanitizeRoomName(roomName)
-	filename := sanitized + ".png"
-	filepath := filepath.Join("cache", "room_images", filename)
+// SetMUDPassword stores a character's password for the connected server
+func (a *App) SetMUDPassword(character, password string) error {
+	serverName := a.engine.ServerName()
+	if serverName == "" {
+		return i18n.Error("error.no_server")
+	}
+	return a.vault.Set(vault.MUDPassword(serverName, character), password)
+}
 
-	// Decode base64 image
-	imageData, err := base64.StdEncoding.DecodeString(base64Image)
+// SendMUDPassword sends a character's stored password at a login prompt,
+// so it never passes through the frontend
+func (a *App) SendMUDPassword(character string) error {
+	serverName := a.engine.ServerName()
+	if serverName == "" {
+		return i18n.Error("error.no_server")
+	}
+	password, err := a.vault.Get(vault.MUDPassword(serverName, character))
 	if err != nil {
-		return fmt.Errorf("failed to decode base64 image: %w", err)
+		return err
 	}
+	return a.SendCommand(password)
+}
 
-	// Write to file
-	if err := os.WriteFile(filepath, imageData, 0644); err != nil {
-		return fmt.Errorf("failed to save image to cache: %w", err)
+// applySecrets hands vault secrets to the backends that use them, clearing
+// them while the vault is locked
+func (a *App) applySecrets() {
+	sdAuth, _ := a.vault.Get(vault.SecretSDAuth)
+	llmKey, _ := a.vault.Get(vault.SecretLLMKey)
+	if images, ok := a.engine.Images.(*engine.SDImageService); ok {
+		images.SetAuth(sdAuth)
+		images.Condenser().SetAPIKey(llmKey)
 	}
+	a.engine.Extractor.SetAPIKey(llmKey)
+}
+
+// GetRecentLogs returns up to limit of the latest log records at or above
+// minLevel, for the debug console
+func (a *App) GetRecentLogs(limit int, minLevel string) ([]logging.Record, error) {
+	return logging.Recent(limit, minLevel)
+}
+
+// GetLogLevel returns the minimum level being logged
+func (a *App) GetLogLevel() string {
+	return logging.Level()
+}
 
-	// Update cache map
-	a.imageCacheMux.Lock()
-	a.roomImageCache[filename] = filepath
-	a.imageCacheMux.Unlock()
+// GetLogLevels returns the level names SetLogLevel accepts
+func (a *App) GetLogLevels() []string {
+	return logging.Levels
+}
 
-	log.Printf("Saved image to cache: %s", filepath)
+// SetLogLevel changes the minimum level logged, e.g. "debug" to see every
+// parsed line
+func (a *App) SetLogLevel(level string) error {
+	if err := logging.SetLevel(level); err != nil {
+		return err
+	}
+	logger.Info("log level changed", "level", logging.Level())
 	return nil
 }
 
-// loadImageFromCache loads an image from cache if it exists
-func (a *App) loadImageFromCache(roomName string) (string, bool) {
-	sanitized := sanitizeRoomName(roomName)
-	filename := sanitized + ".png"
+// GetLayeredMap returns the map as levels, zones, rooms and exits, for
+// drawing multi-level areas in 3D
+func (a *App) GetLayeredMap() (*mapexport.Layered, error) {
+	serverName := a.engine.ServerName()
+	raw, err := a.engine.Mapper.MarshalMap(serverName)
+	if err != nil {
+		return nil, i18n.Wrap(err, "error.read_map")
+	}
+	var data mapper.MapData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, i18n.Wrap(err, "error.read_map")
+	}
+	return mapexport.BuildLayered(&data), nil
+}
 
-	a.imageCacheMux.RLock()
-	filepath, exists := a.roomImageCache[filename]
-	a.imageCacheMux.RUnlock()
+// ExportMudletMap asks the user for a file and saves the current map in
+// Mudlet's JSON map format. It returns the chosen path, or an empty string
+// if the dialog was cancelled.
+func (a *App) ExportMudletMap() (string, error) {
+	serverName := a.engine.ServerName()
+	if serverName == "" {
+		return "", i18n.Error("error.no_server")
+	}
+	if a.ctx == nil {
+		return "", i18n.Error("error.dialog_unavailable")
+	}
 
-	if !exists {
-		return "", false
+	path, err := runtime.SaveFileDialog(a.ctx, runtime.SaveDialogOptions{
+		Title:           "Export Mudlet map",
+		DefaultFilename: serverName + ".json",
+		Filters:         []runtime.FileFilter{{DisplayName: "Mudlet JSON maps", Pattern: "*.json"}},
+	})
+	if err != nil || path == "" {
+		return "", err
 	}
 
-	// Read the file
-	imageData, err := os.ReadFile(filepath)
+	raw, err := a.engine.Mapper.MarshalMap(serverName)
 	if err != nil {
-		log.Printf("Failed to read cached image %s: %v", filepath, err)
-		// Remove from cache if file doesn't exist
-		a.imageCacheMux.Lock()
-		delete(a.roomImageCache, filename)
-		a.imageCacheMux.Unlock()
-		return "", false
+		return "", i18n.Wrap(err, "error.read_map")
 	}
-
-	// Encode to base64
-	base64Image := base64.StdEncoding.EncodeToString(imageData)
-	return base64Image, true
+	var data mapper.MapData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", i18n.Wrap(err, "error.read_map")
+	}
+	var out bytes.Buffer
+	if err := mapexport.Write(&out, &data, mapexport.FormatMudlet); err != nil {
+		return "", i18n.Wrap(err, "error.write_map")
+	}
+	if err := os.WriteFile(path, out.Bytes(), 0644); err != nil {
+		return "", i18n.Wrap(err, "error.write_map")
+	}
+	logger.Info("exported Mudlet map", "path", path, "rooms", data.Graph.GetRoomCount())
+	return path, nil
 }
 
-// GetRoomImage returns a cached image for the current room or empty string if none exists
-func (a *App) GetRoomImage() string {
-	a.roomMux.RLock()
-	currentRoom := a.currentRoom
-	a.roomMux.RUnlock()
+// ImportMudletMapFile adds the rooms of a map in Mudlet's JSON format to
+// the current server's map, returning how many were new
+func (a *App) ImportMudletMapFile(path string) (int, error) {
+	if a.engine.ServerName() == "" {
+		return 0, i18n.Error("error.no_server")
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, i18n.Wrap(err, "error.read_file")
+	}
+	defer file.Close()
+	data, err := mapexport.ReadMudlet(file)
+	if err != nil {
+		return 0, i18n.Wrap(err, "error.read_map")
+	}
 
-	if currentRoom == nil || currentRoom.RoomName == "" {
-		return ""
+	added := a.engine.Mapper.MergeMap(data)
+	if err := a.engine.SaveMap(); err != nil {
+		return added, err
 	}
+	logger.Info("imported Mudlet map", "path", path, "rooms", added)
+	a.emitEvent("map:loaded", a.engine.ServerName())
+	return added, nil
+}
 
-	// Try to load from cache
-	if base64Image, exists := a.loadImageFromCache(currentRoom.RoomName); exists {
-		log.Printf("Returning cached image for room: %s", currentRoom.RoomName)
-		return base64Image
+// ImportMudletMap asks the user for a Mudlet JSON map and imports it. It
+// returns 0 if the dialog was cancelled.
+func (a *App) ImportMudletMap() (int, error) {
+	if a.ctx == nil {
+		return 0, i18n.Error("error.dialog_unavailable")
 	}
 
-	return ""
+	path, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import Mudlet map",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Mudlet JSON maps", Pattern: "*.json"},
+			{DisplayName: "All files", Pattern: "*"},
+		},
+	})
+	if err != nil || path == "" {
+		return 0, err
+	}
+	return a.ImportMudletMapFile(path)
 }
 
-// Mapper API methods
-
 // GetMapData returns the current map data for frontend visualisation
 func (a *App) GetMapData() map[string]interface{} {
-	graph := a.mudMapper.GetGraph()
-	currentRoom := a.mudMapper.GetCurrentRoom()
+	graph := a.engine.Mapper.GetGraph()
+	currentRoom := a.engine.Mapper.GetCurrentRoom()
 
 	// Convert rooms to simplified format for frontend
 	rooms := make([]map[string]interface{}, 0, len(graph.Rooms))
@@ -549,6 +2633,15 @@ func (a *App) GetMapData() map[string]interface{} {
 		currentRoomID = currentRoom.ID
 	}
 
+	// Party members exploring the same server are shown on the map
+	serverName := a.engine.ServerName()
+	members := make([]map[string]string, 0)
+	for _, member := range a.party.Members() {
+		if member.Server == serverName && member.RoomID != "" {
+			members = append(members, map[string]string{"name": member.Name, "room_id": member.RoomID})
+		}
+	}
+
 	return map[string]interface{}{
 		"rooms":           rooms,
 		"current_room_id": currentRoomID,
@@ -561,18 +2654,152 @@ func (a *App) GetMapData() map[string]interface{} {
 			"max_z": maxZ,
 		},
 		"total_rooms": len(rooms),
+		"party":       members,
+		"fingerprint": a.engine.Mapper.Fingerprint(),
 	}
 }
 
+// GetMapSVG draws a level of the live map as SVG, with the current room
+// highlighted
+func (a *App) GetMapSVG(options mapper.SVGOptions) string {
+	return a.engine.Mapper.SVG(options)
+}
+
 // GetMapStats returns statistics about the mapper
 func (a *App) GetMapStats() map[string]interface{} {
-	return a.mudMapper.GetMapStats()
+	return a.engine.Mapper.GetMapStats()
+}
+
+// stateDB returns the state database, if it's turned on
+func (a *App) stateDB() (*statedb.DB, error) {
+	if a.engine.DB == nil {
+		return nil, i18n.Error("error.no_state_db")
+	}
+	return a.engine.DB, nil
+}
+
+// GetNotedRoomsWithoutImages lists rooms on the current map that have notes
+// but no image yet. The map is saved first so the answer is current.
+func (a *App) GetNotedRoomsWithoutImages() ([]statedb.RoomSummary, error) {
+	db, err := a.stateDB()
+	if err != nil {
+		return nil, err
+	}
+	if err := a.engine.SaveMap(); err != nil {
+		return nil, err
+	}
+	return db.NotedRoomsWithoutImages(a.engine.ServerName())
+}
+
+// GetRecentCommands returns up to limit commands typed on the current
+// server, newest first and each only once, for the up arrow to step
+// through; 0 returns them all
+func (a *App) GetRecentCommands(limit int) []string {
+	return a.engine.History.Recent(limit)
+}
+
+// SearchCommands returns up to limit commands typed on the current server
+// that start with prefix, newest first and each only once, for Ctrl-R
+func (a *App) SearchCommands(prefix string, limit int) []string {
+	return a.engine.History.Search(prefix, limit)
+}
+
+// GetSessionCommands returns the commands typed since connecting, oldest
+// first
+func (a *App) GetSessionCommands() []history.Entry {
+	return a.engine.History.Session()
+}
+
+// ClearCommandHistory forgets the commands typed on the current server
+func (a *App) ClearCommandHistory() error {
+	if err := a.engine.History.Clear(); err != nil {
+		return i18n.Wrap(err, "error.history")
+	}
+	return nil
+}
+
+// GetScheduledCommands returns the commands waiting to be sent by #delay,
+// #repeat or a script, soonest first
+func (a *App) GetScheduledCommands() []schedule.Job {
+	return a.engine.Schedule.Jobs()
+}
+
+// CancelScheduledCommand stops a waiting command, returning false if it
+// had already been sent
+func (a *App) CancelScheduledCommand(id int64) bool {
+	return a.engine.Schedule.Cancel(id)
+}
+
+// CancelAllScheduledCommands stops every waiting command and returns how
+// many there were
+func (a *App) CancelAllScheduledCommands() int {
+	return a.engine.Schedule.CancelAll()
+}
+
+// GetCommandHistory returns the last commands sent to the current server,
+// across sessions, from the state database
+func (a *App) GetCommandHistory(limit int) ([]statedb.HistoryEntry, error) {
+	db, err := a.stateDB()
+	if err != nil {
+		return nil, err
+	}
+	return db.History(a.engine.ServerName(), limit)
+}
+
+// GetSessionHistory returns the stats of past sessions on the current
+// server, newest first
+func (a *App) GetSessionHistory(limit int) ([]stats.Stats, error) {
+	db, err := a.stateDB()
+	if err != nil {
+		return nil, err
+	}
+	return db.Sessions(a.engine.ServerName(), limit)
+}
+
+// GetMinimap returns the rooms within radius steps of the current room
+func (a *App) GetMinimap(radius int) *mapper.Minimap {
+	return a.engine.Mapper.GetMinimap(radius)
+}
+
+// GetPathTo returns the directions to walk to a room, given its ID or name
+func (a *App) GetPathTo(target string) ([]string, error) {
+	return a.engine.Mapper.PathTo(target)
+}
+
+// SaveMap saves the current server's map to disk
+func (a *App) SaveMap() error {
+	if err := a.engine.SaveMap(); err != nil {
+		return err
+	}
+
+	a.emitEvent("map:saved", a.engine.ServerName())
+	return nil
+}
+
+// LoadMap reloads the current server's map from disk, discarding unsaved changes
+func (a *App) LoadMap() error {
+	if err := a.engine.LoadMap(); err != nil {
+		return err
+	}
+
+	a.emitEvent("map:loaded", a.engine.ServerName())
+	return nil
+}
+
+// SetMapFingerprint changes how the current map identifies rooms, by name
+// and description or by name and exits, migrating the rooms already mapped.
+// It returns how many rooms the map has afterwards.
+func (a *App) SetMapFingerprint(fingerprint string) (int, error) {
+	rooms, err := a.engine.MigrateFingerprint(fingerprint)
+	if err != nil {
+		return 0, err
+	}
+
+	a.emitEvent("map:loaded", a.engine.ServerName())
+	return rooms, nil
 }
 
 // SaveMapNow manually triggers map save
 func (a *App) SaveMapNow() error {
-	if a.serverName == "" {
-		return fmt.Errorf("no server connected")
-	}
-	return a.mudMapper.SaveMap(a.serverName)
+	return a.SaveMap()
 }
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/trigger"
+)
+
+// definitionsFile is the TinTin++-style script play loads at startup, in
+// the data directory
+const definitionsFile = "play.tin"
+
+// loadDefinitions adds the triggers and aliases in a TinTin++ script or
+// Mudlet package, replacing any with the same names so loading the same
+// file again changes nothing
+func loadDefinitions(mud *engine.Engine, path string) (*trigger.ImportResult, error) {
+	result, err := trigger.ImportFile(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range result.Triggers {
+		if err := mud.Triggers.Set(t); err != nil {
+			return nil, fmt.Errorf("failed to save trigger %q: %w", t.Name, err)
+		}
+	}
+	for _, a := range result.Aliases {
+		if err := mud.Aliases.Set(a); err != nil {
+			return nil, fmt.Errorf("failed to save alias %q: %w", a.Name, err)
+		}
+	}
+	return result, nil
+}
+
+// automationCommand handles /alias, /unalias, /trigger and /untrigger at
+// the prompt, returning false for anything else. Definitions use TinTin++
+// syntax: /alias {name} {commands} and /trigger {pattern} {commands}, with
+// %1.. for arguments and captures; braces are optional around single words.
+func automationCommand(out io.Writer, mud *engine.Engine, command string) bool {
+	verb, args, _ := strings.Cut(command, " ")
+	args = strings.TrimSpace(args)
+
+	switch verb {
+	case "/alias":
+		if args == "" {
+			listAliases(out, mud.Aliases.List())
+			return true
+		}
+		result := trigger.ImportTinTin("#alias " + braced(args))
+		if len(result.Aliases) == 0 {
+			reportSkipped(out, "alias", result)
+			return true
+		}
+		for _, a := range result.Aliases {
+			if err := mud.Aliases.Set(a); err != nil {
+				fmt.Fprintf(out, "⚠️  %v\n", err)
+				continue
+			}
+			fmt.Fprintf(out, "✓ Alias %s → %s\n", a.Name, a.Commands)
+		}
+	case "/unalias":
+		if err := mud.Aliases.Delete(args); err != nil {
+			fmt.Fprintf(out, "⚠️  %v\n", err)
+		} else {
+			fmt.Fprintf(out, "✓ Removed alias %s\n", args)
+		}
+	case "/trigger":
+		if args == "" {
+			listTriggers(out, mud.Triggers.List())
+			return true
+		}
+		result := trigger.ImportTinTin("#action " + braced(args))
+		if len(result.Triggers) == 0 {
+			reportSkipped(out, "trigger", result)
+			return true
+		}
+		for _, t := range result.Triggers {
+			if err := mud.Triggers.Set(t); err != nil {
+				fmt.Fprintf(out, "⚠️  %v\n", err)
+				continue
+			}
+			fmt.Fprintf(out, "✓ Trigger on /%s/ → %s\n", t.Pattern, t.Commands)
+		}
+	case "/untrigger":
+		if err := mud.Triggers.Delete(args); err != nil {
+			fmt.Fprintf(out, "⚠️  %v\n", err)
+		} else {
+			fmt.Fprintf(out, "✓ Removed trigger %s\n", args)
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+// braced wraps "name rest of line" as "{name} {rest of line}" unless the
+// user already used braces
+func braced(args string) string {
+	if strings.HasPrefix(args, "{") {
+		return args
+	}
+	first, rest, _ := strings.Cut(args, " ")
+	return "{" + first + "} {" + strings.TrimSpace(rest) + "}"
+}
+
+// reportSkipped explains why a definition couldn't be converted
+func reportSkipped(out io.Writer, kind string, result *trigger.ImportResult) {
+	for _, skipped := range result.Skipped {
+		fmt.Fprintf(out, "⚠️  Couldn't add %s: %s\n", kind, skipped.Reason)
+		return
+	}
+	fmt.Fprintf(out, "⚠️  Couldn't add %s: use {name} {commands}\n", kind)
+}
+
+// listAliases prints the aliases
+func listAliases(out io.Writer, aliases []trigger.Alias) {
+	if len(aliases) == 0 {
+		fmt.Fprintln(out, "No aliases. Add one with /alias {name} {commands}")
+		return
+	}
+	for _, a := range aliases {
+		fmt.Fprintf(out, "%s %-20s %s\n", enabledMark(a.Enabled), a.Name, a.Commands)
+	}
+}
+
+// listTriggers prints the triggers
+func listTriggers(out io.Writer, triggers []trigger.Trigger) {
+	if len(triggers) == 0 {
+		fmt.Fprintln(out, "No triggers. Add one with /trigger {pattern} {commands}")
+		return
+	}
+	for _, t := range triggers {
+		match := "/" + t.Pattern + "/"
+		if t.Hook != "" {
+			match = "on " + string(t.Hook)
+		}
+		fmt.Fprintf(out, "%s %-20s %-30s %s\n", enabledMark(t.Enabled), t.Name, match, t.Commands)
+	}
+}
+
+// enabledMark shows whether a trigger or alias is on
+func enabledMark(enabled bool) string {
+	if enabled {
+		return "●"
+	}
+	return "○"
+}
+
+// fileExists reports whether path is an existing file
+func fileExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && !info.IsDir()
+}
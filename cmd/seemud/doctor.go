@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/i18n"
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/profile"
+	"seemud-gui/internal/renderer"
+	"seemud-gui/internal/termimage"
+	"seemud-gui/internal/termtheme"
+)
+
+// checkTimeout bounds each network check
+const checkTimeout = 3 * time.Second
+
+// newDoctorCommand creates "seemud doctor"
+func newDoctorCommand() *cobra.Command {
+	var (
+		connection *profile.Flags
+		backend    *imageBackendFlags
+	)
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the data directory, server, map and image backend",
+		Long: `Check everything SeeMUD depends on and report what's wrong. Exits 1 if any
+check fails; informational lines don't count.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			failed := false
+			report := func(ok bool, name, detail string) {
+				mark := "✓"
+				if !ok {
+					mark = "✗"
+					failed = true
+				}
+				fmt.Printf("%s %-18s %s\n", mark, name, detail)
+			}
+			info := func(name, detail string) {
+				fmt.Printf("· %-18s %s\n", name, detail)
+			}
+
+			dir := datadir.Resolve()
+			if err := checkWritable(dir.Root); err != nil {
+				report(false, "Data directory", err.Error())
+			} else {
+				report(true, "Data directory", dir.Root)
+			}
+
+			if err := parser.LoadDir(dir.Join("parsers")); err != nil {
+				report(false, "Parsers", err.Error())
+			} else {
+				report(true, "Parsers", strings.Join(parser.DialectNames(), ", "))
+			}
+
+			server, err := connection.Resolve()
+			if err != nil {
+				report(false, "Profile", err.Error())
+				return exitError(1)
+			}
+			report(true, "Profile", fmt.Sprintf("%s, %s parser", server.Address(), server.Dialect))
+
+			conn, err := net.DialTimeout("tcp", net.JoinHostPort(server.Host, server.Port), checkTimeout)
+			if err != nil {
+				report(false, "MUD server", err.Error())
+			} else {
+				conn.Close()
+				report(true, "MUD server", "accepting connections")
+			}
+
+			if !server.MappingEnabled() {
+				info("Map", "mapping is off for this profile")
+			} else if m, err := loadSavedMap(server.ServerName()); err != nil {
+				report(false, "Map", err.Error())
+			} else {
+				info("Map", fmt.Sprintf("%d rooms saved", m.RoomCount()))
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+			defer cancel()
+			settings := backend.settings(server)
+			if drawer, err := renderer.NewBackend(settings); err != nil {
+				report(false, "Image backend", err.Error())
+			} else if err := drawer.CheckHealth(ctx); err != nil {
+				// Images are optional, so this is worth knowing but isn't a failure
+				info("Image backend", drawer.Name()+" not reachable at "+settings.Endpoint()+"; rooms won't have images")
+			} else {
+				report(true, "Image backend", drawer.Name()+" at "+settings.Endpoint())
+			}
+
+			info("Terminal images", string(termimage.Detect()))
+			info("Terminal colours", termtheme.DetectDepth().String())
+			info("Image encoders", imageEncoders())
+			info("Locale", i18n.Detect())
+
+			if failed {
+				return exitError(1)
+			}
+			return nil
+		},
+	}
+
+	backend = registerImageBackendFlags(cmd.Flags())
+	connection = profile.RegisterFlags(cmd.Flags())
+	return cmd
+}
+
+// checkWritable makes sure files can be created in dir
+func checkWritable(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("can't create %s: %w", dir, err)
+	}
+	f, err := os.CreateTemp(dir, ".doctor-*")
+	if err != nil {
+		return fmt.Errorf("can't write to %s: %w", dir, err)
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// imageEncoders lists the cache formats whose encoders are installed
+func imageEncoders() string {
+	formats := []string{string(engine.FormatPNG)}
+	for _, format := range []engine.ImageFormat{engine.FormatWebP, engine.FormatAVIF} {
+		if ok, _ := engine.EncoderAvailable(format); ok {
+			formats = append(formats, string(format))
+		}
+	}
+	return strings.Join(formats, ", ")
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/parser"
+)
+
+// exitError ends the program with a status code, the reason having already
+// been reported
+type exitError int
+
+func (e exitError) Error() string {
+	return fmt.Sprintf("exit status %d", int(e))
+}
+
+// SeeMUD's command-line tools: terminal play, map and image utilities and
+// diagnostics, in one binary
+func main() {
+	root := &cobra.Command{
+		Use:           "seemud",
+		Short:         "SeeMUD from the command line",
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+	logLevel := root.PersistentFlags().String("log-level", "", "minimum log level: debug, info, warn or error (default $SEEMUD_LOG_LEVEL or info)")
+	root.PersistentPreRunE = func(cmd *cobra.Command, args []string) error {
+		logging.Install()
+		if *logLevel != "" {
+			if err := logging.SetLevel(*logLevel); err != nil {
+				return fmt.Errorf("invalid --log-level: %w", err)
+			}
+		}
+		if err := parser.LoadDir(datadir.Resolve().Join("parsers")); err != nil {
+			logger.Warn("failed to load parser definitions", "error", err)
+		}
+		return nil
+	}
+	root.AddCommand(
+		newPlayCommand(),
+		newRawCommand(),
+		newReplayCommand(),
+		newMapCommand(),
+		newRenderCommand(),
+		newDoctorCommand(),
+		newPartyRelayCommand(),
+	)
+
+	if err := root.Execute(); err != nil {
+		var code exitError
+		if errors.As(err, &code) {
+			os.Exit(int(code))
+		}
+		fmt.Fprintln(os.Stderr, "Error:", err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/mapexport"
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/profile"
+)
+
+// newMapCommand creates "seemud map" and its subcommands
+func newMapCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "map",
+		Short: "Work with saved maps",
+	}
+	cmd.AddCommand(newMapListCommand(), newMapExportCommand(), newMapImportCommand())
+	return cmd
+}
+
+// newMapListCommand creates "seemud map list"
+func newMapListCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the saved maps and how many rooms each has",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			dir := datadir.Resolve().Maps()
+			entries, err := os.ReadDir(dir)
+			if os.IsNotExist(err) {
+				fmt.Println("No maps saved yet")
+				return nil
+			}
+			if err != nil {
+				return fmt.Errorf("failed to read map directory: %w", err)
+			}
+
+			var names []string
+			for _, entry := range entries {
+				if !entry.IsDir() && filepath.Ext(entry.Name()) == ".json" {
+					names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+				}
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				m, err := loadSavedMap(name)
+				if err != nil {
+					fmt.Printf("%-30s %v\n", name, err)
+					continue
+				}
+				fmt.Printf("%-30s %d rooms\n", name, m.RoomCount())
+			}
+			return nil
+		},
+	}
+}
+
+// newMapExportCommand creates "seemud map export". The format comes from
+// --format, or the output file's extension, defaulting to SeeMUD's JSON.
+func newMapExportCommand() *cobra.Command {
+	var connection *profile.Flags
+	var formatName, out string
+	cmd := &cobra.Command{
+		Use:   "export [file]",
+		Short: "Export a server's saved map to share, draw or import elsewhere",
+		Long: `Export a server's saved map without connecting.
+
+Formats are json (SeeMUD's own), svg and png (drawings with one panel per
+level), mudlet (Mudlet's JSON map format, for its "Import map"), layered
+(JSON of levels, zones, rooms and exits for 3D tools) and gltf (a 3D scene
+of the map, one node per level, zone and room).`,
+		Example: `  seemud map export --profile wolfmud --format svg --out wolfmud.svg
+  seemud map export --host example.org --port 4000 --format mudlet -o map.json`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				if out != "" {
+					return fmt.Errorf("give the output file as an argument or with --out, not both")
+				}
+				out = args[0]
+			}
+			if out == "" {
+				return fmt.Errorf("an output file is needed (--out)")
+			}
+
+			format := mapexport.FormatForPath(out)
+			if formatName != "" {
+				var err error
+				if format, err = mapexport.ParseFormat(formatName); err != nil {
+					return err
+				}
+			}
+
+			server, err := connection.Resolve()
+			if err != nil {
+				return fmt.Errorf("invalid connection settings: %w", err)
+			}
+			m, err := loadSavedMap(server.ServerName())
+			if err != nil {
+				return err
+			}
+			if m.RoomCount() == 0 {
+				return fmt.Errorf("no map saved for %s", server.Address())
+			}
+
+			if format == mapexport.FormatJSON {
+				if err := m.ExportMap(out, server.ServerName()); err != nil {
+					return err
+				}
+			} else if err := writeMapExport(out, m, server.ServerName(), format); err != nil {
+				return err
+			}
+			fmt.Printf("Exported %d rooms to %s (%s)\n", m.RoomCount(), out, format)
+			return nil
+		},
+	}
+	connection = profile.RegisterFlags(cmd.Flags())
+	cmd.Flags().StringVar(&formatName, "format", "", "json, svg, png, mudlet, layered or gltf (default from the file extension, else json)")
+	cmd.Flags().StringVarP(&out, "out", "o", "", "file to write")
+	return cmd
+}
+
+// newMapImportCommand creates "seemud map import", which merges a Mudlet
+// map into a server's saved map
+func newMapImportCommand() *cobra.Command {
+	var connection *profile.Flags
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Add the rooms of a Mudlet map to a server's saved map",
+		Long: `Add the rooms of a Mudlet map to a server's saved map without connecting.
+
+The map must be in Mudlet's JSON format: in Mudlet, run
+lua saveJsonMap(getMudletHomeDir() .. "/map.json") to write one. Rooms
+already on the saved map are kept as they are.`,
+		Example: `  seemud map import --profile wolfmud map.json`,
+		Args:    cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server, err := connection.Resolve()
+			if err != nil {
+				return fmt.Errorf("invalid connection settings: %w", err)
+			}
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open map: %w", err)
+			}
+			data, err := mapexport.ReadMudlet(file)
+			file.Close()
+			if err != nil {
+				return err
+			}
+
+			m, err := loadSavedMap(server.ServerName())
+			if err != nil {
+				return err
+			}
+			added := m.MergeMap(data)
+			if err := m.SaveMap(server.ServerName()); err != nil {
+				return err
+			}
+			fmt.Printf("Imported %d of %d rooms into the map for %s (%d rooms)\n",
+				added, data.Graph.GetRoomCount(), server.Address(), m.RoomCount())
+			return nil
+		},
+	}
+	connection = profile.RegisterFlags(cmd.Flags())
+	return cmd
+}
+
+// writeMapExport draws or converts a map into a file
+func writeMapExport(path string, m *mapper.Mapper, serverName string, format mapexport.Format) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create export file: %w", err)
+	}
+	data := &mapper.MapData{
+		Version:       mapper.MapVersion,
+		ServerName:    serverName,
+		Graph:         m.Graph,
+		CurrentRoomID: m.CurrentRoomID,
+		Fingerprint:   m.Fingerprint(),
+	}
+	if err := mapexport.Write(file, data, format); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write export file: %w", err)
+	}
+	return nil
+}
+
+// loadSavedMap loads a server's map from the data directory
+func loadSavedMap(serverName string) (*mapper.Mapper, error) {
+	m := mapper.NewMapper()
+	m.SetDirectory(datadir.Resolve().Maps())
+	if err := m.LoadMap(serverName); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/spf13/cobra"
+
+	"seemud-gui/internal/party"
+)
+
+// newPartyRelayCommand creates "seemud party-relay"
+func newPartyRelayCommand() *cobra.Command {
+	var listen string
+	cmd := &cobra.Command{
+		Use:   "party-relay",
+		Short: "Relay map updates between party members",
+		Long: `Run the relay that party members' SeeMUDs join to share one map, for when
+none of them can host it. Members join with the relay's address, e.g.
+ws://relay.example.com:4060/party, and the same party name; the relay passes
+rooms and positions between them and keeps nothing itself. Runs until
+interrupted.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server, addr, err := party.NewRelay().Listen(listen)
+			if err != nil {
+				return err
+			}
+			fmt.Printf("Relaying parties on ws://%s%s\n", addr, party.RelayPath)
+
+			interrupt := make(chan os.Signal, 1)
+			signal.Notify(interrupt, os.Interrupt)
+			<-interrupt
+			return server.Close()
+		},
+	}
+	cmd.Flags().StringVar(&listen, "listen", party.DefaultSettings().Listen, "host:port to listen on")
+	return cmd
+}
@@ -0,0 +1,485 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/chzyer/readline"
+	"github.com/spf13/cobra"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/profile"
+	"seemud-gui/internal/script"
+	"seemud-gui/internal/session"
+	"seemud-gui/internal/sessionlog"
+	"seemud-gui/internal/termimage"
+	"seemud-gui/internal/tui"
+)
+
+var logger = logging.For("Play")
+
+// minimapRadius is how many rooms the minimap shows each way
+const minimapRadius = 3
+
+// playOptions are the play command's flags
+type playOptions struct {
+	connection *profile.Flags
+	tui        bool
+	script     string
+	log        string
+	logFormat  string
+	minimap    bool
+	ascii      bool
+	images     string
+	defs       string
+	login      string
+}
+
+// newPlayCommand creates "seemud play"
+func newPlayCommand() *cobra.Command {
+	opts := &playOptions{}
+	cmd := &cobra.Command{
+		Use:   "play",
+		Short: "Play in the terminal",
+		Long: `Play in the terminal with line editing and history, a minimap after each
+move and room images where the terminal can draw them. --tui switches to a
+full-screen layout; --script runs a bot script headlessly instead.
+
+--login (or a profile's "login") plays a script before handing over, to get
+through the account and character prompts:
+
+  expect "Account:"
+  send "$SEEMUD_ACCOUNT"
+  expect "Password:"
+  secret "$SEEMUD_PASSWORD"
+  expect "Enter an option"
+  send "1"`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runPlay(opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.BoolVar(&opts.tui, "tui", false, "full-screen mode with output, chat, minimap and vitals panes")
+	flags.StringVar(&opts.script, "script", "", "run a bot script headlessly, then exit 0 if it passed, 1 if a step failed or 2 on error")
+	flags.StringVar(&opts.log, "log", "", "write a timestamped session log for replay to this file (with --script, default stdout)")
+	flags.StringVar(&opts.logFormat, "log-format", "clean", "log output as the parser cleaned it (clean) or as received, colour codes and all (raw)")
+	flags.BoolVar(&opts.minimap, "minimap", true, "draw the area around you after each move; /map draws it on demand")
+	flags.BoolVar(&opts.ascii, "ascii", false, "draw the minimap in plain ASCII instead of box-drawing characters")
+	flags.StringVar(&opts.images, "images", "auto", "room images: auto, kitty, sixel, none (just say where they're cached) or off")
+	flags.StringVar(&opts.defs, "defs", "", "TinTin++ script or Mudlet package of triggers and aliases to load (default "+definitionsFile+" in the data directory, if there is one)")
+	flags.StringVar(&opts.login, "login", "", "login script to play after connecting, overriding the profile's")
+	opts.connection = profile.RegisterFlags(flags)
+	return cmd
+}
+
+// runPlay connects and plays until the user quits
+func runPlay(opts *playOptions) error {
+	server, err := opts.connection.Resolve()
+	if err != nil {
+		return fmt.Errorf("invalid connection settings: %w", err)
+	}
+
+	variant, err := sessionlog.ParseVariant(opts.logFormat)
+	if err != nil {
+		return fmt.Errorf("invalid --log-format: %w", err)
+	}
+
+	if opts.script != "" {
+		if code := runScript(server, opts.script, opts.log, variant); code != 0 {
+			return exitError(code)
+		}
+		return nil
+	}
+	login, err := loadLogin(server, opts.login)
+	if err != nil {
+		return err
+	}
+	sessionLog, logFile, err := openSessionLog(opts.log, variant)
+	if err != nil {
+		return err
+	}
+	if logFile != nil {
+		defer logFile.Close()
+	}
+	if opts.tui {
+		return runTUI(server, login, sessionLog)
+	}
+
+	fmt.Println("🎮 SeeMUD Interactive Client")
+	fmt.Println("==============================")
+	fmt.Printf("Connecting to %s...\n", server.Address())
+
+	protocol, showImages := termimage.ProtocolNone, opts.images != "off"
+	if showImages {
+		protocol, err = termimage.ParseProtocol(opts.images)
+		if err != nil {
+			return fmt.Errorf("invalid --images: %w", err)
+		}
+	}
+	mud := newEngine(server, showImages)
+	if sessionLog != nil {
+		sessionLog.Attach(mud)
+	}
+
+	// Definitions are shared with the GUI, so they're also there next time
+	defs := opts.defs
+	if defs == "" {
+		if path := datadir.Resolve().Join(definitionsFile); fileExists(path) {
+			defs = path
+		}
+	}
+	if defs != "" {
+		result, err := loadDefinitions(mud, defs)
+		if err != nil {
+			return fmt.Errorf("failed to load definitions: %w", err)
+		}
+		fmt.Printf("Loaded %d triggers and %d aliases from %s\n", len(result.Triggers), len(result.Aliases), defs)
+		for _, skipped := range result.Skipped {
+			fmt.Printf("⚠️  Skipped %s %s: %s\n", skipped.Kind, skipped.Name, skipped.Reason)
+		}
+	}
+
+	// Readline gives history, line editing and Ctrl-R search, and redraws
+	// the prompt below incoming output so it never interleaves with typing
+	rl, err := readline.NewEx(&readline.Config{
+		Prompt:            "> ",
+		HistoryFile:       datadir.Resolve().Join("play_history"),
+		HistoryLimit:      1000,
+		HistorySearchFold: true,
+		InterruptPrompt:   "^C",
+		EOFPrompt:         "quit",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to start readline: %w", err)
+	}
+	defer rl.Close()
+
+	out := rl.Stdout()
+	logging.SetOutput(rl.Stderr())
+
+	mapStyle := mapper.UnicodeStyle
+	if opts.ascii {
+		mapStyle = mapper.ASCIIStyle
+	}
+	drawMap := func() {
+		fmt.Fprintln(out, indent(mud.Mapper.GetMinimap(minimapRadius).Text(mapStyle), "   "))
+	}
+
+	// Rooms are mapped before their exits are printed, so hold the map back
+	// until the room has been shown
+	var mapDue, drawing atomic.Bool
+	if opts.minimap && server.MappingEnabled() {
+		mud.Mapper.OnRoomChange(func(roomID string, isNew bool) {
+			mapDue.Store(true)
+		})
+	}
+
+	// Handle output as the engine parses it
+	lastImageRoom := ""
+	mud.OnLine(func(entry output.Entry, parsed *parser.ParsedOutput) {
+		// Only format rooms once the login dance is over
+		inGame := mud.State() == session.StateInGame
+
+		// Clean display based on content
+		if parsed.CleanText != "" {
+			if inGame && parsed.Type == parser.TypeRoomTitle {
+				fmt.Fprintf(out, "\n🏠 === %s ===\n", parsed.CleanText)
+			} else if inGame && parsed.Type == parser.TypeRoomDescription {
+				fmt.Fprintf(out, "📝 %s\n", parsed.CleanText)
+			} else if parsed.Type == parser.TypeExits && len(parsed.Exits) > 0 {
+				fmt.Fprintf(out, "🚪 Exits: %s\n", strings.Join(parsed.Exits, ", "))
+			} else if parsed.Type == parser.TypeInventory {
+				fmt.Fprintf(out, "📦 %s\n", parsed.CleanText)
+			} else {
+				// Regular output
+				fmt.Fprintln(out, parsed.CleanText)
+			}
+		}
+
+		if (parsed.Type == parser.TypeExits || parsed.Type == parser.TypePrompt) && mapDue.Swap(false) {
+			drawMap()
+		}
+
+		// The room is complete once its exits arrive. Only one image is
+		// drawn at a time; moving on quickly skips rooms.
+		if inGame && parsed.Type == parser.TypeExits && mud.Images != nil {
+			if room, ok := mud.Rooms.Current(); ok && room.Name != lastImageRoom && drawing.CompareAndSwap(false, true) {
+				lastImageRoom = room.Name
+				go func() {
+					defer drawing.Store(false)
+					showRoomImage(out, mud, room, protocol)
+				}()
+			}
+		}
+	})
+
+	// The login runner has to exist before connecting to see the banner
+	var runner *script.Runner
+	if login != nil {
+		runner = script.NewRunner(mud, sessionLog)
+	}
+
+	// Connect
+	if err := mud.ConnectAs(server.Host, server.Port, server.ServerName()); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer mud.Disconnect()
+
+	fmt.Println("✓ Connected! Creating/logging into account...")
+	fmt.Println()
+	fmt.Println("Instructions:")
+	if login == nil {
+		fmt.Println("1. Press ENTER to create a new account")
+		fmt.Println("2. Follow prompts to create character")
+	} else {
+		fmt.Println("1. The login script answers the account prompts")
+		fmt.Println("2. If it stops, carry on by hand")
+	}
+	fmt.Println("3. Type 'quit' or press Ctrl-D to exit client")
+	fmt.Println("4. Type '/quit' to quit from MUD")
+	fmt.Println("5. Use the arrow keys for history and Ctrl-R to search it")
+	fmt.Println("6. Type '/map' to draw the area around you")
+	fmt.Println("7. '/alias {name} {commands}' and '/trigger {pattern} {commands}' automate,")
+	fmt.Println("   '/alias' and '/trigger' list, '/unalias' and '/untrigger' remove")
+	fmt.Println()
+	fmt.Println("----------------------------------------")
+
+	if login != nil {
+		fmt.Fprintf(out, "🔑 Logging in with %s...\n", login.Name)
+		if err := runLogin(runner, login); err != nil {
+			fmt.Fprintf(out, "⚠️  Login script stopped: %v\n", err)
+		} else {
+			fmt.Fprintln(out, "✓ Logged in")
+		}
+	}
+
+	// Handle user input
+	for {
+		input, err := rl.Readline()
+		if errors.Is(err, readline.ErrInterrupt) {
+			// Ctrl-C clears the line; Ctrl-D or "quit" leaves
+			continue
+		}
+		if err != nil {
+			// io.EOF from Ctrl-D
+			break
+		}
+
+		command := strings.TrimSpace(input)
+
+		// Check for client quit
+		if command == "quit" {
+			fmt.Fprintln(out, "\n👋 Disconnecting from MUD...")
+			break
+		}
+
+		if command == "/map" {
+			drawMap()
+			continue
+		}
+		if automationCommand(out, mud, command) {
+			continue
+		}
+
+		// Check for MUD quit command
+		if command == "/quit" {
+			command = "QUIT"
+		}
+
+		// Send command to MUD
+		if err := mud.Input(command); err != nil {
+			fmt.Fprintf(out, "⚠️  Error sending command: %v\n", err)
+		}
+	}
+
+	fmt.Println("✓ Session ended. Goodbye!")
+	return nil
+}
+
+// showRoomImage draws a room's image inline, generating it first if Stable
+// Diffusion is running. Terminals without image support are told where the
+// image is cached instead.
+func showRoomImage(out io.Writer, mud *engine.Engine, room engine.Room, protocol termimage.Protocol) {
+	image, cached := mud.Images.Cached(room)
+	if !cached {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		available := mud.Images.Available(ctx)
+		cancel()
+		if !available {
+			return
+		}
+		generated, err := mud.Images.Generate(context.Background(), room, "")
+		if err != nil {
+			fmt.Fprintf(out, "🎨 [image failed: %v]\n", err)
+			return
+		}
+		image = generated
+	}
+
+	// Don't draw a room the player has already left
+	if current, ok := mud.Rooms.Current(); !ok || current.Name != room.Name {
+		return
+	}
+
+	if protocol != termimage.ProtocolNone {
+		data, err := base64.StdEncoding.DecodeString(image)
+		if err == nil {
+			err = termimage.Write(out, protocol, data, termimage.DefaultOptions)
+		}
+		if err == nil {
+			return
+		}
+		fmt.Fprintf(out, "🎨 [couldn't draw image: %v]\n", err)
+	}
+	if images, ok := mud.Images.(*engine.SDImageService); ok {
+		if path, ok := images.CachedPath(room); ok {
+			fmt.Fprintf(out, "🎨 [image cached at %s]\n", path)
+		}
+	}
+}
+
+// indent prefixes every line of text
+func indent(text, prefix string) string {
+	return prefix + strings.ReplaceAll(text, "\n", "\n"+prefix)
+}
+
+// newEngine creates a headless engine for a server profile, without image
+// generation unless images are wanted
+func newEngine(server profile.Profile, images bool) *engine.Engine {
+	cfg := engine.DefaultConfig()
+	if !images {
+		cfg.ImageCacheDir = ""
+	}
+	cfg.Dialect = server.Dialect
+	cfg.NoMapping = !server.MappingEnabled()
+	cfg.ImageBackend = cfg.ImageBackend.Override(server.ImageBackend, server.ImageEndpoint)
+	mud := engine.New(cfg)
+	if err := mud.Styles.SetSession(server.Style); err != nil {
+		logger.Warn("using default image style", "error", err)
+	}
+	mud.Session.SetOptions(server.Options())
+	return mud
+}
+
+// loadLogin loads the login script from --login, or else the profile's
+func loadLogin(server profile.Profile, path string) (*script.Script, error) {
+	if path == "" {
+		path = server.LoginScript()
+	}
+	if path == "" {
+		return nil, nil
+	}
+	login, err := script.Load(path, server.ScriptVars())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load login script: %w", err)
+	}
+	return login, nil
+}
+
+// runLogin plays a login script, then stops the runner so the player can
+// take over. The caller shows the output.
+func runLogin(runner *script.Runner, login *script.Script) error {
+	defer runner.Stop()
+	return runner.Run(login)
+}
+
+// runTUI plays in the full-screen TUI, e.g. over SSH, logging in with a
+// script first if there is one
+func runTUI(server profile.Profile, login *script.Script, sessionLog *sessionlog.Writer) error {
+	mud := newEngine(server, false)
+	if sessionLog != nil {
+		sessionLog.Attach(mud)
+	}
+
+	// Log lines would draw over the panes; they're still in the debug buffer
+	logging.SetOutput(io.Discard)
+
+	var runner *script.Runner
+	if login != nil {
+		runner = script.NewRunner(mud, sessionLog)
+	}
+	if err := mud.ConnectAs(server.Host, server.Port, server.ServerName()); err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer mud.Disconnect()
+
+	// The TUI shows the exchange; a failed login leaves the player at
+	// whatever prompt it stopped on
+	if runner != nil {
+		go func() {
+			if err := runLogin(runner, login); err != nil {
+				logger.Warn("login script stopped", "error", err)
+			}
+		}()
+	}
+
+	if err := tui.Run(mud); err != nil {
+		return fmt.Errorf("TUI failed: %w", err)
+	}
+	return nil
+}
+
+// openSessionLog creates the --log file, returning a nil writer if there
+// isn't one
+func openSessionLog(path string, variant sessionlog.Variant) (*sessionlog.Writer, *os.File, error) {
+	if path == "" {
+		return nil, nil, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create log: %w", err)
+	}
+	return sessionlog.New(f, variant), f, nil
+}
+
+// runScript connects, plays a bot script and returns the exit status
+func runScript(server profile.Profile, path, logPath string, variant sessionlog.Variant) int {
+	bot, err := script.Load(path, server.ScriptVars())
+	if err != nil {
+		log.Printf("Failed to load script: %v", err)
+		return 2
+	}
+
+	sessionLog := sessionlog.New(os.Stdout, variant)
+	if logPath != "" {
+		var f *os.File
+		sessionLog, f, err = openSessionLog(logPath, variant)
+		if err != nil {
+			log.Printf("Couldn't start the session log: %v", err)
+			return 2
+		}
+		defer f.Close()
+	}
+
+	mud := newEngine(server, false)
+	sessionLog.Attach(mud)
+	runner := script.NewRunner(mud, sessionLog)
+	if err := mud.ConnectAs(server.Host, server.Port, server.ServerName()); err != nil {
+		log.Printf("Failed to connect: %v", err)
+		return 2
+	}
+	defer mud.Close()
+
+	start := time.Now()
+	if err := runner.Run(bot); err != nil {
+		log.Printf("Script failed: %v", err)
+		return 1
+	}
+	log.Printf("Script passed: %d steps in %s", len(bot.Steps), time.Since(start).Round(time.Millisecond))
+	return 0
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/profile"
+	"seemud-gui/internal/telnet"
+	"seemud-gui/internal/termtheme"
+)
+
+// newRawCommand creates "seemud raw"
+func newRawCommand() *cobra.Command {
+	var (
+		connection *profile.Flags
+		trace      bool
+		dumpPath   string
+		colours    string
+		themePath  string
+	)
+	cmd := &cobra.Command{
+		Use:   "raw",
+		Short: "Talk to a server with only the telnet client and parser",
+		Long: `Connect with just the telnet client and parser, colouring each line by how
+it was classified. Useful for checking parsing without the engine.
+
+--trace shows each line's raw text, cleaned text, classification and
+extracted fields. Lines that look misclassified are marked with ?; --dump
+writes them to a file to attach to a bug report. At the prompt, /trace and
+/dump toggle these, and /wrong [note] adds the last line to the dump.
+
+Colours come from a theme mapping output classes (room-title, exit, prompt,
+chat-tell...) to styles, drawn with as many colours as the terminal has:
+
+  {"room-title": {"fg": "#5fd7ff", "bold": true}, "exit": {"fg": "214"}}`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			depth, err := termtheme.ParseDepth(colours)
+			if err != nil {
+				return fmt.Errorf("invalid --colours: %w", err)
+			}
+			if themePath == "" {
+				themePath = datadir.Resolve().Join(termtheme.FileName)
+			}
+			theme, err := termtheme.Load(themePath)
+			if err != nil {
+				return err
+			}
+			return runRaw(connection, trace, dumpPath, termtheme.NewPainter(theme, depth))
+		},
+	}
+	flags := cmd.Flags()
+	flags.BoolVar(&trace, "trace", false, "show the raw text, classification and extracted fields of every line")
+	flags.StringVar(&dumpPath, "dump", "", "write suspect classifications to this file")
+	flags.StringVar(&colours, "colours", "auto", "colour depth: auto, none, 16, 256 or truecolor")
+	flags.StringVar(&themePath, "theme", "", "theme file (default "+termtheme.FileName+" in the data directory, if there is one)")
+	connection = profile.RegisterFlags(flags)
+	return cmd
+}
+
+// runRaw connects and echoes classified output until the user quits
+func runRaw(connection *profile.Flags, trace bool, dumpPath string, painter *termtheme.Painter) error {
+	server, err := connection.Resolve()
+	if err != nil {
+		return fmt.Errorf("invalid connection settings: %w", err)
+	}
+
+	fmt.Println("SeeMUD Test Client")
+	fmt.Printf("Connecting to %s...\n", server.Address())
+
+	// Create telnet client
+	client := telnet.NewClientWithOptions(server.Host, server.Port, server.Options())
+	mudParser, err := parser.ForDialect(server.Dialect)
+	if err != nil {
+		return fmt.Errorf("failed to create parser: %w", err)
+	}
+
+	// Connect
+	err = client.Connect()
+	if err != nil {
+		return fmt.Errorf("failed to connect: %w", err)
+	}
+	defer client.Disconnect()
+
+	fmt.Println("Connected! Type 'quit' to exit.")
+
+	diagnostics := &tracer{
+		dumpPath: dumpPath,
+		header:   fmt.Sprintf("SeeMUD parse dump for %s, %s parser", server.Address(), server.Dialect),
+	}
+	defer diagnostics.close()
+	if dumpPath != "" {
+		if _, err := diagnostics.setDumping(true); err != nil {
+			return err
+		}
+	}
+	var tracing atomic.Bool
+	tracing.Store(trace)
+
+	// Start output processing
+	go func() {
+		outputChan := client.GetOutput()
+		for line := range outputChan {
+			// Parse the line
+			parsed := mudParser.ParseLine(line)
+			traced := diagnostics.observe(parsed)
+			if tracing.Load() {
+				printTrace(os.Stdout, traced)
+				continue
+			}
+
+			// Colour output by its semantic class
+			class := output.Class(parsed)
+			switch parsed.Type {
+			case parser.TypeRoomTitle:
+				fmt.Println(painter.Paint(class, "[ROOM] "+parsed.CleanText))
+			case parser.TypeRoomDescription:
+				fmt.Println(painter.Paint(class, "[DESC] "+parsed.CleanText))
+			case parser.TypeExits:
+				fmt.Println(painter.Paint(class, "[EXITS] "+parsed.CleanText))
+			case parser.TypeInventory:
+				fmt.Println(painter.Paint(class, "[ITEM] "+parsed.CleanText))
+			case parser.TypePrompt:
+				fmt.Print(painter.Paint(class, parsed.CleanText) + " ")
+			default:
+				fmt.Println(painter.Paint(class, parsed.CleanText))
+			}
+		}
+	}()
+
+	// Handle user input
+	scanner := bufio.NewScanner(os.Stdin)
+	for {
+		if !scanner.Scan() {
+			break
+		}
+
+		command := strings.TrimSpace(scanner.Text())
+		if command == "quit" {
+			fmt.Println("Disconnecting...")
+			break
+		}
+
+		switch verb, note, _ := strings.Cut(command, " "); verb {
+		case "/trace":
+			tracing.Store(!tracing.Load())
+			fmt.Printf("Tracing %s\n", onOff(tracing.Load()))
+			continue
+		case "/dump":
+			path, err := diagnostics.setDumping(!diagnostics.dumping())
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+			} else {
+				fmt.Printf("Dumping suspect lines to %s %s\n", path, onOff(diagnostics.dumping()))
+			}
+			continue
+		case "/wrong":
+			path, err := diagnostics.report(strings.TrimSpace(note))
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+			} else {
+				fmt.Printf("Added the last line to %s\n", path)
+			}
+			continue
+		}
+
+		if command != "" {
+			err := client.SendCommand(command)
+			if err != nil {
+				fmt.Printf("Error sending command: %v\n", err)
+			}
+		}
+
+		// Give a moment for output to process
+		time.Sleep(10 * time.Millisecond)
+	}
+	return nil
+}
+
+// onOff describes a toggle
+func onOff(on bool) string {
+	if on {
+		return "on"
+	}
+	return "off"
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/profile"
+	"seemud-gui/internal/renderer"
+)
+
+// newRenderCommand creates "seemud render"
+func newRenderCommand() *cobra.Command {
+	var (
+		connection  *profile.Flags
+		description string
+		prompt      string
+		outputPath  string
+		style       string
+		backend     *imageBackendFlags
+	)
+	cmd := &cobra.Command{
+		Use:   "render <room name>",
+		Short: "Generate a room's image with Stable Diffusion or ComfyUI",
+		Long: `Generate a room's image as the GUI would, using the saved map for its
+description and neighbours. The image replaces any cached one; --output also
+writes a copy elsewhere. Rooms that aren't mapped need --description.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			server, err := connection.Resolve()
+			if err != nil {
+				return fmt.Errorf("invalid connection settings: %w", err)
+			}
+			m, err := loadSavedMap(server.ServerName())
+			if err != nil {
+				return err
+			}
+
+			room := engine.Room{Name: args[0], Description: description}
+			if matches := m.GetGraph().FindRoomsByName(args[0]); len(matches) > 0 {
+				if len(matches) > 1 {
+					fmt.Printf("%d rooms are called %q; using the first\n", len(matches), args[0])
+				}
+				if room.Description == "" {
+					room.Description = matches[0].Description
+				}
+				if err := m.SetCurrentRoom(matches[0].ID); err != nil {
+					return err
+				}
+			} else if description == "" {
+				return fmt.Errorf("%q isn't in the map for %s; give a --description", args[0], server.Address())
+			}
+
+			cacheDir := datadir.Resolve().RoomImages()
+			drawer, err := renderer.NewBackend(backend.settings(server))
+			if err != nil {
+				return err
+			}
+			styles, err := renderer.NewStyles(engine.DefaultConfig().StyleFile)
+			if err != nil {
+				return err
+			}
+			if style == "" {
+				style = server.Style
+			}
+			if err := styles.SetSession(style); err != nil {
+				return err
+			}
+			images := engine.NewSDImageService(drawer, m, cacheDir)
+			images.SetCondenser(renderer.NewCondenser(engine.DefaultConfig().Condenser))
+			images.SetStyle(func() renderer.StylePreset { return styles.For("") })
+			images.UseServer(server.ServerName())
+			fmt.Printf("Generating %s with %s...\n", room.Name, drawer.Name())
+			image, err := images.Generate(cmd.Context(), room, prompt)
+			if err != nil {
+				return err
+			}
+			if path, ok := images.CachedPath(room); ok {
+				fmt.Printf("Cached at %s\n", path)
+			}
+
+			if outputPath != "" {
+				data, err := base64.StdEncoding.DecodeString(image)
+				if err != nil {
+					return fmt.Errorf("failed to decode image: %w", err)
+				}
+				if err := os.WriteFile(outputPath, data, 0644); err != nil {
+					return fmt.Errorf("failed to write image: %w", err)
+				}
+				fmt.Printf("Written to %s\n", outputPath)
+			}
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&description, "description", "", "room description, overriding the map's")
+	flags.StringVar(&prompt, "prompt", "", "extra prompt text, added to any saved with the room")
+	flags.StringVarP(&outputPath, "output", "o", "", "also write the PNG here")
+	flags.StringVar(&style, "style", "", "style preset, e.g. oil-painting (default the profile's, or "+renderer.DefaultStyle+")")
+	backend = registerImageBackendFlags(flags)
+	connection = profile.RegisterFlags(flags)
+	return cmd
+}
+
+// imageBackendFlags choose the image backend, overriding the profile's
+type imageBackendFlags struct {
+	fs      *pflag.FlagSet
+	backend *string
+	sd      *string
+	comfyUI *string
+}
+
+// registerImageBackendFlags adds the image backend flags to a command
+func registerImageBackendFlags(fs *pflag.FlagSet) *imageBackendFlags {
+	defaults := engine.DefaultConfig().ImageBackend
+	return &imageBackendFlags{
+		fs:      fs,
+		backend: fs.String("backend", "", "image backend, "+strings.Join(renderer.Backends, " or ")+" (default the profile's, then $"+engine.ImageBackendEnvVar+")"),
+		sd:      fs.String("sd", defaults.SDEndpoint, "Stable Diffusion endpoint"),
+		comfyUI: fs.String("comfyui", renderer.DefaultComfyUIEndpoint, "ComfyUI endpoint"),
+	}
+}
+
+// settings returns the backend settings for a server's profile, with any
+// flags given explicitly on top
+func (f *imageBackendFlags) settings(server profile.Profile) renderer.BackendSettings {
+	settings := engine.DefaultConfig().ImageBackend.Override(server.ImageBackend, server.ImageEndpoint)
+	if f.fs.Changed("backend") {
+		settings.Backend = *f.backend
+	}
+	if f.fs.Changed("sd") {
+		settings.SDEndpoint = *f.sd
+	}
+	if f.fs.Changed("comfyui") {
+		settings.ComfyUIEndpoint = *f.comfyUI
+	}
+	return settings
+}
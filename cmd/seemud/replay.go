@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/replay"
+)
+
+// newReplayCommand creates "seemud replay"
+func newReplayCommand() *cobra.Command {
+	var (
+		dialect    string
+		mapPath    string
+		roomsOnly  bool
+		serverName string
+	)
+	cmd := &cobra.Command{
+		Use:   "replay <logfile>",
+		Short: "Replay a recorded session through the parser and mapper",
+		Long: `Feed a recorded session through the parser and mapper, printing how each
+line was classified and which rooms were mapped, then save the resulting map.
+Takes plain transcripts and "seemud play --log" session logs, clean or raw;
+lines starting "> " are commands.
+Use it to reproduce parsing and mapping problems from a user's log.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			f, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open log: %w", err)
+			}
+			lines, err := replay.ReadLog(f)
+			f.Close()
+			if err != nil {
+				return err
+			}
+
+			session, err := replay.New(dialect)
+			if err != nil {
+				return err
+			}
+
+			counts := make(map[string]int)
+			session.Run(lines, func(d replay.Decision) {
+				if d.Parsed == nil {
+					counts["command"]++
+					if !roomsOnly {
+						movement := ""
+						if d.Movement != "" {
+							movement = "  (move " + d.Movement + ")"
+						}
+						fmt.Printf("%5d %-16s > %s%s\n", d.Line.Number, "command", d.Line.Text, movement)
+					}
+					return
+				}
+
+				kind := d.Parsed.Type.String()
+				counts[kind]++
+				if !roomsOnly || d.Parsed.Type == parser.TypeRoomTitle {
+					fmt.Printf("%5d %-16s %s\n", d.Line.Number, kind, d.Parsed.CleanText)
+				}
+				if d.Refused != "" {
+					fmt.Printf("%5s %-16s move %s cancelled\n", "", "↳ refused", d.Refused)
+				}
+				if d.RoomID != "" {
+					state := "revisited"
+					if d.NewRoom {
+						state = "new"
+					}
+					fmt.Printf("%5s %-16s room %s (%s, %d mapped)\n", "", "↳ mapped", d.RoomID, state, d.RoomCount)
+				}
+			})
+
+			kinds := make([]string, 0, len(counts))
+			for kind := range counts {
+				kinds = append(kinds, kind)
+			}
+			sort.Strings(kinds)
+			summary := make([]string, len(kinds))
+			for i, kind := range kinds {
+				summary[i] = fmt.Sprintf("%s %d", kind, counts[kind])
+			}
+			fmt.Printf("\n%d lines: %s\n", len(lines), strings.Join(summary, ", "))
+
+			if mapPath == "" {
+				mapPath = strings.TrimSuffix(args[0], ".txt") + ".map.json"
+			}
+			if err := session.Mapper.ExportMap(mapPath, serverName); err != nil {
+				return err
+			}
+			fmt.Printf("%d rooms mapped, saved to %s\n", session.Mapper.RoomCount(), mapPath)
+			return nil
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&dialect, "dialect", parser.DefaultDialect, "parser dialect the log came from")
+	flags.StringVarP(&mapPath, "output", "o", "", "where to save the map (default <logfile>.map.json)")
+	flags.BoolVar(&roomsOnly, "rooms", false, "only print room titles and mapping decisions")
+	flags.StringVar(&serverName, "server", "replay", "server name recorded in the map file")
+	return cmd
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/parser"
+)
+
+// parseTrace is one line of output and what the parser made of it
+type parseTrace struct {
+	Number  int
+	Parsed  *parser.ParsedOutput
+	Suspect string // Why the classification looks wrong, if it does
+}
+
+// fields lists what the parser extracted from a line
+func (t parseTrace) fields() string {
+	p := t.Parsed
+	var fields []string
+	add := func(name, value string) {
+		if value != "" {
+			fields = append(fields, name+"="+value)
+		}
+	}
+	add("room", p.RoomName)
+	if len(p.Exits) > 0 {
+		add("exits", "["+strings.Join(p.Exits, "|")+"]")
+	}
+	if len(p.Items) > 0 {
+		add("items", "["+strings.Join(p.Items, "|")+"]")
+	}
+	if len(p.Mobs) > 0 {
+		add("mobs", "["+strings.Join(p.Mobs, "|")+"]")
+	}
+	add("speaker", p.Speaker)
+	add("channel", p.Channel)
+	add("message", p.Message)
+	add("opponent", p.Opponent)
+	if p.Outgoing {
+		add("outgoing", "true")
+	}
+	if p.Incoming {
+		add("incoming", "true")
+	}
+	return strings.Join(fields, " ")
+}
+
+// tracer classifies lines for the raw command's diagnostics, spotting
+// suspect classifications and writing them to a dump file
+type tracer struct {
+	mutex    sync.Mutex
+	count    int
+	previous parser.OutputType // Type of the last non-blank line
+	last     *parseTrace
+
+	dumpPath string
+	dump     io.WriteCloser
+	header   string
+}
+
+// observe records a parsed line and judges whether it looks misclassified
+func (t *tracer) observe(parsed *parser.ParsedOutput) parseTrace {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.count++
+	trace := parseTrace{Number: t.count, Parsed: parsed}
+	if strings.TrimSpace(parsed.CleanText) == "" {
+		return trace
+	}
+
+	switch {
+	case parsed.Type == parser.TypeUnknown:
+		trace.Suspect = "unclassified"
+	case parsed.Type == parser.TypeRoomDescription && t.previous != parser.TypeRoomTitle && t.previous != parser.TypeRoomDescription:
+		// Descriptions are the parser's fallback, so one outside a room
+		// usually means nothing else matched
+		trace.Suspect = "description outside a room"
+	case parsed.Type == parser.TypeExits && len(parsed.Exits) == 1 && strings.Contains(parsed.Exits[0], " "):
+		trace.Suspect = "exits not split"
+	}
+	t.previous = parsed.Type
+	t.last = &trace
+
+	if trace.Suspect != "" && t.dump != nil {
+		t.write(trace, "suspect: "+trace.Suspect)
+	}
+	return trace
+}
+
+// report writes the last line to the dump file as misclassified, with a note
+func (t *tracer) report(note string) (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.last == nil {
+		return "", fmt.Errorf("no output to report yet")
+	}
+	if err := t.open(); err != nil {
+		return "", err
+	}
+	reason := "reported"
+	if note != "" {
+		reason += ": " + note
+	}
+	t.write(*t.last, reason)
+	return t.dumpPath, nil
+}
+
+// setDumping starts or stops writing suspect lines to the dump file
+func (t *tracer) setDumping(on bool) (string, error) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if !on {
+		if t.dump != nil {
+			t.dump.Close()
+			t.dump = nil
+		}
+		return t.dumpPath, nil
+	}
+	return t.dumpPath, t.open()
+}
+
+// dumping reports whether suspect lines are being written
+func (t *tracer) dumping() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.dump != nil
+}
+
+// open opens the dump file for appending; the caller must hold the lock
+func (t *tracer) open() error {
+	if t.dump != nil {
+		return nil
+	}
+	if t.dumpPath == "" {
+		t.dumpPath = "seemud-parse-" + time.Now().Format("20060102-150405") + ".txt"
+	}
+	f, err := os.OpenFile(t.dumpPath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open dump file: %w", err)
+	}
+	t.dump = f
+	fmt.Fprintf(f, "# %s, %s\n\n", t.header, time.Now().Format(time.RFC3339))
+	return nil
+}
+
+// write appends a trace to the dump file; the caller must hold the lock
+func (t *tracer) write(trace parseTrace, reason string) {
+	p := trace.Parsed
+	fmt.Fprintf(t.dump, "line %d (%s)\n", trace.Number, reason)
+	fmt.Fprintf(t.dump, "  raw    %q\n", p.RawText)
+	fmt.Fprintf(t.dump, "  bytes  % x\n", []byte(p.RawText))
+	fmt.Fprintf(t.dump, "  clean  %q\n", p.CleanText)
+	fmt.Fprintf(t.dump, "  type   %s\n", p.Type)
+	if fields := trace.fields(); fields != "" {
+		fmt.Fprintf(t.dump, "  fields %s\n", fields)
+	}
+	fmt.Fprintln(t.dump)
+}
+
+// close closes the dump file
+func (t *tracer) close() {
+	t.setDumping(false)
+}
+
+// traceColumns is how wide the clean text column is in trace mode
+const traceColumns = 48
+
+// printTrace shows a line's classification beside its text, with the raw
+// line underneath when control codes were stripped from it
+func printTrace(w io.Writer, trace parseTrace) {
+	p := trace.Parsed
+	clean := p.CleanText
+	if len(clean) > traceColumns {
+		clean = clean[:traceColumns-1] + "…"
+	}
+	mark := " "
+	if trace.Suspect != "" {
+		mark = "?"
+	}
+	row := fmt.Sprintf("%5d %s %-16s %-*s %s", trace.Number, mark, p.Type, traceColumns, clean, trace.fields())
+	fmt.Fprintln(w, strings.TrimRight(row, " "))
+	if p.RawText != p.CleanText {
+		fmt.Fprintf(w, "%5s   %-16s %q\n", "", "raw", p.RawText)
+	}
+	if trace.Suspect != "" {
+		fmt.Fprintf(w, "%5s   %-16s %s\n", "", "suspect", trace.Suspect)
+	}
+}
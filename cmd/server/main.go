@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"seemud-gui/internal/api"
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/metrics"
+	"seemud-gui/internal/vault"
+)
+
+// Headless server: runs the engine behind a localhost REST API for bots and
+// alternative frontends
+func main() {
+	listen := flag.String("listen", "127.0.0.1:4060", "address for the API to listen on")
+	token := flag.String("token", os.Getenv("SEEMUD_API_TOKEN"), "bearer token required on every request (default $SEEMUD_API_TOKEN, or one generated and printed)")
+	useVault := flag.Bool("vault", false, "read the API token and SD credentials from the keychain-backed vault")
+	sdEndpoint := flag.String("sd", "", "Stable Diffusion endpoint (default http://127.0.0.1:7860)")
+	imageBackend := flag.String("image-backend", "", "image backend, stable-diffusion or comfyui (default $SEEMUD_IMAGE_BACKEND or stable-diffusion)")
+	comfyEndpoint := flag.String("comfyui", "", "ComfyUI endpoint (default $SEEMUD_COMFYUI_ENDPOINT or http://127.0.0.1:8188)")
+	noImages := flag.Bool("no-images", false, "disable room image generation")
+	metricsAddr := flag.String("metrics", "", "serve Prometheus metrics at http://<addr>/metrics (off by default)")
+	logLevel := flag.String("log-level", "", "minimum log level: debug, info, warn or error (default $SEEMUD_LOG_LEVEL or info)")
+	flag.Parse()
+
+	logging.Install()
+	if *logLevel != "" {
+		if err := logging.SetLevel(*logLevel); err != nil {
+			log.Fatalf("Invalid -log-level: %v", err)
+		}
+	}
+
+	cfg := engine.DefaultConfig()
+	if *sdEndpoint != "" {
+		cfg.ImageBackend.SDEndpoint = *sdEndpoint
+	}
+	if *imageBackend != "" {
+		cfg.ImageBackend.Backend = *imageBackend
+	}
+	if *comfyEndpoint != "" {
+		cfg.ImageBackend.ComfyUIEndpoint = *comfyEndpoint
+	}
+	if *noImages {
+		cfg.ImageCacheDir = ""
+	}
+	mud := engine.New(cfg)
+
+	if *useVault {
+		secrets := vault.New(datadir.Resolve().Join("vault.json"))
+		if err := secrets.UnlockWithKeychain(); err != nil {
+			log.Fatalf("Failed to unlock vault: %v", err)
+		}
+		if stored, err := secrets.Get(vault.SecretAPIToken); err == nil && *token == "" {
+			*token = stored
+		}
+		if sdAuth, err := secrets.Get(vault.SecretSDAuth); err == nil {
+			if images, ok := mud.Images.(*engine.SDImageService); ok {
+				images.SetAuth(sdAuth)
+			}
+		}
+		if llmKey, err := secrets.Get(vault.SecretLLMKey); err == nil {
+			if images, ok := mud.Images.(*engine.SDImageService); ok {
+				images.Condenser().SetAPIKey(llmKey)
+			}
+			mud.Extractor.SetAPIKey(llmKey)
+		}
+		secrets.Lock()
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *token == "" {
+		generated, err := api.GenerateToken()
+		if err != nil {
+			log.Fatalf("No -token set: %v", err)
+		}
+		*token = generated
+		log.Printf("No -token set, generated one for this run: %s", *token)
+	}
+
+	if *metricsAddr != "" {
+		exporter := metrics.NewServer(mud.Metrics.Registry)
+		if _, err := exporter.Apply(metrics.Settings{Enabled: true, Address: *metricsAddr}); err != nil {
+			log.Fatalf("Metrics failed: %v", err)
+		}
+		defer exporter.Stop()
+	}
+
+	server, err := api.NewServer(mud, *token)
+	if err != nil {
+		log.Fatalf("API server failed: %v", err)
+	}
+	if err := server.ListenAndServe(ctx, *listen); err != nil {
+		log.Fatalf("API server failed: %v", err)
+	}
+
+	if err := mud.Close(); err != nil {
+		log.Printf("Error closing session: %v", err)
+	}
+}
@@ -0,0 +1,75 @@
+import { useState, useEffect } from 'react';
+import { GetAliases, AddAlias, DeleteAlias } from "../wailsjs/go/main/App";
+import { t } from './i18n.js';
+
+// Alias editor: short forms expanded before commands are sent, written as
+// at other MUD clients, e.g. "k %1" for "kill %1"
+function Aliases({ onClose }) {
+    const [aliases, setAliases] = useState([]);
+    const [draft, setDraft] = useState({ name: '', commands: '' });
+    const [error, setError] = useState('');
+
+    const refresh = () => {
+        GetAliases()
+            .then(list => setAliases(list || []))
+            .catch(err => console.error("Error getting aliases:", err));
+    };
+
+    useEffect(refresh, []);
+
+    const addAlias = (e) => {
+        e.preventDefault();
+        AddAlias(draft.name, draft.commands)
+            .then(() => {
+                setDraft({ name: '', commands: '' });
+                setError('');
+                refresh();
+            })
+            .catch(err => setError(String(err)));
+    };
+
+    const removeAlias = (alias) => {
+        DeleteAlias(alias.name)
+            .then(refresh)
+            .catch(err => console.error("Error removing alias:", err));
+    };
+
+    return (
+        <div className="alias-editor">
+            <div className="alias-editor-header">
+                <span>{t('ui.aliases_title')}</span>
+                <button onClick={onClose} className="btn-abort">{t('ui.close')}</button>
+            </div>
+            <div className="alias-editor-list">
+                {aliases.length === 0 && <div className="alias-entry">{t('ui.aliases_none')}</div>}
+                {aliases.map(alias => (
+                    <div key={alias.name} className="alias-entry">
+                        <span className="alias-name">{alias.name}</span>
+                        <span className="alias-commands">{alias.commands}</span>
+                        <button onClick={() => removeAlias(alias)} className="btn-abort">
+                            {t('ui.alias_remove')}
+                        </button>
+                    </div>
+                ))}
+            </div>
+            {error && <div className="alias-error">{error}</div>}
+            <form className="alias-editor-add" onSubmit={addAlias}>
+                <input
+                    placeholder={t('ui.alias_name')}
+                    value={draft.name}
+                    onChange={e => setDraft({ ...draft, name: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.alias_commands')}
+                    value={draft.commands}
+                    onChange={e => setDraft({ ...draft, commands: e.target.value })}
+                />
+                <button type="submit" disabled={!draft.name || !draft.commands} className="btn-connect">
+                    {t('ui.alias_add')}
+                </button>
+            </form>
+        </div>
+    );
+}
+
+export default Aliases;
@@ -1,33 +1,84 @@
 import { useState, useEffect, useRef } from 'react';
 import './App.css';
-import { AnsiText } from './ansi.jsx';
+import { SpanText } from './ansi.jsx';
 import Map from './Map.jsx';
+import Cooldowns from './Cooldowns.jsx';
+import Tick from './Tick.jsx';
+import Vitals from './Vitals.jsx';
+import Combat from './Combat.jsx';
+import DebugConsole from './DebugConsole.jsx';
+import ServerBrowser from './ServerBrowser.jsx';
+import Aliases from './Aliases.jsx';
+import Styles from './Styles.jsx';
+import Profiles from './Profiles.jsx';
+import Highlights from './Highlights.jsx';
+import Schedule from './Schedule.jsx';
+import PastePreview from './PastePreview.jsx';
+import { t, useMessages } from './i18n.js';
 import {
     ConnectToMUD,
     DisconnectFromMUD,
     SendCommand,
-    GetOutput,
+    GetLinesSince,
     GetConnectionStatus,
     GenerateRoomImage,
     RegenerateRoomImage,
     RegenerateRoomImageWithPrompt,
+    RegenerateRoomImageWithSeed,
+    AutoGenerateRoomImage,
+    CancelImageGeneration,
     GetCurrentRoom,
     GetCurrentEntities,
     GetRoomImage,
-    CheckSDStatus
+    CheckSDStatus,
+    GetImageBackend,
+    GetVariationStrength,
+    SetVariationStrength,
+    SetWindowFocused,
+    ReconnectToMUD,
+    GetRoomPrompt,
+    SetRoomPrompt,
+    GetSpeedwalks,
+    RunSpeedwalkKey,
+    AbortSpeedwalk,
+    Move,
+    CancelPending,
+    Complete,
+    GetRecentCommands,
+    SearchCommands,
+    ChooseFileToSend
 } from "../wailsjs/go/main/App";
+import { EventsOn } from "../wailsjs/runtime/runtime";
+
+// Lines of scrollback kept on screen; the full history stays in the backend
+const MAX_SCROLLBACK = 2000;
+
+// appendLines adds lines to the scrollback, dropping the oldest past the cap
+const appendLines = (prev, lines) => {
+    const next = [...prev, ...lines];
+    return next.length > MAX_SCROLLBACK ? next.slice(next.length - MAX_SCROLLBACK) : next;
+};
 
 function App() {
+    useMessages();
     const [connected, setConnected] = useState(false);
     const [connecting, setConnecting] = useState(false);
+    const [linkDead, setLinkDead] = useState(false);
+    const [speedwalk, setSpeedwalk] = useState(null); // Progress of the running speedwalk
+    const [queueLength, setQueueLength] = useState(0); // Commands waiting in the send queue
     const [output, setOutput] = useState([]);
     const [inputValue, setInputValue] = useState('');
     const [commandHistory, setCommandHistory] = useState([]);
     const [historyIndex, setHistoryIndex] = useState(-1);
     const [currentRoom, setCurrentRoom] = useState({});
     const [roomImage, setRoomImage] = useState(null);
+    const [imageStale, setImageStale] = useState(false); // Cached image drawn for an older description
     const [generatingImage, setGeneratingImage] = useState(false);
     const [sdAvailable, setSdAvailable] = useState(false);
+    const [imageBackend, setImageBackend] = useState(''); // Which backend draws images, as profiles can choose
+    const [variationStrength, setVariationStrength] = useState(0.5); // How far regenerating strays from the image, 0 for new
+    const [imageJob, setImageJob] = useState(null); // Latest image queue status for the current room
+    const [imageProgress, setImageProgress] = useState(null); // How far the current room's image has got
     const [imagePanelWidth, setImagePanelWidth] = useState(600); // Default to 600px
     const [entitiesPanelWidth, setEntitiesPanelWidth] = useState(300); // Default to 300px
     const [mapPanelWidth, setMapPanelWidth] = useState(400); // Default to 400px
@@ -36,11 +87,24 @@ function App() {
     const [isResizingMap, setIsResizingMap] = useState(false);
     const [showPromptInput, setShowPromptInput] = useState(false);
     const [customPrompt, setCustomPrompt] = useState('');
+    const [roomPrompt, setRoomPrompt] = useState(''); // Prompt additions saved for this room
     const [entities, setEntities] = useState({ items: [], mobs: [] });
+    const [showDebug, setShowDebug] = useState(false);
+    const [showServers, setShowServers] = useState(false);
+    const [showAliases, setShowAliases] = useState(false);
+    const [showStyles, setShowStyles] = useState(false);
+    const [showProfiles, setShowProfiles] = useState(false);
+    const [showHighlights, setShowHighlights] = useState(false);
+    const [showSchedule, setShowSchedule] = useState(false);
+    const [pastedText, setPastedText] = useState(null); // Multi-line paste awaiting confirmation
+    const [narration, setNarration] = useState(''); // Latest narration for the screen reader
 
     const outputEndRef = useRef(null);
     const inputRef = useRef(null);
     const generatingRef = useRef(false);
+    const outputSeqRef = useRef(0); // Last output sequence number we've displayed
+    const completionRef = useRef(null); // Tab completion candidates being cycled
+    const searchRef = useRef(null); // Ctrl-R matches being cycled
 
     // Auto-scroll to bottom when new output arrives
     useEffect(() => {
@@ -52,7 +116,184 @@ function App() {
         inputRef.current?.focus();
     }, []);
 
-    // Handle mouse move for resizing image panel
+    // Tell the backend about focus so notifications only fire when we're unfocused
+    useEffect(() => {
+        const handleFocus = () => SetWindowFocused(true);
+        const handleBlur = () => SetWindowFocused(false);
+
+        window.addEventListener('focus', handleFocus);
+        window.addEventListener('blur', handleBlur);
+        return () => {
+            window.removeEventListener('focus', handleFocus);
+            window.removeEventListener('blur', handleBlur);
+        };
+    }, []);
+
+    useEffect(() => {
+        GetVariationStrength().then(setVariationStrength).catch(() => {});
+    }, []);
+
+    const handleVariationStrength = (strength) => {
+        setVariationStrength(strength);
+        SetVariationStrength(strength);
+    };
+
+    // Load the prompt additions saved for each room we enter
+    useEffect(() => {
+        if (!currentRoom.name) return;
+        GetRoomPrompt()
+            .then(prompt => setRoomPrompt(prompt || ''))
+            .catch(() => setRoomPrompt(''));
+    }, [currentRoom.name]);
+
+    // Follow speedwalk progress
+    useEffect(() => {
+        return EventsOn("speedwalk:progress", (progress) => {
+            setSpeedwalk(progress.done ? null : progress);
+            if (progress.cancelled) {
+                setOutput(prev => [...prev, `⏹️ ${t('ui.speedwalk_aborted', progress)}`]);
+            } else if (progress.error) {
+                setOutput(prev => [...prev, `❌ ${t('ui.speedwalk_stopped', progress)}`]);
+            }
+        });
+    }, []);
+
+    useEffect(() => {
+        return EventsOn("queue:changed", setQueueLength);
+    }, []);
+
+    // Narration in screen reader mode is announced through a live region
+    useEffect(() => {
+        return EventsOn("speech:narration", setNarration);
+    }, []);
+
+    // Lua scripts can print to the output and generate the room's image
+    useEffect(() => {
+        return EventsOn("script:print", ({ script, text }) => {
+            setOutput(prev => appendLines(prev, [`📜 ${script}: ${text}`]));
+        });
+    }, []);
+
+    useEffect(() => {
+        // Every finished image arrives here, including ones scripts asked for
+        return EventsOn("image:ready", ({ room, url }) => {
+            if (room === currentRoom.name) {
+                setRoomImage(url);
+                setImageStale(false);
+            }
+        });
+    }, [currentRoom.name]);
+
+    // Image generations wait in a queue; show where the current room's is
+    useEffect(() => {
+        setImageJob(null);
+        return EventsOn("image:queue", (update) => {
+            if (update.room === currentRoom.name) {
+                setImageJob(update);
+                if (update.status !== 'generating') {
+                    setImageProgress(null);
+                }
+            }
+        });
+    }, [currentRoom.name]);
+
+    // The backend reports progress while drawing; previews only come when they change
+    useEffect(() => {
+        setImageProgress(null);
+        return EventsOn("image:progress", (progress) => {
+            if (progress.room === currentRoom.name) {
+                setImageProgress((last) => ({
+                    ...progress,
+                    preview: progress.preview || last?.preview || '',
+                }));
+            }
+        });
+    }, [currentRoom.name]);
+
+    // A cached image drawn before the room changed offers regeneration
+    useEffect(() => {
+        return EventsOn("image:stale", () => setImageStale(true));
+    }, []);
+
+    useEffect(() => {
+        setImageStale(false);
+    }, [currentRoom.name]);
+
+    // Speedwalk hotkeys: function keys and modifier combos can be bound to
+    // routes, and Escape cancels everything still queued
+    useEffect(() => {
+        if (!connected) return;
+
+        let boundKeys = new Set();
+        GetSpeedwalks()
+            .then(routes => {
+                boundKeys = new Set((routes || []).filter(r => r.key).map(r => r.key.toLowerCase()));
+            })
+            .catch(err => console.error("Error loading speedwalks:", err));
+
+        const handleKeyDown = async (e) => {
+            if (e.key === 'Escape') {
+                CancelPending();
+                return;
+            }
+
+            const isFunctionKey = /^F\d{1,2}$/.test(e.key);
+            if (!isFunctionKey && !e.ctrlKey && !e.altKey && !e.metaKey) return;
+            if (['Control', 'Alt', 'Shift', 'Meta'].includes(e.key)) return;
+
+            const parts = [];
+            if (e.ctrlKey) parts.push('Ctrl');
+            if (e.altKey) parts.push('Alt');
+            if (e.shiftKey) parts.push('Shift');
+            if (e.metaKey) parts.push('Meta');
+            parts.push(e.key.length === 1 ? e.key.toUpperCase() : e.key);
+
+            // Leave unbound combos such as Ctrl+C alone
+            const combo = parts.join('+');
+            if (!boundKeys.has(combo.toLowerCase())) return;
+
+            e.preventDefault();
+            try {
+                await RunSpeedwalkKey(combo);
+            } catch (err) {
+                setOutput(prev => [...prev, `❌ ${t('ui.speedwalk_failed', { error: err.message || err })}`]);
+            }
+        };
+
+        window.addEventListener('keydown', handleKeyDown);
+        return () => window.removeEventListener('keydown', handleKeyDown);
+    }, [connected]);
+
+    // Numpad movement while the input line is empty; the backend turns the
+    // key into the right command for the server's dialect
+    useEffect(() => {
+        if (!connected) return;
+
+        const handleKeyDown = (e) => {
+            if (!e.code.startsWith('Numpad') || inputRef.current?.value) return;
+            if (!/^[1-46-9+-]$/.test(e.key)) return;
+
+            e.preventDefault();
+            Move(e.key).catch(err => console.error("Move failed:", err));
+        };
+
+        window.addEventListener('keydown', handleKeyDown);
+        return () => window.removeEventListener('keydown', handleKeyDown);
+    }, [connected]);
+
+    // Watch for the link dropping so the player can resume where they were
+    useEffect(() => {
+        return EventsOn("connection:state", (change) => {
+            if (change.state === "link_dead") {
+                setLinkDead(true);
+                setOutput(prev => [...prev, "", `⚠️ ${t('ui.link_dead')}`]);
+            } else if (change.state !== "connecting") {
+                setLinkDead(false);
+            }
+        });
+    }, []);
+
+        // Handle mouse move for resizing image panel
     useEffect(() => {
         const handleMouseMove = (e) => {
             if (!isResizingImage) return;
@@ -145,42 +386,72 @@ function App() {
         };
     }, [isResizingMap]);
 
-    // Poll for output when connected
+    // Receive output a frame at a time when connected. Listen first, then
+    // backfill anything missed while disconnected; frames arriving during the
+    // backfill wait so lines stay in order.
     useEffect(() => {
         if (!connected) return;
 
-        const pollOutput = async () => {
-            try {
-                const lines = await GetOutput();
-                if (lines && lines.length > 0) {
-                    setOutput(prev => [...prev, ...lines]);
-                }
+        let backfilled = false;
+        const waiting = [];
+        const addLines = (lines) => {
+            const fresh = lines.filter(line => line.seq > outputSeqRef.current);
+            if (fresh.length > 0) {
+                outputSeqRef.current = fresh[fresh.length - 1].seq;
+                setOutput(prev => appendLines(prev, fresh));
+            }
+        };
 
-                // Check for room updates
-                const room = await GetCurrentRoom();
-                if (room && (room.name || room.description)) {
-                    // Check if room has changed
-                    setCurrentRoom(prevRoom => {
-                        if (prevRoom.name !== room.name) {
-                            // Room changed, check for cached image
-                            checkCachedImage(room);
-                        }
-                        return room;
-                    });
-                }
+        const unsubscribe = EventsOn("output:frame", (frame) => {
+            if (backfilled) {
+                addLines(frame.lines);
+            } else {
+                waiting.push(frame);
+            }
+        });
 
-                // Check for entity updates
-                const entitiesData = await GetCurrentEntities();
-                if (entitiesData) {
-                    setEntities(entitiesData);
-                }
-            } catch (err) {
-                console.error("Error getting output:", err);
+        GetLinesSince(outputSeqRef.current)
+            .then(frame => addLines(frame.lines))
+            .catch(err => console.error("Error getting output:", err))
+            .finally(() => {
+                backfilled = true;
+                waiting.forEach(frame => addLines(frame.lines));
+            });
+
+        return unsubscribe;
+    }, [connected]);
+
+    // The backend pushes room and entity changes; fetch them once on
+    // connecting in case they came before we were listening
+    useEffect(() => {
+        if (!connected) return;
+
+        const showRoom = (room) => {
+            if (room && (room.name || room.description)) {
+                setCurrentRoom(prevRoom => {
+                    if (prevRoom.name !== room.name) {
+                        // Room changed, check for cached image
+                        checkCachedImage(room);
+                    }
+                    return room;
+                });
+            }
+        };
+        const showEntities = (entitiesData) => {
+            if (entitiesData) {
+                setEntities(entitiesData);
             }
         };
 
-        const interval = setInterval(pollOutput, 100);
-        return () => clearInterval(interval);
+        const unsubscribeRoom = EventsOn("room:changed", showRoom);
+        const unsubscribeEntities = EventsOn("entities:changed", showEntities);
+        GetCurrentRoom().then(showRoom).catch(err => console.error("Error getting room:", err));
+        GetCurrentEntities().then(showEntities).catch(err => console.error("Error getting entities:", err));
+
+        return () => {
+            unsubscribeRoom();
+            unsubscribeEntities();
+        };
     }, [connected]);
 
     // Check SD status periodically
@@ -188,6 +459,7 @@ function App() {
         const checkSD = async () => {
             try {
                 const available = await CheckSDStatus();
+                setImageBackend(await GetImageBackend());
                 setSdAvailable(prev => {
                     // If SD just became available and we have a room without image, generate
                     if (!prev && available && currentRoom.name && !roomImage && !generatingRef.current) {
@@ -208,12 +480,20 @@ function App() {
         return () => clearInterval(interval);
     }, [currentRoom.name, roomImage]);
 
-    const handleConnect = async () => {
+    // handleConnect connects to the local WolfMUD unless given another way
+    // to connect, such as to a server from the directory
+    const handleConnect = async (connect = () => ConnectToMUD("localhost", "4001")) => {
         setConnecting(true);
         try {
-            await ConnectToMUD("localhost", "4001");
+            await connect();
             setConnected(true);
-            setOutput(prev => [...prev, "🎮 Connected to WolfMUD!", ""]);
+            setShowServers(false);
+            setShowProfiles(false);
+            setOutput(prev => [...prev, `🎮 ${t('ui.connected')}`, ""]);
+            // The up arrow reaches back into earlier sessions on this server
+            GetRecentCommands(0)
+                .then(commands => setCommandHistory((commands || []).reverse()))
+                .catch(err => console.error("Error getting command history:", err));
             // Check for cached image after initial connection with longer delay
             // to ensure room data is loaded
             setTimeout(() => {
@@ -221,18 +501,31 @@ function App() {
                 checkCachedImage();
             }, 1500);
         } catch (err) {
-            setOutput(prev => [...prev, `❌ Connection failed: ${err.message || err}`]);
+            setOutput(prev => [...prev, `❌ ${t('ui.connection_failed', { error: err.message || err })}`]);
             console.error("Connection error:", err);
         } finally {
             setConnecting(false);
         }
     };
 
+    const handleReconnect = async () => {
+        setConnecting(true);
+        try {
+            await ReconnectToMUD();
+            setOutput(prev => [...prev, `🔄 ${t('ui.reconnecting')}`, ""]);
+        } catch (err) {
+            setOutput(prev => [...prev, `❌ ${t('ui.reconnect_failed', { error: err.message || err })}`]);
+            console.error("Reconnect error:", err);
+        } finally {
+            setConnecting(false);
+        }
+    };
+
     const handleDisconnect = async () => {
         try {
             await DisconnectFromMUD();
             setConnected(false);
-            setOutput(prev => [...prev, "", "👋 Disconnected from MUD"]);
+            setOutput(prev => [...prev, "", `👋 ${t('ui.disconnected')}`]);
         } catch (err) {
             console.error("Disconnect error:", err);
         }
@@ -245,27 +538,108 @@ function App() {
         const command = inputValue; // Don't trim here to preserve empty commands
 
         // Add to output display (show what was actually sent)
-        const displayCommand = command || "(enter)";
+        const displayCommand = command || t('ui.empty_command');
         setOutput(prev => [...prev, `> ${displayCommand}`]);
 
-        // Add to history (only non-empty commands)
+        // Add to history (only non-empty commands), moving a repeat to the end
         if (command.trim()) {
-            setCommandHistory(prev => [...prev, command.trim()]);
+            setCommandHistory(prev => [...prev.filter(c => c !== command.trim()), command.trim()]);
         }
         setHistoryIndex(-1);
+        searchRef.current = null;
 
         // Send to MUD
         try {
             await SendCommand(command);
         } catch (err) {
-            setOutput(prev => [...prev, `❌ Error: ${err}`]);
+            setOutput(prev => [...prev, `❌ ${t('ui.error', { error: err })}`]);
         }
 
         setInputValue('');
     };
 
+    // Tab completes the last word from nouns seen this session; pressing it
+    // again cycles through the other candidates
+    const handleTabComplete = async () => {
+        const current = completionRef.current;
+        if (current && current.shown === inputValue && current.words.length > 1) {
+            const index = (current.index + 1) % current.words.length;
+            const shown = current.base + current.words[index];
+            completionRef.current = { ...current, index, shown };
+            setInputValue(shown);
+            return;
+        }
+
+        try {
+            const words = await Complete(inputValue);
+            if (!words || words.length === 0) return;
+
+            const base = inputValue.slice(0, inputValue.lastIndexOf(' ') + 1);
+            const shown = base + words[0];
+            completionRef.current = { base, words, index: 0, shown };
+            setInputValue(shown);
+        } catch (err) {
+            console.error("Completion failed:", err);
+        }
+    };
+
+    // Multi-line pastes are previewed and sent through the queue rather
+    // than dropped into the single-line input
+    const handlePaste = (e) => {
+        const text = e.clipboardData.getData('text');
+        if (!/[\r\n]/.test(text.replace(/[\r\n]+$/, ''))) return;
+        e.preventDefault();
+        setPastedText(text);
+    };
+
+    const handlePasteSent = (count) => {
+        setPastedText(null);
+        setOutput(prev => [...prev, `📋 ${t('ui.sending_paste', { count })}`]);
+        inputRef.current?.focus();
+    };
+
+    const handleSendFile = async () => {
+        try {
+            const batch = await ChooseFileToSend();
+            if (batch) {
+                setOutput(prev => [...prev, `📄 ${t('ui.sending_file')}`]);
+            }
+        } catch (err) {
+            setOutput(prev => [...prev, `❌ ${t('ui.error', { error: err })}`]);
+        }
+    };
+
+    // Ctrl-R finds earlier commands starting with what's typed, newest
+    // first; pressing it again steps further back
+    const handleHistorySearch = async () => {
+        const current = searchRef.current;
+        if (current && current.shown === inputValue) {
+            if (current.matches.length > 1) {
+                const index = (current.index + 1) % current.matches.length;
+                searchRef.current = { ...current, index, shown: current.matches[index] };
+                setInputValue(current.matches[index]);
+            }
+            return;
+        }
+
+        try {
+            const matches = await SearchCommands(inputValue, 50);
+            if (!matches || matches.length === 0) return;
+            searchRef.current = { matches, index: 0, shown: matches[0] };
+            setInputValue(matches[0]);
+        } catch (err) {
+            console.error("History search failed:", err);
+        }
+    };
+
     const handleKeyDown = (e) => {
-        if (e.key === 'ArrowUp') {
+        if (e.key === 'Tab') {
+            e.preventDefault();
+            handleTabComplete();
+        } else if (e.key === 'r' && e.ctrlKey) {
+            e.preventDefault();
+            handleHistorySearch();
+        } else if (e.key === 'ArrowUp') {
             e.preventDefault();
             if (historyIndex < commandHistory.length - 1) {
                 const newIndex = historyIndex + 1;
@@ -291,7 +665,7 @@ function App() {
             const cachedImage = await GetRoomImage();
             if (cachedImage) {
                 console.log("Found cached image for room");
-                setRoomImage(`data:image/png;base64,${cachedImage}`);
+                setRoomImage(cachedImage);
                 return true; // Image found
             } else {
                 // Use passed roomData or fetch current room
@@ -337,14 +711,17 @@ function App() {
         generatingRef.current = true;
         setGeneratingImage(true);
         try {
-            console.log("Calling RegenerateRoomImage for room:", room.name);
-            // Use RegenerateRoomImage to bypass cache and always generate fresh
-            const imageBase64 = await RegenerateRoomImage();
-            console.log("Got image, setting room image");
-            setRoomImage(`data:image/png;base64,${imageBase64}`);
+            console.log("Queueing AutoGenerateRoomImage for room:", room.name);
+            // Queued behind images the player asks for, and cancelled if we move on
+            const imageURL = await AutoGenerateRoomImage();
+            if (imageURL) {
+                console.log("Got image, setting room image");
+                setRoomImage(imageURL);
+                setImageStale(false);
+            }
         } catch (err) {
             console.error("Auto image generation failed:", err);
-            setOutput(prev => [...prev, `❌ Auto image generation failed: ${err.message || err}`]);
+            setOutput(prev => [...prev, `❌ ${t('ui.auto_image_failed', { error: err.message || err })}`]);
         } finally {
             console.log("Auto-generation completed, resetting flags");
             generatingRef.current = false;
@@ -359,94 +736,185 @@ function App() {
         setGeneratingImage(true);
         try {
             // Use RegenerateRoomImage if we already have an image, otherwise use GenerateRoomImage
-            const imageBase64 = roomImage
+            const imageURL = roomImage
                 ? await RegenerateRoomImage()
                 : await GenerateRoomImage();
-            setRoomImage(`data:image/png;base64,${imageBase64}`);
+            if (imageURL) {
+                setRoomImage(imageURL);
+                setImageStale(false);
+            }
         } catch (err) {
             console.error("Image generation failed:", err);
-            setOutput(prev => [...prev, `❌ Image generation failed: ${err.message || err}`]);
+            setOutput(prev => [...prev, `❌ ${t('ui.image_failed', { error: err.message || err })}`]);
         } finally {
             generatingRef.current = false;
             setGeneratingImage(false);
         }
     };
 
-    const handleGenerateWithCustomPrompt = async () => {
-        if (!sdAvailable || !currentRoom.name || generatingRef.current || !customPrompt.trim()) return;
+    // The same seed keeps the cached image's style, so only the prompt's tweaks change
+    const handleGenerateWithCustomPrompt = async (sameSeed) => {
+        if (!sdAvailable || !currentRoom.name || generatingRef.current) return;
+        if (!sameSeed && !customPrompt.trim()) return;
 
         generatingRef.current = true;
         setGeneratingImage(true);
         try {
-            const imageBase64 = await RegenerateRoomImageWithPrompt(customPrompt.trim());
-            setRoomImage(`data:image/png;base64,${imageBase64}`);
+            const imageURL = sameSeed
+                ? await RegenerateRoomImageWithSeed(customPrompt.trim())
+                : await RegenerateRoomImageWithPrompt(customPrompt.trim());
+            if (imageURL) {
+                setRoomImage(imageURL);
+                setImageStale(false);
+            }
         } catch (err) {
             console.error("Custom image generation failed:", err);
-            setOutput(prev => [...prev, `❌ Custom image generation failed: ${err.message || err}`]);
+            setOutput(prev => [...prev, `❌ ${t('ui.custom_image_failed', { error: err.message || err })}`]);
         } finally {
             generatingRef.current = false;
             setGeneratingImage(false);
         }
     };
 
+    const handleSaveRoomPrompt = async (prompt) => {
+        try {
+            await SetRoomPrompt(prompt);
+            setRoomPrompt(prompt);
+        } catch (err) {
+            console.error("Saving room prompt failed:", err);
+            setOutput(prev => [...prev, `❌ ${t('ui.room_prompt_failed', { error: err.message || err })}`]);
+        }
+    };
+
     const togglePromptInput = () => {
         setShowPromptInput(prev => !prev);
     };
 
-    // Parse output for basic formatting with ANSI support
+    // MUD output arrives as structured lines with a type, semantic class,
+    // colour spans and arrival time from the backend; client messages are
+    // plain strings styled by prefix
     const formatLine = (line) => {
+        if (typeof line !== 'string') {
+            if (!line.text.trim()) {
+                return '';
+            }
+            return (
+                <span
+                    className={line.highlight ? `${line.class} highlight` : line.class}
+                    data-type={line.type}
+                    title={new Date(line.time).toLocaleTimeString()}
+                >
+                    <SpanText spans={line.spans} />
+                </span>
+            );
+        }
+
         // Skip completely empty lines
         if (!line.trim()) {
             return '';
         }
 
-        // Get clean text for content type detection
-        const cleaned = line
-            .replace(/\x1b\[[0-9;]*m/g, '')
-            .replace(/\x1b\[[0-9;]*[a-zA-Z]/g, '')
-            .replace(/\x1b[78]/g, '')
-            .replace(/\x1b\[2J/g, '')
-            .replace(/\x1b/g, '');
-
-        // Detect different content types and wrap with appropriate classes
-        if (cleaned.startsWith('>')) {
-            return <span className="command-echo"><AnsiText>{line}</AnsiText></span>;
-        } else if (cleaned.includes('Exits:')) {
-            return <span className="exits"><AnsiText>{line}</AnsiText></span>;
-        } else if (cleaned.startsWith('🎮') || cleaned.startsWith('👋')) {
-            return <span className="system"><AnsiText>{line}</AnsiText></span>;
-        } else if (cleaned.startsWith('❌')) {
-            return <span className="error"><AnsiText>{line}</AnsiText></span>;
+        if (line.startsWith('>')) {
+            return <span className="command-echo">{line}</span>;
+        } else if (line.startsWith('❌')) {
+            return <span className="error">{line}</span>;
         }
-
-        // Default: render with ANSI support
-        return <AnsiText>{line}</AnsiText>;
+        return <span className="system">{line}</span>;
     };
 
     return (
         <div className="App">
             <div className="header">
                 <h1>🎮 SeeMUD Visual Client</h1>
+                <Cooldowns connected={connected} />
+                <Tick connected={connected} />
+                <Vitals connected={connected} />
+                <Combat connected={connected} />
+                <button onClick={() => setShowDebug(!showDebug)} className="btn-debug" title="Debug console">
+                    🐞
+                </button>
+                <button onClick={() => setShowAliases(!showAliases)} className="btn-debug" title={t('ui.aliases')}>
+                    ⌨️
+                </button>
+                <button onClick={() => setShowStyles(!showStyles)} className="btn-debug" title={t('ui.styles')}>
+                    🖌️
+                </button>
+                <button onClick={() => setShowHighlights(!showHighlights)} className="btn-debug" title={t('ui.highlights')}>
+                    🔔
+                </button>
+                <button onClick={() => setShowSchedule(!showSchedule)} className="btn-debug" title={t('ui.schedule')}>
+                    ⏱️
+                </button>
+                {!connected && (
+                    <button onClick={() => setShowServers(!showServers)} className="btn-debug" title={t('ui.servers')}>
+                        🌐
+                    </button>
+                )}
+                {!connected && (
+                    <button onClick={() => setShowProfiles(!showProfiles)} className="btn-debug" title={t('ui.profiles')}>
+                        👤
+                    </button>
+                )}
                 <div className="connection-status">
-                    {connected ? (
+                    {connected && linkDead ? (
                         <>
-                            <span className="status-connected">● Connected</span>
+                            <span className="status-link-dead">● {t('ui.status_link_dead')}</span>
+                            <button onClick={handleReconnect} disabled={connecting} className="btn-connect">
+                                {connecting ? t('ui.reconnecting') : t('ui.reconnect')}
+                            </button>
                             <button onClick={handleDisconnect} className="btn-disconnect">
-                                Disconnect
+                                {t('ui.disconnect')}
+                            </button>
+                        </>
+                    ) : connected ? (
+                        <>
+                            {!speedwalk && queueLength > 0 && (
+                                <span className="speedwalk-status">
+                                    ⏳ {t('ui.queued', { count: queueLength })}
+                                    <button onClick={() => CancelPending()} className="btn-abort">
+                                        {t('ui.cancel')}
+                                    </button>
+                                </span>
+                            )}
+                            {speedwalk && (
+                                <span className="speedwalk-status">
+                                    🚶 {speedwalk.label} {speedwalk.sent}/{speedwalk.total}
+                                    <button onClick={() => AbortSpeedwalk()} className="btn-abort">
+                                        {t('ui.abort')}
+                                    </button>
+                                </span>
+                            )}
+                            <span className="status-connected">● {t('ui.status_connected')}</span>
+                            <button onClick={handleDisconnect} className="btn-disconnect">
+                                {t('ui.disconnect')}
                             </button>
                         </>
                     ) : (
                         <button
-                            onClick={handleConnect}
+                            onClick={() => handleConnect()}
                             disabled={connecting}
                             className="btn-connect"
                         >
-                            {connecting ? 'Connecting...' : 'Connect to WolfMUD'}
+                            {connecting ? t('ui.connecting') : t('ui.connect')}
                         </button>
                     )}
                 </div>
             </div>
 
+            <div className="sr-only" aria-live="polite">{narration}</div>
+
+            {showDebug && <DebugConsole onClose={() => setShowDebug(false)} />}
+            {showServers && !connected && (
+                <ServerBrowser onConnect={handleConnect} onClose={() => setShowServers(false)} />
+            )}
+            {showProfiles && !connected && (
+                <Profiles onConnect={handleConnect} onClose={() => setShowProfiles(false)} />
+            )}
+            {showAliases && <Aliases onClose={() => setShowAliases(false)} />}
+            {showStyles && <Styles onClose={() => setShowStyles(false)} />}
+            {showHighlights && <Highlights onClose={() => setShowHighlights(false)} />}
+            {showSchedule && <Schedule onClose={() => setShowSchedule(false)} />}
+
             <div className="main-content">
                 <div className="terminal-container">
                     <div className="terminal-output">
@@ -466,11 +934,28 @@ function App() {
                             value={inputValue}
                             onChange={(e) => setInputValue(e.target.value)}
                             onKeyDown={handleKeyDown}
+                            onPaste={handlePaste}
                             disabled={!connected}
-                            placeholder={connected ? "Enter command..." : "Connect first"}
+                            placeholder={connected ? t('ui.enter_command') : t('ui.connect_first')}
                             className="command-input"
                         />
+                        <button
+                            type="button"
+                            onClick={handleSendFile}
+                            disabled={!connected}
+                            className="btn-send-file"
+                            title="Send a text file line by line"
+                        >
+                            📄
+                        </button>
                     </form>
+                    {pastedText !== null && (
+                        <PastePreview
+                            text={pastedText}
+                            onSent={handlePasteSent}
+                            onCancel={() => setPastedText(null)}
+                        />
+                    )}
                 </div>
 
                 <div
@@ -502,6 +987,9 @@ function App() {
                         {roomImage ? (
                             // Split button for regeneration
                             <>
+                                {imageStale && (
+                                    <p className="image-stale">🕰️ {t('ui.image_stale')}</p>
+                                )}
                                 <div className="btn-split-group">
                                     <button
                                         onClick={handleGenerateImage}
@@ -533,13 +1021,53 @@ function App() {
                                             placeholder="e.g., darker, more fog, torchlight..."
                                             rows={3}
                                         />
+                                        <label className="variation-strength">
+                                            {variationStrength > 0
+                                                ? `Regenerate as a variation: ${Math.round(variationStrength * 100)}% change`
+                                                : 'Regenerate as a new composition'}
+                                            <input
+                                                type="range"
+                                                min="0"
+                                                max="1"
+                                                step="0.05"
+                                                value={variationStrength}
+                                                onChange={(e) => handleVariationStrength(parseFloat(e.target.value))}
+                                                disabled={generatingImage}
+                                            />
+                                        </label>
                                         <button
-                                            onClick={handleGenerateWithCustomPrompt}
+                                            onClick={() => handleGenerateWithCustomPrompt(false)}
                                             disabled={!sdAvailable || !currentRoom.name || generatingImage || !customPrompt.trim()}
                                             className="btn-generate-custom"
                                         >
                                             {generatingImage ? '🎨 Generating...' : '✨ Generate with Custom Prompt'}
                                         </button>
+                                        <button
+                                            onClick={() => handleGenerateWithCustomPrompt(true)}
+                                            disabled={!sdAvailable || !currentRoom.name || generatingImage}
+                                            className="btn-generate-custom"
+                                            title="Draw again from the current image's seed, keeping its style"
+                                        >
+                                            🎲 Same Seed
+                                        </button>
+                                        <button
+                                            onClick={() => handleSaveRoomPrompt(customPrompt.trim())}
+                                            disabled={!currentRoom.name || !customPrompt.trim()}
+                                            className="btn-room-prompt"
+                                        >
+                                            📌 Always use for this room
+                                        </button>
+                                        {roomPrompt && (
+                                            <div className="room-prompt">
+                                                <span>Saved for this room: {roomPrompt}</span>
+                                                <button
+                                                    onClick={() => handleSaveRoomPrompt('')}
+                                                    className="btn-room-prompt-clear"
+                                                >
+                                                    ✕
+                                                </button>
+                                            </div>
+                                        )}
                                     </div>
                                 )}
                             </>
@@ -553,8 +1081,26 @@ function App() {
                                 {generatingImage ? '🎨 Generating...' : '🎨 Generate Image'}
                             </button>
                         )}
+                        {imageJob && (imageJob.status === 'queued' || imageJob.status === 'generating') && (
+                            <div className="image-queue">
+                                {imageJob.status === 'queued'
+                                    ? `⏳ Queued${imageJob.position ? ` (#${imageJob.position})` : ''}`
+                                    : `🎨 Generating...${imageProgress ? ` ${imageProgress.percent}%` : ''}${imageProgress?.eta ? ` (~${Math.ceil(imageProgress.eta)}s)` : ''}`}
+                                <button onClick={() => CancelImageGeneration()} title="Cancel image generation">✕</button>
+                            </div>
+                        )}
+                        {imageJob?.status === 'generating' && imageProgress && (
+                            <div className="image-progress">
+                                <div className="image-progress-bar">
+                                    <div style={{ width: `${imageProgress.percent}%` }} />
+                                </div>
+                                {imageProgress.preview && (
+                                    <img src={`data:image/png;base64,${imageProgress.preview}`} alt="Preview" />
+                                )}
+                            </div>
+                        )}
                         <div className="sd-status">
-                            SD: <span className={sdAvailable ? 'status-ok' : 'status-error'}>
+                            {imageBackend === 'comfyui' ? 'ComfyUI' : 'SD'}: <span className={sdAvailable ? 'status-ok' : 'status-error'}>
                                 {sdAvailable ? '✅ Ready' : '❌ Not Available'}
                             </span>
                         </div>
@@ -612,7 +1158,7 @@ function App() {
 
             <div className="footer">
                 <div className="help-text">
-                    Press ↑↓ for command history | Type 'help' for commands | Type 'QUIT' to exit MUD
+                    Press ↑↓ for command history, Ctrl-R to search it | Type 'help' for commands | Type 'QUIT' to exit MUD
                 </div>
             </div>
         </div>
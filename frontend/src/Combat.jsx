@@ -0,0 +1,90 @@
+import { useState, useEffect } from 'react';
+import { GetCombatSummary } from "../wailsjs/go/main/App";
+import { EventsOn } from "../wailsjs/runtime/runtime";
+import { t } from './i18n.js';
+
+// Live fight summary: damage per second in the header, and the damage and
+// condition of each opponent one click away
+function Combat({ connected }) {
+    const [summary, setSummary] = useState(null);
+    const [open, setOpen] = useState(false);
+    const [now, setNow] = useState(Date.now());
+
+    useEffect(() => {
+        if (!connected) {
+            setSummary(null);
+            setOpen(false);
+            return;
+        }
+
+        GetCombatSummary()
+            .then(setSummary)
+            .catch(err => console.error("Error getting combat summary:", err));
+
+        return EventsOn("combat:changed", setSummary);
+    }, [connected]);
+
+    // The fight's length grows between blows, so count it locally
+    useEffect(() => {
+        if (!summary || !summary.in_combat) return;
+        const interval = setInterval(() => setNow(Date.now()), 1000);
+        return () => clearInterval(interval);
+    }, [summary]);
+
+    if (!connected || !summary || summary.fights === 0) return null;
+
+    const seconds = summary.in_combat
+        ? Math.max(0, (now - new Date(summary.started)) / 1000)
+        : summary.seconds;
+
+    return (
+        <>
+            <span
+                className={`cooldown-badge ${summary.in_combat ? 'cooldown-cooldown' : 'cooldown-duration'}`}
+                onClick={() => setOpen(!open)}
+                title={t('ui.combat_title')}
+            >
+                ⚔️ {t('ui.combat_dps', { dps: summary.dps.toFixed(1) })}
+            </span>
+            {open && (
+                <div className="alias-editor">
+                    <div className="alias-editor-header">
+                        <span>{t('ui.combat_title')}</span>
+                        <button onClick={() => setOpen(false)} className="btn-abort">{t('ui.close')}</button>
+                    </div>
+                    <div className="alias-entry">
+                        {t('ui.combat_fight', {
+                            seconds: Math.round(seconds),
+                            dealt: summary.damage_dealt,
+                            taken: summary.damage_taken,
+                        })}
+                    </div>
+                    <div className="alias-entry">
+                        {t('ui.combat_session', {
+                            kills: summary.kills,
+                            deaths: summary.deaths,
+                            seconds: Math.round(summary.total_seconds),
+                        })}
+                    </div>
+                    <div className="alias-editor-list">
+                        {summary.opponents.map(opponent => (
+                            <div key={opponent.name} className="alias-entry">
+                                <span className="alias-name">{opponent.killed ? `💀 ${opponent.name}` : opponent.name}</span>
+                                <span className="alias-commands">
+                                    {t('ui.combat_opponent', {
+                                        dealt: opponent.damage_dealt,
+                                        taken: opponent.damage_taken,
+                                        hits: opponent.hits_dealt,
+                                    })}
+                                    {opponent.condition && ` · ${opponent.condition}`}
+                                </span>
+                            </div>
+                        ))}
+                    </div>
+                </div>
+            )}
+        </>
+    );
+}
+
+export default Combat;
@@ -0,0 +1,47 @@
+import { useState, useEffect } from 'react';
+import { GetCooldownTimers } from "../wailsjs/go/main/App";
+import { EventsOn } from "../wailsjs/runtime/runtime";
+
+// Countdown badges for running skill cooldowns and spell durations
+function Cooldowns({ connected }) {
+    const [timers, setTimers] = useState([]);
+    const [now, setNow] = useState(Date.now());
+
+    useEffect(() => {
+        if (!connected) {
+            setTimers([]);
+            return;
+        }
+
+        GetCooldownTimers()
+            .then(list => setTimers(list || []))
+            .catch(err => console.error("Error getting cooldowns:", err));
+
+        return EventsOn("cooldowns:changed", list => setTimers(list || []));
+    }, [connected]);
+
+    // Tick locally rather than polling the backend every second
+    useEffect(() => {
+        if (timers.length === 0) return;
+        const interval = setInterval(() => setNow(Date.now()), 1000);
+        return () => clearInterval(interval);
+    }, [timers.length]);
+
+    const running = timers
+        .map(timer => ({ ...timer, remaining: Math.ceil((new Date(timer.ends_at) - now) / 1000) }))
+        .filter(timer => timer.remaining > 0);
+
+    if (running.length === 0) return null;
+
+    return (
+        <div className="cooldowns">
+            {running.map(timer => (
+                <span key={timer.name} className={`cooldown-badge cooldown-${timer.kind}`}>
+                    {timer.name} {timer.remaining}s
+                </span>
+            ))}
+        </div>
+    );
+}
+
+export default Cooldowns;
@@ -0,0 +1,73 @@
+import { useState, useEffect } from 'react';
+import { GetRecentLogs, GetLogLevel, GetLogLevels, SetLogLevel } from "../wailsjs/go/main/App";
+
+// How many records the console shows
+const LOG_LIMIT = 300;
+
+// In-app view of recent log records, with the runtime log level
+function DebugConsole({ onClose }) {
+    const [records, setRecords] = useState([]);
+    const [levels, setLevels] = useState([]);
+    const [level, setLevel] = useState('info');
+    const [filter, setFilter] = useState('');
+
+    useEffect(() => {
+        GetLogLevels().then(list => setLevels(list || []));
+        GetLogLevel().then(setLevel);
+    }, []);
+
+    // Poll while open; logs arrive far too often to push as events
+    useEffect(() => {
+        const refresh = () => {
+            GetRecentLogs(LOG_LIMIT, filter)
+                .then(list => setRecords(list || []))
+                .catch(err => console.error("Error getting logs:", err));
+        };
+        refresh();
+        const interval = setInterval(refresh, 2000);
+        return () => clearInterval(interval);
+    }, [filter]);
+
+    const changeLevel = (value) => {
+        SetLogLevel(value)
+            .then(() => setLevel(value))
+            .catch(err => console.error("Error setting log level:", err));
+    };
+
+    return (
+        <div className="debug-console">
+            <div className="debug-console-header">
+                <span>Debug console</span>
+                <label>
+                    Log level
+                    <select value={level} onChange={e => changeLevel(e.target.value)}>
+                        {levels.map(name => <option key={name} value={name}>{name}</option>)}
+                    </select>
+                </label>
+                <label>
+                    Show
+                    <select value={filter} onChange={e => setFilter(e.target.value)}>
+                        <option value="">all</option>
+                        {levels.map(name => <option key={name} value={name}>{name}+</option>)}
+                    </select>
+                </label>
+                <button onClick={onClose} className="btn-abort">Close</button>
+            </div>
+            <div className="debug-console-records">
+                {records.map((record, index) => (
+                    <div key={index} className={`debug-record debug-${record.level}`}>
+                        <span className="debug-time">{new Date(record.time).toLocaleTimeString()}</span>
+                        <span className="debug-level">{record.level}</span>
+                        {record.component && <span className="debug-component">[{record.component}]</span>}
+                        <span className="debug-message">{record.message}</span>
+                        {record.fields && Object.entries(record.fields).map(([key, value]) => (
+                            <span key={key} className="debug-field">{key}={value}</span>
+                        ))}
+                    </div>
+                ))}
+            </div>
+        </div>
+    );
+}
+
+export default DebugConsole;
@@ -0,0 +1,112 @@
+import { useState, useEffect } from 'react';
+import { GetHighlights, SetHighlight, DeleteHighlight } from "../wailsjs/go/main/App";
+import { t } from './i18n.js';
+
+const emptyDraft = { pattern: '', regex: false, notify: true, enabled: true };
+
+// Highlight editor: words or patterns to pick out of the output, such as
+// the character's name, optionally raising a desktop notification
+function Highlights({ onClose }) {
+    const [watches, setWatches] = useState([]);
+    const [draft, setDraft] = useState(emptyDraft);
+    const [error, setError] = useState('');
+
+    const refresh = () => {
+        GetHighlights()
+            .then(list => setWatches(list || []))
+            .catch(err => console.error("Error getting highlights:", err));
+    };
+
+    useEffect(refresh, []);
+
+    const save = (watch) => {
+        SetHighlight(watch)
+            .then(() => {
+                setError('');
+                refresh();
+            })
+            .catch(err => setError(String(err)));
+    };
+
+    const addWatch = (e) => {
+        e.preventDefault();
+        SetHighlight(draft)
+            .then(() => {
+                setDraft(emptyDraft);
+                setError('');
+                refresh();
+            })
+            .catch(err => setError(String(err)));
+    };
+
+    const removeWatch = (watch) => {
+        DeleteHighlight(watch.pattern)
+            .then(refresh)
+            .catch(err => console.error("Error removing highlight:", err));
+    };
+
+    return (
+        <div className="alias-editor">
+            <div className="alias-editor-header">
+                <span>{t('ui.highlights_title')}</span>
+                <button onClick={onClose} className="btn-abort">{t('ui.close')}</button>
+            </div>
+            <div className="alias-editor-list">
+                {watches.length === 0 && <div className="alias-entry">{t('ui.highlights_none')}</div>}
+                {watches.map(watch => (
+                    <div key={watch.pattern} className="alias-entry">
+                        <span className="alias-name">{watch.regex ? `/${watch.pattern}/` : watch.pattern}</span>
+                        <label>
+                            <input
+                                type="checkbox"
+                                checked={watch.enabled}
+                                onChange={e => save({ ...watch, enabled: e.target.checked })}
+                            />
+                            {t('ui.highlight_enabled')}
+                        </label>
+                        <label>
+                            <input
+                                type="checkbox"
+                                checked={watch.notify}
+                                onChange={e => save({ ...watch, notify: e.target.checked })}
+                            />
+                            {t('ui.highlight_notify')}
+                        </label>
+                        <button onClick={() => removeWatch(watch)} className="btn-abort">
+                            {t('ui.alias_remove')}
+                        </button>
+                    </div>
+                ))}
+            </div>
+            {error && <div className="alias-error">{error}</div>}
+            <form className="alias-editor-add" onSubmit={addWatch}>
+                <input
+                    placeholder={t('ui.highlight_pattern')}
+                    value={draft.pattern}
+                    onChange={e => setDraft({ ...draft, pattern: e.target.value })}
+                />
+                <label>
+                    <input
+                        type="checkbox"
+                        checked={draft.regex}
+                        onChange={e => setDraft({ ...draft, regex: e.target.checked })}
+                    />
+                    {t('ui.highlight_regex')}
+                </label>
+                <label>
+                    <input
+                        type="checkbox"
+                        checked={draft.notify}
+                        onChange={e => setDraft({ ...draft, notify: e.target.checked })}
+                    />
+                    {t('ui.highlight_notify')}
+                </label>
+                <button type="submit" disabled={!draft.pattern} className="btn-connect">
+                    {t('ui.alias_add')}
+                </button>
+            </form>
+        </div>
+    );
+}
+
+export default Highlights;
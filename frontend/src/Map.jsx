@@ -1,6 +1,7 @@
 import { useState, useEffect, useRef } from 'react';
 import './Map.css';
-import { GetMapData } from "../wailsjs/go/main/App";
+import { ExportMudletMap, GetMapData, GetMapSVG, GetPathTo, ImportMudletMap, SetMapFingerprint, WalkTo } from "../wailsjs/go/main/App";
+import { EventsOn } from "../wailsjs/runtime/runtime";
 
 const CELL_SIZE = 40; // Size of each room cell in pixels
 const GRID_PADDING = 20; // Padding around the map
@@ -9,9 +10,12 @@ function Map({ connected }) {
     const [mapData, setMapData] = useState(null);
     const [selectedRoom, setSelectedRoom] = useState(null);
     const [zLevel, setZLevel] = useState(0);
+    const [selectedPath, setSelectedPath] = useState(null);
+    const [view, setView] = useState('grid'); // 'grid' on the canvas, or 'svg' drawn by the mapper
+    const [svg, setSvg] = useState('');
     const canvasRef = useRef(null);
 
-    // Poll for map data when connected
+    // Fetch map data when connected and whenever the map changes
     useEffect(() => {
         if (!connected) return;
 
@@ -35,11 +39,41 @@ function Map({ connected }) {
         // Initial fetch
         pollMap();
 
-        // Poll every 500ms
-        const interval = setInterval(pollMap, 500);
-        return () => clearInterval(interval);
+        const unsubscribers = [
+            EventsOn("map:updated", pollMap),
+            EventsOn("map:loaded", pollMap),
+            EventsOn("party:members", pollMap),
+        ];
+        return () => unsubscribers.forEach(off => off());
     }, [connected]);
 
+    // Look up the route to the selected room
+    useEffect(() => {
+        if (!selectedRoom || !mapData || selectedRoom.id === mapData.current_room_id) {
+            setSelectedPath(null);
+            return;
+        }
+
+        GetPathTo(selectedRoom.id)
+            .then(path => setSelectedPath(path))
+            .catch(() => setSelectedPath([]));
+    }, [selectedRoom, mapData]);
+
+    // Fetch the SVG drawing whenever what it shows changes
+    useEffect(() => {
+        if (view !== 'svg' || !mapData) return;
+
+        GetMapSVG({
+            level: zLevel,
+            current_room_id: '',
+            selected_room_id: selectedRoom ? selectedRoom.id : '',
+            cell_size: 0,
+            labels: true,
+        })
+            .then(setSvg)
+            .catch(err => console.error("Error drawing map:", err));
+    }, [view, mapData, zLevel, selectedRoom]);
+
     // Render map to canvas
     useEffect(() => {
         if (!mapData || !canvasRef.current) return;
@@ -145,10 +179,38 @@ function Map({ connected }) {
             }
         });
 
+        // Mark where party members are, with their initials
+        (mapData.party || []).forEach(member => {
+            const room = roomsAtLevel.find(r => r.id === member.room_id);
+            if (!room) return;
+
+            const roomX = offsetX + (room.x * CELL_SIZE);
+            const roomY = offsetY - (room.y * CELL_SIZE);
+            ctx.beginPath();
+            ctx.arc(roomX, roomY, 12, 0, 2 * Math.PI);
+            ctx.strokeStyle = '#64b5f6';
+            ctx.lineWidth = 2;
+            ctx.stroke();
+
+            ctx.fillStyle = '#64b5f6';
+            ctx.font = '10px Courier New';
+            ctx.textAlign = 'center';
+            ctx.fillText(member.name.slice(0, 2), roomX, roomY - 16);
+        });
+
         // Reset text alignment
         ctx.textAlign = 'left';
 
-    }, [mapData, zLevel, selectedRoom]);
+    }, [mapData, zLevel, selectedRoom, view]);
+
+    // Handle a click on the SVG drawing, whose rooms carry their IDs
+    const handleSvgClick = (event) => {
+        if (!mapData) return;
+
+        const group = event.target.closest('.room');
+        const room = group && mapData.rooms.find(r => r.id === group.dataset.id);
+        setSelectedRoom(room || null);
+    };
 
     // Handle canvas click
     const handleCanvasClick = (event) => {
@@ -191,6 +253,40 @@ function Map({ connected }) {
                 {mapData && (
                     <div className="map-stats">
                         <span>Rooms: {mapData.total_rooms}</span>
+                        <button
+                            onClick={() => setView(v => v === 'svg' ? 'grid' : 'svg')}
+                            className="z-button"
+                            title="Switch between the grid and the SVG drawing"
+                        >
+                            {view === 'svg' ? 'Grid' : 'SVG'}
+                        </button>
+                        {connected && (
+                            <>
+                                <button
+                                    onClick={() => ImportMudletMap().catch(err => console.error("Error importing Mudlet map:", err))}
+                                    className="z-button"
+                                    title="Add the rooms of a Mudlet JSON map"
+                                >
+                                    Import
+                                </button>
+                                <button
+                                    onClick={() => ExportMudletMap().catch(err => console.error("Error exporting Mudlet map:", err))}
+                                    className="z-button"
+                                    title="Save the map for Mudlet"
+                                >
+                                    Export
+                                </button>
+                            </>
+                        )}
+                        <select
+                            value={mapData.fingerprint}
+                            onChange={e => SetMapFingerprint(e.target.value).catch(err => console.error("Error changing fingerprint:", err))}
+                            className="fingerprint-select"
+                            title="How rooms are told apart; changing it migrates the map"
+                        >
+                            <option value="description">By description</option>
+                            <option value="exits">By exits</option>
+                        </select>
                         <span className="z-level">
                             Level: {zLevel}
                             <button
@@ -213,13 +309,21 @@ function Map({ connected }) {
             </div>
 
             <div className="map-canvas-container">
-                <canvas
-                    ref={canvasRef}
-                    width={600}
-                    height={400}
-                    onClick={handleCanvasClick}
-                    className="map-canvas"
-                />
+                {view === 'svg' ? (
+                    <div
+                        className="map-svg"
+                        onClick={handleSvgClick}
+                        dangerouslySetInnerHTML={{ __html: svg }}
+                    />
+                ) : (
+                    <canvas
+                        ref={canvasRef}
+                        width={600}
+                        height={400}
+                        onClick={handleCanvasClick}
+                        className="map-canvas"
+                    />
+                )}
             </div>
 
             {selectedRoom && (
@@ -236,6 +340,19 @@ function Map({ connected }) {
                             <strong>Exits:</strong> {Object.keys(selectedRoom.exits).join(', ')}
                         </div>
                     )}
+                    {selectedPath && (
+                        <div className="room-path">
+                            <strong>Route:</strong> {selectedPath.length > 0 ? selectedPath.join(', ') : 'no known route'}
+                            {connected && selectedPath.length > 0 && (
+                                <button
+                                    onClick={() => WalkTo(selectedRoom.id).catch(err => console.error("Error walking:", err))}
+                                    className="btn-walk"
+                                >
+                                    Walk here
+                                </button>
+                            )}
+                        </div>
+                    )}
                 </div>
             )}
 
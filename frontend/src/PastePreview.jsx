@@ -0,0 +1,62 @@
+import { useState, useEffect } from 'react';
+import { t } from './i18n.js';
+import { PreviewPaste, SendPaste, GetPasteDelay, SetPasteDelay } from "../wailsjs/go/main/App";
+
+// How many lines the preview lists before summarising the rest
+const PREVIEW_LIMIT = 20;
+
+// Confirms a multi-line paste before it's sent line by line through the
+// send queue, so a pasted note can't flood the server
+function PastePreview({ text, onSent, onCancel }) {
+    const [lines, setLines] = useState([]);
+    const [delay, setDelay] = useState(0);
+    const [error, setError] = useState('');
+
+    useEffect(() => {
+        PreviewPaste(text)
+            .then(list => setLines(list || []))
+            .catch(err => setError(String(err)));
+        GetPasteDelay().then(setDelay);
+    }, [text]);
+
+    const send = async () => {
+        try {
+            await SetPasteDelay(Number(delay) || 0);
+            await SendPaste(text);
+            onSent(lines.length);
+        } catch (err) {
+            setError(String(err));
+        }
+    };
+
+    return (
+        <div className="paste-preview">
+            <div className="paste-preview-header">
+                <span>{t('ui.paste_confirm', { count: lines.length })}</span>
+                <label>
+                    {t('ui.paste_delay')}
+                    <input
+                        type="number"
+                        min="0"
+                        step="50"
+                        value={delay}
+                        onChange={e => setDelay(e.target.value)}
+                    />
+                </label>
+                <button onClick={send} disabled={lines.length === 0} className="btn-connect">{t('ui.send')}</button>
+                <button onClick={onCancel} className="btn-abort">{t('ui.cancel')}</button>
+            </div>
+            {error && <div className="paste-preview-error">❌ {error}</div>}
+            <div className="paste-preview-lines">
+                {lines.slice(0, PREVIEW_LIMIT).map((line, index) => (
+                    <div key={index}>&gt; {line || t('ui.empty_command')}</div>
+                ))}
+                {lines.length > PREVIEW_LIMIT && (
+                    <div className="paste-preview-more">{t('ui.paste_more', { count: lines.length - PREVIEW_LIMIT })}</div>
+                )}
+            </div>
+        </div>
+    );
+}
+
+export default PastePreview;
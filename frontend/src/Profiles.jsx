@@ -0,0 +1,150 @@
+import { useState, useEffect } from 'react';
+import {
+    GetProfiles,
+    SaveProfile,
+    DeleteProfile,
+    SetDefaultProfile,
+    ConnectToProfile,
+    GetParserDialects,
+    GetStyles,
+} from "../wailsjs/go/main/App";
+import { t } from './i18n.js';
+
+const emptyDraft = { name: '', host: '', port: '', character: '', login: '', map: '', dialect: '', style: '' };
+
+// Saved connection profiles: a server and character to connect to, with
+// the parser, login script, image style and map each one uses
+function Profiles({ onConnect, onClose }) {
+    const [profiles, setProfiles] = useState({ default: '', profiles: {} });
+    const [dialects, setDialects] = useState([]);
+    const [styles, setStyles] = useState([]);
+    const [draft, setDraft] = useState(emptyDraft);
+    const [error, setError] = useState('');
+
+    const refresh = () => {
+        GetProfiles()
+            .then(cfg => setProfiles({ default: cfg.default || '', profiles: cfg.profiles || {} }))
+            .catch(err => setError(String(err)));
+    };
+
+    useEffect(() => {
+        refresh();
+        GetParserDialects().then(names => setDialects(names || []));
+        GetStyles().then(settings => setStyles((settings.presets || []).map(preset => preset.name)));
+    }, []);
+
+    const edit = (name) => {
+        setDraft({ ...emptyDraft, ...profiles.profiles[name], name });
+        setError('');
+    };
+
+    const saveProfile = (e) => {
+        e.preventDefault();
+        const { name, ...server } = draft;
+        SaveProfile(name, server)
+            .then(() => {
+                setDraft(emptyDraft);
+                setError('');
+                refresh();
+            })
+            .catch(err => setError(String(err)));
+    };
+
+    const removeProfile = (name) => {
+        DeleteProfile(name)
+            .then(refresh)
+            .catch(err => setError(String(err)));
+    };
+
+    const makeDefault = (name) => {
+        SetDefaultProfile(profiles.default === name ? '' : name)
+            .then(refresh)
+            .catch(err => setError(String(err)));
+    };
+
+    const names = Object.keys(profiles.profiles).sort();
+
+    return (
+        <div className="alias-editor">
+            <div className="alias-editor-header">
+                <span>{t('ui.profiles_title')}</span>
+                <button onClick={onClose} className="btn-abort">{t('ui.close')}</button>
+            </div>
+            <div className="alias-editor-list">
+                {names.map(name => {
+                    const server = profiles.profiles[name];
+                    return (
+                        <div key={name} className="server-entry">
+                            <span className="server-name">{name}</span>
+                            <span className="server-codebase">{server.character}</span>
+                            <span className="server-address">{server.host}:{server.port}</span>
+                            <label>
+                                <input
+                                    type="checkbox"
+                                    checked={profiles.default === name}
+                                    onChange={() => makeDefault(name)}
+                                />
+                                {t('ui.profile_default')}
+                            </label>
+                            <button onClick={() => onConnect(() => ConnectToProfile(name))} className="btn-connect">
+                                {t('ui.server_connect')}
+                            </button>
+                            <button onClick={() => edit(name)} className="btn-debug">
+                                {t('ui.profile_edit')}
+                            </button>
+                            <button onClick={() => removeProfile(name)} className="btn-abort">
+                                {t('ui.alias_remove')}
+                            </button>
+                        </div>
+                    );
+                })}
+            </div>
+            {error && <div className="alias-error">{error}</div>}
+            <form className="alias-editor-add" onSubmit={saveProfile}>
+                <input
+                    placeholder={t('ui.profile_name')}
+                    value={draft.name}
+                    onChange={e => setDraft({ ...draft, name: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.server_host')}
+                    value={draft.host}
+                    onChange={e => setDraft({ ...draft, host: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.server_port')}
+                    value={draft.port}
+                    onChange={e => setDraft({ ...draft, port: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.profile_character')}
+                    value={draft.character}
+                    onChange={e => setDraft({ ...draft, character: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.profile_login')}
+                    value={draft.login}
+                    onChange={e => setDraft({ ...draft, login: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.profile_map')}
+                    value={draft.map}
+                    onChange={e => setDraft({ ...draft, map: e.target.value })}
+                />
+                <select value={draft.dialect} onChange={e => setDraft({ ...draft, dialect: e.target.value })}>
+                    <option value="">{t('ui.profile_dialect_default')}</option>
+                    {dialects.map(name => <option key={name} value={name}>{name}</option>)}
+                </select>
+                <select value={draft.style} onChange={e => setDraft({ ...draft, style: e.target.value })}>
+                    <option value="">{t('ui.profile_style_default')}</option>
+                    {styles.map(name => <option key={name} value={name}>{name}</option>)}
+                </select>
+                <button type="submit" disabled={!draft.name || !draft.host || !draft.port} className="btn-connect">
+                    {t('ui.profile_save')}
+                </button>
+            </form>
+        </div>
+    );
+}
+
+export default Profiles;
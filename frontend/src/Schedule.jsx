@@ -0,0 +1,74 @@
+import { useState, useEffect } from 'react';
+import { GetScheduledCommands, CancelScheduledCommand, CancelAllScheduledCommands } from "../wailsjs/go/main/App";
+import { EventsOn } from "../wailsjs/runtime/runtime";
+import { t } from './i18n.js';
+
+// Commands waiting to be sent by #delay, #repeat or a script, counting down
+// to each and letting the player cancel them
+function Schedule({ onClose }) {
+    const [jobs, setJobs] = useState([]);
+    const [now, setNow] = useState(Date.now());
+
+    const refresh = () => {
+        GetScheduledCommands()
+            .then(list => setJobs(list || []))
+            .catch(err => console.error("Error getting scheduled commands:", err));
+    };
+
+    useEffect(() => {
+        refresh();
+        const timer = setInterval(() => {
+            setNow(Date.now());
+            refresh();
+        }, 1000);
+        const unsubscribe = EventsOn("schedule:fired", refresh);
+        return () => {
+            clearInterval(timer);
+            unsubscribe();
+        };
+    }, []);
+
+    const cancel = (id) => {
+        CancelScheduledCommand(id).then(refresh);
+    };
+
+    const cancelAll = () => {
+        CancelAllScheduledCommands().then(refresh);
+    };
+
+    return (
+        <div className="alias-editor">
+            <div className="alias-editor-header">
+                <span>{t('ui.schedule_title')}</span>
+                <button onClick={onClose} className="btn-abort">{t('ui.close')}</button>
+            </div>
+            <div className="alias-editor-list">
+                {jobs.length === 0 && <div className="alias-entry">{t('ui.schedule_none')}</div>}
+                {jobs.map(job => {
+                    const seconds = Math.max(0, Math.round((new Date(job.due).getTime() - now) / 1000));
+                    return (
+                        <div key={job.id} className="alias-entry">
+                            <span className="alias-name">{job.command}</span>
+                            <span className="alias-commands">
+                                {t('ui.schedule_in', { seconds })}
+                                {job.every > 0 && ` · ${t('ui.schedule_every', { seconds: job.every })}`}
+                                {job.source && ` · ${job.source}`}
+                            </span>
+                            <button onClick={() => cancel(job.id)} className="btn-abort">
+                                {t('ui.schedule_cancel')}
+                            </button>
+                        </div>
+                    );
+                })}
+            </div>
+            <div className="alias-editor-add">
+                <span>{t('ui.schedule_hint')}</span>
+                <button onClick={cancelAll} disabled={jobs.length === 0} className="btn-abort">
+                    {t('ui.schedule_cancel_all')}
+                </button>
+            </div>
+        </div>
+    );
+}
+
+export default Schedule;
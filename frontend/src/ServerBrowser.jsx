@@ -0,0 +1,163 @@
+import { useState, useEffect } from 'react';
+import {
+    GetServerDirectory,
+    BrowseServers,
+    AddDirectoryServer,
+    RemoveDirectoryServer,
+    GetIncludeBundledServers,
+    SetIncludeBundledServers,
+    ConnectToListedServer,
+} from "../wailsjs/go/main/App";
+import { t } from './i18n.js';
+
+// formatUptime shortens seconds to the largest unit or two that matter
+function formatUptime(seconds) {
+    const days = Math.floor(seconds / 86400);
+    const hours = Math.floor((seconds % 86400) / 3600);
+    const minutes = Math.floor((seconds % 3600) / 60);
+    if (days > 0) {
+        return `${days}d ${hours}h`;
+    }
+    if (hours > 0) {
+        return `${hours}h ${minutes}m`;
+    }
+    return `${minutes}m`;
+}
+
+// Directory of MUD servers, probed over MSSP, with one-click connect
+function ServerBrowser({ onConnect, onClose }) {
+    const [servers, setServers] = useState([]);
+    const [probing, setProbing] = useState(false);
+    const [includeBundled, setIncludeBundled] = useState(true);
+    const [draft, setDraft] = useState({ name: '', host: '', port: '' });
+
+    const refresh = () => {
+        GetServerDirectory()
+            .then(list => setServers(list || []))
+            .catch(err => console.error("Error getting server directory:", err));
+    };
+
+    const probe = () => {
+        setProbing(true);
+        BrowseServers()
+            .then(list => setServers(list || []))
+            .catch(err => console.error("Error probing servers:", err))
+            .finally(() => setProbing(false));
+    };
+
+    // Probe on first opening; after that the last results are shown until
+    // asked again
+    useEffect(() => {
+        GetIncludeBundledServers().then(setIncludeBundled);
+        GetServerDirectory()
+            .then(list => {
+                setServers(list || []);
+                if ((list || []).every(server => server.probed.startsWith('0001'))) {
+                    probe();
+                }
+            })
+            .catch(err => console.error("Error getting server directory:", err));
+    }, []);
+
+    const toggleBundled = (include) => {
+        SetIncludeBundledServers(include)
+            .then(() => {
+                setIncludeBundled(include);
+                refresh();
+            })
+            .catch(err => console.error("Error changing bundled servers:", err));
+    };
+
+    const addServer = (e) => {
+        e.preventDefault();
+        AddDirectoryServer(draft)
+            .then(() => {
+                setDraft({ name: '', host: '', port: '' });
+                refresh();
+            })
+            .catch(err => console.error("Error adding server:", err));
+    };
+
+    const removeServer = (server) => {
+        RemoveDirectoryServer(server.host, server.port)
+            .then(refresh)
+            .catch(err => console.error("Error removing server:", err));
+    };
+
+    const status = (server) => {
+        if (server.probed.startsWith('0001')) {
+            return t('ui.server_not_probed');
+        }
+        if (!server.reachable) {
+            return t('ui.server_unreachable');
+        }
+        if (server.error) {
+            return server.error;
+        }
+        const parts = [t('ui.server_players', { count: server.players })];
+        if (server.uptime > 0) {
+            parts.push(t('ui.server_uptime', { duration: formatUptime(server.uptime) }));
+        }
+        return parts.join(' · ');
+    };
+
+    return (
+        <div className="server-browser">
+            <div className="server-browser-header">
+                <span>{t('ui.servers_title')}</span>
+                <label>
+                    <input
+                        type="checkbox"
+                        checked={includeBundled}
+                        onChange={e => toggleBundled(e.target.checked)}
+                    />
+                    {t('ui.servers_bundled')}
+                </label>
+                <button onClick={probe} disabled={probing} className="btn-connect">
+                    {probing ? t('ui.servers_probing') : t('ui.servers_probe')}
+                </button>
+                <button onClick={onClose} className="btn-abort">{t('ui.close')}</button>
+            </div>
+            <div className="server-browser-list">
+                {servers.map(server => (
+                    <div key={`${server.host}:${server.port}`} className="server-entry">
+                        <span className="server-name">{server.name}</span>
+                        <span className="server-codebase">{server.codebase}</span>
+                        <span className="server-address">{server.host}:{server.port}</span>
+                        <span className="server-status">{status(server)}</span>
+                        <button onClick={() => onConnect(() => ConnectToListedServer(server.host, server.port))} className="btn-connect">
+                            {t('ui.server_connect')}
+                        </button>
+                        {!server.bundled && (
+                            <button onClick={() => removeServer(server)} className="btn-abort">
+                                {t('ui.server_remove')}
+                            </button>
+                        )}
+                    </div>
+                ))}
+            </div>
+            <form className="server-browser-add" onSubmit={addServer}>
+                <input
+                    placeholder={t('ui.server_name')}
+                    value={draft.name}
+                    onChange={e => setDraft({ ...draft, name: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.server_host')}
+                    value={draft.host}
+                    onChange={e => setDraft({ ...draft, host: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.server_port')}
+                    value={draft.port}
+                    onChange={e => setDraft({ ...draft, port: e.target.value })}
+                />
+                <button type="submit" disabled={!draft.host || !draft.port} className="btn-connect">
+                    {t('ui.server_add')}
+                </button>
+            </form>
+        </div>
+    );
+}
+
+export default ServerBrowser;
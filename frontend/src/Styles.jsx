@@ -0,0 +1,107 @@
+import { useState, useEffect } from 'react';
+import { GetStyles, SetSessionStyle, SetZoneStyle, SaveStyle, DeleteStyle } from "../wailsjs/go/main/App";
+import { t } from './i18n.js';
+
+// Style preset editor: the looks room images are drawn in, chosen for the
+// session or for the zone the player is in
+function Styles({ onClose }) {
+    const [styles, setStyles] = useState({ presets: [], session: '', zone: '', zone_style: '', active: '' });
+    const [draft, setDraft] = useState({ name: '', suffix: '', negative_prompt: '' });
+    const [error, setError] = useState('');
+
+    const refresh = () => {
+        GetStyles()
+            .then(settings => setStyles({ ...settings, presets: settings.presets || [] }))
+            .catch(err => console.error("Error getting styles:", err));
+    };
+
+    useEffect(refresh, []);
+
+    const choose = (set, name) => {
+        set(name)
+            .then(() => {
+                setError('');
+                refresh();
+            })
+            .catch(err => setError(String(err)));
+    };
+
+    const addStyle = (e) => {
+        e.preventDefault();
+        SaveStyle(draft)
+            .then(() => {
+                setDraft({ name: '', suffix: '', negative_prompt: '' });
+                setError('');
+                refresh();
+            })
+            .catch(err => setError(String(err)));
+    };
+
+    const removeStyle = (preset) => {
+        DeleteStyle(preset.name)
+            .then(refresh)
+            .catch(err => console.error("Error removing style:", err));
+    };
+
+    return (
+        <div className="alias-editor">
+            <div className="alias-editor-header">
+                <span>{t('ui.styles_title')}</span>
+                <button onClick={onClose} className="btn-abort">{t('ui.close')}</button>
+            </div>
+            <div className="style-choice">
+                <label>
+                    {t('ui.style_session')}
+                    <select value={styles.session} onChange={e => choose(SetSessionStyle, e.target.value)}>
+                        {styles.presets.map(preset => <option key={preset.name} value={preset.name}>{preset.name}</option>)}
+                    </select>
+                </label>
+                {styles.zone && (
+                    <label>
+                        {t('ui.style_zone', { zone: styles.zone })}
+                        <select value={styles.zone_style || ''} onChange={e => choose(SetZoneStyle, e.target.value)}>
+                            <option value="">{t('ui.style_zone_none')}</option>
+                            {styles.presets.map(preset => <option key={preset.name} value={preset.name}>{preset.name}</option>)}
+                        </select>
+                    </label>
+                )}
+            </div>
+            <div className="alias-editor-list">
+                {styles.presets.map(preset => (
+                    <div key={preset.name} className="alias-entry">
+                        <span className="alias-name">{preset.name === styles.active ? `▶ ${preset.name}` : preset.name}</span>
+                        <span className="alias-commands">{preset.suffix}</span>
+                        {!preset.built_in && (
+                            <button onClick={() => removeStyle(preset)} className="btn-abort">
+                                {t('ui.alias_remove')}
+                            </button>
+                        )}
+                    </div>
+                ))}
+            </div>
+            {error && <div className="alias-error">{error}</div>}
+            <form className="alias-editor-add" onSubmit={addStyle}>
+                <input
+                    placeholder={t('ui.style_name')}
+                    value={draft.name}
+                    onChange={e => setDraft({ ...draft, name: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.style_suffix')}
+                    value={draft.suffix}
+                    onChange={e => setDraft({ ...draft, suffix: e.target.value })}
+                />
+                <input
+                    placeholder={t('ui.style_negative')}
+                    value={draft.negative_prompt}
+                    onChange={e => setDraft({ ...draft, negative_prompt: e.target.value })}
+                />
+                <button type="submit" disabled={!draft.name || !draft.suffix} className="btn-connect">
+                    {t('ui.alias_add')}
+                </button>
+            </form>
+        </div>
+    );
+}
+
+export default Styles;
@@ -0,0 +1,97 @@
+import { useState, useEffect } from 'react';
+import { GetTick, SyncTick, GetTickSettings, SetTickSettings } from "../wailsjs/go/main/App";
+import { EventsOn } from "../wailsjs/runtime/runtime";
+import { t } from './i18n.js';
+
+// Countdown to the server's next tick, with the tick's length and message
+// one click away
+function Tick({ connected }) {
+    const [status, setStatus] = useState(null);
+    const [now, setNow] = useState(Date.now());
+    const [editing, setEditing] = useState(false);
+    const [draft, setDraft] = useState({ seconds: '', pattern: '' });
+    const [error, setError] = useState('');
+
+    useEffect(() => {
+        if (!connected) {
+            setStatus(null);
+            setEditing(false);
+            return;
+        }
+
+        GetTick()
+            .then(setStatus)
+            .catch(err => console.error("Error getting tick:", err));
+
+        return EventsOn("tick:changed", setStatus);
+    }, [connected]);
+
+    // Tick locally rather than polling the backend every second
+    useEffect(() => {
+        if (!status || !status.seconds) return;
+        const interval = setInterval(() => setNow(Date.now()), 1000);
+        return () => clearInterval(interval);
+    }, [status]);
+
+    const openSettings = () => {
+        GetTickSettings().then(settings => {
+            setDraft({ seconds: settings.seconds ? String(settings.seconds) : '', pattern: settings.pattern || '' });
+            setError('');
+            setEditing(true);
+        });
+    };
+
+    const saveSettings = (e) => {
+        e.preventDefault();
+        SetTickSettings({ seconds: parseInt(draft.seconds, 10) || 0, pattern: draft.pattern })
+            .then(() => setEditing(false))
+            .catch(err => setError(String(err)));
+    };
+
+    if (!connected || !status) return null;
+
+    let label = t('ui.tick_unknown');
+    if (status.seconds && status.count > 0) {
+        const remaining = Math.max(0, Math.ceil((new Date(status.next) - now) / 1000));
+        label = t('ui.tick_in', { seconds: remaining });
+    }
+
+    return (
+        <>
+            <span
+                className={`cooldown-badge ${status.synced ? 'cooldown-duration' : 'cooldown-cooldown'}`}
+                onClick={openSettings}
+                title={t('ui.tick_title')}
+            >
+                {label}
+            </span>
+            {editing && (
+                <div className="alias-editor">
+                    <div className="alias-editor-header">
+                        <span>{t('ui.tick_title')}</span>
+                        <button onClick={() => setEditing(false)} className="btn-abort">{t('ui.close')}</button>
+                    </div>
+                    {error && <div className="alias-error">{error}</div>}
+                    <form className="alias-editor-add" onSubmit={saveSettings}>
+                        <input
+                            placeholder={t('ui.tick_seconds')}
+                            value={draft.seconds}
+                            onChange={e => setDraft({ ...draft, seconds: e.target.value })}
+                        />
+                        <input
+                            placeholder={t('ui.tick_pattern')}
+                            value={draft.pattern}
+                            onChange={e => setDraft({ ...draft, pattern: e.target.value })}
+                        />
+                        <button type="submit" className="btn-connect">{t('ui.profile_save')}</button>
+                        <button type="button" onClick={() => SyncTick()} className="btn-debug">
+                            {t('ui.tick_now')}
+                        </button>
+                    </form>
+                </div>
+            )}
+        </>
+    );
+}
+
+export default Tick;
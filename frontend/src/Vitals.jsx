@@ -0,0 +1,120 @@
+import { useState, useEffect } from 'react';
+import { GetVitals, GetVitalsHistory, GetVitalsSettings, SetVitalsSettings } from "../wailsjs/go/main/App";
+import { EventsOn } from "../wailsjs/runtime/runtime";
+import { t } from './i18n.js';
+
+// Minutes of history the graph shows
+const graphMinutes = 10;
+
+const stats = [
+    { key: 'hp', max: 'max_hp', label: 'ui.vitals_hp', colour: '#e94560' },
+    { key: 'mp', max: 'max_mp', label: 'ui.vitals_mp', colour: '#4a90d9' },
+    { key: 'stamina', max: 'max_stamina', label: 'ui.vitals_stamina', colour: '#4caf50' },
+];
+
+// fraction is how full a stat is, or null if the prompt hasn't shown its max
+const fraction = (reading, stat) => reading[stat.max] ? Math.min(1, reading[stat.key] / reading[stat.max]) : null;
+
+// Health, mana and stamina bars read from the prompt, opening a graph of
+// the last few minutes and the prompt pattern
+function Vitals({ connected }) {
+    const [current, setCurrent] = useState(null);
+    const [history, setHistory] = useState([]);
+    const [open, setOpen] = useState(false);
+    const [pattern, setPattern] = useState('');
+    const [error, setError] = useState('');
+
+    useEffect(() => {
+        if (!connected) {
+            setCurrent(null);
+            setOpen(false);
+            return;
+        }
+
+        GetVitals()
+            .then(setCurrent)
+            .catch(err => console.error("Error getting vitals:", err));
+
+        return EventsOn("vitals:changed", reading => {
+            setCurrent(reading);
+            setHistory(prev => [...prev, reading]);
+        });
+    }, [connected]);
+
+    const openGraph = () => {
+        GetVitalsHistory(graphMinutes).then(list => setHistory(list || []));
+        GetVitalsSettings().then(settings => setPattern(settings.pattern || ''));
+        setError('');
+        setOpen(true);
+    };
+
+    const savePattern = (e) => {
+        e.preventDefault();
+        SetVitalsSettings({ pattern })
+            .then(() => setError(''))
+            .catch(err => setError(String(err)));
+    };
+
+    // Readings hold until the next, so each line steps across the graph
+    const graph = (stat) => {
+        const start = Date.now() - graphMinutes * 60 * 1000;
+        const points = [];
+        let previous = null;
+        for (const reading of history) {
+            const value = fraction(reading, stat);
+            if (value === null) continue;
+            const x = Math.max(0, (new Date(reading.time) - start) / (graphMinutes * 60 * 10));
+            const y = 100 - value * 100;
+            if (previous !== null) points.push(`${x},${previous}`);
+            points.push(`${x},${y}`);
+            previous = y;
+        }
+        if (previous !== null) points.push(`100,${previous}`);
+        return <polyline key={stat.key} points={points.join(' ')} fill="none" stroke={stat.colour} strokeWidth="1" />;
+    };
+
+    if (!connected || !current) return null;
+
+    return (
+        <>
+            <div className="vitals" onClick={openGraph} title={t('ui.vitals_title')}>
+                {stats.filter(stat => current[stat.max] || current[stat.key]).map(stat => {
+                    const full = fraction(current, stat);
+                    return (
+                        <div key={stat.key} className="vitals-bar">
+                            <div
+                                className="vitals-fill"
+                                style={{ width: `${full === null ? 100 : full * 100}%`, background: stat.colour }}
+                            />
+                            <span>
+                                {t(stat.label)} {current[stat.key]}{current[stat.max] ? `/${current[stat.max]}` : ''}
+                            </span>
+                        </div>
+                    );
+                })}
+            </div>
+            {open && (
+                <div className="alias-editor">
+                    <div className="alias-editor-header">
+                        <span>{t('ui.vitals_title')}</span>
+                        <button onClick={() => setOpen(false)} className="btn-abort">{t('ui.close')}</button>
+                    </div>
+                    <svg className="vitals-graph" viewBox="0 0 100 100" preserveAspectRatio="none">
+                        {stats.map(graph)}
+                    </svg>
+                    {error && <div className="alias-error">{error}</div>}
+                    <form className="alias-editor-add" onSubmit={savePattern}>
+                        <input
+                            placeholder={t('ui.vitals_pattern')}
+                            value={pattern}
+                            onChange={e => setPattern(e.target.value)}
+                        />
+                        <button type="submit" className="btn-connect">{t('ui.profile_save')}</button>
+                    </form>
+                </div>
+            )}
+        </>
+    );
+}
+
+export default Vitals;
@@ -156,4 +156,26 @@ export function AnsiText({ children }) {
             dangerouslySetInnerHTML={{ __html: htmlContent }}
         />
     );
-}
\ No newline at end of file
+}
+// Renders the colour spans the backend derives from a line's ANSI codes,
+// so nothing needs parsing or injecting as HTML
+export function SpanText({ spans }) {
+    return (
+        <span className="ansi-container">
+            {(spans || []).map((span, index) => (
+                <span
+                    key={index}
+                    style={{
+                        color: span.fg,
+                        backgroundColor: span.bg,
+                        fontWeight: span.bold ? 'bold' : undefined,
+                        fontStyle: span.italic ? 'italic' : undefined,
+                        textDecoration: span.underline ? 'underline' : undefined,
+                    }}
+                >
+                    {span.text}
+                </span>
+            ))}
+        </span>
+    );
+}
@@ -0,0 +1,30 @@
+import { useState, useEffect } from 'react';
+import { GetMessages } from "../wailsjs/go/main/App";
+import { EventsOn } from "../wailsjs/runtime/runtime";
+
+// Messages for the current locale, loaded from the backend's locale files
+let messages = {};
+
+// t translates a key, replacing {name} placeholders from args. Unknown keys
+// come back as the key so a missing translation is easy to spot.
+export function t(key, args = {}) {
+    let message = messages[key] ?? key;
+    for (const [name, value] of Object.entries(args)) {
+        message = message.replaceAll(`{${name}}`, String(value));
+    }
+    return message;
+}
+
+// useMessages loads the messages and re-renders when the locale changes
+export function useMessages() {
+    const [, setVersion] = useState(0);
+
+    useEffect(() => {
+        const update = (next) => {
+            messages = next || {};
+            setVersion(v => v + 1);
+        };
+        GetMessages().then(update).catch(err => console.error("Error loading messages:", err));
+        return EventsOn("locale:changed", update);
+    }, []);
+}
@@ -1,34 +1,453 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
+import {trigger} from '../models';
+import {mssp} from '../models';
+import {engine} from '../models';
+import {chat} from '../models';
+import {combat} from '../models';
+import {statedb} from '../models';
+import {renderer} from '../models';
+import {cooldown} from '../models';
+import {parser} from '../models';
+import {friends} from '../models';
+import {highlight} from '../models';
+import {idle} from '../models';
+import {imagequeue} from '../models';
+import {inventory} from '../models';
+import {cloudsync} from '../models';
+import {mapexport} from '../models';
+import {output} from '../models';
+import {timeline} from '../models';
+import {telnet} from '../models';
+import {mapper} from '../models';
+import {metrics} from '../models';
+import {notify} from '../models';
+import {party} from '../models';
+import {main} from '../models';
+import {profile} from '../models';
+import {pacing} from '../models';
+import {logging} from '../models';
+import {remote} from '../models';
+import {schedule} from '../models';
+import {scripting} from '../models';
+import {history} from '../models';
+import {stats} from '../models';
+import {sound} from '../models';
+import {speech} from '../models';
+import {speedwalk} from '../models';
+import {tick} from '../models';
+import {vault} from '../models';
+import {vitals} from '../models';
+import {artpack} from '../models';
+
+export function AbortSpeedwalk():Promise<boolean>;
+
+export function AddAlias(arg1:string,arg2:string):Promise<trigger.Alias>;
+
+export function AddDirectoryServer(arg1:mssp.Entry):Promise<void>;
+
+export function AddFriend(arg1:string):Promise<void>;
+
+export function AutoGenerateRoomImage():Promise<string>;
+
+export function BrowseServers():Promise<Array<mssp.Info>>;
+
+export function CancelAllScheduledCommands():Promise<number>;
+
+export function CancelImageGeneration():Promise<number>;
+
+export function CancelPending():Promise<number>;
+
+export function CancelScheduledCommand(arg1:number):Promise<boolean>;
+
+export function ChangeVaultPassphrase(arg1:string):Promise<void>;
 
 export function CheckSDStatus():Promise<boolean>;
 
+export function ChooseFileToSend():Promise<number>;
+
+export function ClearCache():Promise<number>;
+
+export function ClearCommandHistory():Promise<void>;
+
+export function Complete(arg1:string):Promise<Array<string>>;
+
+export function ConnectToListedServer(arg1:string,arg2:string):Promise<void>;
+
 export function ConnectToMUD(arg1:string,arg2:string):Promise<void>;
 
+export function ConnectToProfile(arg1:string):Promise<void>;
+
+export function CopyTranscript(arg1:number,arg2:number,arg3:string):Promise<void>;
+
+export function DeleteAlias(arg1:string):Promise<void>;
+
+export function DeleteCooldown(arg1:string):Promise<void>;
+
+export function DeleteHighlight(arg1:string):Promise<void>;
+
+export function DeleteProfile(arg1:string):Promise<void>;
+
+export function DeleteSecret(arg1:string):Promise<void>;
+
+export function DeleteSpeedwalk(arg1:string):Promise<void>;
+
+export function DeleteStyle(arg1:string):Promise<void>;
+
+export function DeleteTrigger(arg1:string):Promise<void>;
+
 export function DisconnectFromMUD():Promise<void>;
 
+export function ExportArtPack():Promise<string>;
+
+export function ExportMudletMap():Promise<string>;
+
+export function ExportTranscript(arg1:number,arg2:number,arg3:string):Promise<string>;
+
+export function FindDuplicateImages():Promise<Array<engine.DuplicateGroup>>;
+
 export function GenerateRoomImage():Promise<string>;
 
+export function GetAliases():Promise<Array<trigger.Alias>>;
+
+export function GetCacheStats():Promise<engine.CacheStats>;
+
+export function GetChatChannels():Promise<Array<string>>;
+
+export function GetChatMessages(arg1:string,arg2:number):Promise<Array<chat.Message>>;
+
+export function GetCombatSummary():Promise<combat.Summary>;
+
+export function GetCommandHistory(arg1:number):Promise<Array<statedb.HistoryEntry>>;
+
+export function GetCondenserSettings():Promise<renderer.CondenserSettings>;
+
+export function GetConnectionState():Promise<string>;
+
 export function GetConnectionStatus():Promise<boolean>;
 
+export function GetCooldownTimers():Promise<Array<cooldown.Timer>>;
+
+export function GetCooldowns():Promise<Array<cooldown.Definition>>;
+
 export function GetCurrentEntities():Promise<Record<string, Array<string>>>;
 
 export function GetCurrentRoom():Promise<Record<string, string>>;
 
+export function GetDataDir():Promise<string>;
+
+export function GetExtractorSettings():Promise<parser.ExtractorSettings>;
+
+export function GetFriendSettings():Promise<friends.Settings>;
+
+export function GetFriends():Promise<Array<friends.Friend>>;
+
+export function GetHighlights():Promise<Array<highlight.Watch>>;
+
+export function GetIdleSettings():Promise<idle.Settings>;
+
+export function GetIdleStatus():Promise<idle.Status>;
+
+export function GetImageBackend():Promise<string>;
+
+export function GetImageEncoding():Promise<engine.ImageEncoding>;
+
+export function GetImageQueue():Promise<Array<imagequeue.Update>>;
+
+export function GetIncludeBundledServers():Promise<boolean>;
+
+export function GetInventory():Promise<inventory.Snapshot>;
+
+export function GetLastCacheScan():Promise<engine.ScanReport>;
+
+export function GetLastSync():Promise<cloudsync.Result>;
+
+export function GetLayeredMap():Promise<mapexport.Layered>;
+
+export function GetLinesSince(arg1:number):Promise<output.Frame>;
+
+export function GetLocale():Promise<string>;
+
+export function GetLocales():Promise<Array<string>>;
+
+export function GetLocationAt(arg1:number):Promise<timeline.Visit>;
+
+export function GetLogLevel():Promise<string>;
+
+export function GetLogLevels():Promise<Array<string>>;
+
+export function GetMSDP():Promise<telnet.MSDPStatus>;
+
 export function GetMapData():Promise<Record<string, any>>;
 
+export function GetMapSVG(arg1:mapper.SVGOptions):Promise<string>;
+
 export function GetMapStats():Promise<Record<string, any>>;
 
+export function GetMessages():Promise<Record<string, string>>;
+
+export function GetMetricsSettings():Promise<metrics.Settings>;
+
+export function GetMinimap(arg1:number):Promise<mapper.Minimap>;
+
+export function GetMovementCommand(arg1:string):Promise<string>;
+
+export function GetMovementDialect():Promise<mapper.Dialect>;
+
+export function GetMovementDialects():Promise<Array<string>>;
+
+export function GetNotedRoomsWithoutImages():Promise<Array<statedb.RoomSummary>>;
+
+export function GetNotificationSettings():Promise<notify.Settings>;
+
 export function GetOutput():Promise<Array<string>>;
 
+export function GetOutputSince(arg1:number):Promise<output.Batch>;
+
+export function GetParsedOutput():Promise<Array<output.Event>>;
+
+export function GetParserDefinitions():Promise<Array<parser.Definition>>;
+
+export function GetParserDialects():Promise<Array<string>>;
+
+export function GetPartySettings():Promise<party.Settings>;
+
+export function GetPartyStatus():Promise<main.PartyStatus>;
+
+export function GetPasteDelay():Promise<number>;
+
+export function GetPathTo(arg1:string):Promise<Array<string>>;
+
+export function GetProfiles():Promise<profile.Config>;
+
+export function GetQueue():Promise<Array<pacing.Pending>>;
+
+export function GetQueueDelay():Promise<number>;
+
+export function GetRecentCommands(arg1:number):Promise<Array<string>>;
+
+export function GetRecentLogs(arg1:number,arg2:string):Promise<Array<logging.Record>>;
+
+export function GetRemoteSettings():Promise<remote.Settings>;
+
+export function GetRemoteStatus():Promise<main.RemoteStatus>;
+
 export function GetRoomImage():Promise<string>;
 
+export function GetRoomImageInfo():Promise<main.RoomImageInfo>;
+
+export function GetRoomPrompt():Promise<string>;
+
+export function GetScheduledCommands():Promise<Array<schedule.Job>>;
+
+export function GetScripts():Promise<Array<scripting.Info>>;
+
+export function GetServerDirectory():Promise<Array<mssp.Info>>;
+
+export function GetSessionCommands():Promise<Array<history.Entry>>;
+
+export function GetSessionHistory(arg1:number):Promise<Array<stats.Stats>>;
+
+export function GetSessionStats():Promise<stats.Stats>;
+
+export function GetSoundSettings():Promise<sound.Settings>;
+
+export function GetSpeechSettings():Promise<speech.Settings>;
+
+export function GetSpeechTemplates():Promise<Record<string, string>>;
+
+export function GetSpeedwalks():Promise<Array<speedwalk.Route>>;
+
+export function GetStyles():Promise<main.StyleSettings>;
+
+export function GetSyncSettings():Promise<cloudsync.Settings>;
+
+export function GetTick():Promise<tick.Status>;
+
+export function GetTickSettings():Promise<tick.Settings>;
+
+export function GetTimeline(arg1:number,arg2:number):Promise<Array<timeline.Visit>>;
+
+export function GetTriggerHooks():Promise<Array<trigger.Hook>>;
+
+export function GetTriggers():Promise<Array<trigger.Trigger>>;
+
+export function GetVariationStrength():Promise<number>;
+
+export function GetVaultStatus():Promise<vault.Status>;
+
+export function GetVitals():Promise<vitals.Vitals>;
+
+export function GetVitalsHistory(arg1:number):Promise<Array<vitals.Vitals>>;
+
+export function GetVitalsSettings():Promise<vitals.Settings>;
+
 export function Greet(arg1:string):Promise<string>;
 
+export function ImportArtPack():Promise<artpack.Result>;
+
+export function ImportArtPackFile(arg1:string):Promise<artpack.Result>;
+
+export function ImportMudletMap():Promise<number>;
+
+export function ImportMudletMapFile(arg1:string):Promise<number>;
+
+export function ImportTriggerFile(arg1:string):Promise<trigger.ImportResult>;
+
+export function ImportTriggerPackage():Promise<trigger.ImportResult>;
+
+export function LoadMap():Promise<void>;
+
+export function LockVault():Promise<void>;
+
+export function Move(arg1:string):Promise<void>;
+
+export function PreviewPaste(arg1:string):Promise<Array<string>>;
+
+export function PreviewSoundCue(arg1:string):Promise<void>;
+
+export function RecodeCache():Promise<number>;
+
+export function ReconnectToMUD():Promise<void>;
+
 export function RegenerateRoomImage():Promise<string>;
 
 export function RegenerateRoomImageWithPrompt(arg1:string):Promise<string>;
 
+export function RegenerateRoomImageWithSeed(arg1:string):Promise<string>;
+
+export function ReloadScripts():Promise<Array<scripting.Info>>;
+
+export function RemoveDirectoryServer(arg1:string,arg2:string):Promise<void>;
+
+export function RemoveFriend(arg1:string):Promise<void>;
+
+export function ReportMSDP(arg1:Array<string>):Promise<void>;
+
+export function RunSpeedwalk(arg1:string):Promise<void>;
+
+export function RunSpeedwalkKey(arg1:string):Promise<boolean>;
+
+export function SaveMap():Promise<void>;
+
 export function SaveMapNow():Promise<void>;
 
+export function SaveProfile(arg1:string,arg2:profile.Profile):Promise<void>;
+
+export function SaveStyle(arg1:renderer.StylePreset):Promise<void>;
+
+export function SaveTranscript(arg1:number,arg2:number,arg3:string):Promise<string>;
+
+export function ScanCache():Promise<engine.ScanReport>;
+
+export function SearchCommands(arg1:string,arg2:number):Promise<Array<string>>;
+
 export function SendCommand(arg1:string):Promise<void>;
+
+export function SendFile(arg1:string):Promise<number>;
+
+export function SendMSDP(arg1:Array<string>):Promise<void>;
+
+export function SendMUDPassword(arg1:string):Promise<void>;
+
+export function SendPaste(arg1:string):Promise<number>;
+
+export function SetAlias(arg1:trigger.Alias):Promise<void>;
+
+export function SetCacheLimits(arg1:engine.CacheLimits):Promise<void>;
+
+export function SetCondenserSettings(arg1:renderer.CondenserSettings):Promise<void>;
+
+export function SetCooldown(arg1:cooldown.Definition):Promise<void>;
+
+export function SetDataDir(arg1:string):Promise<void>;
+
+export function SetDefaultProfile(arg1:string):Promise<void>;
+
+export function SetExtractorSettings(arg1:parser.ExtractorSettings):Promise<void>;
+
+export function SetFriendSettings(arg1:friends.Settings):Promise<void>;
+
+export function SetHighlight(arg1:highlight.Watch):Promise<void>;
+
+export function SetIdleSettings(arg1:idle.Settings):Promise<void>;
+
+export function SetImageEncoding(arg1:engine.ImageEncoding):Promise<void>;
+
+export function SetIncludeBundledServers(arg1:boolean):Promise<void>;
+
+export function SetInventoryCapacity(arg1:number):Promise<void>;
+
+export function SetItemWeight(arg1:string,arg2:number):Promise<void>;
+
+export function SetLocale(arg1:string):Promise<void>;
+
+export function SetLogLevel(arg1:string):Promise<void>;
+
+export function SetMUDPassword(arg1:string,arg2:string):Promise<void>;
+
+export function SetMapFingerprint(arg1:string):Promise<number>;
+
+export function SetMetricsSettings(arg1:metrics.Settings):Promise<metrics.Settings>;
+
+export function SetMovementDialect(arg1:string):Promise<void>;
+
+export function SetNotificationSettings(arg1:notify.Settings):Promise<void>;
+
+export function SetPartySettings(arg1:party.Settings):Promise<party.Settings>;
+
+export function SetPasteDelay(arg1:number):Promise<void>;
+
+export function SetQueueDelay(arg1:number):Promise<void>;
+
+export function SetRemoteSettings(arg1:remote.Settings):Promise<remote.Settings>;
+
+export function SetRoomPrompt(arg1:string):Promise<void>;
+
+export function SetSecret(arg1:string,arg2:string):Promise<void>;
+
+export function SetSessionStyle(arg1:string):Promise<void>;
+
+export function SetSoundCue(arg1:string,arg2:sound.Cue):Promise<void>;
+
+export function SetSoundSettings(arg1:sound.Settings):Promise<void>;
+
+export function SetSoundsMuted(arg1:boolean):Promise<void>;
+
+export function SetSpeechSettings(arg1:speech.Settings):Promise<void>;
+
+export function SetSpeedwalk(arg1:speedwalk.Route):Promise<void>;
+
+export function SetSyncSettings(arg1:cloudsync.Settings):Promise<void>;
+
+export function SetTickSettings(arg1:tick.Settings):Promise<void>;
+
+export function SetTrigger(arg1:trigger.Trigger):Promise<void>;
+
+export function SetTriggerEnabled(arg1:string,arg2:boolean):Promise<void>;
+
+export function SetVariationStrength(arg1:number):Promise<void>;
+
+export function SetVitalsSettings(arg1:vitals.Settings):Promise<void>;
+
+export function SetWindowFocused(arg1:boolean):Promise<void>;
+
+export function SetZoneStyle(arg1:string):Promise<void>;
+
+export function ShareDuplicateImage(arg1:string,arg2:string):Promise<void>;
+
+export function SpeakText(arg1:string):Promise<void>;
+
+export function StopSpeech():Promise<void>;
+
+export function SyncNow():Promise<cloudsync.Result>;
+
+export function SyncTick():Promise<void>;
+
+export function UnlockVault(arg1:string):Promise<void>;
+
+export function UnlockVaultWithKeychain():Promise<void>;
+
+export function ValidateParserDefinition(arg1:string):Promise<Array<string>>;
+
+export function WalkTo(arg1:string):Promise<void>;
@@ -2,26 +2,186 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function AbortSpeedwalk() {
+  return window['go']['main']['App']['AbortSpeedwalk']();
+}
+
+export function AddAlias(arg1, arg2) {
+  return window['go']['main']['App']['AddAlias'](arg1, arg2);
+}
+
+export function AddDirectoryServer(arg1) {
+  return window['go']['main']['App']['AddDirectoryServer'](arg1);
+}
+
+export function AddFriend(arg1) {
+  return window['go']['main']['App']['AddFriend'](arg1);
+}
+
+export function AutoGenerateRoomImage() {
+  return window['go']['main']['App']['AutoGenerateRoomImage']();
+}
+
+export function BrowseServers() {
+  return window['go']['main']['App']['BrowseServers']();
+}
+
+export function CancelAllScheduledCommands() {
+  return window['go']['main']['App']['CancelAllScheduledCommands']();
+}
+
+export function CancelImageGeneration() {
+  return window['go']['main']['App']['CancelImageGeneration']();
+}
+
+export function CancelPending() {
+  return window['go']['main']['App']['CancelPending']();
+}
+
+export function CancelScheduledCommand(arg1) {
+  return window['go']['main']['App']['CancelScheduledCommand'](arg1);
+}
+
+export function ChangeVaultPassphrase(arg1) {
+  return window['go']['main']['App']['ChangeVaultPassphrase'](arg1);
+}
+
 export function CheckSDStatus() {
   return window['go']['main']['App']['CheckSDStatus']();
 }
 
+export function ChooseFileToSend() {
+  return window['go']['main']['App']['ChooseFileToSend']();
+}
+
+export function ClearCache() {
+  return window['go']['main']['App']['ClearCache']();
+}
+
+export function ClearCommandHistory() {
+  return window['go']['main']['App']['ClearCommandHistory']();
+}
+
+export function Complete(arg1) {
+  return window['go']['main']['App']['Complete'](arg1);
+}
+
+export function ConnectToListedServer(arg1, arg2) {
+  return window['go']['main']['App']['ConnectToListedServer'](arg1, arg2);
+}
+
 export function ConnectToMUD(arg1, arg2) {
   return window['go']['main']['App']['ConnectToMUD'](arg1, arg2);
 }
 
+export function ConnectToProfile(arg1) {
+  return window['go']['main']['App']['ConnectToProfile'](arg1);
+}
+
+export function CopyTranscript(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CopyTranscript'](arg1, arg2, arg3);
+}
+
+export function DeleteAlias(arg1) {
+  return window['go']['main']['App']['DeleteAlias'](arg1);
+}
+
+export function DeleteCooldown(arg1) {
+  return window['go']['main']['App']['DeleteCooldown'](arg1);
+}
+
+export function DeleteHighlight(arg1) {
+  return window['go']['main']['App']['DeleteHighlight'](arg1);
+}
+
+export function DeleteProfile(arg1) {
+  return window['go']['main']['App']['DeleteProfile'](arg1);
+}
+
+export function DeleteSecret(arg1) {
+  return window['go']['main']['App']['DeleteSecret'](arg1);
+}
+
+export function DeleteSpeedwalk(arg1) {
+  return window['go']['main']['App']['DeleteSpeedwalk'](arg1);
+}
+
+export function DeleteStyle(arg1) {
+  return window['go']['main']['App']['DeleteStyle'](arg1);
+}
+
+export function DeleteTrigger(arg1) {
+  return window['go']['main']['App']['DeleteTrigger'](arg1);
+}
+
 export function DisconnectFromMUD() {
   return window['go']['main']['App']['DisconnectFromMUD']();
 }
 
+export function ExportArtPack() {
+  return window['go']['main']['App']['ExportArtPack']();
+}
+
+export function ExportMudletMap() {
+  return window['go']['main']['App']['ExportMudletMap']();
+}
+
+export function ExportTranscript(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportTranscript'](arg1, arg2, arg3);
+}
+
+export function FindDuplicateImages() {
+  return window['go']['main']['App']['FindDuplicateImages']();
+}
+
 export function GenerateRoomImage() {
   return window['go']['main']['App']['GenerateRoomImage']();
 }
 
+export function GetAliases() {
+  return window['go']['main']['App']['GetAliases']();
+}
+
+export function GetCacheStats() {
+  return window['go']['main']['App']['GetCacheStats']();
+}
+
+export function GetChatChannels() {
+  return window['go']['main']['App']['GetChatChannels']();
+}
+
+export function GetChatMessages(arg1, arg2) {
+  return window['go']['main']['App']['GetChatMessages'](arg1, arg2);
+}
+
+export function GetCombatSummary() {
+  return window['go']['main']['App']['GetCombatSummary']();
+}
+
+export function GetCommandHistory(arg1) {
+  return window['go']['main']['App']['GetCommandHistory'](arg1);
+}
+
+export function GetCondenserSettings() {
+  return window['go']['main']['App']['GetCondenserSettings']();
+}
+
+export function GetConnectionState() {
+  return window['go']['main']['App']['GetConnectionState']();
+}
+
 export function GetConnectionStatus() {
   return window['go']['main']['App']['GetConnectionStatus']();
 }
 
+export function GetCooldownTimers() {
+  return window['go']['main']['App']['GetCooldownTimers']();
+}
+
+export function GetCooldowns() {
+  return window['go']['main']['App']['GetCooldowns']();
+}
+
 export function GetCurrentEntities() {
   return window['go']['main']['App']['GetCurrentEntities']();
 }
@@ -30,26 +190,358 @@ export function GetCurrentRoom() {
   return window['go']['main']['App']['GetCurrentRoom']();
 }
 
+export function GetDataDir() {
+  return window['go']['main']['App']['GetDataDir']();
+}
+
+export function GetExtractorSettings() {
+  return window['go']['main']['App']['GetExtractorSettings']();
+}
+
+export function GetFriendSettings() {
+  return window['go']['main']['App']['GetFriendSettings']();
+}
+
+export function GetFriends() {
+  return window['go']['main']['App']['GetFriends']();
+}
+
+export function GetHighlights() {
+  return window['go']['main']['App']['GetHighlights']();
+}
+
+export function GetIdleSettings() {
+  return window['go']['main']['App']['GetIdleSettings']();
+}
+
+export function GetIdleStatus() {
+  return window['go']['main']['App']['GetIdleStatus']();
+}
+
+export function GetImageBackend() {
+  return window['go']['main']['App']['GetImageBackend']();
+}
+
+export function GetImageEncoding() {
+  return window['go']['main']['App']['GetImageEncoding']();
+}
+
+export function GetImageQueue() {
+  return window['go']['main']['App']['GetImageQueue']();
+}
+
+export function GetIncludeBundledServers() {
+  return window['go']['main']['App']['GetIncludeBundledServers']();
+}
+
+export function GetInventory() {
+  return window['go']['main']['App']['GetInventory']();
+}
+
+export function GetLastCacheScan() {
+  return window['go']['main']['App']['GetLastCacheScan']();
+}
+
+export function GetLastSync() {
+  return window['go']['main']['App']['GetLastSync']();
+}
+
+export function GetLayeredMap() {
+  return window['go']['main']['App']['GetLayeredMap']();
+}
+
+export function GetLinesSince(arg1) {
+  return window['go']['main']['App']['GetLinesSince'](arg1);
+}
+
+export function GetLocale() {
+  return window['go']['main']['App']['GetLocale']();
+}
+
+export function GetLocales() {
+  return window['go']['main']['App']['GetLocales']();
+}
+
+export function GetLocationAt(arg1) {
+  return window['go']['main']['App']['GetLocationAt'](arg1);
+}
+
+export function GetLogLevel() {
+  return window['go']['main']['App']['GetLogLevel']();
+}
+
+export function GetLogLevels() {
+  return window['go']['main']['App']['GetLogLevels']();
+}
+
+export function GetMSDP() {
+  return window['go']['main']['App']['GetMSDP']();
+}
+
 export function GetMapData() {
   return window['go']['main']['App']['GetMapData']();
 }
 
+export function GetMapSVG(arg1) {
+  return window['go']['main']['App']['GetMapSVG'](arg1);
+}
+
 export function GetMapStats() {
   return window['go']['main']['App']['GetMapStats']();
 }
 
+export function GetMessages() {
+  return window['go']['main']['App']['GetMessages']();
+}
+
+export function GetMetricsSettings() {
+  return window['go']['main']['App']['GetMetricsSettings']();
+}
+
+export function GetMinimap(arg1) {
+  return window['go']['main']['App']['GetMinimap'](arg1);
+}
+
+export function GetMovementCommand(arg1) {
+  return window['go']['main']['App']['GetMovementCommand'](arg1);
+}
+
+export function GetMovementDialect() {
+  return window['go']['main']['App']['GetMovementDialect']();
+}
+
+export function GetMovementDialects() {
+  return window['go']['main']['App']['GetMovementDialects']();
+}
+
+export function GetNotedRoomsWithoutImages() {
+  return window['go']['main']['App']['GetNotedRoomsWithoutImages']();
+}
+
+export function GetNotificationSettings() {
+  return window['go']['main']['App']['GetNotificationSettings']();
+}
+
 export function GetOutput() {
   return window['go']['main']['App']['GetOutput']();
 }
 
+export function GetOutputSince(arg1) {
+  return window['go']['main']['App']['GetOutputSince'](arg1);
+}
+
+export function GetParsedOutput() {
+  return window['go']['main']['App']['GetParsedOutput']();
+}
+
+export function GetParserDefinitions() {
+  return window['go']['main']['App']['GetParserDefinitions']();
+}
+
+export function GetParserDialects() {
+  return window['go']['main']['App']['GetParserDialects']();
+}
+
+export function GetPartySettings() {
+  return window['go']['main']['App']['GetPartySettings']();
+}
+
+export function GetPartyStatus() {
+  return window['go']['main']['App']['GetPartyStatus']();
+}
+
+export function GetPasteDelay() {
+  return window['go']['main']['App']['GetPasteDelay']();
+}
+
+export function GetPathTo(arg1) {
+  return window['go']['main']['App']['GetPathTo'](arg1);
+}
+
+export function GetProfiles() {
+  return window['go']['main']['App']['GetProfiles']();
+}
+
+export function GetQueue() {
+  return window['go']['main']['App']['GetQueue']();
+}
+
+export function GetQueueDelay() {
+  return window['go']['main']['App']['GetQueueDelay']();
+}
+
+export function GetRecentCommands(arg1) {
+  return window['go']['main']['App']['GetRecentCommands'](arg1);
+}
+
+export function GetRecentLogs(arg1, arg2) {
+  return window['go']['main']['App']['GetRecentLogs'](arg1, arg2);
+}
+
+export function GetRemoteSettings() {
+  return window['go']['main']['App']['GetRemoteSettings']();
+}
+
+export function GetRemoteStatus() {
+  return window['go']['main']['App']['GetRemoteStatus']();
+}
+
 export function GetRoomImage() {
   return window['go']['main']['App']['GetRoomImage']();
 }
 
+export function GetRoomImageInfo() {
+  return window['go']['main']['App']['GetRoomImageInfo']();
+}
+
+export function GetRoomPrompt() {
+  return window['go']['main']['App']['GetRoomPrompt']();
+}
+
+export function GetScheduledCommands() {
+  return window['go']['main']['App']['GetScheduledCommands']();
+}
+
+export function GetScripts() {
+  return window['go']['main']['App']['GetScripts']();
+}
+
+export function GetServerDirectory() {
+  return window['go']['main']['App']['GetServerDirectory']();
+}
+
+export function GetSessionCommands() {
+  return window['go']['main']['App']['GetSessionCommands']();
+}
+
+export function GetSessionHistory(arg1) {
+  return window['go']['main']['App']['GetSessionHistory'](arg1);
+}
+
+export function GetSessionStats() {
+  return window['go']['main']['App']['GetSessionStats']();
+}
+
+export function GetSoundSettings() {
+  return window['go']['main']['App']['GetSoundSettings']();
+}
+
+export function GetSpeechSettings() {
+  return window['go']['main']['App']['GetSpeechSettings']();
+}
+
+export function GetSpeechTemplates() {
+  return window['go']['main']['App']['GetSpeechTemplates']();
+}
+
+export function GetSpeedwalks() {
+  return window['go']['main']['App']['GetSpeedwalks']();
+}
+
+export function GetStyles() {
+  return window['go']['main']['App']['GetStyles']();
+}
+
+export function GetSyncSettings() {
+  return window['go']['main']['App']['GetSyncSettings']();
+}
+
+export function GetTick() {
+  return window['go']['main']['App']['GetTick']();
+}
+
+export function GetTickSettings() {
+  return window['go']['main']['App']['GetTickSettings']();
+}
+
+export function GetTimeline(arg1, arg2) {
+  return window['go']['main']['App']['GetTimeline'](arg1, arg2);
+}
+
+export function GetTriggerHooks() {
+  return window['go']['main']['App']['GetTriggerHooks']();
+}
+
+export function GetTriggers() {
+  return window['go']['main']['App']['GetTriggers']();
+}
+
+export function GetVariationStrength() {
+  return window['go']['main']['App']['GetVariationStrength']();
+}
+
+export function GetVaultStatus() {
+  return window['go']['main']['App']['GetVaultStatus']();
+}
+
+export function GetVitals() {
+  return window['go']['main']['App']['GetVitals']();
+}
+
+export function GetVitalsHistory(arg1) {
+  return window['go']['main']['App']['GetVitalsHistory'](arg1);
+}
+
+export function GetVitalsSettings() {
+  return window['go']['main']['App']['GetVitalsSettings']();
+}
+
 export function Greet(arg1) {
   return window['go']['main']['App']['Greet'](arg1);
 }
 
+export function ImportArtPack() {
+  return window['go']['main']['App']['ImportArtPack']();
+}
+
+export function ImportArtPackFile(arg1) {
+  return window['go']['main']['App']['ImportArtPackFile'](arg1);
+}
+
+export function ImportMudletMap() {
+  return window['go']['main']['App']['ImportMudletMap']();
+}
+
+export function ImportMudletMapFile(arg1) {
+  return window['go']['main']['App']['ImportMudletMapFile'](arg1);
+}
+
+export function ImportTriggerFile(arg1) {
+  return window['go']['main']['App']['ImportTriggerFile'](arg1);
+}
+
+export function ImportTriggerPackage() {
+  return window['go']['main']['App']['ImportTriggerPackage']();
+}
+
+export function LoadMap() {
+  return window['go']['main']['App']['LoadMap']();
+}
+
+export function LockVault() {
+  return window['go']['main']['App']['LockVault']();
+}
+
+export function Move(arg1) {
+  return window['go']['main']['App']['Move'](arg1);
+}
+
+export function PreviewPaste(arg1) {
+  return window['go']['main']['App']['PreviewPaste'](arg1);
+}
+
+export function PreviewSoundCue(arg1) {
+  return window['go']['main']['App']['PreviewSoundCue'](arg1);
+}
+
+export function RecodeCache() {
+  return window['go']['main']['App']['RecodeCache']();
+}
+
+export function ReconnectToMUD() {
+  return window['go']['main']['App']['ReconnectToMUD']();
+}
+
 export function RegenerateRoomImage() {
   return window['go']['main']['App']['RegenerateRoomImage']();
 }
@@ -58,10 +550,278 @@ export function RegenerateRoomImageWithPrompt(arg1) {
   return window['go']['main']['App']['RegenerateRoomImageWithPrompt'](arg1);
 }
 
+export function RegenerateRoomImageWithSeed(arg1) {
+  return window['go']['main']['App']['RegenerateRoomImageWithSeed'](arg1);
+}
+
+export function ReloadScripts() {
+  return window['go']['main']['App']['ReloadScripts']();
+}
+
+export function RemoveDirectoryServer(arg1, arg2) {
+  return window['go']['main']['App']['RemoveDirectoryServer'](arg1, arg2);
+}
+
+export function RemoveFriend(arg1) {
+  return window['go']['main']['App']['RemoveFriend'](arg1);
+}
+
+export function ReportMSDP(arg1) {
+  return window['go']['main']['App']['ReportMSDP'](arg1);
+}
+
+export function RunSpeedwalk(arg1) {
+  return window['go']['main']['App']['RunSpeedwalk'](arg1);
+}
+
+export function RunSpeedwalkKey(arg1) {
+  return window['go']['main']['App']['RunSpeedwalkKey'](arg1);
+}
+
+export function SaveMap() {
+  return window['go']['main']['App']['SaveMap']();
+}
+
 export function SaveMapNow() {
   return window['go']['main']['App']['SaveMapNow']();
 }
 
+export function SaveProfile(arg1, arg2) {
+  return window['go']['main']['App']['SaveProfile'](arg1, arg2);
+}
+
+export function SaveStyle(arg1) {
+  return window['go']['main']['App']['SaveStyle'](arg1);
+}
+
+export function SaveTranscript(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SaveTranscript'](arg1, arg2, arg3);
+}
+
+export function ScanCache() {
+  return window['go']['main']['App']['ScanCache']();
+}
+
+export function SearchCommands(arg1, arg2) {
+  return window['go']['main']['App']['SearchCommands'](arg1, arg2);
+}
+
 export function SendCommand(arg1) {
   return window['go']['main']['App']['SendCommand'](arg1);
 }
+
+export function SendFile(arg1) {
+  return window['go']['main']['App']['SendFile'](arg1);
+}
+
+export function SendMSDP(arg1) {
+  return window['go']['main']['App']['SendMSDP'](arg1);
+}
+
+export function SendMUDPassword(arg1) {
+  return window['go']['main']['App']['SendMUDPassword'](arg1);
+}
+
+export function SendPaste(arg1) {
+  return window['go']['main']['App']['SendPaste'](arg1);
+}
+
+export function SetAlias(arg1) {
+  return window['go']['main']['App']['SetAlias'](arg1);
+}
+
+export function SetCacheLimits(arg1) {
+  return window['go']['main']['App']['SetCacheLimits'](arg1);
+}
+
+export function SetCondenserSettings(arg1) {
+  return window['go']['main']['App']['SetCondenserSettings'](arg1);
+}
+
+export function SetCooldown(arg1) {
+  return window['go']['main']['App']['SetCooldown'](arg1);
+}
+
+export function SetDataDir(arg1) {
+  return window['go']['main']['App']['SetDataDir'](arg1);
+}
+
+export function SetDefaultProfile(arg1) {
+  return window['go']['main']['App']['SetDefaultProfile'](arg1);
+}
+
+export function SetExtractorSettings(arg1) {
+  return window['go']['main']['App']['SetExtractorSettings'](arg1);
+}
+
+export function SetFriendSettings(arg1) {
+  return window['go']['main']['App']['SetFriendSettings'](arg1);
+}
+
+export function SetHighlight(arg1) {
+  return window['go']['main']['App']['SetHighlight'](arg1);
+}
+
+export function SetIdleSettings(arg1) {
+  return window['go']['main']['App']['SetIdleSettings'](arg1);
+}
+
+export function SetImageEncoding(arg1) {
+  return window['go']['main']['App']['SetImageEncoding'](arg1);
+}
+
+export function SetIncludeBundledServers(arg1) {
+  return window['go']['main']['App']['SetIncludeBundledServers'](arg1);
+}
+
+export function SetInventoryCapacity(arg1) {
+  return window['go']['main']['App']['SetInventoryCapacity'](arg1);
+}
+
+export function SetItemWeight(arg1, arg2) {
+  return window['go']['main']['App']['SetItemWeight'](arg1, arg2);
+}
+
+export function SetLocale(arg1) {
+  return window['go']['main']['App']['SetLocale'](arg1);
+}
+
+export function SetLogLevel(arg1) {
+  return window['go']['main']['App']['SetLogLevel'](arg1);
+}
+
+export function SetMUDPassword(arg1, arg2) {
+  return window['go']['main']['App']['SetMUDPassword'](arg1, arg2);
+}
+
+export function SetMapFingerprint(arg1) {
+  return window['go']['main']['App']['SetMapFingerprint'](arg1);
+}
+
+export function SetMetricsSettings(arg1) {
+  return window['go']['main']['App']['SetMetricsSettings'](arg1);
+}
+
+export function SetMovementDialect(arg1) {
+  return window['go']['main']['App']['SetMovementDialect'](arg1);
+}
+
+export function SetNotificationSettings(arg1) {
+  return window['go']['main']['App']['SetNotificationSettings'](arg1);
+}
+
+export function SetPartySettings(arg1) {
+  return window['go']['main']['App']['SetPartySettings'](arg1);
+}
+
+export function SetPasteDelay(arg1) {
+  return window['go']['main']['App']['SetPasteDelay'](arg1);
+}
+
+export function SetQueueDelay(arg1) {
+  return window['go']['main']['App']['SetQueueDelay'](arg1);
+}
+
+export function SetRemoteSettings(arg1) {
+  return window['go']['main']['App']['SetRemoteSettings'](arg1);
+}
+
+export function SetRoomPrompt(arg1) {
+  return window['go']['main']['App']['SetRoomPrompt'](arg1);
+}
+
+export function SetSecret(arg1, arg2) {
+  return window['go']['main']['App']['SetSecret'](arg1, arg2);
+}
+
+export function SetSessionStyle(arg1) {
+  return window['go']['main']['App']['SetSessionStyle'](arg1);
+}
+
+export function SetSoundCue(arg1, arg2) {
+  return window['go']['main']['App']['SetSoundCue'](arg1, arg2);
+}
+
+export function SetSoundSettings(arg1) {
+  return window['go']['main']['App']['SetSoundSettings'](arg1);
+}
+
+export function SetSoundsMuted(arg1) {
+  return window['go']['main']['App']['SetSoundsMuted'](arg1);
+}
+
+export function SetSpeechSettings(arg1) {
+  return window['go']['main']['App']['SetSpeechSettings'](arg1);
+}
+
+export function SetSpeedwalk(arg1) {
+  return window['go']['main']['App']['SetSpeedwalk'](arg1);
+}
+
+export function SetSyncSettings(arg1) {
+  return window['go']['main']['App']['SetSyncSettings'](arg1);
+}
+
+export function SetTickSettings(arg1) {
+  return window['go']['main']['App']['SetTickSettings'](arg1);
+}
+
+export function SetTrigger(arg1) {
+  return window['go']['main']['App']['SetTrigger'](arg1);
+}
+
+export function SetTriggerEnabled(arg1, arg2) {
+  return window['go']['main']['App']['SetTriggerEnabled'](arg1, arg2);
+}
+
+export function SetVariationStrength(arg1) {
+  return window['go']['main']['App']['SetVariationStrength'](arg1);
+}
+
+export function SetVitalsSettings(arg1) {
+  return window['go']['main']['App']['SetVitalsSettings'](arg1);
+}
+
+export function SetWindowFocused(arg1) {
+  return window['go']['main']['App']['SetWindowFocused'](arg1);
+}
+
+export function SetZoneStyle(arg1) {
+  return window['go']['main']['App']['SetZoneStyle'](arg1);
+}
+
+export function ShareDuplicateImage(arg1, arg2) {
+  return window['go']['main']['App']['ShareDuplicateImage'](arg1, arg2);
+}
+
+export function SpeakText(arg1) {
+  return window['go']['main']['App']['SpeakText'](arg1);
+}
+
+export function StopSpeech() {
+  return window['go']['main']['App']['StopSpeech']();
+}
+
+export function SyncNow() {
+  return window['go']['main']['App']['SyncNow']();
+}
+
+export function SyncTick() {
+  return window['go']['main']['App']['SyncTick']();
+}
+
+export function UnlockVault(arg1) {
+  return window['go']['main']['App']['UnlockVault'](arg1);
+}
+
+export function UnlockVaultWithKeychain() {
+  return window['go']['main']['App']['UnlockVaultWithKeychain']();
+}
+
+export function ValidateParserDefinition(arg1) {
+  return window['go']['main']['App']['ValidateParserDefinition'](arg1);
+}
+
+export function WalkTo(arg1) {
+  return window['go']['main']['App']['WalkTo'](arg1);
+}
@@ -0,0 +1,2556 @@
+export namespace ansi {
+	
+	export class Span {
+	    text: string;
+	    fg?: string;
+	    bg?: string;
+	    bold?: boolean;
+	    italic?: boolean;
+	    underline?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Span(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.text = source["text"];
+	        this.fg = source["fg"];
+	        this.bg = source["bg"];
+	        this.bold = source["bold"];
+	        this.italic = source["italic"];
+	        this.underline = source["underline"];
+	    }
+	}
+
+}
+
+export namespace artpack {
+	
+	export class Result {
+	    server_name: string;
+	    rooms: number;
+	    images: number;
+	    kept: number;
+	    skipped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Result(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.server_name = source["server_name"];
+	        this.rooms = source["rooms"];
+	        this.images = source["images"];
+	        this.kept = source["kept"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+
+}
+
+export namespace chat {
+	
+	export class Message {
+	    seq: number;
+	    // Go type: time
+	    time: any;
+	    kind: string;
+	    channel: string;
+	    speaker: string;
+	    text: string;
+	    raw: string;
+	    outgoing: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Message(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.time = this.convertValues(source["time"], null);
+	        this.kind = source["kind"];
+	        this.channel = source["channel"];
+	        this.speaker = source["speaker"];
+	        this.text = source["text"];
+	        this.raw = source["raw"];
+	        this.outgoing = source["outgoing"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace cloudsync {
+	
+	export class Result {
+	    server_name: string;
+	    rooms: number;
+	    images_pulled: number;
+	    images_pushed: number;
+	    kept: number;
+	    skipped: number;
+	    // Go type: time
+	    finished: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Result(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.server_name = source["server_name"];
+	        this.rooms = source["rooms"];
+	        this.images_pulled = source["images_pulled"];
+	        this.images_pushed = source["images_pushed"];
+	        this.kept = source["kept"];
+	        this.skipped = source["skipped"];
+	        this.finished = this.convertValues(source["finished"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Settings {
+	    enabled: boolean;
+	    kind: string;
+	    url: string;
+	    bucket: string;
+	    region: string;
+	    username: string;
+	    password: string;
+	    prefix: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.kind = source["kind"];
+	        this.url = source["url"];
+	        this.bucket = source["bucket"];
+	        this.region = source["region"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	        this.prefix = source["prefix"];
+	    }
+	}
+
+}
+
+export namespace combat {
+	
+	export class Opponent {
+	    name: string;
+	    damage_dealt: number;
+	    damage_taken: number;
+	    hits_dealt: number;
+	    hits_taken: number;
+	    condition?: string;
+	    health: number;
+	    killed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Opponent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.damage_dealt = source["damage_dealt"];
+	        this.damage_taken = source["damage_taken"];
+	        this.hits_dealt = source["hits_dealt"];
+	        this.hits_taken = source["hits_taken"];
+	        this.condition = source["condition"];
+	        this.health = source["health"];
+	        this.killed = source["killed"];
+	    }
+	}
+	export class Summary {
+	    in_combat: boolean;
+	    // Go type: time
+	    started: any;
+	    seconds: number;
+	    damage_dealt: number;
+	    damage_taken: number;
+	    dps: number;
+	    taken_ps: number;
+	    outcome: string;
+	    opponents: Opponent[];
+	    kills: number;
+	    deaths: number;
+	    fights: number;
+	    total_seconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Summary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.in_combat = source["in_combat"];
+	        this.started = this.convertValues(source["started"], null);
+	        this.seconds = source["seconds"];
+	        this.damage_dealt = source["damage_dealt"];
+	        this.damage_taken = source["damage_taken"];
+	        this.dps = source["dps"];
+	        this.taken_ps = source["taken_ps"];
+	        this.outcome = source["outcome"];
+	        this.opponents = this.convertValues(source["opponents"], Opponent);
+	        this.kills = source["kills"];
+	        this.deaths = source["deaths"];
+	        this.fights = source["fights"];
+	        this.total_seconds = source["total_seconds"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace cooldown {
+	
+	export class Definition {
+	    name: string;
+	    pattern: string;
+	    seconds: number;
+	    kind: string;
+	    ends_with?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Definition(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.pattern = source["pattern"];
+	        this.seconds = source["seconds"];
+	        this.kind = source["kind"];
+	        this.ends_with = source["ends_with"];
+	    }
+	}
+	export class Timer {
+	    name: string;
+	    kind: string;
+	    // Go type: time
+	    started_at: any;
+	    // Go type: time
+	    ends_at: any;
+	    remaining_seconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Timer(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.kind = source["kind"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.ends_at = this.convertValues(source["ends_at"], null);
+	        this.remaining_seconds = source["remaining_seconds"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace engine {
+	
+	export class CacheLimits {
+	    max_bytes: number;
+	    max_images: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CacheLimits(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.max_bytes = source["max_bytes"];
+	        this.max_images = source["max_images"];
+	    }
+	}
+	export class CacheStats {
+	    directory: string;
+	    images: number;
+	    bytes: number;
+	    limits: CacheLimits;
+	    evicted: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CacheStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.directory = source["directory"];
+	        this.images = source["images"];
+	        this.bytes = source["bytes"];
+	        this.limits = this.convertValues(source["limits"], CacheLimits);
+	        this.evicted = source["evicted"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DuplicateImage {
+	    room_id: string;
+	    name: string;
+	    file: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DuplicateImage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.room_id = source["room_id"];
+	        this.name = source["name"];
+	        this.file = source["file"];
+	    }
+	}
+	export class DuplicateGroup {
+	    images: DuplicateImage[];
+	    distance: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DuplicateGroup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.images = this.convertValues(source["images"], DuplicateImage);
+	        this.distance = source["distance"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class ImageEncoding {
+	    format: string;
+	    quality: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageEncoding(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.quality = source["quality"];
+	    }
+	}
+	export class ImageRecord {
+	    room_id?: string;
+	    name?: string;
+	    description?: string;
+	    description_hash?: string;
+	    file: string;
+	    size: number;
+	    prompt?: string;
+	    negative_prompt?: string;
+	    custom_prompt?: string;
+	    style?: string;
+	    backend?: string;
+	    seed?: number;
+	    model?: string;
+	    model_hash?: string;
+	    sampler?: string;
+	    steps?: number;
+	    cfg_scale?: number;
+	    width?: number;
+	    height?: number;
+	    neighbours: number;
+	    strength?: number;
+	    hash?: string;
+	    // Go type: time
+	    created: any;
+	    // Go type: time
+	    last_used: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImageRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.room_id = source["room_id"];
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.description_hash = source["description_hash"];
+	        this.file = source["file"];
+	        this.size = source["size"];
+	        this.prompt = source["prompt"];
+	        this.negative_prompt = source["negative_prompt"];
+	        this.custom_prompt = source["custom_prompt"];
+	        this.style = source["style"];
+	        this.backend = source["backend"];
+	        this.seed = source["seed"];
+	        this.model = source["model"];
+	        this.model_hash = source["model_hash"];
+	        this.sampler = source["sampler"];
+	        this.steps = source["steps"];
+	        this.cfg_scale = source["cfg_scale"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.neighbours = source["neighbours"];
+	        this.strength = source["strength"];
+	        this.hash = source["hash"];
+	        this.created = this.convertValues(source["created"], null);
+	        this.last_used = this.convertValues(source["last_used"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ScanReport {
+	    directory: string;
+	    // Go type: time
+	    started: any;
+	    // Go type: time
+	    finished: any;
+	    checked: number;
+	    skipped: number;
+	    missing: string[];
+	    corrupt: string[];
+	    orphans: string[];
+	    freed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ScanReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.directory = source["directory"];
+	        this.started = this.convertValues(source["started"], null);
+	        this.finished = this.convertValues(source["finished"], null);
+	        this.checked = source["checked"];
+	        this.skipped = source["skipped"];
+	        this.missing = source["missing"];
+	        this.corrupt = source["corrupt"];
+	        this.orphans = source["orphans"];
+	        this.freed = source["freed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace friends {
+	
+	export class Friend {
+	    name: string;
+	    online: boolean;
+	    // Go type: time
+	    last_seen?: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Friend(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.online = source["online"];
+	        this.last_seen = this.convertValues(source["last_seen"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Settings {
+	    poll_who: boolean;
+	    interval_minutes: number;
+	    who_command: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.poll_who = source["poll_who"];
+	        this.interval_minutes = source["interval_minutes"];
+	        this.who_command = source["who_command"];
+	    }
+	}
+
+}
+
+export namespace highlight {
+	
+	export class Watch {
+	    pattern: string;
+	    regex: boolean;
+	    notify: boolean;
+	    enabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Watch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pattern = source["pattern"];
+	        this.regex = source["regex"];
+	        this.notify = source["notify"];
+	        this.enabled = source["enabled"];
+	    }
+	}
+
+}
+
+export namespace history {
+	
+	export class Entry {
+	    command: string;
+	    // Go type: time
+	    sent: any;
+	    session: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.command = source["command"];
+	        this.sent = this.convertValues(source["sent"], null);
+	        this.session = source["session"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace idle {
+	
+	export class Settings {
+	    keepalive_command: string;
+	    keepalive_minutes: number;
+	    afk_minutes: number;
+	    auto_reply: boolean;
+	    afk_message: string;
+	    reply_format: string;
+	    pause_minutes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.keepalive_command = source["keepalive_command"];
+	        this.keepalive_minutes = source["keepalive_minutes"];
+	        this.afk_minutes = source["afk_minutes"];
+	        this.auto_reply = source["auto_reply"];
+	        this.afk_message = source["afk_message"];
+	        this.reply_format = source["reply_format"];
+	        this.pause_minutes = source["pause_minutes"];
+	    }
+	}
+	export class Status {
+	    idle_seconds: number;
+	    afk: boolean;
+	    automation_paused: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.idle_seconds = source["idle_seconds"];
+	        this.afk = source["afk"];
+	        this.automation_paused = source["automation_paused"];
+	    }
+	}
+
+}
+
+export namespace imagequeue {
+	
+	export class Update {
+	    job_id: number;
+	    room_id: string;
+	    room: string;
+	    priority: number;
+	    status: string;
+	    position?: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Update(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.job_id = source["job_id"];
+	        this.room_id = source["room_id"];
+	        this.room = source["room"];
+	        this.priority = source["priority"];
+	        this.status = source["status"];
+	        this.position = source["position"];
+	        this.error = source["error"];
+	    }
+	}
+
+}
+
+export namespace inventory {
+	
+	export class Item {
+	    name: string;
+	    slot?: string;
+	    weight: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Item(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.slot = source["slot"];
+	        this.weight = source["weight"];
+	    }
+	}
+	export class Snapshot {
+	    carried: Item[];
+	    worn: Item[];
+	    count: number;
+	    weight: number;
+	    capacity: number;
+	    load: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Snapshot(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.carried = this.convertValues(source["carried"], Item);
+	        this.worn = this.convertValues(source["worn"], Item);
+	        this.count = source["count"];
+	        this.weight = source["weight"];
+	        this.capacity = source["capacity"];
+	        this.load = source["load"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace logging {
+	
+	export class Record {
+	    // Go type: time
+	    time: any;
+	    level: string;
+	    component?: string;
+	    message: string;
+	    fields?: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Record(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = this.convertValues(source["time"], null);
+	        this.level = source["level"];
+	        this.component = source["component"];
+	        this.message = source["message"];
+	        this.fields = source["fields"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace main {
+	
+	export class PartyStatus {
+	    connected: boolean;
+	    members: party.Member[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PartyStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.connected = source["connected"];
+	        this.members = this.convertValues(source["members"], party.Member);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RemoteStatus {
+	    running: boolean;
+	    clients: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RemoteStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.running = source["running"];
+	        this.clients = source["clients"];
+	    }
+	}
+	export class RoomImageInfo {
+	    record: engine.ImageRecord;
+	    stale: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new RoomImageInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.record = this.convertValues(source["record"], engine.ImageRecord);
+	        this.stale = source["stale"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class StyleSettings {
+	    presets: renderer.StylePreset[];
+	    session: string;
+	    zone: string;
+	    zone_style?: string;
+	    active: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new StyleSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.presets = this.convertValues(source["presets"], renderer.StylePreset);
+	        this.session = source["session"];
+	        this.zone = source["zone"];
+	        this.zone_style = source["zone_style"];
+	        this.active = source["active"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace mapexport {
+	
+	export class LayeredEdge {
+	    from: string;
+	    to: string;
+	    direction: string;
+	    vertical?: boolean;
+	    one_way?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LayeredEdge(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	        this.direction = source["direction"];
+	        this.vertical = source["vertical"];
+	        this.one_way = source["one_way"];
+	    }
+	}
+	export class LayeredNode {
+	    id: string;
+	    name: string;
+	    x: number;
+	    y: number;
+	    z: number;
+	    zone: string;
+	    visit_count: number;
+	    uncertain?: boolean;
+	    unexplored?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LayeredNode(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.z = source["z"];
+	        this.zone = source["zone"];
+	        this.visit_count = source["visit_count"];
+	        this.uncertain = source["uncertain"];
+	        this.unexplored = source["unexplored"];
+	    }
+	}
+	export class LayeredZone {
+	    id: string;
+	    name: string;
+	    z: number;
+	    rooms: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new LayeredZone(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.z = source["z"];
+	        this.rooms = source["rooms"];
+	    }
+	}
+	export class LayeredLevel {
+	    z: number;
+	    zones: string[];
+	    rooms: number;
+	    min_x: number;
+	    max_x: number;
+	    min_y: number;
+	    max_y: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LayeredLevel(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.z = source["z"];
+	        this.zones = source["zones"];
+	        this.rooms = source["rooms"];
+	        this.min_x = source["min_x"];
+	        this.max_x = source["max_x"];
+	        this.min_y = source["min_y"];
+	        this.max_y = source["max_y"];
+	    }
+	}
+	export class Layered {
+	    format: string;
+	    version: number;
+	    server_name: string;
+	    current_room_id?: string;
+	    levels: LayeredLevel[];
+	    zones: LayeredZone[];
+	    nodes: LayeredNode[];
+	    edges: LayeredEdge[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Layered(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.format = source["format"];
+	        this.version = source["version"];
+	        this.server_name = source["server_name"];
+	        this.current_room_id = source["current_room_id"];
+	        this.levels = this.convertValues(source["levels"], LayeredLevel);
+	        this.zones = this.convertValues(source["zones"], LayeredZone);
+	        this.nodes = this.convertValues(source["nodes"], LayeredNode);
+	        this.edges = this.convertValues(source["edges"], LayeredEdge);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	
+
+}
+
+export namespace mapper {
+	
+	export class Dialect {
+	    name: string;
+	    commands: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Dialect(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.commands = source["commands"];
+	    }
+	}
+	export class MinimapRoom {
+	    id: string;
+	    name: string;
+	    dx: number;
+	    dy: number;
+	    exits: Record<string, string>;
+	    visit_count: number;
+	    current: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MinimapRoom(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.dx = source["dx"];
+	        this.dy = source["dy"];
+	        this.exits = source["exits"];
+	        this.visit_count = source["visit_count"];
+	        this.current = source["current"];
+	    }
+	}
+	export class Minimap {
+	    radius: number;
+	    current_room_id: string;
+	    z: number;
+	    rooms: MinimapRoom[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Minimap(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.radius = source["radius"];
+	        this.current_room_id = source["current_room_id"];
+	        this.z = source["z"];
+	        this.rooms = this.convertValues(source["rooms"], MinimapRoom);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class SVGOptions {
+	    level: number;
+	    current_room_id: string;
+	    selected_room_id: string;
+	    cell_size: number;
+	    labels: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SVGOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.level = source["level"];
+	        this.current_room_id = source["current_room_id"];
+	        this.selected_room_id = source["selected_room_id"];
+	        this.cell_size = source["cell_size"];
+	        this.labels = source["labels"];
+	    }
+	}
+
+}
+
+export namespace metrics {
+	
+	export class Settings {
+	    enabled: boolean;
+	    address: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.address = source["address"];
+	    }
+	}
+
+}
+
+export namespace mssp {
+	
+	export class Entry {
+	    name: string;
+	    host: string;
+	    port: string;
+	    bundled?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.bundled = source["bundled"];
+	    }
+	}
+	export class Info {
+	    host: string;
+	    port: string;
+	    name: string;
+	    codebase?: string;
+	    players: number;
+	    uptime: number;
+	    variables?: Record<string, Array<string>>;
+	    reachable: boolean;
+	    error?: string;
+	    // Go type: time
+	    probed: any;
+	    bundled?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Info(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.name = source["name"];
+	        this.codebase = source["codebase"];
+	        this.players = source["players"];
+	        this.uptime = source["uptime"];
+	        this.variables = source["variables"];
+	        this.reachable = source["reachable"];
+	        this.error = source["error"];
+	        this.probed = this.convertValues(source["probed"], null);
+	        this.bundled = source["bundled"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace notify {
+	
+	export class Settings {
+	    enabled: boolean;
+	    only_when_unfocused: boolean;
+	    events: Record<string, boolean>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.only_when_unfocused = source["only_when_unfocused"];
+	        this.events = source["events"];
+	    }
+	}
+
+}
+
+export namespace output {
+	
+	export class Event {
+	    type: string;
+	    class: string;
+	    text: string;
+	    raw: string;
+	    spans: ansi.Span[];
+	    room_name?: string;
+	    exits?: string[];
+	    items?: string[];
+	    mobs?: string[];
+	    speaker?: string;
+	    channel?: string;
+	    message?: string;
+	    outgoing?: boolean;
+	    highlight?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Event(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.type = source["type"];
+	        this.class = source["class"];
+	        this.text = source["text"];
+	        this.raw = source["raw"];
+	        this.spans = this.convertValues(source["spans"], ansi.Span);
+	        this.room_name = source["room_name"];
+	        this.exits = source["exits"];
+	        this.items = source["items"];
+	        this.mobs = source["mobs"];
+	        this.speaker = source["speaker"];
+	        this.channel = source["channel"];
+	        this.message = source["message"];
+	        this.outgoing = source["outgoing"];
+	        this.highlight = source["highlight"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Entry {
+	    seq: number;
+	    // Go type: time
+	    time: any;
+	    line: string;
+	    event: Event;
+	
+	    static createFrom(source: any = {}) {
+	        return new Entry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.time = this.convertValues(source["time"], null);
+	        this.line = source["line"];
+	        this.event = this.convertValues(source["event"], Event);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Batch {
+	    entries: Entry[];
+	    last_seq: number;
+	    missed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Batch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = this.convertValues(source["entries"], Entry);
+	        this.last_seq = source["last_seq"];
+	        this.missed = source["missed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class FrameLine {
+	    seq: number;
+	    // Go type: time
+	    time: any;
+	    type: string;
+	    class: string;
+	    text: string;
+	    spans: ansi.Span[];
+	    room_name?: string;
+	    exits?: string[];
+	    items?: string[];
+	    mobs?: string[];
+	    highlight?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new FrameLine(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.time = this.convertValues(source["time"], null);
+	        this.type = source["type"];
+	        this.class = source["class"];
+	        this.text = source["text"];
+	        this.spans = this.convertValues(source["spans"], ansi.Span);
+	        this.room_name = source["room_name"];
+	        this.exits = source["exits"];
+	        this.items = source["items"];
+	        this.mobs = source["mobs"];
+	        this.highlight = source["highlight"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Frame {
+	    first_seq: number;
+	    last_seq: number;
+	    lines: FrameLine[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Frame(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.first_seq = source["first_seq"];
+	        this.last_seq = source["last_seq"];
+	        this.lines = this.convertValues(source["lines"], FrameLine);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace pacing {
+	
+	export class Pending {
+	    batch_id: number;
+	    label: string;
+	    sent: number;
+	    total: number;
+	    upcoming: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Pending(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.batch_id = source["batch_id"];
+	        this.label = source["label"];
+	        this.sent = source["sent"];
+	        this.total = source["total"];
+	        this.upcoming = source["upcoming"];
+	    }
+	}
+
+}
+
+export namespace parser {
+	
+	export class TitleRules {
+	    patterns?: string[];
+	    brackets?: boolean;
+	    title_case?: boolean;
+	    coloured?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TitleRules(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.patterns = source["patterns"];
+	        this.brackets = source["brackets"];
+	        this.title_case = source["title_case"];
+	        this.coloured = source["coloured"];
+	    }
+	}
+	export class Definition {
+	    name: string;
+	    description?: string;
+	    title: TitleRules;
+	    exits?: string[];
+	    prompt?: string;
+	    items?: string[];
+	    mobs?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Definition(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.description = source["description"];
+	        this.title = this.convertValues(source["title"], TitleRules);
+	        this.exits = source["exits"];
+	        this.prompt = source["prompt"];
+	        this.items = source["items"];
+	        this.mobs = source["mobs"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ExtractorSettings {
+	    enabled: boolean;
+	    endpoint: string;
+	    model: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExtractorSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.endpoint = source["endpoint"];
+	        this.model = source["model"];
+	    }
+	}
+
+}
+
+export namespace party {
+	
+	export class Member {
+	    name: string;
+	    server: string;
+	    room_id: string;
+	    room_name: string;
+	    // Go type: time
+	    updated: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new Member(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.server = source["server"];
+	        this.room_id = source["room_id"];
+	        this.room_name = source["room_name"];
+	        this.updated = this.convertValues(source["updated"], null);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Settings {
+	    enabled: boolean;
+	    url: string;
+	    party: string;
+	    name: string;
+	    host: boolean;
+	    listen: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.url = source["url"];
+	        this.party = source["party"];
+	        this.name = source["name"];
+	        this.host = source["host"];
+	        this.listen = source["listen"];
+	    }
+	}
+
+}
+
+export namespace profile {
+	
+	export class Profile {
+	    host: string;
+	    port: string;
+	    character?: string;
+	    dialect?: string;
+	    mapping?: boolean;
+	    login?: string;
+	    map?: string;
+	    style?: string;
+	    image_backend?: string;
+	    image_endpoint?: string;
+	    tls?: telnet.TLSConfig;
+	    proxy?: telnet.ProxyConfig;
+	
+	    static createFrom(source: any = {}) {
+	        return new Profile(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.character = source["character"];
+	        this.dialect = source["dialect"];
+	        this.mapping = source["mapping"];
+	        this.login = source["login"];
+	        this.map = source["map"];
+	        this.style = source["style"];
+	        this.image_backend = source["image_backend"];
+	        this.image_endpoint = source["image_endpoint"];
+	        this.tls = this.convertValues(source["tls"], telnet.TLSConfig);
+	        this.proxy = this.convertValues(source["proxy"], telnet.ProxyConfig);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class Config {
+	    default?: string;
+	    profiles: Record<string, Profile>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Config(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.default = source["default"];
+	        this.profiles = this.convertValues(source["profiles"], Profile, true);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace remote {
+	
+	export class Settings {
+	    enabled: boolean;
+	    address: string;
+	    token: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.address = source["address"];
+	        this.token = source["token"];
+	    }
+	}
+
+}
+
+export namespace renderer {
+	
+	export class CondenserSettings {
+	    enabled: boolean;
+	    endpoint: string;
+	    model: string;
+	    min_length: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CondenserSettings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.endpoint = source["endpoint"];
+	        this.model = source["model"];
+	        this.min_length = source["min_length"];
+	    }
+	}
+	export class StylePreset {
+	    name: string;
+	    suffix: string;
+	    negative_prompt?: string;
+	    built_in: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new StylePreset(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.suffix = source["suffix"];
+	        this.negative_prompt = source["negative_prompt"];
+	        this.built_in = source["built_in"];
+	    }
+	}
+
+}
+
+export namespace schedule {
+	
+	export class Job {
+	    id: number;
+	    command: string;
+	    // Go type: time
+	    due: any;
+	    every?: number;
+	    source?: string;
+	    fired: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Job(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.command = source["command"];
+	        this.due = this.convertValues(source["due"], null);
+	        this.every = source["every"];
+	        this.source = source["source"];
+	        this.fired = source["fired"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace scripting {
+	
+	export class Info {
+	    name: string;
+	    handlers: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Info(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.handlers = source["handlers"];
+	        this.error = source["error"];
+	    }
+	}
+
+}
+
+export namespace sound {
+	
+	export class Cue {
+	    file: string;
+	    volume: number;
+	    muted: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Cue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.file = source["file"];
+	        this.volume = source["volume"];
+	        this.muted = source["muted"];
+	    }
+	}
+	export class Settings {
+	    enabled: boolean;
+	    master_volume: number;
+	    cues: Record<string, Cue>;
+	    msp_enabled: boolean;
+	    msp_directory: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.master_volume = source["master_volume"];
+	        this.cues = this.convertValues(source["cues"], Cue, true);
+	        this.msp_enabled = source["msp_enabled"];
+	        this.msp_directory = source["msp_directory"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace speech {
+	
+	export class Settings {
+	    enabled: boolean;
+	    verbosity: number;
+	    voice: string;
+	    rate: number;
+	    summarise: boolean;
+	    screen_reader: boolean;
+	    templates: Record<string, string>;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.verbosity = source["verbosity"];
+	        this.voice = source["voice"];
+	        this.rate = source["rate"];
+	        this.summarise = source["summarise"];
+	        this.screen_reader = source["screen_reader"];
+	        this.templates = source["templates"];
+	    }
+	}
+
+}
+
+export namespace speedwalk {
+	
+	export class Route {
+	    name: string;
+	    path: string;
+	    key?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Route(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.key = source["key"];
+	    }
+	}
+
+}
+
+export namespace statedb {
+	
+	export class HistoryEntry {
+	    // Go type: time
+	    sent: any;
+	    command: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new HistoryEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.sent = this.convertValues(source["sent"], null);
+	        this.command = source["command"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RoomSummary {
+	    id: string;
+	    name: string;
+	    notes: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RoomSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.notes = source["notes"];
+	    }
+	}
+
+}
+
+export namespace stats {
+	
+	export class Stats {
+	    active: boolean;
+	    // Go type: time
+	    started_at: any;
+	    // Go type: time
+	    ended_at?: any;
+	    uptime_seconds: number;
+	    commands_sent: number;
+	    lines_received: number;
+	    rooms_discovered: number;
+	    images_generated: number;
+	    deaths: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Stats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.active = source["active"];
+	        this.started_at = this.convertValues(source["started_at"], null);
+	        this.ended_at = this.convertValues(source["ended_at"], null);
+	        this.uptime_seconds = source["uptime_seconds"];
+	        this.commands_sent = source["commands_sent"];
+	        this.lines_received = source["lines_received"];
+	        this.rooms_discovered = source["rooms_discovered"];
+	        this.images_generated = source["images_generated"];
+	        this.deaths = source["deaths"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace telnet {
+	
+	export class MSDPStatus {
+	    enabled: boolean;
+	    room_vnum?: string;
+	    room_name?: string;
+	    room_area?: string;
+	    health: number;
+	    health_max: number;
+	    mana: number;
+	    mana_max: number;
+	    movement: number;
+	    movement_max: number;
+	    level: number;
+	    experience: number;
+	    opponent_name?: string;
+	    opponent_health: number;
+	    opponent_health_max: number;
+	    opponent_level: number;
+	    variables: Record<string, any>;
+	
+	    static createFrom(source: any = {}) {
+	        return new MSDPStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.room_vnum = source["room_vnum"];
+	        this.room_name = source["room_name"];
+	        this.room_area = source["room_area"];
+	        this.health = source["health"];
+	        this.health_max = source["health_max"];
+	        this.mana = source["mana"];
+	        this.mana_max = source["mana_max"];
+	        this.movement = source["movement"];
+	        this.movement_max = source["movement_max"];
+	        this.level = source["level"];
+	        this.experience = source["experience"];
+	        this.opponent_name = source["opponent_name"];
+	        this.opponent_health = source["opponent_health"];
+	        this.opponent_health_max = source["opponent_health_max"];
+	        this.opponent_level = source["opponent_level"];
+	        this.variables = source["variables"];
+	    }
+	}
+	export class ProxyConfig {
+	    url: string;
+	    username?: string;
+	    password?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProxyConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.username = source["username"];
+	        this.password = source["password"];
+	    }
+	}
+	export class TLSConfig {
+	    server_name?: string;
+	    ca_file?: string;
+	    pins?: string[];
+	    pin_only?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TLSConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.server_name = source["server_name"];
+	        this.ca_file = source["ca_file"];
+	        this.pins = source["pins"];
+	        this.pin_only = source["pin_only"];
+	    }
+	}
+
+}
+
+export namespace tick {
+	
+	export class Settings {
+	    seconds: number;
+	    pattern?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seconds = source["seconds"];
+	        this.pattern = source["pattern"];
+	    }
+	}
+	export class Status {
+	    seconds: number;
+	    // Go type: time
+	    last: any;
+	    // Go type: time
+	    next: any;
+	    remaining: number;
+	    synced: boolean;
+	    count: number;
+	    pattern?: string;
+	    learned?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seconds = source["seconds"];
+	        this.last = this.convertValues(source["last"], null);
+	        this.next = this.convertValues(source["next"], null);
+	        this.remaining = source["remaining"];
+	        this.synced = source["synced"];
+	        this.count = source["count"];
+	        this.pattern = source["pattern"];
+	        this.learned = source["learned"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace timeline {
+	
+	export class Visit {
+	    seq: number;
+	    // Go type: time
+	    time: any;
+	    room_id: string;
+	    name: string;
+	    x: number;
+	    y: number;
+	    z: number;
+	    direction?: string;
+	    new: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Visit(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.seq = source["seq"];
+	        this.time = this.convertValues(source["time"], null);
+	        this.room_id = source["room_id"];
+	        this.name = source["name"];
+	        this.x = source["x"];
+	        this.y = source["y"];
+	        this.z = source["z"];
+	        this.direction = source["direction"];
+	        this.new = source["new"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
+export namespace trigger {
+	
+	export class Alias {
+	    name: string;
+	    pattern: string;
+	    commands: string;
+	    group?: string;
+	    enabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Alias(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.pattern = source["pattern"];
+	        this.commands = source["commands"];
+	        this.group = source["group"];
+	        this.enabled = source["enabled"];
+	    }
+	}
+	export class Skipped {
+	    kind: string;
+	    name: string;
+	    reason: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Skipped(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.kind = source["kind"];
+	        this.name = source["name"];
+	        this.reason = source["reason"];
+	    }
+	}
+	export class Trigger {
+	    name: string;
+	    pattern?: string;
+	    hook?: string;
+	    commands: string;
+	    group?: string;
+	    enabled: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new Trigger(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.pattern = source["pattern"];
+	        this.hook = source["hook"];
+	        this.commands = source["commands"];
+	        this.group = source["group"];
+	        this.enabled = source["enabled"];
+	    }
+	}
+	export class ImportResult {
+	    source: string;
+	    triggers: Trigger[];
+	    aliases: Alias[];
+	    skipped: Skipped[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.triggers = this.convertValues(source["triggers"], Trigger);
+	        this.aliases = this.convertValues(source["aliases"], Alias);
+	        this.skipped = this.convertValues(source["skipped"], Skipped);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+
+}
+
+export namespace vault {
+	
+	export class Status {
+	    exists: boolean;
+	    locked: boolean;
+	    mode?: string;
+	    names: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new Status(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.exists = source["exists"];
+	        this.locked = source["locked"];
+	        this.mode = source["mode"];
+	        this.names = source["names"];
+	    }
+	}
+
+}
+
+export namespace vitals {
+	
+	export class Settings {
+	    pattern?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pattern = source["pattern"];
+	    }
+	}
+	export class Vitals {
+	    // Go type: time
+	    time: any;
+	    hp: number;
+	    max_hp: number;
+	    mp: number;
+	    max_mp: number;
+	    stamina: number;
+	    max_stamina: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Vitals(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = this.convertValues(source["time"], null);
+	        this.hp = source["hp"];
+	        this.max_hp = source["max_hp"];
+	        this.mp = source["mp"];
+	        this.max_mp = source["max_mp"];
+	        this.stamina = source["stamina"];
+	        this.max_stamina = source["max_stamina"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+
+}
+
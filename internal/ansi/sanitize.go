@@ -0,0 +1,207 @@
+package ansi
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// state is where the scanner is in an escape sequence, after the VT500
+// parser: https://vt100.net/emu/dec_ansi_parser
+type state int
+
+const (
+	ground              state = iota
+	escape                    // After ESC
+	escapeIntermediate        // ESC followed by intermediate bytes
+	csiParam                  // In a CSI's parameters
+	csiIntermediate           // After a CSI's parameters
+	csiIgnore                 // In a malformed CSI, waiting for its end
+	controlString             // In an OSC, DCS, SOS, PM or APC string
+	controlStringEscape       // After ESC in a control string, which ST needs
+)
+
+// Control characters with meaning to the scanner
+const (
+	bel = 0x07
+	can = 0x18
+	sub = 0x1a
+	esc = 0x1b
+	del = 0x7f
+
+	// 8-bit forms of the ESC sequences introducing control strings and CSI
+	c1DCS = 0x90
+	c1SOS = 0x98
+	c1CSI = 0x9b
+	c1OSC = 0x9d
+	c1PM  = 0x9e
+	c1APC = 0x9f
+)
+
+// scan walks text through an escape sequence state machine, passing on runs
+// of printable text and the parameters of each SGR sequence. Everything
+// else is consumed: other CSI sequences including DEC private modes, OSC
+// and other control strings, two-byte ESC sequences, 8-bit C1 controls,
+// C0 controls other than tab and newline, invalid UTF-8, and sequences that
+// are malformed or cut off at the end of text.
+func scan(text string, onText func(string), onSGR func(string)) {
+	var run strings.Builder
+	flush := func() {
+		if run.Len() > 0 {
+			onText(run.String())
+			run.Reset()
+		}
+	}
+
+	current := ground
+	paramStart := 0
+	bellEnds := false // Only OSC strings may end with BEL
+	for i := 0; i < len(text); {
+		r, size := utf8.DecodeRuneInString(text[i:])
+
+		// Some controls mean the same thing in any state
+		switch {
+		case r == utf8.RuneError && size == 1:
+			current = ground
+			i += size
+			continue
+		case r == can || r == sub:
+			current = ground
+			i += size
+			continue
+		case r == esc:
+			if current == controlString {
+				current = controlStringEscape
+			} else {
+				current = escape
+			}
+			i += size
+			continue
+		case r >= 0x80 && r <= 0x9f:
+			switch r {
+			case c1CSI:
+				current, paramStart = csiParam, i+size
+			case c1OSC:
+				current, bellEnds = controlString, true
+			case c1DCS, c1SOS, c1PM, c1APC:
+				current, bellEnds = controlString, false
+			default:
+				// Including ST, which ends a control string
+				current = ground
+			}
+			i += size
+			continue
+		}
+
+		// Anything unexpected ends a sequence early and is read again as
+		// ordinary input, so a broken escape can't swallow the text after it
+		reprocess := false
+		switch current {
+		case ground:
+			if (r >= 0x20 && r != del) || r == '\t' || r == '\n' {
+				run.WriteString(text[i : i+size])
+			}
+
+		case escape:
+			switch {
+			case r == '[':
+				current, paramStart = csiParam, i+size
+			case r == ']':
+				current, bellEnds = controlString, true
+			case r == 'P' || r == 'X' || r == '^' || r == '_':
+				current, bellEnds = controlString, false
+			case r >= 0x20 && r <= 0x2f:
+				current = escapeIntermediate
+			case r >= 0x30 && r <= 0x7e:
+				current = ground
+			default:
+				current, reprocess = ground, true
+			}
+
+		case escapeIntermediate:
+			switch {
+			case r >= 0x20 && r <= 0x2f:
+			case r >= 0x30 && r <= 0x7e:
+				current = ground
+			default:
+				current, reprocess = ground, true
+			}
+
+		case csiParam:
+			switch {
+			case r >= 0x30 && r <= 0x3f:
+			case r >= 0x20 && r <= 0x2f:
+				current = csiIntermediate
+			case r >= 0x40 && r <= 0x7e:
+				if params := text[paramStart:i]; r == 'm' && isSGR(params) {
+					flush()
+					onSGR(params)
+				}
+				current = ground
+			default:
+				current, reprocess = ground, true
+			}
+
+		case csiIntermediate, csiIgnore:
+			switch {
+			case r >= 0x20 && r <= 0x2f:
+			case r >= 0x30 && r <= 0x3f:
+				current = csiIgnore
+			case r >= 0x40 && r <= 0x7e:
+				current = ground
+			default:
+				current, reprocess = ground, true
+			}
+
+		case controlString:
+			if r == bel && bellEnds {
+				current = ground
+			}
+
+		case controlStringEscape:
+			if r == '\\' {
+				current = ground
+			} else {
+				// ESC ends the string and starts another sequence
+				current, reprocess = escape, true
+			}
+		}
+
+		if !reprocess {
+			i += size
+		}
+	}
+	flush()
+}
+
+// isSGR reports whether CSI parameters ending in m set graphic rendition,
+// rather than being a private sequence that happens to end the same way
+func isSGR(params string) bool {
+	for i := 0; i < len(params); i++ {
+		if (params[i] < '0' || params[i] > '9') && params[i] != ';' {
+			return false
+		}
+	}
+	return true
+}
+
+// Sanitize returns text with every escape sequence and control character
+// removed except SGR colour sequences, so it's safe to show in a terminal
+func Sanitize(text string) string {
+	var b strings.Builder
+	scan(text, func(segment string) {
+		b.WriteString(segment)
+	}, func(params string) {
+		b.WriteString("\x1b[" + params + "m")
+	})
+	return b.String()
+}
+
+// Strip returns text with all escape sequences and control characters
+// removed
+func Strip(text string) string {
+	var b strings.Builder
+	scan(text, func(segment string) {
+		b.WriteString(segment)
+	}, func(string) {})
+	return b.String()
+}
@@ -0,0 +1,140 @@
+package ansi
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// hostile is a corpus of inputs a server, or someone echoed by one, could
+// send to leak control bytes into descriptions, prompts or the terminal
+var hostile = []string{
+	"plain text",
+	"\x1b[1;31mred\x1b[0m and plain",
+	"\x1b[38;5;208morange\x1b[38;2;1;2;3mrgb",
+	"\x1b[2J\x1b[H\x1b[255;255Hcleared",
+	"\x1b[?25l\x1b[?1049hhidden cursor\x1b[?1049l\x1b[?25h",
+	"\x1b[?1000;1006hmouse",
+	"\x1b[>cdevice attributes\x1b[6n",
+	"\x1b[?5mprivate m",
+	"\x1b[38:2::255:0:0mcolon",
+	"\x1b]0;evil title\x07after title",
+	"\x1b]8;;http://example.com\x1b\\link\x1b]8;;\x1b\\",
+	"\x1b]52;c;ZWNobyBwd25lZA==\x07clipboard",
+	"\x1b]0;unterminated title",
+	"\x1bPq#0;2;0;0;0#0~~@@vv@@~~$\x1b\\sixel",
+	"\x1bP$qm\x1b\\decrqss",
+	"\x1bX sos \x1b\\\x1b^ pm \x1b\\\x1b_ apc \x1b\\strings",
+	"\x1b_Ga=T,f=100;AAAA\x1b\\kitty",
+	"\x1b7saved\x1b8\x1bc\x1b(B\x1b)0charsets",
+	"\x1b[31",
+	"\x1b[",
+	"\x1b",
+	"\x1b\x1b[31mdouble",
+	"\x1b[1;2\x1b[32mrestarted",
+	"\x1b[12\ncut by newline",
+	"\x1b[1$\x07x",
+	"\x1b[1 1mignored",
+	"\x1b]0;title\x1b[31mnot string end",
+	"\x1b[3\x18cancelled",
+	"\x1b]0;title\x1acancelled",
+	"\u009b31mc1 csi\u009b0m",
+	"\u009d0;c1 title\u009cafter",
+	"\u0090c1 dcs\u009c\u0085\u008d",
+	"\x9b31mraw c1 byte",
+	"\xff\xfe\xc0\xafinvalid utf-8",
+	"bell\x07back\x08space\rreturn\x00null\x7fdelete",
+	"tab\tnew\nline",
+	"ünïcödé ☃ text",
+	strings.Repeat("\x1b[", 1000) + "m",
+	strings.Repeat("\x1b]", 100) + "x",
+	strings.Repeat("9;", 5000) + "m",
+}
+
+// sgrPattern is the only escape sequence Sanitize may leave in
+var sgrPattern = regexp.MustCompile(`\x1b\[[0-9;]*m`)
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		in, sanitized, stripped string
+	}{
+		{"\x1b[1;31mred\x1b[0m", "\x1b[1;31mred\x1b[0m", "red"},
+		{"\x1b[2J\x1b[Hroom", "room", "room"},
+		{"\x1b[?25lcursor", "cursor", "cursor"},
+		{"\x1b]0;title\x07after", "after", "after"},
+		{"\x1b]8;;url\x1b\\link\x1b]8;;\x1b\\", "link", "link"},
+		{"\x1bPdata\x1b\\text", "text", "text"},
+		{"\x1b7a\x1b8b", "ab", "ab"},
+		{"cut\x1b[31", "cut", "cut"},
+		{"\x1b[12\nline", "\nline", "\nline"},
+		{"\x1b]0;title\x1b[32mgreen", "\x1b[32mgreen", "green"},
+		{"\u009b31mc1\u009d0;t\u009cx", "\x1b[31mc1x", "c1x"},
+		{"a\x07b\x00c\rd\x7fe\xfff", "abcdef", "abcdef"},
+		{"\x1b[38:2::1:2:3mcolon", "colon", "colon"},
+		{"tab\there ünï", "tab\there ünï", "tab\there ünï"},
+	}
+	for _, test := range tests {
+		if got := Sanitize(test.in); got != test.sanitized {
+			t.Errorf("Sanitize(%q) = %q, want %q", test.in, got, test.sanitized)
+		}
+		if got := Strip(test.in); got != test.stripped {
+			t.Errorf("Strip(%q) = %q, want %q", test.in, got, test.stripped)
+		}
+	}
+}
+
+func TestSpans(t *testing.T) {
+	spans := Spans("\x1b]0;t\x07\x1b[1;31mred\x1b[?25l\x1b[0m plain")
+	if len(spans) != 2 {
+		t.Fatalf("got %d spans, want 2: %+v", len(spans), spans)
+	}
+	if spans[0].Text != "red" || spans[0].FG != palette[1] || !spans[0].Bold {
+		t.Errorf("first span = %+v", spans[0])
+	}
+	if spans[1].Text != " plain" || spans[1].FG != "" || spans[1].Bold {
+		t.Errorf("second span = %+v", spans[1])
+	}
+}
+
+func FuzzSanitize(f *testing.F) {
+	for _, input := range hostile {
+		f.Add(input)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		stripped := Strip(input)
+		checkClean(t, "Strip", input, stripped)
+
+		sanitized := Sanitize(input)
+		checkClean(t, "Sanitize", input, sgrPattern.ReplaceAllString(sanitized, ""))
+		if again := Sanitize(sanitized); again != sanitized {
+			t.Errorf("Sanitize not idempotent on %q: %q then %q", input, sanitized, again)
+		}
+		if again := Strip(sanitized); again != stripped {
+			t.Errorf("Strip(Sanitize(%q)) = %q, want %q", input, again, stripped)
+		}
+
+		var text strings.Builder
+		for _, span := range Spans(input) {
+			text.WriteString(span.Text)
+		}
+		if text.String() != stripped {
+			t.Errorf("Spans(%q) text = %q, want %q", input, text.String(), stripped)
+		}
+	})
+}
+
+// checkClean fails if output has anything but printable text, tabs and
+// newlines
+func checkClean(t *testing.T, name, input, output string) {
+	t.Helper()
+	if !utf8.ValidString(output) {
+		t.Errorf("%s(%q) = %q, invalid UTF-8", name, input, output)
+	}
+	for _, r := range output {
+		if (r < 0x20 && r != '\t' && r != '\n') || (r >= 0x7f && r <= 0x9f) {
+			t.Errorf("%s(%q) = %q, contains control %U", name, input, output, r)
+			return
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package ansi
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Span is a run of text sharing the same SGR styling
+type Span struct {
+	Text      string `json:"text"`
+	FG        string `json:"fg,omitempty"` // CSS colour, empty for the default
+	BG        string `json:"bg,omitempty"`
+	Bold      bool   `json:"bold,omitempty"`
+	Italic    bool   `json:"italic,omitempty"`
+	Underline bool   `json:"underline,omitempty"`
+}
+
+// Palette for the 16 standard colours, matching frontend/src/ansi.jsx so
+// spans render the same as the legacy HTML conversion
+var palette = [16]string{
+	"#000000", "#cd0000", "#00cd00", "#cdcd00", "#0000ee", "#cd00cd", "#00cdcd", "#e5e5e5",
+	"#7f7f7f", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff",
+}
+
+// style is the SGR state carried between spans
+type style struct {
+	fg, bg                  string
+	bold, italic, underline bool
+}
+
+// Spans converts text containing ANSI SGR sequences into styled spans.
+// Non-colour control sequences are dropped.
+func Spans(text string) []Span {
+	var spans []Span
+	var current style
+
+	scan(text, func(segment string) {
+		spans = append(spans, Span{
+			Text:      segment,
+			FG:        current.fg,
+			BG:        current.bg,
+			Bold:      current.bold,
+			Italic:    current.italic,
+			Underline: current.underline,
+		})
+	}, current.apply)
+
+	return spans
+}
+
+// apply updates the style from the parameters of one SGR sequence
+func (s *style) apply(params string) {
+	if params == "" {
+		*s = style{}
+		return
+	}
+
+	codes := strings.Split(params, ";")
+	for i := 0; i < len(codes); i++ {
+		code, _ := strconv.Atoi(codes[i])
+		switch {
+		case code == 0:
+			*s = style{}
+		case code == 1:
+			s.bold = true
+		case code == 3:
+			s.italic = true
+		case code == 4:
+			s.underline = true
+		case code == 22:
+			s.bold = false
+		case code == 23:
+			s.italic = false
+		case code == 24:
+			s.underline = false
+		case code >= 30 && code <= 37:
+			s.fg = palette[code-30]
+		case code == 39:
+			s.fg = ""
+		case code >= 40 && code <= 47:
+			s.bg = palette[code-40]
+		case code == 49:
+			s.bg = ""
+		case code >= 90 && code <= 97:
+			s.fg = palette[code-90+8]
+		case code >= 100 && code <= 107:
+			s.bg = palette[code-100+8]
+		case code == 38 || code == 48:
+			colour, used := extendedColour(codes[i+1:])
+			i += used
+			if code == 38 {
+				s.fg = colour
+			} else {
+				s.bg = colour
+			}
+		}
+	}
+}
+
+// extendedColour parses the arguments of a 38/48 sequence (5;n or 2;r;g;b)
+// and returns the colour plus how many arguments were consumed
+func extendedColour(args []string) (string, int) {
+	if len(args) == 0 {
+		return "", 0
+	}
+
+	switch args[0] {
+	case "5":
+		if len(args) < 2 {
+			return "", len(args)
+		}
+		n, _ := strconv.Atoi(args[1])
+		return colour256(n), 2
+	case "2":
+		if len(args) < 4 {
+			return "", len(args)
+		}
+		r, _ := strconv.Atoi(args[1])
+		g, _ := strconv.Atoi(args[2])
+		b, _ := strconv.Atoi(args[3])
+		return fmt.Sprintf("#%02x%02x%02x", clamp(r), clamp(g), clamp(b)), 4
+	}
+
+	return "", 1
+}
+
+// colour256 maps an xterm 256-colour index to a CSS colour
+func colour256(n int) string {
+	switch {
+	case n < 0 || n > 255:
+		return ""
+	case n < 16:
+		return palette[n]
+	case n < 232:
+		// 6x6x6 colour cube
+		n -= 16
+		levels := [6]int{0, 95, 135, 175, 215, 255}
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[(n/6)%6], levels[n%6])
+	default:
+		grey := 8 + (n-232)*10
+		return fmt.Sprintf("#%02x%02x%02x", grey, grey, grey)
+	}
+}
+
+func clamp(v int) int {
+	if v < 0 {
+		return 0
+	}
+	if v > 255 {
+		return 255
+	}
+	return v
+}
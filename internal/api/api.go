@@ -0,0 +1,369 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/telnet"
+)
+
+var logger = logging.For("API")
+
+// Server exposes an engine over a small REST API so bots and alternative
+// frontends can drive it without the GUI
+type Server struct {
+	engine *engine.Engine
+	token  string
+	mux    *http.ServeMux
+}
+
+// NewServer creates an API for e. Every request must carry token as a
+// bearer token; an empty one is refused, so the API is never left open.
+func NewServer(e *engine.Engine, token string) (*Server, error) {
+	if token == "" {
+		return nil, fmt.Errorf("the API needs a token")
+	}
+	s := &Server{engine: e, token: token, mux: http.NewServeMux()}
+
+	s.mux.HandleFunc("/api/connect", s.post(s.handleConnect))
+	s.mux.HandleFunc("/api/disconnect", s.post(s.handleDisconnect))
+	s.mux.HandleFunc("/api/send", s.post(s.handleSend))
+	s.mux.HandleFunc("/api/state", s.get(s.handleState))
+	s.mux.HandleFunc("/api/output", s.get(s.handleOutput))
+	s.mux.HandleFunc("/api/output/stream", s.get(s.handleStream))
+	s.mux.HandleFunc("/api/room", s.get(s.handleRoom))
+	s.mux.HandleFunc("/api/map", s.get(s.handleMap))
+	s.mux.HandleFunc("/api/map/path", s.get(s.handlePath))
+	s.mux.HandleFunc("/api/timeline", s.get(s.handleTimeline))
+	s.mux.HandleFunc("/api/image", s.post(s.handleImage))
+
+	return s, nil
+}
+
+// GenerateToken returns a random token for when none is given
+func GenerateToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// ServeHTTP implements http.Handler. Browsers are turned away before the
+// token is checked: a web page the player has open could otherwise reach
+// the API on localhost, directly or by rebinding its own name to it.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Header.Get("Origin") != "" {
+		writeError(w, http.StatusForbidden, fmt.Errorf("requests from web pages are not allowed"))
+		return
+	}
+	if !isLoopbackHost(r.Host) {
+		writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not allowed", r.Host))
+		return
+	}
+	given := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) != 1 {
+		writeError(w, http.StatusUnauthorized, fmt.Errorf("unauthorised"))
+		return
+	}
+	s.mux.ServeHTTP(w, r)
+}
+
+// isLoopbackHost reports whether a Host header names this machine
+func isLoopbackHost(host string) bool {
+	if name, _, err := net.SplitHostPort(host); err == nil {
+		host = name
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// get restricts a handler to GET requests
+func (s *Server) get(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("use GET"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// post restricts a handler to POST requests with a JSON body, which a web
+// page can't send without the browser asking first
+func (s *Server) post(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("use POST"))
+			return
+		}
+		if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+			writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("use Content-Type: application/json"))
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(data); err != nil {
+		logger.Warn("failed to write response", "error", err)
+	}
+}
+
+// writeError writes a JSON error response
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+// readJSON decodes a request body
+func readJSON(r *http.Request, v interface{}) error {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Host string            `json:"host"`
+		Port string            `json:"port"`
+		TLS  *telnet.TLSConfig `json:"tls,omitempty"`
+	}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if req.Host == "" || req.Port == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("host and port are required"))
+		return
+	}
+	s.engine.Session.SetOptions(telnet.Options{TLS: req.TLS})
+
+	if err := s.engine.Connect(req.Host, req.Port); err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"state": string(s.engine.State())})
+}
+
+func (s *Server) handleDisconnect(w http.ResponseWriter, r *http.Request) {
+	if err := s.engine.Disconnect(); err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"state": string(s.engine.State())})
+}
+
+func (s *Server) handleSend(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Command string `json:"command"`
+	}
+	if err := readJSON(r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := s.engine.Input(req.Command); err != nil {
+		writeError(w, http.StatusConflict, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]bool{"sent": true})
+}
+
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"state":     s.engine.State(),
+		"connected": s.engine.Session.IsConnected(),
+		"server":    s.engine.ServerName(),
+		"stats":     s.engine.Stats.Snapshot(),
+	})
+}
+
+// handleOutput returns output after ?since=<seq>, for polling clients
+func (s *Server) handleOutput(w http.ResponseWriter, r *http.Request) {
+	since, _ := strconv.ParseInt(r.URL.Query().Get("since"), 10, 64)
+	writeJSON(w, http.StatusOK, s.engine.Output.Since(since))
+}
+
+// handleStream streams output entries as server-sent events
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("streaming unsupported"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	entries := make(chan output.Entry, 256)
+	unsubscribe := s.engine.Output.Subscribe(func(entry output.Entry) {
+		select {
+		case entries <- entry:
+		default:
+			// Slow reader; it can catch up with /api/output?since=
+		}
+	})
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case entry := <-entries:
+			data, err := json.Marshal(entry)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %d\ndata: %s\n\n", entry.Seq, data)
+			flusher.Flush()
+		}
+	}
+}
+
+func (s *Server) handleRoom(w http.ResponseWriter, r *http.Request) {
+	room, ok := s.engine.Rooms.Current()
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"known":    ok,
+		"room":     room,
+		"entities": s.engine.Rooms.Entities(),
+	})
+}
+
+func (s *Server) handleMap(w http.ResponseWriter, r *http.Request) {
+	radius, _ := strconv.Atoi(r.URL.Query().Get("radius"))
+	if radius > 0 {
+		writeJSON(w, http.StatusOK, s.engine.Mapper.GetMinimap(radius))
+		return
+	}
+	writeJSON(w, http.StatusOK, s.engine.Mapper.GetMapStats())
+}
+
+// handlePath returns directions to ?to=<room id or name>
+func (s *Server) handlePath(w http.ResponseWriter, r *http.Request) {
+	path, err := s.engine.Mapper.PathTo(r.URL.Query().Get("to"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]string{"path": path})
+}
+
+// handleTimeline returns the rooms visited between ?from= and ?to=, each
+// either RFC 3339 or a duration ago such as 1h. Either may be left out.
+func (s *Server) handleTimeline(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	from, err := parseMoment(r.URL.Query().Get("from"), now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	to, err := parseMoment(r.URL.Query().Get("to"), now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.engine.Timeline.Range(from, to))
+}
+
+// parseMoment reads a time as RFC 3339 or a duration before now; empty is
+// the zero time
+func parseMoment(value string, now time.Time) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if ago, err := time.ParseDuration(value); err == nil {
+		return now.Add(-ago), nil
+	}
+	moment, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: use RFC 3339 or a duration like 1h", value)
+	}
+	return moment, nil
+}
+
+// handleImage returns the current room's image, generating one if needed
+// or if {"regenerate": true}
+func (s *Server) handleImage(w http.ResponseWriter, r *http.Request) {
+	if s.engine.Images == nil {
+		writeError(w, http.StatusServiceUnavailable, fmt.Errorf("image generation is disabled"))
+		return
+	}
+
+	var req struct {
+		Regenerate bool   `json:"regenerate"`
+		Prompt     string `json:"prompt"`
+	}
+	if r.ContentLength > 0 {
+		if err := readJSON(r, &req); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	room, ok := s.engine.Rooms.Current()
+	if !ok {
+		writeError(w, http.StatusConflict, fmt.Errorf("no room data available"))
+		return
+	}
+
+	if !req.Regenerate && req.Prompt == "" {
+		if image, cached := s.engine.Images.Cached(room); cached {
+			writeJSON(w, http.StatusOK, map[string]interface{}{"room": room.Name, "image": image, "cached": true})
+			return
+		}
+	}
+
+	image, err := s.engine.Images.Generate(r.Context(), room, req.Prompt)
+	if err != nil {
+		writeError(w, http.StatusBadGateway, err)
+		return
+	}
+	s.engine.Stats.ImageGenerated()
+	writeJSON(w, http.StatusOK, map[string]interface{}{"room": room.Name, "image": image, "cached": false})
+}
+
+// ListenAndServe runs the API until ctx is cancelled
+func (s *Server) ListenAndServe(ctx context.Context, addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		server.Shutdown(shutdownCtx)
+	}()
+
+	logger.Info("listening", "url", fmt.Sprintf("http://%s/api", addr))
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRequestChecks(t *testing.T) {
+	server, err := NewServer(nil, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		method      string
+		host        string
+		token       string
+		origin      string
+		contentType string
+		want        int
+	}{
+		// Requests that pass every check reach the mux, which has no such path
+		{"accepted", http.MethodGet, "127.0.0.1:4070", "secret", "", "", http.StatusNotFound},
+		{"localhost", http.MethodGet, "localhost:4070", "secret", "", "", http.StatusNotFound},
+		{"ipv6 loopback", http.MethodGet, "[::1]:4070", "secret", "", "", http.StatusNotFound},
+		{"no token", http.MethodGet, "127.0.0.1:4070", "", "", "", http.StatusUnauthorized},
+		{"wrong token", http.MethodGet, "127.0.0.1:4070", "guess", "", "", http.StatusUnauthorized},
+		{"from a web page", http.MethodGet, "127.0.0.1:4070", "secret", "https://evil.example", "", http.StatusForbidden},
+		{"rebound name", http.MethodGet, "evil.example:4070", "secret", "", "", http.StatusForbidden},
+		{"lan address", http.MethodGet, "192.168.1.2:4070", "secret", "", "", http.StatusForbidden},
+		{"form post", http.MethodPost, "127.0.0.1:4070", "secret", "", "text/plain", http.StatusUnsupportedMediaType},
+		{"post without type", http.MethodPost, "127.0.0.1:4070", "secret", "", "", http.StatusUnsupportedMediaType},
+	}
+	for _, test := range tests {
+		path := "/api/nothing"
+		if test.method == http.MethodPost {
+			path = "/api/send"
+		}
+		request := httptest.NewRequest(test.method, path, nil)
+		request.Host = test.host
+		if test.token != "" {
+			request.Header.Set("Authorization", "Bearer "+test.token)
+		}
+		if test.origin != "" {
+			request.Header.Set("Origin", test.origin)
+		}
+		if test.contentType != "" {
+			request.Header.Set("Content-Type", test.contentType)
+		}
+		recorder := httptest.NewRecorder()
+		server.ServeHTTP(recorder, request)
+		if recorder.Code != test.want {
+			t.Errorf("%s: status %d, want %d", test.name, recorder.Code, test.want)
+		}
+	}
+}
+
+func TestNewServerNeedsToken(t *testing.T) {
+	if _, err := NewServer(nil, ""); err == nil {
+		t.Error("NewServer with no token succeeded, want an error")
+	}
+}
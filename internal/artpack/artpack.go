@@ -0,0 +1,235 @@
+package artpack
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/mapper"
+)
+
+var logger = logging.For("ArtPack")
+
+// Extension is the file extension art packs are saved with. They're zip
+// archives, so any zip tool can look inside.
+const Extension = ".seemudart"
+
+// Version is bumped if the pack layout changes incompatibly
+const Version = 1
+
+// Files within a pack
+const (
+	manifestFile = "manifest.json"
+	mapFile      = "map.json"
+	imageDir     = "images/"
+)
+
+// maxImageSize stops a pack from filling the disk with one entry
+const maxImageSize = 32 << 20
+
+// signatures start every image in each format, at the given offset
+var signatures = map[engine.ImageFormat]struct {
+	offset int
+	magic  string
+}{
+	engine.FormatPNG:  {0, "\x89PNG\r\n\x1a\n"},
+	engine.FormatWebP: {8, "WEBP"},
+	engine.FormatAVIF: {4, "ftyp"},
+}
+
+// Manifest describes a pack and how each image in it was made
+type Manifest struct {
+	Version    int                  `json:"version"`
+	ServerName string               `json:"server_name"`
+	Created    time.Time            `json:"created"`
+	Rooms      int                  `json:"rooms"`
+	Images     []engine.ImageRecord `json:"images"`
+}
+
+// Result summarises an import
+type Result struct {
+	ServerName string `json:"server_name"`
+	Rooms      int    `json:"rooms"`   // Rooms new to the map
+	Images     int    `json:"images"`  // Images added to the cache
+	Kept       int    `json:"kept"`    // Rooms whose own image was kept over the pack's
+	Skipped    int    `json:"skipped"` // Images that weren't valid
+}
+
+// Export writes a server's map and the cached images of its rooms as a
+// pack, returning how many images it holds. Rooms without an image are
+// still in the map, so the recipient can draw them.
+func Export(w io.Writer, m *mapper.Mapper, serverName string, cache *engine.SDImageService) (int, error) {
+	mapJSON, err := m.MarshalMap(serverName)
+	if err != nil {
+		return 0, err
+	}
+	var data mapper.MapData
+	if err := json.Unmarshal(mapJSON, &data); err != nil {
+		return 0, fmt.Errorf("failed to read map: %w", err)
+	}
+
+	manifest := Manifest{
+		Version:    Version,
+		ServerName: serverName,
+		Created:    time.Now(),
+		Images:     []engine.ImageRecord{},
+	}
+	archive := zip.NewWriter(w)
+
+	if data.Graph != nil {
+		manifest.Rooms = len(data.Graph.Rooms)
+		for id := range data.Graph.Rooms {
+			record, image, exists := cache.ImageData(id)
+			if !exists {
+				continue
+			}
+			// Images are already compressed
+			entry, err := archive.CreateHeader(&zip.FileHeader{Name: imageDir + record.File, Method: zip.Store, Modified: record.Created})
+			if err != nil {
+				return 0, fmt.Errorf("failed to write art pack: %w", err)
+			}
+			if _, err := entry.Write(image); err != nil {
+				return 0, fmt.Errorf("failed to write art pack: %w", err)
+			}
+			manifest.Images = append(manifest.Images, record)
+		}
+	}
+
+	if err := writeEntry(archive, mapFile, mapJSON); err != nil {
+		return 0, err
+	}
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal art pack manifest: %w", err)
+	}
+	if err := writeEntry(archive, manifestFile, manifestJSON); err != nil {
+		return 0, err
+	}
+	if err := archive.Close(); err != nil {
+		return 0, fmt.Errorf("failed to write art pack: %w", err)
+	}
+	return len(manifest.Images), nil
+}
+
+// writeEntry adds a compressed file to a pack
+func writeEntry(archive *zip.Writer, name string, data []byte) error {
+	entry, err := archive.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to write art pack: %w", err)
+	}
+	if _, err := entry.Write(data); err != nil {
+		return fmt.Errorf("failed to write art pack: %w", err)
+	}
+	return nil
+}
+
+// Open reads a pack's manifest and map without importing anything, so the
+// caller can decide which server's map it belongs in
+func Open(r io.ReaderAt, size int64) (*zip.Reader, *Manifest, *mapper.MapData, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to open art pack: %w", err)
+	}
+
+	var manifest Manifest
+	if err := readJSON(archive, manifestFile, &manifest); err != nil {
+		return nil, nil, nil, err
+	}
+	if manifest.Version > Version {
+		return nil, nil, nil, fmt.Errorf("art pack is version %d, newer than this version of SeeMUD reads", manifest.Version)
+	}
+	var data mapper.MapData
+	if err := readJSON(archive, mapFile, &data); err != nil {
+		return nil, nil, nil, err
+	}
+	return archive, &manifest, &data, nil
+}
+
+// Import merges an opened pack's map into m and adds its images to the
+// cache. Rooms already mapped or drawn are left as they are.
+func Import(archive *zip.Reader, manifest *Manifest, data *mapper.MapData, m *mapper.Mapper, cache *engine.SDImageService) *Result {
+	result := &Result{ServerName: manifest.ServerName}
+	result.Rooms = m.MergeMap(data)
+
+	entries := make(map[string]*zip.File)
+	for _, f := range archive.File {
+		if strings.HasPrefix(f.Name, imageDir) {
+			entries[strings.TrimPrefix(f.Name, imageDir)] = f
+		}
+	}
+
+	for _, record := range manifest.Images {
+		format, err := engine.ParseImageFormat(strings.TrimPrefix(path.Ext(record.File), "."))
+		f, exists := entries[record.File]
+		if err != nil || !exists || record.File != record.RoomID+format.Extension() {
+			result.Skipped++
+			continue
+		}
+		image, err := readImage(f, format)
+		if err != nil {
+			logger.Warn("skipping art pack image", "room", record.Name, "error", err)
+			result.Skipped++
+			continue
+		}
+		added, err := cache.AddImage(record, image)
+		switch {
+		case err != nil:
+			logger.Warn("skipping art pack image", "room", record.Name, "error", err)
+			result.Skipped++
+		case added:
+			result.Images++
+		default:
+			result.Kept++
+		}
+	}
+
+	logger.Info("imported art pack", "server", manifest.ServerName, "rooms", result.Rooms,
+		"images", result.Images, "kept", result.Kept, "skipped", result.Skipped)
+	return result
+}
+
+// readJSON decodes a JSON file from a pack
+func readJSON(archive *zip.Reader, name string, v any) error {
+	f, err := archive.Open(name)
+	if err != nil {
+		return fmt.Errorf("not an art pack: missing %s", name)
+	}
+	defer f.Close()
+	if err := json.NewDecoder(f).Decode(v); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return nil
+}
+
+// readImage reads an image from a pack, checking it's in the format its name
+// says and of sensible size
+func readImage(f *zip.File, format engine.ImageFormat) ([]byte, error) {
+	if f.UncompressedSize64 > maxImageSize {
+		return nil, fmt.Errorf("image is too large")
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	image, err := io.ReadAll(io.LimitReader(rc, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(image) > maxImageSize {
+		return nil, fmt.Errorf("image is too large")
+	}
+	signature := signatures[format]
+	if len(image) < signature.offset || !bytes.HasPrefix(image[signature.offset:], []byte(signature.magic)) {
+		return nil, fmt.Errorf("not a %s image", format)
+	}
+	return image, nil
+}
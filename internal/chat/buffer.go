@@ -0,0 +1,140 @@
+package chat
+
+import (
+	"sync"
+	"time"
+)
+
+// Kind identifies the type of conversation a message belongs to
+type Kind string
+
+const (
+	KindSay     Kind = "say"
+	KindTell    Kind = "tell"
+	KindChannel Kind = "channel"
+)
+
+// Message is a single captured chat line
+type Message struct {
+	Seq      int64     `json:"seq"`
+	Time     time.Time `json:"time"`
+	Kind     Kind      `json:"kind"`
+	Channel  string    `json:"channel"` // Buffer name: "say", "tell" or the channel name
+	Speaker  string    `json:"speaker"`
+	Text     string    `json:"text"`
+	Raw      string    `json:"raw"`
+	Outgoing bool      `json:"outgoing"`
+}
+
+// Buffer is a fixed-size ring of chat messages with live subscribers
+type Buffer struct {
+	mutex       sync.RWMutex
+	messages    []Message
+	start       int // Index of the oldest message
+	count       int
+	subscribers map[int]chan Message
+	nextSubID   int
+}
+
+// NewBuffer creates a ring buffer holding at most capacity messages
+func NewBuffer(capacity int) *Buffer {
+	if capacity <= 0 {
+		capacity = 1
+	}
+	return &Buffer{
+		messages:    make([]Message, capacity),
+		subscribers: make(map[int]chan Message),
+	}
+}
+
+// Add appends a message, overwriting the oldest once the buffer is full
+func (b *Buffer) Add(msg Message) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	capacity := len(b.messages)
+	if b.count < capacity {
+		b.messages[(b.start+b.count)%capacity] = msg
+		b.count++
+	} else {
+		b.messages[b.start] = msg
+		b.start = (b.start + 1) % capacity
+	}
+
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- msg:
+		default:
+			// Slow subscriber, drop rather than block the output pipeline
+		}
+	}
+}
+
+// Since returns all buffered messages with a sequence number greater than seq
+func (b *Buffer) Since(seq int64) []Message {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	result := make([]Message, 0, b.count)
+	for i := 0; i < b.count; i++ {
+		msg := b.messages[(b.start+i)%len(b.messages)]
+		if msg.Seq > seq {
+			result = append(result, msg)
+		}
+	}
+	return result
+}
+
+// Recent returns up to n of the newest messages, oldest first
+func (b *Buffer) Recent(n int) []Message {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if n <= 0 || n > b.count {
+		n = b.count
+	}
+
+	result := make([]Message, 0, n)
+	for i := b.count - n; i < b.count; i++ {
+		result = append(result, b.messages[(b.start+i)%len(b.messages)])
+	}
+	return result
+}
+
+// Len returns the number of buffered messages
+func (b *Buffer) Len() int {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+	return b.count
+}
+
+// Clear removes all buffered messages
+func (b *Buffer) Clear() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.start = 0
+	b.count = 0
+}
+
+// Subscribe returns a channel receiving every new message and a function
+// that cancels the subscription
+func (b *Buffer) Subscribe(size int) (<-chan Message, func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	id := b.nextSubID
+	b.nextSubID++
+	ch := make(chan Message, size)
+	b.subscribers[id] = ch
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			b.mutex.Lock()
+			delete(b.subscribers, id)
+			b.mutex.Unlock()
+			close(ch)
+		})
+	}
+}
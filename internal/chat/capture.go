@@ -0,0 +1,139 @@
+package chat
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/parser"
+)
+
+// DefaultBufferSize is how many messages each chat buffer keeps
+const DefaultBufferSize = 500
+
+// Capture routes parsed chat lines into dedicated buffers, one for says,
+// one for tells and one per named channel, so chat isn't buried by room
+// and combat output
+type Capture struct {
+	mutex      sync.RWMutex
+	buffers    map[string]*Buffer
+	all        *Buffer // Every chat message regardless of buffer
+	bufferSize int
+	seq        int64
+}
+
+// NewCapture creates a capture with buffers of the given size
+func NewCapture(bufferSize int) *Capture {
+	if bufferSize <= 0 {
+		bufferSize = DefaultBufferSize
+	}
+	return &Capture{
+		buffers:    make(map[string]*Buffer),
+		all:        NewBuffer(bufferSize),
+		bufferSize: bufferSize,
+	}
+}
+
+// Route captures a parsed line if it is chat. It returns the stored
+// message and true, or false if the line was not chat.
+func (c *Capture) Route(parsed *parser.ParsedOutput) (Message, bool) {
+	var kind Kind
+	var name string
+
+	switch parsed.Type {
+	case parser.TypeSay:
+		kind, name = KindSay, string(KindSay)
+	case parser.TypeTell:
+		kind, name = KindTell, string(KindTell)
+	case parser.TypeChannel:
+		kind, name = KindChannel, parsed.Channel
+	default:
+		return Message{}, false
+	}
+
+	c.mutex.Lock()
+	c.seq++
+	msg := Message{
+		Seq:      c.seq,
+		Time:     time.Now(),
+		Kind:     kind,
+		Channel:  name,
+		Speaker:  parsed.Speaker,
+		Text:     parsed.Message,
+		Raw:      parsed.CleanText,
+		Outgoing: parsed.Outgoing,
+	}
+	buffer, exists := c.buffers[name]
+	if !exists {
+		buffer = NewBuffer(c.bufferSize)
+		c.buffers[name] = buffer
+	}
+	c.mutex.Unlock()
+
+	buffer.Add(msg)
+	c.all.Add(msg)
+
+	return msg, true
+}
+
+// Buffer returns the named buffer, or the combined buffer for an empty name.
+// Unknown names return nil.
+func (c *Capture) Buffer(name string) *Buffer {
+	if name == "" {
+		return c.all
+	}
+
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.buffers[name]
+}
+
+// Channels returns the names of all buffers that have received messages
+func (c *Capture) Channels() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	names := make([]string, 0, len(c.buffers))
+	for name := range c.buffers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Since returns messages newer than seq from the named buffer
+func (c *Capture) Since(name string, seq int64) []Message {
+	buffer := c.Buffer(name)
+	if buffer == nil {
+		return []Message{}
+	}
+	return buffer.Since(seq)
+}
+
+// Subscribe subscribes to the named buffer (or all chat for an empty name)
+func (c *Capture) Subscribe(name string, size int) (<-chan Message, func()) {
+	if name == "" {
+		return c.all.Subscribe(size)
+	}
+
+	c.mutex.Lock()
+	buffer, exists := c.buffers[name]
+	if !exists {
+		buffer = NewBuffer(c.bufferSize)
+		c.buffers[name] = buffer
+	}
+	c.mutex.Unlock()
+
+	return buffer.Subscribe(size)
+}
+
+// Clear empties every buffer
+func (c *Capture) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, buffer := range c.buffers {
+		buffer.Clear()
+	}
+	c.all.Clear()
+}
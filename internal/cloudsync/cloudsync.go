@@ -0,0 +1,289 @@
+package cloudsync
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/mapper"
+)
+
+var logger = logging.For("Sync")
+
+// Backend kinds
+const (
+	KindWebDAV = "webdav"
+	KindS3     = "s3"
+)
+
+// Settings configures where maps and images are synced to
+type Settings struct {
+	Enabled  bool   `json:"enabled"`
+	Kind     string `json:"kind"`     // webdav or s3
+	URL      string `json:"url"`      // WebDAV folder, or the S3 endpoint
+	Bucket   string `json:"bucket"`   // S3 only
+	Region   string `json:"region"`   // S3 only
+	Username string `json:"username"` // WebDAV user or S3 access key
+	Password string `json:"password"` // WebDAV password or S3 secret key
+	Prefix   string `json:"prefix"`   // Folder everything is kept under
+}
+
+// DefaultSettings keeps everything under a seemud folder
+func DefaultSettings() Settings {
+	return Settings{
+		Kind:   KindWebDAV,
+		Region: "us-east-1",
+		Prefix: "seemud",
+	}
+}
+
+// ErrNotFound is returned by backends for files that don't exist yet
+var ErrNotFound = errors.New("not found on the sync server")
+
+// Backend stores files by slash-separated key
+type Backend interface {
+	Get(ctx context.Context, key string) ([]byte, error)
+	Put(ctx context.Context, key string, data []byte) error
+}
+
+// New creates the backend the settings describe
+func New(settings Settings) (Backend, error) {
+	if strings.TrimSpace(settings.URL) == "" {
+		return nil, fmt.Errorf("sync server URL is required")
+	}
+	switch settings.Kind {
+	case KindWebDAV:
+		return NewWebDAV(settings.URL, settings.Username, settings.Password)
+	case KindS3:
+		return NewS3(settings.URL, settings.Bucket, settings.Region, settings.Username, settings.Password)
+	}
+	return nil, fmt.Errorf("unknown sync backend %q (webdav or s3)", settings.Kind)
+}
+
+// Result is what a sync moved in each direction
+type Result struct {
+	ServerName   string    `json:"server_name"`
+	Rooms        int       `json:"rooms"`         // Rooms pulled into the local map
+	ImagesPulled int       `json:"images_pulled"` // Images added to the local cache
+	ImagesPushed int       `json:"images_pushed"` // Images uploaded
+	Kept         int       `json:"kept"`          // Rooms whose local image was kept over the remote one
+	Skipped      int       `json:"skipped"`       // Remote images that couldn't be used
+	Finished     time.Time `json:"finished"`
+}
+
+// remoteIndex lists the images synced for a server. It has the same shape
+// as the local cache index.
+type remoteIndex struct {
+	Version int                  `json:"version"`
+	Images  []engine.ImageRecord `json:"images"`
+}
+
+// maxImageSize bounds a downloaded image
+const maxImageSize = 32 << 20
+
+// syncTimeout bounds a whole sync
+const syncTimeout = 10 * time.Minute
+
+// Syncer holds the sync settings and runs one sync at a time
+type Syncer struct {
+	mutex    sync.RWMutex
+	settings Settings
+	last     *Result
+	running  sync.Mutex
+}
+
+// NewSyncer creates a syncer with sync turned off
+func NewSyncer() *Syncer {
+	return &Syncer{settings: DefaultSettings()}
+}
+
+// Settings returns the current settings
+func (s *Syncer) Settings() Settings {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings
+}
+
+// Apply stores new settings, checking an enabled backend can be created
+func (s *Syncer) Apply(settings Settings) error {
+	if settings.Prefix = strings.Trim(settings.Prefix, "/"); settings.Prefix == "" {
+		settings.Prefix = DefaultSettings().Prefix
+	}
+	if settings.Enabled {
+		if _, err := New(settings); err != nil {
+			return err
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.settings = settings
+	return nil
+}
+
+// Enabled reports whether syncing is turned on
+func (s *Syncer) Enabled() bool {
+	return s.Settings().Enabled
+}
+
+// Last returns the most recent sync's result, if there has been one
+func (s *Syncer) Last() (Result, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.last == nil {
+		return Result{}, false
+	}
+	return *s.last, true
+}
+
+// Sync pulls a server's map and images from the remote, merging them into
+// m and the cache, then pushes the merged result back. Rooms and images
+// both sides have are resolved the way the map merge does: what's here
+// already is kept. The caller saves the map afterwards.
+func (s *Syncer) Sync(serverName string, m *mapper.Mapper, cache *engine.SDImageService) (*Result, error) {
+	settings := s.Settings()
+	backend, err := New(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), syncTimeout)
+	defer cancel()
+
+	ns := datadir.Namespace(serverName)
+	mapKey := path.Join(settings.Prefix, "maps", ns+".json")
+	imageDir := path.Join(settings.Prefix, "images", ns)
+	indexKey := path.Join(imageDir, "index.json")
+	result := &Result{ServerName: serverName}
+
+	// Pull
+	if data, err := backend.Get(ctx, mapKey); err == nil {
+		var mapData mapper.MapData
+		if err := json.Unmarshal(data, &mapData); err != nil {
+			return nil, fmt.Errorf("failed to read synced map: %w", err)
+		}
+		result.Rooms = m.MergeMap(&mapData)
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("failed to download map: %w", err)
+	}
+
+	index := remoteIndex{Version: 1}
+	if data, err := backend.Get(ctx, indexKey); err == nil {
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("failed to read synced image index: %w", err)
+		}
+	} else if !errors.Is(err, ErrNotFound) {
+		return nil, fmt.Errorf("failed to download image index: %w", err)
+	}
+	remote := make(map[string]engine.ImageRecord, len(index.Images))
+	for _, record := range index.Images {
+		remote[record.RoomID] = record
+		if _, _, exists := cache.ImageData(record.RoomID); exists {
+			continue
+		}
+		if err := s.pullImage(ctx, backend, imageDir, record, cache, result); err != nil {
+			logger.Warn("skipping synced image", "room", record.Name, "error", err)
+			result.Skipped++
+		}
+	}
+
+	// Push
+	mapJSON, err := m.MarshalMap(serverName)
+	if err != nil {
+		return nil, err
+	}
+	if err := backend.Put(ctx, mapKey, mapJSON); err != nil {
+		return nil, fmt.Errorf("failed to upload map: %w", err)
+	}
+
+	var mapData mapper.MapData
+	if err := json.Unmarshal(mapJSON, &mapData); err != nil {
+		return nil, fmt.Errorf("failed to read map: %w", err)
+	}
+	if mapData.Graph != nil {
+		for id := range mapData.Graph.Rooms {
+			record, image, exists := cache.ImageData(id)
+			if !exists {
+				continue
+			}
+			if current, synced := remote[id]; synced {
+				if current.File == record.File && current.Created.Equal(record.Created) {
+					continue
+				}
+				result.Kept++
+			}
+			if err := backend.Put(ctx, path.Join(imageDir, record.File), image); err != nil {
+				return nil, fmt.Errorf("failed to upload image: %w", err)
+			}
+			remote[id] = record
+			result.ImagesPushed++
+		}
+	}
+
+	// Images only the remote has stay listed, so other machines still get them
+	index.Images = make([]engine.ImageRecord, 0, len(remote))
+	for _, record := range remote {
+		index.Images = append(index.Images, record)
+	}
+	indexJSON, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal image index: %w", err)
+	}
+	if err := backend.Put(ctx, indexKey, indexJSON); err != nil {
+		return nil, fmt.Errorf("failed to upload image index: %w", err)
+	}
+
+	result.Finished = time.Now()
+	s.mutex.Lock()
+	s.last = result
+	s.mutex.Unlock()
+
+	logger.Info("synced", "server", serverName, "rooms", result.Rooms, "pulled", result.ImagesPulled,
+		"pushed", result.ImagesPushed, "kept", result.Kept, "skipped", result.Skipped)
+	return result, nil
+}
+
+// pullImage downloads a remote image into the cache
+func (s *Syncer) pullImage(ctx context.Context, backend Backend, imageDir string, record engine.ImageRecord, cache *engine.SDImageService, result *Result) error {
+	format, err := engine.ParseImageFormat(strings.TrimPrefix(path.Ext(record.File), "."))
+	if err != nil || record.File != record.RoomID+format.Extension() {
+		return fmt.Errorf("invalid image file %q", record.File)
+	}
+	image, err := backend.Get(ctx, path.Join(imageDir, record.File))
+	if err != nil {
+		return err
+	}
+	added, err := cache.AddImage(record, image)
+	if err != nil {
+		return err
+	}
+	if added {
+		result.ImagesPulled++
+	}
+	return nil
+}
+
+// readBody reads a response body, refusing anything larger than an image
+// could be
+func readBody(r io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, maxImageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxImageSize {
+		return nil, fmt.Errorf("file is too large")
+	}
+	return data, nil
+}
@@ -0,0 +1,122 @@
+package cloudsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// WebDAV stores files on a WebDAV server, such as Nextcloud or a NAS
+type WebDAV struct {
+	base     *url.URL
+	username string
+	password string
+	client   *http.Client
+}
+
+// NewWebDAV creates a backend rooted at the given folder URL
+func NewWebDAV(rawURL, username, password string) (*WebDAV, error) {
+	base, err := url.Parse(strings.TrimRight(rawURL, "/"))
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid WebDAV URL %q", rawURL)
+	}
+	return &WebDAV{
+		base:     base,
+		username: username,
+		password: password,
+		client:   &http.Client{Timeout: time.Minute},
+	}, nil
+}
+
+// url returns the URL of a key
+func (w *WebDAV) url(key string) string {
+	u := *w.base
+	u.Path = path.Join(u.Path, key)
+	return u.String()
+}
+
+// do sends an authenticated request
+func (w *WebDAV) do(ctx context.Context, method, key string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, w.url(key), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if w.username != "" {
+		req.SetBasicAuth(w.username, w.password)
+	}
+	return w.client.Do(req)
+}
+
+// Get downloads a file
+func (w *WebDAV) Get(ctx context.Context, key string) ([]byte, error) {
+	resp, err := w.do(ctx, http.MethodGet, key, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("GET %s: %s", key, resp.Status)
+	}
+	return readBody(resp.Body)
+}
+
+// Put uploads a file, creating the folders it's in if the server wants them
+// first
+func (w *WebDAV) Put(ctx context.Context, key string, data []byte) error {
+	status, err := w.put(ctx, key, data)
+	if err != nil {
+		return err
+	}
+	if status == http.StatusConflict {
+		if err := w.mkdirAll(ctx, path.Dir(key)); err != nil {
+			return err
+		}
+		if status, err = w.put(ctx, key, data); err != nil {
+			return err
+		}
+	}
+	if status < 200 || status > 299 {
+		return fmt.Errorf("PUT %s: %s", key, http.StatusText(status))
+	}
+	return nil
+}
+
+// put uploads a file, returning the status code
+func (w *WebDAV) put(ctx context.Context, key string, data []byte) (int, error) {
+	resp, err := w.do(ctx, http.MethodPut, key, data)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// mkdirAll creates a folder and its parents. Servers answer 405 for folders
+// that already exist.
+func (w *WebDAV) mkdirAll(ctx context.Context, dir string) error {
+	current := ""
+	for _, part := range strings.Split(dir, "/") {
+		if part == "" || part == "." {
+			continue
+		}
+		current = path.Join(current, part)
+		resp, err := w.do(ctx, "MKCOL", current+"/", nil)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusMethodNotAllowed {
+			return fmt.Errorf("MKCOL %s: %s", current, resp.Status)
+		}
+	}
+	return nil
+}
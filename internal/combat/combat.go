@@ -0,0 +1,322 @@
+package combat
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/events"
+	"seemud-gui/internal/parser"
+)
+
+// Ways a fight ends, as the detector reports them
+const (
+	OutcomeKill    = "kill"
+	OutcomeDeath   = "death"
+	OutcomeTimeout = "timeout"
+)
+
+// Opponent is what happened between the player and one enemy in a fight
+type Opponent struct {
+	Name        string `json:"name"`
+	DamageDealt int    `json:"damage_dealt"`
+	DamageTaken int    `json:"damage_taken"`
+	HitsDealt   int    `json:"hits_dealt"` // Blows landed, counted whether or not the MUD says how hard
+	HitsTaken   int    `json:"hits_taken"`
+	Condition   string `json:"condition,omitempty"` // Last condition line, e.g. "has a few scratches"
+	Health      int    `json:"health"`              // Rough percent from the condition; -1 until one is seen
+	Killed      bool   `json:"killed"`
+}
+
+// Summary is the current fight, or the last one, and the session's totals
+type Summary struct {
+	InCombat    bool       `json:"in_combat"`
+	Started     time.Time  `json:"started"`
+	Seconds     float64    `json:"seconds"` // Length of the fight so far, or of the last
+	DamageDealt int        `json:"damage_dealt"`
+	DamageTaken int        `json:"damage_taken"`
+	DPS         float64    `json:"dps"`       // Damage dealt per second
+	TakenPS     float64    `json:"taken_ps"`  // Damage taken per second
+	Outcome     string     `json:"outcome"`   // How the last fight ended; empty while fighting
+	Opponents   []Opponent `json:"opponents"` // Most damage dealt first
+
+	Kills        int     `json:"kills"` // This session
+	Deaths       int     `json:"deaths"`
+	Fights       int     `json:"fights"`
+	TotalSeconds float64 `json:"total_seconds"` // Time spent fighting this session
+}
+
+// conditions are the condition lines common to Diku-derived MUDs, with
+// roughly how healthy each means the opponent is
+var conditions = []struct {
+	phrase string
+	health int
+}{
+	{"is in excellent condition", 100},
+	{"is in perfect health", 100},
+	{"has a few scratches", 90},
+	{"has some small wounds and bruises", 75},
+	{"has some minor wounds", 75},
+	{"has quite a few wounds", 50},
+	{"has some big nasty wounds and scratches", 30},
+	{"looks pretty hurt", 15},
+	{"is bleeding awfully from big wounds", 10},
+	{"is in awful condition", 5},
+	{"is nearly dead", 5},
+	{"is mortally wounded", 1},
+}
+
+var (
+	conditionRegex = regexp.MustCompile(`(?i)^(.+?) (` + conditionPhrases() + `)[.!]?$`)
+	// Damage comes as "for 12", "12 damage", "12 points of damage" or "[12]"
+	damageRegex = regexp.MustCompile(`(?i)\bfor (\d+)\b|\b(\d+) (?:points? of )?(?:damage|dmg)\b|[\[(](\d+)[\])]`)
+	missRegex   = regexp.MustCompile(`(?i)\bmiss(?:es)?\b`)
+	articles    = regexp.MustCompile(`(?i)^(?:the|an?)\s+`)
+	damageTag   = regexp.MustCompile(`\s*[\[(]\d+[\])]$`)
+)
+
+// conditionPhrases joins the condition phrases for a regex
+func conditionPhrases() string {
+	phrases := make([]string, len(conditions))
+	for i, c := range conditions {
+		phrases[i] = regexp.QuoteMeta(c.phrase)
+	}
+	return strings.Join(phrases, "|")
+}
+
+// healthOf returns how healthy a condition phrase means
+func healthOf(phrase string) int {
+	for _, c := range conditions {
+		if strings.EqualFold(c.phrase, phrase) {
+			return c.health
+		}
+	}
+	return -1
+}
+
+// Tracker follows fights: when they start and stop, the damage dealt and
+// taken per opponent, their condition and the session's kills
+type Tracker struct {
+	mutex     sync.Mutex
+	inCombat  bool
+	started   time.Time
+	lastBlow  time.Time
+	ended     time.Time
+	outcome   string
+	opponents map[string]*Opponent // Keyed by lowercased name
+	last      string               // Key of the opponent last fought
+	kills     int
+	deaths    int
+	fights    int
+	total     time.Duration // Fights finished this session
+	listeners []func(Summary)
+}
+
+// NewTracker creates a tracker with no fights yet
+func NewTracker() *Tracker {
+	return &Tracker{opponents: make(map[string]*Opponent)}
+}
+
+// OnChange registers a listener for every change to the summary
+func (t *Tracker) OnChange(fn func(Summary)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.listeners = append(t.listeners, fn)
+}
+
+// HandleParsed records blows and condition lines. A blow starts a fight if
+// one isn't on, so the first one counts before the detector says so.
+func (t *Tracker) HandleParsed(parsed *parser.ParsedOutput) {
+	text := strings.TrimSpace(parsed.CleanText)
+
+	t.mutex.Lock()
+	changed := false
+	if parsed.Type == parser.TypeCombat && parsed.Opponent != "" {
+		if !t.inCombat {
+			t.start()
+		}
+		opponent := t.opponent(parsed.Opponent)
+		damage := damageIn(text)
+		landed := !missRegex.MatchString(text)
+		if parsed.Incoming {
+			opponent.DamageTaken += damage
+			if landed {
+				opponent.HitsTaken++
+			}
+		} else {
+			opponent.DamageDealt += damage
+			if landed {
+				opponent.HitsDealt++
+			}
+		}
+		t.lastBlow = time.Now()
+		changed = true
+	} else if t.inCombat {
+		if match := conditionRegex.FindStringSubmatch(text); match != nil {
+			opponent := t.opponent(match[1])
+			opponent.Condition = strings.ToLower(match[2])
+			opponent.Health = healthOf(match[2])
+			changed = true
+		}
+	}
+	t.notify(changed)
+}
+
+// HandleEvent starts and ends fights as the detector sees them
+func (t *Tracker) HandleEvent(event events.Event) {
+	t.mutex.Lock()
+	changed := false
+	switch event.Kind {
+	case events.KindCombatStart:
+		if !t.inCombat {
+			t.start()
+			changed = true
+		}
+	case events.KindCombatEnd:
+		if t.inCombat {
+			t.end(event.Fields["reason"])
+			changed = true
+		}
+	case events.KindDeath:
+		// The detector ends the fight too, but death counts even outside one
+		t.deaths++
+		changed = true
+	}
+	t.notify(changed)
+}
+
+// notify releases the lock and tells the listeners if the summary changed
+func (t *Tracker) notify(changed bool) {
+	if !changed {
+		t.mutex.Unlock()
+		return
+	}
+	summary, listeners := t.summary(), t.listeners
+	t.mutex.Unlock()
+
+	for _, fn := range listeners {
+		fn(summary)
+	}
+}
+
+// start begins a new fight; the caller must hold the lock
+func (t *Tracker) start() {
+	t.inCombat = true
+	t.started, t.lastBlow = time.Now(), time.Now()
+	t.ended = time.Time{}
+	t.outcome = ""
+	t.opponents = make(map[string]*Opponent)
+	t.last = ""
+	t.fights++
+}
+
+// end finishes the fight; the caller must hold the lock
+func (t *Tracker) end(outcome string) {
+	t.inCombat = false
+	t.outcome = outcome
+	// A fight that timed out was over at the last blow, not when noticed
+	t.ended = time.Now()
+	if outcome == OutcomeTimeout {
+		t.ended = t.lastBlow
+	}
+	t.total += t.ended.Sub(t.started)
+	if outcome == OutcomeKill {
+		t.kills++
+		if opponent, exists := t.opponents[t.last]; exists {
+			opponent.Killed = true
+		}
+	}
+}
+
+// opponent finds or adds an opponent by name, ignoring any article or
+// damage tag; the caller must hold the lock
+func (t *Tracker) opponent(name string) *Opponent {
+	name = damageTag.ReplaceAllString(strings.TrimSpace(name), "")
+	name = articles.ReplaceAllString(name, "")
+	key := strings.ToLower(name)
+	opponent, exists := t.opponents[key]
+	if !exists {
+		opponent = &Opponent{Name: name, Health: -1}
+		t.opponents[key] = opponent
+	}
+	t.last = key
+	return opponent
+}
+
+// damageIn returns the damage a combat line reports, or 0 if it doesn't
+func damageIn(text string) int {
+	match := damageRegex.FindStringSubmatch(text)
+	if match == nil {
+		return 0
+	}
+	for _, group := range match[1:] {
+		if group != "" {
+			damage, _ := strconv.Atoi(group)
+			return damage
+		}
+	}
+	return 0
+}
+
+// Summary returns the current or last fight and the session's totals
+func (t *Tracker) Summary() Summary {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.summary()
+}
+
+// summary builds the summary; the caller must hold the lock
+func (t *Tracker) summary() Summary {
+	summary := Summary{
+		InCombat:  t.inCombat,
+		Started:   t.started,
+		Outcome:   t.outcome,
+		Opponents: make([]Opponent, 0, len(t.opponents)),
+		Kills:     t.kills,
+		Deaths:    t.deaths,
+		Fights:    t.fights,
+	}
+	for _, opponent := range t.opponents {
+		summary.Opponents = append(summary.Opponents, *opponent)
+		summary.DamageDealt += opponent.DamageDealt
+		summary.DamageTaken += opponent.DamageTaken
+	}
+	sort.Slice(summary.Opponents, func(i, j int) bool {
+		if summary.Opponents[i].DamageDealt != summary.Opponents[j].DamageDealt {
+			return summary.Opponents[i].DamageDealt > summary.Opponents[j].DamageDealt
+		}
+		return summary.Opponents[i].Name < summary.Opponents[j].Name
+	})
+
+	var length time.Duration
+	total := t.total
+	if t.inCombat {
+		length = time.Since(t.started)
+		total += length
+	} else if !t.started.IsZero() {
+		length = t.ended.Sub(t.started)
+	}
+	summary.Seconds = length.Seconds()
+	summary.TotalSeconds = total.Seconds()
+	// A fight under a second would make wild rates
+	if length >= time.Second {
+		summary.DPS = float64(summary.DamageDealt) / length.Seconds()
+		summary.TakenPS = float64(summary.DamageTaken) / length.Seconds()
+	}
+	return summary
+}
+
+// Reset forgets the fights and the session's totals, e.g. on connecting
+func (t *Tracker) Reset() {
+	t.mutex.Lock()
+	t.inCombat = false
+	t.started, t.lastBlow, t.ended = time.Time{}, time.Time{}, time.Time{}
+	t.outcome = ""
+	t.opponents = make(map[string]*Opponent)
+	t.last = ""
+	t.kills, t.deaths, t.fights, t.total = 0, 0, 0, 0
+	t.notify(true)
+}
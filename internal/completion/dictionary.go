@@ -0,0 +1,146 @@
+package completion
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"seemud-gui/internal/parser"
+)
+
+// Kind says where a word was seen
+type Kind string
+
+const (
+	KindItem   Kind = "item"
+	KindMob    Kind = "mob"
+	KindPlayer Kind = "player"
+	KindExit   Kind = "exit"
+)
+
+// maxResults caps how many completions are offered at once
+const maxResults = 20
+
+// entry is a known word and how often it has been seen
+type entry struct {
+	word  string
+	kind  Kind
+	count int
+	seq   int64 // When it was last seen, for recency ordering
+}
+
+// Dictionary collects nouns seen in parsed output for tab completion
+type Dictionary struct {
+	mutex sync.RWMutex
+	words map[string]*entry // Keyed by lowercased word
+	seq   int64
+}
+
+// NewDictionary creates an empty dictionary
+func NewDictionary() *Dictionary {
+	return &Dictionary{words: make(map[string]*entry)}
+}
+
+// stopWords are never offered as completions
+var stopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "some": true, "of": true,
+	"and": true, "is": true, "are": true, "here": true, "with": true,
+}
+
+// Observe adds the nouns from a parsed line
+func (d *Dictionary) Observe(parsed *parser.ParsedOutput) {
+	for _, item := range parsed.Items {
+		d.AddPhrase(item, KindItem)
+	}
+	for _, mob := range parsed.Mobs {
+		d.AddPhrase(mob, KindMob)
+	}
+	for _, exit := range parsed.Exits {
+		d.Add(exit, KindExit)
+	}
+	if parsed.Speaker != "" && !parsed.Outgoing {
+		d.Add(parsed.Speaker, KindPlayer)
+	}
+	if parsed.Opponent != "" {
+		d.AddPhrase(parsed.Opponent, KindMob)
+	}
+}
+
+// AddPhrase adds each significant word of a name like "a rusty sword"
+func (d *Dictionary) AddPhrase(phrase string, kind Kind) {
+	for _, word := range strings.FieldsFunc(phrase, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' && r != '\''
+	}) {
+		d.Add(word, kind)
+	}
+}
+
+// Add records a single word
+func (d *Dictionary) Add(word string, kind Kind) {
+	word = strings.TrimSpace(word)
+	key := strings.ToLower(word)
+	if len(key) < 2 || stopWords[key] {
+		return
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.seq++
+	e, exists := d.words[key]
+	if !exists {
+		// Players keep their capitalisation, everything else is lowercased
+		if kind != KindPlayer {
+			word = key
+		}
+		e = &entry{word: word, kind: kind}
+		d.words[key] = e
+	}
+	e.count++
+	e.seq = d.seq
+}
+
+// Complete returns known words starting with the last word of input,
+// most recently seen first
+func (d *Dictionary) Complete(input string) []string {
+	prefix := input
+	if i := strings.LastIndexAny(input, " \t"); i >= 0 {
+		prefix = input[i+1:]
+	}
+	prefix = strings.ToLower(prefix)
+	if prefix == "" {
+		return []string{}
+	}
+
+	d.mutex.RLock()
+	var matches []*entry
+	for key, e := range d.words {
+		if strings.HasPrefix(key, prefix) && key != prefix {
+			matches = append(matches, e)
+		}
+	}
+	d.mutex.RUnlock()
+
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].seq > matches[j].seq
+	})
+	if len(matches) > maxResults {
+		matches = matches[:maxResults]
+	}
+
+	words := make([]string, 0, len(matches))
+	for _, e := range matches {
+		words = append(words, e.word)
+	}
+	return words
+}
+
+// Clear forgets every word, e.g. at the start of a new session
+func (d *Dictionary) Clear() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	d.words = make(map[string]*entry)
+	d.seq = 0
+}
@@ -0,0 +1,242 @@
+package cooldown
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/safefile"
+)
+
+// Kind says what a timer counts down to
+type Kind string
+
+const (
+	KindCooldown Kind = "cooldown" // Until the skill can be used again
+	KindDuration Kind = "duration" // Until the effect wears off
+)
+
+// Definition describes a skill or spell whose use message starts a timer
+type Definition struct {
+	Name     string `json:"name"`
+	Pattern  string `json:"pattern"` // Regex matched against clean output
+	Seconds  int    `json:"seconds"`
+	Kind     Kind   `json:"kind"`
+	EndsWith string `json:"ends_with,omitempty"` // Optional regex that clears the timer early, e.g. "wears off"
+
+	regex    *regexp.Regexp
+	endRegex *regexp.Regexp
+}
+
+// Timer is a running countdown for the GUI's badges
+type Timer struct {
+	Name             string    `json:"name"`
+	Kind             Kind      `json:"kind"`
+	StartedAt        time.Time `json:"started_at"`
+	EndsAt           time.Time `json:"ends_at"`
+	RemainingSeconds int       `json:"remaining_seconds"`
+}
+
+// compile checks a definition and prepares its patterns
+func (d *Definition) compile() error {
+	d.Name = strings.TrimSpace(d.Name)
+	if d.Name == "" {
+		return fmt.Errorf("cooldown needs a name")
+	}
+	if d.Seconds <= 0 {
+		return fmt.Errorf("cooldown %q needs a positive length", d.Name)
+	}
+	if d.Kind == "" {
+		d.Kind = KindCooldown
+	}
+
+	if d.Pattern == "" {
+		return fmt.Errorf("cooldown %q needs a pattern", d.Name)
+	}
+	regex, err := regexp.Compile(d.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern for %q: %w", d.Name, err)
+	}
+	d.regex = regex
+
+	if d.EndsWith != "" {
+		endRegex, err := regexp.Compile(d.EndsWith)
+		if err != nil {
+			return fmt.Errorf("invalid end pattern for %q: %w", d.Name, err)
+		}
+		d.endRegex = endRegex
+	}
+	return nil
+}
+
+// Tracker starts timers from use messages. Definitions are kept per server
+// since every MUD words its messages differently.
+type Tracker struct {
+	mutex       sync.RWMutex
+	dir         string
+	server      string
+	definitions map[string]*Definition // Keyed by lowercased name
+	timers      map[string]Timer
+}
+
+// NewTracker creates a tracker storing definitions under dir
+func NewTracker(dir string) *Tracker {
+	return &Tracker{
+		dir:         dir,
+		definitions: make(map[string]*Definition),
+		timers:      make(map[string]Timer),
+	}
+}
+
+// Load switches to a server's definitions, clearing running timers
+func (t *Tracker) Load(server string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.server = server
+	t.definitions = make(map[string]*Definition)
+	t.timers = make(map[string]Timer)
+
+	data, err := safefile.ReadFile(t.path(), json.Valid)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cooldowns: %w", err)
+	}
+
+	var definitions []Definition
+	if err := json.Unmarshal(data, &definitions); err != nil {
+		return fmt.Errorf("failed to unmarshal cooldowns: %w", err)
+	}
+	for _, d := range definitions {
+		definition := d
+		if err := definition.compile(); err != nil {
+			continue
+		}
+		t.definitions[strings.ToLower(definition.Name)] = &definition
+	}
+	return nil
+}
+
+// path is the current server's definition file; the caller must hold the lock
+func (t *Tracker) path() string {
+	name := t.server
+	if name == "" {
+		name = "default"
+	}
+	return filepath.Join(t.dir, name+".json")
+}
+
+// Definitions returns the current server's definitions sorted by name
+func (t *Tracker) Definitions() []Definition {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.list()
+}
+
+// list returns sorted definitions; the caller must hold the lock
+func (t *Tracker) list() []Definition {
+	definitions := make([]Definition, 0, len(t.definitions))
+	for _, d := range t.definitions {
+		definitions = append(definitions, *d)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return strings.ToLower(definitions[i].Name) < strings.ToLower(definitions[j].Name)
+	})
+	return definitions
+}
+
+// SetDefinition adds or replaces a definition and saves
+func (t *Tracker) SetDefinition(d Definition) error {
+	if err := d.compile(); err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.definitions[strings.ToLower(d.Name)] = &d
+	return t.save()
+}
+
+// DeleteDefinition removes a definition and any running timer for it
+func (t *Tracker) DeleteDefinition(name string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	key := strings.ToLower(strings.TrimSpace(name))
+	if d, exists := t.definitions[key]; exists {
+		delete(t.timers, d.Name)
+	}
+	delete(t.definitions, key)
+	return t.save()
+}
+
+// save writes definitions to disk; the caller must hold the lock
+func (t *Tracker) save() error {
+	data, err := json.MarshalIndent(t.list(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal cooldowns: %w", err)
+	}
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create cooldown directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(t.path(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write cooldowns: %w", err)
+	}
+	return nil
+}
+
+// ProcessLine starts or clears timers from a line of clean output. It
+// returns true if the running timers changed.
+func (t *Tracker) ProcessLine(line string) bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	changed := false
+	for _, d := range t.definitions {
+		if d.regex.MatchString(line) {
+			t.timers[d.Name] = Timer{
+				Name:      d.Name,
+				Kind:      d.Kind,
+				StartedAt: now,
+				EndsAt:    now.Add(time.Duration(d.Seconds) * time.Second),
+			}
+			changed = true
+		} else if d.endRegex != nil && d.endRegex.MatchString(line) {
+			if _, running := t.timers[d.Name]; running {
+				delete(t.timers, d.Name)
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// Timers returns the running timers soonest first, dropping expired ones
+func (t *Tracker) Timers() []Timer {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	now := time.Now()
+	timers := make([]Timer, 0, len(t.timers))
+	for name, timer := range t.timers {
+		if !now.Before(timer.EndsAt) {
+			delete(t.timers, name)
+			continue
+		}
+		timer.RemainingSeconds = int(timer.EndsAt.Sub(now).Round(time.Second).Seconds())
+		timers = append(timers, timer)
+	}
+	sort.Slice(timers, func(i, j int) bool {
+		return timers[i].EndsAt.Before(timers[j].EndsAt)
+	})
+	return timers
+}
@@ -0,0 +1,239 @@
+package datadir
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/safefile"
+)
+
+var logger = logging.For("DataDir")
+
+// EnvVar overrides the data directory, taking precedence over the saved setting
+const EnvVar = "SEEMUD_DATA_DIR"
+
+// legacyDir is where older versions kept everything, relative to the
+// working directory
+const legacyDir = "cache"
+
+// migratedMarker is written once legacy data has been moved across
+const migratedMarker = ".migrated"
+
+// Dir is the root of everything SeeMUD stores on disk
+type Dir struct {
+	Root string `json:"root"`
+}
+
+// RoomImages is where generated room images are cached, in a directory per
+// server
+func (d Dir) RoomImages() string {
+	return filepath.Join(d.Root, "room_images")
+}
+
+// Maps is where per-server maps are saved
+func (d Dir) Maps() string {
+	return filepath.Join(d.Root, "maps")
+}
+
+// Sounds is where MSP sound files are looked up
+func (d Dir) Sounds() string {
+	return filepath.Join(d.Root, "sounds")
+}
+
+// Namespace turns a server name into a file or directory name of its own.
+// Characters that aren't safe in file names become underscores; dots are
+// kept so that hosts differing only by them don't collide.
+func Namespace(serverName string) string {
+	var safe strings.Builder
+	for _, r := range serverName {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') ||
+			r == '_' || r == '-' || r == '.' {
+			safe.WriteRune(r)
+		} else {
+			safe.WriteRune('_')
+		}
+	}
+	name := strings.TrimLeft(safe.String(), ".")
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// Join returns a path inside the data directory
+func (d Dir) Join(elem ...string) string {
+	return filepath.Join(append([]string{d.Root}, elem...)...)
+}
+
+// Default returns the platform's conventional data location: AppData on
+// Windows, Application Support on macOS and XDG_DATA_HOME elsewhere
+func Default() (string, error) {
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		base, err := os.UserConfigDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(base, "SeeMUD"), nil
+	default:
+		if base := os.Getenv("XDG_DATA_HOME"); base != "" {
+			return filepath.Join(base, "seemud"), nil
+		}
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(home, ".local", "share", "seemud"), nil
+	}
+}
+
+// overridePath is the small file holding a user-chosen data directory. It
+// lives in the config directory so it can point the data anywhere.
+func overridePath() (string, error) {
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, "seemud", "data_dir"), nil
+}
+
+// Resolve works out the data directory from the environment, the saved
+// override and the platform default, falling back to the legacy location
+func Resolve() Dir {
+	if value := strings.TrimSpace(os.Getenv(EnvVar)); value != "" {
+		return Dir{Root: value}
+	}
+
+	if path, err := overridePath(); err == nil {
+		if data, err := os.ReadFile(path); err == nil {
+			if value := strings.TrimSpace(string(data)); value != "" {
+				return Dir{Root: value}
+			}
+		}
+	}
+
+	root, err := Default()
+	if err != nil {
+		logger.Warn("no platform data directory", "using", legacyDir, "error", err)
+		return Dir{Root: legacyDir}
+	}
+	return Dir{Root: root}
+}
+
+// SetOverride saves a data directory to use from the next launch. An empty
+// dir removes the override, returning to the platform default.
+func SetOverride(dir string) error {
+	path, err := overridePath()
+	if err != nil {
+		return fmt.Errorf("failed to locate config directory: %w", err)
+	}
+
+	dir = strings.TrimSpace(dir)
+	if dir == "" {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove data directory override: %w", err)
+		}
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if err := safefile.WriteFile(path, []byte(dir+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to save data directory override: %w", err)
+	}
+	return nil
+}
+
+// Prepare creates the data directory and moves any caches left in the
+// working directory by older versions into it. Migration only runs once.
+func Prepare(d Dir) error {
+	if err := os.MkdirAll(d.Root, 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+
+	marker := d.Join(migratedMarker)
+	if _, err := os.Stat(marker); err == nil {
+		return nil
+	}
+
+	legacy, err := filepath.Abs(legacyDir)
+	if err != nil {
+		return err
+	}
+	root, err := filepath.Abs(d.Root)
+	if err != nil {
+		return err
+	}
+
+	if legacy != root {
+		for _, name := range []string{"room_images", "maps", "sounds"} {
+			from := filepath.Join(legacy, name)
+			to := filepath.Join(root, name)
+			if err := migrate(from, to); err != nil {
+				return fmt.Errorf("failed to migrate %s: %w", name, err)
+			}
+		}
+	}
+
+	return os.WriteFile(marker, []byte{}, 0644)
+}
+
+// migrate moves a legacy directory unless the destination already exists
+func migrate(from, to string) error {
+	if _, err := os.Stat(from); os.IsNotExist(err) {
+		return nil
+	}
+	if _, err := os.Stat(to); err == nil {
+		logger.Info("not migrating, destination exists", "from", from, "to", to)
+		return nil
+	}
+
+	logger.Info("migrating", "from", from, "to", to)
+
+	// Rename is instant but fails across filesystems, so fall back to copying
+	if err := os.Rename(from, to); err == nil {
+		return nil
+	}
+	return copyDir(from, to)
+}
+
+// copyDir recursively copies a directory, leaving the source in place
+func copyDir(from, to string) error {
+	return filepath.Walk(from, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(from, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(to, rel)
+
+		if info.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+
+		src, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer src.Close()
+
+		dst, err := os.Create(target)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(dst, src); err != nil {
+			dst.Close()
+			return err
+		}
+		return dst.Close()
+	})
+}
@@ -0,0 +1,801 @@
+package engine
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/combat"
+	"seemud-gui/internal/completion"
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/events"
+	"seemud-gui/internal/highlight"
+	"seemud-gui/internal/history"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/pacing"
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/renderer"
+	"seemud-gui/internal/schedule"
+	"seemud-gui/internal/session"
+	"seemud-gui/internal/statedb"
+	"seemud-gui/internal/stats"
+	"seemud-gui/internal/tick"
+	"seemud-gui/internal/timeline"
+	"seemud-gui/internal/trigger"
+)
+
+var logger = logging.For("Engine")
+
+// Config configures a new engine
+type Config struct {
+	ImageBackend  renderer.BackendSettings // Which backend draws room images, and where it is
+	ImageCacheDir string                   // Empty disables image generation
+	MapDir        string
+	TriggerFile   string // Empty keeps triggers in memory only
+	AliasFile     string // Empty keeps aliases in memory only
+	StyleFile     string // Image style presets; empty keeps them in memory only
+	HighlightFile string // Highlight watch-list; empty keeps it in memory only
+	HistoryDir    string // Commands typed on each server; empty keeps them in memory only
+	TickDir       string // Tick length and message for each server; empty keeps them in memory only
+	OutputSize    int    // Lines of scrollback to keep
+	Dialect       string // Parser dialect, empty for parser.DefaultDialect
+	NoMapping     bool   // Don't build, load or save maps
+	StateDB       string // SQLite database for maps, image metadata, triggers, history and stats; empty for none
+
+	// Condenser configures the language model that condenses descriptions
+	// into image prompts
+	Condenser renderer.CondenserSettings
+	// Extractor configures the language model that picks out items and
+	// mobs the parser isn't sure of
+	Extractor parser.ExtractorSettings
+}
+
+// DefaultConfig returns the configuration used by the GUI, storing data in
+// the resolved data directory
+func DefaultConfig() Config {
+	return ConfigFor(datadir.Resolve())
+}
+
+// ConfigFor returns the default configuration storing data under dir
+func ConfigFor(dir datadir.Dir) Config {
+	return Config{
+		ImageBackend:  imageBackendSettings(),
+		ImageCacheDir: dir.RoomImages(),
+		MapDir:        dir.Maps(),
+		TriggerFile:   dir.Join("triggers.json"),
+		AliasFile:     dir.Join("aliases.json"),
+		StyleFile:     dir.Join("styles.json"),
+		HighlightFile: dir.Join("highlights.json"),
+		HistoryDir:    dir.Join("history"),
+		TickDir:       dir.Join("ticks"),
+		OutputSize:    output.DefaultRingSize,
+		StateDB:       stateDBPath(dir),
+		Condenser:     condenserSettings(),
+		Extractor:     extractorSettings(),
+	}
+}
+
+// Environment variables that turn on condensing room descriptions with a
+// language model: an OpenAI-compatible endpoint and the model to ask
+const (
+	LLMEndpointEnvVar = "SEEMUD_LLM_ENDPOINT"
+	LLMModelEnvVar    = "SEEMUD_LLM_MODEL"
+	// LLMEntitiesEnvVar set to 1 also asks the model about items and mobs
+	LLMEntitiesEnvVar = "SEEMUD_LLM_ENTITIES"
+)
+
+// Environment variables that choose the image backend. The Stable
+// Diffusion endpoint is the GUI's SEEMUD_SD_ENDPOINT.
+const (
+	ImageBackendEnvVar    = "SEEMUD_IMAGE_BACKEND" // stable-diffusion or comfyui
+	ComfyUIEndpointEnvVar = "SEEMUD_COMFYUI_ENDPOINT"
+	ComfyUIWorkflowEnvVar = "SEEMUD_COMFYUI_WORKFLOW" // Workflow file in ComfyUI's API format
+	ComfyUIModelEnvVar    = "SEEMUD_COMFYUI_MODEL"    // Checkpoint for the workflow to load
+	// ComfyUIVariationWorkflowEnvVar is the workflow for variations of a
+	// room's image
+	ComfyUIVariationWorkflowEnvVar = "SEEMUD_COMFYUI_VARIATION_WORKFLOW"
+)
+
+// imageBackendSettings returns the image backend settings, Stable
+// Diffusion on its default port unless the environment says otherwise
+func imageBackendSettings() renderer.BackendSettings {
+	return renderer.BackendSettings{
+		Backend:         strings.TrimSpace(os.Getenv(ImageBackendEnvVar)),
+		SDEndpoint:      renderer.DefaultSDEndpoint,
+		ComfyUIEndpoint: strings.TrimSpace(os.Getenv(ComfyUIEndpointEnvVar)),
+		ComfyUIWorkflow: strings.TrimSpace(os.Getenv(ComfyUIWorkflowEnvVar)),
+		ComfyUIModel:    strings.TrimSpace(os.Getenv(ComfyUIModelEnvVar)),
+
+		ComfyUIVariationWorkflow: strings.TrimSpace(os.Getenv(ComfyUIVariationWorkflowEnvVar)),
+	}
+}
+
+// condenserSettings returns the condenser settings, enabled if an endpoint
+// is set in the environment
+func condenserSettings() renderer.CondenserSettings {
+	settings := renderer.DefaultCondenserSettings()
+	if endpoint := strings.TrimSpace(os.Getenv(LLMEndpointEnvVar)); endpoint != "" {
+		settings.Enabled = true
+		settings.Endpoint = strings.TrimRight(endpoint, "/")
+	}
+	if model := strings.TrimSpace(os.Getenv(LLMModelEnvVar)); model != "" {
+		settings.Model = model
+	}
+	return settings
+}
+
+// extractorSettings returns the entity extractor settings, enabled if
+// asked for in the environment, using the same model as the condenser
+func extractorSettings() parser.ExtractorSettings {
+	condenser := condenserSettings()
+	return parser.ExtractorSettings{
+		Enabled:  strings.TrimSpace(os.Getenv(LLMEntitiesEnvVar)) == "1",
+		Endpoint: condenser.Endpoint,
+		Model:    condenser.Model,
+	}
+}
+
+// LineHandler is called for every line of output after the engine's own
+// subsystems have seen it
+type LineHandler func(entry output.Entry, parsed *parser.ParsedOutput)
+
+// lineHandler is a registered LineHandler, numbered so it can be removed
+type lineHandler struct {
+	id      int
+	handler LineHandler
+}
+
+// SendHandler is called with every command sent on the player's behalf,
+// including alias expansions and trigger commands
+type SendHandler func(command string)
+
+// Engine is the MUD client without any UI: it owns the connection, parsing,
+// output storage, room tracking, mapping and image generation, so the Wails
+// GUI, the CLI clients and bots can all share it
+type Engine struct {
+	Session  Session
+	Output   OutputHub
+	Rooms    RoomTracker
+	Images   ImageService
+	Parser   parser.Parser // Change with SetDialect once running
+	Mapper   *mapper.Mapper
+	Events   *events.Bus
+	Stats    *stats.Tracker
+	Queue    *pacing.Queue       // Paced sending for speedwalks and other bursts
+	Schedule *schedule.Scheduler // Commands sent later or over and over
+	Triggers *trigger.Engine
+	Aliases  *trigger.Aliases
+	// Looks to draw room images in, chosen for the session or each zone
+	Styles *renderer.Styles
+	// Highlights picks out lines the player is watching for
+	Highlights *highlight.List
+	// History is the commands the player typed on this server
+	History *history.History
+	// Ticks follows the server's heartbeat
+	Ticks *tick.Tracker
+	// Combat follows fights and the damage dealt in them
+	Combat *combat.Tracker
+	// Words seen this session, for tab completion
+	Completions *completion.Dictionary
+	Metrics     *Metrics
+	// Second opinion from a language model on the items and mobs the
+	// parser has to guess at
+	Extractor *parser.EntityExtractor
+	// Trail of rooms entered, for retracing the session
+	Timeline *timeline.Timeline
+	// Optional SQLite store, nil unless configured
+	DB *statedb.DB
+
+	detector *events.Detector
+	mapping  bool // False if maps are neither built nor saved
+
+	mutex      sync.RWMutex
+	handlers   []lineHandler // In the order registered
+	nextLine   int
+	senders    []SendHandler
+	serverName string
+	host       string
+	port       string
+	closing    bool // Set while Close is quitting, so the drop isn't link-dead
+
+	// Commands the player typed while logging in, replayed on Resume
+	loginScript []string
+	replay      []string
+	resyncing   bool // Send a look once back in game after Resume
+
+	imageBackend renderer.BackendSettings // Backend profiles choose from, as configured
+}
+
+// quitTimeout is how long Close waits for the server to hang up after QUIT
+const quitTimeout = 2 * time.Second
+
+// New creates an engine with the default subsystem implementations
+func New(cfg Config) *Engine {
+	if cfg.OutputSize <= 0 {
+		cfg.OutputSize = output.DefaultRingSize
+	}
+
+	m := mapper.NewMapper()
+	if cfg.MapDir != "" {
+		m.SetDirectory(cfg.MapDir)
+	}
+	mudParser, err := parser.ForDialect(cfg.Dialect)
+	if err != nil {
+		logger.Warn("using default parser", "error", err)
+		mudParser = parser.NewWolfMUDParser()
+	}
+	// Without mapping the tracker still follows rooms, it just doesn't map them
+	tracked := m
+	if cfg.NoMapping {
+		tracked = nil
+	}
+	e := &Engine{
+		Session:     NewTelnetSession(),
+		Output:      NewRingHub(cfg.OutputSize),
+		Rooms:       NewParsedRoomTracker(tracked),
+		Parser:      mudParser,
+		Mapper:      m,
+		Extractor:   parser.NewEntityExtractor(cfg.Extractor),
+		Events:      events.NewBus(),
+		Stats:       stats.NewTracker(),
+		Completions: completion.NewDictionary(),
+		Timeline:    timeline.New(timeline.DefaultCapacity),
+		detector:    events.NewDetector(),
+		mapping:     !cfg.NoMapping,
+
+		imageBackend: cfg.ImageBackend,
+	}
+	e.Queue = pacing.NewQueue(e.dispatch)
+	e.Schedule = schedule.New(e.Input)
+	e.Extractor.OnResult(e.Rooms.Revise)
+
+	// Triggers go through the queue so a runaway one can be stopped
+	triggers, err := trigger.NewEngine(cfg.TriggerFile, func(name string, commands []string) {
+		e.Queue.Add("trigger: "+name, commands)
+	})
+	if err != nil {
+		logger.Warn("failed to load triggers", "error", err)
+	}
+	e.Triggers = triggers
+	aliases, err := trigger.NewAliases(cfg.AliasFile)
+	if err != nil {
+		logger.Warn("failed to load aliases", "error", err)
+	}
+	e.Aliases = aliases
+	styles, err := renderer.NewStyles(cfg.StyleFile)
+	if err != nil {
+		logger.Warn("failed to load image styles", "error", err)
+	}
+	e.Styles = styles
+	highlights, err := highlight.NewList(cfg.HighlightFile)
+	if err != nil {
+		logger.Warn("failed to load highlights", "error", err)
+	}
+	e.Highlights = highlights
+	e.History = history.New(cfg.HistoryDir)
+	e.Ticks = tick.NewTracker(cfg.TickDir)
+	e.Ticks.OnTick(e.publishTick)
+	e.Combat = combat.NewTracker()
+	e.Metrics = newMetrics(e)
+	e.openState(cfg.StateDB)
+	if cfg.ImageCacheDir != "" {
+		backend, err := renderer.NewBackend(cfg.ImageBackend)
+		if err != nil {
+			logger.Warn("using Stable Diffusion for images", "error", err)
+			backend = renderer.NewStableDiffusionClient(cfg.ImageBackend.SDEndpoint)
+		}
+		images := NewSDImageService(backend, m, cfg.ImageCacheDir)
+		images.OnGenerated(e.Metrics.imageGenerated)
+		images.SetCondenser(renderer.NewCondenser(cfg.Condenser))
+		images.SetStyle(e.Style)
+		e.Images = images
+	}
+
+	e.Session.Machine().OnChange(func(from, to session.State) {
+		logger.Info("connection state", "from", from, "to", to)
+
+		// Connect and disconnect are game events too, so sounds and
+		// notifications can react to them
+		if from == session.StateConnecting && to.IsConnected() {
+			e.mutex.RLock()
+			resuming := e.resyncing
+			e.mutex.RUnlock()
+
+			// A resumed session carries on counting where it left off
+			if resuming {
+				e.Stats.Resume()
+			} else {
+				e.Stats.Start()
+			}
+			e.Events.Publish(events.New(events.KindConnect, "", nil))
+		} else if to == session.StateLinkDead || (to == session.StateDisconnected && from.IsConnected()) {
+			// End the stats first so disconnect handlers see the final summary
+			active := e.Stats.Snapshot().Active
+			if final := e.Stats.End(); active {
+				e.recordSession(final)
+			}
+			e.Events.Publish(events.New(events.KindDisconnect, "", map[string]string{"state": string(to)}))
+			e.Ticks.Stop()
+		}
+	})
+
+	e.Events.Subscribe(e.Stats.HandleEvent)
+	e.Events.Subscribe(e.Triggers.HandleEvent)
+	e.Events.Subscribe(e.Combat.HandleEvent)
+	m.OnRoomChange(func(roomID string, isNew bool) {
+		if isNew {
+			e.Stats.RoomDiscovered()
+		}
+		e.recordVisit(roomID, isNew)
+	})
+
+	return e
+}
+
+// recordVisit adds a room entry to the timeline. Looking around again
+// without moving doesn't count as a new visit.
+func (e *Engine) recordVisit(roomID string, isNew bool) {
+	room := e.Mapper.GetCurrentRoom()
+	if room == nil || room.ID != roomID {
+		return
+	}
+
+	visit := timeline.Visit{
+		RoomID: roomID,
+		Name:   room.Name,
+		X:      room.X,
+		Y:      room.Y,
+		Z:      room.Z,
+		New:    isNew,
+	}
+	if last, ok := e.Timeline.Last(); ok {
+		if last.RoomID == roomID {
+			return
+		}
+		visit.Direction = e.Mapper.ExitBetween(last.RoomID, roomID)
+	}
+	e.Timeline.Record(visit)
+}
+
+// OnLine registers a handler for every parsed line and returns a function
+// removing it
+func (e *Engine) OnLine(handler LineHandler) func() {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	id := e.nextLine
+	e.nextLine++
+	e.handlers = append(e.handlers, lineHandler{id: id, handler: handler})
+
+	return func() {
+		e.mutex.Lock()
+		defer e.mutex.Unlock()
+		// A new slice, as lines being handled may still range over the old
+		handlers := make([]lineHandler, 0, len(e.handlers))
+		for _, registered := range e.handlers {
+			if registered.id != id {
+				handlers = append(handlers, registered)
+			}
+		}
+		e.handlers = handlers
+	}
+}
+
+// OnSend registers a handler for every command sent
+func (e *Engine) OnSend(handler SendHandler) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.senders = append(e.senders, handler)
+}
+
+// State returns the connection state
+func (e *Engine) State() session.State {
+	return e.Session.Machine().State()
+}
+
+// OnStateChange registers a listener for connection state changes and
+// returns a function removing it
+func (e *Engine) OnStateChange(fn session.ChangeFunc) func() {
+	return e.Session.Machine().OnChange(fn)
+}
+
+// ServerName returns the name used for per-server persistence
+func (e *Engine) ServerName() string {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.serverName
+}
+
+// Zone returns the zone the player is in, as the server reports it over
+// MSDP; empty if it doesn't
+func (e *Engine) Zone() string {
+	return e.Session.MSDP().Status().RoomArea
+}
+
+// Style returns the style room images are drawn in here: the zone's own,
+// or the session's
+func (e *Engine) Style() renderer.StylePreset {
+	return e.Styles.For(e.Zone())
+}
+
+// Connect connects to a MUD, loads its map and starts processing output
+func (e *Engine) Connect(host, port string) error {
+	return e.ConnectAs(host, port, "")
+}
+
+// ConnectAs connects like Connect, keeping the map, images and history
+// under serverName instead of host_port; empty uses host_port
+func (e *Engine) ConnectAs(host, port, serverName string) error {
+	if err := e.Session.Connect(host, port); err != nil {
+		return err
+	}
+
+	if serverName == "" {
+		serverName = fmt.Sprintf("%s_%s", host, port)
+	}
+	e.mutex.Lock()
+	e.serverName = serverName
+	e.host, e.port = host, port
+	e.closing = false
+	e.loginScript = nil
+	e.replay = nil
+	e.resyncing = false
+	e.mutex.Unlock()
+	e.Completions.Clear()
+	e.Combat.Reset()
+	if err := e.History.Load(serverName); err != nil {
+		logger.Warn("failed to load command history", "error", err)
+	}
+	if err := e.Ticks.Load(serverName); err != nil {
+		logger.Warn("failed to load tick settings", "error", err)
+	}
+
+	// Load existing map for this server
+	if e.mapping {
+		if err := e.loadMap(serverName); err != nil {
+			logger.Warn("failed to load map", "error", err)
+			// Continue anyway - we'll start a new map
+		}
+	}
+
+	// Each server has its own images, claimed from the shared cache by map
+	if images, ok := e.Images.(*SDImageService); ok {
+		images.UseServer(serverName)
+	}
+
+	// Start processing output
+	go e.processOutput(e.Session.Lines(), e.Session.Done())
+
+	return nil
+}
+
+// Resume reconnects after the link dropped, keeping the map, room and
+// scrollback. The login the player typed last time is replayed, then a look
+// puts the mapper back in the right room.
+func (e *Engine) Resume() error {
+	e.mutex.RLock()
+	host, port := e.host, e.port
+	e.mutex.RUnlock()
+
+	if host == "" {
+		return fmt.Errorf("no previous connection to resume")
+	}
+	if e.State() != session.StateLinkDead {
+		return fmt.Errorf("cannot resume from state %s", e.State())
+	}
+
+	if err := e.Session.Connect(host, port); err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	e.closing = false
+	e.replay = append([]string{}, e.loginScript...)
+	e.resyncing = true
+	e.mutex.Unlock()
+
+	logger.Info("resuming session", "host", host, "port", port)
+	go e.processOutput(e.Session.Lines(), e.Session.Done())
+
+	return nil
+}
+
+// recordLogin remembers commands typed during login so Resume can replay them
+func (e *Engine) recordLogin(command string) {
+	switch e.State() {
+	case session.StateLoginPrompt, session.StateCharacterSelect:
+		e.mutex.Lock()
+		e.loginScript = append(e.loginScript, command)
+		e.mutex.Unlock()
+	}
+}
+
+// resume drives the replayed login and the look once back in game
+func (e *Engine) resume(parsed *parser.ParsedOutput) {
+	e.mutex.Lock()
+	var next string
+	look := false
+	switch {
+	case len(e.replay) > 0 && (parsed.Type == parser.TypeLoginPrompt || parsed.Type == parser.TypeMenu):
+		next = e.replay[0]
+		e.replay = e.replay[1:]
+	case e.resyncing && e.State() == session.StateInGame:
+		e.resyncing = false
+		e.replay = nil
+		look = true
+	}
+	e.mutex.Unlock()
+
+	if next != "" {
+		if err := e.SendRaw(next); err != nil {
+			logger.Warn("failed to replay login", "error", err)
+		}
+	}
+	if look {
+		logger.Info("resumed, resynchronising position")
+		e.SendRaw("look")
+	}
+}
+
+// Disconnect saves the map and closes the connection. Scheduled commands
+// are dropped, as they were meant for this session.
+func (e *Engine) Disconnect() error {
+	e.Schedule.CancelAll()
+
+	// Save map before disconnecting
+	if err := e.SaveMap(); err != nil {
+		logger.Warn("failed to save map", "error", err)
+	}
+
+	return e.Session.Disconnect()
+}
+
+// Close quits the game cleanly if connected and saves the map and triggers.
+// It is meant for application shutdown.
+func (e *Engine) Close() error {
+	if err := e.Triggers.Save(); err != nil {
+		logger.Warn("failed to save triggers", "error", err)
+	}
+	defer e.closeState()
+
+	if !e.Session.IsConnected() {
+		if e.ServerName() == "" {
+			return nil
+		}
+		return e.SaveMap()
+	}
+
+	e.mutex.Lock()
+	e.closing = true
+	e.mutex.Unlock()
+
+	// Ask the server to log us out so the character is saved, then give it
+	// a moment to hang up before closing our end
+	if err := e.SendRaw("QUIT"); err == nil {
+		select {
+		case <-e.Session.Done():
+		case <-time.After(quitTimeout):
+		}
+	}
+
+	return e.Disconnect()
+}
+
+// isClosing reports whether Close is in progress
+func (e *Engine) isClosing() bool {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+	return e.closing
+}
+
+// SaveMap saves the current server's map. It does nothing with mapping off.
+func (e *Engine) SaveMap() error {
+	if !e.mapping {
+		return nil
+	}
+	serverName := e.ServerName()
+	if serverName == "" {
+		return fmt.Errorf("no server connected")
+	}
+	return e.saveMap(serverName)
+}
+
+// LoadMap reloads the current server's map from disk
+func (e *Engine) LoadMap() error {
+	serverName := e.ServerName()
+	if serverName == "" {
+		return fmt.Errorf("no server connected")
+	}
+	return e.loadMap(serverName)
+}
+
+// Send sends a command typed by the user, notifying the mapper of movement
+func (e *Engine) Send(command string) error {
+	if !e.Session.IsConnected() {
+		return fmt.Errorf("not connected to MUD")
+	}
+
+	// Check if this is a movement command and notify mapper
+	if isMovement, direction := mapper.IsMovementCommand(command); isMovement {
+		e.Mapper.OnMovement(direction)
+	}
+
+	if err := e.Session.Send(command); err != nil {
+		return err
+	}
+
+	e.recordLogin(command)
+	e.Stats.CommandSent()
+
+	e.mutex.RLock()
+	senders := e.senders
+	e.mutex.RUnlock()
+	for _, handler := range senders {
+		handler(command)
+	}
+	return nil
+}
+
+// Input sends a command typed by the user, expanding aliases. An alias
+// expanding to several commands goes through the queue so it can be stopped.
+// "#delay 5s stand" and "#repeat 10m save", typed or in an alias, are
+// scheduled rather than sent.
+func (e *Engine) Input(command string) error {
+	name, commands, ok := e.Aliases.Expand(command)
+	if !ok {
+		return e.dispatch(command)
+	}
+	if len(commands) == 1 {
+		return e.dispatch(commands[0])
+	}
+	if !e.Session.IsConnected() {
+		return fmt.Errorf("not connected to MUD")
+	}
+	if len(commands) > 1 {
+		e.Queue.Add("alias: "+name, commands)
+	}
+	return nil
+}
+
+// dispatch sends a command, or schedules it if it's a #delay or #repeat
+func (e *Engine) dispatch(command string) error {
+	request, isSchedule, err := schedule.Parse(command)
+	if !isSchedule {
+		return e.Send(command)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = e.Schedule.Add(request)
+	return err
+}
+
+// publishTick tells the event bus about a tick, so triggers can react to it
+func (e *Engine) publishTick(status tick.Status) {
+	e.Events.Publish(events.New(events.KindTick, "", map[string]string{
+		"count":   strconv.Itoa(status.Count),
+		"seconds": strconv.Itoa(status.Seconds),
+		"synced":  strconv.FormatBool(status.Synced),
+	}))
+}
+
+// RememberCommand adds a command the player typed to the server's history.
+// Only commands typed in game are kept, so logins and passwords never are.
+func (e *Engine) RememberCommand(command string) {
+	if e.State() != session.StateInGame {
+		return
+	}
+	if err := e.History.Add(command); err != nil {
+		logger.Warn("failed to record command", "error", err)
+	}
+}
+
+// SendRaw sends a command generated by the client itself, bypassing
+// movement tracking
+func (e *Engine) SendRaw(command string) error {
+	return e.Session.Send(command)
+}
+
+// processOutput handles incoming MUD output for one connection
+func (e *Engine) processOutput(lines <-chan string, done <-chan struct{}) {
+	for {
+		select {
+		case <-done:
+			// If the user didn't ask to disconnect, the server dropped us
+			if !e.Session.UserClosed() && !e.isClosing() {
+				e.Session.Machine().Transition(session.StateLinkDead)
+
+				// Disconnect saves the map for us, but a dropped link doesn't
+				if err := e.SaveMap(); err != nil {
+					logger.Warn("failed to save map", "error", err)
+				}
+			}
+			return
+		case line, ok := <-lines:
+			if !ok {
+				return
+			}
+			e.HandleLine(line)
+		}
+	}
+}
+
+// SetDialect switches to the parser for another family of servers, such
+// as the one a server's profile names
+func (e *Engine) SetDialect(name string) error {
+	mudParser, err := parser.ForDialect(name)
+	if err != nil {
+		return err
+	}
+	e.mutex.Lock()
+	e.Parser = mudParser
+	e.mutex.Unlock()
+	return nil
+}
+
+// UseImageBackend switches room images to another backend, as a profile
+// chooses. Empty names and endpoints use the configured ones, so a profile
+// that sets neither goes back to the default.
+func (e *Engine) UseImageBackend(name, endpoint string) error {
+	images, ok := e.Images.(*SDImageService)
+	if !ok {
+		return nil
+	}
+	backend, err := renderer.NewBackend(e.imageBackend.Override(name, endpoint))
+	if err != nil {
+		return err
+	}
+	images.SetBackend(backend)
+	return nil
+}
+
+// HandleLine runs one line of output through the whole pipeline. It is
+// exported so recorded sessions can be replayed without a connection.
+func (e *Engine) HandleLine(line string) {
+	// Parse the line
+	e.mutex.RLock()
+	mudParser := e.Parser
+	e.mutex.RUnlock()
+	parsed := mudParser.ParseLine(line)
+	e.Extractor.Apply(parsed)
+	e.Stats.LineReceived()
+	e.Metrics.lineHandled(parsed)
+	e.Session.Machine().HandleParsed(parsed)
+	e.resume(parsed)
+	if watch, matched := e.Highlights.Match(parsed.CleanText); matched {
+		parsed.Highlight = watch.Pattern
+	}
+	e.Ticks.ProcessLine(parsed.CleanText)
+
+	// Add to output hub, which drops the oldest lines once full
+	entry := e.Output.Publish(line, parsed)
+
+	logger.Debug("parsed", "type", parsed.Type.String(), "content", parsed.CleanText)
+
+	if mapper.IsMovementFailure(parsed.CleanText) {
+		e.Mapper.CancelMovement()
+	}
+	e.Rooms.HandleParsed(parsed)
+	e.Completions.Observe(parsed)
+
+	e.mutex.RLock()
+	handlers := e.handlers
+	e.mutex.RUnlock()
+	for _, registered := range handlers {
+		registered.handler(entry, parsed)
+	}
+
+	e.Triggers.HandleLine(parsed.CleanText)
+
+	// Blows count before the detector's events, so the first one of a
+	// fight isn't missed
+	e.Combat.HandleParsed(parsed)
+	for _, event := range e.detector.Detect(parsed) {
+		e.Events.Publish(event)
+	}
+}
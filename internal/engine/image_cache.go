@@ -0,0 +1,852 @@
+package engine
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/safefile"
+)
+
+// imageIndexFile records how every cached image was made
+const imageIndexFile = "index.json"
+
+// imageIndexVersion is bumped if the index format changes incompatibly
+const imageIndexVersion = 1
+
+// metadataSuffix ends the file beside each image holding a copy of its
+// record, so an image copied out of the cache keeps how it was drawn and a
+// lost index can be rebuilt
+const metadataSuffix = ".meta.json"
+
+// CacheLimits bound the room image cache. Zero means no limit.
+type CacheLimits struct {
+	MaxBytes  int64 `json:"max_bytes"`
+	MaxImages int   `json:"max_images"`
+}
+
+// DefaultCacheLimits keep a few thousand 512x512 images
+var DefaultCacheLimits = CacheLimits{MaxBytes: 1 << 30}
+
+// CacheStats describe the room image cache
+type CacheStats struct {
+	Directory string      `json:"directory"`
+	Images    int         `json:"images"`
+	Bytes     int64       `json:"bytes"`
+	Limits    CacheLimits `json:"limits"`
+	Evicted   int         `json:"evicted"` // Images removed to stay within the limits this session
+}
+
+// ImageRecord is the index entry for a cached image: which room it shows
+// and everything needed to draw it again. Images cached before the index
+// existed only have the room and file details.
+type ImageRecord struct {
+	RoomID      string `json:"room_id,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"` // As it was when the image was drawn
+	// DescriptionHash of Description, to spot the room changing
+	DescriptionHash string `json:"description_hash,omitempty"`
+	File            string `json:"file"` // Within the cache directory
+	Size            int64  `json:"size"`
+
+	Prompt         string  `json:"prompt,omitempty"`
+	NegativePrompt string  `json:"negative_prompt,omitempty"`
+	CustomPrompt   string  `json:"custom_prompt,omitempty"` // The player's additions, included in Prompt
+	Style          string  `json:"style,omitempty"`
+	Backend        string  `json:"backend,omitempty"` // Which renderer backend drew it; empty for images from before there was a choice
+	Seed           int64   `json:"seed,omitempty"`
+	Model          string  `json:"model,omitempty"`
+	ModelHash      string  `json:"model_hash,omitempty"`
+	Sampler        string  `json:"sampler,omitempty"`
+	Steps          int     `json:"steps,omitempty"`
+	CFGScale       float64 `json:"cfg_scale,omitempty"`
+	Width          int     `json:"width,omitempty"`
+	Height         int     `json:"height,omitempty"`
+	Neighbours     int     `json:"neighbours"`         // Neighbouring rooms described in the prompt
+	Strength       float64 `json:"strength,omitempty"` // Denoising strength, if drawn as a variation of the image before
+	Hash           string  `json:"hash,omitempty"`     // Perceptual hash, for spotting near-duplicates
+
+	Created  time.Time `json:"created"`
+	LastUsed time.Time `json:"last_used"`
+}
+
+// imageIndex is the index file
+type imageIndex struct {
+	Version int            `json:"version"`
+	Images  []*ImageRecord `json:"images"`
+}
+
+// roomIDPattern matches the mapper's room IDs
+var roomIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// imageSidecar is the metadata file briefly saved beside each image before
+// the index replaced it
+type imageSidecar struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Created     time.Time `json:"created"`
+}
+
+// loadIndex reads the index and reconciles it with the images on disk:
+// entries whose file has gone are dropped, images missing from the index
+// are added with what can be recovered, and images still named after their
+// room wait in legacyImages to be migrated. The caller must hold the lock.
+func (s *SDImageService) loadIndex() {
+	s.roomImageCache = make(map[string]*ImageRecord)
+	s.legacyImages = make(map[string]*ImageRecord)
+
+	indexed := make(map[string]*ImageRecord)
+	data, err := safefile.ReadFile(filepath.Join(s.cacheDir, imageIndexFile), json.Valid)
+	if err == nil {
+		var index imageIndex
+		if err := json.Unmarshal(data, &index); err != nil {
+			logger.Warn("failed to read image index, rebuilding it", "error", err)
+		} else {
+			for _, record := range index.Images {
+				indexed[record.File] = record
+			}
+		}
+	} else if !os.IsNotExist(err) {
+		logger.Warn("failed to read image index, rebuilding it", "error", err)
+	}
+
+	entries, err := os.ReadDir(s.cacheDir)
+	if err != nil {
+		logger.Warn("could not read cache directory", "error", err)
+		return
+	}
+
+	changed := len(indexed) == 0
+	for _, entry := range entries {
+		format, isImage := imageFormatOf(entry.Name())
+		if entry.IsDir() || !isImage {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		key := strings.TrimSuffix(entry.Name(), format.Extension())
+
+		record, known := indexed[entry.Name()]
+		delete(indexed, entry.Name())
+		if !known {
+			record = s.readMetadata(key)
+			if record == nil {
+				record = &ImageRecord{Created: info.ModTime()}
+			}
+			record.File = entry.Name()
+			changed = true
+		}
+		record.Size = info.Size()
+		// Images are touched when shown, which the index isn't saved for
+		if info.ModTime().After(record.LastUsed) {
+			record.LastUsed = info.ModTime()
+		}
+
+		if !roomIDPattern.MatchString(key) {
+			if format != FormatPNG {
+				continue
+			}
+			s.legacyImages[key] = record
+			continue
+		}
+		record.RoomID = key
+		if s.absorbSidecar(record) {
+			changed = true
+		}
+		s.roomImageCache[key] = record
+	}
+
+	if len(indexed) > 0 {
+		logger.Info("dropping index entries for missing images", "count", len(indexed))
+		changed = true
+	}
+	if len(s.legacyImages) > 0 {
+		logger.Info("found images named by room, migrating as rooms are visited", "count", len(s.legacyImages))
+	}
+	if changed {
+		if err := s.saveIndex(); err != nil {
+			logger.Warn("failed to save image index", "error", err)
+		}
+	}
+}
+
+// absorbSidecar moves the details from an image's old metadata file into
+// its record, reporting whether there was one
+func (s *SDImageService) absorbSidecar(record *ImageRecord) bool {
+	path := filepath.Join(s.cacheDir, record.RoomID+".json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var sidecar imageSidecar
+	if err := json.Unmarshal(data, &sidecar); err == nil {
+		record.Name = sidecar.Name
+		record.Description = sidecar.Description
+		record.DescriptionHash = DescriptionHash(record.Description)
+		if !sidecar.Created.IsZero() {
+			record.Created = sidecar.Created
+		}
+	}
+	if err := os.Remove(path); err != nil {
+		logger.Debug("failed to remove image metadata", "path", path, "error", err)
+	}
+	return true
+}
+
+// readMetadata reads the record saved beside a room's image, nil if there
+// isn't one
+func (s *SDImageService) readMetadata(roomID string) *ImageRecord {
+	data, err := os.ReadFile(filepath.Join(s.cacheDir, roomID+metadataSuffix))
+	if err != nil {
+		return nil
+	}
+	var record ImageRecord
+	if err := json.Unmarshal(data, &record); err != nil {
+		logger.Debug("failed to read image metadata", "room", roomID, "error", err)
+		return nil
+	}
+	logger.Debug("recovered image record from its metadata", "room", roomID)
+	return &record
+}
+
+// writeMetadata saves a copy of an image's record beside it
+func (s *SDImageService) writeMetadata(record *ImageRecord) {
+	data, err := json.MarshalIndent(record, "", "  ")
+	if err != nil {
+		logger.Warn("failed to marshal image metadata", "error", err)
+		return
+	}
+	if err := safefile.WriteFile(filepath.Join(s.cacheDir, record.RoomID+metadataSuffix), data, 0644); err != nil {
+		logger.Warn("failed to write image metadata", "room", record.Name, "error", err)
+	}
+}
+
+// saveIndex writes the index; the caller must hold the lock
+func (s *SDImageService) saveIndex() error {
+	index := imageIndex{Version: imageIndexVersion, Images: []*ImageRecord{}}
+	for _, images := range []map[string]*ImageRecord{s.roomImageCache, s.legacyImages} {
+		for _, record := range images {
+			index.Images = append(index.Images, record)
+		}
+	}
+	sort.Slice(index.Images, func(i, j int) bool { return index.Images[i].File < index.Images[j].File })
+
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal image index: %w", err)
+	}
+	if err := safefile.WriteWithBackup(filepath.Join(s.cacheDir, imageIndexFile), data, 0644); err != nil {
+		return fmt.Errorf("failed to write image index: %w", err)
+	}
+	return nil
+}
+
+// Record returns the index entry for a room's cached image
+func (s *SDImageService) Record(room Room) (ImageRecord, bool) {
+	if _, exists := s.cachedPath(room); !exists {
+		return ImageRecord{}, false
+	}
+	s.imageCacheMux.RLock()
+	defer s.imageCacheMux.RUnlock()
+	record, exists := s.roomImageCache[room.ID()]
+	if !exists {
+		return ImageRecord{}, false
+	}
+	return *record, true
+}
+
+// Records returns the index entries for the current server's images
+func (s *SDImageService) Records() []ImageRecord {
+	s.imageCacheMux.RLock()
+	defer s.imageCacheMux.RUnlock()
+	records := make([]ImageRecord, 0, len(s.roomImageCache))
+	for _, record := range s.roomImageCache {
+		records = append(records, *record)
+	}
+	return records
+}
+
+// cachedPath returns a room's image path, first moving an image named after
+// the room to its ID. Rooms sharing a name used to share an image, so the
+// first of them to be visited keeps it and the others get their own.
+func (s *SDImageService) cachedPath(room Room) (string, bool) {
+	id := room.ID()
+	s.imageCacheMux.Lock()
+	defer s.imageCacheMux.Unlock()
+
+	if record, exists := s.roomImageCache[id]; exists {
+		return filepath.Join(s.cacheDir, record.File), true
+	}
+
+	name := sanitizeRoomName(room.Name)
+	record, exists := s.legacyImages[name]
+	if !exists {
+		return "", false
+	}
+	delete(s.legacyImages, name)
+
+	legacy := filepath.Join(s.cacheDir, record.File)
+	path := filepath.Join(s.cacheDir, id+".png")
+	if err := os.Rename(legacy, path); err != nil {
+		logger.Warn("failed to migrate cached image", "path", legacy, "error", err)
+		return "", false
+	}
+	record.RoomID, record.Name, record.Description, record.File = id, room.Name, room.Description, id+".png"
+	record.DescriptionHash = DescriptionHash(record.Description)
+	s.roomImageCache[id] = record
+	s.writeMetadata(record)
+	if err := s.saveIndex(); err != nil {
+		logger.Warn("failed to save image index", "error", err)
+	}
+	logger.Info("migrated cached image", "room", room.Name, "from", legacy, "to", path)
+	return path, true
+}
+
+// saveImageToCache saves a base64 image to the cache directory with how it
+// was generated, in the current encoding
+func (s *SDImageService) saveImageToCache(room Room, base64Image string, record ImageRecord) error {
+	id := room.ID()
+
+	// Decode base64 image
+	imageData, err := base64.StdEncoding.DecodeString(base64Image)
+	if err != nil {
+		return fmt.Errorf("failed to decode base64 image: %w", err)
+	}
+
+	if record.Hash, err = hashImage(imageData); err != nil {
+		logger.Debug("could not hash image", "room", room.Name, "error", err)
+	}
+
+	// A failed encoding keeps the PNG rather than losing the image
+	encoding := s.Encoding()
+	if encoded, err := encodeImage(imageData, encoding); err != nil {
+		logger.Warn("failed to encode image, keeping it as PNG", "format", encoding.Format, "error", err)
+		encoding.Format = FormatPNG
+	} else {
+		imageData = encoded
+	}
+
+	// Write to file
+	filepath := filepath.Join(s.cacheDir, id+encoding.Format.Extension())
+	if err := safefile.WriteFile(filepath, imageData, 0644); err != nil {
+		return fmt.Errorf("failed to save image to cache: %w", err)
+	}
+
+	now := time.Now()
+	record.RoomID, record.Name, record.Description = id, room.Name, room.Description
+	record.DescriptionHash = DescriptionHash(record.Description)
+	record.File, record.Size = id+encoding.Format.Extension(), int64(len(imageData))
+	record.Created, record.LastUsed = now, now
+
+	// Update the index, making room for the new image
+	s.imageCacheMux.Lock()
+	if previous, exists := s.roomImageCache[id]; exists && previous.File != record.File {
+		s.removeFile(previous.File)
+	}
+	s.roomImageCache[id] = &record
+	s.writeMetadata(&record)
+	s.evict()
+	err = s.saveIndex()
+	s.imageCacheMux.Unlock()
+	if err != nil {
+		logger.Warn("failed to save image index", "error", err)
+	}
+
+	logger.Info("saved image to cache", "room", room.Name, "path", filepath)
+	return nil
+}
+
+// loadImageFromCache loads an image from cache if it exists
+func (s *SDImageService) loadImageFromCache(room Room) (string, bool) {
+	filepath, exists := s.cachedPath(room)
+	if !exists {
+		return "", false
+	}
+
+	// Read the file
+	imageData, err := os.ReadFile(filepath)
+	if err != nil {
+		logger.Warn("failed to read cached image", "path", filepath, "error", err)
+		// Remove from cache if file doesn't exist
+		s.imageCacheMux.Lock()
+		delete(s.roomImageCache, room.ID())
+		s.imageCacheMux.Unlock()
+		return "", false
+	}
+
+	s.touch(room.ID())
+
+	// Hand out PNG whatever the image is stored as
+	if format, _ := imageFormatOf(filepath); format != FormatPNG {
+		imageData, err = decodeImage(imageData, format)
+		if err != nil {
+			logger.Warn("failed to decode cached image", "path", filepath, "error", err)
+			return "", false
+		}
+	}
+
+	// Encode to base64
+	base64Image := base64.StdEncoding.EncodeToString(imageData)
+	return base64Image, true
+}
+
+// Limits returns the cache limits
+func (s *SDImageService) Limits() CacheLimits {
+	s.imageCacheMux.RLock()
+	defer s.imageCacheMux.RUnlock()
+	return s.limits
+}
+
+// SetLimits changes the cache limits, evicting images at once if the cache
+// is now over them
+func (s *SDImageService) SetLimits(limits CacheLimits) {
+	s.imageCacheMux.Lock()
+	defer s.imageCacheMux.Unlock()
+	s.limits = limits
+	if s.evict() > 0 {
+		if err := s.saveIndex(); err != nil {
+			logger.Warn("failed to save image index", "error", err)
+		}
+	}
+}
+
+// Stats summarises the cache
+func (s *SDImageService) Stats() CacheStats {
+	s.imageCacheMux.RLock()
+	defer s.imageCacheMux.RUnlock()
+
+	stats := CacheStats{Directory: s.cacheDir, Limits: s.limits, Evicted: s.evicted}
+	for _, images := range []map[string]*ImageRecord{s.roomImageCache, s.legacyImages} {
+		for _, record := range images {
+			stats.Images++
+			stats.Bytes += record.Size
+		}
+	}
+	return stats
+}
+
+// Clear deletes every cached image, returning how many were removed
+func (s *SDImageService) Clear() (int, error) {
+	s.imageCacheMux.Lock()
+	defer s.imageCacheMux.Unlock()
+
+	removed := 0
+	var failed error
+	for _, images := range []map[string]*ImageRecord{s.roomImageCache, s.legacyImages} {
+		for key, record := range images {
+			if err := os.Remove(filepath.Join(s.cacheDir, record.File)); err != nil && !os.IsNotExist(err) {
+				failed = err
+				continue
+			}
+			if record.RoomID != "" {
+				s.removeFile(record.RoomID + metadataSuffix)
+			}
+			delete(images, key)
+			removed++
+		}
+	}
+	if err := s.saveIndex(); err != nil && failed == nil {
+		failed = err
+	}
+	logger.Info("cleared image cache", "removed", removed)
+	if failed != nil {
+		return removed, fmt.Errorf("failed to clear image cache: %w", failed)
+	}
+	return removed, nil
+}
+
+// touch marks a room's image as just used. The file's modification time is
+// updated rather than the index, so showing an image doesn't rewrite it.
+func (s *SDImageService) touch(roomID string) {
+	now := time.Now()
+	s.imageCacheMux.Lock()
+	record, exists := s.roomImageCache[roomID]
+	var path string
+	if exists {
+		record.LastUsed = now
+		path = filepath.Join(s.cacheDir, record.File)
+	}
+	s.imageCacheMux.Unlock()
+
+	if exists {
+		if err := os.Chtimes(path, now, now); err != nil {
+			logger.Debug("failed to touch cached image", "path", path, "error", err)
+		}
+	}
+}
+
+// evict removes the least recently used images until the cache is within
+// its limits, returning how many went; the caller must hold the lock and
+// save the index
+func (s *SDImageService) evict() int {
+	type candidate struct {
+		images map[string]*ImageRecord
+		key    string
+		record *ImageRecord
+	}
+
+	var all []candidate
+	var total int64
+	for _, images := range []map[string]*ImageRecord{s.roomImageCache, s.legacyImages} {
+		for key, record := range images {
+			all = append(all, candidate{images, key, record})
+			total += record.Size
+		}
+	}
+
+	over := func() bool {
+		return (s.limits.MaxBytes > 0 && total > s.limits.MaxBytes) ||
+			(s.limits.MaxImages > 0 && len(all) > s.limits.MaxImages)
+	}
+	if !over() {
+		return 0
+	}
+
+	sort.Slice(all, func(i, j int) bool { return all[i].record.LastUsed.Before(all[j].record.LastUsed) })
+	removed := 0
+	// The newest image always stays, even if it alone is over the limit
+	for len(all) > 1 && over() {
+		oldest := all[0]
+		all = all[1:]
+		path := filepath.Join(s.cacheDir, oldest.record.File)
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			logger.Warn("failed to evict cached image", "path", path, "error", err)
+			continue
+		}
+		if oldest.record.RoomID != "" {
+			s.removeFile(oldest.record.RoomID + metadataSuffix)
+		}
+		delete(oldest.images, oldest.key)
+		total -= oldest.record.Size
+		removed++
+	}
+	s.evicted += removed
+	logger.Info("evicted least recently used images", "removed", removed, "images", len(all), "bytes", total)
+	return removed
+}
+
+// ImageData returns a cached image and its record by room ID, for sharing
+func (s *SDImageService) ImageData(roomID string) (ImageRecord, []byte, bool) {
+	s.imageCacheMux.RLock()
+	record, exists := s.roomImageCache[roomID]
+	var copied ImageRecord
+	if exists {
+		copied = *record
+	}
+	s.imageCacheMux.RUnlock()
+	if !exists {
+		return ImageRecord{}, nil, false
+	}
+
+	data, err := os.ReadFile(filepath.Join(s.cacheDir, copied.File))
+	if err != nil {
+		logger.Warn("failed to read cached image", "path", copied.File, "error", err)
+		return ImageRecord{}, nil, false
+	}
+	return copied, data, true
+}
+
+// AddImage caches an image made elsewhere under record's room ID, in the
+// format record.File names. A room that already has an image keeps it, and
+// false is returned.
+func (s *SDImageService) AddImage(record ImageRecord, data []byte) (bool, error) {
+	if !roomIDPattern.MatchString(record.RoomID) {
+		return false, fmt.Errorf("invalid room ID %q", record.RoomID)
+	}
+	format, known := imageFormatOf(record.File)
+	if !known {
+		format = FormatPNG
+	}
+
+	s.imageCacheMux.Lock()
+	defer s.imageCacheMux.Unlock()
+	if _, exists := s.roomImageCache[record.RoomID]; exists {
+		return false, nil
+	}
+
+	record.File = record.RoomID + format.Extension()
+	record.Size = int64(len(data))
+	if record.Created.IsZero() {
+		record.Created = time.Now()
+	}
+	record.LastUsed = time.Now()
+	if err := safefile.WriteFile(filepath.Join(s.cacheDir, record.File), data, 0644); err != nil {
+		return false, fmt.Errorf("failed to save image to cache: %w", err)
+	}
+	s.roomImageCache[record.RoomID] = &record
+	s.writeMetadata(&record)
+	s.evict()
+	if err := s.saveIndex(); err != nil {
+		logger.Warn("failed to save image index", "error", err)
+	}
+	return true, nil
+}
+
+// RenameRooms moves images to their rooms' new IDs after the map changed
+// how rooms are identified, returning how many moved. Where copies of a room
+// were merged into one, the most recently used image is kept.
+func (s *SDImageService) RenameRooms(ids map[string]string) int {
+	s.imageCacheMux.Lock()
+	defer s.imageCacheMux.Unlock()
+
+	oldIDs := make([]string, 0, len(s.roomImageCache))
+	for id := range s.roomImageCache {
+		if newID, mapped := ids[id]; mapped && newID != id {
+			oldIDs = append(oldIDs, id)
+		}
+	}
+	// Most recently used first, so it claims the new ID
+	sort.Slice(oldIDs, func(i, j int) bool {
+		return s.roomImageCache[oldIDs[i]].LastUsed.After(s.roomImageCache[oldIDs[j]].LastUsed)
+	})
+	moving := make(map[string]*ImageRecord, len(oldIDs))
+	for _, id := range oldIDs {
+		moving[id] = s.roomImageCache[id]
+		delete(s.roomImageCache, id)
+	}
+
+	renamed := make(map[string]*ImageRecord, len(oldIDs))
+	for _, id := range oldIDs {
+		record, newID := moving[id], ids[id]
+		_, taken := renamed[newID]
+		if _, cached := s.roomImageCache[newID]; taken || cached {
+			s.removeFile(record.File)
+			s.removeFile(id + metadataSuffix)
+			continue
+		}
+
+		format, _ := imageFormatOf(record.File)
+		file := newID + format.Extension()
+		if err := os.Rename(filepath.Join(s.cacheDir, record.File), filepath.Join(s.cacheDir, file)); err != nil {
+			logger.Warn("failed to move cached image", "path", record.File, "error", err)
+			continue
+		}
+		s.removeFile(id + metadataSuffix)
+		record.RoomID, record.File = newID, file
+		s.writeMetadata(record)
+		renamed[newID] = record
+	}
+	for id, record := range renamed {
+		s.roomImageCache[id] = record
+	}
+
+	if len(oldIDs) > 0 {
+		if err := s.saveIndex(); err != nil {
+			logger.Warn("failed to save image index", "error", err)
+		}
+	}
+	logger.Info("moved images to new room IDs", "count", len(renamed))
+	return len(renamed)
+}
+
+// removeFile deletes a file from the cache directory; the caller must hold
+// the lock
+func (s *SDImageService) removeFile(file string) {
+	path := filepath.Join(s.cacheDir, file)
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		logger.Warn("failed to remove cached image", "path", path, "error", err)
+	}
+}
+
+// Encoding returns how new images are stored
+func (s *SDImageService) Encoding() ImageEncoding {
+	s.imageCacheMux.RLock()
+	defer s.imageCacheMux.RUnlock()
+	return s.encoding
+}
+
+// SetEncoding changes how new images are stored. Images already cached keep
+// their format until Recode is called.
+func (s *SDImageService) SetEncoding(encoding ImageEncoding) error {
+	if err := encoding.Validate(); err != nil {
+		return err
+	}
+	s.imageCacheMux.Lock()
+	defer s.imageCacheMux.Unlock()
+	s.encoding = encoding
+	return nil
+}
+
+// Recode converts cached images in other formats to the current encoding,
+// returning how many were converted. Images are converted one at a time
+// without holding the lock, so the cache stays usable meanwhile.
+func (s *SDImageService) Recode() (int, error) {
+	encoding := s.Encoding()
+
+	s.imageCacheMux.RLock()
+	var records []ImageRecord
+	for _, record := range s.roomImageCache {
+		if format, _ := imageFormatOf(record.File); format != encoding.Format {
+			records = append(records, *record)
+		}
+	}
+	s.imageCacheMux.RUnlock()
+
+	converted := 0
+	var failed error
+	for _, record := range records {
+		if err := s.recode(record, encoding); err != nil {
+			logger.Warn("failed to convert cached image", "path", record.File, "error", err)
+			failed = err
+			continue
+		}
+		converted++
+	}
+
+	s.imageCacheMux.Lock()
+	err := s.saveIndex()
+	s.imageCacheMux.Unlock()
+	if err != nil && failed == nil {
+		failed = err
+	}
+	logger.Info("converted cached images", "format", encoding.Format, "converted", converted, "of", len(records))
+	if failed != nil {
+		return converted, fmt.Errorf("failed to convert image cache: %w", failed)
+	}
+	return converted, nil
+}
+
+// recode converts one cached image, swapping its record over once the new
+// file is written
+func (s *SDImageService) recode(record ImageRecord, encoding ImageEncoding) error {
+	format, _ := imageFormatOf(record.File)
+	data, err := os.ReadFile(filepath.Join(s.cacheDir, record.File))
+	if err != nil {
+		return err
+	}
+	if data, err = decodeImage(data, format); err != nil {
+		return err
+	}
+	if data, err = encodeImage(data, encoding); err != nil {
+		return err
+	}
+
+	file := record.RoomID + encoding.Format.Extension()
+	if err := safefile.WriteFile(filepath.Join(s.cacheDir, file), data, 0644); err != nil {
+		return fmt.Errorf("failed to save image to cache: %w", err)
+	}
+
+	s.imageCacheMux.Lock()
+	defer s.imageCacheMux.Unlock()
+	current, exists := s.roomImageCache[record.RoomID]
+	if !exists || current.File != record.File {
+		// Regenerated or evicted while converting
+		if !exists || current.File != file {
+			s.removeFile(file)
+		}
+		return nil
+	}
+	s.removeFile(record.File)
+	current.File, current.Size = file, int64(len(data))
+	s.writeMetadata(current)
+	return nil
+}
+
+// UseServer switches the cache to a server's own directory, so rooms on
+// different MUDs can't share an image. Images in the shared directory from
+// before caches were split are moved across if they show a room on the
+// server's map, which should already be loaded.
+func (s *SDImageService) UseServer(serverName string) {
+	dir := filepath.Join(s.root, datadir.Namespace(serverName))
+	s.imageCacheMux.Lock()
+	defer s.imageCacheMux.Unlock()
+	if dir == s.cacheDir {
+		return
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		logger.Warn("failed to create cache directory", "error", err)
+		return
+	}
+	s.cacheDir = dir
+	s.loadIndex()
+	s.claimShared()
+	logger.Info("using image cache", "server", serverName, "path", dir, "images", len(s.roomImageCache))
+}
+
+// claimShared moves images for rooms on the current map out of the shared
+// directory. The caller must hold the lock.
+func (s *SDImageService) claimShared() {
+	if s.mapper == nil {
+		return
+	}
+	graph := s.mapper.GetGraph()
+	if graph == nil || len(graph.Rooms) == 0 {
+		return
+	}
+
+	shared := &SDImageService{root: s.root, cacheDir: s.root}
+	shared.loadIndex()
+	if len(shared.roomImageCache) == 0 && len(shared.legacyImages) == 0 {
+		return
+	}
+
+	// Images still named after their room go to the first room of that name
+	ids := make([]string, 0, len(graph.Rooms))
+	for id := range graph.Rooms {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	byName := make(map[string]string)
+	for _, id := range ids {
+		name := sanitizeRoomName(graph.Rooms[id].Name)
+		if _, exists := byName[name]; !exists {
+			byName[name] = id
+		}
+	}
+
+	claimed := 0
+	claim := func(images map[string]*ImageRecord, key, id string) {
+		record := images[key]
+		if _, exists := s.roomImageCache[id]; exists {
+			return
+		}
+		format, _ := imageFormatOf(record.File)
+		file := id + format.Extension()
+		if err := os.Rename(filepath.Join(s.root, record.File), filepath.Join(s.cacheDir, file)); err != nil {
+			logger.Warn("failed to move cached image", "path", record.File, "error", err)
+			return
+		}
+		delete(images, key)
+		if record.RoomID == "" {
+			room := graph.Rooms[id]
+			record.RoomID, record.Name, record.Description = id, room.Name, room.Description
+			record.DescriptionHash = DescriptionHash(record.Description)
+		} else {
+			shared.removeFile(record.RoomID + metadataSuffix)
+		}
+		record.File = file
+		s.roomImageCache[id] = record
+		s.writeMetadata(record)
+		claimed++
+	}
+	for id := range shared.roomImageCache {
+		if _, onMap := graph.Rooms[id]; onMap {
+			claim(shared.roomImageCache, id, id)
+		}
+	}
+	for name := range shared.legacyImages {
+		if id, onMap := byName[name]; onMap {
+			claim(shared.legacyImages, name, id)
+		}
+	}
+	if claimed == 0 {
+		return
+	}
+
+	for _, cache := range []*SDImageService{shared, s} {
+		if err := cache.saveIndex(); err != nil {
+			logger.Warn("failed to save image index", "error", err)
+		}
+	}
+	logger.Info("moved shared images into server cache", "count", claimed, "path", s.cacheDir)
+}
@@ -0,0 +1,243 @@
+package engine
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"math/bits"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"seemud-gui/internal/safefile"
+)
+
+// DuplicateThreshold is how many of the 64 hash bits two images may differ
+// by and still look the same to a player
+const DuplicateThreshold = 6
+
+// DuplicateImage is a room in a group of near-identical images
+type DuplicateImage struct {
+	RoomID string `json:"room_id"`
+	Name   string `json:"name"`
+	File   string `json:"file"`
+}
+
+// DuplicateGroup is a set of rooms whose images are indistinguishable. They
+// can share one file, or be regenerated with more distinctive prompts.
+type DuplicateGroup struct {
+	Images   []DuplicateImage `json:"images"`
+	Distance int              `json:"distance"` // Most bits any two differ by
+}
+
+// hashImage returns the perceptual hash of a PNG, as hex
+func hashImage(data []byte) (string, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return fmt.Sprintf("%016x", differenceHash(img)), nil
+}
+
+// differenceHash shrinks an image to 9x8 greys and records whether each is
+// brighter than its right-hand neighbour. Small changes in colour, detail or
+// compression leave it alone; a different scene doesn't.
+func differenceHash(img image.Image) uint64 {
+	b := img.Bounds()
+	var grey [8][9]float64
+	for y := 0; y < 8; y++ {
+		y0, y1 := b.Min.Y+y*b.Dy()/8, b.Min.Y+(y+1)*b.Dy()/8
+		for x := 0; x < 9; x++ {
+			x0, x1 := b.Min.X+x*b.Dx()/9, b.Min.X+(x+1)*b.Dx()/9
+			var sum float64
+			n := 0
+			// Every other pixel is plenty to average a block
+			for py := y0; py < y1; py += 2 {
+				for px := x0; px < x1; px += 2 {
+					r, g, bl, _ := img.At(px, py).RGBA()
+					sum += 0.299*float64(r) + 0.587*float64(g) + 0.114*float64(bl)
+					n++
+				}
+			}
+			if n > 0 {
+				grey[y][x] = sum / float64(n)
+			}
+		}
+	}
+
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			hash <<= 1
+			if grey[y][x] > grey[y][x+1] {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+// hashDistance counts the bits two hex hashes differ by
+func hashDistance(a, b string) (int, bool) {
+	x, errA := strconv.ParseUint(a, 16, 64)
+	y, errB := strconv.ParseUint(b, 16, 64)
+	if errA != nil || errB != nil {
+		return 0, false
+	}
+	return bits.OnesCount64(x ^ y), true
+}
+
+// FindDuplicates groups rooms whose images are within threshold bits of
+// each other. Images cached before hashes were recorded are hashed first,
+// without holding the lock.
+func (s *SDImageService) FindDuplicates(threshold int) []DuplicateGroup {
+	s.imageCacheMux.RLock()
+	dir := s.cacheDir
+	records := make([]ImageRecord, 0, len(s.roomImageCache))
+	for _, record := range s.roomImageCache {
+		records = append(records, *record)
+	}
+	s.imageCacheMux.RUnlock()
+
+	hashed := make(map[string]string)
+	for i, record := range records {
+		if record.Hash != "" {
+			continue
+		}
+		format, _ := imageFormatOf(record.File)
+		data, err := os.ReadFile(filepath.Join(dir, record.File))
+		if err == nil {
+			data, err = decodeImage(data, format)
+		}
+		if err == nil {
+			records[i].Hash, err = hashImage(data)
+		}
+		if err != nil {
+			logger.Debug("could not hash cached image", "path", record.File, "error", err)
+			continue
+		}
+		hashed[record.File] = records[i].Hash
+	}
+	if len(hashed) > 0 {
+		s.imageCacheMux.Lock()
+		if s.cacheDir == dir {
+			for _, record := range s.roomImageCache {
+				if hash, ok := hashed[record.File]; ok {
+					record.Hash = hash
+				}
+			}
+			if err := s.saveIndex(); err != nil {
+				logger.Warn("failed to save image index", "error", err)
+			}
+		}
+		s.imageCacheMux.Unlock()
+	}
+
+	// Link every close pair, then read the groups off
+	parent := make([]int, len(records))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range records {
+		for j := i + 1; j < len(records); j++ {
+			if distance, ok := hashDistance(records[i].Hash, records[j].Hash); ok && distance <= threshold {
+				parent[find(i)] = find(j)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	for i := range records {
+		root := find(i)
+		members[root] = append(members[root], i)
+	}
+	groups := []DuplicateGroup{}
+	for _, indexes := range members {
+		if len(indexes) < 2 {
+			continue
+		}
+		group := DuplicateGroup{}
+		for n, i := range indexes {
+			group.Images = append(group.Images, DuplicateImage{RoomID: records[i].RoomID, Name: records[i].Name, File: records[i].File})
+			for _, j := range indexes[n+1:] {
+				if distance, _ := hashDistance(records[i].Hash, records[j].Hash); distance > group.Distance {
+					group.Distance = distance
+				}
+			}
+		}
+		sort.Slice(group.Images, func(a, b int) bool { return group.Images[a].Name < group.Images[b].Name })
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(a, b int) bool {
+		if len(groups[a].Images) != len(groups[b].Images) {
+			return len(groups[a].Images) > len(groups[b].Images)
+		}
+		return groups[a].Images[0].Name < groups[b].Images[0].Name
+	})
+	return groups
+}
+
+// ShareImage gives a room another room's image, hard-linked where the file
+// system allows so the two take the space of one. The room keeps its own
+// record, so regenerating it later only replaces its copy.
+func (s *SDImageService) ShareImage(fromRoomID, toRoomID string) error {
+	s.imageCacheMux.Lock()
+	defer s.imageCacheMux.Unlock()
+
+	from, exists := s.roomImageCache[fromRoomID]
+	if !exists {
+		return fmt.Errorf("room %s has no cached image", fromRoomID)
+	}
+	to, exists := s.roomImageCache[toRoomID]
+	if !exists {
+		return fmt.Errorf("room %s has no cached image", toRoomID)
+	}
+	if fromRoomID == toRoomID {
+		return nil
+	}
+
+	format, _ := imageFormatOf(from.File)
+	file := toRoomID + format.Extension()
+	source, target := filepath.Join(s.cacheDir, from.File), filepath.Join(s.cacheDir, file)
+	temp := target + ".share"
+	os.Remove(temp)
+	if err := os.Link(source, temp); err != nil {
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return fmt.Errorf("failed to read cached image: %w", err)
+		}
+		if err := safefile.WriteFile(temp, data, 0644); err != nil {
+			return fmt.Errorf("failed to copy cached image: %w", err)
+		}
+	}
+	if err := os.Rename(temp, target); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("failed to share cached image: %w", err)
+	}
+	if to.File != file {
+		s.removeFile(to.File)
+	}
+
+	// The picture and how it was drawn are the other room's now; what it
+	// shows stays this room's, so it isn't marked stale
+	shared := *from
+	shared.RoomID, shared.Name, shared.Description = to.RoomID, to.Name, to.Description
+	shared.DescriptionHash = to.DescriptionHash
+	shared.File = file
+	shared.Created, shared.LastUsed = time.Now(), time.Now()
+	s.roomImageCache[toRoomID] = &shared
+	if err := s.saveIndex(); err != nil {
+		logger.Warn("failed to save image index", "error", err)
+	}
+	logger.Info("shared cached image", "from", from.Name, "to", to.Name)
+	return nil
+}
@@ -0,0 +1,162 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/image/webp"
+)
+
+// ImageFormat is how cached images are stored on disk. Whatever the format,
+// the cache hands out PNG.
+type ImageFormat string
+
+const (
+	FormatPNG  ImageFormat = "png"  // As Stable Diffusion returns it
+	FormatWebP ImageFormat = "webp" // Encoded with cwebp, decoded in-process
+	FormatAVIF ImageFormat = "avif" // Encoded with avifenc and decoded with avifdec
+)
+
+// ImageEncoding chooses how new images are stored
+type ImageEncoding struct {
+	Format  ImageFormat `json:"format"`
+	Quality int         `json:"quality"` // 1-100, ignored for PNG
+}
+
+// DefaultImageEncoding keeps images as Stable Diffusion returns them
+var DefaultImageEncoding = ImageEncoding{Format: FormatPNG, Quality: 80}
+
+// encodeTimeout bounds a single encoder or decoder run
+const encodeTimeout = 30 * time.Second
+
+// ParseImageFormat validates a format name
+func ParseImageFormat(name string) (ImageFormat, error) {
+	switch ImageFormat(strings.ToLower(strings.TrimSpace(name))) {
+	case FormatPNG:
+		return FormatPNG, nil
+	case FormatWebP:
+		return FormatWebP, nil
+	case FormatAVIF:
+		return FormatAVIF, nil
+	}
+	return "", fmt.Errorf("unknown image format %q (png, webp or avif)", name)
+}
+
+// Extension returns the file extension images in the format are saved with
+func (f ImageFormat) Extension() string {
+	return "." + string(f)
+}
+
+// imageFormatOf returns the format of a cached file from its extension
+func imageFormatOf(file string) (ImageFormat, bool) {
+	format, err := ParseImageFormat(strings.TrimPrefix(filepath.Ext(file), "."))
+	return format, err == nil
+}
+
+// encoderCommands are the tools each format needs, encoder first
+var encoderCommands = map[ImageFormat][]string{
+	FormatWebP: {"cwebp"},
+	FormatAVIF: {"avifenc", "avifdec"},
+}
+
+// EncoderAvailable reports whether the tools a format needs are installed,
+// returning the first one missing if not
+func EncoderAvailable(format ImageFormat) (bool, string) {
+	for _, command := range encoderCommands[format] {
+		if _, err := exec.LookPath(command); err != nil {
+			return false, command
+		}
+	}
+	return true, ""
+}
+
+// Validate checks the encoding can be used on this machine
+func (e ImageEncoding) Validate() error {
+	if _, err := ParseImageFormat(string(e.Format)); err != nil {
+		return err
+	}
+	if e.Format != FormatPNG && (e.Quality < 1 || e.Quality > 100) {
+		return fmt.Errorf("image quality must be from 1 to 100")
+	}
+	if ok, missing := EncoderAvailable(e.Format); !ok {
+		return fmt.Errorf("%s images need %s installed", e.Format, missing)
+	}
+	return nil
+}
+
+// encodeImage converts a PNG to the chosen format
+func encodeImage(data []byte, encoding ImageEncoding) ([]byte, error) {
+	quality := strconv.Itoa(encoding.Quality)
+	switch encoding.Format {
+	case FormatPNG:
+		return data, nil
+	case FormatWebP:
+		return convertImage(data, ".png", ".webp", func(in, out string) []string {
+			return []string{"cwebp", "-quiet", "-q", quality, in, "-o", out}
+		})
+	case FormatAVIF:
+		return convertImage(data, ".png", ".avif", func(in, out string) []string {
+			return []string{"avifenc", "-q", quality, in, out}
+		})
+	}
+	return nil, fmt.Errorf("unknown image format %q", encoding.Format)
+}
+
+// decodeImage converts a cached image back to PNG
+func decodeImage(data []byte, format ImageFormat) ([]byte, error) {
+	switch format {
+	case FormatPNG:
+		return data, nil
+	case FormatWebP:
+		img, err := webp.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode WebP image: %w", err)
+		}
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, fmt.Errorf("failed to encode PNG image: %w", err)
+		}
+		return buf.Bytes(), nil
+	case FormatAVIF:
+		return convertImage(data, ".avif", ".png", func(in, out string) []string {
+			return []string{"avifdec", in, out}
+		})
+	}
+	return nil, fmt.Errorf("unknown image format %q", format)
+}
+
+// convertImage runs a command-line converter through temporary files, since
+// the encoders can't all stream
+func convertImage(data []byte, fromExt, toExt string, command func(in, out string) []string) ([]byte, error) {
+	dir, err := os.MkdirTemp("", "seemud-image-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	in, out := filepath.Join(dir, "in"+fromExt), filepath.Join(dir, "out"+toExt)
+	if err := os.WriteFile(in, data, 0644); err != nil {
+		return nil, fmt.Errorf("failed to write temporary image: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), encodeTimeout)
+	defer cancel()
+	args := command(in, out)
+	if output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput(); err != nil {
+		return nil, fmt.Errorf("%s failed: %w: %s", args[0], err, strings.TrimSpace(string(output)))
+	}
+
+	converted, err := os.ReadFile(out)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read converted image: %w", err)
+	}
+	return converted, nil
+}
@@ -0,0 +1,86 @@
+package engine
+
+import (
+	"bytes"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ImageURLPrefix is the path cached room images are served under, by room
+// ID, so the GUI can show them with plain <img> URLs
+const ImageURLPrefix = "/images/"
+
+// ImageURL returns the URL of a room's cached image. It changes whenever the
+// image is regenerated, so the webview never shows a stale copy.
+func (s *SDImageService) ImageURL(room Room) (string, bool) {
+	if _, exists := s.cachedPath(room); !exists {
+		return "", false
+	}
+	s.imageCacheMux.RLock()
+	defer s.imageCacheMux.RUnlock()
+	record, exists := s.roomImageCache[room.ID()]
+	if !exists {
+		return "", false
+	}
+	return ImageURL(record.RoomID, record.Created.UnixMilli()), true
+}
+
+// ImageURL builds an image URL from a room ID and version
+func ImageURL(roomID string, version int64) string {
+	return ImageURLPrefix + roomID + "?v=" + strconv.FormatInt(version, 10)
+}
+
+// ServeHTTP streams cached images from disk. PNG and WebP are sent as they
+// are stored; AVIF, which not every webview shows, is converted to PNG.
+func (s *SDImageService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	id, found := strings.CutPrefix(r.URL.Path, ImageURLPrefix)
+	if !found || !roomIDPattern.MatchString(id) {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.imageCacheMux.RLock()
+	record, exists := s.roomImageCache[id]
+	var path string
+	var created time.Time
+	if exists {
+		path, created = filepath.Join(s.cacheDir, record.File), record.Created
+	}
+	s.imageCacheMux.RUnlock()
+	if !exists {
+		http.NotFound(w, r)
+		return
+	}
+	s.touch(id)
+
+	// The URL changes with each new image, so it can be kept indefinitely
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	format, _ := imageFormatOf(path)
+	if format == FormatAVIF {
+		data, err := os.ReadFile(path)
+		if err == nil {
+			data, err = decodeImage(data, format)
+		}
+		if err != nil {
+			logger.Warn("failed to serve cached image", "path", path, "error", err)
+			http.Error(w, "image unavailable", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		http.ServeContent(w, r, id+".png", created, bytes.NewReader(data))
+		return
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer file.Close()
+	w.Header().Set("Content-Type", "image/"+string(format))
+	http.ServeContent(w, r, filepath.Base(path), created, file)
+}
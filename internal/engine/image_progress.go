@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"seemud-gui/internal/renderer"
+)
+
+// progressInterval is how often a backend is asked how far it's got
+const progressInterval = 500 * time.Millisecond
+
+// ImageProgress reports how far a room's image has got
+type ImageProgress struct {
+	RoomID  string  `json:"room_id"`
+	Room    string  `json:"room"`
+	Percent int     `json:"percent"`
+	ETA     float64 `json:"eta"` // Seconds left, as the backend guesses
+	Step    int     `json:"step"`
+	Steps   int     `json:"steps"`
+	// Preview is a base64 PNG of the image so far, sent only when it's
+	// changed and only by backends with live previews turned on
+	Preview string `json:"preview,omitempty"`
+}
+
+// OnProgress sets a function told how far each generation has got, every
+// progressInterval while the backend can say
+func (s *SDImageService) OnProgress(fn func(ImageProgress)) {
+	s.onProgress = fn
+}
+
+// watchProgress polls the backend for a room's generation until the
+// returned function is called, which waits for the last report to be made
+func (s *SDImageService) watchProgress(ctx context.Context, backend renderer.ImageBackend, room Room) func() {
+	reporter, ok := backend.(renderer.ProgressReporter)
+	if !ok || s.onProgress == nil {
+		return func() {}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	finished := make(chan struct{})
+	go func() {
+		defer close(finished)
+		ticker := time.NewTicker(progressInterval)
+		defer ticker.Stop()
+
+		lastPreview := ""
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			progress, err := reporter.Progress(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					logger.Debug("failed to check image progress", "error", err)
+				}
+				continue
+			}
+			if ctx.Err() != nil {
+				return
+			}
+
+			update := ImageProgress{
+				RoomID:  room.ID(),
+				Room:    room.Name,
+				Percent: int(math.Round(min(max(progress.Progress, 0), 1) * 100)),
+				ETA:     math.Max(progress.ETA, 0),
+				Step:    progress.State.SamplingStep,
+				Steps:   progress.State.SamplingSteps,
+			}
+			if progress.Preview != "" && progress.Preview != lastPreview {
+				update.Preview, lastPreview = progress.Preview, progress.Preview
+			}
+			s.onProgress(update)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-finished
+	}
+}
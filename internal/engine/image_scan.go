@@ -0,0 +1,183 @@
+package engine
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"time"
+
+	"seemud-gui/internal/safefile"
+)
+
+// ScanReport is what a cache integrity scan found. Everything listed has
+// been dealt with: missing entries dropped and bad or orphan files deleted.
+type ScanReport struct {
+	Directory string    `json:"directory"`
+	Started   time.Time `json:"started"`
+	Finished  time.Time `json:"finished"`
+	Checked   int       `json:"checked"` // Images that decoded
+	Skipped   int       `json:"skipped"` // Images in a format this machine can't decode
+	Missing   []string  `json:"missing"` // Index entries whose file had gone
+	Corrupt   []string  `json:"corrupt"` // Images that didn't decode
+	Orphans   []string  `json:"orphans"` // Files the index doesn't know
+	Freed     int64     `json:"freed"`   // Bytes deleted
+}
+
+// Problems returns how many things the scan fixed
+func (r ScanReport) Problems() int {
+	return len(r.Missing) + len(r.Corrupt) + len(r.Orphans)
+}
+
+// Scan checks every cached image decodes, drops index entries whose file has
+// vanished and deletes files nothing in the index refers to. Images are
+// decoded without holding the lock, so the cache stays usable meanwhile.
+func (s *SDImageService) Scan() ScanReport {
+	s.imageCacheMux.RLock()
+	report := ScanReport{Directory: s.cacheDir, Started: time.Now(), Missing: []string{}, Corrupt: []string{}, Orphans: []string{}}
+	var records []ImageRecord
+	for _, images := range []map[string]*ImageRecord{s.roomImageCache, s.legacyImages} {
+		for _, record := range images {
+			records = append(records, *record)
+		}
+	}
+	s.imageCacheMux.RUnlock()
+
+	var missing, corrupt []ImageRecord
+	for _, record := range records {
+		err := checkImage(filepath.Join(report.Directory, record.File))
+		switch {
+		case err == errNoDecoder:
+			report.Skipped++
+		case os.IsNotExist(err):
+			missing = append(missing, record)
+		case err != nil:
+			logger.Warn("cached image is corrupt", "path", record.File, "error", err)
+			corrupt = append(corrupt, record)
+		default:
+			report.Checked++
+		}
+	}
+
+	s.imageCacheMux.Lock()
+	// The server may have changed while decoding
+	if s.cacheDir == report.Directory {
+		for _, record := range missing {
+			if s.forget(record) {
+				report.Missing = append(report.Missing, record.File)
+			}
+		}
+		for _, record := range corrupt {
+			if s.forget(record) {
+				s.removeFile(record.File)
+				report.Corrupt = append(report.Corrupt, record.File)
+				report.Freed += record.Size
+			}
+		}
+		s.removeOrphans(&report)
+		if len(report.Missing)+len(report.Corrupt) > 0 {
+			if err := s.saveIndex(); err != nil {
+				logger.Warn("failed to save image index", "error", err)
+			}
+		}
+	}
+	report.Finished = time.Now()
+	s.lastScan = &report
+	s.imageCacheMux.Unlock()
+
+	logger.Info("scanned image cache", "path", report.Directory, "checked", report.Checked,
+		"missing", len(report.Missing), "corrupt", len(report.Corrupt), "orphans", len(report.Orphans))
+	return report
+}
+
+// LastScan returns the most recent scan's report, if there has been one
+func (s *SDImageService) LastScan() (ScanReport, bool) {
+	s.imageCacheMux.RLock()
+	defer s.imageCacheMux.RUnlock()
+	if s.lastScan == nil {
+		return ScanReport{}, false
+	}
+	return *s.lastScan, true
+}
+
+// forget drops a record from the index if it still refers to the same file,
+// reporting whether it did; the caller must hold the lock
+func (s *SDImageService) forget(record ImageRecord) bool {
+	for _, images := range []map[string]*ImageRecord{s.roomImageCache, s.legacyImages} {
+		for key, current := range images {
+			if current.File == record.File {
+				delete(images, key)
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeOrphans deletes files in the cache directory that no record refers
+// to, such as images left by an interrupted conversion and old metadata
+// files. The caller must hold the lock.
+func (s *SDImageService) removeOrphans(report *ScanReport) {
+	known := map[string]bool{imageIndexFile: true, imageIndexFile + safefile.BackupSuffix: true}
+	for _, images := range []map[string]*ImageRecord{s.roomImageCache, s.legacyImages} {
+		for _, record := range images {
+			known[record.File] = true
+			if record.RoomID != "" {
+				known[record.RoomID+metadataSuffix] = true
+			}
+		}
+	}
+
+	entries, err := os.ReadDir(s.cacheDir)
+	if err != nil {
+		logger.Warn("could not read cache directory", "error", err)
+		return
+	}
+	for _, entry := range entries {
+		// Directories are other servers' caches
+		if entry.IsDir() || known[entry.Name()] {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		// Leave files that may still be being written
+		if time.Since(info.ModTime()) < time.Minute {
+			continue
+		}
+		s.removeFile(entry.Name())
+		report.Orphans = append(report.Orphans, entry.Name())
+		report.Freed += info.Size()
+	}
+}
+
+// errNoDecoder means an image couldn't be checked, rather than being bad
+var errNoDecoder = errors.New("no decoder installed")
+
+// checkImage decodes a cached image fully
+func checkImage(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	format, known := imageFormatOf(path)
+	if !known {
+		return fmt.Errorf("unknown image format")
+	}
+	if ok, _ := EncoderAvailable(format); !ok && format == FormatAVIF {
+		return errNoDecoder
+	}
+	if format != FormatPNG {
+		// WebP and AVIF are checked by converting them as they'd be served
+		if data, err = decodeImage(data, format); err != nil {
+			return err
+		}
+	}
+	if _, _, err := image.Decode(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+	return nil
+}
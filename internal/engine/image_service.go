@@ -0,0 +1,364 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/renderer"
+)
+
+// ImageService produces room images, caching them on disk
+type ImageService interface {
+	// Cached returns the cached base64 image for a room if there is one
+	Cached(room Room) (string, bool)
+	// Generate always creates a new image, replacing any cached one. It
+	// gives up if ctx is cancelled.
+	Generate(ctx context.Context, room Room, customPrompt string) (string, error)
+	// Vary redraws the cached image, keeping its composition more the lower
+	// strength is, or creates a new one if there's none
+	Vary(ctx context.Context, room Room, customPrompt string, strength float64) (string, error)
+	// Redraw creates a new image from the cached image's seed and sampler,
+	// so prompt tweaks keep its style, or from a random seed if there's none
+	Redraw(ctx context.Context, room Room, customPrompt string) (string, error)
+	// Available reports whether the image backend can be reached
+	Available(ctx context.Context) bool
+	// Pending returns how many generations are in progress
+	Pending() int
+}
+
+// SDImageService generates images with Stable Diffusion or another image
+// backend, using the mapper for neighbour context so adjacent rooms look
+// cohesive
+type SDImageService struct {
+	backendMux sync.RWMutex
+	backend    renderer.ImageBackend
+	auth       string // Credentials for the backend, kept for the next one
+
+	mapper   *mapper.Mapper
+	root     string // The shared cache directory, holding a directory per server
+	cacheDir string // The current server's directory, or root before connecting
+
+	imageCacheMux  sync.RWMutex
+	roomImageCache map[string]*ImageRecord // Keyed by room ID
+	legacyImages   map[string]*ImageRecord // Images still named after their room, by sanitised name
+	limits         CacheLimits
+	evicted        int // Images evicted this session
+	encoding       ImageEncoding
+	lastScan       *ScanReport
+	condenser      *renderer.Condenser // Rewrites long descriptions as prompts, if set
+	chooseStyle    func() renderer.StylePreset
+
+	pending     atomic.Int32
+	onGenerated func(elapsed time.Duration, err error)
+	onProgress  func(ImageProgress)
+}
+
+// NewSDImageService creates an image service drawing with backend and
+// caching into cacheDir, or a directory per server within it once UseServer
+// is called. A nil backend makes a service that only reads the cache.
+func NewSDImageService(backend renderer.ImageBackend, m *mapper.Mapper, cacheDir string) *SDImageService {
+	// Ensure cache directory exists
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		logger.Warn("failed to create cache directory", "error", err)
+	}
+
+	s := &SDImageService{
+		backend:  backend,
+		mapper:   m,
+		root:     cacheDir,
+		cacheDir: cacheDir,
+		limits:   DefaultCacheLimits,
+		encoding: DefaultImageEncoding,
+	}
+	s.loadIndex()
+	return s
+}
+
+// Available implements ImageService
+func (s *SDImageService) Available(ctx context.Context) bool {
+	backend := s.Backend()
+	return backend != nil && backend.CheckHealth(ctx) == nil
+}
+
+// Backend returns what draws the images, nil for a cache-only service
+func (s *SDImageService) Backend() renderer.ImageBackend {
+	s.backendMux.RLock()
+	defer s.backendMux.RUnlock()
+	return s.backend
+}
+
+// SetBackend switches to another image backend, keeping the credentials
+// set for the last. Images already cached are kept.
+func (s *SDImageService) SetBackend(backend renderer.ImageBackend) {
+	s.backendMux.Lock()
+	defer s.backendMux.Unlock()
+
+	backend.SetAuth(s.auth)
+	s.backend = backend
+	logger.Info("image backend", "backend", backend.Name())
+}
+
+// Cached implements ImageService
+func (s *SDImageService) Cached(room Room) (string, bool) {
+	return s.loadImageFromCache(room)
+}
+
+// CachedPath returns where a room's cached image is on disk
+func (s *SDImageService) CachedPath(room Room) (string, bool) {
+	return s.cachedPath(room)
+}
+
+// Pending implements ImageService
+func (s *SDImageService) Pending() int {
+	return int(s.pending.Load())
+}
+
+// SetAuth sets the credentials sent to the image backend
+func (s *SDImageService) SetAuth(credentials string) {
+	s.backendMux.Lock()
+	defer s.backendMux.Unlock()
+
+	s.auth = credentials
+	if s.backend != nil {
+		s.backend.SetAuth(credentials)
+	}
+}
+
+// SetCondenser sets the language model that condenses long descriptions
+// before they're drawn
+func (s *SDImageService) SetCondenser(condenser *renderer.Condenser) {
+	s.condenser = condenser
+}
+
+// Condenser returns the description condenser, nil if there isn't one
+func (s *SDImageService) Condenser() *renderer.Condenser {
+	return s.condenser
+}
+
+// SetStyle sets how the style of each new image is chosen; without it
+// rooms are drawn in renderer.DefaultStyle
+func (s *SDImageService) SetStyle(choose func() renderer.StylePreset) {
+	s.chooseStyle = choose
+}
+
+// Style returns the style the next image will be drawn in
+func (s *SDImageService) Style() renderer.StylePreset {
+	if s.chooseStyle != nil {
+		return s.chooseStyle()
+	}
+	return renderer.BuiltInStyles[0]
+}
+
+// OnGenerated sets a function told how long each generation took
+func (s *SDImageService) OnGenerated(fn func(elapsed time.Duration, err error)) {
+	s.onGenerated = fn
+}
+
+// Generate implements ImageService
+func (s *SDImageService) Generate(ctx context.Context, room Room, customPrompt string) (string, error) {
+	return s.timed(ctx, room, customPrompt, "", 0, ImageRecord{})
+}
+
+// Vary implements ImageService
+func (s *SDImageService) Vary(ctx context.Context, room Room, customPrompt string, strength float64) (string, error) {
+	initImage, _ := s.loadImageFromCache(room)
+	return s.timed(ctx, room, customPrompt, initImage, strength, ImageRecord{})
+}
+
+// Redraw implements ImageService
+func (s *SDImageService) Redraw(ctx context.Context, room Room, customPrompt string) (string, error) {
+	previous, _ := s.Record(room)
+	return s.timed(ctx, room, customPrompt, "", 0, previous)
+}
+
+// timed generates an image, counting it as pending and reporting how long
+// it took
+func (s *SDImageService) timed(ctx context.Context, room Room, customPrompt, initImage string, strength float64, previous ImageRecord) (string, error) {
+	s.pending.Add(1)
+	defer s.pending.Add(-1)
+
+	started := time.Now()
+	image, err := s.generate(ctx, room, customPrompt, initImage, strength, previous)
+	if s.onGenerated != nil {
+		s.onGenerated(time.Since(started), err)
+	}
+	return image, err
+}
+
+// generate does the work for Generate, Vary and Redraw, starting from
+// initImage if it isn't empty and reusing the seed of previous if it has one
+func (s *SDImageService) generate(parent context.Context, room Room, customPrompt, initImage string, strength float64, previous ImageRecord) (string, error) {
+	backend := s.Backend()
+	if backend == nil {
+		return "", fmt.Errorf("no image backend")
+	}
+
+	// Check if the backend is available
+	ctx, cancel := context.WithTimeout(parent, 5*time.Second)
+	defer cancel()
+
+	if err := backend.CheckHealth(ctx); err != nil {
+		return "", fmt.Errorf("%s not available: %w", backend.Name(), err)
+	}
+
+	// Get neighbour context from mapper
+	neighbours := s.mapper.GetNeighbours()
+	neighbourMap := make(map[string]map[string]string)
+
+	if neighbours != nil {
+		for direction, neighbour := range neighbours {
+			neighbourMap[direction] = map[string]string{
+				"name":        neighbour.Name,
+				"description": neighbour.Description,
+			}
+		}
+	}
+
+	// Prompt additions saved with the room apply to every generation
+	if saved := s.mapper.RoomPrompt(room.ID()); saved != "" {
+		if customPrompt != "" {
+			customPrompt = saved + ", " + customPrompt
+		} else {
+			customPrompt = saved
+		}
+	}
+
+	description := s.condense(parent, room)
+	style := s.Style()
+
+	// Generate new image with neighbour context
+	logger.Info("generating image", "room", room.Name, "neighbours", len(neighbourMap))
+	var prompt string
+	if customPrompt != "" {
+		logger.Debug("using custom prompt additions", "prompt", customPrompt)
+		prompt = renderer.RoomImagePromptWithNeighboursAndCustom(room.Name, description, neighbourMap, customPrompt, style)
+	} else if len(neighbourMap) > 0 {
+		prompt = renderer.RoomImagePromptWithNeighbours(room.Name, description, neighbourMap, style)
+	} else {
+		prompt = renderer.RoomImagePrompt(room.Name, description, style)
+	}
+	req := &renderer.Txt2ImgRequest{
+		Prompt:         prompt,
+		NegativePrompt: style.Negative(),
+		Width:          512,
+		Height:         512,
+		Steps:          20,
+		CFGScale:       7.0,
+		Seed:           previous.Seed,
+	}
+	// Sampler names differ between backends
+	if previous.Backend == backend.Name() {
+		req.SamplerName = previous.Sampler
+	}
+	if previous.Seed != 0 {
+		logger.Debug("reusing seed", "room", room.Name, "seed", previous.Seed)
+	}
+
+	ctx, cancel = context.WithTimeout(parent, 120*time.Second)
+	defer cancel()
+
+	stopWatching := s.watchProgress(ctx, backend, room)
+	var resp *renderer.Txt2ImgResponse
+	var err error
+	if initImage != "" {
+		variation := &renderer.Img2ImgRequest{
+			Txt2ImgRequest:    *req,
+			InitImages:        []string{initImage},
+			DenoisingStrength: strength,
+		}
+		logger.Debug("drawing a variation", "room", room.Name, "strength", strength)
+		resp, err = backend.Img2Img(ctx, variation)
+		*req, strength = variation.Txt2ImgRequest, variation.DenoisingStrength
+	} else {
+		resp, err = backend.GenerateImage(ctx, req)
+	}
+	stopWatching()
+	if err != nil {
+		return "", fmt.Errorf("failed to generate image: %w", err)
+	}
+
+	if len(resp.Images) == 0 {
+		return "", fmt.Errorf("no images generated")
+	}
+
+	base64Image := resp.Images[0]
+	info := resp.ParseInfo()
+	record := ImageRecord{
+		Prompt:         req.Prompt,
+		NegativePrompt: req.NegativePrompt,
+		CustomPrompt:   customPrompt,
+		Style:          style.Name,
+		Backend:        backend.Name(),
+		Seed:           info.Seed,
+		Model:          info.Model,
+		ModelHash:      info.ModelHash,
+		Sampler:        req.SamplerName,
+		Steps:          req.Steps,
+		CFGScale:       req.CFGScale,
+		Width:          req.Width,
+		Height:         req.Height,
+		Neighbours:     len(neighbourMap),
+	}
+	if initImage != "" {
+		record.Strength = strength
+	}
+	if info.Sampler != "" {
+		record.Sampler = info.Sampler
+	}
+
+	// Save to cache (overwrites existing)
+	if err := s.saveImageToCache(room, base64Image, record); err != nil {
+		logger.Warn("failed to save image to cache", "error", err)
+		// Don't fail the operation, just warn
+	}
+
+	// Return base64 encoded image
+	return base64Image, nil
+}
+
+// Helper functions for image caching
+
+// sanitizeRoomName converts a room name to the filename images were cached
+// under before they were keyed by room ID
+func sanitizeRoomName(roomName string) string {
+	// Remove or replace characters that aren't safe for filenames
+	reg := regexp.MustCompile(`[^a-zA-Z0-9_\-]`)
+	sanitized := reg.ReplaceAllString(strings.ToLower(roomName), "_")
+	// Remove multiple underscores
+	reg = regexp.MustCompile(`_+`)
+	sanitized = reg.ReplaceAllString(sanitized, "_")
+	// Trim underscores from ends
+	sanitized = strings.Trim(sanitized, "_")
+
+	if sanitized == "" {
+		sanitized = "unknown_room"
+	}
+
+	return sanitized
+}
+
+// condense returns the room's description as a tight visual prompt when a
+// condenser is set up, or unchanged if not or if the model can't be reached
+func (s *SDImageService) condense(parent context.Context, room Room) string {
+	if s.condenser == nil || !s.condenser.Active(room.Description) {
+		return room.Description
+	}
+
+	ctx, cancel := context.WithTimeout(parent, 60*time.Second)
+	defer cancel()
+
+	condensed, err := s.condenser.Condense(ctx, room.Name, room.Description)
+	if err != nil {
+		logger.Warn("failed to condense description, using it as is", "room", room.Name, "error", err)
+		return room.Description
+	}
+	logger.Debug("condensed description", "room", room.Name, "prompt", condensed)
+	return condensed
+}
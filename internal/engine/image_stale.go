@@ -0,0 +1,69 @@
+package engine
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"unicode"
+)
+
+// sameDescription is how much of a description's wording must survive for
+// an image to still fit it. Below this, the room has been renovated or has
+// changed with the seasons and wants a new image.
+const sameDescription = 0.8
+
+// DescriptionHash fingerprints a description by its words, ignoring case,
+// punctuation and spacing, which don't change what the room looks like
+func DescriptionHash(description string) string {
+	sum := sha256.Sum256([]byte(strings.Join(descriptionWords(description), " ")))
+	return hex.EncodeToString(sum[:8])
+}
+
+// descriptionWords splits a description into lowercase words
+func descriptionWords(description string) []string {
+	return strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// Stale reports whether the image no longer matches the room, because its
+// description has changed materially since the image was drawn, or it was
+// drawn in a style other than the one the room is drawn in now or before
+// styles were recorded
+func (r ImageRecord) Stale(room Room, style string) bool {
+	return r.Style != style || r.descriptionChanged(room.Description)
+}
+
+// descriptionChanged reports whether a description differs enough from the
+// one the image was drawn for to need a new image. Rewording a sentence or
+// a changing detail or two doesn't.
+func (r ImageRecord) descriptionChanged(description string) bool {
+	if r.DescriptionHash != "" && r.DescriptionHash == DescriptionHash(description) {
+		return false
+	}
+	if r.Description == "" {
+		return true
+	}
+	return wordSimilarity(descriptionWords(r.Description), descriptionWords(description)) < sameDescription
+}
+
+// wordSimilarity is the share of distinct words two texts have in common
+func wordSimilarity(a, b []string) float64 {
+	words := make(map[string]int)
+	for _, word := range a {
+		words[word] |= 1
+	}
+	for _, word := range b {
+		words[word] |= 2
+	}
+	if len(words) == 0 {
+		return 1
+	}
+	shared := 0
+	for _, sides := range words {
+		if sides == 3 {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(words))
+}
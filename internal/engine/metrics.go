@@ -0,0 +1,77 @@
+package engine
+
+import (
+	"time"
+
+	"seemud-gui/internal/metrics"
+	"seemud-gui/internal/parser"
+)
+
+// Metrics are the engine's Prometheus metrics. They are always collected;
+// serving them is opt-in.
+type Metrics struct {
+	Registry *metrics.Registry
+
+	lines       *metrics.Counter
+	lineTypes   *metrics.CounterVec
+	lineRate    *metrics.Rate
+	generations *metrics.CounterVec
+	latency     *metrics.Histogram
+}
+
+// newMetrics registers the engine's metrics, reading gauges from e on
+// every scrape
+func newMetrics(e *Engine) *Metrics {
+	r := metrics.NewRegistry()
+	m := &Metrics{
+		Registry:    r,
+		lines:       r.Counter("seemud_lines_total", "Lines of output received."),
+		lineTypes:   r.CounterVec("seemud_parsed_lines_total", "Lines of output by parsed type.", "type"),
+		lineRate:    metrics.NewRate(time.Minute),
+		generations: r.CounterVec("seemud_image_generations_total", "Image generations by result.", "result"),
+		latency: r.Histogram("seemud_image_generation_seconds", "Time taken to generate a room image.",
+			[]float64{1, 2, 5, 10, 20, 30, 60, 120}),
+	}
+
+	r.GaugeFunc("seemud_lines_per_second", "Lines of output per second over the last minute.", m.lineRate.PerSecond)
+	r.CounterFunc("seemud_dropped_lines_total", "Lines dropped because output was not read fast enough.", func() float64 {
+		return float64(e.Session.Dropped())
+	})
+	r.GaugeFunc("seemud_image_queue_depth", "Image generations in progress.", func() float64 {
+		if e.Images == nil {
+			return 0
+		}
+		return float64(e.Images.Pending())
+	})
+	r.GaugeFunc("seemud_map_rooms", "Rooms in the current map.", func() float64 {
+		return float64(e.Mapper.RoomCount())
+	})
+	r.GaugeFunc("seemud_command_queue_depth", "Paced commands waiting to be sent.", func() float64 {
+		return float64(e.Queue.Len())
+	})
+	r.GaugeFunc("seemud_connected", "Whether a MUD connection is open.", func() float64 {
+		if e.Session.IsConnected() {
+			return 1
+		}
+		return 0
+	})
+
+	return m
+}
+
+// lineHandled counts a line of output
+func (m *Metrics) lineHandled(parsed *parser.ParsedOutput) {
+	m.lines.Inc()
+	m.lineRate.Mark()
+	m.lineTypes.Inc(parsed.Type.String())
+}
+
+// imageGenerated records how a generation went
+func (m *Metrics) imageGenerated(elapsed time.Duration, err error) {
+	if err != nil {
+		m.generations.Inc("error")
+		return
+	}
+	m.generations.Inc("success")
+	m.latency.ObserveDuration(elapsed)
+}
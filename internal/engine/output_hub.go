@@ -0,0 +1,76 @@
+package engine
+
+import (
+	"sync"
+
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/parser"
+)
+
+// OutputHub stores parsed output and fans it out to live subscribers
+type OutputHub interface {
+	Publish(line string, parsed *parser.ParsedOutput) output.Entry
+	Since(seq int64) output.Batch
+	Range(first, last int64) []output.Entry
+	Subscribe(fn func(output.Entry)) func()
+}
+
+// RingHub is an OutputHub backed by a sequenced ring buffer
+type RingHub struct {
+	ring        *output.Ring
+	mutex       sync.RWMutex
+	subscribers map[int]func(output.Entry)
+	nextID      int
+}
+
+// NewRingHub creates a hub keeping size lines of scrollback
+func NewRingHub(size int) *RingHub {
+	return &RingHub{
+		ring:        output.NewRing(size),
+		subscribers: make(map[int]func(output.Entry)),
+	}
+}
+
+// Publish stores a line and notifies subscribers
+func (h *RingHub) Publish(line string, parsed *parser.ParsedOutput) output.Entry {
+	entry := h.ring.Append(line, output.FromParsed(parsed))
+
+	h.mutex.RLock()
+	subscribers := make([]func(output.Entry), 0, len(h.subscribers))
+	for _, fn := range h.subscribers {
+		subscribers = append(subscribers, fn)
+	}
+	h.mutex.RUnlock()
+
+	for _, fn := range subscribers {
+		fn(entry)
+	}
+	return entry
+}
+
+// Since returns entries newer than seq
+func (h *RingHub) Since(seq int64) output.Batch {
+	return h.ring.Since(seq, 0)
+}
+
+// Range returns entries between two sequence numbers inclusive
+func (h *RingHub) Range(first, last int64) []output.Entry {
+	return h.ring.Range(first, last)
+}
+
+// Subscribe registers a function called for every new entry. It runs on the
+// output goroutine, so it must not block.
+func (h *RingHub) Subscribe(fn func(output.Entry)) func() {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	id := h.nextID
+	h.nextID++
+	h.subscribers[id] = fn
+
+	return func() {
+		h.mutex.Lock()
+		delete(h.subscribers, id)
+		h.mutex.Unlock()
+	}
+}
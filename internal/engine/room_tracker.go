@@ -0,0 +1,216 @@
+package engine
+
+import (
+	"sync"
+
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/parser"
+)
+
+// Room is the room the player is currently in, as seen in the output
+type Room struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MapID       string `json:"-"` // The mapper's ID for the room, once it has one
+}
+
+// ID returns the room's mapper ID, or the one its name and description
+// would make before the mapper has seen it
+func (r Room) ID() string {
+	if r.MapID != "" {
+		return r.MapID
+	}
+	return mapper.GenerateRoomID(r.Name, r.Description)
+}
+
+// Entities are the items and mobs seen in the current room
+type Entities struct {
+	Items []string `json:"items"`
+	Mobs  []string `json:"mobs"`
+}
+
+// RoomTracker follows the current room and its contents, feeding the mapper
+type RoomTracker interface {
+	HandleParsed(parsed *parser.ParsedOutput)
+	Current() (Room, bool)
+	Entities() Entities
+	// Revise replaces entities the parser guessed with a better answer
+	Revise(guess, result parser.EntityResult)
+	// OnRevise registers a function called when Revise changes the current
+	// room's entities, which happens between lines
+	OnRevise(fn func())
+}
+
+// ParsedRoomTracker assembles rooms from parsed title, description and exit
+// lines
+type ParsedRoomTracker struct {
+	mapper *mapper.Mapper
+
+	roomMux     sync.RWMutex
+	currentRoom *parser.ParsedOutput
+	mapped      bool // The mapper has entered the current room
+
+	entityMux    sync.RWMutex
+	currentItems []string
+	currentMobs  []string
+	onRevise     []func()
+}
+
+// NewParsedRoomTracker creates a tracker notifying the given mapper, or
+// none if m is nil
+func NewParsedRoomTracker(m *mapper.Mapper) *ParsedRoomTracker {
+	return &ParsedRoomTracker{mapper: m}
+}
+
+// HandleParsed updates the current room from one line of output
+func (t *ParsedRoomTracker) HandleParsed(parsed *parser.ParsedOutput) {
+	if parsed.Type == parser.TypeRoomTitle {
+		t.roomMux.Lock()
+		t.currentRoom = parsed
+		t.mapped = false
+		t.roomMux.Unlock()
+
+		// Clear entities when entering new room
+		t.entityMux.Lock()
+		t.currentItems = []string{}
+		t.currentMobs = []string{}
+		t.entityMux.Unlock()
+
+		logger.Debug("room title detected", "room", parsed.RoomName)
+
+		// Notify mapper of room entry (will be updated with description and exits later)
+	} else if parsed.Type == parser.TypeRoomDescription {
+		t.roomMux.Lock()
+		if t.currentRoom != nil && t.currentRoom.Type == parser.TypeRoomTitle {
+			// Only add description if we have a valid room title
+			t.currentRoom.Content += " " + parsed.Content
+			logger.Debug("room description added", "content", parsed.Content)
+		}
+		t.roomMux.Unlock()
+	} else if parsed.Type == parser.TypeExits && len(parsed.Exits) > 0 {
+		// When we get exits, we have enough info to notify mapper
+		t.roomMux.RLock()
+		if t.currentRoom != nil && t.currentRoom.RoomName != "" {
+			roomName := t.currentRoom.RoomName
+			roomDesc := t.currentRoom.Content
+			exits := parsed.Exits
+			t.roomMux.RUnlock()
+
+			// Notify mapper inline so the room is linked before the next
+			// movement command can overwrite the direction taken
+			if t.mapper != nil {
+				// Marked first so listeners asking for the room get its ID
+				t.roomMux.Lock()
+				t.mapped = true
+				t.roomMux.Unlock()
+				t.mapper.OnRoomEntered(roomName, roomDesc, exits)
+			}
+		} else {
+			t.roomMux.RUnlock()
+		}
+	}
+
+	// Entities usually have lines of their own, but a language model can
+	// find them in description too, and one line may have items and mobs
+	if len(parsed.Items) > 0 {
+		// Add items to current room inventory
+		t.entityMux.Lock()
+		t.currentItems = append(t.currentItems, parsed.Items...)
+		t.entityMux.Unlock()
+		logger.Debug("items detected", "items", parsed.Items)
+	}
+	if len(parsed.Mobs) > 0 {
+		// Add mobs to current room
+		t.entityMux.Lock()
+		t.currentMobs = append(t.currentMobs, parsed.Mobs...)
+		t.entityMux.Unlock()
+		logger.Debug("mobs detected", "mobs", parsed.Mobs)
+	}
+}
+
+// Revise swaps what the parser guessed a line held for what a language
+// model made of it, if the guess is still among the current room's
+// entities. Answers come back after the line, by which time the player may
+// have moved on; a guess found here means the same line was seen here.
+func (t *ParsedRoomTracker) Revise(guess, result parser.EntityResult) {
+	if len(guess.Items) == 0 && len(guess.Mobs) == 0 {
+		return
+	}
+
+	t.entityMux.Lock()
+	items, foundItems := without(t.currentItems, guess.Items)
+	mobs, foundMobs := without(t.currentMobs, guess.Mobs)
+	if !foundItems || !foundMobs {
+		t.entityMux.Unlock()
+		return
+	}
+	t.currentItems = append(items, result.Items...)
+	t.currentMobs = append(mobs, result.Mobs...)
+	listeners := t.onRevise
+	t.entityMux.Unlock()
+	logger.Debug("entities revised", "items", result.Items, "mobs", result.Mobs)
+
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+// OnRevise implements RoomTracker
+func (t *ParsedRoomTracker) OnRevise(fn func()) {
+	t.entityMux.Lock()
+	defer t.entityMux.Unlock()
+	t.onRevise = append(t.onRevise, fn)
+}
+
+// without returns list less one of each of remove, and whether they were
+// all there
+func without(list, remove []string) ([]string, bool) {
+	kept := append([]string(nil), list...)
+	for _, name := range remove {
+		found := false
+		for i, entry := range kept {
+			if entry == name {
+				kept = append(kept[:i], kept[i+1:]...)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return list, false
+		}
+	}
+	return kept, true
+}
+
+// Current returns the current room, or false if no room has been seen
+func (t *ParsedRoomTracker) Current() (Room, bool) {
+	t.roomMux.RLock()
+	defer t.roomMux.RUnlock()
+
+	if t.currentRoom == nil || t.currentRoom.Type != parser.TypeRoomTitle || t.currentRoom.RoomName == "" {
+		return Room{}, false
+	}
+
+	room := Room{
+		Name:        t.currentRoom.RoomName,
+		Description: t.currentRoom.Content,
+	}
+	// Asked rather than kept, so the ID follows a change of fingerprint
+	if t.mapped {
+		if current := t.mapper.GetCurrentRoom(); current != nil && current.Name == room.Name {
+			room.MapID = current.ID
+		}
+	}
+	return room, true
+}
+
+// Entities returns the items and mobs in the current room
+func (t *ParsedRoomTracker) Entities() Entities {
+	t.entityMux.RLock()
+	defer t.entityMux.RUnlock()
+
+	return Entities{
+		Items: t.currentItems,
+		Mobs:  t.currentMobs,
+	}
+}
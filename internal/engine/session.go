@@ -0,0 +1,163 @@
+package engine
+
+import (
+	"fmt"
+	"sync"
+
+	"seemud-gui/internal/session"
+	"seemud-gui/internal/telnet"
+)
+
+// Session is a connection to a MUD server plus its connection state
+type Session interface {
+	// SetOptions sets how later connections are made, e.g. over TLS
+	SetOptions(opts telnet.Options)
+	Connect(host, port string) error
+	Disconnect() error
+	Send(command string) error
+	IsConnected() bool
+	// Lines and Done belong to the current connection and change on reconnect
+	Lines() <-chan string
+	Done() <-chan struct{}
+	// UserClosed reports whether the last connection was ended by Disconnect
+	// rather than dropped by the server
+	UserClosed() bool
+	// Dropped counts lines lost because output wasn't read fast enough
+	Dropped() int64
+	Machine() *session.Machine
+	// MSDP holds what the server reports over MSDP, across connections
+	MSDP() *telnet.MSDP
+}
+
+// TelnetSession is a Session over the telnet client
+type TelnetSession struct {
+	mutex      sync.RWMutex
+	client     *telnet.Client
+	machine    *session.Machine
+	options    telnet.Options
+	msdp       *telnet.MSDP
+	userClosed bool
+	dropped    int64 // Lines dropped by earlier connections
+}
+
+// NewTelnetSession creates a disconnected telnet session
+func NewTelnetSession() *TelnetSession {
+	return &TelnetSession{machine: session.NewMachine(), msdp: telnet.NewMSDP()}
+}
+
+// SetOptions implements Session
+func (s *TelnetSession) SetOptions(opts telnet.Options) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.options = opts
+}
+
+// Connect opens a new telnet connection
+func (s *TelnetSession) Connect(host, port string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.client != nil && s.client.IsConnected() {
+		return fmt.Errorf("already connected")
+	}
+
+	s.machine.Transition(session.StateConnecting)
+	client := telnet.NewClientWithOptions(host, port, s.options)
+	client.UseMSDP(s.msdp)
+	if err := client.Connect(); err != nil {
+		s.machine.Transition(session.StateDisconnected)
+		return err
+	}
+
+	if s.client != nil {
+		s.dropped += s.client.Dropped()
+	}
+	s.client = client
+	s.userClosed = false
+	return nil
+}
+
+// Disconnect closes the connection at the user's request
+func (s *TelnetSession) Disconnect() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.client == nil {
+		return nil
+	}
+
+	s.userClosed = true
+	s.machine.Transition(session.StateDisconnected)
+	return s.client.Disconnect()
+}
+
+// Send sends a command to the server
+func (s *TelnetSession) Send(command string) error {
+	s.mutex.RLock()
+	client := s.client
+	s.mutex.RUnlock()
+
+	if client == nil || !client.IsConnected() {
+		return fmt.Errorf("not connected to MUD")
+	}
+	return client.SendCommand(command)
+}
+
+// IsConnected reports whether the connection is open
+func (s *TelnetSession) IsConnected() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.client != nil && s.client.IsConnected()
+}
+
+// Lines returns the current connection's output channel
+func (s *TelnetSession) Lines() <-chan string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.client == nil {
+		return nil
+	}
+	return s.client.GetOutput()
+}
+
+// Done returns a channel closed when the current connection ends
+func (s *TelnetSession) Done() <-chan struct{} {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.client == nil {
+		closed := make(chan struct{})
+		close(closed)
+		return closed
+	}
+	return s.client.Done()
+}
+
+// UserClosed implements Session
+func (s *TelnetSession) UserClosed() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.userClosed
+}
+
+// Dropped implements Session, counting every connection this session made
+func (s *TelnetSession) Dropped() int64 {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if s.client == nil {
+		return s.dropped
+	}
+	return s.dropped + s.client.Dropped()
+}
+
+// Machine returns the connection state machine
+func (s *TelnetSession) Machine() *session.Machine {
+	return s.machine
+}
+
+// MSDP implements Session
+func (s *TelnetSession) MSDP() *telnet.MSDP {
+	return s.msdp
+}
@@ -0,0 +1,192 @@
+package engine
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/session"
+	"seemud-gui/internal/statedb"
+	"seemud-gui/internal/stats"
+)
+
+// StateDBEnvVar turns on the SQLite state database when set to 1
+const StateDBEnvVar = "SEEMUD_STATE_DB"
+
+// importedSuffix is added to map files once the database has absorbed them
+const importedSuffix = ".imported"
+
+// stateDBPath returns where the state database lives, or "" unless it has
+// been turned on
+func stateDBPath(dir datadir.Dir) string {
+	if strings.TrimSpace(os.Getenv(StateDBEnvVar)) != "1" {
+		return ""
+	}
+	return dir.Join("state.db")
+}
+
+// openState opens the state database and starts recording command history
+// in it. Only commands sent in game are kept, so logins and passwords never
+// are. Without a database, everything stays in the JSON files.
+func (e *Engine) openState(path string) {
+	if path == "" {
+		return
+	}
+	db, err := statedb.Open(path)
+	if err != nil {
+		logger.Warn("failed to open state database, using files", "error", err)
+		return
+	}
+	e.DB = db
+	logger.Info("using state database", "path", path)
+
+	e.senders = append(e.senders, func(command string) {
+		if e.State() != session.StateInGame {
+			return
+		}
+		if err := e.DB.AddCommand(e.ServerName(), command); err != nil {
+			logger.Warn("failed to record command", "error", err)
+		}
+	})
+}
+
+// recordSession saves a finished session's stats
+func (e *Engine) recordSession(final stats.Stats) {
+	if e.DB == nil {
+		return
+	}
+	if err := e.DB.AddSession(e.ServerName(), final); err != nil {
+		logger.Warn("failed to record session", "error", err)
+	}
+}
+
+// saveMap saves a server's map, to the database if there is one, along with
+// the image metadata and triggers it has alongside
+func (e *Engine) saveMap(serverName string) error {
+	if e.DB == nil {
+		return e.Mapper.SaveMap(serverName)
+	}
+
+	raw, err := e.Mapper.MarshalMap(serverName)
+	if err != nil {
+		return err
+	}
+	var data mapper.MapData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return fmt.Errorf("failed to read map: %w", err)
+	}
+	if err := e.DB.SaveMap(serverName, &data); err != nil {
+		return err
+	}
+
+	if images, ok := e.Images.(*SDImageService); ok {
+		var saved []statedb.Image
+		for _, record := range images.Records() {
+			encoded, err := json.Marshal(record)
+			if err != nil {
+				continue
+			}
+			saved = append(saved, statedb.Image{
+				RoomID:  record.RoomID,
+				File:    record.File,
+				Prompt:  record.Prompt,
+				Created: record.Created,
+				Record:  encoded,
+			})
+		}
+		if err := e.DB.SaveImages(serverName, saved); err != nil {
+			logger.Warn("failed to save image metadata", "error", err)
+		}
+	}
+	if err := e.DB.SaveTriggers(e.Triggers.List()); err != nil {
+		logger.Warn("failed to save triggers", "error", err)
+	}
+
+	logger.Info("saved map", "rooms", e.Mapper.RoomCount(), "server", serverName)
+	return nil
+}
+
+// loadMap loads a server's map, from the database if there is one. A map
+// file beside it is from before the database, or was written since by
+// something working on files, such as an art pack import for another
+// server; its rooms are merged in and the file set aside.
+func (e *Engine) loadMap(serverName string) error {
+	if e.DB == nil {
+		return e.Mapper.LoadMap(serverName)
+	}
+
+	data, found, err := e.DB.LoadMap(serverName)
+	if err != nil {
+		return err
+	}
+	if found {
+		e.Mapper.SetGraph(data.Graph)
+		if err := e.Mapper.SetFingerprint(data.Fingerprint); err != nil {
+			logger.Warn("unknown map fingerprint", "error", err)
+		}
+		if data.CurrentRoomID != "" {
+			if err := e.Mapper.SetCurrentRoom(data.CurrentRoomID); err != nil {
+				logger.Debug("saved room is not on the map", "error", err)
+			}
+		}
+	} else if err := e.Mapper.LoadMap(serverName); err != nil {
+		return err
+	}
+
+	path := e.Mapper.MapFile(serverName)
+	if _, err := os.Stat(path); err != nil {
+		return nil
+	}
+	if found {
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read map file: %w", err)
+		}
+		var file mapper.MapData
+		if err := json.Unmarshal(raw, &file); err != nil {
+			return fmt.Errorf("failed to unmarshal map data: %w", err)
+		}
+		added := e.Mapper.MergeMap(&file)
+		logger.Info("merged map file into database", "path", path, "rooms", added)
+	}
+	if err := e.saveMap(serverName); err != nil {
+		return err
+	}
+	if err := os.Rename(path, path+importedSuffix); err != nil {
+		logger.Warn("failed to set aside imported map file", "error", err)
+	}
+	return nil
+}
+
+// closeState closes the state database, if there is one
+func (e *Engine) closeState() {
+	if e.DB == nil {
+		return
+	}
+	if err := e.DB.Close(); err != nil {
+		logger.Warn("failed to close state database", "error", err)
+	}
+}
+
+// MigrateFingerprint changes how the current server's rooms are identified,
+// giving every mapped room an ID made the new way, moving cached images
+// with their rooms and saving the map. It returns how many rooms there are
+// now, fewer if copies of a room were merged.
+func (e *Engine) MigrateFingerprint(fingerprint string) (int, error) {
+	ids, err := e.Mapper.MigrateFingerprint(fingerprint)
+	if err != nil {
+		return 0, err
+	}
+	if images, ok := e.Images.(*SDImageService); ok {
+		images.RenameRooms(ids)
+	}
+	if e.ServerName() != "" {
+		if err := e.SaveMap(); err != nil {
+			return 0, err
+		}
+	}
+	return e.Mapper.RoomCount(), nil
+}
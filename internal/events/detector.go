@@ -0,0 +1,146 @@
+package events
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/parser"
+)
+
+// CombatTimeout is how long without a combat line before a fight is over
+const CombatTimeout = 10 * time.Second
+
+// Detector turns parsed lines into semantic events. It keeps a little state
+// so that, for example, only the first blow of a fight is a combat start.
+type Detector struct {
+	mutex      sync.Mutex
+	inCombat   bool
+	lastCombat time.Time
+
+	deathRegex *regexp.Regexp
+	levelRegex *regexp.Regexp
+	killRegex  *regexp.Regexp
+	mspRegex   *regexp.Regexp
+	mspArg     *regexp.Regexp
+}
+
+// NewDetector creates a detector with patterns common across MUD families
+func NewDetector() *Detector {
+	return &Detector{
+		deathRegex: regexp.MustCompile(`(?i)^(?:you (?:are|have been) (?:dead|killed|slain)|you have died|you die\b|R\.?I\.?P\.?)`),
+		levelRegex: regexp.MustCompile(`(?i)(?:you (?:raise|gain|advance|have gained) (?:a|another) level|you are now level \d+|welcome to level \d+|level up!)`),
+		killRegex:  regexp.MustCompile(`(?i)^(?:you (?:kill|have killed|slay) .+|.+ (?:is dead|dies|has died)[.!]?)$`),
+		mspRegex:   regexp.MustCompile(`!!(SOUND|MUSIC)\(([^)]*)\)`),
+		mspArg:     regexp.MustCompile(`([A-Z])=(\S+)`),
+	}
+}
+
+// Detect returns the events caused by one parsed line
+func (d *Detector) Detect(parsed *parser.ParsedOutput) []Event {
+	text := strings.TrimSpace(parsed.CleanText)
+	if text == "" {
+		return nil
+	}
+
+	var found []Event
+
+	// MSP triggers can appear on any line
+	for _, match := range d.mspRegex.FindAllStringSubmatch(text, -1) {
+		found = append(found, New(KindSound, match[0], d.mspFields(match[1], match[2])))
+	}
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	now := time.Now()
+	if d.inCombat && now.Sub(d.lastCombat) > CombatTimeout {
+		d.inCombat = false
+		found = append(found, New(KindCombatEnd, "", map[string]string{"reason": "timeout"}))
+	}
+
+	switch parsed.Type {
+	case parser.TypeRoomTitle:
+		found = append(found, New(KindRoomEnter, parsed.RoomName, map[string]string{"room": parsed.RoomName}))
+	case parser.TypeSay:
+		found = append(found, New(KindSay, text, chatFields(parsed)))
+	case parser.TypeTell:
+		found = append(found, New(KindTell, text, chatFields(parsed)))
+	case parser.TypeChannel:
+		found = append(found, New(KindChannel, text, chatFields(parsed)))
+	case parser.TypeCombat:
+		fields := map[string]string{"opponent": parsed.Opponent}
+		if !d.inCombat {
+			d.inCombat = true
+			found = append(found, New(KindCombatStart, text, fields))
+		}
+		d.lastCombat = now
+		if parsed.Incoming {
+			found = append(found, New(KindAttacked, text, fields))
+		}
+	}
+
+	if d.deathRegex.MatchString(text) {
+		found = append(found, New(KindDeath, text, nil))
+		if d.inCombat {
+			d.inCombat = false
+			found = append(found, New(KindCombatEnd, text, map[string]string{"reason": "death"}))
+		}
+	} else if d.inCombat && d.killRegex.MatchString(text) {
+		d.inCombat = false
+		found = append(found, New(KindCombatEnd, text, map[string]string{"reason": "kill"}))
+	}
+
+	if d.levelRegex.MatchString(text) {
+		found = append(found, New(KindLevelUp, text, nil))
+	}
+
+	return found
+}
+
+// Reset clears combat state, e.g. after a disconnect
+func (d *Detector) Reset() {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+	d.inCombat = false
+}
+
+// mspFields parses "file V=50 L=1 T=combat" into event fields
+func (d *Detector) mspFields(trigger, args string) map[string]string {
+	fields := map[string]string{"trigger": strings.ToLower(trigger)}
+
+	parts := strings.Fields(args)
+	if len(parts) > 0 && !strings.Contains(parts[0], "=") {
+		fields["file"] = parts[0]
+	}
+	for _, match := range d.mspArg.FindAllStringSubmatch(args, -1) {
+		switch match[1] {
+		case "V":
+			fields["volume"] = match[2]
+		case "L":
+			fields["loops"] = match[2]
+		case "P":
+			fields["priority"] = match[2]
+		case "T":
+			fields["type"] = match[2]
+		case "U":
+			fields["url"] = match[2]
+		}
+	}
+	return fields
+}
+
+func chatFields(parsed *parser.ParsedOutput) map[string]string {
+	fields := map[string]string{
+		"speaker": parsed.Speaker,
+		"message": parsed.Message,
+	}
+	if parsed.Channel != "" {
+		fields["channel"] = parsed.Channel
+	}
+	if parsed.Outgoing {
+		fields["outgoing"] = "true"
+	}
+	return fields
+}
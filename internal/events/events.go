@@ -0,0 +1,91 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Kind identifies a semantic game event
+type Kind string
+
+const (
+	KindConnect      Kind = "connect"
+	KindDisconnect   Kind = "disconnect"
+	KindRoomEnter    Kind = "room_enter"
+	KindSay          Kind = "say"
+	KindTell         Kind = "tell"
+	KindChannel      Kind = "channel"
+	KindCombatStart  Kind = "combat_start"
+	KindCombatEnd    Kind = "combat_end" // Fields["reason"] is kill, death or timeout
+	KindAttacked     Kind = "attacked"
+	KindDeath        Kind = "death"
+	KindLevelUp      Kind = "level_up"
+	KindSound        Kind = "sound" // MSP !!SOUND / !!MUSIC trigger
+	KindFriendOnline Kind = "friend_online"
+	KindTick         Kind = "tick" // The server's heartbeat, seen or predicted
+)
+
+// Event is something meaningful that happened in the game, derived from
+// one or more lines of output
+type Event struct {
+	Kind   Kind              `json:"kind"`
+	Time   time.Time         `json:"time"`
+	Text   string            `json:"text"`
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+// New creates an event stamped with the current time
+func New(kind Kind, text string, fields map[string]string) Event {
+	return Event{
+		Kind:   kind,
+		Time:   time.Now(),
+		Text:   text,
+		Fields: fields,
+	}
+}
+
+// Handler receives published events
+type Handler func(Event)
+
+// Bus fans events out to subscribers. Handlers are called synchronously on
+// the publishing goroutine, so slow work should be handed off.
+type Bus struct {
+	mutex    sync.RWMutex
+	handlers map[int]Handler
+	nextID   int
+}
+
+// NewBus creates an empty bus
+func NewBus() *Bus {
+	return &Bus{handlers: make(map[int]Handler)}
+}
+
+// Subscribe registers a handler and returns a function removing it
+func (b *Bus) Subscribe(handler Handler) func() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	id := b.nextID
+	b.nextID++
+	b.handlers[id] = handler
+
+	return func() {
+		b.mutex.Lock()
+		delete(b.handlers, id)
+		b.mutex.Unlock()
+	}
+}
+
+// Publish delivers an event to every subscriber
+func (b *Bus) Publish(event Event) {
+	b.mutex.RLock()
+	handlers := make([]Handler, 0, len(b.handlers))
+	for _, handler := range b.handlers {
+		handlers = append(handlers, handler)
+	}
+	b.mutex.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event)
+	}
+}
@@ -0,0 +1,272 @@
+package friends
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/safefile"
+)
+
+// Settings configures how presence is kept up to date
+type Settings struct {
+	PollWho         bool   `json:"poll_who"`
+	IntervalMinutes int    `json:"interval_minutes"`
+	WhoCommand      string `json:"who_command"`
+}
+
+// DefaultSettings polls the who-list every five minutes
+func DefaultSettings() Settings {
+	return Settings{
+		PollWho:         true,
+		IntervalMinutes: 5,
+		WhoCommand:      "who",
+	}
+}
+
+// Friend is a player the user follows, with their last known presence
+type Friend struct {
+	Name     string    `json:"name"`
+	Online   bool      `json:"online"`
+	LastSeen time.Time `json:"last_seen,omitempty"`
+}
+
+// Tracker keeps the friends list and follows who is online from login and
+// logout messages and who-list output
+type Tracker struct {
+	mutex    sync.RWMutex
+	path     string
+	settings Settings
+	friends  map[string]*Friend // Keyed by lowercased name
+
+	inWho   bool            // Inside a who-list listing
+	seenWho map[string]bool // Names seen in the current listing
+
+	loginRegex   *regexp.Regexp
+	logoutRegex  *regexp.Regexp
+	whoStart     *regexp.Regexp
+	whoEnd       *regexp.Regexp
+	whoNameRegex *regexp.Regexp
+}
+
+// NewTracker creates a tracker persisting the list to path, loading any
+// saved friends
+func NewTracker(path string) (*Tracker, error) {
+	t := &Tracker{
+		path:         path,
+		settings:     DefaultSettings(),
+		friends:      make(map[string]*Friend),
+		loginRegex:   regexp.MustCompile(`^(\w+) (?:has entered the game|enters the game|has arrived|has connected|has logged in)`),
+		logoutRegex:  regexp.MustCompile(`^(\w+) (?:has left the game|leaves the game|has quit|has disconnected|has logged out)`),
+		whoStart:     regexp.MustCompile(`(?i)^(?:players (?:currently )?(?:online|on)|who is (?:on|online)|the following players|online players)`),
+		whoEnd:       regexp.MustCompile(`(?i)^(?:there (?:are|is) \d+|\d+ players?|total)`),
+		whoNameRegex: regexp.MustCompile(`^[\s\[\]\-*]*(?:\[[^\]]*\]\s*)?([A-Z]\w+)`),
+	}
+
+	data, err := safefile.ReadFile(path, json.Valid)
+	if os.IsNotExist(err) {
+		return t, nil
+	}
+	if err != nil {
+		return t, fmt.Errorf("failed to read friends: %w", err)
+	}
+
+	var names []string
+	if err := json.Unmarshal(data, &names); err != nil {
+		return t, fmt.Errorf("failed to unmarshal friends: %w", err)
+	}
+	for _, name := range names {
+		t.friends[strings.ToLower(name)] = &Friend{Name: name}
+	}
+	return t, nil
+}
+
+// Settings returns the presence settings
+func (t *Tracker) Settings() Settings {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.settings
+}
+
+// SetSettings replaces the presence settings
+func (t *Tracker) SetSettings(settings Settings) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if settings.IntervalMinutes <= 0 {
+		settings.IntervalMinutes = DefaultSettings().IntervalMinutes
+	}
+	if strings.TrimSpace(settings.WhoCommand) == "" {
+		settings.WhoCommand = DefaultSettings().WhoCommand
+	}
+	t.settings = settings
+}
+
+// List returns the friends sorted online first, then by name
+func (t *Tracker) List() []Friend {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	list := make([]Friend, 0, len(t.friends))
+	for _, f := range t.friends {
+		list = append(list, *f)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Online != list[j].Online {
+			return list[i].Online
+		}
+		return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name)
+	})
+	return list
+}
+
+// Len returns the number of friends
+func (t *Tracker) Len() int {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return len(t.friends)
+}
+
+// Add marks a player as a friend
+func (t *Tracker) Add(name string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("friend needs a name")
+	}
+
+	t.mutex.Lock()
+	if _, exists := t.friends[strings.ToLower(name)]; !exists {
+		t.friends[strings.ToLower(name)] = &Friend{Name: name}
+	}
+	t.mutex.Unlock()
+
+	return t.save()
+}
+
+// Remove unmarks a friend
+func (t *Tracker) Remove(name string) error {
+	t.mutex.Lock()
+	delete(t.friends, strings.ToLower(strings.TrimSpace(name)))
+	t.mutex.Unlock()
+
+	return t.save()
+}
+
+// save writes the friend names to disk
+func (t *Tracker) save() error {
+	t.mutex.RLock()
+	names := make([]string, 0, len(t.friends))
+	for _, f := range t.friends {
+		names = append(names, f.Name)
+	}
+	t.mutex.RUnlock()
+	sort.Strings(names)
+
+	data, err := json.MarshalIndent(names, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal friends: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0755); err != nil {
+		return fmt.Errorf("failed to create friends directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(t.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write friends: %w", err)
+	}
+	return nil
+}
+
+// ProcessLine updates presence from a line of clean output. It returns the
+// friends who just came online and whether anyone's presence changed.
+func (t *Tracker) ProcessLine(line string) ([]string, bool) {
+	trimmed := strings.TrimSpace(line)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.inWho {
+		// Some MUDs put a blank line straight after the header, so a blank
+		// line only ends the listing once names have been seen
+		if (trimmed == "" && len(t.seenWho) > 0) || t.whoEnd.MatchString(trimmed) {
+			return t.finishWho()
+		}
+		if trimmed == "" {
+			return nil, false
+		}
+		if matches := t.whoNameRegex.FindStringSubmatch(line); matches != nil {
+			t.seenWho[strings.ToLower(matches[1])] = true
+		}
+		return nil, false
+	}
+
+	if t.whoStart.MatchString(trimmed) {
+		t.inWho = true
+		t.seenWho = make(map[string]bool)
+		return nil, false
+	}
+
+	if matches := t.loginRegex.FindStringSubmatch(trimmed); matches != nil {
+		if t.setOnline(matches[1], true) {
+			return []string{t.friends[strings.ToLower(matches[1])].Name}, true
+		}
+		return nil, false
+	}
+
+	if matches := t.logoutRegex.FindStringSubmatch(trimmed); matches != nil {
+		return nil, t.setOnline(matches[1], false)
+	}
+
+	return nil, false
+}
+
+// finishWho applies a completed who-list; the caller must hold the lock
+func (t *Tracker) finishWho() ([]string, bool) {
+	t.inWho = false
+
+	var cameOnline []string
+	changed := false
+	for key, f := range t.friends {
+		online := t.seenWho[key]
+		if online && !f.Online {
+			cameOnline = append(cameOnline, f.Name)
+		}
+		if t.setOnline(f.Name, online) {
+			changed = true
+		}
+	}
+	sort.Strings(cameOnline)
+	return cameOnline, changed
+}
+
+// setOnline records a friend's presence, returning true if it changed; the
+// caller must hold the lock
+func (t *Tracker) setOnline(name string, online bool) bool {
+	f, exists := t.friends[strings.ToLower(name)]
+	if !exists {
+		return false
+	}
+	if online {
+		f.LastSeen = time.Now()
+	}
+	if f.Online == online {
+		return false
+	}
+	f.Online = online
+	return true
+}
+
+// Reset marks everyone offline, e.g. after disconnecting
+func (t *Tracker) Reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	for _, f := range t.friends {
+		f.Online = false
+	}
+	t.inWho = false
+}
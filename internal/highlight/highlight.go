@@ -0,0 +1,205 @@
+package highlight
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/safefile"
+)
+
+var logger = logging.For("Highlight")
+
+// Watch is something to pick out of the output, such as the character's
+// name or "tells you"
+type Watch struct {
+	Pattern string `json:"pattern"`
+	Regex   bool   `json:"regex"`  // Pattern is a regular expression rather than words to find
+	Notify  bool   `json:"notify"` // Show a desktop notification when it matches
+	Enabled bool   `json:"enabled"`
+
+	compiled *regexp.Regexp
+}
+
+// compile prepares a watch for matching. Words match whole and in any
+// case, so "Bob" finds "bob waves" but not "Bobby".
+func (w *Watch) compile() error {
+	if w.Regex {
+		compiled, err := regexp.Compile(w.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", w.Pattern, err)
+		}
+		w.compiled = compiled
+		return nil
+	}
+
+	expr := regexp.QuoteMeta(w.Pattern)
+	if isWord(w.Pattern[0]) {
+		expr = `\b` + expr
+	}
+	if isWord(w.Pattern[len(w.Pattern)-1]) {
+		expr += `\b`
+	}
+	w.compiled = regexp.MustCompile("(?i)" + expr)
+	return nil
+}
+
+// isWord reports whether a byte is one \b treats as part of a word
+func isWord(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+// List holds the watches, saved as JSON
+type List struct {
+	mutex     sync.RWMutex
+	path      string
+	watches   []*Watch
+	listeners []func(Watch, string)
+}
+
+// NewList creates a watch-list saving to path, loading any saved watches.
+// An empty path keeps them in memory only.
+func NewList(path string) (*List, error) {
+	l := &List{path: path}
+	if path == "" {
+		return l, nil
+	}
+
+	data, err := safefile.ReadFile(path, json.Valid)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return l, fmt.Errorf("failed to read highlights: %w", err)
+	}
+
+	var watches []Watch
+	if err := json.Unmarshal(data, &watches); err != nil {
+		return l, fmt.Errorf("failed to unmarshal highlights: %w", err)
+	}
+	for _, w := range watches {
+		if w.Pattern == "" {
+			continue
+		}
+		if err := w.compile(); err != nil {
+			logger.Warn("skipping highlight", "error", err)
+			continue
+		}
+		watch := w
+		l.watches = append(l.watches, &watch)
+	}
+	return l, nil
+}
+
+// OnMatch registers a listener for lines a watch matches, given the watch
+// and the line's clean text
+func (l *List) OnMatch(fn func(Watch, string)) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.listeners = append(l.listeners, fn)
+}
+
+// List returns the watches sorted by pattern
+func (l *List) List() []Watch {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+
+	watches := make([]Watch, 0, len(l.watches))
+	for _, w := range l.watches {
+		watches = append(watches, *w)
+	}
+	sort.Slice(watches, func(i, j int) bool {
+		return strings.ToLower(watches[i].Pattern) < strings.ToLower(watches[j].Pattern)
+	})
+	return watches
+}
+
+// Set adds a watch, or replaces the one with the same pattern
+func (l *List) Set(watch Watch) error {
+	watch.Pattern = strings.TrimSpace(watch.Pattern)
+	if watch.Pattern == "" {
+		return fmt.Errorf("highlight needs a pattern")
+	}
+	if err := watch.compile(); err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	replaced := false
+	for i, w := range l.watches {
+		if w.Pattern == watch.Pattern {
+			l.watches[i], replaced = &watch, true
+			break
+		}
+	}
+	if !replaced {
+		l.watches = append(l.watches, &watch)
+	}
+	l.mutex.Unlock()
+
+	return l.save()
+}
+
+// Delete removes the watch with a pattern
+func (l *List) Delete(pattern string) error {
+	pattern = strings.TrimSpace(pattern)
+	l.mutex.Lock()
+	kept := l.watches[:0]
+	for _, w := range l.watches {
+		if w.Pattern != pattern {
+			kept = append(kept, w)
+		}
+	}
+	l.watches = kept
+	l.mutex.Unlock()
+
+	return l.save()
+}
+
+// Match returns the first enabled watch matching a line, telling the
+// listeners if there is one
+func (l *List) Match(text string) (Watch, bool) {
+	l.mutex.RLock()
+	var matched *Watch
+	for _, w := range l.watches {
+		if w.Enabled && w.compiled.MatchString(text) {
+			matched = w
+			break
+		}
+	}
+	listeners := l.listeners
+	l.mutex.RUnlock()
+
+	if matched == nil {
+		return Watch{}, false
+	}
+	for _, fn := range listeners {
+		fn(*matched, text)
+	}
+	return *matched, true
+}
+
+// save writes the watches to disk
+func (l *List) save() error {
+	if l.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(l.List(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal highlights: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(l.path), 0755); err != nil {
+		return fmt.Errorf("failed to create highlight directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(l.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write highlights: %w", err)
+	}
+	return nil
+}
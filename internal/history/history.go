@@ -0,0 +1,181 @@
+package history
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/safefile"
+)
+
+// Limit is how many commands are kept for each server; older ones are
+// forgotten
+const Limit = 1000
+
+// Entry is a command the player typed
+type Entry struct {
+	Command string    `json:"command"`
+	Sent    time.Time `json:"sent"`
+	Session int       `json:"session"` // Counts up with each connection to the server
+}
+
+// History keeps the commands typed on each server, saved across restarts
+// as a JSON file per server. A command typed twice in a row is only kept
+// once, as shells do.
+type History struct {
+	mutex   sync.RWMutex
+	dir     string
+	server  string
+	entries []Entry // Oldest first
+	session int
+}
+
+// New creates a history storing files under dir. An empty dir keeps it in
+// memory only.
+func New(dir string) *History {
+	return &History{dir: dir}
+}
+
+// Load switches to a server's history and starts a new session on it
+func (h *History) Load(server string) error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.server = server
+	h.entries = nil
+	h.session = 1
+	if h.dir == "" {
+		return nil
+	}
+
+	data, err := safefile.ReadFile(h.path(), json.Valid)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read history: %w", err)
+	}
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		return fmt.Errorf("failed to unmarshal history: %w", err)
+	}
+	if len(h.entries) > 0 {
+		h.session = h.entries[len(h.entries)-1].Session + 1
+	}
+	return nil
+}
+
+// path is the current server's history file; the caller must hold the lock
+func (h *History) path() string {
+	name := h.server
+	if name == "" {
+		name = "default"
+	}
+	return filepath.Join(h.dir, datadir.Namespace(name)+".json")
+}
+
+// Add records a command and saves the history. Blank commands and repeats
+// of the one before are skipped.
+func (h *History) Add(command string) error {
+	command = strings.TrimSpace(command)
+	if command == "" {
+		return nil
+	}
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	if n := len(h.entries); n > 0 && h.entries[n-1].Command == command {
+		h.entries[n-1].Sent, h.entries[n-1].Session = time.Now(), h.session
+	} else {
+		h.entries = append(h.entries, Entry{Command: command, Sent: time.Now(), Session: h.session})
+	}
+	if len(h.entries) > Limit {
+		h.entries = append([]Entry(nil), h.entries[len(h.entries)-Limit:]...)
+	}
+	return h.save()
+}
+
+// Recent returns up to limit commands, newest first, each only once, for
+// stepping back through with the up arrow. A limit of 0 or less returns
+// them all.
+func (h *History) Recent(limit int) []string {
+	return h.find(limit, func(string) bool { return true })
+}
+
+// Search returns up to limit commands starting with prefix, newest first
+// and each only once, ignoring case
+func (h *History) Search(prefix string, limit int) []string {
+	prefix = strings.ToLower(prefix)
+	return h.find(limit, func(command string) bool {
+		return strings.HasPrefix(strings.ToLower(command), prefix)
+	})
+}
+
+// find returns the distinct commands matching a test, newest first
+func (h *History) find(limit int, matches func(string) bool) []string {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	commands := []string{}
+	seen := make(map[string]bool)
+	for i := len(h.entries) - 1; i >= 0; i-- {
+		command := h.entries[i].Command
+		if seen[command] || !matches(command) {
+			continue
+		}
+		seen[command] = true
+		commands = append(commands, command)
+		if limit > 0 && len(commands) == limit {
+			break
+		}
+	}
+	return commands
+}
+
+// Session returns the commands typed since connecting, oldest first,
+// repeats included
+func (h *History) Session() []Entry {
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+
+	entries := []Entry{}
+	for _, entry := range h.entries {
+		if entry.Session == h.session {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Clear forgets the current server's history
+func (h *History) Clear() error {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.entries = nil
+	return h.save()
+}
+
+// save writes the history to disk; the caller must hold the lock
+func (h *History) save() error {
+	if h.dir == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(h.entries)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+	if err := os.MkdirAll(h.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(h.path(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write history: %w", err)
+	}
+	return nil
+}
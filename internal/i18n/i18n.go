@@ -0,0 +1,229 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("I18n")
+
+// DefaultLocale is used for any message a locale doesn't translate
+const DefaultLocale = "en"
+
+//go:embed locales/*.json
+var builtin embed.FS
+
+// Catalogue holds the client's own messages in every known locale. Text
+// from the MUD server is never translated.
+type Catalogue struct {
+	mutex    sync.RWMutex
+	locale   string
+	messages map[string]map[string]string // locale -> key -> message
+}
+
+// New creates a catalogue with the built-in locales, using the default
+func New() *Catalogue {
+	c := &Catalogue{
+		locale:   DefaultLocale,
+		messages: make(map[string]map[string]string),
+	}
+
+	files, _ := builtin.ReadDir("locales")
+	for _, f := range files {
+		data, err := builtin.ReadFile("locales/" + f.Name())
+		if err != nil {
+			continue
+		}
+		if err := c.add(localeName(f.Name()), data); err != nil {
+			logger.Warn("bad built-in locale", "file", f.Name(), "error", err)
+		}
+	}
+	return c
+}
+
+// LoadDir adds locale files (e.g. fr.json) from dir, so users can add or
+// correct translations without a new build. A missing dir isn't an error.
+func (c *Catalogue) LoadDir(dir string) error {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read locale directory: %w", err)
+	}
+
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name(), err)
+		}
+		if err := c.add(localeName(f.Name()), data); err != nil {
+			return fmt.Errorf("failed to load %s: %w", f.Name(), err)
+		}
+	}
+	return nil
+}
+
+// add merges a locale file's messages over any already loaded
+func (c *Catalogue) add(locale string, data []byte) error {
+	var messages map[string]string
+	if err := json.Unmarshal(data, &messages); err != nil {
+		return err
+	}
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if c.messages[locale] == nil {
+		c.messages[locale] = make(map[string]string)
+	}
+	for key, message := range messages {
+		c.messages[locale][key] = message
+	}
+	return nil
+}
+
+// SetLocale chooses the locale, e.g. "de". A region such as "de_AT.UTF-8"
+// falls back to its language if the region isn't translated.
+func (c *Catalogue) SetLocale(locale string) error {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	for _, candidate := range candidates(locale) {
+		if _, known := c.messages[candidate]; known {
+			c.locale = candidate
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown locale %q", locale)
+}
+
+// Locale returns the current locale
+func (c *Catalogue) Locale() string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.locale
+}
+
+// Locales returns every locale with messages, sorted
+func (c *Catalogue) Locales() []string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	locales := make([]string, 0, len(c.messages))
+	for locale := range c.messages {
+		locales = append(locales, locale)
+	}
+	sort.Strings(locales)
+	return locales
+}
+
+// T returns the message for key in the current locale, replacing {name}
+// placeholders from name/value pairs. Unknown keys come back as the key
+// itself so a missing translation is visible rather than blank.
+func (c *Catalogue) T(key string, args ...string) string {
+	c.mutex.RLock()
+	message, ok := c.messages[c.locale][key]
+	if !ok {
+		message, ok = c.messages[DefaultLocale][key]
+	}
+	c.mutex.RUnlock()
+
+	if !ok {
+		return key
+	}
+	for i := 0; i+1 < len(args); i += 2 {
+		message = strings.ReplaceAll(message, "{"+args[i]+"}", args[i+1])
+	}
+	return message
+}
+
+// Messages returns every message in the current locale, with the default
+// locale filling the gaps, for the frontend to translate its own text
+func (c *Catalogue) Messages() map[string]string {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	messages := make(map[string]string, len(c.messages[DefaultLocale]))
+	for key, message := range c.messages[DefaultLocale] {
+		messages[key] = message
+	}
+	for key, message := range c.messages[c.locale] {
+		messages[key] = message
+	}
+	return messages
+}
+
+// localeName turns a file name such as "pt-BR.json" into a locale
+func localeName(file string) string {
+	return normalise(strings.TrimSuffix(file, filepath.Ext(file)))
+}
+
+// normalise lowercases the language and uppercases the region, dropping
+// any encoding, so "de_at.UTF-8" becomes "de-AT"
+func normalise(locale string) string {
+	locale = strings.SplitN(locale, ".", 2)[0]
+	locale = strings.SplitN(locale, "@", 2)[0]
+	parts := strings.SplitN(strings.ReplaceAll(locale, "_", "-"), "-", 2)
+	if len(parts) == 2 {
+		return strings.ToLower(parts[0]) + "-" + strings.ToUpper(parts[1])
+	}
+	return strings.ToLower(parts[0])
+}
+
+// candidates returns the locales to try for a requested one, most specific
+// first
+func candidates(locale string) []string {
+	locale = normalise(strings.TrimSpace(locale))
+	if i := strings.IndexByte(locale, '-'); i > 0 {
+		return []string{locale, locale[:i]}
+	}
+	return []string{locale}
+}
+
+// Detect returns the locale from $SEEMUD_LOCALE, or the system's $LC_ALL,
+// $LC_MESSAGES or $LANG, or the default
+func Detect() string {
+	for _, name := range []string{"SEEMUD_LOCALE", "LC_ALL", "LC_MESSAGES", "LANG"} {
+		value := strings.TrimSpace(os.Getenv(name))
+		if value != "" && value != "C" && value != "POSIX" {
+			return normalise(value)
+		}
+	}
+	return DefaultLocale
+}
+
+// defaultCatalogue is the process-wide catalogue used by the package functions
+var defaultCatalogue = New()
+
+// Default returns the process-wide catalogue
+func Default() *Catalogue {
+	return defaultCatalogue
+}
+
+// T translates key with the process-wide catalogue
+func T(key string, args ...string) string {
+	return defaultCatalogue.T(key, args...)
+}
+
+// Error returns a translated message as an error
+func Error(key string, args ...string) error {
+	return errors.New(defaultCatalogue.T(key, args...))
+}
+
+// Wrap returns a translated message as an error wrapping err
+func Wrap(err error, key string, args ...string) error {
+	return fmt.Errorf("%s: %w", defaultCatalogue.T(key, args...), err)
+}
@@ -0,0 +1,218 @@
+package idle
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/events"
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("Idle")
+
+// checkInterval is how often the monitor looks at the idle time
+const checkInterval = 15 * time.Second
+
+// replyCooldown stops the AFK auto-reply answering the same player repeatedly
+const replyCooldown = 5 * time.Minute
+
+// Settings configures the idle monitor. Zero durations disable a feature.
+type Settings struct {
+	KeepaliveCommand string `json:"keepalive_command"`
+	KeepaliveMinutes int    `json:"keepalive_minutes"`
+	AFKMinutes       int    `json:"afk_minutes"`
+	AutoReply        bool   `json:"auto_reply"`
+	AFKMessage       string `json:"afk_message"`
+	// ReplyFormat builds the reply command; {name} and {message} are replaced
+	ReplyFormat  string `json:"reply_format"`
+	PauseMinutes int    `json:"pause_minutes"` // Pause automations after this long
+}
+
+// DefaultSettings returns settings with AFK replies and automation pausing on
+// but no keepalive, as some MUDs frown on idle-timer dodging
+func DefaultSettings() Settings {
+	return Settings{
+		KeepaliveCommand: "look",
+		AFKMinutes:       10,
+		AutoReply:        true,
+		AFKMessage:       "I'm away from the keyboard right now.",
+		ReplyFormat:      "tell {name} {message}",
+		PauseMinutes:     15,
+	}
+}
+
+// Status is the monitor's view of the player's activity
+type Status struct {
+	IdleSeconds      int  `json:"idle_seconds"`
+	AFK              bool `json:"afk"`
+	AutomationPaused bool `json:"automation_paused"`
+}
+
+// SendFunc sends a command to the MUD without counting as user input
+type SendFunc func(command string) error
+
+// Monitor watches for user inactivity, sending keepalives, answering tells
+// while AFK and pausing automations that shouldn't run unattended
+type Monitor struct {
+	mutex         sync.Mutex
+	settings      Settings
+	send          SendFunc
+	lastInput     time.Time
+	lastKeepalive time.Time
+	afk           bool
+	paused        bool
+	replied       map[string]time.Time
+	onChange      func(Status)
+}
+
+// NewMonitor creates a monitor that sends commands through send
+func NewMonitor(settings Settings, send SendFunc) *Monitor {
+	return &Monitor{
+		settings:  settings,
+		send:      send,
+		lastInput: time.Now(),
+		replied:   make(map[string]time.Time),
+	}
+}
+
+// OnChange registers a callback for AFK or pause state changes
+func (m *Monitor) OnChange(fn func(Status)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.onChange = fn
+}
+
+// Settings returns the current settings
+func (m *Monitor) Settings() Settings {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.settings
+}
+
+// SetSettings replaces the settings
+func (m *Monitor) SetSettings(settings Settings) {
+	m.mutex.Lock()
+	m.settings = settings
+	m.mutex.Unlock()
+
+	m.check(time.Now())
+}
+
+// Run checks idle time until ctx is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			m.check(now)
+		}
+	}
+}
+
+// UserInput records activity from the player, ending AFK and resuming
+// automations
+func (m *Monitor) UserInput() {
+	m.mutex.Lock()
+	m.lastInput = time.Now()
+	changed := m.afk || m.paused
+	m.afk = false
+	m.paused = false
+	m.replied = make(map[string]time.Time)
+	status, onChange := m.statusLocked(m.lastInput), m.onChange
+	m.mutex.Unlock()
+
+	if changed && onChange != nil {
+		onChange(status)
+	}
+}
+
+// Status returns the current activity status
+func (m *Monitor) Status() Status {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.statusLocked(time.Now())
+}
+
+// AutomationPaused reports whether automations should hold off because the
+// player has been away too long
+func (m *Monitor) AutomationPaused() bool {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.paused
+}
+
+// HandleEvent auto-replies to tells while the player is AFK
+func (m *Monitor) HandleEvent(event events.Event) {
+	if event.Kind != events.KindTell || event.Fields["outgoing"] == "true" {
+		return
+	}
+
+	name := event.Fields["speaker"]
+	if name == "" {
+		return
+	}
+
+	m.mutex.Lock()
+	settings := m.settings
+	if !m.afk || !settings.AutoReply || settings.AFKMessage == "" ||
+		time.Since(m.replied[name]) < replyCooldown {
+		m.mutex.Unlock()
+		return
+	}
+	m.replied[name] = time.Now()
+	m.mutex.Unlock()
+
+	reply := strings.NewReplacer("{name}", name, "{message}", settings.AFKMessage).Replace(settings.ReplyFormat)
+	if err := m.send(reply); err != nil {
+		logger.Warn("failed to send AFK reply", "error", err)
+	}
+}
+
+// check updates AFK/pause state and sends a keepalive if one is due
+func (m *Monitor) check(now time.Time) {
+	m.mutex.Lock()
+	settings := m.settings
+	idle := now.Sub(m.lastInput)
+
+	wasAFK, wasPaused := m.afk, m.paused
+	m.afk = settings.AFKMinutes > 0 && idle >= minutes(settings.AFKMinutes)
+	m.paused = settings.PauseMinutes > 0 && idle >= minutes(settings.PauseMinutes)
+
+	sendKeepalive := settings.KeepaliveMinutes > 0 && settings.KeepaliveCommand != "" &&
+		idle >= minutes(settings.KeepaliveMinutes) &&
+		now.Sub(m.lastKeepalive) >= minutes(settings.KeepaliveMinutes)
+	if sendKeepalive {
+		m.lastKeepalive = now
+	}
+
+	changed := wasAFK != m.afk || wasPaused != m.paused
+	status, onChange := m.statusLocked(now), m.onChange
+	m.mutex.Unlock()
+
+	if sendKeepalive {
+		if err := m.send(settings.KeepaliveCommand); err != nil {
+			logger.Warn("failed to send keepalive", "error", err)
+		}
+	}
+	if changed && onChange != nil {
+		onChange(status)
+	}
+}
+
+func (m *Monitor) statusLocked(now time.Time) Status {
+	return Status{
+		IdleSeconds:      int(now.Sub(m.lastInput).Seconds()),
+		AFK:              m.afk,
+		AutomationPaused: m.paused,
+	}
+}
+
+func minutes(n int) time.Duration {
+	return time.Duration(n) * time.Minute
+}
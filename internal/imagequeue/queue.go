@@ -0,0 +1,307 @@
+package imagequeue
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("ImageQueue")
+
+// Priority orders waiting generations; higher goes first, and the oldest
+// first among equals
+type Priority int
+
+const (
+	PriorityAuto   Priority = iota // Drawn on arriving in a room without an image
+	PriorityScript                 // Asked for by a Lua script
+	PriorityUser                   // Asked for by the player
+)
+
+// Status is where a generation has got to
+type Status string
+
+const (
+	StatusQueued     Status = "queued"
+	StatusGenerating Status = "generating"
+	StatusDone       Status = "done"
+	StatusFailed     Status = "failed"
+	StatusCancelled  Status = "cancelled"
+)
+
+// ErrCancelled is what waiting on a cancelled generation returns
+var ErrCancelled = errors.New("image generation cancelled")
+
+// Func draws an image, giving up when ctx is cancelled, and returns what
+// the waiters should get, such as the URL to show it from
+type Func func(ctx context.Context) (string, error)
+
+// Update reports a generation's status
+type Update struct {
+	JobID    int64    `json:"job_id"`
+	RoomID   string   `json:"room_id"`
+	Room     string   `json:"room"` // The room's name, for showing
+	Priority Priority `json:"priority"`
+	Status   Status   `json:"status"`
+	Position int      `json:"position,omitempty"` // Place in the queue while queued, 1 being next
+	Error    string   `json:"error,omitempty"`
+}
+
+// job is one generation, shared by everyone who asked for its room
+type job struct {
+	Update
+	run    Func
+	queued time.Time
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+	result string
+	err    error
+}
+
+// Queue runs image generations one at a time, most important first. Asking
+// for a room that's already waiting joins that request instead of drawing
+// it twice.
+type Queue struct {
+	mutex     sync.Mutex
+	waiting   []*job
+	running   *job
+	nextID    int64
+	listeners []func(Update)
+}
+
+// New creates an empty queue
+func New() *Queue {
+	return &Queue{}
+}
+
+// OnUpdate registers a listener for every change in a generation's status
+func (q *Queue) OnUpdate(fn func(Update)) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.listeners = append(q.listeners, fn)
+}
+
+// Ticket is a place in the queue
+type Ticket struct {
+	job *job
+}
+
+// ID returns the generation's job ID, as updates report it
+func (t *Ticket) ID() int64 {
+	return t.job.JobID
+}
+
+// Wait blocks until the generation finishes, returning ErrCancelled if it
+// was cancelled
+func (t *Ticket) Wait() (string, error) {
+	<-t.job.done
+	return t.job.result, t.job.err
+}
+
+// Submit queues a generation for a room. If the room is already waiting,
+// the new request replaces how it's drawn unless it matters less, and both
+// callers get the result. A room being drawn right now queues
+// again behind itself, as the request may want something different.
+func (q *Queue) Submit(roomID, room string, priority Priority, run Func) *Ticket {
+	q.mutex.Lock()
+	for _, waiting := range q.waiting {
+		if waiting.RoomID == roomID {
+			if priority >= waiting.Priority {
+				waiting.run, waiting.Priority = run, priority
+			}
+			updates := q.reorder()
+			q.mutex.Unlock()
+			logger.Debug("joined queued generation", "room", room, "job", waiting.JobID)
+			q.notify(updates...)
+			return &Ticket{job: waiting}
+		}
+	}
+
+	q.nextID++
+	ctx, cancel := context.WithCancel(context.Background())
+	added := &job{
+		Update: Update{JobID: q.nextID, RoomID: roomID, Room: room, Priority: priority, Status: StatusQueued},
+		run:    run,
+		queued: time.Now(),
+		ctx:    ctx,
+		cancel: cancel,
+		done:   make(chan struct{}),
+	}
+	q.waiting = append(q.waiting, added)
+	start := q.running == nil
+	if start {
+		q.reorder()
+		q.running = q.next()
+	}
+	updates := q.reorder()
+	q.mutex.Unlock()
+
+	logger.Debug("queued generation", "room", room, "job", added.JobID, "priority", priority)
+	q.notify(updates...)
+	if start {
+		go q.process()
+	}
+	return &Ticket{job: added}
+}
+
+// CancelOthers cancels generations for rooms other than roomID, as when the
+// player moves on, returning how many. Ones the player asked for carry on,
+// so the image is there when they return.
+func (q *Queue) CancelOthers(roomID string) int {
+	return q.cancel(func(j *job) bool {
+		return j.RoomID != roomID && j.Priority < PriorityUser
+	})
+}
+
+// Cancel cancels a room's generations, returning how many
+func (q *Queue) Cancel(roomID string) int {
+	return q.cancel(func(j *job) bool { return j.RoomID == roomID })
+}
+
+// CancelAll cancels every generation, returning how many
+func (q *Queue) CancelAll() int {
+	return q.cancel(func(*job) bool { return true })
+}
+
+// cancel cancels the waiting and running generations matching a test
+func (q *Queue) cancel(matches func(*job) bool) int {
+	q.mutex.Lock()
+	var updates []Update
+	kept := q.waiting[:0]
+	for _, waiting := range q.waiting {
+		if !matches(waiting) {
+			kept = append(kept, waiting)
+			continue
+		}
+		waiting.cancel()
+		waiting.Status, waiting.Position, waiting.err = StatusCancelled, 0, ErrCancelled
+		close(waiting.done)
+		updates = append(updates, waiting.Update)
+	}
+	count := len(updates)
+	q.waiting = kept
+	updates = append(updates, q.reorder()...)
+	if q.running != nil && q.running.ctx.Err() == nil && matches(q.running) {
+		// The worker reports it once the backend gives up
+		q.running.cancel()
+		count++
+	}
+	q.mutex.Unlock()
+
+	if count > 0 {
+		logger.Debug("cancelled generations", "count", count)
+	}
+	q.notify(updates...)
+	return count
+}
+
+// Jobs returns the generation running, if any, then those waiting in order
+func (q *Queue) Jobs() []Update {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	jobs := make([]Update, 0, len(q.waiting)+1)
+	if q.running != nil {
+		jobs = append(jobs, q.running.Update)
+	}
+	for _, waiting := range q.waiting {
+		jobs = append(jobs, waiting.Update)
+	}
+	return jobs
+}
+
+// process runs generations until none are waiting
+func (q *Queue) process() {
+	for {
+		q.mutex.Lock()
+		current := q.running
+		if current == nil {
+			q.mutex.Unlock()
+			return
+		}
+		current.Status = StatusGenerating
+		run, ctx := current.run, current.ctx
+		q.mutex.Unlock()
+		q.notify(current.Update)
+
+		var result string
+		var err error
+		if ctx.Err() == nil {
+			result, err = run(ctx)
+		}
+		cancelled := ctx.Err() != nil
+		current.cancel()
+
+		q.mutex.Lock()
+		switch {
+		case cancelled:
+			current.Status, current.err = StatusCancelled, ErrCancelled
+		case err != nil:
+			current.Status, current.Error, current.err = StatusFailed, err.Error(), err
+		default:
+			current.Status, current.result = StatusDone, result
+		}
+		finished := current.Update
+		close(current.done)
+		q.running = q.next()
+		updates := q.reorder()
+		q.mutex.Unlock()
+
+		if err != nil && !cancelled {
+			logger.Warn("image generation failed", "room", current.Room, "error", err)
+		}
+		q.notify(append([]Update{finished}, updates...)...)
+	}
+}
+
+// next takes the most important waiting generation off the queue; the
+// caller must hold the lock
+func (q *Queue) next() *job {
+	if len(q.waiting) == 0 {
+		return nil
+	}
+	first := q.waiting[0]
+	q.waiting = q.waiting[1:]
+	first.Position = 0
+	return first
+}
+
+// reorder sorts the waiting generations and numbers their places,
+// returning updates for those that moved; the caller must hold the lock
+func (q *Queue) reorder() []Update {
+	sort.SliceStable(q.waiting, func(i, j int) bool {
+		if q.waiting[i].Priority != q.waiting[j].Priority {
+			return q.waiting[i].Priority > q.waiting[j].Priority
+		}
+		return q.waiting[i].queued.Before(q.waiting[j].queued)
+	})
+	var updates []Update
+	for i, waiting := range q.waiting {
+		if waiting.Position != i+1 {
+			waiting.Position = i + 1
+			updates = append(updates, waiting.Update)
+		}
+	}
+	return updates
+}
+
+// notify reports updates to the listeners
+func (q *Queue) notify(updates ...Update) {
+	if len(updates) == 0 {
+		return
+	}
+	q.mutex.Lock()
+	listeners := q.listeners
+	q.mutex.Unlock()
+
+	for _, update := range updates {
+		for _, fn := range listeners {
+			fn(update)
+		}
+	}
+}
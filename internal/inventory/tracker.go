@@ -0,0 +1,294 @@
+package inventory
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// DefaultCapacity is the carrying capacity used until the user sets one.
+// Most MUDs don't report weights, so by default every item weighs 1.
+const DefaultCapacity = 20
+
+// Item is something the character is carrying or wearing
+type Item struct {
+	Name   string  `json:"name"`
+	Slot   string  `json:"slot,omitempty"` // Equipment slot if the MUD reports one
+	Weight float64 `json:"weight"`
+}
+
+// Snapshot is a copy of the tracked inventory for the GUI
+type Snapshot struct {
+	Carried  []Item  `json:"carried"`
+	Worn     []Item  `json:"worn"`
+	Count    int     `json:"count"`
+	Weight   float64 `json:"weight"`
+	Capacity float64 `json:"capacity"`
+	Load     float64 `json:"load"` // Weight as a fraction of capacity, for the weight bar
+}
+
+// listing tracks which multi-line inventory listing we're inside
+type listing int
+
+const (
+	listingNone listing = iota
+	listingCarried
+	listingWorn
+)
+
+// Tracker maintains a live model of the character's inventory from
+// inventory/equipment listings and get/drop/wear messages
+type Tracker struct {
+	mutex    sync.RWMutex
+	carried  []Item
+	worn     []Item
+	capacity float64
+	weights  map[string]float64 // Known item weights by normalised name
+	listing  listing
+
+	carryingRegex *regexp.Regexp
+	wearingRegex  *regexp.Regexp
+	emptyRegex    *regexp.Regexp
+	slotRegex     *regexp.Regexp
+	getRegex      *regexp.Regexp
+	dropRegex     *regexp.Regexp
+	wearRegex     *regexp.Regexp
+	removeRegex   *regexp.Regexp
+}
+
+// NewTracker creates an empty inventory tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		capacity:      DefaultCapacity,
+		weights:       make(map[string]float64),
+		carryingRegex: regexp.MustCompile(`^You are (?:carrying|holding)(?::|\.\.\.)?\s*(.*)$`),
+		wearingRegex:  regexp.MustCompile(`^You are (?:wearing|using|wielding)(?::|\.\.\.)?\s*(.*)$`),
+		emptyRegex:    regexp.MustCompile(`^You (?:are not carrying anything|aren't carrying anything|are carrying nothing|have nothing)`),
+		slotRegex:     regexp.MustCompile(`^<([^>]+)>\s+(.+)$`),
+		getRegex:      regexp.MustCompile(`^You (?:get|take|pick up) (.+?)(?: from .+)?\.$`),
+		dropRegex:     regexp.MustCompile(`^You (?:drop|put|give|junk|sacrifice) (.+?)(?: (?:in|into|on|to) .+)?\.$`),
+		wearRegex:     regexp.MustCompile(`^You (?:wear|wield|hold) (.+?)(?: on .+)?\.$`),
+		removeRegex:   regexp.MustCompile(`^You (?:remove|stop using|stop wielding|unwield) (.+?)\.$`),
+	}
+}
+
+// ProcessLine updates the model from a line of clean (colourless) text.
+// It returns true if the inventory changed.
+func (t *Tracker) ProcessLine(line string) bool {
+	trimmed := strings.TrimSpace(line)
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	// Inside a multi-line listing, indented or slotted lines are items
+	if t.listing != listingNone {
+		if trimmed == "" || !(strings.HasPrefix(line, " ") || t.slotRegex.MatchString(trimmed)) {
+			t.listing = listingNone
+		} else {
+			t.addListed(trimmed)
+			return true
+		}
+	}
+
+	if trimmed == "" {
+		return false
+	}
+
+	if t.emptyRegex.MatchString(trimmed) {
+		t.carried = nil
+		return true
+	}
+
+	if matches := t.carryingRegex.FindStringSubmatch(trimmed); matches != nil {
+		t.carried = nil
+		t.listing = listingCarried
+		t.addList(matches[1])
+		return true
+	}
+
+	if matches := t.wearingRegex.FindStringSubmatch(trimmed); matches != nil {
+		t.worn = nil
+		t.listing = listingWorn
+		t.addList(matches[1])
+		return true
+	}
+
+	if matches := t.getRegex.FindStringSubmatch(trimmed); matches != nil {
+		for _, name := range splitList(matches[1]) {
+			t.carried = append(t.carried, t.newItem(name, ""))
+		}
+		return true
+	}
+
+	if matches := t.dropRegex.FindStringSubmatch(trimmed); matches != nil {
+		changed := false
+		for _, name := range splitList(matches[1]) {
+			var removed bool
+			t.carried, _, removed = removeItem(t.carried, name)
+			changed = changed || removed
+		}
+		return changed
+	}
+
+	if matches := t.wearRegex.FindStringSubmatch(trimmed); matches != nil {
+		name := matches[1]
+		var item Item
+		var found bool
+		t.carried, item, found = removeItem(t.carried, name)
+		if !found {
+			item = t.newItem(name, "")
+		}
+		t.worn = append(t.worn, item)
+		return true
+	}
+
+	if matches := t.removeRegex.FindStringSubmatch(trimmed); matches != nil {
+		var item Item
+		var found bool
+		t.worn, item, found = removeItem(t.worn, matches[1])
+		if found {
+			t.carried = append(t.carried, item)
+		}
+		return found
+	}
+
+	return false
+}
+
+// addList adds a comma separated list of items to the current listing
+func (t *Tracker) addList(list string) {
+	for _, name := range splitList(list) {
+		t.addListed(name)
+	}
+}
+
+// addListed adds a single listed item to the current listing
+func (t *Tracker) addListed(entry string) {
+	slot := ""
+	if matches := t.slotRegex.FindStringSubmatch(entry); matches != nil {
+		slot = strings.TrimSpace(matches[1])
+		entry = matches[2]
+		// Diku style equipment lists mark worn items with a slot even in
+		// inventory output
+		if t.listing == listingCarried {
+			t.worn = append(t.worn, t.newItem(entry, slot))
+			return
+		}
+	}
+
+	item := t.newItem(strings.TrimSuffix(entry, "."), slot)
+	if t.listing == listingWorn {
+		t.worn = append(t.worn, item)
+	} else {
+		t.carried = append(t.carried, item)
+	}
+}
+
+// newItem creates an item using any known weight
+func (t *Tracker) newItem(name, slot string) Item {
+	weight, known := t.weights[normalise(name)]
+	if !known {
+		weight = 1
+	}
+	return Item{Name: name, Slot: slot, Weight: weight}
+}
+
+// SetCapacity sets the carrying capacity used for the load fraction
+func (t *Tracker) SetCapacity(capacity float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	t.capacity = capacity
+}
+
+// SetItemWeight records the weight of an item, updating tracked copies
+func (t *Tracker) SetItemWeight(name string, weight float64) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	key := normalise(name)
+	t.weights[key] = weight
+	for i := range t.carried {
+		if normalise(t.carried[i].Name) == key {
+			t.carried[i].Weight = weight
+		}
+	}
+	for i := range t.worn {
+		if normalise(t.worn[i].Name) == key {
+			t.worn[i].Weight = weight
+		}
+	}
+}
+
+// Reset forgets everything, e.g. on connecting as a different character
+func (t *Tracker) Reset() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.carried = nil
+	t.worn = nil
+	t.listing = listingNone
+}
+
+// Snapshot returns a copy of the current inventory
+func (t *Tracker) Snapshot() Snapshot {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	snapshot := Snapshot{
+		Carried:  append([]Item{}, t.carried...),
+		Worn:     append([]Item{}, t.worn...),
+		Count:    len(t.carried) + len(t.worn),
+		Capacity: t.capacity,
+	}
+	for _, item := range t.carried {
+		snapshot.Weight += item.Weight
+	}
+	for _, item := range t.worn {
+		snapshot.Weight += item.Weight
+	}
+	snapshot.Load = snapshot.Weight / t.capacity
+
+	return snapshot
+}
+
+// splitList splits "a knife, a torch and an apple" into item names
+func splitList(list string) []string {
+	list = strings.TrimSuffix(strings.TrimSpace(list), ".")
+	list = strings.ReplaceAll(list, " and ", ", ")
+
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name != "" && !strings.EqualFold(name, "nothing") {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// removeItem removes the first item matching name, ignoring articles
+func removeItem(items []Item, name string) ([]Item, Item, bool) {
+	key := normalise(name)
+	for i, item := range items {
+		if normalise(item.Name) == key {
+			return append(items[:i:i], items[i+1:]...), item, true
+		}
+	}
+	return items, Item{}, false
+}
+
+// normalise lowercases a name and strips leading articles so "the knife"
+// matches "a knife"
+func normalise(name string) string {
+	name = strings.ToLower(strings.TrimSpace(name))
+	for _, article := range []string{"a ", "an ", "the ", "some "} {
+		if strings.HasPrefix(name, article) {
+			return strings.TrimPrefix(name, article)
+		}
+	}
+	return name
+}
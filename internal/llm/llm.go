@@ -0,0 +1,123 @@
+package llm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultEndpoint is the OpenAI-compatible API a local Ollama serves
+const DefaultEndpoint = "http://127.0.0.1:11434/v1"
+
+// DefaultModel is a small model that runs comfortably alongside Stable
+// Diffusion
+const DefaultModel = "llama3.2"
+
+// Endpoint is where to send requests and which model to ask
+type Endpoint struct {
+	URL    string // Base URL, e.g. DefaultEndpoint or https://api.openai.com/v1
+	Model  string
+	APIKey string // Sent as a bearer token; Ollama needs none
+}
+
+// Request is one question for the model
+type Request struct {
+	Instructions string // The system message
+	Prompt       string // The user message
+	Temperature  float64
+	JSON         bool // Ask for a JSON object in reply
+}
+
+// Client talks to any OpenAI-compatible chat completions API, which covers
+// OpenAI itself, Ollama, llama.cpp's server and LM Studio
+type Client struct {
+	client *http.Client
+}
+
+// NewClient creates a client whose requests give up after timeout
+func NewClient(timeout time.Duration) *Client {
+	return &Client{
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+// message is one message in a chat completions request or reply
+type message struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// responseFormat asks for a particular kind of reply
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+// chatRequest is a chat completions request
+type chatRequest struct {
+	Model          string          `json:"model"`
+	Messages       []message       `json:"messages"`
+	Temperature    float64         `json:"temperature"`
+	Stream         bool            `json:"stream"`
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+}
+
+// chatResponse is the part of a chat completions reply used here
+type chatResponse struct {
+	Choices []struct {
+		Message message `json:"message"`
+	} `json:"choices"`
+}
+
+// Complete sends a request and returns the model's reply
+func (c *Client) Complete(ctx context.Context, endpoint Endpoint, req Request) (string, error) {
+	chat := chatRequest{
+		Model: endpoint.Model,
+		Messages: []message{
+			{Role: "system", Content: req.Instructions},
+			{Role: "user", Content: req.Prompt},
+		},
+		Temperature: req.Temperature,
+	}
+	if req.JSON {
+		chat.ResponseFormat = &responseFormat{Type: "json_object"}
+	}
+	reqBody, err := json.Marshal(chat)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal request: %w", err)
+	}
+
+	url := strings.TrimRight(endpoint.URL, "/") + "/chat/completions"
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(reqBody))
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if endpoint.APIKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+endpoint.APIKey)
+	}
+
+	resp, err := c.client.Do(httpReq)
+	if err != nil {
+		return "", fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return "", fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if len(result.Choices) == 0 {
+		return "", fmt.Errorf("no reply from the model")
+	}
+	return result.Choices[0].Message.Content, nil
+}
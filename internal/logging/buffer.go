@@ -0,0 +1,80 @@
+package logging
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+// buffer keeps the most recent records in a ring
+type buffer struct {
+	mutex   sync.RWMutex
+	records []Record
+	levels  []slog.Level
+	start   int
+	count   int
+}
+
+// newBuffer creates a ring holding at most capacity records
+func newBuffer(capacity int) *buffer {
+	return &buffer{
+		records: make([]Record, capacity),
+		levels:  make([]slog.Level, capacity),
+	}
+}
+
+// add stores a record, overwriting the oldest once full
+func (b *buffer) add(record Record, l slog.Level) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	capacity := len(b.records)
+	i := (b.start + b.count) % capacity
+	if b.count == capacity {
+		i = b.start
+		b.start = (b.start + 1) % capacity
+	} else {
+		b.count++
+	}
+	b.records[i] = record
+	b.levels[i] = l
+}
+
+// since returns up to limit of the newest records at or above min, oldest
+// first
+func (b *buffer) since(limit int, min slog.Level) []Record {
+	b.mutex.RLock()
+	defer b.mutex.RUnlock()
+
+	if limit <= 0 || limit > b.count {
+		limit = b.count
+	}
+
+	// Walk back from the newest until enough records match
+	var matched []Record
+	capacity := len(b.records)
+	for n := b.count - 1; n >= 0 && len(matched) < limit; n-- {
+		i := (b.start + n) % capacity
+		if b.levels[i] >= min {
+			matched = append(matched, b.records[i])
+		}
+	}
+
+	for i, j := 0, len(matched)-1; i < j; i, j = i+1, j-1 {
+		matched[i], matched[j] = matched[j], matched[i]
+	}
+	if matched == nil {
+		matched = []Record{}
+	}
+	return matched
+}
+
+// sortedKeys returns a map's keys in order, so fields print consistently
+func sortedKeys(fields map[string]string) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
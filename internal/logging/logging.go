@@ -0,0 +1,213 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecentSize is how many log records are kept for the debug console
+const RecentSize = 1000
+
+// Record is one log line as shown in the debug console
+type Record struct {
+	Time      time.Time         `json:"time"`
+	Level     string            `json:"level"`
+	Component string            `json:"component,omitempty"`
+	Message   string            `json:"message"`
+	Fields    map[string]string `json:"fields,omitempty"`
+}
+
+var (
+	level   = new(slog.LevelVar)
+	recent  = newBuffer(RecentSize)
+	handler = &Handler{sink: &sink{w: os.Stderr}}
+)
+
+// For returns a logger tagged with a component name, e.g. "Engine"
+func For(component string) *slog.Logger {
+	return slog.New(handler).With("component", component)
+}
+
+// Install makes this package the destination for the standard log package
+// and slog's default logger, and applies SEEMUD_LOG_LEVEL if set
+func Install() {
+	if value := os.Getenv("SEEMUD_LOG_LEVEL"); value != "" {
+		if err := SetLevel(value); err != nil {
+			For("Logging").Warn("ignoring SEEMUD_LOG_LEVEL", "error", err)
+		}
+	}
+	// This also sends the log package's output through the handler
+	slog.SetDefault(slog.New(handler))
+}
+
+// SetOutput changes where log lines are written
+func SetOutput(w io.Writer) {
+	handler.sink.mutex.Lock()
+	defer handler.sink.mutex.Unlock()
+	handler.sink.w = w
+}
+
+// Levels are the level names accepted by SetLevel
+var Levels = []string{"debug", "info", "warn", "error"}
+
+// SetLevel changes the minimum level logged, at runtime
+func SetLevel(name string) error {
+	parsed, err := parseLevel(name)
+	if err != nil {
+		return err
+	}
+	level.Set(parsed)
+	return nil
+}
+
+// Level returns the current minimum level name
+func Level() string {
+	return levelName(level.Level())
+}
+
+// Recent returns up to limit of the latest records at or above minLevel,
+// oldest first. An empty minLevel returns every level.
+func Recent(limit int, minLevel string) ([]Record, error) {
+	min := slog.LevelDebug
+	if minLevel != "" {
+		parsed, err := parseLevel(minLevel)
+		if err != nil {
+			return nil, err
+		}
+		min = parsed
+	}
+	return recent.since(limit, min), nil
+}
+
+// parseLevel turns a level name into a slog level
+func parseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn", "warning":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", name)
+}
+
+// levelName returns the lowercase name for a slog level
+func levelName(l slog.Level) string {
+	switch {
+	case l < slog.LevelInfo:
+		return "debug"
+	case l < slog.LevelWarn:
+		return "info"
+	case l < slog.LevelError:
+		return "warn"
+	}
+	return "error"
+}
+
+// sink is the writer shared by every handler derived from the root one
+type sink struct {
+	mutex sync.Mutex
+	w     io.Writer
+}
+
+// Handler is a slog handler writing "[Component] message key=value" lines
+// and keeping recent records for the debug console
+type Handler struct {
+	attrs []slog.Attr
+	group string
+	sink  *sink
+}
+
+// Enabled implements slog.Handler
+func (h *Handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= level.Level()
+}
+
+// Handle implements slog.Handler
+func (h *Handler) Handle(_ context.Context, r slog.Record) error {
+	record := Record{
+		Time:    r.Time,
+		Level:   levelName(r.Level),
+		Message: r.Message,
+	}
+
+	addField := func(a slog.Attr) {
+		if a.Key == "component" && h.group == "" {
+			record.Component = a.Value.String()
+			return
+		}
+		if record.Fields == nil {
+			record.Fields = make(map[string]string)
+		}
+		key := a.Key
+		if h.group != "" {
+			key = h.group + "." + key
+		}
+		record.Fields[key] = a.Value.String()
+	}
+	for _, a := range h.attrs {
+		addField(a)
+	}
+	r.Attrs(func(a slog.Attr) bool {
+		addField(a)
+		return true
+	})
+
+	// Lines from the standard log package may carry a "[Component]" prefix
+	if record.Component == "" && strings.HasPrefix(record.Message, "[") {
+		if end := strings.Index(record.Message, "] "); end > 0 {
+			record.Component = record.Message[1:end]
+			record.Message = record.Message[end+2:]
+		}
+	}
+
+	recent.add(record, r.Level)
+
+	var line strings.Builder
+	line.WriteString(record.Time.Format("2006/01/02 15:04:05 "))
+	line.WriteString(strings.ToUpper(record.Level))
+	line.WriteString(" ")
+	if record.Component != "" {
+		line.WriteString("[" + record.Component + "] ")
+	}
+	line.WriteString(record.Message)
+	for _, key := range sortedKeys(record.Fields) {
+		value := record.Fields[key]
+		if strings.ContainsAny(value, " \t\"=") {
+			value = fmt.Sprintf("%q", value)
+		}
+		line.WriteString(" " + key + "=" + value)
+	}
+	line.WriteString("\n")
+
+	h.sink.mutex.Lock()
+	defer h.sink.mutex.Unlock()
+	_, err := io.WriteString(h.sink.w, line.String())
+	return err
+}
+
+// WithAttrs implements slog.Handler
+func (h *Handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clone := *h
+	clone.attrs = append(append([]slog.Attr(nil), h.attrs...), attrs...)
+	return &clone
+}
+
+// WithGroup implements slog.Handler by prefixing later keys
+func (h *Handler) WithGroup(name string) slog.Handler {
+	clone := *h
+	if clone.group != "" {
+		name = clone.group + "." + name
+	}
+	clone.group = name
+	return &clone
+}
@@ -0,0 +1,355 @@
+package mapexport
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"seemud-gui/internal/mapper"
+)
+
+// Scene scale in metres, which glTF viewers assume: rooms sit a metre
+// apart, levels further so the one above doesn't hide the one below
+const (
+	gltfRoomSpacing = 1.0
+	gltfLevelHeight = 1.5
+	gltfRoomSize    = 0.6
+)
+
+// glTF enumerations used here
+const (
+	gltfFloat         = 5126
+	gltfUnsignedShort = 5123
+	gltfArrayBuffer   = 34962
+	gltfElementBuffer = 34963
+	gltfModeLines     = 1
+	gltfModeTriangles = 4
+)
+
+// Materials, by index into the document's materials
+const (
+	materialRoom = iota
+	materialUncertain
+	materialCurrent
+	materialLink
+)
+
+// gltfDocument is the top level of a glTF 2.0 file
+type gltfDocument struct {
+	Asset       gltfAsset        `json:"asset"`
+	Scene       int              `json:"scene"`
+	Scenes      []gltfScene      `json:"scenes"`
+	Nodes       []gltfNode       `json:"nodes,omitempty"` // glTF forbids empty arrays
+	Meshes      []gltfMesh       `json:"meshes,omitempty"`
+	Materials   []gltfMaterial   `json:"materials"`
+	Accessors   []gltfAccessor   `json:"accessors,omitempty"`
+	BufferViews []gltfBufferView `json:"bufferViews,omitempty"`
+	Buffers     []gltfBuffer     `json:"buffers,omitempty"`
+}
+
+// gltfAsset says which glTF version the file is and what wrote it
+type gltfAsset struct {
+	Version   string `json:"version"`
+	Generator string `json:"generator"`
+}
+
+// gltfScene lists the root nodes to show
+type gltfScene struct {
+	Name  string `json:"name"`
+	Nodes []int  `json:"nodes,omitempty"`
+}
+
+// gltfNode is a room, or a level or zone grouping rooms
+type gltfNode struct {
+	Name        string         `json:"name,omitempty"`
+	Mesh        *int           `json:"mesh,omitempty"`
+	Translation []float64      `json:"translation,omitempty"`
+	Children    []int          `json:"children,omitempty"`
+	Extras      map[string]any `json:"extras,omitempty"`
+}
+
+// gltfMesh is geometry drawn at each node using it
+type gltfMesh struct {
+	Name       string          `json:"name"`
+	Primitives []gltfPrimitive `json:"primitives"`
+}
+
+// gltfPrimitive is one draw call of a mesh
+type gltfPrimitive struct {
+	Attributes map[string]int `json:"attributes"`
+	Indices    *int           `json:"indices,omitempty"`
+	Material   int            `json:"material"`
+	Mode       int            `json:"mode"`
+}
+
+// gltfMaterial is a flat colour
+type gltfMaterial struct {
+	Name                 string      `json:"name"`
+	PBRMetallicRoughness gltfPBR     `json:"pbrMetallicRoughness"`
+	Emissive             *[3]float64 `json:"emissiveFactor,omitempty"`
+}
+
+// gltfPBR is a material's physically based parameters
+type gltfPBR struct {
+	BaseColorFactor [4]float64 `json:"baseColorFactor"`
+	MetallicFactor  float64    `json:"metallicFactor"`
+	RoughnessFactor float64    `json:"roughnessFactor"`
+}
+
+// gltfAccessor describes typed data within a buffer view
+type gltfAccessor struct {
+	BufferView    int       `json:"bufferView"`
+	ComponentType int       `json:"componentType"`
+	Count         int       `json:"count"`
+	Type          string    `json:"type"`
+	Min           []float64 `json:"min,omitempty"`
+	Max           []float64 `json:"max,omitempty"`
+}
+
+// gltfBufferView is a slice of the buffer
+type gltfBufferView struct {
+	Buffer     int `json:"buffer"`
+	ByteOffset int `json:"byteOffset"`
+	ByteLength int `json:"byteLength"`
+	Target     int `json:"target,omitempty"`
+}
+
+// gltfBuffer is the binary data, embedded as a data URI
+type gltfBuffer struct {
+	ByteLength int    `json:"byteLength"`
+	URI        string `json:"uri"`
+}
+
+// gltfBuilder collects binary data and the accessors describing it
+type gltfBuilder struct {
+	doc gltfDocument
+	bin bytes.Buffer
+}
+
+// addView appends binary data, kept 4-byte aligned, and returns its view
+func (b *gltfBuilder) addView(data any, target int) int {
+	for b.bin.Len()%4 != 0 {
+		b.bin.WriteByte(0)
+	}
+	offset := b.bin.Len()
+	binary.Write(&b.bin, binary.LittleEndian, data)
+	b.doc.BufferViews = append(b.doc.BufferViews, gltfBufferView{
+		ByteOffset: offset,
+		ByteLength: b.bin.Len() - offset,
+		Target:     target,
+	})
+	return len(b.doc.BufferViews) - 1
+}
+
+// addPositions adds a VEC3 float accessor with the bounds glTF requires
+func (b *gltfBuilder) addPositions(positions []float32) int {
+	minimum := []float64{math.MaxFloat64, math.MaxFloat64, math.MaxFloat64}
+	maximum := []float64{-math.MaxFloat64, -math.MaxFloat64, -math.MaxFloat64}
+	for i, value := range positions {
+		minimum[i%3] = math.Min(minimum[i%3], float64(value))
+		maximum[i%3] = math.Max(maximum[i%3], float64(value))
+	}
+	b.doc.Accessors = append(b.doc.Accessors, gltfAccessor{
+		BufferView:    b.addView(positions, gltfArrayBuffer),
+		ComponentType: gltfFloat,
+		Count:         len(positions) / 3,
+		Type:          "VEC3",
+		Min:           minimum,
+		Max:           maximum,
+	})
+	return len(b.doc.Accessors) - 1
+}
+
+// addAccessor adds an accessor for data already laid out
+func (b *gltfBuilder) addAccessor(data any, count, componentType int, kind string, target int) int {
+	b.doc.Accessors = append(b.doc.Accessors, gltfAccessor{
+		BufferView:    b.addView(data, target),
+		ComponentType: componentType,
+		Count:         count,
+		Type:          kind,
+	})
+	return len(b.doc.Accessors) - 1
+}
+
+// cubeMeshes adds a room-sized cube for each room material, returning the
+// mesh index for each
+func (b *gltfBuilder) cubeMeshes() map[int]int {
+	half := float32(gltfRoomSize / 2)
+	// Each face has its own four corners so it can have its own normal
+	faces := []struct {
+		normal  [3]float32
+		corners [4][3]float32
+	}{
+		{[3]float32{1, 0, 0}, [4][3]float32{{1, -1, 1}, {1, -1, -1}, {1, 1, -1}, {1, 1, 1}}},
+		{[3]float32{-1, 0, 0}, [4][3]float32{{-1, -1, -1}, {-1, -1, 1}, {-1, 1, 1}, {-1, 1, -1}}},
+		{[3]float32{0, 1, 0}, [4][3]float32{{-1, 1, 1}, {1, 1, 1}, {1, 1, -1}, {-1, 1, -1}}},
+		{[3]float32{0, -1, 0}, [4][3]float32{{-1, -1, -1}, {1, -1, -1}, {1, -1, 1}, {-1, -1, 1}}},
+		{[3]float32{0, 0, 1}, [4][3]float32{{-1, -1, 1}, {1, -1, 1}, {1, 1, 1}, {-1, 1, 1}}},
+		{[3]float32{0, 0, -1}, [4][3]float32{{1, -1, -1}, {-1, -1, -1}, {-1, 1, -1}, {1, 1, -1}}},
+	}
+	var positions, normals []float32
+	var indices []uint16
+	for i, face := range faces {
+		for _, corner := range face.corners {
+			positions = append(positions, corner[0]*half, corner[1]*half, corner[2]*half)
+			normals = append(normals, face.normal[:]...)
+		}
+		base := uint16(i * 4)
+		indices = append(indices, base, base+1, base+2, base, base+2, base+3)
+	}
+
+	position := b.addPositions(positions)
+	normal := b.addAccessor(normals, len(normals)/3, gltfFloat, "VEC3", gltfArrayBuffer)
+	index := b.addAccessor(indices, len(indices), gltfUnsignedShort, "SCALAR", gltfElementBuffer)
+
+	meshes := make(map[int]int)
+	for _, material := range []int{materialRoom, materialUncertain, materialCurrent} {
+		b.doc.Meshes = append(b.doc.Meshes, gltfMesh{
+			Name: b.doc.Materials[material].Name,
+			Primitives: []gltfPrimitive{{
+				Attributes: map[string]int{"POSITION": position, "NORMAL": normal},
+				Indices:    &index,
+				Material:   material,
+				Mode:       gltfModeTriangles,
+			}},
+		})
+		meshes[material] = len(b.doc.Meshes) - 1
+	}
+	return meshes
+}
+
+// gltfPosition converts grid coordinates to glTF's, where y is up and the
+// viewer looks down -z, so north is away from them
+func gltfPosition(x, y, z int) [3]float32 {
+	return [3]float32{
+		float32(float64(x) * gltfRoomSpacing),
+		float32(float64(z) * gltfLevelHeight),
+		float32(float64(-y) * gltfRoomSpacing),
+	}
+}
+
+// gltfMaterials are the colours the map is drawn in
+func gltfMaterials() []gltfMaterial {
+	material := func(name string, r, g, b float64) gltfMaterial {
+		return gltfMaterial{
+			Name:                 name,
+			PBRMetallicRoughness: gltfPBR{BaseColorFactor: [4]float64{r, g, b, 1}, RoughnessFactor: 0.8},
+		}
+	}
+	current := material("current room", 0.95, 0.75, 0.2)
+	current.Emissive = &[3]float64{0.4, 0.3, 0.05}
+	return []gltfMaterial{
+		materialRoom:      material("room", 0.45, 0.55, 0.75),
+		materialUncertain: material("uncertain room", 0.85, 0.45, 0.3),
+		materialCurrent:   current,
+		materialLink:      material("exit", 0.8, 0.8, 0.8),
+	}
+}
+
+// writeGLTF writes the map as a glTF 2.0 scene, with the binary data
+// embedded so it's one file. Each level is a node holding its zones, each
+// zone a node holding its rooms, so viewers can show or hide them;
+// explored exits are drawn as lines.
+func writeGLTF(w io.Writer, data *mapper.MapData) error {
+	layered := BuildLayered(data)
+	b := &gltfBuilder{doc: gltfDocument{
+		Asset:     gltfAsset{Version: "2.0", Generator: "SeeMUD"},
+		Materials: gltfMaterials(),
+	}}
+
+	name := data.ServerName
+	if name == "" {
+		name = "SeeMUD"
+	}
+	b.doc.Scenes = []gltfScene{{Name: name}}
+
+	if len(layered.Nodes) > 0 {
+		meshes := b.cubeMeshes()
+
+		rooms := make(map[string]int, len(layered.Nodes))
+		for _, node := range layered.Nodes {
+			material := materialRoom
+			switch {
+			case node.ID == layered.CurrentRoomID:
+				material = materialCurrent
+			case node.Uncertain:
+				material = materialUncertain
+			}
+			mesh := meshes[material]
+			position := gltfPosition(node.X, node.Y, node.Z)
+			b.doc.Nodes = append(b.doc.Nodes, gltfNode{
+				Name:        node.Name,
+				Mesh:        &mesh,
+				Translation: []float64{float64(position[0]), float64(position[1]), float64(position[2])},
+				Extras: map[string]any{
+					"seemud_id":   node.ID,
+					"zone":        node.Zone,
+					"grid":        []int{node.X, node.Y, node.Z},
+					"visit_count": node.VisitCount,
+				},
+			})
+			rooms[node.ID] = len(b.doc.Nodes) - 1
+		}
+
+		zones := make(map[string]int, len(layered.Zones))
+		for _, zone := range layered.Zones {
+			node := gltfNode{Name: zone.Name, Extras: map[string]any{"zone": zone.ID}}
+			for _, id := range zone.Rooms {
+				node.Children = append(node.Children, rooms[id])
+			}
+			b.doc.Nodes = append(b.doc.Nodes, node)
+			zones[zone.ID] = len(b.doc.Nodes) - 1
+		}
+		for _, level := range layered.Levels {
+			node := gltfNode{Name: fmt.Sprintf("Level %d", level.Z), Extras: map[string]any{"z": level.Z}}
+			for _, id := range level.Zones {
+				node.Children = append(node.Children, zones[id])
+			}
+			b.doc.Nodes = append(b.doc.Nodes, node)
+			b.doc.Scenes[0].Nodes = append(b.doc.Scenes[0].Nodes, len(b.doc.Nodes)-1)
+		}
+
+		if len(layered.Edges) > 0 {
+			grid := make(map[string]LayeredNode, len(layered.Nodes))
+			for _, node := range layered.Nodes {
+				grid[node.ID] = node
+			}
+			var positions []float32
+			for _, edge := range layered.Edges {
+				from, to := grid[edge.From], grid[edge.To]
+				start, end := gltfPosition(from.X, from.Y, from.Z), gltfPosition(to.X, to.Y, to.Z)
+				positions = append(positions, start[:]...)
+				positions = append(positions, end[:]...)
+			}
+			b.doc.Meshes = append(b.doc.Meshes, gltfMesh{
+				Name: "exits",
+				Primitives: []gltfPrimitive{{
+					Attributes: map[string]int{"POSITION": b.addPositions(positions)},
+					Material:   materialLink,
+					Mode:       gltfModeLines,
+				}},
+			})
+			mesh := len(b.doc.Meshes) - 1
+			b.doc.Nodes = append(b.doc.Nodes, gltfNode{Name: "Exits", Mesh: &mesh})
+			b.doc.Scenes[0].Nodes = append(b.doc.Scenes[0].Nodes, len(b.doc.Nodes)-1)
+		}
+
+		b.doc.Buffers = []gltfBuffer{{
+			ByteLength: b.bin.Len(),
+			URI:        "data:application/octet-stream;base64," + base64.StdEncoding.EncodeToString(b.bin.Bytes()),
+		}}
+	}
+
+	encoded, err := json.MarshalIndent(b.doc, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal glTF map: %w", err)
+	}
+	if _, err := w.Write(encoded); err != nil {
+		return fmt.Errorf("failed to write glTF map: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,226 @@
+package mapexport
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"seemud-gui/internal/mapper"
+)
+
+// layeredFormatVersion changes whenever the layered format does
+const layeredFormatVersion = 1
+
+// Layered is the map as nodes and edges, grouped into levels and zones, for
+// drawing in 3D. Coordinates are the mapper's grid: x east, y north, z up.
+type Layered struct {
+	Format        string         `json:"format"` // Always "seemud-layered"
+	Version       int            `json:"version"`
+	ServerName    string         `json:"server_name"`
+	CurrentRoomID string         `json:"current_room_id,omitempty"`
+	Levels        []LayeredLevel `json:"levels"`
+	Zones         []LayeredZone  `json:"zones"`
+	Nodes         []LayeredNode  `json:"nodes"`
+	Edges         []LayeredEdge  `json:"edges"`
+}
+
+// LayeredLevel is one z level of the map
+type LayeredLevel struct {
+	Z     int      `json:"z"`
+	Zones []string `json:"zones"` // IDs of the zones on this level
+	Rooms int      `json:"rooms"`
+	MinX  int      `json:"min_x"`
+	MaxX  int      `json:"max_x"`
+	MinY  int      `json:"min_y"`
+	MaxY  int      `json:"max_y"`
+}
+
+// LayeredZone is a group of rooms on one level joined by explored exits,
+// such as one floor of a dungeon or a stretch of town. SeeMUD maps have no
+// areas of their own, so zones are named after their commonest room name.
+type LayeredZone struct {
+	ID    string   `json:"id"`
+	Name  string   `json:"name"`
+	Z     int      `json:"z"`
+	Rooms []string `json:"rooms"`
+}
+
+// LayeredNode is a room
+type LayeredNode struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	X          int    `json:"x"`
+	Y          int    `json:"y"`
+	Z          int    `json:"z"`
+	Zone       string `json:"zone"`
+	VisitCount int    `json:"visit_count"`
+	Uncertain  bool   `json:"uncertain,omitempty"`
+	Unexplored int    `json:"unexplored,omitempty"` // Exits not yet taken
+}
+
+// LayeredEdge is an explored exit. Exits both ways between two rooms are
+// one edge.
+type LayeredEdge struct {
+	From      string `json:"from"`
+	To        string `json:"to"`
+	Direction string `json:"direction"`
+	Vertical  bool   `json:"vertical,omitempty"` // Joins two levels
+	OneWay    bool   `json:"one_way,omitempty"`  // No exit leads back
+}
+
+// BuildLayered arranges a map into levels, zones, nodes and edges
+func BuildLayered(data *mapper.MapData) *Layered {
+	layered := &Layered{
+		Format:        "seemud-layered",
+		Version:       layeredFormatVersion,
+		ServerName:    data.ServerName,
+		CurrentRoomID: data.CurrentRoomID,
+		Levels:        []LayeredLevel{},
+		Zones:         []LayeredZone{},
+		Nodes:         []LayeredNode{},
+		Edges:         []LayeredEdge{},
+	}
+	if data.Graph == nil {
+		return layered
+	}
+
+	zoneOf := make(map[string]string)
+	for _, l := range levels(data.Graph) {
+		minX, maxX, minY, maxY := l.bounds()
+		summary := LayeredLevel{Z: l.Z, Rooms: len(l.Rooms), MinX: minX, MaxX: maxX, MinY: minY, MaxY: maxY}
+		for i, rooms := range l.zones() {
+			zone := LayeredZone{
+				ID:   fmt.Sprintf("z%d-%d", l.Z, i+1),
+				Name: commonestName(rooms),
+				Z:    l.Z,
+			}
+			for _, room := range rooms {
+				zone.Rooms = append(zone.Rooms, room.ID)
+				zoneOf[room.ID] = zone.ID
+			}
+			layered.Zones = append(layered.Zones, zone)
+			summary.Zones = append(summary.Zones, zone.ID)
+		}
+		layered.Levels = append(layered.Levels, summary)
+	}
+
+	for _, room := range sortedRooms(data.Graph) {
+		node := LayeredNode{
+			ID:         room.ID,
+			Name:       room.Name,
+			X:          room.X,
+			Y:          room.Y,
+			Z:          room.Z,
+			Zone:       zoneOf[room.ID],
+			VisitCount: room.VisitCount,
+			Uncertain:  room.Uncertain,
+		}
+		for _, target := range room.Exits {
+			if target == "" {
+				node.Unexplored++
+			}
+		}
+		layered.Nodes = append(layered.Nodes, node)
+	}
+	layered.Edges = edges(data.Graph)
+	return layered
+}
+
+// edges returns every explored exit between two known rooms once
+func edges(graph *mapper.RoomGraph) []LayeredEdge {
+	result := []LayeredEdge{}
+	seen := make(map[[2]string]bool)
+	for _, room := range sortedRooms(graph) {
+		for _, direction := range sortedExits(room) {
+			target := graph.GetRoom(room.Exits[direction])
+			if target == nil || target.ID == room.ID {
+				continue
+			}
+			key := [2]string{room.ID, target.ID}
+			if room.ID > target.ID {
+				key = [2]string{target.ID, room.ID}
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			oneWay := true
+			for _, back := range target.Exits {
+				if back == room.ID {
+					oneWay = false
+					break
+				}
+			}
+			result = append(result, LayeredEdge{
+				From:      room.ID,
+				To:        target.ID,
+				Direction: mapper.NormaliseDirection(direction),
+				Vertical:  target.Z != room.Z,
+				OneWay:    oneWay,
+			})
+		}
+	}
+	return result
+}
+
+// zones splits a level's rooms into groups joined by its links, largest
+// first
+func (l *level) zones() [][]*mapper.Room {
+	neighbours := make(map[string][]*mapper.Room)
+	for _, lk := range l.Links {
+		neighbours[lk.from.ID] = append(neighbours[lk.from.ID], lk.to)
+		neighbours[lk.to.ID] = append(neighbours[lk.to.ID], lk.from)
+	}
+
+	var groups [][]*mapper.Room
+	placed := make(map[string]bool)
+	for _, start := range l.Rooms {
+		if placed[start.ID] {
+			continue
+		}
+		placed[start.ID] = true
+		group := []*mapper.Room{start}
+		for i := 0; i < len(group); i++ {
+			for _, next := range neighbours[group[i].ID] {
+				if !placed[next.ID] {
+					placed[next.ID] = true
+					group = append(group, next)
+				}
+			}
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].ID < group[j].ID })
+		groups = append(groups, group)
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return len(groups[i]) > len(groups[j]) })
+	return groups
+}
+
+// commonestName returns the room name used most in a group, the first
+// alphabetically on a tie
+func commonestName(rooms []*mapper.Room) string {
+	counts := make(map[string]int)
+	for _, room := range rooms {
+		counts[room.Name]++
+	}
+	best := ""
+	for name, count := range counts {
+		if count > counts[best] || (count == counts[best] && name < best) {
+			best = name
+		}
+	}
+	return best
+}
+
+// writeLayered writes the layered JSON format
+func writeLayered(w io.Writer, data *mapper.MapData) error {
+	encoded, err := json.MarshalIndent(BuildLayered(data), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal layered map: %w", err)
+	}
+	if _, err := w.Write(encoded); err != nil {
+		return fmt.Errorf("failed to write layered map: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package mapexport
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"seemud-gui/internal/mapper"
+)
+
+// Format is a map export format
+type Format string
+
+const (
+	FormatJSON   Format = "json"   // SeeMUD's own format, for importing elsewhere
+	FormatSVG    Format = "svg"    // Vector drawing for wikis and forums
+	FormatPNG    Format = "png"    // Bitmap drawing
+	FormatMudlet Format = "mudlet" // Mudlet's JSON map format
+	// Levels, zones, nodes and edges as JSON, for 3D views of the map
+	FormatLayered Format = "layered"
+	FormatGLTF    Format = "gltf" // 3D scene for Blender, three.js and glTF viewers
+)
+
+// ParseFormat validates a format name
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case FormatJSON:
+		return FormatJSON, nil
+	case FormatSVG:
+		return FormatSVG, nil
+	case FormatPNG:
+		return FormatPNG, nil
+	case FormatMudlet:
+		return FormatMudlet, nil
+	case FormatLayered:
+		return FormatLayered, nil
+	case FormatGLTF:
+		return FormatGLTF, nil
+	}
+	return "", fmt.Errorf("unknown map format %q", name)
+}
+
+// FormatForPath guesses the format from a file extension, defaulting to JSON
+func FormatForPath(path string) Format {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".svg":
+		return FormatSVG
+	case ".png":
+		return FormatPNG
+	case ".gltf":
+		return FormatGLTF
+	}
+	return FormatJSON
+}
+
+// Write exports a map in a drawing or conversion format. JSON is left to
+// mapper.ExportMap, which already writes it.
+func Write(w io.Writer, data *mapper.MapData, format Format) error {
+	switch format {
+	case FormatSVG:
+		return writeSVG(w, data)
+	case FormatPNG:
+		return writePNG(w, data)
+	case FormatMudlet:
+		return writeMudlet(w, data)
+	case FormatLayered:
+		return writeLayered(w, data)
+	case FormatGLTF:
+		return writeGLTF(w, data)
+	}
+	return fmt.Errorf("format %s isn't drawn or converted", format)
+}
+
+// link is an explored exit between two rooms on the same level
+type link struct {
+	from, to *mapper.Room
+}
+
+// level is the rooms on one z level and the links between them
+type level struct {
+	Z     int
+	Rooms []*mapper.Room
+	Links []link
+}
+
+// levels groups rooms by z level, lowest first, with the links on each.
+// Exits between levels aren't drawn.
+func levels(graph *mapper.RoomGraph) []*level {
+	byZ := make(map[int]*level)
+	for _, room := range sortedRooms(graph) {
+		l, ok := byZ[room.Z]
+		if !ok {
+			l = &level{Z: room.Z}
+			byZ[room.Z] = l
+		}
+		l.Rooms = append(l.Rooms, room)
+	}
+
+	for _, l := range byZ {
+		seen := make(map[[2]string]bool)
+		for _, room := range l.Rooms {
+			for _, direction := range sortedExits(room) {
+				target := graph.GetRoom(room.Exits[direction])
+				if target == nil || target.Z != room.Z {
+					continue
+				}
+				key := [2]string{room.ID, target.ID}
+				if room.ID > target.ID {
+					key = [2]string{target.ID, room.ID}
+				}
+				if seen[key] {
+					continue
+				}
+				seen[key] = true
+				l.Links = append(l.Links, link{from: room, to: target})
+			}
+		}
+	}
+
+	result := make([]*level, 0, len(byZ))
+	for _, l := range byZ {
+		result = append(result, l)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Z < result[j].Z })
+	return result
+}
+
+// bounds returns the grid extent of a level's rooms
+func (l *level) bounds() (minX, maxX, minY, maxY int) {
+	for i, room := range l.Rooms {
+		if i == 0 || room.X < minX {
+			minX = room.X
+		}
+		if i == 0 || room.X > maxX {
+			maxX = room.X
+		}
+		if i == 0 || room.Y < minY {
+			minY = room.Y
+		}
+		if i == 0 || room.Y > maxY {
+			maxY = room.Y
+		}
+	}
+	return minX, maxX, minY, maxY
+}
+
+// sortedRooms returns a graph's rooms in a stable order
+func sortedRooms(graph *mapper.RoomGraph) []*mapper.Room {
+	rooms := make([]*mapper.Room, 0, len(graph.Rooms))
+	for _, room := range graph.Rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	return rooms
+}
+
+// sortedExits returns a room's exit directions in a stable order
+func sortedExits(room *mapper.Room) []string {
+	directions := make([]string, 0, len(room.Exits))
+	for direction := range room.Exits {
+		directions = append(directions, direction)
+	}
+	sort.Strings(directions)
+	return directions
+}
@@ -0,0 +1,192 @@
+package mapexport
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+
+	"seemud-gui/internal/mapper"
+)
+
+// mudletFormatVersion is the JSON map format Mudlet 4.16 and later import
+// with "Import map" in the mapper's context menu
+const mudletFormatVersion = 1.001
+
+// mudletMap is the top level of Mudlet's JSON map format
+type mudletMap struct {
+	FormatVersion   float64      `json:"formatVersion"`
+	DefaultAreaName string       `json:"defaultAreaName"`
+	PlayerRoomID    int          `json:"playerRoomId,omitempty"`
+	Areas           []mudletArea `json:"areas"`
+}
+
+// mudletImport is what's read of a Mudlet map. Mudlet itself writes the
+// player's room per profile, so it's only taken when it's a plain number.
+type mudletImport struct {
+	FormatVersion float64         `json:"formatVersion"`
+	PlayerRoomID  json.RawMessage `json:"playerRoomId"`
+	Areas         []mudletArea    `json:"areas"`
+}
+
+// mudletArea holds every room, since SeeMUD maps have no areas
+type mudletArea struct {
+	ID    int          `json:"id"`
+	Name  string       `json:"name"`
+	Rooms []mudletRoom `json:"rooms"`
+}
+
+// mudletRoom is a room; Mudlet wants integer IDs, so the SeeMUD ID is kept
+// in the room's user data
+type mudletRoom struct {
+	ID          int               `json:"id"`
+	Name        string            `json:"name"`
+	Coordinates [3]int            `json:"coordinates"`
+	Exits       []mudletExit      `json:"exits,omitempty"`
+	StubExits   []string          `json:"stubExits,omitempty"` // Exits not yet explored
+	UserData    map[string]string `json:"userData,omitempty"`
+}
+
+// mudletExit is a standard exit to another room
+type mudletExit struct {
+	ExitID int    `json:"exitId"`
+	Name   string `json:"name"`
+}
+
+// writeMudlet converts the map to Mudlet's JSON map format. Unexplored
+// exits become stubs, as Mudlet exits must lead somewhere.
+func writeMudlet(w io.Writer, data *mapper.MapData) error {
+	rooms := sortedRooms(data.Graph)
+	ids := make(map[string]int, len(rooms))
+	for i, room := range rooms {
+		ids[room.ID] = i + 1
+	}
+
+	name := data.ServerName
+	if name == "" {
+		name = "SeeMUD"
+	}
+	area := mudletArea{ID: 1, Name: name}
+	for _, room := range rooms {
+		converted := mudletRoom{
+			ID:          ids[room.ID],
+			Name:        room.Name,
+			Coordinates: [3]int{room.X, room.Y, room.Z},
+			UserData:    map[string]string{"seemud_id": room.ID},
+		}
+		if room.Description != "" {
+			converted.UserData["description"] = room.Description
+		}
+		for _, direction := range sortedExits(room) {
+			target, known := ids[room.Exits[direction]]
+			if !known {
+				converted.StubExits = append(converted.StubExits, mapper.NormaliseDirection(direction))
+				continue
+			}
+			converted.Exits = append(converted.Exits, mudletExit{
+				ExitID: target,
+				Name:   mapper.NormaliseDirection(direction),
+			})
+		}
+		area.Rooms = append(area.Rooms, converted)
+	}
+
+	out := mudletMap{
+		FormatVersion:   mudletFormatVersion,
+		DefaultAreaName: "Default Area",
+		PlayerRoomID:    ids[data.CurrentRoomID],
+		Areas:           []mudletArea{area},
+	}
+	encoded, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal Mudlet map: %w", err)
+	}
+	if _, err := w.Write(encoded); err != nil {
+		return fmt.Errorf("failed to write Mudlet map: %w", err)
+	}
+	return nil
+}
+
+// seemudIDPattern matches the room IDs SeeMUD keeps in Mudlet user data
+var seemudIDPattern = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// ReadMudlet reads a map in Mudlet's JSON map format, as Mudlet writes with
+// saveJsonMap() or as exported here, so players moving from Mudlet keep
+// what they've mapped. Areas are flattened into one map and rooms keep
+// their coordinates. A room exported from SeeMUD gets its old ID back;
+// others get the ID their name and description make, or one from their
+// Mudlet ID where rooms share both. Mudlet's binary .dat maps aren't read.
+func ReadMudlet(r io.Reader) (*mapper.MapData, error) {
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Mudlet map: %w", err)
+	}
+	if trimmed := bytes.TrimSpace(raw); len(trimmed) == 0 || trimmed[0] != '{' {
+		return nil, fmt.Errorf("not a Mudlet JSON map; save binary maps as JSON in Mudlet first with saveJsonMap()")
+	}
+	var in mudletImport
+	if err := json.Unmarshal(raw, &in); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Mudlet map: %w", err)
+	}
+	if in.FormatVersion == 0 && len(in.Areas) == 0 {
+		return nil, fmt.Errorf("not a Mudlet JSON map: no formatVersion or areas")
+	}
+
+	var rooms []mudletRoom
+	for _, area := range in.Areas {
+		rooms = append(rooms, area.Rooms...)
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+
+	// IDs first, so exits can be turned into links
+	ids := make(map[int]string, len(rooms))
+	used := make(map[string]bool, len(rooms))
+	for _, room := range rooms {
+		id := room.UserData["seemud_id"]
+		if !seemudIDPattern.MatchString(id) || used[id] {
+			id = mapper.GenerateRoomID(room.Name, room.UserData["description"])
+		}
+		if used[id] {
+			hash := sha256.Sum256([]byte(fmt.Sprintf("mudlet|%d|%s", room.ID, room.Name)))
+			id = fmt.Sprintf("%x", hash[:16])
+		}
+		used[id] = true
+		ids[room.ID] = id
+	}
+
+	graph := mapper.NewRoomGraph()
+	for _, room := range rooms {
+		converted := &mapper.Room{
+			ID:          ids[room.ID],
+			Name:        room.Name,
+			Description: room.UserData["description"],
+			X:           room.Coordinates[0],
+			Y:           room.Coordinates[1],
+			Z:           room.Coordinates[2],
+			Exits:       make(map[string]string),
+			VisitCount:  1,
+		}
+		for _, stub := range room.StubExits {
+			converted.Exits[strings.ToLower(stub)] = ""
+		}
+		for _, exit := range room.Exits {
+			direction := strings.ToLower(exit.Name)
+			converted.Exits[direction] = ids[exit.ExitID] // Unexplored if the room isn't in the file
+			if target := ids[exit.ExitID]; target != "" {
+				graph.AddExit(converted.ID, direction, target)
+			}
+		}
+		graph.PlaceRoom(converted)
+	}
+
+	data := &mapper.MapData{Version: mapper.MapVersion, Graph: graph}
+	var player int
+	if json.Unmarshal(in.PlayerRoomID, &player) == nil {
+		data.CurrentRoomID = ids[player]
+	}
+	return data, nil
+}
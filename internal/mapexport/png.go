@@ -0,0 +1,93 @@
+package mapexport
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+
+	"seemud-gui/internal/mapper"
+)
+
+// Colours matching the SVG export
+var (
+	pngBackground = color.RGBA{0x1e, 0x1e, 0x24, 0xff}
+	pngLink       = color.RGBA{0x6c, 0x6c, 0x78, 0xff}
+	pngRoom       = color.RGBA{0x3a, 0x7b, 0xd5, 0xff}
+	pngCurrent    = color.RGBA{0xe8, 0xa3, 0x3d, 0xff}
+	pngBorder     = color.RGBA{0xdc, 0xdc, 0xe4, 0xff}
+	pngDivider    = color.RGBA{0x32, 0x32, 0x3a, 0xff}
+)
+
+// writePNG draws the map with the SVG export's layout. There's no text, so
+// levels are separated by a divider line instead of a heading.
+func writePNG(w io.Writer, data *mapper.MapData) error {
+	panels, width, height := layout(data.Graph)
+	img := image.NewRGBA(image.Rect(0, 0, max(width, 1), max(height, 1)))
+	draw.Draw(img, img.Bounds(), image.NewUniform(pngBackground), image.Point{}, draw.Src)
+
+	for i, p := range panels {
+		if i > 0 {
+			drawLine(img, margin/2, p.top+levelTitle/2, width-margin/2, p.top+levelTitle/2, pngDivider)
+		}
+		for _, l := range p.level.Links {
+			x1, y1 := p.centre(l.from)
+			x2, y2 := p.centre(l.to)
+			drawLine(img, x1, y1, x2, y2, pngLink)
+			drawLine(img, x1+1, y1, x2+1, y2, pngLink)
+		}
+		for _, room := range p.level.Rooms {
+			x, y := p.centre(room)
+			fill := pngRoom
+			if room.ID == data.CurrentRoomID {
+				fill = pngCurrent
+			}
+			outer := image.Rect(x-roomSize/2, y-roomSize/2, x+roomSize/2, y+roomSize/2)
+			draw.Draw(img, outer, image.NewUniform(pngBorder), image.Point{}, draw.Src)
+			draw.Draw(img, outer.Inset(1), image.NewUniform(fill), image.Point{}, draw.Src)
+		}
+	}
+
+	if err := png.Encode(w, img); err != nil {
+		return fmt.Errorf("failed to encode PNG: %w", err)
+	}
+	return nil
+}
+
+// drawLine draws a one pixel line with Bresenham's algorithm
+func drawLine(img *image.RGBA, x1, y1, x2, y2 int, c color.Color) {
+	dx, dy := abs(x2-x1), -abs(y2-y1)
+	sx, sy := 1, 1
+	if x1 > x2 {
+		sx = -1
+	}
+	if y1 > y2 {
+		sy = -1
+	}
+	err := dx + dy
+	for {
+		img.Set(x1, y1, c)
+		if x1 == x2 && y1 == y2 {
+			return
+		}
+		e2 := 2 * err
+		if e2 >= dy {
+			err += dy
+			x1 += sx
+		}
+		if e2 <= dx {
+			err += dx
+			y1 += sy
+		}
+	}
+}
+
+// abs returns the absolute value of n
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
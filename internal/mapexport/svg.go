@@ -0,0 +1,125 @@
+package mapexport
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"seemud-gui/internal/mapper"
+)
+
+// Drawing dimensions in pixels, shared by the SVG and PNG exports
+const (
+	cellSize   = 48 // Grid spacing between room centres
+	roomSize   = 24 // Side of a room square
+	margin     = 24 // Space around each level
+	levelTitle = 24 // Height of a level's heading
+)
+
+// panel is where a level is drawn in the image
+type panel struct {
+	level      *level
+	minX, maxY int // Grid origin, top left
+	top        int // Pixel offset of the level's heading
+	width      int
+	height     int
+}
+
+// centre returns a room's pixel centre in the image
+func (p *panel) centre(room *mapper.Room) (int, int) {
+	x := margin + (room.X-p.minX)*cellSize + cellSize/2
+	y := p.top + levelTitle + (p.maxY-room.Y)*cellSize + cellSize/2
+	return x, y
+}
+
+// layout stacks the levels top to bottom, highest first, returning the
+// panels and the image size
+func layout(graph *mapper.RoomGraph) ([]*panel, int, int) {
+	all := levels(graph)
+	var panels []*panel
+	width, height := 0, 0
+	for i := len(all) - 1; i >= 0; i-- {
+		minX, maxX, minY, maxY := all[i].bounds()
+		p := &panel{
+			level:  all[i],
+			minX:   minX,
+			maxY:   maxY,
+			top:    height,
+			width:  (maxX-minX+1)*cellSize + margin*2,
+			height: (maxY-minY+1)*cellSize + levelTitle + margin,
+		}
+		panels = append(panels, p)
+		width = max(width, p.width)
+		height += p.height
+	}
+	return panels, width, height
+}
+
+// writeSVG draws the map with each level as its own panel. Rooms carry
+// their name and description as tooltips; the current room is highlighted.
+func writeSVG(w io.Writer, data *mapper.MapData) error {
+	panels, width, height := layout(data.Graph)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="#1e1e24"/>`+"\n")
+	if data.ServerName != "" {
+		fmt.Fprintf(&b, "<title>%s</title>\n", html.EscapeString(data.ServerName))
+	}
+
+	for _, p := range panels {
+		fmt.Fprintf(&b, `<g id="level-%d">`+"\n", p.level.Z)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#9a9aa6" font-size="13">Level %d</text>`+"\n", margin, p.top+levelTitle-6, p.level.Z)
+
+		for _, l := range p.level.Links {
+			x1, y1 := p.centre(l.from)
+			x2, y2 := p.centre(l.to)
+			fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="#6c6c78" stroke-width="2"/>`+"\n", x1, y1, x2, y2)
+		}
+
+		for _, room := range p.level.Rooms {
+			x, y := p.centre(room)
+			fill := "#3a7bd5"
+			if room.ID == data.CurrentRoomID {
+				fill = "#e8a33d"
+			}
+			fmt.Fprintf(&b, `<g class="room" data-id="%s">`, html.EscapeString(room.ID))
+			fmt.Fprintf(&b, "<title>%s</title>", html.EscapeString(tooltip(room)))
+			fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="3" fill="%s" stroke="#dcdce4"/>`, x-roomSize/2, y-roomSize/2, roomSize, roomSize, fill)
+			if marks := verticalMarks(room); marks != "" {
+				fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#ffffff" font-size="10" text-anchor="middle">%s</text>`, x, y+4, marks)
+			}
+			b.WriteString("</g>\n")
+		}
+		b.WriteString("</g>\n")
+	}
+	b.WriteString("</svg>\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write SVG: %w", err)
+	}
+	return nil
+}
+
+// tooltip is a room's name and description
+func tooltip(room *mapper.Room) string {
+	if room.Description == "" {
+		return room.Name
+	}
+	return room.Name + "\n\n" + room.Description
+}
+
+// verticalMarks shows whether a room has exits up or down
+func verticalMarks(room *mapper.Room) string {
+	marks := ""
+	for direction := range room.Exits {
+		switch mapper.NormaliseDirection(direction) {
+		case "up":
+			marks = "▲" + strings.TrimPrefix(marks, "▲")
+		case "down":
+			marks = strings.TrimSuffix(marks, "▼") + "▼"
+		}
+	}
+	return marks
+}
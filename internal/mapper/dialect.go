@@ -0,0 +1,116 @@
+package mapper
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Intents are the directions the GUI can ask to move in, independent of
+// what a particular server calls them
+var Intents = []string{
+	"north", "northeast", "east", "southeast",
+	"south", "southwest", "west", "northwest",
+	"up", "down",
+}
+
+// Dialect maps movement intents to the commands a family of servers expects.
+// Intents missing from Commands aren't supported by that dialect.
+type Dialect struct {
+	Name     string            `json:"name"`
+	Commands map[string]string `json:"commands"`
+}
+
+// Dialects are the built-in movement dialects
+var Dialects = map[string]Dialect{
+	"wolfmud": {
+		Name: "wolfmud",
+		Commands: map[string]string{
+			"north": "n", "northeast": "ne", "east": "e", "southeast": "se",
+			"south": "s", "southwest": "sw", "west": "w", "northwest": "nw",
+			"up": "u", "down": "d",
+		},
+	},
+	// Diku derivatives have no diagonals
+	"diku": {
+		Name: "diku",
+		Commands: map[string]string{
+			"north": "north", "east": "east", "south": "south", "west": "west",
+			"up": "up", "down": "down",
+		},
+	},
+	"lp": {
+		Name: "lp",
+		Commands: map[string]string{
+			"north": "north", "northeast": "northeast", "east": "east", "southeast": "southeast",
+			"south": "south", "southwest": "southwest", "west": "west", "northwest": "northwest",
+			"up": "up", "down": "down",
+		},
+	},
+}
+
+// DefaultDialect is used until another is chosen
+const DefaultDialect = "wolfmud"
+
+// LookupDialect returns a built-in dialect by name
+func LookupDialect(name string) (Dialect, error) {
+	dialect, exists := Dialects[strings.ToLower(strings.TrimSpace(name))]
+	if !exists {
+		return Dialect{}, fmt.Errorf("unknown movement dialect %q", name)
+	}
+	return dialect, nil
+}
+
+// DialectNames returns the built-in dialect names sorted
+func DialectNames() []string {
+	names := make([]string, 0, len(Dialects))
+	for name := range Dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Command returns the command for a movement intent. Intents may also be
+// given in short form ("ne") or as a numpad digit laid out like a compass
+// (8 north, 9 northeast, ... with + and - for up and down).
+func (d Dialect) Command(intent string) (string, error) {
+	normalised := normaliseIntent(intent)
+	command, supported := d.Commands[normalised]
+	if !supported {
+		return "", fmt.Errorf("%s has no %q movement", d.Name, intent)
+	}
+	return command, nil
+}
+
+// numpad maps numpad keys to intents
+var numpad = map[string]string{
+	"8": "north", "9": "northeast", "6": "east", "3": "southeast",
+	"2": "south", "1": "southwest", "4": "west", "7": "northwest",
+	"+": "up", "-": "down",
+}
+
+// shortIntents maps abbreviated directions to intents
+var shortIntents = map[string]string{
+	"n": "north", "ne": "northeast", "e": "east", "se": "southeast",
+	"s": "south", "sw": "southwest", "w": "west", "nw": "northwest",
+	"u": "up", "d": "down",
+}
+
+// NormaliseDirection returns the full name of a direction in any accepted
+// spelling, e.g. "ne" or "9" becomes "northeast"
+func NormaliseDirection(direction string) string {
+	return normaliseIntent(direction)
+}
+
+// normaliseIntent turns any accepted spelling into an intent name
+func normaliseIntent(intent string) string {
+	intent = strings.ToLower(strings.TrimSpace(intent))
+	if full, isNumpad := numpad[intent]; isNumpad {
+		return full
+	}
+	if full, isShort := shortIntents[intent]; isShort {
+		return full
+	}
+	return intent
+}
@@ -0,0 +1,322 @@
+package mapper
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Fingerprints are the ways a room's ID can be made from what's seen of it
+const (
+	// FingerprintDescription hashes the room's name and the start of its
+	// description. Maps from before there was a choice use it.
+	FingerprintDescription = "description"
+	// FingerprintExits hashes the room's name and its set of exits, so
+	// descriptions that change with the weather or who's about don't make
+	// the same room twice. Rooms alike in both are told apart by the rooms
+	// around them.
+	FingerprintExits = "exits"
+)
+
+// Fingerprints lists the fingerprints a map can use
+var Fingerprints = []string{FingerprintDescription, FingerprintExits}
+
+// ParseFingerprint checks a fingerprint's name; empty is the description
+// fingerprint, as maps saved without one use
+func ParseFingerprint(name string) (string, error) {
+	switch name = strings.ToLower(strings.TrimSpace(name)); name {
+	case "":
+		return FingerprintDescription, nil
+	case FingerprintDescription, FingerprintExits:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown room fingerprint %q (want %s)", name, strings.Join(Fingerprints, " or "))
+}
+
+// ExitsRoomID creates a room ID from a room's name and exits, in any order
+func ExitsRoomID(name string, exits []string) string {
+	hash := sha256.Sum256([]byte(name + "|" + strings.Join(exitSet(exits), ",")))
+	return fmt.Sprintf("%x", hash[:16])
+}
+
+// variantRoomID makes another ID for a room whose name and exits match a
+// different room's, from what told them apart
+func variantRoomID(baseID, context string) string {
+	hash := sha256.Sum256([]byte(baseID + "|" + context))
+	return fmt.Sprintf("%x", hash[:16])
+}
+
+// exitSet returns exits in lower case, sorted and without repeats
+func exitSet(exits []string) []string {
+	seen := make(map[string]bool, len(exits))
+	set := make([]string, 0, len(exits))
+	for _, exit := range exits {
+		exit = strings.ToLower(strings.TrimSpace(exit))
+		if exit != "" && !seen[exit] {
+			seen[exit] = true
+			set = append(set, exit)
+		}
+	}
+	sort.Strings(set)
+	return set
+}
+
+// roomExits returns the directions out of a mapped room
+func roomExits(room *Room) []string {
+	exits := make([]string, 0, len(room.Exits))
+	for direction := range room.Exits {
+		exits = append(exits, direction)
+	}
+	return exitSet(exits)
+}
+
+// SetFingerprint chooses how IDs are made for rooms entered from now on.
+// Rooms already mapped keep theirs; MigrateFingerprint changes those too.
+func (m *Mapper) SetFingerprint(name string) error {
+	fingerprint, err := ParseFingerprint(name)
+	if err != nil {
+		return err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.fingerprint = fingerprint
+	return nil
+}
+
+// Fingerprint returns how room IDs are made
+func (m *Mapper) Fingerprint() string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.currentFingerprint()
+}
+
+// currentFingerprint returns the fingerprint in use; the caller must hold
+// the lock
+func (m *Mapper) currentFingerprint() string {
+	if m.fingerprint == "" {
+		return FingerprintDescription
+	}
+	return m.fingerprint
+}
+
+// identify returns the ID of the room just entered, an existing room's if
+// it's been mapped; the caller must hold the lock and have confirmed the
+// move that led here
+func (m *Mapper) identify(name, description string, exits []string) string {
+	if m.currentFingerprint() != FingerprintExits {
+		return GenerateRoomID(name, description)
+	}
+
+	baseID := ExitsRoomID(name, exits)
+	set := strings.Join(exitSet(exits), ",")
+	matches := func(room *Room) bool {
+		return room != nil && room.Name == name && strings.Join(roomExits(room), ",") == set
+	}
+
+	from := m.Graph.GetRoom(m.CurrentRoomID)
+	direction := strings.ToLower(m.LastDirection)
+	if from == nil || direction == "" {
+		// Looking again, or arriving without a known move
+		if current := m.Graph.GetRoom(m.CurrentRoomID); matches(current) {
+			return current.ID
+		}
+		if m.Graph.GetRoom(baseID) != nil {
+			return baseID
+		}
+		for _, room := range m.sortedRooms() {
+			if matches(room) {
+				return room.ID
+			}
+		}
+		return baseID
+	}
+
+	// The exit taken already leads here
+	if target := m.Graph.GetRoom(from.Exits[direction]); matches(target) {
+		return target.ID
+	}
+
+	// A room alike in name and exits is this one unless its way back leads
+	// somewhere else
+	back := OppositeDirection[direction]
+	var candidates []*Room
+	for _, room := range m.sortedRooms() {
+		if !matches(room) {
+			continue
+		}
+		if linked := room.Exits[back]; back != "" && linked != "" && linked != from.ID {
+			continue
+		}
+		if room.ID == baseID {
+			return baseID
+		}
+		candidates = append(candidates, room)
+	}
+	if len(candidates) > 0 {
+		return candidates[0].ID
+	}
+	if m.Graph.GetRoom(baseID) == nil {
+		return baseID
+	}
+	return variantRoomID(baseID, from.ID+"|"+direction)
+}
+
+// sortedRooms returns the mapped rooms in ID order, so choices between
+// alike rooms come out the same each time; the caller must hold the lock
+func (m *Mapper) sortedRooms() []*Room {
+	rooms := make([]*Room, 0, len(m.Graph.Rooms))
+	for _, room := range m.Graph.Rooms {
+		rooms = append(rooms, room)
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+	return rooms
+}
+
+// MigrateFingerprint gives every mapped room a new ID made with another
+// fingerprint and uses it for rooms entered from now on. Moving to the
+// exits fingerprint merges copies of a room made when its description
+// changed, unless their exits lead to rooms that differ. It returns each
+// old ID's new one, so anything kept by room ID can follow. Migrating to
+// the fingerprint in use changes nothing, as rekeying alike rooms again
+// could swap their IDs.
+func (m *Mapper) MigrateFingerprint(name string) (map[string]string, error) {
+	fingerprint, err := ParseFingerprint(name)
+	if err != nil {
+		return nil, err
+	}
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if fingerprint == m.currentFingerprint() {
+		ids := make(map[string]string, len(m.Graph.Rooms))
+		for id := range m.Graph.Rooms {
+			ids[id] = id
+		}
+		return ids, nil
+	}
+
+	graph, ids := rekeyGraph(m.Graph, fingerprint)
+	m.Graph = graph
+	m.fingerprint = fingerprint
+	m.CurrentRoomID = ids[m.CurrentRoomID]
+	m.PreviousRoomID = ids[m.PreviousRoomID]
+	logger.Info("migrated room fingerprint", "fingerprint", fingerprint, "rooms", len(ids), "now", len(graph.Rooms))
+	return ids, nil
+}
+
+// rekeyGraph copies a graph with its rooms' IDs made by a fingerprint,
+// returning the copy and each old ID's new one
+func rekeyGraph(graph *RoomGraph, fingerprint string) (*RoomGraph, map[string]string) {
+	ids := make(map[string]string, len(graph.Rooms))
+	oldIDs := make([]string, 0, len(graph.Rooms))
+	for id := range graph.Rooms {
+		oldIDs = append(oldIDs, id)
+	}
+	sort.Strings(oldIDs)
+
+	if fingerprint == FingerprintDescription {
+		for _, id := range oldIDs {
+			room := graph.Rooms[id]
+			ids[id] = GenerateRoomID(room.Name, room.Description)
+		}
+	} else {
+		baseIDs := make(map[string]string, len(oldIDs))
+		for _, id := range oldIDs {
+			room := graph.Rooms[id]
+			baseIDs[id] = ExitsRoomID(room.Name, roomExits(room))
+		}
+
+		// Rooms alike in name and exits are one room unless an exit they
+		// have both explored leads to rooms that aren't alike
+		conflict := func(a, b *Room) bool {
+			for direction, target := range a.Exits {
+				other := b.Exits[direction]
+				if target != "" && other != "" && baseIDs[target] != baseIDs[other] {
+					return true
+				}
+			}
+			return false
+		}
+		groups := make(map[string][]string) // New IDs made from each base ID
+		members := make(map[string][]*Room) // Old rooms taken by each new ID
+		for _, id := range oldIDs {
+			room, baseID := graph.Rooms[id], baseIDs[id]
+			newID := ""
+			for _, candidate := range groups[baseID] {
+				clash := false
+				for _, member := range members[candidate] {
+					clash = clash || conflict(room, member)
+				}
+				if !clash {
+					newID = candidate
+					break
+				}
+			}
+			if newID == "" {
+				newID = baseID
+				if len(groups[baseID]) > 0 {
+					newID = variantRoomID(baseID, id)
+				}
+				groups[baseID] = append(groups[baseID], newID)
+			}
+			members[newID] = append(members[newID], room)
+			ids[id] = newID
+		}
+	}
+
+	rekeyed := NewRoomGraph()
+	for _, id := range oldIDs {
+		old := graph.Rooms[id]
+		room, exists := rekeyed.Rooms[ids[id]]
+		if !exists {
+			copied := *old
+			copied.ID = ids[id]
+			copied.Exits = make(map[string]string, len(old.Exits))
+			rekeyed.PlaceRoom(&copied)
+			room = &copied
+		} else {
+			mergeRoom(room, old)
+		}
+		for direction, target := range old.Exits {
+			if target != "" {
+				target = ids[target]
+			}
+			if room.Exits[direction] == "" {
+				room.Exits[direction] = target
+			}
+		}
+	}
+	for _, exit := range graph.Exits {
+		to := exit.To
+		if to != "" {
+			to = ids[to]
+		}
+		rekeyed.AddExit(ids[exit.From], exit.Direction, to)
+	}
+	return rekeyed, ids
+}
+
+// mergeRoom folds a copy of a room into the one kept: visits add up, the
+// latest description wins and notes and image prompts are kept
+func mergeRoom(kept, duplicate *Room) {
+	kept.VisitCount += duplicate.VisitCount
+	if duplicate.Visited.After(kept.Visited) {
+		kept.Visited = duplicate.Visited
+		kept.Description = duplicate.Description
+	}
+	kept.Uncertain = kept.Uncertain || duplicate.Uncertain
+	if kept.Notes == "" {
+		kept.Notes = duplicate.Notes
+	} else if duplicate.Notes != "" && duplicate.Notes != kept.Notes {
+		kept.Notes += "\n" + duplicate.Notes
+	}
+	if kept.PromptAdditions == "" {
+		kept.PromptAdditions = duplicate.PromptAdditions
+	}
+	if kept.ImagePath == "" {
+		kept.ImagePath = duplicate.ImagePath
+	}
+}
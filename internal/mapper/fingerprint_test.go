@@ -0,0 +1,179 @@
+package mapper
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// oldMap writes a map as maps were saved before fingerprints: no
+// fingerprint field and IDs made from descriptions. The square was mapped
+// twice as its description changed with the weather; the two corridors
+// are alike but lead to different rooms.
+func oldMap(t *testing.T, dir, server string) map[string]string {
+	t.Helper()
+	ids := map[string]string{
+		"square (sun)":  GenerateRoomID("Square", "The sun beats down on the square."),
+		"square (rain)": GenerateRoomID("Square", "Rain lashes the square."),
+		"tavern":        GenerateRoomID("Tavern", "A warm tavern."),
+		"corridor a":    GenerateRoomID("Corridor", "A dusty corridor."),
+		"corridor b":    GenerateRoomID("Corridor", "A damp corridor."),
+		"armoury":       GenerateRoomID("Armoury", "Racks of swords."),
+		"library":       GenerateRoomID("Library", "Shelves of books."),
+	}
+	room := func(key, name, description string, visits int, exits map[string]string) *Room {
+		resolved := make(map[string]string, len(exits))
+		for direction, target := range exits {
+			resolved[direction] = ids[target]
+		}
+		return &Room{ID: ids[key], Name: name, Description: description, VisitCount: visits, Exits: resolved}
+	}
+
+	graph := NewRoomGraph()
+	for _, r := range []*Room{
+		room("square (sun)", "Square", "The sun beats down on the square.", 2, map[string]string{"north": "tavern"}),
+		room("square (rain)", "Square", "Rain lashes the square.", 3, map[string]string{"north": "tavern"}),
+		room("tavern", "Tavern", "A warm tavern.", 1, map[string]string{"south": "square (rain)", "east": "corridor a"}),
+		room("corridor a", "Corridor", "A dusty corridor.", 1, map[string]string{"west": "tavern", "east": "armoury"}),
+		room("corridor b", "Corridor", "A damp corridor.", 1, map[string]string{"west": "tavern", "east": "library"}),
+		room("armoury", "Armoury", "Racks of swords.", 1, map[string]string{"west": "corridor a"}),
+		room("library", "Library", "Shelves of books.", 1, map[string]string{"west": "corridor b"}),
+	} {
+		graph.Rooms[r.ID] = r
+	}
+	graph.AddExit(ids["square (sun)"], "north", ids["tavern"])
+	graph.AddExit(ids["square (rain)"], "north", ids["tavern"])
+
+	data, err := json.Marshal(&MapData{
+		Version:       MapVersion,
+		ServerName:    server,
+		Graph:         graph,
+		CurrentRoomID: ids["square (sun)"],
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, server+".json"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return ids
+}
+
+func TestMigrateOldMap(t *testing.T) {
+	dir := t.TempDir()
+	ids := oldMap(t, dir, "mud")
+
+	m := NewMapper()
+	m.SetDirectory(dir)
+	if err := m.LoadMap("mud"); err != nil {
+		t.Fatalf("LoadMap: %v", err)
+	}
+	if got := m.Fingerprint(); got != FingerprintDescription {
+		t.Fatalf("old map fingerprint = %q, want %q", got, FingerprintDescription)
+	}
+
+	moved, err := m.MigrateFingerprint(FingerprintExits)
+	if err != nil {
+		t.Fatalf("MigrateFingerprint: %v", err)
+	}
+
+	// The two squares become one; the corridors stay two
+	if got := m.RoomCount(); got != 6 {
+		t.Errorf("%d rooms after migrating, want 6", got)
+	}
+	if moved[ids["square (sun)"]] != moved[ids["square (rain)"]] {
+		t.Error("the square's two copies kept different IDs")
+	}
+	if moved[ids["corridor a"]] == moved[ids["corridor b"]] {
+		t.Error("corridors leading to different rooms were merged")
+	}
+	for id, room := range m.Graph.Rooms {
+		if room.ID != id {
+			t.Errorf("room %s is keyed as %s", room.ID, id)
+		}
+		if !m.hasOnlyKnownExits(room) {
+			t.Errorf("%s has exits to rooms not on the map: %v", room.Name, room.Exits)
+		}
+	}
+
+	square := m.Graph.GetRoom(moved[ids["square (sun)"]])
+	if square == nil {
+		t.Fatal("square missing after migrating")
+	}
+	if square.VisitCount != 5 {
+		t.Errorf("square visits = %d, want 5", square.VisitCount)
+	}
+	if square.ID != ExitsRoomID("Square", []string{"north"}) {
+		t.Errorf("square ID = %s, want the exits fingerprint's", square.ID)
+	}
+	if m.CurrentRoomID != square.ID {
+		t.Errorf("current room = %s, want the square, %s", m.CurrentRoomID, square.ID)
+	}
+	for _, exit := range m.Graph.Exits {
+		if m.Graph.GetRoom(exit.From) == nil || m.Graph.GetRoom(exit.To) == nil {
+			t.Errorf("exit %s from %s to %s names a room not on the map", exit.Direction, exit.From, exit.To)
+		}
+	}
+
+	// Migrating again changes nothing
+	again, err := m.MigrateFingerprint(FingerprintExits)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for old, id := range again {
+		if old != id {
+			t.Errorf("second migration moved %s to %s", old, id)
+		}
+	}
+
+	// Saved and loaded, the map keeps its fingerprint and rooms
+	if err := m.SaveMap("mud"); err != nil {
+		t.Fatal(err)
+	}
+	loaded := NewMapper()
+	loaded.SetDirectory(dir)
+	if err := loaded.LoadMap("mud"); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Fingerprint() != FingerprintExits || loaded.RoomCount() != 6 {
+		t.Errorf("reloaded map has fingerprint %q and %d rooms, want %q and 6", loaded.Fingerprint(), loaded.RoomCount(), FingerprintExits)
+	}
+}
+
+func TestMergeOldMap(t *testing.T) {
+	dir := t.TempDir()
+	oldMap(t, dir, "shared")
+	data, err := os.ReadFile(filepath.Join(dir, "shared.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mapData MapData
+	if err := json.Unmarshal(data, &mapData); err != nil {
+		t.Fatal(err)
+	}
+
+	m := NewMapper()
+	if err := m.SetFingerprint(FingerprintExits); err != nil {
+		t.Fatal(err)
+	}
+	if added := m.MergeMap(&mapData); added != 6 {
+		t.Errorf("MergeMap added %d rooms, want 6", added)
+	}
+	for _, room := range m.Graph.Rooms {
+		if !m.hasOnlyKnownExits(room) {
+			t.Errorf("%s has exits to rooms not on the map: %v", room.Name, room.Exits)
+		}
+	}
+}
+
+// hasOnlyKnownExits reports whether every explored exit leads to a room
+// on the map
+func (m *Mapper) hasOnlyKnownExits(room *Room) bool {
+	for _, target := range room.Exits {
+		if target != "" && m.Graph.GetRoom(target) == nil {
+			return false
+		}
+	}
+	return true
+}
@@ -3,23 +3,25 @@ package mapper
 import (
 	"crypto/sha256"
 	"fmt"
+	"sync"
 	"time"
 )
 
 // Room represents a location in the MUD world
 type Room struct {
-	ID          string            `json:"id"`
-	Name        string            `json:"name"`
-	Description string            `json:"description"`
-	X           int               `json:"x"`
-	Y           int               `json:"y"`
-	Z           int               `json:"z"`
-	Exits       map[string]string `json:"exits"`        // direction -> room ID (nil if unexplored)
-	ImagePath   string            `json:"image_path"`   // Path to cached image
-	Visited     time.Time         `json:"visited"`      // Last visit time
-	VisitCount  int               `json:"visit_count"`  // Number of times visited
-	Uncertain   bool              `json:"uncertain"`    // Flag for coordinate uncertainty
-	Notes       string            `json:"notes"`        // User notes
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	Description     string            `json:"description"`
+	X               int               `json:"x"`
+	Y               int               `json:"y"`
+	Z               int               `json:"z"`
+	Exits           map[string]string `json:"exits"`                      // direction -> room ID (nil if unexplored)
+	ImagePath       string            `json:"image_path"`                 // Path to cached image
+	Visited         time.Time         `json:"visited"`                    // Last visit time
+	VisitCount      int               `json:"visit_count"`                // Number of times visited
+	Uncertain       bool              `json:"uncertain"`                  // Flag for coordinate uncertainty
+	Notes           string            `json:"notes"`                      // User notes
+	PromptAdditions string            `json:"prompt_additions,omitempty"` // Appended to every image prompt for this room
 }
 
 // Exit represents a directional connection between rooms
@@ -33,6 +35,13 @@ type Exit struct {
 type RoomGraph struct {
 	Rooms map[string]*Room `json:"rooms"` // room ID -> Room
 	Exits []*Exit          `json:"exits"` // All exits in the graph
+
+	// The rooms by coordinates, so finding what's at a spot doesn't scan the
+	// whole map. It's built on first use and kept up to date by PlaceRoom,
+	// so rooms must be added or moved with it once the graph is in use.
+	indexMux sync.Mutex
+	index    map[[3]int][]*Room
+	spots    map[string][3]int // Where each room is indexed, by ID
 }
 
 // NewRoomGraph creates a new empty room graph
@@ -77,10 +86,81 @@ func (g *RoomGraph) AddRoom(room *Room) {
 		// New room
 		room.Visited = time.Now()
 		room.VisitCount = 1
-		g.Rooms[room.ID] = room
+		g.PlaceRoom(room)
+	}
+}
+
+// PlaceRoom puts a room on the graph as it is, replacing any with its ID,
+// and indexes it by its coordinates. A room already placed is moved by
+// changing its coordinates and placing it again.
+func (g *RoomGraph) PlaceRoom(room *Room) {
+	g.indexMux.Lock()
+	defer g.indexMux.Unlock()
+
+	g.unindex(room.ID)
+	g.Rooms[room.ID] = room
+	if g.index != nil {
+		g.addToIndex(room)
+	}
+}
+
+// Reindex rebuilds the coordinate index, for after rooms were moved or
+// added without PlaceRoom
+func (g *RoomGraph) Reindex() {
+	g.indexMux.Lock()
+	defer g.indexMux.Unlock()
+
+	g.rebuildIndex()
+}
+
+// rebuildIndex indexes every room; the caller must hold indexMux
+func (g *RoomGraph) rebuildIndex() {
+	g.index = make(map[[3]int][]*Room, len(g.Rooms))
+	g.spots = make(map[string][3]int, len(g.Rooms))
+	for _, room := range g.Rooms {
+		g.addToIndex(room)
+	}
+}
+
+// addToIndex indexes a room where it is; the caller must hold indexMux
+func (g *RoomGraph) addToIndex(room *Room) {
+	key := [3]int{room.X, room.Y, room.Z}
+	g.index[key] = append(g.index[key], room)
+	g.spots[room.ID] = key
+}
+
+// unindex drops a room from where it was indexed, which may not be where
+// it is now; the caller must hold indexMux
+func (g *RoomGraph) unindex(id string) {
+	key, indexed := g.spots[id]
+	if !indexed {
+		return
+	}
+	delete(g.spots, id)
+	rooms := g.index[key]
+	for i, room := range rooms {
+		if room.ID == id {
+			g.index[key] = append(rooms[:i:i], rooms[i+1:]...)
+			break
+		}
+	}
+	if len(g.index[key]) == 0 {
+		delete(g.index, key)
 	}
 }
 
+// RoomsAt returns the rooms at specific coordinates; more than one shares
+// a spot only where mapping collided
+func (g *RoomGraph) RoomsAt(x, y, z int) []*Room {
+	g.indexMux.Lock()
+	defer g.indexMux.Unlock()
+
+	if g.index == nil {
+		g.rebuildIndex()
+	}
+	return append([]*Room(nil), g.index[[3]int{x, y, z}]...)
+}
+
 // GetRoom retrieves a room by ID
 func (g *RoomGraph) GetRoom(id string) *Room {
 	return g.Rooms[id]
@@ -140,10 +220,8 @@ func (g *RoomGraph) FindRoomsByName(name string) []*Room {
 
 // FindRoomAt returns the room at specific coordinates
 func (g *RoomGraph) FindRoomAt(x, y, z int) *Room {
-	for _, room := range g.Rooms {
-		if room.X == x && room.Y == y && room.Z == z {
-			return room
-		}
+	if rooms := g.RoomsAt(x, y, z); len(rooms) > 0 {
+		return rooms[0]
 	}
 	return nil
 }
@@ -0,0 +1,105 @@
+package mapper
+
+import (
+	"sort"
+	"testing"
+)
+
+// idsAt lists the IDs of the rooms at a spot, sorted
+func idsAt(g *RoomGraph, x, y, z int) []string {
+	var ids []string
+	for _, room := range g.RoomsAt(x, y, z) {
+		ids = append(ids, room.ID)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+// checkIndex compares RoomsAt with a scan of every room
+func checkIndex(t *testing.T, what string, g *RoomGraph) {
+	t.Helper()
+	spots := make(map[[3]int][]string)
+	for _, room := range g.Rooms {
+		key := [3]int{room.X, room.Y, room.Z}
+		spots[key] = append(spots[key], room.ID)
+	}
+	for key, want := range spots {
+		sort.Strings(want)
+		got := idsAt(g, key[0], key[1], key[2])
+		if len(got) != len(want) {
+			t.Errorf("%s: RoomsAt%v = %v, want %v", what, key, got, want)
+			continue
+		}
+		for i := range got {
+			if got[i] != want[i] {
+				t.Errorf("%s: RoomsAt%v = %v, want %v", what, key, got, want)
+				break
+			}
+		}
+	}
+	// Nothing may linger where no room is
+	g.indexMux.Lock()
+	defer g.indexMux.Unlock()
+	for key, rooms := range g.index {
+		if len(rooms) != len(spots[key]) {
+			t.Errorf("%s: index holds %d rooms at %v, want %d", what, len(rooms), key, len(spots[key]))
+		}
+	}
+}
+
+func TestRoomsAtFollowsChanges(t *testing.T) {
+	g := NewRoomGraph()
+	g.PlaceRoom(&Room{ID: "a", Exits: map[string]string{}})
+	g.PlaceRoom(&Room{ID: "b", X: 1, Exits: map[string]string{}})
+	checkIndex(t, "placed", g)
+
+	// Moved in place, then placed again
+	b := g.GetRoom("b")
+	b.X, b.Y = 4, 4
+	g.PlaceRoom(b)
+	checkIndex(t, "moved", g)
+	if ids := idsAt(g, 1, 0, 0); len(ids) != 0 {
+		t.Errorf("moved room still found where it was: %v", ids)
+	}
+
+	// Replaced by a copy elsewhere
+	g.PlaceRoom(&Room{ID: "a", X: -2, Exits: map[string]string{}})
+	checkIndex(t, "replaced", g)
+
+	// Two rooms on one spot, then one moved off it
+	g.PlaceRoom(&Room{ID: "c", X: 4, Y: 4, Exits: map[string]string{}})
+	checkIndex(t, "collided", g)
+	b.Z = 1
+	g.PlaceRoom(b)
+	checkIndex(t, "uncollided", g)
+
+	// Rooms written straight into a graph before it's used are picked up
+	loaded := NewRoomGraph()
+	loaded.Rooms["x"] = &Room{ID: "x", X: 3, Exits: map[string]string{}}
+	checkIndex(t, "loaded", loaded)
+}
+
+func TestRoomsAtAfterMerging(t *testing.T) {
+	m := NewMapper()
+	m.Graph.PlaceRoom(&Room{ID: "home", Exits: map[string]string{"east": ""}})
+	checkIndex(t, "before merging", m.Graph)
+
+	other := NewRoomGraph()
+	other.PlaceRoom(&Room{ID: "far", X: 7, Exits: map[string]string{}})
+	m.MergeMap(&MapData{Graph: other})
+	checkIndex(t, "merged map", m.Graph)
+
+	m.AddSharedRooms(
+		[]*Room{{ID: "shared", X: 99, Y: 99, Exits: map[string]string{"west": "home"}}},
+		[]*Exit{{From: "home", Direction: "east", To: "shared"}},
+	)
+	checkIndex(t, "shared rooms", m.Graph)
+	if ids := idsAt(m.Graph, 1, 0, 0); len(ids) != 1 || ids[0] != "shared" {
+		t.Errorf("shared room placed at %v, want beside home", ids)
+	}
+
+	if _, err := m.MigrateFingerprint(FingerprintExits); err != nil {
+		t.Fatal(err)
+	}
+	checkIndex(t, "migrated", m.Graph)
+}
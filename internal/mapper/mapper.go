@@ -1,18 +1,26 @@
 package mapper
 
 import (
-	"log"
+	"fmt"
 	"strings"
 	"sync"
+
+	"seemud-gui/internal/logging"
 )
 
+var logger = logging.For("Mapper")
+
 // Mapper handles automatic mapping of the MUD world
 type Mapper struct {
-	Graph         *RoomGraph
-	CurrentRoomID string
+	Graph          *RoomGraph
+	CurrentRoomID  string
 	PreviousRoomID string
-	LastDirection string // Last movement direction taken
-	mutex         sync.RWMutex
+	LastDirection  string // Direction of the move that led to the current room
+	mutex          sync.RWMutex
+	pending        []pendingMove // Movement commands not yet answered, oldest first
+	listeners      []func(roomID string, isNew bool)
+	dir            string // Where maps are saved, MapCacheDir if empty
+	fingerprint    string // How room IDs are made, FingerprintDescription if empty
 }
 
 // NewMapper creates a new mapper instance
@@ -24,69 +32,90 @@ func NewMapper() *Mapper {
 
 // DirectionOffsets defines coordinate changes for each direction
 var DirectionOffsets = map[string][3]int{
-	"n":     {0, 1, 0},
-	"north": {0, 1, 0},
-	"s":     {0, -1, 0},
-	"south": {0, -1, 0},
-	"e":     {1, 0, 0},
-	"east":  {1, 0, 0},
-	"w":     {-1, 0, 0},
-	"west":  {-1, 0, 0},
-	"ne":    {1, 1, 0},
+	"n":         {0, 1, 0},
+	"north":     {0, 1, 0},
+	"s":         {0, -1, 0},
+	"south":     {0, -1, 0},
+	"e":         {1, 0, 0},
+	"east":      {1, 0, 0},
+	"w":         {-1, 0, 0},
+	"west":      {-1, 0, 0},
+	"ne":        {1, 1, 0},
 	"northeast": {1, 1, 0},
-	"nw":    {-1, 1, 0},
+	"nw":        {-1, 1, 0},
 	"northwest": {-1, 1, 0},
-	"se":    {1, -1, 0},
+	"se":        {1, -1, 0},
 	"southeast": {1, -1, 0},
-	"sw":    {-1, -1, 0},
+	"sw":        {-1, -1, 0},
 	"southwest": {-1, -1, 0},
-	"u":     {0, 0, 1},
-	"up":    {0, 0, 1},
-	"d":     {0, 0, -1},
-	"down":  {0, 0, -1},
+	"u":         {0, 0, 1},
+	"up":        {0, 0, 1},
+	"d":         {0, 0, -1},
+	"down":      {0, 0, -1},
 }
 
 // OppositeDirection returns the reverse of a direction
 var OppositeDirection = map[string]string{
-	"n":     "s",
-	"north": "south",
-	"s":     "n",
-	"south": "north",
-	"e":     "w",
-	"east":  "west",
-	"w":     "e",
-	"west":  "east",
-	"ne":    "sw",
+	"n":         "s",
+	"north":     "south",
+	"s":         "n",
+	"south":     "north",
+	"e":         "w",
+	"east":      "west",
+	"w":         "e",
+	"west":      "east",
+	"ne":        "sw",
 	"northeast": "southwest",
-	"nw":    "se",
+	"nw":        "se",
 	"northwest": "southeast",
-	"se":    "nw",
+	"se":        "nw",
 	"southeast": "northwest",
-	"sw":    "ne",
+	"sw":        "ne",
 	"southwest": "northeast",
-	"u":     "d",
-	"up":    "down",
-	"d":     "u",
-	"down":  "up",
+	"u":         "d",
+	"up":        "down",
+	"d":         "u",
+	"down":      "up",
 }
 
 // OnRoomEntered should be called when the player enters a room
 func (m *Mapper) OnRoomEntered(name, description string, exits []string) string {
+	m.mutex.Lock()
+	// Only a move answered by this room links it
+	m.confirmMovement()
+	roomID := m.identify(name, description, exits)
+	isNew := m.Graph.GetRoom(roomID) == nil
+	m.enterRoom(roomID, name, description, exits)
+	listeners := m.listeners
+	m.mutex.Unlock()
+
+	// Notify outside the lock so listeners can read the map
+	for _, fn := range listeners {
+		fn(roomID, isNew)
+	}
+
+	return roomID
+}
+
+// OnRoomChange registers a listener called after every room entry, noting
+// whether the room was mapped for the first time
+func (m *Mapper) OnRoomChange(fn func(roomID string, isNew bool)) {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	// Generate room ID
-	roomID := GenerateRoomID(name, description)
+	m.listeners = append(m.listeners, fn)
+}
 
+// enterRoom records a room entry; the caller must hold the lock
+func (m *Mapper) enterRoom(roomID, name, description string, exits []string) {
 	// Check if this room exists
 	existingRoom := m.Graph.GetRoom(roomID)
 
 	if existingRoom != nil {
 		// Room already mapped, update visit info
-		log.Printf("[Mapper] Returned to known room: %s (ID: %s)", name, roomID[:8])
+		logger.Debug("returned to known room", "room", name, "id", roomID[:8])
 		m.PreviousRoomID = m.CurrentRoomID
 		m.CurrentRoomID = roomID
-		existingRoom.Visited = existingRoom.Visited // Will be updated by AddRoom
 		m.Graph.AddRoom(existingRoom)
 
 		// Link from previous room if we moved
@@ -94,8 +123,7 @@ func (m *Mapper) OnRoomEntered(name, description string, exits []string) string
 			m.linkRooms(m.PreviousRoomID, m.LastDirection, roomID)
 		}
 
-		m.LastDirection = "" // Reset after use
-		return roomID
+		return
 	}
 
 	// New room - need to calculate coordinates
@@ -112,7 +140,7 @@ func (m *Mapper) OnRoomEntered(name, description string, exits []string) string
 				z = prevRoom.Z + offset[2]
 			} else {
 				// Unknown direction - place randomly offset
-				log.Printf("[Mapper] Unknown direction: %s", m.LastDirection)
+				logger.Warn("unknown direction", "direction", m.LastDirection)
 				x = prevRoom.X + 1
 				y = prevRoom.Y
 				z = prevRoom.Z
@@ -120,7 +148,7 @@ func (m *Mapper) OnRoomEntered(name, description string, exits []string) string
 
 			// Check for coordinate collision
 			if collision := m.Graph.FindRoomAt(x, y, z); collision != nil {
-				log.Printf("[Mapper] Coordinate collision at (%d,%d,%d) for new room %s", x, y, z, name)
+				logger.Warn("coordinate collision", "x", x, "y", y, "z", z, "room", name)
 				// Offset slightly - this needs manual review
 				x += 1
 			}
@@ -144,27 +172,15 @@ func (m *Mapper) OnRoomEntered(name, description string, exits []string) string
 	}
 
 	m.Graph.AddRoom(newRoom)
-	log.Printf("[Mapper] Mapped new room: %s at (%d,%d,%d) [ID: %s]", name, x, y, z, roomID[:8])
+	logger.Info("mapped new room", "room", name, "x", x, "y", y, "z", z, "id", roomID[:8])
+
+	m.PreviousRoomID = m.CurrentRoomID
+	m.CurrentRoomID = roomID
 
 	// Link from previous room if we moved
 	if m.PreviousRoomID != "" && m.LastDirection != "" {
 		m.linkRooms(m.PreviousRoomID, m.LastDirection, roomID)
 	}
-
-	m.PreviousRoomID = m.CurrentRoomID
-	m.CurrentRoomID = roomID
-	m.LastDirection = "" // Reset after use
-
-	return roomID
-}
-
-// OnMovement should be called when the player issues a movement command
-func (m *Mapper) OnMovement(direction string) {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
-	m.LastDirection = direction
-	log.Printf("[Mapper] Movement command: %s", direction)
 }
 
 // linkRooms creates bidirectional links between rooms
@@ -188,7 +204,7 @@ func (m *Mapper) linkRooms(fromID, direction, toID string) {
 		m.Graph.AddExit(toID, reverseDir, fromID)
 	}
 
-	log.Printf("[Mapper] Linked rooms: %s -[%s]-> %s", fromRoom.Name, normalizedDir, toRoom.Name)
+	logger.Debug("linked rooms", "from", fromRoom.Name, "direction", normalizedDir, "to", toRoom.Name)
 }
 
 // GetCurrentRoom returns the current room object
@@ -199,6 +215,37 @@ func (m *Mapper) GetCurrentRoom() *Room {
 	return m.Graph.GetRoom(m.CurrentRoomID)
 }
 
+// SetCurrentRoom moves the player to a mapped room without walking there,
+// e.g. to render it with its neighbours
+func (m *Mapper) SetCurrentRoom(roomID string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.Graph.GetRoom(roomID) == nil {
+		return fmt.Errorf("room %s is not mapped", roomID)
+	}
+	m.CurrentRoomID = roomID
+	return nil
+}
+
+// ExitBetween returns the direction of the exit from one room that leads to
+// another, or "" if they aren't linked
+func (m *Mapper) ExitBetween(fromID, toID string) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	from := m.Graph.GetRoom(fromID)
+	if from == nil {
+		return ""
+	}
+	for direction, target := range from.Exits {
+		if target == toID {
+			return direction
+		}
+	}
+	return ""
+}
+
 // GetNeighbours returns neighbouring rooms with their directions
 func (m *Mapper) GetNeighbours() map[string]*Room {
 	m.mutex.RLock()
@@ -225,7 +272,15 @@ func (m *Mapper) SetGraph(graph *RoomGraph) {
 	defer m.mutex.Unlock()
 
 	m.Graph = graph
-	log.Printf("[Mapper] Loaded graph with %d rooms", len(graph.Rooms))
+	logger.Info("loaded graph", "rooms", len(graph.Rooms))
+}
+
+// RoomCount returns the number of mapped rooms
+func (m *Mapper) RoomCount() int {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.Graph.GetRoomCount()
 }
 
 // GetMapStats returns statistics about the mapped area
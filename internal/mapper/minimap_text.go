@@ -0,0 +1,98 @@
+package mapper
+
+import "strings"
+
+// TextStyle is the set of characters a minimap is drawn with
+type TextStyle struct {
+	Current, Revisited, Room       rune // Rooms: the player's, seen more than once, the rest
+	Vertical, Horizontal           rune // n/s and e/w exits
+	Rising, Falling                rune // ne/sw and nw/se exits
+	TopLeft, TopRight              rune // Frame corners, or 0 for no frame
+	BottomLeft, BottomRight        rune
+	FrameVertical, FrameHorizontal rune
+}
+
+// ASCIIStyle draws with plain ASCII and no frame, for any terminal
+var ASCIIStyle = TextStyle{
+	Current: '@', Revisited: '#', Room: 'o',
+	Vertical: '|', Horizontal: '-', Rising: '/', Falling: '\\',
+}
+
+// UnicodeStyle draws with box-drawing characters inside a frame
+var UnicodeStyle = TextStyle{
+	Current: '◉', Revisited: '■', Room: '□',
+	Vertical: '│', Horizontal: '─', Rising: '╱', Falling: '╲',
+	TopLeft: '╭', TopRight: '╮', BottomLeft: '╰', BottomRight: '╯',
+	FrameVertical: '│', FrameHorizontal: '─',
+}
+
+// textConnectors are where each exit is drawn relative to its room in the
+// doubled-up grid, and whether it uses the vertical, horizontal, rising or
+// falling character
+var textConnectors = map[string]struct {
+	dx, dy int
+	kind   int
+}{
+	"north": {0, -1, 0}, "south": {0, 1, 0},
+	"east": {1, 0, 1}, "west": {-1, 0, 1},
+	"northeast": {1, -1, 2}, "southwest": {-1, 1, 2},
+	"northwest": {-1, -1, 3}, "southeast": {1, 1, 3},
+}
+
+// Text draws the minimap north up. Rooms sit on every other cell so exits
+// can be drawn between them.
+func (mm *Minimap) Text(style TextStyle) string {
+	if len(mm.Rooms) == 0 {
+		return "(no map yet)"
+	}
+
+	size := mm.Radius*4 + 1
+	grid := make([][]rune, size)
+	for y := range grid {
+		grid[y] = []rune(strings.Repeat(" ", size))
+	}
+	centre := mm.Radius * 2
+	glyphs := [4]rune{style.Vertical, style.Horizontal, style.Rising, style.Falling}
+
+	for _, room := range mm.Rooms {
+		x, y := centre+room.DX*2, centre-room.DY*2
+
+		for direction, target := range room.Exits {
+			if target == "" {
+				continue
+			}
+			c, ok := textConnectors[normaliseIntent(direction)]
+			if !ok {
+				continue
+			}
+			cx, cy := x+c.dx, y+c.dy
+			if cx >= 0 && cy >= 0 && cx < size && cy < size && grid[cy][cx] == ' ' {
+				grid[cy][cx] = glyphs[c.kind]
+			}
+		}
+
+		switch {
+		case room.Current:
+			grid[y][x] = style.Current
+		case room.VisitCount > 1:
+			grid[y][x] = style.Revisited
+		default:
+			grid[y][x] = style.Room
+		}
+	}
+
+	lines := make([]string, 0, size+2)
+	if style.TopLeft != 0 {
+		border := strings.Repeat(string(style.FrameHorizontal), size+2)
+		lines = append(lines, string(style.TopLeft)+border+string(style.TopRight))
+		for _, row := range grid {
+			lines = append(lines, string(style.FrameVertical)+" "+string(row)+" "+string(style.FrameVertical))
+		}
+		lines = append(lines, string(style.BottomLeft)+border+string(style.BottomRight))
+		return strings.Join(lines, "\n")
+	}
+	for _, row := range grid {
+		lines = append(lines, strings.TrimRight(string(row), " "))
+	}
+	return strings.Join(lines, "\n")
+}
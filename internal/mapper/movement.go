@@ -0,0 +1,103 @@
+package mapper
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+// MovementTimeout is how long a movement command waits for a room before
+// it's taken to have gone nowhere, so an unanswered one can't link the next
+// room seen, such as after a "look"
+const MovementTimeout = 10 * time.Second
+
+// pendingMove is a movement command sent but not yet answered with a room
+// or a refusal
+type pendingMove struct {
+	direction string
+	sent      time.Time
+}
+
+// movementFailureRegexes match what servers say when a move doesn't happen,
+// matched against a line without colours, in lower case
+var movementFailureRegexes = []*regexp.Regexp{
+	regexp.MustCompile(`^(?:alas, )?you (?:can't|cannot|can not) go (?:that way|there|in that direction|\w+ from here)`),
+	regexp.MustCompile(`^there (?:is|'s) no (?:exit|way)\b`),
+	regexp.MustCompile(`^no (?:exit|way) (?:that way|in that direction)`),
+	regexp.MustCompile(`^the [\w ]+ (?:is|are|seems to be) (?:closed|locked)`),
+	regexp.MustCompile(`^you are too (?:exhausted|tired)`),
+	regexp.MustCompile(`^no way! you're fighting`),
+	regexp.MustCompile(`^command '[^']+' is not available`),
+}
+
+// IsMovementFailure reports whether a line is a server refusing a move, like
+// "You can't go that way." or "The door is closed."
+func IsMovementFailure(line string) bool {
+	line = strings.ToLower(strings.TrimSpace(line))
+	for _, failureRegex := range movementFailureRegexes {
+		if failureRegex.MatchString(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// OnMovement should be called when the player issues a movement command.
+// The move waits until a room is parsed before it's used to link rooms.
+func (m *Mapper) OnMovement(direction string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.pending = append(m.pending, pendingMove{direction: direction, sent: time.Now()})
+	logger.Debug("movement command", "direction", direction, "pending", len(m.pending))
+}
+
+// CancelMovement drops the oldest move waiting for a room, as when the
+// server refuses it, returning its direction or "" if none was waiting
+func (m *Mapper) CancelMovement() string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.dropExpiredMoves()
+	if len(m.pending) == 0 {
+		return ""
+	}
+	direction := m.pending[0].direction
+	m.pending = m.pending[1:]
+	logger.Debug("movement refused", "direction", direction)
+	return direction
+}
+
+// PendingMovements returns the directions waiting for a room, oldest first
+func (m *Mapper) PendingMovements() []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.dropExpiredMoves()
+	directions := make([]string, len(m.pending))
+	for i, move := range m.pending {
+		directions[i] = move.direction
+	}
+	return directions
+}
+
+// confirmMovement commits the oldest waiting move as LastDirection now a
+// room has been parsed, or clears it if nothing was waiting; the caller
+// must hold the lock
+func (m *Mapper) confirmMovement() {
+	m.dropExpiredMoves()
+	m.LastDirection = ""
+	if len(m.pending) > 0 {
+		m.LastDirection = m.pending[0].direction
+		m.pending = m.pending[1:]
+	}
+}
+
+// dropExpiredMoves forgets moves that waited longer than MovementTimeout;
+// the caller must hold the lock
+func (m *Mapper) dropExpiredMoves() {
+	for len(m.pending) > 0 && time.Since(m.pending[0].sent) > MovementTimeout {
+		logger.Debug("movement timed out", "direction", m.pending[0].direction)
+		m.pending = m.pending[1:]
+	}
+}
@@ -0,0 +1,294 @@
+package mapper
+
+import (
+	"container/heap"
+	"fmt"
+	"maps"
+	"sort"
+	"strings"
+)
+
+// MinimapRoom is a room positioned relative to the player for the minimap
+type MinimapRoom struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	DX         int               `json:"dx"` // Offset from the current room
+	DY         int               `json:"dy"`
+	Exits      map[string]string `json:"exits"`
+	VisitCount int               `json:"visit_count"`
+	Current    bool              `json:"current"`
+}
+
+// Minimap is the area around the current room on the current level
+type Minimap struct {
+	Radius        int           `json:"radius"`
+	CurrentRoomID string        `json:"current_room_id"`
+	Z             int           `json:"z"`
+	Rooms         []MinimapRoom `json:"rooms"`
+}
+
+// FindPath returns the directions leading from one room to another, the
+// shortest over explored exits. An empty path means from and to are the
+// same room.
+func (g *RoomGraph) FindPath(from, to string) ([]string, bool) {
+	walk, found := g.findWalk(from, to)
+	if !found {
+		return nil, false
+	}
+	return walk.Directions, true
+}
+
+// Walk is a route through the map: each step's direction and the room the
+// map says it leads to
+type Walk struct {
+	Directions []string `json:"directions"`
+	Rooms      []string `json:"rooms"` // ID of the room each step arrives in
+}
+
+// findWalk searches with A*, guided by the rooms' coordinates. Every exit
+// costs one step, but custom exits, up and down, and rooms placed away
+// from where their exits point can jump further than one square, so the
+// distance left is divided by the longest jump any exit makes. That never
+// overestimates the steps left and the walk found is a shortest one.
+func (g *RoomGraph) findWalk(from, to string) (*Walk, bool) {
+	goal := g.GetRoom(to)
+	if g.GetRoom(from) == nil || goal == nil {
+		return nil, false
+	}
+	if from == to {
+		return &Walk{Directions: []string{}, Rooms: []string{}}, true
+	}
+
+	reach := g.longestJump()
+	estimate := func(room *Room) int {
+		return (distance(room, goal) + reach - 1) / reach
+	}
+	type step struct {
+		previous  string
+		direction string
+		cost      int // Steps from the start
+	}
+	reached := map[string]step{from: {}}
+	open := &searchQueue{}
+	heap.Push(open, searchNode{id: from, priority: estimate(g.GetRoom(from))})
+
+	for open.Len() > 0 {
+		node := heap.Pop(open).(searchNode)
+		if node.id == to {
+			// Walk back to the start to build the route
+			walk := &Walk{}
+			for id := to; id != from; id = reached[id].previous {
+				walk.Directions = append([]string{reached[id].direction}, walk.Directions...)
+				walk.Rooms = append([]string{id}, walk.Rooms...)
+			}
+			return walk, true
+		}
+		cost := reached[node.id].cost
+		if node.priority-estimate(g.GetRoom(node.id)) > cost {
+			continue // A shorter way here was found after this was queued
+		}
+
+		// Sorted so equally short routes come out the same every time
+		room := g.GetRoom(node.id)
+		directions := make([]string, 0, len(room.Exits))
+		for direction := range room.Exits {
+			directions = append(directions, direction)
+		}
+		sort.Strings(directions)
+		for _, direction := range directions {
+			next := g.GetRoom(room.Exits[direction])
+			if next == nil || next.ID == from {
+				continue
+			}
+			if previous, seen := reached[next.ID]; seen && previous.cost <= cost+1 {
+				continue
+			}
+			reached[next.ID] = step{previous: node.id, direction: direction, cost: cost + 1}
+			heap.Push(open, searchNode{id: next.ID, priority: cost + 1 + estimate(next)})
+		}
+	}
+
+	return nil, false
+}
+
+// longestJump returns the furthest any exit moves across the map, at least
+// one square
+func (g *RoomGraph) longestJump() int {
+	longest := 1
+	for _, room := range g.Rooms {
+		for _, id := range room.Exits {
+			if next := g.GetRoom(id); next != nil {
+				longest = max(longest, distance(room, next))
+			}
+		}
+	}
+	return longest
+}
+
+// distance is the most squares apart two rooms are on any axis
+func distance(a, b *Room) int {
+	return max(abs(a.X-b.X), abs(a.Y-b.Y), abs(a.Z-b.Z))
+}
+
+// searchNode is a room waiting to be explored, with its estimated total cost
+type searchNode struct {
+	id       string
+	priority int
+	order    int // Tie-break, first queued first
+}
+
+// searchQueue is a priority queue of rooms for findWalk
+type searchQueue struct {
+	nodes []searchNode
+	count int
+}
+
+func (q *searchQueue) Len() int { return len(q.nodes) }
+func (q *searchQueue) Less(i, j int) bool {
+	if q.nodes[i].priority != q.nodes[j].priority {
+		return q.nodes[i].priority < q.nodes[j].priority
+	}
+	return q.nodes[i].order < q.nodes[j].order
+}
+func (q *searchQueue) Swap(i, j int) { q.nodes[i], q.nodes[j] = q.nodes[j], q.nodes[i] }
+func (q *searchQueue) Push(x any) {
+	node := x.(searchNode)
+	node.order = q.count
+	q.count++
+	q.nodes = append(q.nodes, node)
+}
+func (q *searchQueue) Pop() any {
+	node := q.nodes[len(q.nodes)-1]
+	q.nodes = q.nodes[:len(q.nodes)-1]
+	return node
+}
+
+// WalkTo returns the route from the current room to a mapped room, for
+// walking it a step at a time
+func (m *Mapper) WalkTo(roomID string) (*Walk, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.CurrentRoomID == "" {
+		return nil, fmt.Errorf("current room is unknown")
+	}
+	if m.Graph.GetRoom(roomID) == nil {
+		return nil, fmt.Errorf("room %s is not mapped", roomID)
+	}
+	walk, found := m.Graph.findWalk(m.CurrentRoomID, roomID)
+	if !found {
+		return nil, fmt.Errorf("no known route to room %s", roomID)
+	}
+	return walk, nil
+}
+
+// PathTo finds directions from the current room to a target given as a room
+// ID or a room name (case-insensitive, nearest match wins)
+func (m *Mapper) PathTo(target string) ([]string, error) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if m.CurrentRoomID == "" {
+		return nil, fmt.Errorf("current room is unknown")
+	}
+
+	if m.Graph.GetRoom(target) != nil {
+		path, found := m.Graph.FindPath(m.CurrentRoomID, target)
+		if !found {
+			return nil, fmt.Errorf("no known route to room %s", target)
+		}
+		return path, nil
+	}
+
+	var best []string
+	matched := false
+	for _, room := range m.Graph.Rooms {
+		if !strings.EqualFold(room.Name, target) {
+			continue
+		}
+		matched = true
+		if path, found := m.Graph.FindPath(m.CurrentRoomID, room.ID); found && (best == nil || len(path) < len(best)) {
+			best = path
+		}
+	}
+
+	if !matched {
+		return nil, fmt.Errorf("no mapped room called %q", target)
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no known route to %q", target)
+	}
+	return best, nil
+}
+
+// GetMinimap returns the rooms within radius of the current room on its level
+func (m *Mapper) GetMinimap(radius int) *Minimap {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if radius <= 0 {
+		radius = 2
+	}
+
+	minimap := &Minimap{
+		Radius:        radius,
+		CurrentRoomID: m.CurrentRoomID,
+		Rooms:         []MinimapRoom{},
+	}
+
+	current := m.Graph.GetRoom(m.CurrentRoomID)
+	if current == nil {
+		return minimap
+	}
+	minimap.Z = current.Z
+
+	// Looked up cell by cell, so big maps cost no more than small ones
+	for dy := radius; dy >= -radius; dy-- {
+		for dx := -radius; dx <= radius; dx++ {
+			for _, room := range m.Graph.RoomsAt(current.X+dx, current.Y+dy, current.Z) {
+				minimap.Rooms = append(minimap.Rooms, MinimapRoom{
+					ID:         room.ID,
+					Name:       room.Name,
+					DX:         dx,
+					DY:         dy,
+					Exits:      maps.Clone(room.Exits),
+					VisitCount: room.VisitCount,
+					Current:    room.ID == current.ID,
+				})
+			}
+		}
+	}
+
+	return minimap
+}
+
+// SetRoomPrompt sets the image prompt additions kept with a room
+func (m *Mapper) SetRoomPrompt(roomID, additions string) error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	room := m.Graph.GetRoom(roomID)
+	if room == nil {
+		return fmt.Errorf("room %s is not mapped", roomID)
+	}
+	room.PromptAdditions = strings.TrimSpace(additions)
+	return nil
+}
+
+// RoomPrompt returns the image prompt additions kept with a room
+func (m *Mapper) RoomPrompt(roomID string) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if room := m.Graph.GetRoom(roomID); room != nil {
+		return room.PromptAdditions
+	}
+	return ""
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -0,0 +1,177 @@
+package mapper
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// layout is a room for testGraph: where it is and where its exits lead
+type layout struct {
+	x, y, z int
+	exits   map[string]string
+}
+
+// testGraph builds a graph whose room IDs are also their names
+func testGraph(rooms map[string]layout) *RoomGraph {
+	g := NewRoomGraph()
+	for id, room := range rooms {
+		exits := room.exits
+		if exits == nil {
+			exits = map[string]string{}
+		}
+		g.PlaceRoom(&Room{ID: id, Name: id, X: room.x, Y: room.y, Z: room.z, Exits: exits})
+	}
+	return g
+}
+
+func TestFindWalk(t *testing.T) {
+	tests := []struct {
+		name     string
+		rooms    map[string]layout
+		from, to string
+		want     []string // nil for no route
+	}{
+		{"same room", map[string]layout{"a": {}}, "a", "a", []string{}},
+		{"straight line", map[string]layout{
+			"a": {0, 0, 0, map[string]string{"east": "b"}},
+			"b": {1, 0, 0, map[string]string{"east": "c", "west": "a"}},
+			"c": {2, 0, 0, map[string]string{"west": "b"}},
+		}, "a", "c", []string{"east", "east"}},
+		{"one-way exit", map[string]layout{
+			"a": {0, 0, 0, map[string]string{"east": "b"}},
+			"b": {1, 0, 0, nil},
+		}, "b", "a", nil},
+		{"unknown room", map[string]layout{"a": {}}, "a", "nowhere", nil},
+		{"unreachable", map[string]layout{"a": {}, "b": {1, 0, 0, nil}}, "a", "b", nil},
+		// The portal's far end is far from both rooms on the map, which
+		// misleads a search guided by coordinates into the three-step way
+		{"custom exit shortcut", map[string]layout{
+			"start":  {0, 0, 0, map[string]string{"east": "a", "enter portal": "portal"}},
+			"a":      {1, 0, 0, map[string]string{"north": "b"}},
+			"b":      {1, 1, 0, map[string]string{"southeast": "goal"}},
+			"portal": {50, 0, 0, map[string]string{"enter portal": "goal"}},
+			"goal":   {2, 0, 0, nil},
+		}, "start", "goal", []string{"enter portal", "enter portal"}},
+		// Up then down lands far across the map from a tower's top
+		{"up and down", map[string]layout{
+			"foot":  {0, 0, 0, map[string]string{"up": "top", "east": "e1"}},
+			"top":   {0, 0, 1, map[string]string{"down": "far"}},
+			"e1":    {1, 0, 0, map[string]string{"east": "e2"}},
+			"e2":    {2, 0, 0, map[string]string{"east": "far"}},
+			"far":   {9, 0, 0, nil},
+			"other": {3, 0, 0, nil},
+		}, "foot", "far", []string{"up", "down"}},
+		{"equal routes pick the same", map[string]layout{
+			"a": {0, 0, 0, map[string]string{"east": "b", "north": "c"}},
+			"b": {1, 0, 0, map[string]string{"north": "d"}},
+			"c": {0, 1, 0, map[string]string{"east": "d"}},
+			"d": {1, 1, 0, nil},
+		}, "a", "d", []string{"east", "north"}},
+		{"loop back to start", map[string]layout{
+			"a": {0, 0, 0, map[string]string{"east": "b"}},
+			"b": {1, 0, 0, map[string]string{"west": "a", "east": "c"}},
+			"c": {2, 0, 0, map[string]string{"west": "b"}},
+		}, "c", "a", []string{"west", "west"}},
+	}
+	for _, test := range tests {
+		walk, found := testGraph(test.rooms).findWalk(test.from, test.to)
+		if test.want == nil {
+			if found {
+				t.Errorf("%s: found %v, want no route", test.name, walk.Directions)
+			}
+			continue
+		}
+		if !found {
+			t.Errorf("%s: no route, want %v", test.name, test.want)
+			continue
+		}
+		if !reflect.DeepEqual(walk.Directions, test.want) {
+			t.Errorf("%s: directions = %v, want %v", test.name, walk.Directions, test.want)
+		}
+		if len(walk.Rooms) != len(walk.Directions) {
+			t.Errorf("%s: %d rooms for %d directions", test.name, len(walk.Rooms), len(walk.Directions))
+		} else if len(walk.Rooms) > 0 && walk.Rooms[len(walk.Rooms)-1] != test.to {
+			t.Errorf("%s: walk ends in %s, want %s", test.name, walk.Rooms[len(walk.Rooms)-1], test.to)
+		}
+	}
+}
+
+// TestFindWalkShortest checks every route on a grid with random shortcuts
+// against the distances a plain breadth-first count gives
+func TestFindWalkShortest(t *testing.T) {
+	const size = 6
+	rooms := make(map[string]layout)
+	id := func(x, y int) string { return fmt.Sprintf("%d,%d", x, y) }
+	for x := 0; x < size; x++ {
+		for y := 0; y < size; y++ {
+			exits := map[string]string{}
+			if x+1 < size {
+				exits["east"] = id(x+1, y)
+			}
+			if y+1 < size {
+				exits["north"] = id(x, y+1)
+			}
+			// One-way shortcuts that jump across the grid
+			if (x*7+y*3)%5 == 0 {
+				exits["climb"] = id((x*3+1)%size, (y*5+2)%size)
+			}
+			rooms[id(x, y)] = layout{x, y, 0, exits}
+		}
+	}
+	g := testGraph(rooms)
+
+	for from := range g.Rooms {
+		distances := map[string]int{from: 0}
+		queue := []string{from}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for _, next := range g.Rooms[current].Exits {
+				if _, seen := distances[next]; !seen {
+					distances[next] = distances[current] + 1
+					queue = append(queue, next)
+				}
+			}
+		}
+
+		for to := range g.Rooms {
+			walk, found := g.findWalk(from, to)
+			distance, reachable := distances[to]
+			if found != reachable {
+				t.Errorf("%s to %s: found = %v, want %v", from, to, found, reachable)
+				continue
+			}
+			if found && len(walk.Directions) != distance {
+				t.Errorf("%s to %s: %d steps, want %d", from, to, len(walk.Directions), distance)
+			}
+		}
+	}
+}
+
+func TestPathTo(t *testing.T) {
+	m := NewMapper()
+	m.Graph = testGraph(map[string]layout{
+		"a":      {0, 0, 0, map[string]string{"east": "b", "west": "near"}},
+		"b":      {1, 0, 0, map[string]string{"east": "far"}},
+		"far":    {2, 0, 0, nil},
+		"near":   {-1, 0, 0, nil},
+		"island": {5, 5, 0, nil},
+	})
+	m.Graph.Rooms["far"].Name = "Well"
+	m.Graph.Rooms["near"].Name = "well"
+	m.CurrentRoomID = "a"
+
+	if path, err := m.PathTo("b"); err != nil || !reflect.DeepEqual(path, []string{"east"}) {
+		t.Errorf("PathTo(b) = %v, %v, want [east]", path, err)
+	}
+	if path, err := m.PathTo("WELL"); err != nil || !reflect.DeepEqual(path, []string{"west"}) {
+		t.Errorf("PathTo(WELL) = %v, %v, want the nearer well, [west]", path, err)
+	}
+	if _, err := m.PathTo("island"); err == nil {
+		t.Error("PathTo(island) found a route to an unconnected room")
+	}
+	if _, err := m.PathTo("Nowhere"); err == nil {
+		t.Error("PathTo(Nowhere) found an unmapped room")
+	}
+}
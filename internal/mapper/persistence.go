@@ -3,9 +3,11 @@ package mapper
 import (
 	"encoding/json"
 	"fmt"
-	"log"
 	"os"
 	"path/filepath"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/safefile"
 )
 
 // MapData represents the serialisable map structure
@@ -14,6 +16,7 @@ type MapData struct {
 	ServerName    string     `json:"server_name"`
 	Graph         *RoomGraph `json:"graph"`
 	CurrentRoomID string     `json:"current_room_id"`
+	Fingerprint   string     `json:"fingerprint,omitempty"` // How room IDs were made, FingerprintDescription if empty
 }
 
 const (
@@ -22,13 +25,29 @@ const (
 	DefaultMapFile = "default.json"
 )
 
+// SetDirectory changes where maps are saved and loaded
+func (m *Mapper) SetDirectory(dir string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	m.dir = dir
+}
+
+// directory returns the map directory; the caller must hold the lock
+func (m *Mapper) directory() string {
+	if m.dir == "" {
+		return MapCacheDir
+	}
+	return m.dir
+}
+
 // SaveMap saves the current map to disk
 func (m *Mapper) SaveMap(serverName string) error {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
 	// Ensure cache directory exists
-	if err := os.MkdirAll(MapCacheDir, 0755); err != nil {
+	if err := os.MkdirAll(m.directory(), 0755); err != nil {
 		return fmt.Errorf("failed to create map cache directory: %w", err)
 	}
 
@@ -38,17 +57,10 @@ func (m *Mapper) SaveMap(serverName string) error {
 		ServerName:    serverName,
 		Graph:         m.Graph,
 		CurrentRoomID: m.CurrentRoomID,
+		Fingerprint:   m.currentFingerprint(),
 	}
 
-	// Determine filename
-	filename := sanitiseFilename(serverName)
-	if filename == "" {
-		filename = DefaultMapFile
-	} else {
-		filename = filename + ".json"
-	}
-
-	filepath := filepath.Join(MapCacheDir, filename)
+	filepath := m.mapPath(serverName)
 
 	// Marshal to JSON with indentation for readability
 	data, err := json.MarshalIndent(mapData, "", "  ")
@@ -56,12 +68,12 @@ func (m *Mapper) SaveMap(serverName string) error {
 		return fmt.Errorf("failed to marshal map data: %w", err)
 	}
 
-	// Write to file
-	if err := os.WriteFile(filepath, data, 0644); err != nil {
+	// Write to file, keeping the last copy in case this one is damaged
+	if err := safefile.WriteWithBackup(filepath, data, 0644); err != nil {
 		return fmt.Errorf("failed to write map file: %w", err)
 	}
 
-	log.Printf("[Mapper] Saved map with %d rooms to %s", m.Graph.GetRoomCount(), filepath)
+	logger.Info("saved map", "rooms", m.Graph.GetRoomCount(), "path", filepath)
 	return nil
 }
 
@@ -70,24 +82,15 @@ func (m *Mapper) LoadMap(serverName string) error {
 	m.mutex.Lock()
 	defer m.mutex.Unlock()
 
-	// Determine filename
-	filename := sanitiseFilename(serverName)
-	if filename == "" {
-		filename = DefaultMapFile
-	} else {
-		filename = filename + ".json"
-	}
-
-	filepath := filepath.Join(MapCacheDir, filename)
+	filepath := m.mapPath(serverName)
+	m.migrateMapFile(serverName, filepath)
 
-	// Check if file exists
-	if _, err := os.Stat(filepath); os.IsNotExist(err) {
-		log.Printf("[Mapper] No existing map found at %s", filepath)
+	// Read file, falling back to the last copy if it's damaged
+	data, err := safefile.ReadFile(filepath, json.Valid)
+	if os.IsNotExist(err) {
+		logger.Info("no existing map", "path", filepath)
 		return nil // Not an error, just no map to load
 	}
-
-	// Read file
-	data, err := os.ReadFile(filepath)
 	if err != nil {
 		return fmt.Errorf("failed to read map file: %w", err)
 	}
@@ -100,16 +103,19 @@ func (m *Mapper) LoadMap(serverName string) error {
 
 	// Version check
 	if mapData.Version != MapVersion {
-		log.Printf("[Mapper] Warning: Map version mismatch (file: %s, expected: %s)",
-			mapData.Version, MapVersion)
+		logger.Warn("map version mismatch", "file", mapData.Version, "expected", MapVersion)
 		// Continue anyway - we can handle minor version differences
 	}
 
 	// Load graph
 	m.Graph = mapData.Graph
 	m.CurrentRoomID = mapData.CurrentRoomID
+	if m.fingerprint, err = ParseFingerprint(mapData.Fingerprint); err != nil {
+		logger.Warn("unknown map fingerprint, using description", "fingerprint", mapData.Fingerprint)
+		m.fingerprint = FingerprintDescription
+	}
 
-	log.Printf("[Mapper] Loaded map with %d rooms from %s", len(m.Graph.Rooms), filepath)
+	logger.Info("loaded map", "rooms", len(m.Graph.Rooms), "path", filepath)
 	return nil
 }
 
@@ -120,6 +126,21 @@ func (m *Mapper) AutoSave(serverName string, intervalSeconds int) {
 
 // ExportMap exports the map to a specific file path (for sharing)
 func (m *Mapper) ExportMap(filepath, serverName string) error {
+	data, err := m.MarshalMap(serverName)
+	if err != nil {
+		return err
+	}
+
+	if err := safefile.WriteFile(filepath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write map file: %w", err)
+	}
+
+	logger.Info("exported map", "path", filepath)
+	return nil
+}
+
+// MarshalMap returns the map as it would be saved, for exporting
+func (m *Mapper) MarshalMap(serverName string) ([]byte, error) {
 	m.mutex.RLock()
 	defer m.mutex.RUnlock()
 
@@ -128,26 +149,18 @@ func (m *Mapper) ExportMap(filepath, serverName string) error {
 		ServerName:    serverName,
 		Graph:         m.Graph,
 		CurrentRoomID: m.CurrentRoomID,
+		Fingerprint:   m.currentFingerprint(),
 	}
 
 	data, err := json.MarshalIndent(mapData, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal map data: %w", err)
-	}
-
-	if err := os.WriteFile(filepath, data, 0644); err != nil {
-		return fmt.Errorf("failed to write map file: %w", err)
+		return nil, fmt.Errorf("failed to marshal map data: %w", err)
 	}
-
-	log.Printf("[Mapper] Exported map to %s", filepath)
-	return nil
+	return data, nil
 }
 
 // ImportMap imports a map from a specific file path
 func (m *Mapper) ImportMap(filepath string) error {
-	m.mutex.Lock()
-	defer m.mutex.Unlock()
-
 	data, err := os.ReadFile(filepath)
 	if err != nil {
 		return fmt.Errorf("failed to read map file: %w", err)
@@ -158,26 +171,88 @@ func (m *Mapper) ImportMap(filepath string) error {
 		return fmt.Errorf("failed to unmarshal map data: %w", err)
 	}
 
+	m.MergeMap(&mapData)
+	logger.Info("imported map", "path", filepath, "rooms", m.RoomCount())
+	return nil
+}
+
+// MergeMap adds another map's rooms and exits, keeping any rooms already
+// mapped. It returns how many rooms were new.
+func (m *Mapper) MergeMap(mapData *MapData) int {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
 	// Merge with existing map (don't overwrite)
 	if m.Graph == nil {
 		m.Graph = NewRoomGraph()
 	}
+	if mapData.Graph == nil {
+		return 0
+	}
+
+	// Rooms from a map made with another fingerprint get IDs made with ours
+	graph := mapData.Graph
+	if fingerprint, err := ParseFingerprint(mapData.Fingerprint); err == nil && fingerprint != m.currentFingerprint() {
+		graph, _ = rekeyGraph(graph, m.currentFingerprint())
+	}
 
-	for id, room := range mapData.Graph.Rooms {
+	added := 0
+	for id, room := range graph.Rooms {
 		if _, exists := m.Graph.Rooms[id]; !exists {
-			m.Graph.Rooms[id] = room
+			m.Graph.PlaceRoom(room)
+			added++
 		}
 	}
 
-	for _, exit := range mapData.Graph.Exits {
+	for _, exit := range graph.Exits {
 		m.Graph.AddExit(exit.From, exit.Direction, exit.To)
 	}
+	return added
+}
 
-	log.Printf("[Mapper] Imported map from %s (now %d rooms)", filepath, len(m.Graph.Rooms))
-	return nil
+// MapFile returns the file a server's map is saved in
+func (m *Mapper) MapFile(serverName string) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	return m.mapPath(serverName)
+}
+
+// mapPath returns where a server's map is saved; the caller must hold the
+// lock
+func (m *Mapper) mapPath(serverName string) string {
+	return filepath.Join(m.directory(), datadir.Namespace(serverName)+".json")
+}
+
+// migrateMapFile renames a map saved under the old file name, which dropped
+// unsafe characters (dots included) rather than replacing them and so could
+// mix up servers. The caller must hold the lock.
+func (m *Mapper) migrateMapFile(serverName, path string) {
+	legacy := sanitiseFilename(serverName)
+	if legacy == "" {
+		legacy = DefaultMapFile
+	} else {
+		legacy = legacy + ".json"
+	}
+	legacy = filepath.Join(m.directory(), legacy)
+	if legacy == path {
+		return
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return
+	}
+	if _, err := os.Stat(legacy); err != nil {
+		return
+	}
+	if err := os.Rename(legacy, path); err != nil {
+		logger.Warn("failed to migrate map file", "from", legacy, "error", err)
+		return
+	}
+	logger.Info("migrated map file", "from", legacy, "to", path)
 }
 
-// sanitiseFilename removes unsafe characters from filename
+// sanitiseFilename removes unsafe characters from filename. It named map
+// files before datadir.Namespace, and is kept to find them.
 func sanitiseFilename(name string) string {
 	// Simple sanitisation - remove path separators and special chars
 	safe := ""
@@ -0,0 +1,146 @@
+package mapper
+
+import (
+	"strings"
+)
+
+// SharedRoom returns a copy of a room with the explored exits into and out
+// of it, for sending to someone else mapping the same server
+func (m *Mapper) SharedRoom(roomID string) (*Room, []*Exit, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	room := m.Graph.GetRoom(roomID)
+	if room == nil {
+		return nil, nil, false
+	}
+	shared := *room
+	shared.Exits = make(map[string]string, len(room.Exits))
+	var exits []*Exit
+	for direction, to := range room.Exits {
+		shared.Exits[direction] = to
+		if to != "" {
+			exits = append(exits, &Exit{From: roomID, Direction: direction, To: to})
+		}
+	}
+	for _, to := range room.Exits {
+		neighbour := m.Graph.GetRoom(to)
+		if neighbour == nil || to == roomID {
+			continue
+		}
+		for direction, back := range neighbour.Exits {
+			if back == roomID {
+				exits = append(exits, &Exit{From: to, Direction: direction, To: roomID})
+			}
+		}
+	}
+	// Images and notes are the player's own
+	shared.ImagePath, shared.Notes, shared.PromptAdditions = "", "", ""
+	return &shared, exits, true
+}
+
+// AddSharedRooms merges rooms someone else mapped. Rooms already on the map
+// keep their details but learn any exits they hadn't explored. New rooms are
+// placed beside a room they link to, since the other map's coordinates
+// start from wherever its player did; only rooms linked to nothing here keep
+// the coordinates they came with. It returns the IDs of rooms added.
+func (m *Mapper) AddSharedRooms(rooms []*Room, exits []*Exit) []string {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if m.Graph == nil {
+		m.Graph = NewRoomGraph()
+	}
+
+	pending := make(map[string]*Room)
+	for _, room := range rooms {
+		if room == nil || room.ID == "" || m.Graph.GetRoom(room.ID) != nil {
+			continue
+		}
+		if room.Exits == nil {
+			room.Exits = make(map[string]string)
+		}
+		room.ImagePath, room.Notes, room.PromptAdditions = "", "", ""
+		room.VisitCount = 0
+		pending[room.ID] = room
+	}
+
+	// Place rooms outwards from the ones already mapped, until no more link.
+	// Free spots are taken first; a room only goes on top of another, offset
+	// and marked uncertain, when there's nowhere else for it.
+	var added []string
+	crowded := false
+	for len(pending) > 0 {
+		progress := false
+		for _, exit := range exits {
+			from, to := m.Graph.GetRoom(exit.From), m.Graph.GetRoom(exit.To)
+			offset, known := DirectionOffsets[strings.ToLower(exit.Direction)]
+			if !known {
+				continue
+			}
+			var room *Room
+			var x, y, z int
+			switch {
+			case from != nil && to == nil && pending[exit.To] != nil:
+				room = pending[exit.To]
+				x, y, z = from.X+offset[0], from.Y+offset[1], from.Z+offset[2]
+			case to != nil && from == nil && pending[exit.From] != nil:
+				room = pending[exit.From]
+				x, y, z = to.X-offset[0], to.Y-offset[1], to.Z-offset[2]
+			default:
+				continue
+			}
+			if m.Graph.FindRoomAt(x, y, z) != nil {
+				if !crowded {
+					continue
+				}
+				// Same as mapping it by walking: offset and leave for review
+				x++
+				room.Uncertain = true
+			}
+			room.X, room.Y, room.Z = x, y, z
+			m.Graph.PlaceRoom(room)
+			delete(pending, room.ID)
+			added = append(added, room.ID)
+			progress = true
+			if crowded {
+				// One at a time, in case it frees up spots for the rest
+				break
+			}
+		}
+		if progress {
+			crowded = false
+		} else if !crowded {
+			crowded = true
+		} else {
+			break
+		}
+	}
+	for id, room := range pending {
+		if m.Graph.FindRoomAt(room.X, room.Y, room.Z) != nil {
+			room.Uncertain = true
+		}
+		m.Graph.PlaceRoom(room)
+		added = append(added, id)
+	}
+
+	for _, exit := range exits {
+		if exit.To == "" {
+			continue
+		}
+		from := m.Graph.GetRoom(exit.From)
+		if from == nil {
+			continue
+		}
+		direction := strings.ToLower(exit.Direction)
+		if from.Exits[direction] == "" {
+			from.Exits[direction] = exit.To
+			m.Graph.AddExit(exit.From, direction, exit.To)
+		}
+	}
+
+	if len(added) > 0 {
+		logger.Info("added shared rooms", "rooms", len(added))
+	}
+	return added
+}
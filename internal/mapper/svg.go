@@ -0,0 +1,183 @@
+package mapper
+
+import (
+	"fmt"
+	"html"
+	"sort"
+	"strings"
+)
+
+// DefaultSVGCellSize is the spacing between room centres when none is given
+const DefaultSVGCellSize = 40
+
+// SVGOptions choose what RenderSVG draws
+type SVGOptions struct {
+	Level          int    `json:"level"`            // Z level drawn; rooms on others are left out
+	CurrentRoomID  string `json:"current_room_id"`  // Highlighted as where the player is
+	SelectedRoomID string `json:"selected_room_id"` // Outlined, e.g. when picked in the GUI
+	CellSize       int    `json:"cell_size"`        // Pixels between room centres, DefaultSVGCellSize if 0
+	Labels         bool   `json:"labels"`           // Write each room's name beneath it
+}
+
+// svgColours match the exported drawings so the live map looks the same
+const (
+	svgBackground = "#1e1e24"
+	svgRoom       = "#3a7bd5"
+	svgCurrent    = "#e8a33d"
+	svgEdge       = "#6c6c78"
+	svgOutline    = "#dcdce4"
+	svgSelected   = "#e94560"
+	svgText       = "#9a9aa6"
+)
+
+// RenderSVG draws one level of a map as SVG, north up: rooms as squares,
+// explored exits as lines between them, unexplored ones as dashed stubs and
+// exits up or down as arrows. Each room is a group with class "room" and
+// its ID in data-id, so a page can tell which was clicked.
+func RenderSVG(graph *RoomGraph, options SVGOptions) string {
+	cell := options.CellSize
+	if cell <= 0 {
+		cell = DefaultSVGCellSize
+	}
+	size := cell / 2
+
+	var rooms []*Room
+	if graph != nil {
+		for _, room := range graph.Rooms {
+			if room.Z == options.Level {
+				rooms = append(rooms, room)
+			}
+		}
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].ID < rooms[j].ID })
+
+	var b strings.Builder
+	if len(rooms) == 0 {
+		fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`, cell*4, cell, cell*4, cell)
+		fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`, svgBackground)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s" font-family="sans-serif" font-size="12" text-anchor="middle">No rooms on level %d</text></svg>`,
+			cell*2, cell/2+4, svgText, options.Level)
+		return b.String()
+	}
+
+	minX, maxX, minY, maxY := rooms[0].X, rooms[0].X, rooms[0].Y, rooms[0].Y
+	for _, room := range rooms {
+		minX, maxX = min(minX, room.X), max(maxX, room.X)
+		minY, maxY = min(minY, room.Y), max(maxY, room.Y)
+	}
+	labelSpace := 0
+	if options.Labels {
+		labelSpace = cell / 2
+	}
+	width := (maxX-minX+1)*cell + cell
+	height := (maxY-minY+1)*cell + cell + labelSpace
+	centre := func(room *Room) (int, int) {
+		return (room.X-minX)*cell + cell, (maxY-room.Y)*cell + cell
+	}
+
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif">`+"\n", width, height, width, height)
+	fmt.Fprintf(&b, `<rect width="100%%" height="100%%" fill="%s"/>`+"\n", svgBackground)
+
+	// Exits first, so rooms are drawn over their ends
+	seen := make(map[[2]string]bool)
+	for _, room := range rooms {
+		x, y := centre(room)
+		for _, direction := range sortedDirections(room) {
+			offset, flat := DirectionOffsets[NormaliseDirection(direction)]
+			if !flat || offset[2] != 0 {
+				continue // Up and down are marked on the room
+			}
+			target := graph.GetRoom(room.Exits[direction])
+			if target == nil {
+				// Unexplored, or leading off the map
+				fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="2" stroke-dasharray="3 3"/>`+"\n",
+					x, y, x+offset[0]*size, y-offset[1]*size, svgEdge)
+				continue
+			}
+			if target.Z != room.Z {
+				continue
+			}
+			key := [2]string{room.ID, target.ID}
+			if room.ID > target.ID {
+				key = [2]string{target.ID, room.ID}
+			}
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			tx, ty := centre(target)
+			fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="2"/>`+"\n", x, y, tx, ty, svgEdge)
+		}
+	}
+
+	for _, room := range rooms {
+		x, y := centre(room)
+		fill, stroke, strokeWidth := svgRoom, svgOutline, 1
+		if room.ID == options.CurrentRoomID {
+			fill = svgCurrent
+		}
+		if room.ID == options.SelectedRoomID {
+			stroke, strokeWidth = svgSelected, 3
+		}
+
+		fmt.Fprintf(&b, `<g class="room" data-id="%s">`, html.EscapeString(room.ID))
+		fmt.Fprintf(&b, "<title>%s</title>", html.EscapeString(room.Name))
+		fmt.Fprintf(&b, `<rect x="%d" y="%d" width="%d" height="%d" rx="3" fill="%s" stroke="%s" stroke-width="%d"/>`,
+			x-size/2, y-size/2, size, size, fill, stroke, strokeWidth)
+		if marks := levelMarks(room); marks != "" {
+			fmt.Fprintf(&b, `<text x="%d" y="%d" fill="#ffffff" font-size="10" text-anchor="middle">%s</text>`, x, y+4, marks)
+		}
+		if options.Labels {
+			fmt.Fprintf(&b, `<text x="%d" y="%d" fill="%s" font-size="9" text-anchor="middle">%s</text>`,
+				x, y+size/2+11, svgText, html.EscapeString(shortName(room.Name, 18)))
+		}
+		b.WriteString("</g>\n")
+	}
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// SVG draws one level of the map as RenderSVG does, highlighting the
+// current room unless options name another
+func (m *Mapper) SVG(options SVGOptions) string {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	if options.CurrentRoomID == "" {
+		options.CurrentRoomID = m.CurrentRoomID
+	}
+	return RenderSVG(m.Graph, options)
+}
+
+// sortedDirections returns a room's exits in a stable order
+func sortedDirections(room *Room) []string {
+	directions := make([]string, 0, len(room.Exits))
+	for direction := range room.Exits {
+		directions = append(directions, direction)
+	}
+	sort.Strings(directions)
+	return directions
+}
+
+// levelMarks shows whether a room has exits up or down
+func levelMarks(room *Room) string {
+	up, down := "", ""
+	for direction := range room.Exits {
+		switch NormaliseDirection(direction) {
+		case "up":
+			up = "▲"
+		case "down":
+			down = "▼"
+		}
+	}
+	return up + down
+}
+
+// shortName cuts a room name to fit under its square
+func shortName(name string, limit int) string {
+	runes := []rune(name)
+	if len(runes) <= limit {
+		return name
+	}
+	return string(runes[:limit-1]) + "…"
+}
@@ -0,0 +1,299 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Registry holds metrics and writes them in the Prometheus text format.
+// It only covers what the client needs: counters, labelled counters, gauges
+// read on scrape, and histograms.
+type Registry struct {
+	mutex   sync.RWMutex
+	metrics []metric
+}
+
+// metric is anything the registry can write
+type metric interface {
+	name() string
+	write(w io.Writer)
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// register adds a metric, keeping them sorted by name for stable output
+func (r *Registry) register(m metric) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.metrics = append(r.metrics, m)
+	sort.Slice(r.metrics, func(i, j int) bool {
+		return r.metrics[i].name() < r.metrics[j].name()
+	})
+}
+
+// Write writes every metric in the Prometheus text exposition format
+func (r *Registry) Write(w io.Writer) {
+	r.mutex.RLock()
+	metrics := r.metrics
+	r.mutex.RUnlock()
+
+	for _, m := range metrics {
+		m.write(w)
+	}
+}
+
+// ServeHTTP serves the registry, so it can be mounted at /metrics
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.Write(w)
+}
+
+// writeHeader writes the HELP and TYPE lines for a metric
+func writeHeader(w io.Writer, name, help, kind string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+// formatFloat formats a value the way Prometheus expects
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return fmt.Sprintf("%g", v)
+}
+
+// escapeLabel escapes a label value
+func escapeLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	mutex sync.Mutex
+	n     string
+	help  string
+	value float64
+}
+
+// Counter registers a new counter
+func (r *Registry) Counter(name, help string) *Counter {
+	c := &Counter{n: name, help: help}
+	r.register(c)
+	return c
+}
+
+// Inc adds one
+func (c *Counter) Inc() {
+	c.Add(1)
+}
+
+// Add adds delta, which must not be negative
+func (c *Counter) Add(delta float64) {
+	if delta < 0 {
+		return
+	}
+	c.mutex.Lock()
+	c.value += delta
+	c.mutex.Unlock()
+}
+
+// Value returns the current count
+func (c *Counter) Value() float64 {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.value
+}
+
+func (c *Counter) name() string { return c.n }
+
+func (c *Counter) write(w io.Writer) {
+	writeHeader(w, c.n, c.help, "counter")
+	fmt.Fprintf(w, "%s %s\n", c.n, formatFloat(c.Value()))
+}
+
+// CounterVec is a family of counters split by one label
+type CounterVec struct {
+	mutex  sync.Mutex
+	n      string
+	help   string
+	label  string
+	values map[string]float64
+}
+
+// CounterVec registers a new labelled counter
+func (r *Registry) CounterVec(name, help, label string) *CounterVec {
+	c := &CounterVec{n: name, help: help, label: label, values: make(map[string]float64)}
+	r.register(c)
+	return c
+}
+
+// Inc adds one to the counter for a label value
+func (c *CounterVec) Inc(value string) {
+	c.mutex.Lock()
+	c.values[value]++
+	c.mutex.Unlock()
+}
+
+func (c *CounterVec) name() string { return c.n }
+
+func (c *CounterVec) write(w io.Writer) {
+	c.mutex.Lock()
+	values := make([]string, 0, len(c.values))
+	for value := range c.values {
+		values = append(values, value)
+	}
+	sort.Strings(values)
+	counts := make([]float64, len(values))
+	for i, value := range values {
+		counts[i] = c.values[value]
+	}
+	c.mutex.Unlock()
+
+	writeHeader(w, c.n, c.help, "counter")
+	for i, value := range values {
+		fmt.Fprintf(w, "%s{%s=\"%s\"} %s\n", c.n, c.label, escapeLabel(value), formatFloat(counts[i]))
+	}
+}
+
+// funcMetric is a gauge or counter read from a function when scraped
+type funcMetric struct {
+	n    string
+	help string
+	kind string
+	fn   func() float64
+}
+
+// GaugeFunc registers a gauge whose value comes from fn at scrape time
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{n: name, help: help, kind: "gauge", fn: fn})
+}
+
+// CounterFunc registers a counter kept elsewhere, read from fn at scrape
+// time
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.register(&funcMetric{n: name, help: help, kind: "counter", fn: fn})
+}
+
+func (f *funcMetric) name() string { return f.n }
+
+func (f *funcMetric) write(w io.Writer) {
+	writeHeader(w, f.n, f.help, f.kind)
+	fmt.Fprintf(w, "%s %s\n", f.n, formatFloat(f.fn()))
+}
+
+// Histogram counts observations into cumulative buckets
+type Histogram struct {
+	mutex   sync.Mutex
+	n       string
+	help    string
+	buckets []float64 // Upper bounds, ascending
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+// Histogram registers a new histogram with the given bucket upper bounds
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &Histogram{n: name, help: help, buckets: sorted, counts: make([]uint64, len(sorted))}
+	r.register(h)
+	return h
+}
+
+// Observe records one value
+func (h *Histogram) Observe(v float64) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+// ObserveDuration records a duration in seconds
+func (h *Histogram) ObserveDuration(d time.Duration) {
+	h.Observe(d.Seconds())
+}
+
+func (h *Histogram) name() string { return h.n }
+
+func (h *Histogram) write(w io.Writer) {
+	h.mutex.Lock()
+	counts := append([]uint64(nil), h.counts...)
+	sum, count := h.sum, h.count
+	h.mutex.Unlock()
+
+	writeHeader(w, h.n, h.help, "histogram")
+	for i, bound := range h.buckets {
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.n, formatFloat(bound), counts[i])
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.n, count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.n, formatFloat(sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.n, count)
+}
+
+// Rate tracks events per second over a sliding window, for a gauge that
+// reads sensibly without a Prometheus rate() query
+type Rate struct {
+	mutex   sync.Mutex
+	window  time.Duration
+	buckets map[int64]int // Unix second to count
+}
+
+// NewRate creates a rate over window
+func NewRate(window time.Duration) *Rate {
+	return &Rate{window: window, buckets: make(map[int64]int)}
+}
+
+// Mark records one event now
+func (r *Rate) Mark() {
+	now := time.Now().Unix()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.buckets[now]++
+	r.prune(now)
+}
+
+// PerSecond returns the average events per second over the window
+func (r *Rate) PerSecond() float64 {
+	now := time.Now().Unix()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.prune(now)
+	total := 0
+	for _, n := range r.buckets {
+		total += n
+	}
+	return float64(total) / r.window.Seconds()
+}
+
+// prune drops buckets older than the window; the caller must hold the lock
+func (r *Rate) prune(now int64) {
+	oldest := now - int64(r.window.Seconds())
+	for second := range r.buckets {
+		if second <= oldest {
+			delete(r.buckets, second)
+		}
+	}
+}
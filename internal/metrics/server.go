@@ -0,0 +1,118 @@
+package metrics
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("Metrics")
+
+// Settings configures the metrics endpoint
+type Settings struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address"` // host:port to listen on
+}
+
+// DefaultSettings keeps the endpoint off and local only
+func DefaultSettings() Settings {
+	return Settings{
+		Address: "127.0.0.1:9464",
+	}
+}
+
+// Server serves a registry at /metrics while enabled
+type Server struct {
+	mutex    sync.RWMutex
+	registry *Registry
+	settings Settings
+	http     *http.Server
+	address  string // Where it is actually listening
+}
+
+// NewServer creates a stopped endpoint for registry
+func NewServer(registry *Registry) *Server {
+	return &Server{registry: registry, settings: DefaultSettings()}
+}
+
+// Settings returns the current settings
+func (s *Server) Settings() Settings {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings
+}
+
+// Address returns the address being listened on, or "" when stopped
+func (s *Server) Address() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.address
+}
+
+// Apply stores new settings, starting or restarting the endpoint if enabled
+// and stopping it otherwise
+func (s *Server) Apply(settings Settings) (Settings, error) {
+	if settings.Address == "" {
+		settings.Address = DefaultSettings().Address
+	}
+
+	s.Stop()
+
+	s.mutex.Lock()
+	s.settings = settings
+	s.mutex.Unlock()
+
+	if !settings.Enabled {
+		return settings, nil
+	}
+	return settings, s.start()
+}
+
+// start listens using the current settings
+func (s *Server) start() error {
+	settings := s.Settings()
+
+	listener, err := net.Listen("tcp", settings.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", settings.Address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", s.registry)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	s.mutex.Lock()
+	s.http = server
+	s.address = listener.Addr().String()
+	s.mutex.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error("server stopped", "error", err)
+		}
+	}()
+
+	logger.Info("listening", "url", fmt.Sprintf("http://%s/metrics", listener.Addr()))
+	return nil
+}
+
+// Stop closes the endpoint
+func (s *Server) Stop() {
+	s.mutex.Lock()
+	server := s.http
+	s.http = nil
+	s.address = ""
+	s.mutex.Unlock()
+
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+		logger.Info("stopped")
+	}
+}
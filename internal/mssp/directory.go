@@ -0,0 +1,237 @@
+package mssp
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"seemud-gui/internal/safefile"
+)
+
+// bundledJSON lists well-known servers to browse before the user has added
+// any of their own
+//
+//go:embed bundled.json
+var bundledJSON []byte
+
+// browseWorkers is how many servers are probed at once
+const browseWorkers = 8
+
+// Entry is a server in the directory
+type Entry struct {
+	Name    string `json:"name"`
+	Host    string `json:"host"`
+	Port    string `json:"port"`
+	Bundled bool   `json:"bundled,omitempty"` // From the list shipped with SeeMUD
+}
+
+// Address returns host:port
+func (e Entry) Address() string {
+	return net.JoinHostPort(e.Host, e.Port)
+}
+
+// List is the directory file: the user's servers, and whether the bundled
+// ones are shown alongside them
+type List struct {
+	IncludeBundled bool    `json:"include_bundled"`
+	Servers        []Entry `json:"servers"`
+}
+
+// Directory is the list of servers to browse, with what each last reported
+type Directory struct {
+	mutex   sync.RWMutex
+	path    string
+	list    List
+	bundled []Entry
+	results map[string]Info // Keyed by address
+}
+
+// NewDirectory creates a directory persisting the user's list to path,
+// loading any saved one. Without one, only the bundled servers are listed.
+func NewDirectory(path string) (*Directory, error) {
+	d := &Directory{
+		path:    path,
+		list:    List{IncludeBundled: true},
+		results: make(map[string]Info),
+	}
+	if err := json.Unmarshal(bundledJSON, &d.bundled); err != nil {
+		return d, fmt.Errorf("failed to read bundled servers: %w", err)
+	}
+	for i := range d.bundled {
+		d.bundled[i].Bundled = true
+	}
+
+	data, err := safefile.ReadFile(path, json.Valid)
+	if os.IsNotExist(err) {
+		return d, nil
+	}
+	if err != nil {
+		return d, fmt.Errorf("failed to read server directory: %w", err)
+	}
+	if err := json.Unmarshal(data, &d.list); err != nil {
+		return d, fmt.Errorf("failed to unmarshal server directory: %w", err)
+	}
+	return d, nil
+}
+
+// Entries returns the user's servers followed by any bundled ones they
+// haven't also added
+func (d *Directory) Entries() []Entry {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+
+	entries := make([]Entry, 0, len(d.list.Servers)+len(d.bundled))
+	seen := make(map[string]bool)
+	for _, entry := range d.list.Servers {
+		entries = append(entries, entry)
+		seen[entry.Address()] = true
+	}
+	if d.list.IncludeBundled {
+		for _, entry := range d.bundled {
+			if !seen[entry.Address()] {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries
+}
+
+// IncludeBundled reports whether the bundled servers are listed
+func (d *Directory) IncludeBundled() bool {
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	return d.list.IncludeBundled
+}
+
+// SetIncludeBundled shows or hides the bundled servers
+func (d *Directory) SetIncludeBundled(include bool) error {
+	d.mutex.Lock()
+	d.list.IncludeBundled = include
+	d.mutex.Unlock()
+
+	return d.save()
+}
+
+// Add adds a server, or renames it if it's already listed
+func (d *Directory) Add(entry Entry) error {
+	entry.Name = strings.TrimSpace(entry.Name)
+	entry.Host = strings.TrimSpace(entry.Host)
+	entry.Port = strings.TrimSpace(entry.Port)
+	entry.Bundled = false
+	if entry.Host == "" || entry.Port == "" {
+		return fmt.Errorf("server needs a host and port")
+	}
+	if entry.Name == "" {
+		entry.Name = entry.Host
+	}
+
+	d.mutex.Lock()
+	replaced := false
+	for i, existing := range d.list.Servers {
+		if existing.Address() == entry.Address() {
+			d.list.Servers[i], replaced = entry, true
+			break
+		}
+	}
+	if !replaced {
+		d.list.Servers = append(d.list.Servers, entry)
+	}
+	d.mutex.Unlock()
+
+	return d.save()
+}
+
+// Remove removes one of the user's servers
+func (d *Directory) Remove(host, port string) error {
+	address := net.JoinHostPort(host, port)
+
+	d.mutex.Lock()
+	kept := d.list.Servers[:0]
+	for _, entry := range d.list.Servers {
+		if entry.Address() != address {
+			kept = append(kept, entry)
+		}
+	}
+	d.list.Servers = kept
+	delete(d.results, address)
+	d.mutex.Unlock()
+
+	return d.save()
+}
+
+// Browse probes every listed server at once and returns what each reported,
+// in the order they're listed
+func (d *Directory) Browse(ctx context.Context) []Info {
+	entries := d.Entries()
+	results := make([]Info, len(entries))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < browseWorkers && w < len(entries); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = Probe(ctx, entries[i].Host, entries[i].Port)
+				if results[i].Name == "" {
+					results[i].Name = entries[i].Name
+				}
+				results[i].Bundled = entries[i].Bundled
+			}
+		}()
+	}
+	for i := range entries {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	d.mutex.Lock()
+	for _, info := range results {
+		d.results[net.JoinHostPort(info.Host, info.Port)] = info
+	}
+	d.mutex.Unlock()
+	return results
+}
+
+// Results returns what each listed server reported when last browsed.
+// Servers not yet probed have only their listed name.
+func (d *Directory) Results() []Info {
+	entries := d.Entries()
+
+	d.mutex.RLock()
+	defer d.mutex.RUnlock()
+	results := make([]Info, 0, len(entries))
+	for _, entry := range entries {
+		info, exists := d.results[entry.Address()]
+		if !exists {
+			info = Info{Host: entry.Host, Port: entry.Port, Name: entry.Name}
+		}
+		info.Bundled = entry.Bundled
+		results = append(results, info)
+	}
+	return results
+}
+
+// save writes the user's list to disk
+func (d *Directory) save() error {
+	d.mutex.RLock()
+	data, err := json.MarshalIndent(d.list, "", "  ")
+	d.mutex.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal server directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(d.path), 0755); err != nil {
+		return fmt.Errorf("failed to create data directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(d.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write server directory: %w", err)
+	}
+	return nil
+}
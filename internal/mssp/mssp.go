@@ -0,0 +1,256 @@
+package mssp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("MSSP")
+
+// Telnet bytes used negotiating MSSP, the Mud Server Status Protocol:
+// https://tintin.mudhalla.net/protocols/mssp/
+const (
+	se     = 240
+	sb     = 250
+	will   = 251
+	wont   = 252
+	do     = 253
+	dont   = 254
+	iac    = 255
+	option = 70 // MSSP's telnet option
+
+	msspVar = 1
+	msspVal = 2
+)
+
+// ProbeTimeout is how long a server gets to report its status
+const ProbeTimeout = 8 * time.Second
+
+// fallbackAfter is how long to wait for telnet negotiation before asking
+// for the plain text reply some servers give instead
+const fallbackAfter = 2 * time.Second
+
+// maxProbeBytes stops a server that never stops talking from holding a probe
+const maxProbeBytes = 64 << 10
+
+// Info is what a server reported about itself
+type Info struct {
+	Host      string              `json:"host"`
+	Port      string              `json:"port"`
+	Name      string              `json:"name"`
+	Codebase  string              `json:"codebase,omitempty"`
+	Players   int                 `json:"players"`
+	Uptime    int64               `json:"uptime"` // Seconds since the server started, 0 if it didn't say
+	Variables map[string][]string `json:"variables,omitempty"`
+	Reachable bool                `json:"reachable"`
+	Error     string              `json:"error,omitempty"`
+	Probed    time.Time           `json:"probed"`
+	Bundled   bool                `json:"bundled,omitempty"` // Listed from the servers shipped with SeeMUD
+}
+
+// Probe connects to a server, asks for its MSSP status and disconnects.
+// Servers that don't negotiate MSSP are sent MSSP-REQUEST for the plain
+// text form. A server that answers but reports nothing is reachable with
+// an error saying so.
+func Probe(ctx context.Context, host, port string) Info {
+	info := Info{Host: host, Port: port, Probed: time.Now()}
+
+	ctx, cancel := context.WithTimeout(ctx, ProbeTimeout)
+	defer cancel()
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, port))
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	defer conn.Close()
+	info.Reachable = true
+
+	// Closing the connection is the only way to interrupt a read
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	variables, err := readStatus(conn)
+	if err != nil {
+		info.Error = err.Error()
+		return info
+	}
+	info.apply(variables)
+	logger.Debug("probed server", "address", net.JoinHostPort(host, port), "name", info.Name, "players", info.Players)
+	return info
+}
+
+// apply fills the well-known fields from the reported variables
+func (i *Info) apply(variables map[string][]string) {
+	i.Variables = variables
+	first := func(name string) string {
+		if values := variables[name]; len(values) > 0 {
+			return strings.TrimSpace(values[0])
+		}
+		return ""
+	}
+
+	i.Name = first("NAME")
+	i.Codebase = first("CODEBASE")
+	i.Players, _ = strconv.Atoi(first("PLAYERS"))
+	// UPTIME is when the server started, as a Unix time
+	if started, err := strconv.ParseInt(first("UPTIME"), 10, 64); err == nil && started > 0 {
+		if uptime := time.Now().Unix() - started; uptime > 0 {
+			i.Uptime = uptime
+		}
+	}
+}
+
+// readStatus reads from a server until it has reported its variables,
+// answering telnet negotiation along the way
+func readStatus(conn net.Conn) (map[string][]string, error) {
+	var (
+		buffer    = make([]byte, 4096)
+		text      bytes.Buffer // Plain text, for the MSSP-REQUEST reply
+		sub       []byte       // Subnegotiation being read
+		inSub     bool
+		command   byte // Pending WILL, WONT, DO or DONT
+		afterIAC  bool
+		requested bool
+		offered   bool
+		total     int
+	)
+	deadline := time.Now().Add(fallbackAfter)
+
+	for total < maxProbeBytes {
+		conn.SetReadDeadline(deadline)
+		n, err := conn.Read(buffer)
+		if err != nil {
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() && !requested && !offered {
+				// No MSSP yet; ask in plain text instead
+				requested = true
+				deadline = time.Now().Add(ProbeTimeout)
+				if _, err := conn.Write([]byte("MSSP-REQUEST\r\n")); err != nil {
+					return nil, fmt.Errorf("failed to request status: %w", err)
+				}
+				continue
+			}
+			if offered {
+				return nil, fmt.Errorf("server offered MSSP but sent no status")
+			}
+			return nil, fmt.Errorf("server sent no MSSP status")
+		}
+		total += n
+
+		var replies []byte
+		for _, b := range buffer[:n] {
+			switch {
+			case command != 0:
+				switch {
+				case command == will && b == option:
+					// The status follows once accepted, so stop waiting to
+					// fall back
+					if !offered {
+						offered, deadline = true, time.Now().Add(ProbeTimeout)
+					}
+					replies = append(replies, iac, do, option)
+				case command == will:
+					replies = append(replies, iac, dont, b)
+				case command == do:
+					replies = append(replies, iac, wont, b)
+				}
+				command = 0
+			case afterIAC:
+				afterIAC = false
+				switch b {
+				case will, wont, do, dont:
+					command = b
+				case sb:
+					inSub, sub = true, sub[:0]
+				case se:
+					if inSub && len(sub) > 0 && sub[0] == option {
+						return parseSubnegotiation(sub[1:]), nil
+					}
+					inSub = false
+				case iac:
+					if inSub {
+						sub = append(sub, iac)
+					}
+				}
+			case b == iac:
+				afterIAC = true
+			case inSub:
+				sub = append(sub, b)
+			default:
+				text.WriteByte(b)
+			}
+		}
+		if len(replies) > 0 {
+			if _, err := conn.Write(replies); err != nil {
+				return nil, fmt.Errorf("failed to negotiate: %w", err)
+			}
+		}
+
+		if variables, ok := parseText(text.String()); ok {
+			return variables, nil
+		}
+	}
+	return nil, fmt.Errorf("server sent too much without an MSSP status")
+}
+
+// parseSubnegotiation reads MSSP_VAR name MSSP_VAL value pairs. A variable
+// may have several values, each after its own MSSP_VAL.
+func parseSubnegotiation(data []byte) map[string][]string {
+	variables := make(map[string][]string)
+	var name, value []byte
+	var target *[]byte
+	finish := func() {
+		if target == &value && len(name) > 0 {
+			key := strings.ToUpper(string(name))
+			variables[key] = append(variables[key], string(value))
+		}
+	}
+
+	for _, b := range data {
+		switch b {
+		case msspVar:
+			finish()
+			name, target = name[:0], &name
+		case msspVal:
+			finish()
+			value, target = value[:0], &value
+		default:
+			if target != nil {
+				*target = append(*target, b)
+			}
+		}
+	}
+	finish()
+	return variables
+}
+
+// parseText reads the plain text reply to MSSP-REQUEST, a tab-separated
+// variable and value per line between MSSP-REPLY-START and MSSP-REPLY-END
+func parseText(text string) (map[string][]string, bool) {
+	start := strings.Index(text, "MSSP-REPLY-START")
+	if start < 0 {
+		return nil, false
+	}
+	end := strings.Index(text[start:], "MSSP-REPLY-END")
+	if end < 0 {
+		return nil, false
+	}
+
+	variables := make(map[string][]string)
+	for _, line := range strings.Split(text[start:start+end], "\n") {
+		name, value, found := strings.Cut(strings.TrimRight(line, "\r"), "\t")
+		if !found || name == "" {
+			continue
+		}
+		key := strings.ToUpper(strings.TrimSpace(name))
+		variables[key] = append(variables[key], strings.Split(value, "\t")...)
+	}
+	return variables, true
+}
@@ -0,0 +1,162 @@
+package mudtest_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/mudtest"
+	"seemud-gui/internal/telnet"
+)
+
+// timeout is how long a test waits for the client or server to catch up
+const timeout = 5 * time.Second
+
+// start runs a fake server and an engine connected to it, closing both when
+// the test ends
+func start(t *testing.T, opts mudtest.Options) (*mudtest.Server, *engine.Engine) {
+	t.Helper()
+	server, err := mudtest.NewServer(nil, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { server.Close() })
+
+	mud := engine.New(engine.Config{MapDir: t.TempDir()})
+	host, port := server.HostPort()
+	if err := mud.Connect(host, port); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	t.Cleanup(func() { mud.Close() })
+	return server, mud
+}
+
+// send sends a command and waits for the server to receive it
+func send(t *testing.T, server *mudtest.Server, mud *engine.Engine, command string) {
+	t.Helper()
+	if err := mud.Input(command); err != nil {
+		t.Fatalf("Input(%q): %v", command, err)
+	}
+	if err := server.Await(command, timeout); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// eventually waits for done to hold, failing with what if it never does
+func eventually(t *testing.T, what string, done func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !done() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// currentRoom returns the room the engine thinks the player is in
+func currentRoom(mud *engine.Engine) string {
+	room, ok := mud.Rooms.Current()
+	if !ok {
+		return ""
+	}
+	return room.Name
+}
+
+// mappedRoom returns the room the mapper has the player in
+func mappedRoom(mud *engine.Engine) string {
+	room := mud.Mapper.GetCurrentRoom()
+	if room == nil {
+		return ""
+	}
+	return room.Name
+}
+
+func TestLoginAndMapping(t *testing.T) {
+	opts := mudtest.DefaultOptions
+	opts.Account, opts.Password = "player", "secret"
+	server, mud := start(t, opts)
+
+	send(t, server, mud, "player")
+	send(t, server, mud, "secret")
+	send(t, server, mud, "1")
+
+	eventually(t, "the starting room to be parsed", func() bool { return currentRoom(mud) == "Fireplace" })
+	eventually(t, "the starting room to be mapped", func() bool { return mappedRoom(mud) == "Fireplace" })
+
+	send(t, server, mud, "e")
+	eventually(t, "the next room to be mapped", func() bool { return mappedRoom(mud) == "Common Room" })
+	if count := mud.Mapper.RoomCount(); count != 2 {
+		t.Errorf("mapped %d rooms, want 2", count)
+	}
+	common := mud.Mapper.GetCurrentRoom()
+	if _, ok := common.Exits["west"]; !ok {
+		t.Errorf("Common Room exits = %v, want west back to Fireplace", common.Exits)
+	}
+
+	// Going back must find the mapped room rather than add another
+	send(t, server, mud, "w")
+	eventually(t, "the way back to be mapped", func() bool { return mappedRoom(mud) == "Fireplace" })
+	if count := mud.Mapper.RoomCount(); count != 2 {
+		t.Errorf("mapped %d rooms after going back, want 2", count)
+	}
+}
+
+func TestMSDPRoundTrip(t *testing.T) {
+	opts := mudtest.Options{
+		Negotiate: [][]byte{{mudtest.IAC, mudtest.WILL, telnet.OptMSDP}},
+	}
+	server, mud := start(t, opts)
+
+	// The client agrees, then asks for its reports
+	eventually(t, "the client to agree to MSDP", func() bool {
+		return hasNegotiation(server, []byte{mudtest.IAC, mudtest.DO, telnet.OptMSDP})
+	})
+	eventually(t, "the client to ask for reports", func() bool {
+		return hasNegotiation(server, []byte{mudtest.IAC, mudtest.SB, telnet.OptMSDP, 1, 'R', 'E', 'P', 'O', 'R', 'T'})
+	})
+
+	// VAR ROOM VAL TABLE_OPEN VAR NAME VAL Fireplace VAR AREA VAL Tavern TABLE_CLOSE
+	// VAR HEALTH VAL 42
+	server.Handle("report", func(c *mudtest.Conn, args string) {
+		data := []byte{mudtest.IAC, mudtest.SB, telnet.OptMSDP}
+		data = append(data, 1)
+		data = append(data, "ROOM"...)
+		data = append(data, 2, 3, 1)
+		data = append(data, "NAME"...)
+		data = append(data, 2)
+		data = append(data, "Fireplace"...)
+		data = append(data, 1)
+		data = append(data, "AREA"...)
+		data = append(data, 2)
+		data = append(data, "Tavern"...)
+		data = append(data, 4, 1)
+		data = append(data, "HEALTH"...)
+		data = append(data, 2)
+		data = append(data, "42"...)
+		data = append(data, mudtest.IAC, mudtest.SE)
+		c.SendRaw(data)
+	})
+	send(t, server, mud, "report")
+
+	eventually(t, "the MSDP report", func() bool { return mud.Session.MSDP().Status().Health == 42 })
+	status := mud.Session.MSDP().Status()
+	if !status.Enabled {
+		t.Error("MSDP not enabled after the server offered it")
+	}
+	if status.RoomName != "Fireplace" || status.RoomArea != "Tavern" {
+		t.Errorf("room = %q in %q, want \"Fireplace\" in \"Tavern\"", status.RoomName, status.RoomArea)
+	}
+}
+
+// hasNegotiation reports whether the client sent a telnet sequence
+// starting with prefix
+func hasNegotiation(server *mudtest.Server, prefix []byte) bool {
+	for _, sequence := range server.Negotiations() {
+		if bytes.HasPrefix(sequence, prefix) {
+			return true
+		}
+	}
+	return false
+}
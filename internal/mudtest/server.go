@@ -0,0 +1,510 @@
+package mudtest
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Telnet bytes the server can negotiate with
+const (
+	IAC  byte = 255
+	DONT byte = 254
+	DO   byte = 253
+	WONT byte = 252
+	WILL byte = 251
+	SB   byte = 250
+	GA   byte = 249
+	SE   byte = 240
+
+	OptEcho  byte = 1
+	OptSGA   byte = 3
+	OptTType byte = 24
+	OptEOR   byte = 25
+	OptNAWS  byte = 31
+)
+
+// Options control how the fake server behaves
+type Options struct {
+	Banner    []string // Sent on connect, before negotiation and login
+	Login     bool     // Ask for an account and password, then show a menu
+	Account   string   // Accepted account; empty accepts any
+	Password  string   // Accepted password; empty accepts any
+	Prompt    string   // Sent without a newline after each response; empty for none
+	GoAhead   bool     // Follow prompts with IAC GA
+	Negotiate [][]byte // Telnet sequences sent on connect, e.g. {IAC, WILL, OptEcho}
+}
+
+// DefaultOptions behave like a stock WolfMUD with login
+var DefaultOptions = Options{
+	Banner: []string{"", "WolfMUD Copyright 1984-2024 Andrew 'Diddymus' Rolfe", ""},
+	Login:  true,
+}
+
+// Handler answers a command; args is everything after the first word
+type Handler func(c *Conn, args string)
+
+// Server is a fake WolfMUD-style telnet server on a random localhost port,
+// for tests that need a MUD without running one
+type Server struct {
+	listener net.Listener
+	world    *World
+	opts     Options
+
+	mutex        sync.RWMutex
+	handlers     map[string]Handler
+	conns        map[*Conn]bool
+	received     []string
+	negotiations [][]byte
+	changed      chan struct{} // Closed and replaced whenever received grows
+
+	wg sync.WaitGroup
+}
+
+// NewServer starts a fake server with a world (nil for DefaultWorld)
+func NewServer(world *World, opts Options) (*Server, error) {
+	if world == nil {
+		world = DefaultWorld()
+	}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen: %w", err)
+	}
+
+	s := &Server{
+		listener: listener,
+		world:    world,
+		opts:     opts,
+		handlers: make(map[string]Handler),
+		conns:    make(map[*Conn]bool),
+		changed:  make(chan struct{}),
+	}
+	s.wg.Add(1)
+	go s.accept()
+	return s, nil
+}
+
+// Addr returns host:port to connect to
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// HostPort returns the host and port separately, as engine.Connect takes them
+func (s *Server) HostPort() (string, string) {
+	host, port, _ := net.SplitHostPort(s.Addr())
+	return host, port
+}
+
+// Close stops listening and drops every connection
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	s.DropAll()
+	s.wg.Wait()
+	return err
+}
+
+// Handle answers a command word with fn instead of the built-in behaviour
+func (s *Server) Handle(verb string, fn Handler) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.handlers[strings.ToLower(verb)] = fn
+}
+
+// Broadcast sends lines to every player in the game, e.g. a tell
+func (s *Server) Broadcast(lines ...string) {
+	for _, c := range s.connections() {
+		if c.inGame() {
+			c.Send(lines...)
+			c.prompt()
+		}
+	}
+}
+
+// DropAll hangs up on every player, as a crash or network drop would
+func (s *Server) DropAll() {
+	for _, c := range s.connections() {
+		c.Close()
+	}
+}
+
+// Connections returns how many players are connected
+func (s *Server) Connections() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.conns)
+}
+
+// Received returns every line players have sent, in order
+func (s *Server) Received() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([]string{}, s.received...)
+}
+
+// Negotiations returns the telnet sequences players have sent
+func (s *Server) Negotiations() [][]byte {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return append([][]byte{}, s.negotiations...)
+}
+
+// Await waits until a player has sent command, or the timeout passes
+func (s *Server) Await(command string, timeout time.Duration) error {
+	deadline := time.After(timeout)
+	for {
+		s.mutex.RLock()
+		changed := s.changed
+		for _, line := range s.received {
+			if line == command {
+				s.mutex.RUnlock()
+				return nil
+			}
+		}
+		s.mutex.RUnlock()
+
+		select {
+		case <-changed:
+		case <-deadline:
+			return fmt.Errorf("no %q received within %s", command, timeout)
+		}
+	}
+}
+
+// connections returns a snapshot of the open connections
+func (s *Server) connections() []*Conn {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	conns := make([]*Conn, 0, len(s.conns))
+	for c := range s.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// accept serves connections until the listener closes
+func (s *Server) accept() {
+	defer s.wg.Done()
+	for {
+		netConn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		c := &Conn{server: s, conn: netConn, room: s.world.Start}
+		s.mutex.Lock()
+		s.conns[c] = true
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			c.serve()
+			s.mutex.Lock()
+			delete(s.conns, c)
+			s.mutex.Unlock()
+		}()
+	}
+}
+
+// record notes a line a player sent and wakes Await
+func (s *Server) record(line string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.received = append(s.received, line)
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+// recordNegotiation notes a telnet sequence a player sent
+func (s *Server) recordNegotiation(sequence []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.negotiations = append(s.negotiations, sequence)
+}
+
+// handler returns the custom handler for a verb, if any
+func (s *Server) handler(verb string) (Handler, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	fn, ok := s.handlers[verb]
+	return fn, ok
+}
+
+// Login stages
+const (
+	stageAccount = iota
+	stagePassword
+	stageMenu
+	stageGame
+)
+
+// Conn is one player's connection
+type Conn struct {
+	server *Server
+	conn   net.Conn
+
+	mutex sync.Mutex
+	stage int
+	room  string
+}
+
+// Send writes lines to the player
+func (c *Conn) Send(lines ...string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, line := range lines {
+		c.conn.Write([]byte(line + "\r\n"))
+	}
+}
+
+// SendRaw writes bytes as they are, e.g. a partial line or telnet sequence
+func (c *Conn) SendRaw(data []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.conn.Write(data)
+}
+
+// Room returns the key of the player's room
+func (c *Conn) Room() string {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.room
+}
+
+// MoveTo puts the player in a room and describes it
+func (c *Conn) MoveTo(key string) {
+	c.mutex.Lock()
+	c.room = key
+	c.mutex.Unlock()
+	c.look()
+}
+
+// Close hangs up
+func (c *Conn) Close() error {
+	return c.conn.Close()
+}
+
+// inGame reports whether the player is past the login
+func (c *Conn) inGame() bool {
+	return c.currentStage() == stageGame
+}
+
+// currentStage returns how far through the login the player is
+func (c *Conn) currentStage() int {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return c.stage
+}
+
+// setStage moves the player through the login
+func (c *Conn) setStage(stage int) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.stage = stage
+}
+
+// prompt sends the prompt, if there is one
+func (c *Conn) prompt() {
+	opts := c.server.opts
+	if opts.Prompt == "" && !opts.GoAhead {
+		return
+	}
+	data := []byte(opts.Prompt)
+	if opts.GoAhead {
+		data = append(data, IAC, GA)
+	}
+	c.SendRaw(data)
+}
+
+// serve runs the login and command loop
+func (c *Conn) serve() {
+	defer c.conn.Close()
+	opts := c.server.opts
+
+	c.Send(opts.Banner...)
+	for _, sequence := range opts.Negotiate {
+		c.SendRaw(sequence)
+	}
+
+	if opts.Login {
+		c.setStage(stageAccount)
+		c.Send("Enter your account ID or just press enter to create a new account:")
+	} else {
+		c.enterGame()
+	}
+
+	reader := bufio.NewReader(c.conn)
+	for {
+		line, err := c.readLine(reader)
+		if err != nil {
+			return
+		}
+		c.server.record(line)
+		if !c.handle(line) {
+			return
+		}
+	}
+}
+
+// readLine reads a line, taking telnet sequences out of it
+func (c *Conn) readLine(reader *bufio.Reader) (string, error) {
+	var line []byte
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		switch {
+		case b == IAC:
+			sequence, err := readSequence(reader)
+			if err != nil {
+				return "", err
+			}
+			c.server.recordNegotiation(sequence)
+		case b == '\n':
+			return strings.TrimRight(string(line), "\r"), nil
+		default:
+			line = append(line, b)
+		}
+	}
+}
+
+// readSequence reads the rest of a telnet sequence after IAC
+func readSequence(reader *bufio.Reader) ([]byte, error) {
+	command, err := reader.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	sequence := []byte{IAC, command}
+	switch command {
+	case WILL, WONT, DO, DONT:
+		option, err := reader.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		sequence = append(sequence, option)
+	case SB:
+		// Subnegotiation runs to IAC SE
+		for {
+			b, err := reader.ReadByte()
+			if err != nil {
+				return nil, err
+			}
+			sequence = append(sequence, b)
+			n := len(sequence)
+			if n >= 4 && sequence[n-2] == IAC && sequence[n-1] == SE {
+				break
+			}
+		}
+	}
+	return sequence, nil
+}
+
+// handle answers one line, returning false once the player has quit
+func (c *Conn) handle(line string) bool {
+	opts := c.server.opts
+	switch c.currentStage() {
+	case stageAccount:
+		// The client sends a blank line on connect to get past negotiation
+		if line == "" {
+			return true
+		}
+		if opts.Account != "" && line != opts.Account {
+			c.Send("Account ID or password is incorrect.", "Enter your account ID or just press enter to create a new account:")
+			return true
+		}
+		c.setStage(stagePassword)
+		c.Send("Enter the password for your account:")
+		return true
+	case stagePassword:
+		if opts.Password != "" && line != opts.Password {
+			c.setStage(stageAccount)
+			c.Send("Account ID or password is incorrect.", "Enter your account ID or just press enter to create a new account:")
+			return true
+		}
+		c.setStage(stageMenu)
+		c.menu()
+		return true
+	case stageMenu:
+		switch strings.TrimSpace(line) {
+		case "1":
+			c.enterGame()
+		case "0":
+			c.Send("Bye bye!")
+			return false
+		default:
+			c.Send("Invalid option selected.")
+			c.menu()
+		}
+		return true
+	}
+
+	verb, args, _ := strings.Cut(strings.TrimSpace(line), " ")
+	verb = strings.ToLower(verb)
+	if fn, ok := c.server.handler(verb); ok {
+		fn(c, args)
+		c.prompt()
+		return true
+	}
+
+	switch {
+	case verb == "":
+	case verb == "quit":
+		c.Send("You leave this world behind.", "Bye bye!")
+		return false
+	case verb == "look" || verb == "l":
+		c.look()
+	case direction(verb) != "":
+		c.move(direction(verb))
+	case verb == "say" && args != "":
+		c.Send("You say: " + args)
+	case verb == "who":
+		c.Send("Players online:", "  You", fmt.Sprintf("%d player(s) online.", c.server.Connections()))
+	case verb == "inventory" || verb == "inv" || verb == "i":
+		c.Send("You are not carrying anything.")
+	default:
+		c.Send("Eh?")
+	}
+	c.prompt()
+	return true
+}
+
+// menu shows WolfMUD's main menu
+func (c *Conn) menu() {
+	c.Send("", "Main Menu", "---------", "  1. Enter game", "  0. Quit", "", "Select option:")
+}
+
+// enterGame finishes the login and shows the starting room
+func (c *Conn) enterGame() {
+	c.setStage(stageGame)
+	c.Send("", "Welcome to WolfMUD!", "")
+	c.look()
+	c.prompt()
+}
+
+// look describes the player's room
+func (c *Conn) look() {
+	room, ok := c.server.world.Rooms[c.Room()]
+	if !ok {
+		c.Send("You are nowhere.")
+		return
+	}
+	c.Send(room.describe()...)
+}
+
+// move walks through an exit if the room has one
+func (c *Conn) move(dir string) {
+	room := c.server.world.Rooms[c.Room()]
+	if room == nil {
+		c.Send("You can't go that way.")
+		return
+	}
+	target, ok := room.Exits[dir]
+	if !ok {
+		c.Send("You can't go " + dir + " from here.")
+		return
+	}
+	c.MoveTo(target)
+}
@@ -0,0 +1,117 @@
+package mudtest
+
+import (
+	"sort"
+	"strings"
+)
+
+// Room is a canned room the fake server describes
+type Room struct {
+	Name        string
+	Description string
+	Exits       map[string]string // Full direction name to room key
+	Items       []string          // Shown as "You see X here."
+}
+
+// World is the rooms a fake server serves, keyed by a short name
+type World struct {
+	Rooms map[string]*Room
+	Start string
+}
+
+// DefaultWorld is a small tavern and street laid out on a grid, with a
+// one-way door and a loop back to the start so mappers have something to do
+func DefaultWorld() *World {
+	return &World{
+		Start: "fireplace",
+		Rooms: map[string]*Room{
+			"fireplace": {
+				Name:        "Fireplace",
+				Description: "This is the corner of the common room where a large fireplace warms the tavern.",
+				Exits:       map[string]string{"east": "common", "south": "bar"},
+				Items:       []string{"a poker"},
+			},
+			"common": {
+				Name:        "Common Room",
+				Description: "Tables and benches crowd this small, cosy common room.",
+				Exits:       map[string]string{"west": "fireplace", "south": "entrance"},
+			},
+			"bar": {
+				Name:        "Bar",
+				Description: "A long wooden bar runs along the west wall.",
+				Exits:       map[string]string{"north": "fireplace", "east": "entrance", "up": "cellar"},
+				Items:       []string{"the barman"},
+			},
+			"entrance": {
+				Name:        "Tavern Entrance",
+				Description: "The tavern door opens onto a cobbled street to the south.",
+				Exits:       map[string]string{"north": "common", "west": "bar", "south": "street"},
+			},
+			"street": {
+				Name:        "Cobbled Street",
+				Description: "Cobbles glisten in the rain outside the tavern.",
+				Exits:       map[string]string{"north": "entrance"},
+			},
+			"cellar": {
+				Name:        "Storeroom",
+				Description: "Barrels are stacked to the rafters. The hatch has swung shut behind you.",
+				Exits:       map[string]string{"down": "street"},
+			},
+		},
+	}
+}
+
+// exitNames are directions in the order exits are listed
+var exitNames = []string{"north", "northeast", "east", "southeast", "south", "southwest", "west", "northwest", "up", "down"}
+
+// shortDirections are the abbreviations players type
+var shortDirections = map[string]string{
+	"n": "north", "ne": "northeast", "e": "east", "se": "southeast",
+	"s": "south", "sw": "southwest", "w": "west", "nw": "northwest",
+	"u": "up", "d": "down",
+}
+
+// direction returns the full direction for a command, or "" if it isn't one
+func direction(command string) string {
+	command = strings.ToLower(strings.TrimSpace(command))
+	if full, ok := shortDirections[command]; ok {
+		return full
+	}
+	for _, name := range exitNames {
+		if command == name {
+			return name
+		}
+	}
+	return ""
+}
+
+// describe returns a room as WolfMUD prints it
+func (r *Room) describe() []string {
+	lines := []string{"[" + r.Name + "]", r.Description}
+	for _, item := range r.Items {
+		lines = append(lines, "You see "+item+" here.")
+	}
+
+	var exits []string
+	for _, name := range exitNames {
+		if _, ok := r.Exits[name]; ok {
+			exits = append(exits, name)
+		}
+	}
+	// Directions outside the usual set go last, in a stable order
+	var extra []string
+	for name := range r.Exits {
+		if direction(name) == "" {
+			extra = append(extra, name)
+		}
+	}
+	sort.Strings(extra)
+	exits = append(exits, extra...)
+
+	if len(exits) == 0 {
+		lines = append(lines, "You see no obvious exits.")
+	} else {
+		lines = append(lines, "You see exits: "+strings.Join(exits, ", "))
+	}
+	return lines
+}
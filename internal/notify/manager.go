@@ -0,0 +1,173 @@
+package notify
+
+import (
+	"sync"
+	"time"
+
+	"seemud-gui/internal/events"
+	"seemud-gui/internal/i18n"
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("Notify")
+
+// throttle stops a burst of the same event (e.g. every blow of a fight)
+// producing a notification each
+const throttle = 30 * time.Second
+
+// Settings configures which events raise desktop notifications
+type Settings struct {
+	Enabled           bool                 `json:"enabled"`
+	OnlyWhenUnfocused bool                 `json:"only_when_unfocused"`
+	Events            map[events.Kind]bool `json:"events"` // Per-event opt-in
+}
+
+// DefaultSettings opts in to private tells, being attacked and disconnects
+func DefaultSettings() Settings {
+	return Settings{
+		Enabled:           true,
+		OnlyWhenUnfocused: true,
+		Events: map[events.Kind]bool{
+			events.KindTell:         true,
+			events.KindAttacked:     true,
+			events.KindDisconnect:   true,
+			events.KindDeath:        false,
+			events.KindLevelUp:      false,
+			events.KindFriendOnline: true,
+		},
+	}
+}
+
+// Manager decides which game events become desktop notifications
+type Manager struct {
+	mutex    sync.Mutex
+	settings Settings
+	notifier Notifier
+	focused  bool
+	lastSent map[events.Kind]time.Time
+	lastKey  map[string]time.Time // For SendThrottled
+}
+
+// NewManager creates a notification manager. The window is assumed focused
+// until told otherwise.
+func NewManager(notifier Notifier, settings Settings) *Manager {
+	if settings.Events == nil {
+		settings.Events = make(map[events.Kind]bool)
+	}
+	return &Manager{
+		settings: settings,
+		notifier: notifier,
+		focused:  true,
+		lastSent: make(map[events.Kind]time.Time),
+		lastKey:  make(map[string]time.Time),
+	}
+}
+
+// Settings returns a copy of the current settings
+func (m *Manager) Settings() Settings {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	copied := m.settings
+	copied.Events = make(map[events.Kind]bool, len(m.settings.Events))
+	for kind, enabled := range m.settings.Events {
+		copied.Events[kind] = enabled
+	}
+	return copied
+}
+
+// SetSettings replaces the settings
+func (m *Manager) SetSettings(settings Settings) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if settings.Events == nil {
+		settings.Events = make(map[events.Kind]bool)
+	}
+	m.settings = settings
+}
+
+// SetFocused records whether the application window has focus
+func (m *Manager) SetFocused(focused bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.focused = focused
+}
+
+// HandleEvent shows a notification for an event if the user opted in
+func (m *Manager) HandleEvent(event events.Event) {
+	m.mutex.Lock()
+	settings := m.settings
+	if !settings.Enabled || !settings.Events[event.Kind] ||
+		(settings.OnlyWhenUnfocused && m.focused) ||
+		time.Since(m.lastSent[event.Kind]) < throttle {
+		m.mutex.Unlock()
+		return
+	}
+	m.lastSent[event.Kind] = time.Now()
+	m.mutex.Unlock()
+
+	title, body := Describe(event)
+	if err := m.notifier.Notify(title, body); err != nil {
+		logger.Warn("failed to notify", "error", err)
+	}
+}
+
+// Send shows a notification directly, still respecting focus and the
+// master switch
+func (m *Manager) Send(title, body string) {
+	m.mutex.Lock()
+	suppress := !m.settings.Enabled || (m.settings.OnlyWhenUnfocused && m.focused)
+	m.mutex.Unlock()
+
+	if suppress {
+		return
+	}
+	if err := m.notifier.Notify(title, body); err != nil {
+		logger.Warn("failed to notify", "error", err)
+	}
+}
+
+// SendThrottled shows a notification like Send, but only once in a while
+// for each key, so a watch matching a flood of lines raises one
+func (m *Manager) SendThrottled(key, title, body string) {
+	m.mutex.Lock()
+	if time.Since(m.lastKey[key]) < throttle {
+		m.mutex.Unlock()
+		return
+	}
+	suppress := !m.settings.Enabled || (m.settings.OnlyWhenUnfocused && m.focused)
+	if !suppress {
+		m.lastKey[key] = time.Now()
+	}
+	m.mutex.Unlock()
+
+	if suppress {
+		return
+	}
+	if err := m.notifier.Notify(title, body); err != nil {
+		logger.Warn("failed to notify", "error", err)
+	}
+}
+
+// Describe returns the notification title and body for an event
+func Describe(event events.Event) (string, string) {
+	switch event.Kind {
+	case events.KindTell:
+		return i18n.T("notify.tell", "speaker", event.Fields["speaker"]), event.Fields["message"]
+	case events.KindAttacked:
+		return i18n.T("notify.attacked"), event.Text
+	case events.KindDisconnect:
+		if event.Fields["state"] == "link_dead" {
+			return i18n.T("notify.link_dead"), i18n.T("notify.link_dead_body")
+		}
+		return i18n.T("notify.disconnected"), i18n.T("notify.disconnected_body")
+	case events.KindDeath:
+		return i18n.T("notify.died"), event.Text
+	case events.KindLevelUp:
+		return i18n.T("notify.level_up"), event.Text
+	case events.KindFriendOnline:
+		return i18n.T("notify.friend_online"), i18n.T("notify.friend_online_body", "name", event.Fields["name"])
+	}
+	return "SeeMUD", event.Text
+}
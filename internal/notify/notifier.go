@@ -0,0 +1,66 @@
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// Notifier shows a desktop notification
+type Notifier interface {
+	Notify(title, body string) error
+}
+
+// SystemNotifier shows notifications with the platform's own tooling:
+// osascript on macOS, notify-send on Linux and a tray balloon via
+// PowerShell on Windows
+type SystemNotifier struct {
+	AppName string
+}
+
+// NewSystemNotifier creates a notifier for the current platform
+func NewSystemNotifier(appName string) *SystemNotifier {
+	return &SystemNotifier{AppName: appName}
+}
+
+// Notify implements Notifier
+func (n *SystemNotifier) Notify(title, body string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %s with title %s",
+			appleQuote(body), appleQuote(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "windows":
+		script := "Add-Type -AssemblyName System.Windows.Forms; " +
+			"$n = New-Object System.Windows.Forms.NotifyIcon; " +
+			"$n.Icon = [System.Drawing.SystemIcons]::Information; " +
+			"$n.Visible = $true; " +
+			"$n.ShowBalloonTip(5000, '" + psQuote(title) + "', '" + psQuote(body) + "', 'Info'); " +
+			"Start-Sleep -Seconds 6; $n.Dispose()"
+		cmd = exec.Command("powershell", "-NoProfile", "-Command", script)
+	default:
+		cmd = exec.Command("notify-send", "--app-name="+n.AppName, title, body)
+	}
+
+	// Don't wait: the Windows balloon has to sleep to stay visible
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to show notification: %w", err)
+	}
+	go cmd.Wait()
+
+	return nil
+}
+
+// appleQuote quotes a string for AppleScript
+func appleQuote(text string) string {
+	text = strings.ReplaceAll(text, `\`, `\\`)
+	return `"` + strings.ReplaceAll(text, `"`, `\"`) + `"`
+}
+
+// psQuote escapes a string for a single-quoted PowerShell literal
+func psQuote(text string) string {
+	return strings.ReplaceAll(text, "'", "''")
+}
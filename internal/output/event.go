@@ -0,0 +1,98 @@
+package output
+
+import (
+	"seemud-gui/internal/ansi"
+	"seemud-gui/internal/parser"
+)
+
+// Event is a parsed line in the form delivered to the frontend, so the UI
+// can render rooms, chat, prompts and system messages without re-parsing
+type Event struct {
+	Type      string      `json:"type"`
+	Class     string      `json:"class"` // Semantic style class, see Class
+	Text      string      `json:"text"`  // Clean text without escape codes
+	Raw       string      `json:"raw"`
+	Spans     []ansi.Span `json:"spans"`
+	RoomName  string      `json:"room_name,omitempty"`
+	Exits     []string    `json:"exits,omitempty"`
+	Items     []string    `json:"items,omitempty"`
+	Mobs      []string    `json:"mobs,omitempty"`
+	Speaker   string      `json:"speaker,omitempty"`
+	Channel   string      `json:"channel,omitempty"`
+	Message   string      `json:"message,omitempty"`
+	Outgoing  bool        `json:"outgoing,omitempty"`
+	Highlight string      `json:"highlight,omitempty"` // Watch-list pattern the line matched, if any
+}
+
+// FromParsed builds an event from the parser's output for one line
+func FromParsed(parsed *parser.ParsedOutput) Event {
+	return Event{
+		Type:      parsed.Type.String(),
+		Class:     Class(parsed),
+		Text:      parsed.CleanText,
+		Raw:       parsed.RawText,
+		Spans:     parsed.Spans,
+		RoomName:  parsed.RoomName,
+		Exits:     parsed.Exits,
+		Items:     parsed.Items,
+		Mobs:      parsed.Mobs,
+		Speaker:   parsed.Speaker,
+		Channel:   parsed.Channel,
+		Message:   parsed.Message,
+		Outgoing:  parsed.Outgoing,
+		Highlight: parsed.Highlight,
+	}
+}
+
+// Semantic style classes attached to every event, so the frontend can theme
+// output in CSS instead of sniffing the text
+const (
+	ClassText            = "text"
+	ClassRoomTitle       = "room-title"
+	ClassRoomDescription = "room-description"
+	ClassExit            = "exit"
+	ClassInventory       = "inventory"
+	ClassMobs            = "mobs"
+	ClassPrompt          = "prompt"
+	ClassSystem          = "system"
+	ClassLogin           = "login"
+	ClassChatSay         = "chat-say"
+	ClassChatTell        = "chat-tell"
+	ClassChatChannel     = "chat-channel"
+	ClassCombatAttack    = "combat-attack" // The player attacking
+	ClassCombatDamage    = "combat-damage" // Something attacking the player
+)
+
+// Class returns the semantic style class for a parsed line
+func Class(parsed *parser.ParsedOutput) string {
+	switch parsed.Type {
+	case parser.TypeRoomTitle:
+		return ClassRoomTitle
+	case parser.TypeRoomDescription:
+		return ClassRoomDescription
+	case parser.TypeExits:
+		return ClassExit
+	case parser.TypeInventory:
+		return ClassInventory
+	case parser.TypeMobs:
+		return ClassMobs
+	case parser.TypePrompt:
+		return ClassPrompt
+	case parser.TypeSystem:
+		return ClassSystem
+	case parser.TypeLoginPrompt, parser.TypeMenu:
+		return ClassLogin
+	case parser.TypeSay:
+		return ClassChatSay
+	case parser.TypeTell:
+		return ClassChatTell
+	case parser.TypeChannel:
+		return ClassChatChannel
+	case parser.TypeCombat:
+		if parsed.Incoming {
+			return ClassCombatDamage
+		}
+		return ClassCombatAttack
+	}
+	return ClassText
+}
@@ -0,0 +1,146 @@
+package output
+
+import (
+	"sync"
+	"time"
+
+	"seemud-gui/internal/ansi"
+)
+
+// DefaultFrameInterval is how long output collects before it's delivered:
+// short enough to feel immediate, long enough that combat spam arrives in a
+// handful of frames a second rather than a message per line
+const DefaultFrameInterval = 40 * time.Millisecond
+
+// MaxFrameLines delivers a frame early once it's this long, so a flood
+// can't build one enormous message
+const MaxFrameLines = 500
+
+// FrameLine is a line ready to draw: its type and style class, its clean
+// text split into styled spans, what the parser found in it and when it
+// arrived, without the raw text the UI doesn't need
+type FrameLine struct {
+	Seq       int64       `json:"seq"`
+	Time      time.Time   `json:"time"`
+	Type      string      `json:"type"`
+	Class     string      `json:"class"`
+	Text      string      `json:"text"`
+	Spans     []ansi.Span `json:"spans"`
+	RoomName  string      `json:"room_name,omitempty"`
+	Exits     []string    `json:"exits,omitempty"`
+	Items     []string    `json:"items,omitempty"`
+	Mobs      []string    `json:"mobs,omitempty"`
+	Highlight string      `json:"highlight,omitempty"` // Watch-list pattern the line matched, if any
+}
+
+// Frame is the output that arrived during one frame interval
+type Frame struct {
+	FirstSeq int64       `json:"first_seq"`
+	LastSeq  int64       `json:"last_seq"`
+	Lines    []FrameLine `json:"lines"`
+}
+
+// Framer coalesces output into timed frames, so the UI gets one message per
+// frame instead of one per line
+type Framer struct {
+	mutex    sync.Mutex
+	interval time.Duration
+	pending  []FrameLine
+	timer    *time.Timer
+	stopped  bool
+
+	// deliverMux keeps frames in order when the timer and a full frame
+	// flush at once
+	deliverMux sync.Mutex
+	deliver    func(Frame)
+}
+
+// NewFramer creates a framer passing each frame to deliver
+func NewFramer(interval time.Duration, deliver func(Frame)) *Framer {
+	if interval <= 0 {
+		interval = DefaultFrameInterval
+	}
+	return &Framer{interval: interval, deliver: deliver}
+}
+
+// NewFrameLine converts an entry for a frame
+func NewFrameLine(entry Entry) FrameLine {
+	return FrameLine{
+		Seq:       entry.Seq,
+		Time:      entry.Time,
+		Type:      entry.Event.Type,
+		Class:     entry.Event.Class,
+		Text:      entry.Event.Text,
+		Spans:     entry.Event.Spans,
+		RoomName:  entry.Event.RoomName,
+		Exits:     entry.Event.Exits,
+		Items:     entry.Event.Items,
+		Mobs:      entry.Event.Mobs,
+		Highlight: entry.Event.Highlight,
+	}
+}
+
+// FrameOf converts a batch read from the ring into a frame, so backfilled
+// scrollback arrives in the same shape as live output
+func FrameOf(batch Batch) Frame {
+	frame := Frame{LastSeq: batch.LastSeq, Lines: make([]FrameLine, len(batch.Entries))}
+	for i, entry := range batch.Entries {
+		frame.Lines[i] = NewFrameLine(entry)
+	}
+	if len(frame.Lines) > 0 {
+		frame.FirstSeq = frame.Lines[0].Seq
+	}
+	return frame
+}
+
+// Add queues an entry for the next frame. The frame's timer starts with its
+// first line, so a quiet connection sends nothing.
+func (f *Framer) Add(entry Entry) {
+	f.mutex.Lock()
+	if f.stopped {
+		f.mutex.Unlock()
+		return
+	}
+	f.pending = append(f.pending, NewFrameLine(entry))
+	full := len(f.pending) >= MaxFrameLines
+	if len(f.pending) == 1 && !full {
+		f.timer = time.AfterFunc(f.interval, f.Flush)
+	}
+	f.mutex.Unlock()
+
+	if full {
+		f.Flush()
+	}
+}
+
+// Flush delivers whatever is pending now
+func (f *Framer) Flush() {
+	f.deliverMux.Lock()
+	defer f.deliverMux.Unlock()
+
+	f.mutex.Lock()
+	lines := f.pending
+	f.pending = nil
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+	f.mutex.Unlock()
+
+	if len(lines) == 0 {
+		return
+	}
+	f.deliver(Frame{
+		FirstSeq: lines[0].Seq,
+		LastSeq:  lines[len(lines)-1].Seq,
+		Lines:    lines,
+	})
+}
+
+// Stop delivers anything pending and ignores further output
+func (f *Framer) Stop() {
+	f.mutex.Lock()
+	f.stopped = true
+	f.mutex.Unlock()
+	f.Flush()
+}
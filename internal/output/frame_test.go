@@ -0,0 +1,148 @@
+package output
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"seemud-gui/internal/ansi"
+)
+
+// combatLine is the kind of line that arrives by the hundred in a fight
+const combatLine = "\x1b[1;31mThe goblin warrior\x1b[0m slashes you with a \x1b[33mrusty scimitar\x1b[0m! [\x1b[32m142\x1b[0m/\x1b[32m200\x1b[0m hp]"
+
+// benchEntry builds a classified, styled entry as the hub publishes it
+func benchEntry(seq int64) Entry {
+	return Entry{
+		Seq:  seq,
+		Time: time.Now(),
+		Line: combatLine,
+		Event: Event{
+			Type:  "combat",
+			Class: "combat",
+			Text:  ansi.Strip(combatLine),
+			Raw:   combatLine,
+			Spans: ansi.Spans(combatLine),
+		},
+	}
+}
+
+// BenchmarkPerLineDelivery sends every line to the UI as its own message,
+// as the output poll and per-line events did
+func BenchmarkPerLineDelivery(b *testing.B) {
+	entry := benchEntry(1)
+	var messages, bytes int
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Seq = int64(i + 1)
+		encoded, err := json.Marshal(entry)
+		if err != nil {
+			b.Fatal(err)
+		}
+		messages++
+		bytes += len(encoded)
+	}
+	b.ReportMetric(float64(messages)/float64(b.N), "msgs/line")
+	b.ReportMetric(float64(bytes)/float64(b.N), "bytes/line")
+}
+
+// BenchmarkFramedDelivery sends the same lines through a framer, as a flood
+// of combat output does: most frames fill well before their interval ends
+func BenchmarkFramedDelivery(b *testing.B) {
+	var messages, bytes int
+	framer := NewFramer(time.Hour, func(frame Frame) {
+		encoded, err := json.Marshal(frame)
+		if err != nil {
+			b.Fatal(err)
+		}
+		messages++
+		bytes += len(encoded)
+	})
+	entry := benchEntry(1)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		entry.Seq = int64(i + 1)
+		framer.Add(entry)
+	}
+	framer.Stop()
+	b.ReportMetric(float64(messages)/float64(b.N), "msgs/line")
+	b.ReportMetric(float64(bytes)/float64(b.N), "bytes/line")
+}
+
+// TestFramerOrder checks frames arrive in order, cover every line once and
+// respect MaxFrameLines
+func TestFramerOrder(t *testing.T) {
+	var frames []Frame
+	framer := NewFramer(time.Hour, func(frame Frame) {
+		frames = append(frames, frame)
+	})
+	total := MaxFrameLines*2 + 7
+	for i := 1; i <= total; i++ {
+		framer.Add(benchEntry(int64(i)))
+	}
+	framer.Stop()
+	framer.Add(benchEntry(int64(total + 1)))
+
+	next := int64(1)
+	for _, frame := range frames {
+		if len(frame.Lines) > MaxFrameLines {
+			t.Errorf("frame has %d lines, want at most %d", len(frame.Lines), MaxFrameLines)
+		}
+		if frame.FirstSeq != next {
+			t.Errorf("frame starts at %d, want %d", frame.FirstSeq, next)
+		}
+		for _, line := range frame.Lines {
+			if line.Seq != next {
+				t.Fatalf("line %d out of order, want %d", line.Seq, next)
+			}
+			next++
+		}
+		if frame.LastSeq != next-1 {
+			t.Errorf("frame ends at %d, want %d", frame.LastSeq, next-1)
+		}
+	}
+	if next-1 != int64(total) {
+		t.Errorf("delivered %d lines, want %d", next-1, total)
+	}
+}
+
+// TestFramerInterval checks a lone line is delivered once its interval ends
+func TestFramerInterval(t *testing.T) {
+	delivered := make(chan Frame, 1)
+	framer := NewFramer(10*time.Millisecond, func(frame Frame) {
+		delivered <- frame
+	})
+	defer framer.Stop()
+
+	framer.Add(benchEntry(1))
+	select {
+	case frame := <-delivered:
+		if len(frame.Lines) != 1 || frame.Lines[0].Class != "combat" || len(frame.Lines[0].Spans) == 0 {
+			t.Errorf("unexpected frame %+v", frame)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("frame not delivered")
+	}
+}
+
+// TestFrameOf checks backfilled output has the same shape as live frames,
+// carrying each line's type, time and what the parser found in it
+func TestFrameOf(t *testing.T) {
+	entry := benchEntry(3)
+	entry.Event.Exits = []string{"north", "east"}
+	frame := FrameOf(Batch{Entries: []Entry{entry, benchEntry(4)}, LastSeq: 4})
+
+	if frame.FirstSeq != 3 || frame.LastSeq != 4 || len(frame.Lines) != 2 {
+		t.Fatalf("unexpected frame %+v", frame)
+	}
+	line := frame.Lines[0]
+	if line.Type != "combat" || !line.Time.Equal(entry.Time) || len(line.Exits) != 2 || line.Text != entry.Event.Text {
+		t.Errorf("unexpected line %+v", line)
+	}
+
+	if empty := FrameOf(Batch{LastSeq: 9}); empty.FirstSeq != 0 || empty.LastSeq != 9 || len(empty.Lines) != 0 {
+		t.Errorf("unexpected empty frame %+v", empty)
+	}
+}
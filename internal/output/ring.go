@@ -0,0 +1,141 @@
+package output
+
+import (
+	"sync"
+	"time"
+)
+
+// DefaultRingSize is how many lines of scrollback the ring keeps
+const DefaultRingSize = 5000
+
+// Entry is one line of output with its sequence number
+type Entry struct {
+	Seq   int64     `json:"seq"`
+	Time  time.Time `json:"time"`
+	Line  string    `json:"line"` // Raw line as received
+	Event Event     `json:"event"`
+}
+
+// Batch is the result of reading the ring from a sequence number
+type Batch struct {
+	Entries []Entry `json:"entries"`
+	LastSeq int64   `json:"last_seq"` // Pass back in as the next "since"
+	Missed  int64   `json:"missed"`   // Entries that were overwritten before being read
+}
+
+// Ring is a fixed-size buffer of sequenced output entries. Readers keep their
+// own cursor, so any number of consumers can read independently and a
+// reconnecting frontend can backfill its scrollback.
+type Ring struct {
+	mutex   sync.RWMutex
+	entries []Entry
+	start   int
+	count   int
+	lastSeq int64
+}
+
+// NewRing creates a ring holding at most capacity entries
+func NewRing(capacity int) *Ring {
+	if capacity <= 0 {
+		capacity = DefaultRingSize
+	}
+	return &Ring{entries: make([]Entry, capacity)}
+}
+
+// Append adds a line and returns the stored entry
+func (r *Ring) Append(line string, event Event) Entry {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	r.lastSeq++
+	entry := Entry{
+		Seq:   r.lastSeq,
+		Time:  time.Now(),
+		Line:  line,
+		Event: event,
+	}
+
+	capacity := len(r.entries)
+	if r.count < capacity {
+		r.entries[(r.start+r.count)%capacity] = entry
+		r.count++
+	} else {
+		r.entries[r.start] = entry
+		r.start = (r.start + 1) % capacity
+	}
+
+	return entry
+}
+
+// Since returns up to limit entries with a sequence number greater than seq.
+// A limit of zero or less returns everything available.
+func (r *Ring) Since(seq int64, limit int) Batch {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	batch := Batch{Entries: []Entry{}, LastSeq: seq}
+	if r.count == 0 || seq >= r.lastSeq {
+		if seq > r.lastSeq {
+			// Cursor from a previous session, start again from the beginning
+			batch.LastSeq = r.lastSeq
+		}
+		return batch
+	}
+
+	oldest := r.entries[r.start].Seq
+	if seq < oldest-1 {
+		batch.Missed = oldest - 1 - seq
+		seq = oldest - 1
+	}
+
+	// Sequence numbers are contiguous, so the first wanted entry is at a
+	// fixed offset from the oldest
+	offset := int(seq - oldest + 1)
+	n := r.count - offset
+	if limit > 0 && n > limit {
+		n = limit
+	}
+
+	batch.Entries = make([]Entry, 0, n)
+	for i := offset; i < offset+n; i++ {
+		batch.Entries = append(batch.Entries, r.entries[(r.start+i)%len(r.entries)])
+	}
+	batch.LastSeq = batch.Entries[len(batch.Entries)-1].Seq
+
+	return batch
+}
+
+// Range returns the entries with sequence numbers from first to last
+// inclusive. A last of zero or less means up to the newest entry.
+func (r *Ring) Range(first, last int64) []Entry {
+	if first < 1 {
+		first = 1
+	}
+
+	batch := r.Since(first-1, 0)
+	if last <= 0 {
+		return batch.Entries
+	}
+
+	entries := batch.Entries[:0]
+	for _, entry := range batch.Entries {
+		if entry.Seq <= last {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// LastSeq returns the sequence number of the newest entry
+func (r *Ring) LastSeq() int64 {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.lastSeq
+}
+
+// Len returns the number of entries held
+func (r *Ring) Len() int {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+	return r.count
+}
@@ -0,0 +1,36 @@
+package pacing
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultPasteDelay is the pause between pasted lines. Pastes are usually
+// notes or board posts, so there's no mapper to keep up with, but servers
+// still throttle clients that send dozens of lines at once.
+const DefaultPasteDelay = 250 * time.Millisecond
+
+// MaxPasteLines stops an accidental paste of a whole log file
+const MaxPasteLines = 2000
+
+// SplitLines splits pasted text into commands, one per line. Line endings
+// are normalised and trailing blank lines dropped, but blank lines in the
+// middle are kept since they often end paragraphs in MUD editors.
+func SplitLines(text string) ([]string, error) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	text = strings.TrimRight(text, "\n \t")
+	if text == "" {
+		return nil, nil
+	}
+
+	lines := strings.Split(text, "\n")
+	if len(lines) > MaxPasteLines {
+		return nil, fmt.Errorf("too many lines to send (%d, limit %d)", len(lines), MaxPasteLines)
+	}
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return lines, nil
+}
@@ -0,0 +1,279 @@
+package pacing
+
+import (
+	"sync"
+	"time"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("Pacing")
+
+// DefaultDelay is the pause between queued commands, slow enough for the
+// server's output (and the mapper) to keep up with movement
+const DefaultDelay = 500 * time.Millisecond
+
+// SendFunc sends a single command to the server
+type SendFunc func(command string) error
+
+// Progress reports how far a batch of queued commands has got
+type Progress struct {
+	BatchID   int64  `json:"batch_id"`
+	Label     string `json:"label"`
+	Sent      int    `json:"sent"`
+	Total     int    `json:"total"`
+	Done      bool   `json:"done"`
+	Cancelled bool   `json:"cancelled"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Pending describes a batch still waiting in the queue
+type Pending struct {
+	BatchID  int64    `json:"batch_id"`
+	Label    string   `json:"label"`
+	Sent     int      `json:"sent"`
+	Total    int      `json:"total"`
+	Upcoming []string `json:"upcoming"` // Commands not yet sent
+}
+
+// batch is a group of commands queued together, e.g. one speedwalk
+type batch struct {
+	id       int64
+	label    string
+	commands []string
+	sent     int
+	delay    time.Duration // Overrides the queue's delay if not negative
+}
+
+// Queue sends batches of commands one at a time with a delay between each,
+// so long sequences can be watched and aborted part way
+type Queue struct {
+	mutex     sync.Mutex
+	send      SendFunc
+	delay     time.Duration
+	batches   []*batch
+	nextID    int64
+	running   bool
+	listeners []func(Progress)
+}
+
+// NewQueue creates a queue sending through send
+func NewQueue(send SendFunc) *Queue {
+	return &Queue{
+		send:  send,
+		delay: DefaultDelay,
+	}
+}
+
+// SetDelay changes the pause between commands
+func (q *Queue) SetDelay(delay time.Duration) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if delay < 0 {
+		delay = 0
+	}
+	q.delay = delay
+}
+
+// Delay returns the pause between commands
+func (q *Queue) Delay() time.Duration {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return q.delay
+}
+
+// OnProgress registers a listener called after each command and when a
+// batch finishes or is cancelled
+func (q *Queue) OnProgress(fn func(Progress)) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	q.listeners = append(q.listeners, fn)
+}
+
+// Add queues a batch of commands and returns its ID, or 0 if there was
+// nothing to queue
+func (q *Queue) Add(label string, commands []string) int64 {
+	return q.AddWithDelay(label, commands, -1)
+}
+
+// AddWithDelay queues a batch that pauses for delay between its commands
+// instead of the queue's delay, e.g. a slower paste. A negative delay uses
+// the queue's.
+func (q *Queue) AddWithDelay(label string, commands []string, delay time.Duration) int64 {
+	if len(commands) == 0 {
+		return 0
+	}
+
+	q.mutex.Lock()
+	q.nextID++
+	b := &batch{
+		id:       q.nextID,
+		label:    label,
+		commands: append([]string{}, commands...),
+		delay:    delay,
+	}
+	q.batches = append(q.batches, b)
+	start := !q.running
+	q.running = true
+	q.mutex.Unlock()
+
+	if start {
+		go q.run()
+	}
+	return b.id
+}
+
+// Cancel drops the rest of a batch. It returns false if the batch has
+// already finished.
+func (q *Queue) Cancel(batchID int64) bool {
+	q.mutex.Lock()
+	var progress *Progress
+	for i, b := range q.batches {
+		if b.id == batchID {
+			p := b.progress(true, false, nil)
+			progress = &p
+			q.batches = append(q.batches[:i:i], q.batches[i+1:]...)
+			break
+		}
+	}
+	q.mutex.Unlock()
+
+	if progress == nil {
+		return false
+	}
+
+	logger.Info("cancelled batch", "label", progress.Label, "sent", progress.Sent, "total", progress.Total)
+	q.notify(*progress)
+	return true
+}
+
+// Pending returns the batches waiting to be sent, in order
+func (q *Queue) Pending() []Pending {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	pending := make([]Pending, 0, len(q.batches))
+	for _, b := range q.batches {
+		pending = append(pending, Pending{
+			BatchID:  b.id,
+			Label:    b.label,
+			Sent:     b.sent,
+			Total:    len(b.commands),
+			Upcoming: append([]string{}, b.commands[b.sent:]...),
+		})
+	}
+	return pending
+}
+
+// Len returns the number of commands waiting to be sent
+func (q *Queue) Len() int {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	count := 0
+	for _, b := range q.batches {
+		count += len(b.commands) - b.sent
+	}
+	return count
+}
+
+// CancelAll drops everything waiting to be sent and returns how many
+// commands were dropped
+func (q *Queue) CancelAll() int {
+	q.mutex.Lock()
+	dropped := 0
+	cancelled := make([]Progress, 0, len(q.batches))
+	for _, b := range q.batches {
+		dropped += len(b.commands) - b.sent
+		cancelled = append(cancelled, b.progress(true, false, nil))
+	}
+	q.batches = nil
+	q.mutex.Unlock()
+
+	if dropped > 0 {
+		logger.Info("cancelled pending commands", "count", dropped)
+	}
+	for _, progress := range cancelled {
+		q.notify(progress)
+	}
+	return dropped
+}
+
+// run sends queued commands until the queue is empty
+func (q *Queue) run() {
+	for {
+		q.mutex.Lock()
+		if len(q.batches) == 0 {
+			q.running = false
+			q.mutex.Unlock()
+			return
+		}
+		b := q.batches[0]
+		command := b.commands[b.sent]
+		delay := q.delay
+		if b.delay >= 0 {
+			delay = b.delay
+		}
+		q.mutex.Unlock()
+
+		err := q.send(command)
+
+		q.mutex.Lock()
+		// The batch may have been cancelled while we were sending
+		if len(q.batches) == 0 || q.batches[0] != b {
+			q.mutex.Unlock()
+			continue
+		}
+		b.sent++
+		done := b.sent >= len(b.commands) || err != nil
+		if done {
+			q.batches = q.batches[1:]
+		}
+		progress := b.progress(false, done, err)
+		q.mutex.Unlock()
+
+		if err != nil {
+			logger.Warn("stopping batch", "label", b.label, "error", err)
+		}
+		q.notify(progress)
+
+		if !done || q.hasPending() {
+			time.Sleep(delay)
+		}
+	}
+}
+
+// hasPending reports whether anything is waiting to be sent
+func (q *Queue) hasPending() bool {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	return len(q.batches) > 0
+}
+
+// notify tells listeners about progress
+func (q *Queue) notify(progress Progress) {
+	q.mutex.Lock()
+	listeners := q.listeners
+	q.mutex.Unlock()
+
+	for _, fn := range listeners {
+		fn(progress)
+	}
+}
+
+// progress describes a batch's current position
+func (b *batch) progress(cancelled, done bool, err error) Progress {
+	p := Progress{
+		BatchID:   b.id,
+		Label:     b.label,
+		Sent:      b.sent,
+		Total:     len(b.commands),
+		Done:      done || cancelled,
+		Cancelled: cancelled,
+	}
+	if err != nil {
+		p.Error = err.Error()
+	}
+	return p
+}
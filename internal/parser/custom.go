@@ -0,0 +1,263 @@
+package parser
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// bundledDefinitions are parser profiles shipped with SeeMUD, for families
+// close enough to one another that a few patterns tell them apart
+//
+//go:embed profiles/*.json
+var bundledDefinitions embed.FS
+
+// Definition is a parser described in a JSON file rather than compiled in,
+// so a new MUD can be supported without rebuilding SeeMUD. Patterns are Go
+// regular expressions matched against a line with its colours and
+// surrounding space removed.
+type Definition struct {
+	Name        string     `json:"name"` // Dialect name profiles refer to it by
+	Description string     `json:"description,omitempty"`
+	Title       TitleRules `json:"title"`
+	Exits       []string   `json:"exits,omitempty"`  // First submatch is the list of exits
+	Prompt      string     `json:"prompt,omitempty"` // Whole prompt line
+	Items       []string   `json:"items,omitempty"`  // First submatch is a list of items
+	Mobs        []string   `json:"mobs,omitempty"`   // First submatch is a list of creatures
+}
+
+// TitleRules are the ways a definition recognises a room's name; a line
+// passing any of them is a title
+type TitleRules struct {
+	Patterns  []string `json:"patterns,omitempty"`   // First submatch, if any, is the name
+	Brackets  bool     `json:"brackets,omitempty"`   // "[Town Square]", as WolfMUD writes them
+	TitleCase bool     `json:"title_case,omitempty"` // Short lines like "The Temple Of Midgaard"
+	Coloured  bool     `json:"coloured,omitempty"`   // Short lines all in one colour
+}
+
+// definitionNameRegex keeps names to what can be typed in a flag or a
+// profile without quoting
+var definitionNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// builtinDialects are the compiled-in parsers, which definitions can't replace
+var builtinDialects = map[string]bool{"wolfmud": true, "diku": true, "evennia": true, "generic": true}
+
+// custom are the dialects registered from definitions, by name
+var custom = make(map[string]Definition)
+
+func init() {
+	files, _ := fs.Glob(bundledDefinitions, "profiles/*.json")
+	for _, file := range files {
+		data, _ := bundledDefinitions.ReadFile(file)
+		definition, err := ParseDefinition(data)
+		if err != nil {
+			panic(fmt.Sprintf("bundled parser %s: %v", file, err))
+		}
+		definition.register()
+	}
+}
+
+// ParseDefinition reads a definition and checks it, so a mistake is
+// reported rather than making a parser that never matches
+func ParseDefinition(data []byte) (Definition, error) {
+	definition, err := decodeDefinition(data)
+	if err != nil {
+		return definition, fmt.Errorf("failed to unmarshal parser definition: %w", err)
+	}
+	if problems := definition.Validate(); len(problems) > 0 {
+		return definition, fmt.Errorf("invalid parser definition: %s", strings.Join(problems, "; "))
+	}
+	return definition, nil
+}
+
+// ValidateDefinition returns everything wrong with a definition file,
+// empty if it would load
+func ValidateDefinition(data []byte) []string {
+	definition, err := decodeDefinition(data)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	return definition.Validate()
+}
+
+// decodeDefinition unmarshals a definition, rejecting unknown fields so a
+// misspelt one isn't silently ignored
+func decodeDefinition(data []byte) (Definition, error) {
+	var definition Definition
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(&definition)
+	return definition, err
+}
+
+// Validate returns everything wrong with a definition, empty if it's usable
+func (d Definition) Validate() []string {
+	var problems []string
+	switch {
+	case d.Name == "":
+		problems = append(problems, "name is required")
+	case !definitionNameRegex.MatchString(d.Name):
+		problems = append(problems, fmt.Sprintf("name %q must be lower case letters, digits, - and _", d.Name))
+	case builtinDialects[d.Name]:
+		problems = append(problems, fmt.Sprintf("name %q is a built-in parser", d.Name))
+	}
+
+	check := func(field, pattern string, submatches int) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", field, err))
+		} else if re.NumSubexp() < submatches {
+			problems = append(problems, fmt.Sprintf("%s: %q needs a group capturing the list", field, pattern))
+		}
+	}
+	for i, pattern := range d.Title.Patterns {
+		check(fmt.Sprintf("title.patterns[%d]", i), pattern, 0)
+	}
+	for i, pattern := range d.Exits {
+		check(fmt.Sprintf("exits[%d]", i), pattern, 1)
+	}
+	if d.Prompt != "" {
+		check("prompt", d.Prompt, 0)
+	}
+	for i, pattern := range d.Items {
+		check(fmt.Sprintf("items[%d]", i), pattern, 1)
+	}
+	for i, pattern := range d.Mobs {
+		check(fmt.Sprintf("mobs[%d]", i), pattern, 1)
+	}
+
+	if len(d.Title.Patterns) == 0 && !d.Title.Brackets && !d.Title.TitleCase && !d.Title.Coloured {
+		problems = append(problems, "title needs at least one rule, or no room will ever be found")
+	}
+	return problems
+}
+
+// compile makes a parser from a definition already validated
+func (d Definition) compile() Parser {
+	compileAll := func(patterns []string) []*regexp.Regexp {
+		compiled := make([]*regexp.Regexp, len(patterns))
+		for i, pattern := range patterns {
+			compiled[i] = regexp.MustCompile(pattern)
+		}
+		return compiled
+	}
+
+	wolfmud := NewWolfMUDParser()
+	parser := &familyParser{
+		base:  wolfmud,
+		exits: compileAll(d.Exits),
+	}
+	if d.Prompt != "" {
+		parser.prompt = regexp.MustCompile(d.Prompt)
+	}
+
+	titles := compileAll(d.Title.Patterns)
+	rules := d.Title
+	parser.title = func(raw, cleaned string) (string, bool) {
+		for _, titleRegex := range titles {
+			if matches := titleRegex.FindStringSubmatch(cleaned); matches != nil {
+				if len(matches) > 1 && matches[1] != "" {
+					return strings.TrimSpace(matches[1]), true
+				}
+				return cleaned, true
+			}
+		}
+		if rules.Brackets && wolfmud.isRoomTitle(cleaned) {
+			return cleaned[1 : len(cleaned)-1], true
+		}
+		if rules.Coloured && colouredLine(raw) && shortLine(cleaned) {
+			return cleaned, true
+		}
+		if rules.TitleCase {
+			return titleByCase(raw, cleaned)
+		}
+		return "", false
+	}
+
+	items, mobs := compileAll(d.Items), compileAll(d.Mobs)
+	if len(items) > 0 || len(mobs) > 0 {
+		parser.special = func(cleaned string, output *ParsedOutput) bool {
+			for _, itemRegex := range items {
+				if matches := itemRegex.FindStringSubmatch(cleaned); matches != nil {
+					output.Type = TypeInventory
+					output.Items = splitList(matches[1])
+					return true
+				}
+			}
+			for _, mobRegex := range mobs {
+				if matches := mobRegex.FindStringSubmatch(cleaned); matches != nil {
+					output.Type = TypeMobs
+					output.Mobs = splitList(matches[1])
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return parser
+}
+
+// register makes a valid definition available by name, replacing any
+// earlier definition of the same name
+func (d Definition) register() {
+	dialectMux.Lock()
+	defer dialectMux.Unlock()
+	dialects[d.Name] = d.compile
+	custom[d.Name] = d
+}
+
+// LoadDir registers the definitions in a directory's .json files over the
+// bundled ones. A missing directory has none. A bad file is skipped and
+// reported, the rest still being loaded.
+func LoadDir(dir string) error {
+	files, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read parser directory: %w", err)
+	}
+
+	var errs []error
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, f.Name()))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to read %s: %w", f.Name(), err))
+			continue
+		}
+		definition, err := ParseDefinition(data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", f.Name(), err))
+			continue
+		}
+		definition.register()
+	}
+	return errors.Join(errs...)
+}
+
+// Definitions returns the parsers defined in JSON, bundled and loaded,
+// sorted by name; a good starting point for writing another
+func Definitions() []Definition {
+	dialectMux.RLock()
+	defer dialectMux.RUnlock()
+
+	definitions := make([]Definition, 0, len(custom))
+	for _, definition := range custom {
+		definitions = append(definitions, definition)
+	}
+	sort.Slice(definitions, func(i, j int) bool {
+		return definitions[i].Name < definitions[j].Name
+	})
+	return definitions
+}
@@ -0,0 +1,69 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// DefaultDialect is the parser used when none is chosen
+const DefaultDialect = "wolfmud"
+
+// Parser classifies the output of one family of MUD servers
+type Parser interface {
+	// ParseLine parses a single line of output
+	ParseLine(line string) *ParsedOutput
+	// ParseBlock parses several lines, folding each room's description
+	// into its title
+	ParseBlock(lines []string) []*ParsedOutput
+}
+
+var (
+	dialectMux sync.RWMutex
+	// dialects are the output formats understood, by name
+	dialects = map[string]func() Parser{
+		"wolfmud": func() Parser { return NewWolfMUDParser() },
+		"diku":    func() Parser { return NewDikuParser() },
+		"evennia": func() Parser { return NewEvenniaParser() },
+		"generic": func() Parser { return NewGenericParser() },
+	}
+)
+
+// Register adds a parser for a family of servers, replacing any already
+// registered under the name
+func Register(name string, create func() Parser) {
+	dialectMux.Lock()
+	defer dialectMux.Unlock()
+	name = strings.ToLower(strings.TrimSpace(name))
+	dialects[name] = create
+	delete(custom, name)
+}
+
+// ForDialect returns a parser for a family of servers; empty means the default
+func ForDialect(name string) (Parser, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "" {
+		name = DefaultDialect
+	}
+	dialectMux.RLock()
+	create, exists := dialects[name]
+	dialectMux.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("unknown parser dialect %q (known: %s)", name, strings.Join(DialectNames(), ", "))
+	}
+	return create(), nil
+}
+
+// DialectNames returns the known parser dialects, sorted
+func DialectNames() []string {
+	dialectMux.RLock()
+	defer dialectMux.RUnlock()
+
+	names := make([]string, 0, len(dialects))
+	for name := range dialects {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
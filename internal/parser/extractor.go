@@ -0,0 +1,261 @@
+package parser
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/llm"
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("Parser")
+
+// extractInstructions tells the model how to pick out what's in a room
+const extractInstructions = "You read one line of output from a text adventure and list the things in the room it mentions. " +
+	"Mobs are creatures and characters: people, animals, monsters. Items are objects that could be picked up or used. " +
+	`Reply with only a JSON object like {"items": ["a rusty sword"], "mobs": ["a goblin"]}, ` +
+	"copying each name as the line writes it, articles included. Use empty lists if it mentions nothing in the room."
+
+// maxExtracted bounds how many lines' answers are remembered
+const maxExtracted = 2000
+
+// extractQueueSize is how many lines can wait for the model before further
+// ones are left for their next appearance
+const extractQueueSize = 64
+
+// hereRegex matches lines the parser took for description that may well
+// say something is in the room, like "A goblin is here."
+var hereRegex = regexp.MustCompile(`(?i)\bhere\b[.!]?$`)
+
+// maxHereLength keeps the long description lines that happen to end in
+// "here" away from the model
+const maxHereLength = 120
+
+// ExtractorSettings configures asking a language model about the items and
+// mobs in lines the parser can't be sure of
+type ExtractorSettings struct {
+	Enabled  bool   `json:"enabled"`
+	Endpoint string `json:"endpoint"` // OpenAI-compatible base URL, e.g. Ollama's http://127.0.0.1:11434/v1
+	Model    string `json:"model"`
+}
+
+// DefaultExtractorSettings points at a local Ollama, switched off
+func DefaultExtractorSettings() ExtractorSettings {
+	return ExtractorSettings{
+		Endpoint: llm.DefaultEndpoint,
+		Model:    llm.DefaultModel,
+	}
+}
+
+// EntityResult is the items and mobs picked out of a line
+type EntityResult struct {
+	Items []string `json:"items"`
+	Mobs  []string `json:"mobs"`
+}
+
+// empty reports whether nothing was found
+func (r EntityResult) empty() bool {
+	return len(r.Items) == 0 && len(r.Mobs) == 0
+}
+
+// extractJob is a line waiting for the model, with what the parser made
+// of it
+type extractJob struct {
+	line  string
+	guess EntityResult
+}
+
+// EntityExtractor asks a small language model which items and mobs a line
+// mentions when the parser had to guess or found nothing in a line that
+// looks like it has some. The model is slow next to the parser, so lines
+// are analysed in the background: the first time a line is seen it keeps
+// the parser's guess, and every time after gets the model's answer. Each
+// distinct line is only ever sent once.
+type EntityExtractor struct {
+	mutex    sync.RWMutex
+	settings ExtractorSettings
+	apiKey   string
+	client   *llm.Client
+	cache    map[string]EntityResult // Answers by line
+	pending  map[string]bool         // Lines queued or being analysed
+	queue    chan extractJob
+	onResult func(guess, result EntityResult)
+}
+
+// NewEntityExtractor creates an extractor and starts its worker
+func NewEntityExtractor(settings ExtractorSettings) *EntityExtractor {
+	x := &EntityExtractor{
+		settings: settings,
+		client:   llm.NewClient(30 * time.Second),
+		cache:    make(map[string]EntityResult),
+		pending:  make(map[string]bool),
+		queue:    make(chan extractJob, extractQueueSize),
+	}
+	go x.run()
+	return x
+}
+
+// Settings returns the current settings
+func (x *EntityExtractor) Settings() ExtractorSettings {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+	return x.settings
+}
+
+// SetSettings replaces the settings. Changing model forgets the old one's
+// answers.
+func (x *EntityExtractor) SetSettings(settings ExtractorSettings) {
+	settings.Endpoint = strings.TrimRight(strings.TrimSpace(settings.Endpoint), "/")
+	settings.Model = strings.TrimSpace(settings.Model)
+
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	if settings.Model != x.settings.Model || settings.Endpoint != x.settings.Endpoint {
+		x.cache = make(map[string]EntityResult)
+	}
+	x.settings = settings
+}
+
+// SetAPIKey sets the key sent to hosted APIs. Empty removes it.
+func (x *EntityExtractor) SetAPIKey(key string) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.apiKey = key
+}
+
+// OnResult registers a callback for each answer, with what the parser had
+// guessed for the line, so the current room can be corrected
+func (x *EntityExtractor) OnResult(fn func(guess, result EntityResult)) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+	x.onResult = fn
+}
+
+// Apply fills in a parsed line's items and mobs from the model's answer if
+// there is one, or queues the line to be asked about
+func (x *EntityExtractor) Apply(parsed *ParsedOutput) {
+	settings := x.Settings()
+	if !settings.Enabled || settings.Endpoint == "" || settings.Model == "" || !uncertain(parsed) {
+		return
+	}
+	line := strings.TrimSpace(parsed.CleanText)
+
+	x.mutex.Lock()
+	result, answered := x.cache[line]
+	if !answered && !x.pending[line] {
+		select {
+		case x.queue <- extractJob{line: line, guess: EntityResult{Items: parsed.Items, Mobs: parsed.Mobs}}:
+			x.pending[line] = true
+		default:
+			// The model is behind; the line will be asked about when next seen
+		}
+	}
+	x.mutex.Unlock()
+
+	if answered && !result.empty() {
+		applyResult(parsed, result)
+	}
+}
+
+// uncertain reports whether the parser's take on a line's entities could
+// use a second opinion
+func uncertain(parsed *ParsedOutput) bool {
+	switch parsed.Type {
+	case TypeInventory, TypeMobs:
+		return parsed.EntitiesGuessed
+	case TypeRoomDescription:
+		text := strings.TrimSpace(parsed.CleanText)
+		return len(text) <= maxHereLength && hereRegex.MatchString(text)
+	}
+	return false
+}
+
+// applyResult replaces a line's items and mobs. A line that was taken for
+// description stays description, so the room's text and ID don't change.
+func applyResult(parsed *ParsedOutput, result EntityResult) {
+	parsed.Items, parsed.Mobs = result.Items, result.Mobs
+	parsed.EntitiesGuessed = false
+	if parsed.Type == TypeInventory || parsed.Type == TypeMobs {
+		parsed.Type = TypeInventory
+		if len(result.Mobs) > 0 {
+			parsed.Type = TypeMobs
+		}
+	}
+}
+
+// run asks the model about queued lines one at a time
+func (x *EntityExtractor) run() {
+	for job := range x.queue {
+		result, err := x.extract(job.line)
+
+		x.mutex.Lock()
+		delete(x.pending, job.line)
+		if err == nil {
+			if len(x.cache) >= maxExtracted {
+				x.cache = make(map[string]EntityResult)
+			}
+			x.cache[job.line] = result
+		}
+		onResult := x.onResult
+		x.mutex.Unlock()
+
+		if err != nil {
+			logger.Warn("failed to extract entities", "line", job.line, "error", err)
+			continue
+		}
+		logger.Debug("extracted entities", "line", job.line, "items", result.Items, "mobs", result.Mobs)
+		if onResult != nil && !result.empty() {
+			onResult(job.guess, result)
+		}
+	}
+}
+
+// extract asks the model which items and mobs a line mentions
+func (x *EntityExtractor) extract(line string) (EntityResult, error) {
+	x.mutex.RLock()
+	endpoint := llm.Endpoint{URL: x.settings.Endpoint, Model: x.settings.Model, APIKey: x.apiKey}
+	x.mutex.RUnlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	reply, err := x.client.Complete(ctx, endpoint, llm.Request{
+		Instructions: extractInstructions,
+		Prompt:       line,
+		JSON:         true,
+	})
+	if err != nil {
+		return EntityResult{}, err
+	}
+
+	// Some models wrap the object in prose or a code fence regardless
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return EntityResult{}, fmt.Errorf("no JSON in reply %q", reply)
+	}
+	var result EntityResult
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &result); err != nil {
+		return EntityResult{}, fmt.Errorf("failed to decode reply: %w", err)
+	}
+	result.Items, result.Mobs = cleanNames(result.Items), cleanNames(result.Mobs)
+	return result, nil
+}
+
+// cleanNames drops blank and repeated names
+func cleanNames(names []string) []string {
+	seen := make(map[string]bool)
+	var cleaned []string
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name != "" && !seen[name] {
+			seen[name] = true
+			cleaned = append(cleaned, name)
+		}
+	}
+	return cleaned
+}
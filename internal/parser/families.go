@@ -0,0 +1,271 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"seemud-gui/internal/ansi"
+)
+
+// familyParser parses servers other than WolfMUD. Chat, combat, things in
+// the room and system messages read much the same everywhere, so those
+// come from the WolfMUD rules; what differs is how a family writes room
+// titles, exits and prompts.
+type familyParser struct {
+	base   *WolfMUDParser
+	prompt *regexp.Regexp
+	exits  []*regexp.Regexp // First submatch is the list of exits
+	// title reports whether a line is a room title and the room's name
+	title func(raw, cleaned string) (string, bool)
+	// special parses lines peculiar to the family, such as Evennia's
+	// "Characters: Bob and Alice"; nil if it has none
+	special func(cleaned string, output *ParsedOutput) bool
+}
+
+// ParseLine parses a single line of MUD output
+func (p *familyParser) ParseLine(line string) *ParsedOutput {
+	output := newParsedOutput(line)
+	cleaned := output.CleanText
+	trimmed := strings.TrimSpace(cleaned)
+
+	if trimmed == "" || p.base.parseSession(cleaned, output) {
+		return output
+	}
+
+	if p.prompt != nil && p.prompt.MatchString(trimmed) {
+		output.Type = TypePrompt
+		output.Content = cleaned
+		return output
+	}
+
+	for _, exitRegex := range p.exits {
+		if matches := exitRegex.FindStringSubmatch(trimmed); matches != nil {
+			output.Type = TypeExits
+			output.Content = matches[1]
+			output.Exits = splitExits(matches[1])
+			return output
+		}
+	}
+
+	if p.special != nil && p.special(trimmed, output) {
+		output.Content = cleaned
+		return output
+	}
+
+	if name, ok := p.title(line, trimmed); ok {
+		output.Type = TypeRoomTitle
+		output.Content = cleaned
+		output.RoomName = name
+		output.IsRoomEntry = true
+		return output
+	}
+
+	p.base.parseContent(cleaned, output)
+	return output
+}
+
+// ParseBlock parses several lines, folding each room's description into
+// its title
+func (p *familyParser) ParseBlock(lines []string) []*ParsedOutput {
+	return parseBlock(p, lines)
+}
+
+// NewDikuParser creates a parser for DikuMUD and its descendants: ROM,
+// Merc, CircleMUD and the like. Room titles stand alone on a line, exits
+// come as "[Exits: north east]" or "Obvious exits:", and prompts look like
+// "<20hp 100m 80mv>".
+func NewDikuParser() Parser {
+	return &familyParser{
+		base:   NewWolfMUDParser(),
+		prompt: regexp.MustCompile(`(?i)^(?:<[^<>]*\d[^<>]*>|\d+\s*h(?:p)?\s+\d+\s*m\s+\d+\s*(?:mv|v)\b.*>)\s*$`),
+		exits: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)^\[\s*exits?:\s*(.*?)\s*\]$`),
+			regexp.MustCompile(`(?i)^(?:obvious )?exits?:\s*(.*)$`),
+		},
+		title:   titleByCase,
+		special: dikuChat,
+	}
+}
+
+// NewEvenniaParser creates a parser for Evennia games as they come out of
+// the box. The room's name is a line to itself, usually in bold cyan,
+// followed by "Exits: north, south and east", "Characters: Bob" and
+// "You see: a box and a lamp".
+func NewEvenniaParser() Parser {
+	return &familyParser{
+		base: NewWolfMUDParser(),
+		exits: []*regexp.Regexp{
+			regexp.MustCompile(`^Exits:\s*(.+)$`),
+		},
+		title: func(raw, cleaned string) (string, bool) {
+			if colouredLine(raw) && shortLine(cleaned) {
+				return cleaned, true
+			}
+			return titleByCase(raw, cleaned)
+		},
+		special: evenniaLists,
+	}
+}
+
+// NewGenericParser creates a parser for servers of no particular family,
+// taking titles in brackets or title case, exits in any of the common
+// forms and prompts in angle brackets
+func NewGenericParser() Parser {
+	wolfmud := NewWolfMUDParser()
+	return &familyParser{
+		base:   wolfmud,
+		prompt: regexp.MustCompile(`^<[^<>]*>\s*$`),
+		exits: []*regexp.Regexp{
+			regexp.MustCompile(`(?i)^\[\s*exits?:\s*(.*?)\s*\]$`),
+			regexp.MustCompile(`(?i)^(?:you see |obvious )?exits?:\s*(.+)$`),
+		},
+		title: func(raw, cleaned string) (string, bool) {
+			if wolfmud.isRoomTitle(cleaned) {
+				return cleaned[1 : len(cleaned)-1], true
+			}
+			return titleByCase(raw, cleaned)
+		},
+	}
+}
+
+// Diku chat puts what's said in single quotes: "Bob says 'hello'"
+var (
+	dikuSayRegex      = regexp.MustCompile(`^(You|[A-Z][\w'-]*) (?:says?|asks?|exclaims?)(?: to [^']+)? '(.+)'$`)
+	dikuTellRegex     = regexp.MustCompile(`^([A-Z][\w'-]*) tells you '(.+)'$`)
+	dikuTellSentRegex = regexp.MustCompile(`^You tell ([A-Z][\w'-]*) '(.+)'$`)
+)
+
+// dikuChat parses says and tells in the Diku style
+func dikuChat(cleaned string, output *ParsedOutput) bool {
+	if matches := dikuTellRegex.FindStringSubmatch(cleaned); matches != nil {
+		output.Type, output.Speaker, output.Message = TypeTell, matches[1], matches[2]
+		return true
+	}
+	if matches := dikuTellSentRegex.FindStringSubmatch(cleaned); matches != nil {
+		output.Type, output.Speaker, output.Message = TypeTell, matches[1], matches[2]
+		output.Outgoing = true
+		return true
+	}
+	if matches := dikuSayRegex.FindStringSubmatch(cleaned); matches != nil {
+		output.Type, output.Speaker, output.Message = TypeSay, matches[1], matches[2]
+		output.Outgoing = matches[1] == "You"
+		return true
+	}
+	return false
+}
+
+// evenniaListRegex matches Evennia's lists of what's in a room
+var evenniaListRegex = regexp.MustCompile(`^(Characters|You see):\s*(.+)$`)
+
+// evenniaLists parses "Characters: Bob and Alice" as mobs and
+// "You see: a box, a lamp" as items
+func evenniaLists(cleaned string, output *ParsedOutput) bool {
+	matches := evenniaListRegex.FindStringSubmatch(cleaned)
+	if matches == nil {
+		return false
+	}
+	names := splitList(matches[2])
+	if matches[1] == "Characters" {
+		output.Type = TypeMobs
+		output.Mobs = names
+	} else {
+		output.Type = TypeInventory
+		output.Items = names
+	}
+	return true
+}
+
+// roomFlagsRegex matches what some servers put after a room's name, such
+// as "[Room 3001]" or "(Lit)"
+var roomFlagsRegex = regexp.MustCompile(`(?:\s*(?:\[[^\]]*\]|\([^)]*\)))+$`)
+
+// minorWords may be lower case in a title
+var minorWords = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "by": true, "for": true, "from": true,
+	"in": true, "into": true, "near": true, "of": true, "on": true, "or": true,
+	"the": true, "to": true, "under": true, "with": true,
+}
+
+// titleByCase takes a short line in title case without closing
+// punctuation, like "The Temple Of Midgaard", for a room title. Wrapped
+// description rarely passes, since it has lower case words.
+func titleByCase(raw, cleaned string) (string, bool) {
+	name := strings.TrimSpace(roomFlagsRegex.ReplaceAllString(cleaned, ""))
+	if !shortLine(name) {
+		return "", false
+	}
+	words := strings.Fields(name)
+	if len(words) > 8 {
+		return "", false
+	}
+	for i, word := range words {
+		first := word[0]
+		switch {
+		case first >= 'A' && first <= 'Z', first >= '0' && first <= '9':
+		case i > 0 && minorWords[strings.ToLower(word)]:
+		default:
+			return "", false
+		}
+	}
+	return name, true
+}
+
+// shortLine reports whether a line is short enough for a room name and
+// doesn't end like a sentence or start a list
+func shortLine(line string) bool {
+	if len(line) < 3 || len(line) > 60 {
+		return false
+	}
+	if first := line[0]; !(first >= 'A' && first <= 'Z') {
+		return false
+	}
+	return !strings.ContainsAny(line[len(line)-1:], ".!?:,;\"'") && !strings.Contains(line, ": ")
+}
+
+// colouredLine reports whether all of a line's text is in one colour other
+// than the default, as many servers write room names
+func colouredLine(raw string) bool {
+	colour := ""
+	for _, span := range ansi.Spans(raw) {
+		if strings.TrimSpace(span.Text) == "" {
+			continue
+		}
+		if span.FG == "" || (colour != "" && span.FG != colour) {
+			return false
+		}
+		colour = span.FG
+	}
+	return colour != ""
+}
+
+// splitExits reads exits separated by commas and "and", or by spaces if
+// there are neither, dropping the brackets some servers put round closed
+// doors. "none" is no exits.
+func splitExits(list string) []string {
+	names := splitList(list)
+	if len(names) == 1 {
+		names = strings.Fields(names[0])
+	}
+	var exits []string
+	for _, exit := range names {
+		exit = strings.ToLower(strings.Trim(exit, "()[]<>*"))
+		if exit != "" && exit != "none" {
+			exits = append(exits, exit)
+		}
+	}
+	return exits
+}
+
+// splitList reads a list like "a box, a lamp and a sword"
+func splitList(list string) []string {
+	list = strings.TrimRight(strings.TrimSpace(list), ".")
+	var names []string
+	for _, part := range strings.Split(list, ",") {
+		for _, name := range strings.Split(part, " and ") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -3,6 +3,8 @@ package parser
 import (
 	"regexp"
 	"strings"
+
+	"seemud-gui/internal/ansi"
 )
 
 // WolfMUDParser handles parsing of WolfMUD specific output format
@@ -12,7 +14,14 @@ type WolfMUDParser struct {
 	exitRegex      *regexp.Regexp
 	inventoryRegex *regexp.Regexp
 	entityRegex    *regexp.Regexp // For "You see X here." pattern
-	colorCodeRegex *regexp.Regexp
+	sayRegex       *regexp.Regexp
+	tellRegex      *regexp.Regexp
+	tellSentRegex  *regexp.Regexp
+	channelRegex   *regexp.Regexp
+	loginRegex     *regexp.Regexp
+	menuRegex      *regexp.Regexp
+	attackRegex    *regexp.Regexp // Player attacking
+	attackedRegex  *regexp.Regexp // Something attacking the player
 }
 
 // OutputType represents the type of parsed content
@@ -29,19 +38,67 @@ const (
 	TypeSystem
 	TypeSay
 	TypeTell
+	TypeChannel
+	TypeLoginPrompt
+	TypeMenu
+	TypeCombat
 )
 
+// typeNames are the stable names used when output types leave the Go side
+var typeNames = map[OutputType]string{
+	TypeUnknown:         "unknown",
+	TypeRoomDescription: "room_description",
+	TypeRoomTitle:       "room_title",
+	TypeExits:           "exits",
+	TypeInventory:       "inventory",
+	TypeMobs:            "mobs",
+	TypePrompt:          "prompt",
+	TypeSystem:          "system",
+	TypeSay:             "say",
+	TypeTell:            "tell",
+	TypeChannel:         "channel",
+	TypeLoginPrompt:     "login_prompt",
+	TypeMenu:            "menu",
+	TypeCombat:          "combat",
+}
+
+// String returns the output type's stable name
+func (t OutputType) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return "unknown"
+}
+
 // ParsedOutput represents a parsed line from the MUD
 type ParsedOutput struct {
 	Type        OutputType
 	Content     string
 	CleanText   string
 	RawText     string
+	Spans       []ansi.Span // RawText split where its colours change
 	RoomName    string
 	Exits       []string
 	Items       []string
 	Mobs        []string
 	IsRoomEntry bool
+	// EntitiesGuessed is set when Items and Mobs were told apart by
+	// guesswork, e.g. from articles and capitals, so may be wrong
+	EntitiesGuessed bool
+
+	// Chat fields, set for TypeSay, TypeTell and TypeChannel
+	Speaker  string
+	Channel  string
+	Message  string
+	Outgoing bool // True when the player is the speaker
+
+	// Combat fields, set for TypeCombat
+	Opponent string
+	Incoming bool // True when the opponent is attacking the player
+
+	// Highlight is the watch-list pattern the line matched, set by the
+	// engine rather than the parser; empty if none did
+	Highlight string
 }
 
 // NewWolfMUDParser creates a new parser for WolfMUD
@@ -51,30 +108,43 @@ func NewWolfMUDParser() *WolfMUDParser {
 		exitRegex:      regexp.MustCompile(`^(?:You see )?[Ee]xits?:\s*(.+)$`),
 		inventoryRegex: regexp.MustCompile(`^(A|An|The)\s+.*\s+(is|are|sits?|lies?|stands?|rests?)\s+.*\.$`),
 		entityRegex:    regexp.MustCompile(`^You see\s+(.+?)\s+here\.$`),
-		colorCodeRegex: regexp.MustCompile(`\x1b\[[0-9;]*m`),
+		sayRegex:       regexp.MustCompile(`^(You|[A-Z][\w'-]*(?: [A-Z][\w'-]*)?) (?:says?|asks?|exclaims?|whispers?)(?: to [^:]+)?: "?(.+?)"?$`),
+		tellRegex:      regexp.MustCompile(`^([A-Z][\w'-]*) tells you: "?(.+?)"?$`),
+		tellSentRegex:  regexp.MustCompile(`^You tell ([A-Z][\w'-]*): "?(.+?)"?$`),
+		channelRegex:   regexp.MustCompile(`^\[([A-Za-z][\w -]{0,19})\] ([A-Z][\w'-]*): (.+)$`),
+		loginRegex:     regexp.MustCompile(`(?i)^(?:enter (?:your )?(?:account|password|name)|account(?: id)?:|password:|login:|what is your name|by what name)`),
+		menuRegex:      regexp.MustCompile(`(?i)^(?:main menu|select (?:an )?option|(?:select|choose) (?:a |your )?character|\s*\d+[.)]\s+(?:enter|play|create|select|delete)\b)`),
+		attackRegex:    regexp.MustCompile(`^You (?:hit|strike|slash|stab|punch|kick|bite|claw|miss|attack|kill|wound)s? (.+?)(?: (?:with|for|but) .*)?[.!]$`),
+		attackedRegex:  regexp.MustCompile(`^(.+?) (?:hits|strikes|slashes|stabs|punches|kicks|bites|claws|misses|attacks|wounds) you\b.*[.!]$`),
 	}
 }
 
-// ParseLine parses a single line of MUD output
-func (p *WolfMUDParser) ParseLine(line string) *ParsedOutput {
-	output := &ParsedOutput{
-		RawText: line,
-		Type:    TypeUnknown,
+// newParsedOutput starts parsing a line, keeping its colours for display
+// and stripping them for analysis
+func newParsedOutput(line string) *ParsedOutput {
+	return &ParsedOutput{
+		RawText:   line,
+		Spans:     ansi.Spans(line),
+		Type:      TypeUnknown,
+		Content:   ansi.Sanitize(line), // Control codes removed, colours kept for display
+		CleanText: ansi.Strip(line),    // Everything removed, for analysis
 	}
+}
 
-	// Remove control codes but preserve colors for display
-	withColors := p.stripControlCodes(line)
-	output.Content = withColors
-
-	// Remove all codes for text analysis
-	cleaned := p.stripColorCodes(withColors)
-	output.CleanText = cleaned
+// ParseLine parses a single line of MUD output
+func (p *WolfMUDParser) ParseLine(line string) *ParsedOutput {
+	output := newParsedOutput(line)
+	cleaned := output.CleanText
 
 	// Skip empty lines
 	if strings.TrimSpace(cleaned) == "" {
 		return output
 	}
 
+	if p.parseSession(cleaned, output) {
+		return output
+	}
+
 	// Check if this looks like a room title first (bracketed titles like [South bridge])
 	if p.isRoomTitle(cleaned) {
 		output.Type = TypeRoomTitle
@@ -104,21 +174,69 @@ func (p *WolfMUDParser) ParseLine(line string) *ParsedOutput {
 		return output
 	}
 
+	p.parseContent(cleaned, output)
+	return output
+}
+
+// parseSession detects login and menu prompts, which drive the connection
+// state machine
+func (p *WolfMUDParser) parseSession(cleaned string, output *ParsedOutput) bool {
+	if p.loginRegex.MatchString(cleaned) {
+		output.Type = TypeLoginPrompt
+		output.Content = cleaned
+		return true
+	}
+	if p.menuRegex.MatchString(cleaned) {
+		output.Type = TypeMenu
+		output.Content = cleaned
+		return true
+	}
+	return false
+}
+
+// parseContent classifies what's left once titles, prompts and exits are
+// ruled out: chat, combat, things in the room, system messages and, failing
+// all of those, description. Most MUD families share these.
+func (p *WolfMUDParser) parseContent(cleaned string, output *ParsedOutput) {
+	// Check for chat before system messages, as "You say" would otherwise
+	// be swallowed by the "You ..." system prefixes
+	if p.parseChat(cleaned, output) {
+		output.Content = cleaned
+		return
+	}
+
+	if matches := p.attackRegex.FindStringSubmatch(cleaned); matches != nil {
+		output.Type = TypeCombat
+		output.Content = cleaned
+		output.Opponent = matches[1]
+		return
+	}
+	if matches := p.attackedRegex.FindStringSubmatch(cleaned); matches != nil {
+		output.Type = TypeCombat
+		output.Content = cleaned
+		output.Opponent = matches[1]
+		output.Incoming = true
+		return
+	}
+
 	// Check for entities (items/mobs) with "You see X here." pattern
 	if matches := p.entityRegex.FindStringSubmatch(cleaned); matches != nil {
 		entityName := matches[1]
 
 		// Determine if it's likely a mob (NPC) or item based on name
 		// Mobs typically have proper names or titles, items have articles
-		if p.isLikelyMob(entityName) {
+		mob, sure := p.isLikelyMob(entityName)
+		if mob {
 			output.Type = TypeMobs
 			output.Mobs = []string{entityName}
 		} else {
 			output.Type = TypeInventory
 			output.Items = []string{entityName}
 		}
+		// "You see a sword and a goblin here." is several things at once
+		output.EntitiesGuessed = !sure || strings.Contains(entityName, ",") || strings.Contains(entityName, " and ")
 		output.Content = cleaned
-		return output
+		return
 	}
 
 	// Check for inventory items (things in the room) - legacy pattern
@@ -126,25 +244,31 @@ func (p *WolfMUDParser) ParseLine(line string) *ParsedOutput {
 		output.Type = TypeInventory
 		output.Content = cleaned
 		output.Items = []string{p.extractItemName(cleaned)}
-		return output
+		// "A goblin stands here." matches too
+		output.EntitiesGuessed = true
+		return
 	}
 
 	// Check for system messages
 	if p.isSystemMessage(cleaned) {
 		output.Type = TypeSystem
 		output.Content = cleaned
-		return output
+		return
 	}
 
 	// Default to room description or general content
 	output.Type = TypeRoomDescription
 	output.Content = cleaned
+}
 
-	return output
+// ParseBlock parses several lines, folding each room's description into
+// its title
+func (p *WolfMUDParser) ParseBlock(lines []string) []*ParsedOutput {
+	return parseBlock(p, lines)
 }
 
-// ParseMultipleLines parses multiple lines and groups them logically
-func (p *WolfMUDParser) ParseMultipleLines(lines []string) []*ParsedOutput {
+// parseBlock implements ParseBlock for any parser
+func parseBlock(p Parser, lines []string) []*ParsedOutput {
 	var results []*ParsedOutput
 	var currentRoom *ParsedOutput
 
@@ -166,31 +290,6 @@ func (p *WolfMUDParser) ParseMultipleLines(lines []string) []*ParsedOutput {
 	return results
 }
 
-// stripControlCodes removes ANSI control sequences but preserves color codes
-func (p *WolfMUDParser) stripControlCodes(text string) string {
-	// Remove cursor position sequences like ESC[255;255H
-	cleaned := regexp.MustCompile(`\x1b\[[0-9]+;[0-9]+[HfABCDEFGJKST]`).ReplaceAllString(text, "")
-
-	// Remove cursor movement sequences
-	cleaned = regexp.MustCompile(`\x1b\[[ABCD]`).ReplaceAllString(cleaned, "")
-
-	// Remove other escape sequences (ESC followed by single character)
-	cleaned = regexp.MustCompile(`\x1b[78]`).ReplaceAllString(cleaned, "")
-
-	// Remove screen clear sequences
-	cleaned = regexp.MustCompile(`\x1b\[2J`).ReplaceAllString(cleaned, "")
-
-	// Remove other control sequences but keep color codes (ESC[...m)
-	cleaned = regexp.MustCompile(`\x1b\[[0-9]*[JK]`).ReplaceAllString(cleaned, "")
-
-	return cleaned
-}
-
-// stripColorCodes removes ANSI color codes for clean text processing
-func (p *WolfMUDParser) stripColorCodes(text string) string {
-	return p.colorCodeRegex.ReplaceAllString(text, "")
-}
-
 // parseExits parses the exits string into a slice
 func (p *WolfMUDParser) parseExits(exitStr string) []string {
 	// Handle common exit formats: "north, south, east" or "n, s, e"
@@ -224,7 +323,7 @@ func (p *WolfMUDParser) extractItemName(line string) string {
 			continue
 		}
 		if lower == "is" || lower == "are" || lower == "sits" ||
-		   lower == "lies" || lower == "stands" || lower == "rests" {
+			lower == "lies" || lower == "stands" || lower == "rests" {
 			// Found verb, item name is between start and here
 			if i > start {
 				return strings.Join(words[start:i], " ")
@@ -267,6 +366,44 @@ func (p *WolfMUDParser) isRoomTitle(line string) bool {
 	return false
 }
 
+// parseChat detects says, tells and channel messages, filling in the chat fields
+func (p *WolfMUDParser) parseChat(line string, output *ParsedOutput) bool {
+	if matches := p.tellRegex.FindStringSubmatch(line); matches != nil {
+		output.Type = TypeTell
+		output.Speaker = matches[1]
+		output.Message = matches[2]
+		return true
+	}
+
+	if matches := p.tellSentRegex.FindStringSubmatch(line); matches != nil {
+		// Outgoing tells keep the recipient as the speaker so conversations
+		// with one player group together
+		output.Type = TypeTell
+		output.Speaker = matches[1]
+		output.Message = matches[2]
+		output.Outgoing = true
+		return true
+	}
+
+	if matches := p.channelRegex.FindStringSubmatch(line); matches != nil {
+		output.Type = TypeChannel
+		output.Channel = strings.ToLower(strings.TrimSpace(matches[1]))
+		output.Speaker = matches[2]
+		output.Message = matches[3]
+		return true
+	}
+
+	if matches := p.sayRegex.FindStringSubmatch(line); matches != nil {
+		output.Type = TypeSay
+		output.Speaker = matches[1]
+		output.Message = matches[2]
+		output.Outgoing = matches[1] == "You"
+		return true
+	}
+
+	return false
+}
+
 // isSystemMessage checks if a line is a system message
 func (p *WolfMUDParser) isSystemMessage(line string) bool {
 	// Common system message patterns
@@ -282,6 +419,12 @@ func (p *WolfMUDParser) isSystemMessage(line string) bool {
 		"You feel",
 		"You hear",
 		"You smell",
+		"You get",
+		"You take",
+		"You drop",
+		"You wear",
+		"You wield",
+		"You remove",
 	}
 
 	for _, prefix := range systemPrefixes {
@@ -293,16 +436,17 @@ func (p *WolfMUDParser) isSystemMessage(line string) bool {
 	return false
 }
 
-// isLikelyMob determines if an entity name is likely a mob/NPC vs an item
-func (p *WolfMUDParser) isLikelyMob(name string) bool {
+// isLikelyMob determines if an entity name is likely a mob/NPC vs an item.
+// sure is false when it came down to articles and capitals.
+func (p *WolfMUDParser) isLikelyMob(name string) (mob, sure bool) {
 	nameLower := strings.ToLower(name)
 
 	// Items typically start with articles
 	if strings.HasPrefix(nameLower, "a ") ||
-	   strings.HasPrefix(nameLower, "an ") ||
-	   strings.HasPrefix(nameLower, "the ") ||
-	   strings.HasPrefix(nameLower, "some ") {
-		return false
+		strings.HasPrefix(nameLower, "an ") ||
+		strings.HasPrefix(nameLower, "the ") ||
+		strings.HasPrefix(nameLower, "some ") {
+		return false, false
 	}
 
 	// Mobs often have titles or are proper names without articles
@@ -316,15 +460,15 @@ func (p *WolfMUDParser) isLikelyMob(name string) bool {
 
 	for _, indicator := range mobIndicators {
 		if strings.Contains(nameLower, indicator) {
-			return true
+			return true, true
 		}
 	}
 
 	// If it starts with a capital letter and no article, likely a proper name (mob)
 	if len(name) > 0 && name[0] >= 'A' && name[0] <= 'Z' {
-		return true
+		return true, false
 	}
 
 	// Default to item
-	return false
-}
\ No newline at end of file
+	return false, false
+}
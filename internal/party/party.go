@@ -0,0 +1,398 @@
+package party
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/mapper"
+)
+
+var logger = logging.For("Party")
+
+// Message types sent between party members
+const (
+	TypeHello    = "hello"    // Joined; the others reply with their maps
+	TypeRooms    = "rooms"    // Rooms someone mapped
+	TypePosition = "position" // Where someone is
+	TypeLeave    = "leave"    // Someone disconnected; only the relay sends it
+)
+
+// retryInterval is how long to wait before rejoining after losing the relay
+const retryInterval = 5 * time.Second
+
+// Settings configures party map sync
+type Settings struct {
+	Enabled bool   `json:"enabled"`
+	URL     string `json:"url"`    // Relay to join, e.g. ws://host:4060/party
+	Party   string `json:"party"`  // Members joining the same party share a map; anyone who knows it can join
+	Name    string `json:"name"`   // Shown to the others beside your marker
+	Host    bool   `json:"host"`   // Run the relay here for the others to join, ignoring URL
+	Listen  string `json:"listen"` // host:port the relay listens on when hosting
+}
+
+// DefaultSettings hosts on every interface, since the point is for others
+// to join
+func DefaultSettings() Settings {
+	return Settings{
+		Listen: ":4060",
+	}
+}
+
+// Message is the envelope for everything sent through the relay
+type Message struct {
+	Type   string          `json:"type"`
+	From   string          `json:"from,omitempty"`   // Set by the relay
+	Server string          `json:"server,omitempty"` // The MUD the sender is mapping
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// Rooms is the data of a rooms message
+type Rooms struct {
+	Rooms []*mapper.Room `json:"rooms"`
+	Exits []*mapper.Exit `json:"exits"`
+}
+
+// position is the data of a position message
+type position struct {
+	RoomID   string `json:"room_id"`
+	RoomName string `json:"room_name"`
+}
+
+// Member is another player in the party and where they last were
+type Member struct {
+	Name     string    `json:"name"`
+	Server   string    `json:"server"`
+	RoomID   string    `json:"room_id"`
+	RoomName string    `json:"room_name"`
+	Updated  time.Time `json:"updated"`
+}
+
+// Handler receives what the rest of the party sends. Any field may be nil.
+type Handler struct {
+	Rooms   func(server string, rooms Rooms)
+	Members func(members []Member)
+	// Joined is called with the name of someone who just joined, or "" once
+	// this client has; either way it's the time to share the whole map
+	Joined func(name string)
+}
+
+// Client is this player's link to a party
+type Client struct {
+	mutex     sync.RWMutex
+	settings  Settings
+	handler   Handler
+	relay     *http.Server // Set while hosting
+	conn      *websocket.Conn
+	members   map[string]Member
+	stop      chan struct{}
+	writeMux  sync.Mutex
+	connected bool
+}
+
+// NewClient creates a client that isn't in a party
+func NewClient(handler Handler) *Client {
+	return &Client{
+		settings: DefaultSettings(),
+		handler:  handler,
+		members:  make(map[string]Member),
+	}
+}
+
+// Settings returns the current settings
+func (c *Client) Settings() Settings {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.settings
+}
+
+// Connected reports whether the client is in a party right now
+func (c *Client) Connected() bool {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.connected
+}
+
+// Members returns the others in the party, sorted by name
+func (c *Client) Members() []Member {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	members := make([]Member, 0, len(c.members))
+	for _, member := range c.members {
+		members = append(members, member)
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	return members
+}
+
+// Apply stores new settings, leaving any party and joining the new one if
+// enabled. An empty party name is replaced with a random one to share.
+func (c *Client) Apply(settings Settings) (Settings, error) {
+	settings.Name = strings.TrimSpace(settings.Name)
+	if settings.Listen == "" {
+		settings.Listen = DefaultSettings().Listen
+	}
+	if settings.Party == "" {
+		name, err := newPartyName()
+		if err != nil {
+			return c.Settings(), err
+		}
+		settings.Party = name
+	}
+
+	c.Stop()
+
+	c.mutex.Lock()
+	c.settings = settings
+	c.mutex.Unlock()
+
+	if !settings.Enabled {
+		return settings, nil
+	}
+	if settings.Name == "" {
+		return settings, fmt.Errorf("a name is needed to join a party")
+	}
+
+	relayURL := settings.URL
+	if settings.Host {
+		server, addr, err := NewRelay().Listen(settings.Listen)
+		if err != nil {
+			return settings, err
+		}
+		_, port, _ := net.SplitHostPort(addr.String())
+		relayURL = "ws://" + net.JoinHostPort("127.0.0.1", port) + RelayPath
+		c.mutex.Lock()
+		c.relay = server
+		c.mutex.Unlock()
+	}
+	if relayURL == "" {
+		return settings, fmt.Errorf("no relay to join")
+	}
+	address, err := joinURL(relayURL, settings)
+	if err != nil {
+		return settings, err
+	}
+
+	stop := make(chan struct{})
+	c.mutex.Lock()
+	c.stop = stop
+	c.mutex.Unlock()
+	go c.run(address, stop)
+	return settings, nil
+}
+
+// newPartyName returns a random party name, hard enough to guess that it
+// keeps strangers out
+func newPartyName() (string, error) {
+	buf := make([]byte, 6)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate party name: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// joinURL adds the party and name to the relay's address
+func joinURL(relayURL string, settings Settings) (string, error) {
+	u, err := url.Parse(relayURL)
+	if err != nil || (u.Scheme != "ws" && u.Scheme != "wss") {
+		return "", fmt.Errorf("invalid relay address %q", relayURL)
+	}
+	query := u.Query()
+	query.Set("party", settings.Party)
+	query.Set("name", settings.Name)
+	u.RawQuery = query.Encode()
+	return u.String(), nil
+}
+
+// Stop leaves the party and stops hosting
+func (c *Client) Stop() {
+	c.mutex.Lock()
+	stop, relay, conn := c.stop, c.relay, c.conn
+	c.stop, c.relay, c.conn = nil, nil, nil
+	c.connected = false
+	c.members = make(map[string]Member)
+	c.mutex.Unlock()
+
+	if stop != nil {
+		close(stop)
+	}
+	if conn != nil {
+		conn.Close()
+	}
+	if relay != nil {
+		relay.Close()
+	}
+	c.notifyMembers()
+}
+
+// run stays in the party until stopped, rejoining whenever the relay drops
+func (c *Client) run(address string, stop chan struct{}) {
+	for {
+		conn, _, err := websocket.DefaultDialer.Dial(address, nil)
+		if err != nil {
+			logger.Warn("failed to join party", "error", err)
+		} else {
+			c.session(conn, stop)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(retryInterval):
+		}
+	}
+}
+
+// session reads from the relay until the connection ends
+func (c *Client) session(conn *websocket.Conn, stop chan struct{}) {
+	conn.SetReadLimit(maxMessage)
+	c.mutex.Lock()
+	select {
+	case <-stop:
+		c.mutex.Unlock()
+		conn.Close()
+		return
+	default:
+	}
+	c.conn, c.connected = conn, true
+	c.mutex.Unlock()
+	logger.Info("joined party", "party", c.Settings().Party)
+
+	defer func() {
+		c.mutex.Lock()
+		if c.conn == conn {
+			c.conn, c.connected = nil, false
+			c.members = make(map[string]Member)
+		}
+		c.mutex.Unlock()
+		conn.Close()
+		c.notifyMembers()
+	}()
+
+	c.Hello()
+	if c.handler.Joined != nil {
+		c.handler.Joined("")
+	}
+
+	for {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		c.receive(msg)
+	}
+}
+
+// receive handles one message from another member
+func (c *Client) receive(msg Message) {
+	switch msg.Type {
+	case TypeHello:
+		c.updateMember(msg.From, func(m *Member) {})
+		if c.handler.Joined != nil {
+			c.handler.Joined(msg.From)
+		}
+
+	case TypeRooms:
+		var rooms Rooms
+		if err := json.Unmarshal(msg.Data, &rooms); err != nil {
+			logger.Debug("ignoring bad rooms message", "from", msg.From, "error", err)
+			return
+		}
+		if c.handler.Rooms != nil {
+			c.handler.Rooms(msg.Server, rooms)
+		}
+
+	case TypePosition:
+		var pos position
+		if err := json.Unmarshal(msg.Data, &pos); err != nil {
+			return
+		}
+		c.updateMember(msg.From, func(m *Member) {
+			m.Server, m.RoomID, m.RoomName = msg.Server, pos.RoomID, pos.RoomName
+		})
+
+	case TypeLeave:
+		c.mutex.Lock()
+		delete(c.members, msg.From)
+		c.mutex.Unlock()
+		c.notifyMembers()
+	}
+}
+
+// updateMember changes what's known about a member, adding them if new
+func (c *Client) updateMember(name string, update func(*Member)) {
+	if name == "" {
+		return
+	}
+	c.mutex.Lock()
+	member := c.members[name]
+	member.Name = name
+	update(&member)
+	member.Updated = time.Now()
+	c.members[name] = member
+	c.mutex.Unlock()
+	c.notifyMembers()
+}
+
+// notifyMembers passes the member list to the handler
+func (c *Client) notifyMembers() {
+	if c.handler.Members != nil {
+		c.handler.Members(c.Members())
+	}
+}
+
+// send writes a message to the relay, if connected
+func (c *Client) send(msg Message) {
+	c.mutex.RLock()
+	conn := c.conn
+	c.mutex.RUnlock()
+	if conn == nil {
+		return
+	}
+
+	// Only one writer at a time may use a websocket connection
+	c.writeMux.Lock()
+	defer c.writeMux.Unlock()
+	conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := conn.WriteJSON(msg); err != nil {
+		logger.Debug("failed to send to party", "type", msg.Type, "error", err)
+	}
+}
+
+// Hello asks the others in the party to send their maps
+func (c *Client) Hello() {
+	c.send(Message{Type: TypeHello})
+}
+
+// SendRooms shares rooms mapped on a server
+func (c *Client) SendRooms(server string, rooms Rooms) {
+	if len(rooms.Rooms) == 0 {
+		return
+	}
+	data, err := json.Marshal(rooms)
+	if err != nil {
+		return
+	}
+	c.send(Message{Type: TypeRooms, Server: server, Data: data})
+}
+
+// SendPosition tells the party which room this player is in
+func (c *Client) SendPosition(server, roomID, roomName string) {
+	data, err := json.Marshal(position{RoomID: roomID, RoomName: roomName})
+	if err != nil {
+		return
+	}
+	c.send(Message{Type: TypePosition, Server: server, Data: data})
+}
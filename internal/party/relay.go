@@ -0,0 +1,173 @@
+package party
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// RelayPath is where the relay accepts party members
+const RelayPath = "/party"
+
+// maxMessage bounds what one member can send, which is mostly a whole map
+// when someone joins
+const maxMessage = 8 << 20
+
+// memberBuffer is how many messages can queue for a slow member before
+// further messages to it are dropped
+const memberBuffer = 256
+
+// relayMember is one connected party member
+type relayMember struct {
+	name  string
+	party string
+	conn  *websocket.Conn
+	send  chan []byte
+}
+
+// Relay forwards messages between the members of each party. It keeps no
+// map of its own, so it can run on any machine the party can reach: inside
+// one member's SeeMUD, or on its own with "seemud party-relay".
+type Relay struct {
+	mutex    sync.Mutex
+	parties  map[string]map[*relayMember]bool
+	upgrader websocket.Upgrader
+}
+
+// NewRelay creates a relay with no parties
+func NewRelay() *Relay {
+	return &Relay{
+		parties: make(map[string]map[*relayMember]bool),
+		upgrader: websocket.Upgrader{
+			// The party name is the access control, so any origin may connect
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Listen serves the relay on address until the returned server is shut down
+func (r *Relay) Listen(address string) (*http.Server, net.Addr, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to listen on %s: %w", address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle(RelayPath, r)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error("relay stopped", "error", err)
+		}
+	}()
+
+	logger.Info("relay listening", "url", fmt.Sprintf("ws://%s%s", listener.Addr(), RelayPath))
+	return server, listener.Addr(), nil
+}
+
+// ServeHTTP joins a member to the party named in the query string
+func (r *Relay) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	partyName, name := req.URL.Query().Get("party"), req.URL.Query().Get("name")
+	if partyName == "" || name == "" {
+		http.Error(w, "party and name are required", http.StatusBadRequest)
+		return
+	}
+
+	r.mutex.Lock()
+	for existing := range r.parties[partyName] {
+		if existing.name == name {
+			r.mutex.Unlock()
+			http.Error(w, "name already in the party", http.StatusConflict)
+			return
+		}
+	}
+	r.mutex.Unlock()
+
+	conn, err := r.upgrader.Upgrade(w, req, nil)
+	if err != nil {
+		logger.Warn("upgrade failed", "error", err)
+		return
+	}
+	conn.SetReadLimit(maxMessage)
+
+	m := &relayMember{name: name, party: partyName, conn: conn, send: make(chan []byte, memberBuffer)}
+	r.mutex.Lock()
+	if r.parties[partyName] == nil {
+		r.parties[partyName] = make(map[*relayMember]bool)
+	}
+	r.parties[partyName][m] = true
+	r.mutex.Unlock()
+	logger.Info("member joined", "party", partyName, "name", name, "address", req.RemoteAddr)
+
+	go m.writeLoop()
+	r.readLoop(m)
+}
+
+// readLoop forwards a member's messages to the rest of the party until it
+// disconnects, then tells them it left
+func (r *Relay) readLoop(m *relayMember) {
+	defer func() {
+		r.mutex.Lock()
+		if members := r.parties[m.party]; members[m] {
+			delete(members, m)
+			close(m.send)
+			if len(members) == 0 {
+				delete(r.parties, m.party)
+			}
+		}
+		r.mutex.Unlock()
+		r.forward(m, Message{Type: TypeLeave})
+		logger.Info("member left", "party", m.party, "name", m.name)
+	}()
+
+	for {
+		var msg Message
+		if err := m.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if msg.Type == TypeLeave {
+			continue
+		}
+		r.forward(m, msg)
+	}
+}
+
+// forward sends a message from one member to the others in its party,
+// saying who it's from so members can't speak for each other
+func (r *Relay) forward(from *relayMember, msg Message) {
+	msg.From = from.name
+	encoded, err := json.Marshal(msg)
+	if err != nil {
+		return
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for m := range r.parties[from.party] {
+		if m == from {
+			continue
+		}
+		select {
+		case m.send <- encoded:
+		default:
+		}
+	}
+}
+
+// writeLoop delivers queued messages to a member
+func (m *relayMember) writeLoop() {
+	defer m.conn.Close()
+
+	for data := range m.send {
+		m.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := m.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return
+		}
+	}
+	m.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
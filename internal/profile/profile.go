@@ -0,0 +1,363 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/pflag"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/safefile"
+	"seemud-gui/internal/telnet"
+)
+
+// FileName is the profile file inside the data directory
+const FileName = "profiles.json"
+
+// CharacterVar is the variable login scripts find the profile's character
+// in, as $SEEMUD_CHARACTER
+const CharacterVar = "SEEMUD_CHARACTER"
+
+// Profile is a server, and perhaps a character on it, to connect to
+type Profile struct {
+	Host      string `json:"host"`
+	Port      string `json:"port"`
+	Character string `json:"character,omitempty"` // Character played, for login scripts and telling profiles apart
+	Dialect   string `json:"dialect,omitempty"`   // Parser dialect, empty for the default
+	Mapping   *bool  `json:"mapping,omitempty"`   // Build a map; defaults to on
+	Login     string `json:"login,omitempty"`     // Login script, relative to the data directory
+	// Map is the name the map is saved under, empty for the server's
+	// address. Characters on one server can keep their own maps this way,
+	// or servers that mirror each other share one.
+	Map string `json:"map,omitempty"`
+
+	// Style is the image style preset rooms are drawn in, empty for the one
+	// chosen in the app
+	Style string `json:"style,omitempty"`
+
+	// Image backend for the server's rooms, stable-diffusion or comfyui;
+	// empty for the one configured
+	ImageBackend  string `json:"image_backend,omitempty"`
+	ImageEndpoint string `json:"image_endpoint,omitempty"` // Backend URL, empty for its default
+
+	// Connect over TLS; nil for plain telnet
+	TLS *telnet.TLSConfig `json:"tls,omitempty"`
+	// Connect through a proxy; nil for the one in SEEMUD_PROXY, if any
+	Proxy *telnet.ProxyConfig `json:"proxy,omitempty"`
+}
+
+// Options returns how to connect to the profile's server
+func (p Profile) Options() telnet.Options {
+	return telnet.Options{TLS: p.TLS, Proxy: p.Proxy}
+}
+
+// MappingEnabled reports whether the profile wants a map built
+func (p Profile) MappingEnabled() bool {
+	return p.Mapping == nil || *p.Mapping
+}
+
+// LoginScript returns the path of the profile's login script, if it has one
+func (p Profile) LoginScript() string {
+	if p.Login == "" || filepath.IsAbs(p.Login) {
+		return p.Login
+	}
+	return datadir.Resolve().Join(p.Login)
+}
+
+// ScriptVars returns the variables the profile gives its scripts: the
+// character in CharacterVar, if it has one
+func (p Profile) ScriptVars() map[string]string {
+	vars := make(map[string]string)
+	if p.Character != "" {
+		vars[CharacterVar] = p.Character
+	}
+	return vars
+}
+
+// Address returns host:port for display
+func (p Profile) Address() string {
+	return p.Host + ":" + p.Port
+}
+
+// Config is the profile file: named profiles and which one to use by default
+type Config struct {
+	Default  string             `json:"default,omitempty"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// builtin is used when there's no profile file, or a profile leaves a field out
+var builtin = Profile{Host: "localhost", Port: "4001", Dialect: parser.DefaultDialect}
+
+// Load reads a profile file. A missing file is an empty config.
+func Load(path string) (*Config, error) {
+	cfg := &Config{Profiles: make(map[string]Profile)}
+
+	data, err := safefile.ReadFile(path, json.Valid)
+	if os.IsNotExist(err) {
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read profiles: %w", err)
+	}
+	if err := json.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal profiles: %w", err)
+	}
+	if cfg.Profiles == nil {
+		cfg.Profiles = make(map[string]Profile)
+	}
+	return cfg, nil
+}
+
+// Names returns the profile names sorted
+func (c *Config) Names() []string {
+	names := make([]string, 0, len(c.Profiles))
+	for name := range c.Profiles {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Save writes the profile file
+func (c *Config) Save(path string) error {
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal profiles: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create profile directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write profiles: %w", err)
+	}
+	return nil
+}
+
+// Find returns the name of the profile for a host and port, if there is one
+func (c *Config) Find(host, port string) (string, bool) {
+	for _, name := range c.Names() {
+		p := c.Profiles[name]
+		if strings.EqualFold(p.Host, host) && p.Port == port {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// Add adds a profile under a name made from title, numbered if the name is
+// taken, and returns the name used
+func (c *Config) Add(title string, p Profile) string {
+	base := strings.Trim(strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		}
+		return '-'
+	}, title), "-")
+	if base == "" {
+		base = "server"
+	}
+
+	name := base
+	for n := 2; ; n++ {
+		if _, exists := c.Profiles[name]; !exists {
+			break
+		}
+		name = fmt.Sprintf("%s-%d", base, n)
+	}
+	c.Profiles[name] = p
+	return name
+}
+
+// Set adds or replaces a named profile, checking it can be connected to
+func (c *Config) Set(name string, p Profile) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("profile needs a name")
+	}
+	p.Host, p.Port = strings.TrimSpace(p.Host), strings.TrimSpace(p.Port)
+	if p.Host == "" {
+		return fmt.Errorf("profile %q needs a host", name)
+	}
+	if port, err := strconv.Atoi(p.Port); err != nil || port < 1 || port > 65535 {
+		return fmt.Errorf("profile %q has an invalid port %q", name, p.Port)
+	}
+	if p.Dialect != "" {
+		if _, err := parser.ForDialect(p.Dialect); err != nil {
+			return err
+		}
+	}
+	p.Character = strings.TrimSpace(p.Character)
+	p.Map = strings.TrimSpace(p.Map)
+
+	// Editing keeps the name's spelling rather than adding a near-duplicate
+	for key := range c.Profiles {
+		if key != name && strings.EqualFold(key, name) {
+			delete(c.Profiles, key)
+			if c.Default == key {
+				c.Default = name
+			}
+		}
+	}
+	c.Profiles[name] = p
+	return nil
+}
+
+// Delete removes a named profile, and stops it being the default
+func (c *Config) Delete(name string) error {
+	for key := range c.Profiles {
+		if strings.EqualFold(key, name) {
+			delete(c.Profiles, key)
+			if c.Default == key {
+				c.Default = ""
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown profile %q", name)
+}
+
+// Get returns a named profile, or the default one if name is empty, with
+// missing fields filled from the built-in localhost:4001 profile
+func (c *Config) Get(name string) (Profile, error) {
+	if name == "" {
+		name = c.Default
+	}
+	if name == "" {
+		return builtin, nil
+	}
+
+	p, exists := c.Profiles[name]
+	if !exists {
+		for key, candidate := range c.Profiles {
+			if strings.EqualFold(key, name) {
+				p, exists = candidate, true
+				break
+			}
+		}
+	}
+	if !exists {
+		return Profile{}, fmt.Errorf("unknown profile %q (known: %s)", name, strings.Join(c.Names(), ", "))
+	}
+
+	if p.Host == "" {
+		p.Host = builtin.Host
+	}
+	if p.Port == "" {
+		p.Port = builtin.Port
+	}
+	if p.Dialect == "" {
+		p.Dialect = builtin.Dialect
+	}
+	return p, nil
+}
+
+// Flags are the connection flags shared by the command-line clients
+type Flags struct {
+	fs      *pflag.FlagSet
+	config  *string
+	profile *string
+	host    *string
+	port    *string
+	dialect *string
+	mapping *bool
+	tls     *bool
+	tlsCA   *string
+	tlsPins *[]string
+	pinOnly *bool
+	proxy   *string
+}
+
+// RegisterFlags adds --config, --profile, --host, --port, --dialect, --map,
+// --proxy and the --tls flags to fs
+func RegisterFlags(fs *pflag.FlagSet) *Flags {
+	return &Flags{
+		fs:      fs,
+		config:  fs.String("config", "", "profile file (default "+FileName+" in the data directory)"),
+		profile: fs.String("profile", "", "named profile from the profile file"),
+		host:    fs.String("host", builtin.Host, "server host, overriding the profile"),
+		port:    fs.String("port", builtin.Port, "server port, overriding the profile"),
+		dialect: fs.String("dialect", builtin.Dialect, "parser dialect ("+strings.Join(parser.DialectNames(), ", ")+")"),
+		mapping: fs.Bool("map", true, "build a map while exploring; --map=false turns it off"),
+		tls:     fs.Bool("tls", false, "connect over TLS"),
+		tlsCA:   fs.String("tls-ca", "", "PEM file of certificates to trust for TLS, as well as the system's"),
+		tlsPins: fs.StringSlice("tls-pin", nil, "SHA-256 of the server's public key, base64 or hex; repeat for several"),
+		pinOnly: fs.Bool("tls-pin-only", false, "trust a pinned certificate nothing vouches for, e.g. one self-signed"),
+		proxy:   fs.String("proxy", "", "connect through a proxy, socks5://[user:pass@]host:port or http://[user:pass@]host:port"),
+	}
+}
+
+// Resolve loads the chosen profile and applies any flags given explicitly on
+// top of it. Call it after fs has been parsed.
+func (f *Flags) Resolve() (Profile, error) {
+	path := *f.config
+	if path == "" {
+		path = datadir.Resolve().Join(FileName)
+	}
+	cfg, err := Load(path)
+	if err != nil {
+		return Profile{}, err
+	}
+	p, err := cfg.Get(*f.profile)
+	if err != nil {
+		return Profile{}, err
+	}
+
+	// Only flags that were set override the profile; defaults don't
+	if f.fs.Changed("host") {
+		p.Host = *f.host
+	}
+	if f.fs.Changed("port") {
+		p.Port = *f.port
+	}
+	if f.fs.Changed("dialect") {
+		p.Dialect = *f.dialect
+	}
+	if f.fs.Changed("map") {
+		mapping := *f.mapping
+		p.Mapping = &mapping
+	}
+	if f.fs.Changed("proxy") {
+		p.Proxy = &telnet.ProxyConfig{URL: *f.proxy}
+	}
+	if f.fs.Changed("tls") && !*f.tls {
+		p.TLS = nil
+	} else if *f.tls || f.fs.Changed("tls-ca") || f.fs.Changed("tls-pin") || f.fs.Changed("tls-pin-only") {
+		// Any TLS flag turns it on, keeping the profile's other TLS settings
+		tls := telnet.TLSConfig{}
+		if p.TLS != nil {
+			tls = *p.TLS
+		}
+		if f.fs.Changed("tls-ca") {
+			tls.CAFile = *f.tlsCA
+		}
+		if f.fs.Changed("tls-pin") {
+			tls.Pins = *f.tlsPins
+		}
+		if f.fs.Changed("tls-pin-only") {
+			tls.PinOnly = *f.pinOnly
+		}
+		p.TLS = &tls
+	}
+
+	if _, err := parser.ForDialect(p.Dialect); err != nil {
+		return Profile{}, err
+	}
+	return p, nil
+}
+
+// ServerName is the name the engine saves this server's map under
+func (p Profile) ServerName() string {
+	if p.Map != "" {
+		return p.Map
+	}
+	return p.Host + "_" + p.Port
+}
@@ -0,0 +1,307 @@
+package remote
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("Remote")
+
+// Settings configures the mirroring bridge
+type Settings struct {
+	Enabled bool   `json:"enabled"`
+	Address string `json:"address"` // host:port to listen on
+	Token   string `json:"token"`   // Clients must present this to connect
+}
+
+// DefaultSettings listens on localhost only; set the address to 0.0.0.0 to
+// mirror to another device
+func DefaultSettings() Settings {
+	return Settings{
+		Address: "127.0.0.1:4050",
+	}
+}
+
+// NewToken returns a random token for authenticating clients
+func NewToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Message is the envelope for everything sent over the bridge, both ways.
+// The server sends output, event, room and image messages; clients send
+// command messages.
+type Message struct {
+	Type    string          `json:"type"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Command string          `json:"command,omitempty"`
+}
+
+// CommandFunc runs a command received from a remote client
+type CommandFunc func(command string) error
+
+// clientBuffer is how many messages can queue for a slow client before
+// further messages to it are dropped
+const clientBuffer = 256
+
+// client is one connected mirror
+type client struct {
+	conn *websocket.Conn
+	send chan []byte
+}
+
+// Server streams session activity to WebSocket clients and accepts their
+// commands
+type Server struct {
+	mutex    sync.RWMutex
+	settings Settings
+	command  CommandFunc
+	http     *http.Server
+	clients  map[*client]bool
+	upgrader websocket.Upgrader
+}
+
+// NewServer creates a stopped bridge
+func NewServer(command CommandFunc) *Server {
+	return &Server{
+		settings: DefaultSettings(),
+		command:  command,
+		clients:  make(map[*client]bool),
+		upgrader: websocket.Upgrader{
+			// The token is the access control, so any origin may connect
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+	}
+}
+
+// Settings returns the current settings
+func (s *Server) Settings() Settings {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.settings
+}
+
+// Running reports whether the bridge is listening
+func (s *Server) Running() bool {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.http != nil
+}
+
+// Clients returns the number of connected clients
+func (s *Server) Clients() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return len(s.clients)
+}
+
+// Apply stores new settings, starting or restarting the bridge if enabled
+// and stopping it otherwise. An empty token is replaced with a random one.
+func (s *Server) Apply(settings Settings) (Settings, error) {
+	if settings.Address == "" {
+		settings.Address = DefaultSettings().Address
+	}
+	if settings.Token == "" {
+		token, err := NewToken()
+		if err != nil {
+			return s.Settings(), err
+		}
+		settings.Token = token
+	}
+
+	s.Stop()
+
+	s.mutex.Lock()
+	s.settings = settings
+	s.mutex.Unlock()
+
+	if !settings.Enabled {
+		return settings, nil
+	}
+	return settings, s.start()
+}
+
+// start listens using the current settings
+func (s *Server) start() error {
+	settings := s.Settings()
+
+	listener, err := net.Listen("tcp", settings.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", settings.Address, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ws", s.handleWebSocket)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+
+	s.mutex.Lock()
+	s.http = server
+	s.mutex.Unlock()
+
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			logger.Error("server stopped", "error", err)
+		}
+	}()
+
+	logger.Info("listening", "url", fmt.Sprintf("ws://%s/ws", listener.Addr()))
+	return nil
+}
+
+// Stop closes the listener and every client
+func (s *Server) Stop() {
+	s.mutex.Lock()
+	server := s.http
+	s.http = nil
+	clients := s.clients
+	s.clients = make(map[*client]bool)
+	s.mutex.Unlock()
+
+	for c := range clients {
+		close(c.send)
+	}
+	if server != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		server.Shutdown(ctx)
+		logger.Info("stopped")
+	}
+}
+
+// authorised checks the token from the query string or a bearer header
+func (s *Server) authorised(r *http.Request) bool {
+	token := r.URL.Query().Get("token")
+	if header := r.Header.Get("Authorization"); strings.HasPrefix(header, "Bearer ") {
+		token = strings.TrimPrefix(header, "Bearer ")
+	}
+
+	expected := s.Settings().Token
+	return expected != "" && subtle.ConstantTimeCompare([]byte(token), []byte(expected)) == 1
+}
+
+// handleWebSocket upgrades an authorised request and serves the client
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	if !s.authorised(r) {
+		http.Error(w, "unauthorised", http.StatusUnauthorized)
+		return
+	}
+
+	conn, err := s.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		logger.Warn("upgrade failed", "error", err)
+		return
+	}
+
+	c := &client{conn: conn, send: make(chan []byte, clientBuffer)}
+	s.mutex.Lock()
+	s.clients[c] = true
+	s.mutex.Unlock()
+	logger.Info("client connected", "address", r.RemoteAddr)
+
+	go s.writeLoop(c)
+	s.readLoop(c)
+}
+
+// readLoop runs commands from a client until it disconnects
+func (s *Server) readLoop(c *client) {
+	defer func() {
+		s.mutex.Lock()
+		if s.clients[c] {
+			delete(s.clients, c)
+			close(c.send)
+		}
+		s.mutex.Unlock()
+		logger.Info("client disconnected")
+	}()
+
+	for {
+		var msg Message
+		if err := c.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+		if msg.Type != "command" || s.command == nil {
+			continue
+		}
+		if err := s.command(msg.Command); err != nil {
+			s.sendTo(c, "error", err.Error())
+		}
+	}
+}
+
+// writeLoop delivers queued messages to a client
+func (s *Server) writeLoop(c *client) {
+	defer c.conn.Close()
+
+	for data := range c.send {
+		c.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if err := c.conn.WriteMessage(websocket.TextMessage, data); err != nil {
+			return
+		}
+	}
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+}
+
+// encode wraps data in a message envelope
+func encode(kind string, data interface{}) ([]byte, error) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(Message{Type: kind, Data: payload})
+}
+
+// sendTo queues a message for one client
+func (s *Server) sendTo(c *client, kind string, data interface{}) {
+	encoded, err := encode(kind, data)
+	if err != nil {
+		return
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.clients[c] {
+		select {
+		case c.send <- encoded:
+		default:
+		}
+	}
+}
+
+// Broadcast sends a message to every client. Slow clients miss messages
+// rather than holding up the session.
+func (s *Server) Broadcast(kind string, data interface{}) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if len(s.clients) == 0 {
+		return
+	}
+
+	encoded, err := encode(kind, data)
+	if err != nil {
+		logger.Warn("failed to encode message", "type", kind, "error", err)
+		return
+	}
+	for c := range s.clients {
+		select {
+		case c.send <- encoded:
+		default:
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// Image backends SeeMUD can draw rooms with
+const (
+	// BackendStableDiffusion is the AUTOMATIC1111 WebUI's API, or anything
+	// compatible with it such as Forge or SD.Next
+	BackendStableDiffusion = "stable-diffusion"
+	// BackendComfyUI queues a workflow on a ComfyUI server
+	BackendComfyUI = "comfyui"
+)
+
+// Backends lists the image backends that can be chosen
+var Backends = []string{BackendStableDiffusion, BackendComfyUI}
+
+// Default endpoints for each backend, as they listen when run locally
+const (
+	DefaultSDEndpoint      = "http://127.0.0.1:7860"
+	DefaultComfyUIEndpoint = "http://127.0.0.1:8188"
+)
+
+// ImageBackend draws images from text prompts
+type ImageBackend interface {
+	// Name returns which backend this is, e.g. BackendComfyUI
+	Name() string
+	// GenerateImage draws an image, filling in defaults for anything the
+	// request leaves out
+	GenerateImage(ctx context.Context, req *Txt2ImgRequest) (*Txt2ImgResponse, error)
+	// Img2Img draws a variation of an image, likewise filling in defaults
+	Img2Img(ctx context.Context, req *Img2ImgRequest) (*Txt2ImgResponse, error)
+	// CheckHealth returns an error if the backend can't be reached
+	CheckHealth(ctx context.Context) error
+	// SetAuth sets the credentials sent with every request; empty removes them
+	SetAuth(credentials string)
+}
+
+// BackendSettings choose the image backend and where to find it
+type BackendSettings struct {
+	Backend         string `json:"backend"` // BackendStableDiffusion or BackendComfyUI, empty for Stable Diffusion
+	SDEndpoint      string `json:"sd_endpoint"`
+	ComfyUIEndpoint string `json:"comfyui_endpoint"`
+	// ComfyUIWorkflow is a workflow file saved in ComfyUI's API format,
+	// empty for the built-in one. See ComfyUIClient for its placeholders.
+	ComfyUIWorkflow string `json:"comfyui_workflow,omitempty"`
+	// ComfyUIVariationWorkflow is likewise the workflow for variations of an
+	// image, which also has {{image}} and {{denoise}}
+	ComfyUIVariationWorkflow string `json:"comfyui_variation_workflow,omitempty"`
+	// ComfyUIModel is the checkpoint the workflow loads, empty for the
+	// first the server lists
+	ComfyUIModel string `json:"comfyui_model,omitempty"`
+}
+
+// ParseBackend checks a backend's name; empty is Stable Diffusion
+func ParseBackend(name string) (string, error) {
+	switch name = strings.ToLower(strings.TrimSpace(name)); name {
+	case "", "sd", BackendStableDiffusion:
+		return BackendStableDiffusion, nil
+	case BackendComfyUI:
+		return name, nil
+	}
+	return "", fmt.Errorf("unknown image backend %q (want %s)", name, strings.Join(Backends, " or "))
+}
+
+// Override returns the settings with another backend and endpoint, as a
+// profile might choose; empty leaves each as it was
+func (s BackendSettings) Override(backend, endpoint string) BackendSettings {
+	if backend = strings.TrimSpace(backend); backend != "" {
+		s.Backend = backend
+	}
+	if endpoint = strings.TrimSpace(endpoint); endpoint != "" {
+		if name, _ := ParseBackend(s.Backend); name == BackendComfyUI {
+			s.ComfyUIEndpoint = endpoint
+		} else {
+			s.SDEndpoint = endpoint
+		}
+	}
+	return s
+}
+
+// Endpoint returns the URL of the chosen backend
+func (s BackendSettings) Endpoint() string {
+	if name, _ := ParseBackend(s.Backend); name == BackendComfyUI {
+		return endpointOr(s.ComfyUIEndpoint, DefaultComfyUIEndpoint)
+	}
+	return endpointOr(s.SDEndpoint, DefaultSDEndpoint)
+}
+
+// NewBackend creates a client for the chosen backend
+func NewBackend(settings BackendSettings) (ImageBackend, error) {
+	name, err := ParseBackend(settings.Backend)
+	if err != nil {
+		return nil, err
+	}
+	if name == BackendComfyUI {
+		client := NewComfyUIClient(settings.Endpoint())
+		client.SetModel(settings.ComfyUIModel)
+		if settings.ComfyUIWorkflow != "" {
+			if err := client.LoadWorkflow(settings.ComfyUIWorkflow); err != nil {
+				return nil, err
+			}
+		}
+		if settings.ComfyUIVariationWorkflow != "" {
+			if err := client.LoadVariationWorkflow(settings.ComfyUIVariationWorkflow); err != nil {
+				return nil, err
+			}
+		}
+		return client, nil
+	}
+	return NewStableDiffusionClient(settings.Endpoint()), nil
+}
+
+// endpointOr returns an endpoint without its trailing slash, or fallback if
+// it's empty
+func endpointOr(endpoint, fallback string) string {
+	if endpoint = strings.TrimRight(strings.TrimSpace(endpoint), "/"); endpoint != "" {
+		return endpoint
+	}
+	return fallback
+}
+
+// applyCredentials adds credentials to a request: "user:pass" as basic
+// auth, anything else as a bearer token for proxies
+func applyCredentials(req *http.Request, credentials string) {
+	if credentials == "" {
+		return
+	}
+	if user, pass, isBasic := strings.Cut(credentials, ":"); isBasic {
+		req.SetBasicAuth(user, pass)
+		return
+	}
+	req.Header.Set("Authorization", "Bearer "+credentials)
+}
@@ -0,0 +1,527 @@
+package renderer
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// comfyPollInterval is how often a queued workflow is checked on
+const comfyPollInterval = 500 * time.Millisecond
+
+// defaultComfyWorkflow is ComfyUI's default text-to-image graph in its API
+// format, with placeholders for everything a request sets
+const defaultComfyWorkflow = `{
+  "3": {"class_type": "KSampler", "inputs": {
+    "seed": "{{seed}}", "steps": "{{steps}}", "cfg": "{{cfg}}",
+    "sampler_name": "{{sampler}}", "scheduler": "{{scheduler}}", "denoise": 1,
+    "model": ["4", 0], "positive": ["6", 0], "negative": ["7", 0], "latent_image": ["5", 0]}},
+  "4": {"class_type": "CheckpointLoaderSimple", "inputs": {"ckpt_name": "{{model}}"}},
+  "5": {"class_type": "EmptyLatentImage", "inputs": {"width": "{{width}}", "height": "{{height}}", "batch_size": 1}},
+  "6": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{prompt}}", "clip": ["4", 1]}},
+  "7": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{negative_prompt}}", "clip": ["4", 1]}},
+  "8": {"class_type": "VAEDecode", "inputs": {"samples": ["3", 0], "vae": ["4", 2]}},
+  "9": {"class_type": "SaveImage", "inputs": {"filename_prefix": "seemud", "images": ["8", 0]}}
+}`
+
+// defaultComfyVariationWorkflow is the default graph starting from an
+// uploaded image rather than an empty one
+const defaultComfyVariationWorkflow = `{
+  "3": {"class_type": "KSampler", "inputs": {
+    "seed": "{{seed}}", "steps": "{{steps}}", "cfg": "{{cfg}}",
+    "sampler_name": "{{sampler}}", "scheduler": "{{scheduler}}", "denoise": "{{denoise}}",
+    "model": ["4", 0], "positive": ["6", 0], "negative": ["7", 0], "latent_image": ["11", 0]}},
+  "4": {"class_type": "CheckpointLoaderSimple", "inputs": {"ckpt_name": "{{model}}"}},
+  "6": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{prompt}}", "clip": ["4", 1]}},
+  "7": {"class_type": "CLIPTextEncode", "inputs": {"text": "{{negative_prompt}}", "clip": ["4", 1]}},
+  "8": {"class_type": "VAEDecode", "inputs": {"samples": ["3", 0], "vae": ["4", 2]}},
+  "9": {"class_type": "SaveImage", "inputs": {"filename_prefix": "seemud", "images": ["8", 0]}},
+  "10": {"class_type": "LoadImage", "inputs": {"image": "{{image}}"}},
+  "11": {"class_type": "VAEEncode", "inputs": {"pixels": ["10", 0], "vae": ["4", 2]}}
+}`
+
+// comfySamplers are ComfyUI's names for the WebUI's samplers, and the
+// scheduler the WebUI name implies
+var comfySamplers = map[string][2]string{
+	"euler":            {"euler", "normal"},
+	"euler a":          {"euler_ancestral", "normal"},
+	"heun":             {"heun", "normal"},
+	"lms":              {"lms", "normal"},
+	"ddim":             {"ddim", "ddim_uniform"},
+	"dpm++ 2m":         {"dpmpp_2m", "normal"},
+	"dpm++ 2m karras":  {"dpmpp_2m", "karras"},
+	"dpm++ sde":        {"dpmpp_sde", "normal"},
+	"dpm++ sde karras": {"dpmpp_sde", "karras"},
+	"unipc":            {"uni_pc", "normal"},
+}
+
+// ComfyUIClient draws images by queueing a workflow on a ComfyUI server and
+// fetching what it saves. A custom workflow, exported with "Save (API
+// Format)", can use these placeholders as input values: {{prompt}},
+// {{negative_prompt}}, {{seed}}, {{steps}}, {{cfg}}, {{width}}, {{height}},
+// {{sampler}}, {{scheduler}} and {{model}}; a variation workflow also has
+// {{image}}, the uploaded image to load, and {{denoise}}. A value that is
+// only a placeholder takes the setting's type; one within other text is
+// replaced in place.
+type ComfyUIClient struct {
+	baseURL  string
+	client   *http.Client
+	clientID string
+
+	mutex     sync.RWMutex
+	auth      string
+	workflow  string // API-format JSON with placeholders
+	variation string // Likewise, for variations of an image
+	model     string // Checkpoint to load, empty to ask the server
+}
+
+// NewComfyUIClient creates a ComfyUI client using the built-in workflow
+func NewComfyUIClient(baseURL string) *ComfyUIClient {
+	id := make([]byte, 8)
+	rand.Read(id)
+	return &ComfyUIClient{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		client:    &http.Client{Timeout: 30 * time.Second}, // Each request is short; generation is polled
+		clientID:  "seemud-" + hex.EncodeToString(id),
+		workflow:  defaultComfyWorkflow,
+		variation: defaultComfyVariationWorkflow,
+	}
+}
+
+// Name implements ImageBackend
+func (c *ComfyUIClient) Name() string {
+	return BackendComfyUI
+}
+
+// SetAuth implements ImageBackend, for servers behind an authenticating
+// proxy
+func (c *ComfyUIClient) SetAuth(credentials string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.auth = credentials
+}
+
+// SetModel chooses the checkpoint the workflow loads; empty uses the first
+// the server lists
+func (c *ComfyUIClient) SetModel(model string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.model = strings.TrimSpace(model)
+}
+
+// LoadWorkflow replaces the built-in workflow with one from a file saved in
+// ComfyUI's API format
+func (c *ComfyUIClient) LoadWorkflow(path string) error {
+	workflow, err := readWorkflow(path, "{{prompt}}")
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.workflow = workflow
+	return nil
+}
+
+// LoadVariationWorkflow replaces the built-in workflow for variations of an
+// image
+func (c *ComfyUIClient) LoadVariationWorkflow(path string) error {
+	workflow, err := readWorkflow(path, "{{prompt}}", "{{image}}")
+	if err != nil {
+		return err
+	}
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.variation = workflow
+	return nil
+}
+
+// readWorkflow reads a workflow file, checking it has the placeholders it
+// can't do without
+func readWorkflow(path string, required ...string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read workflow: %w", err)
+	}
+	var nodes map[string]json.RawMessage
+	if err := json.Unmarshal(data, &nodes); err != nil {
+		return "", fmt.Errorf("failed to parse workflow %s (save it with \"Save (API Format)\"): %w", path, err)
+	}
+	for _, placeholder := range required {
+		if !strings.Contains(string(data), placeholder) {
+			return "", fmt.Errorf("workflow %s has no %s placeholder", path, placeholder)
+		}
+	}
+	return string(data), nil
+}
+
+// GenerateImage implements ImageBackend, queueing the workflow and waiting
+// for its image
+func (c *ComfyUIClient) GenerateImage(ctx context.Context, req *Txt2ImgRequest) (*Txt2ImgResponse, error) {
+	req.applyDefaults()
+
+	c.mutex.RLock()
+	workflow := c.workflow
+	c.mutex.RUnlock()
+	return c.run(ctx, workflow, req, nil)
+}
+
+// Img2Img implements ImageBackend, uploading the image for the variation
+// workflow to start from
+func (c *ComfyUIClient) Img2Img(ctx context.Context, req *Img2ImgRequest) (*Txt2ImgResponse, error) {
+	req.applyDefaults()
+	if len(req.InitImages) == 0 {
+		return nil, fmt.Errorf("no image to vary")
+	}
+	data, err := base64.StdEncoding.DecodeString(req.InitImages[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode image: %w", err)
+	}
+	name, err := c.upload(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mutex.RLock()
+	workflow := c.variation
+	c.mutex.RUnlock()
+	return c.run(ctx, workflow, &req.Txt2ImgRequest, map[string]any{
+		"image":   name,
+		"denoise": req.DenoisingStrength,
+	})
+}
+
+// run fills in a workflow from a request and any further values, queues it
+// and waits for its image
+func (c *ComfyUIClient) run(ctx context.Context, workflow string, req *Txt2ImgRequest, extra map[string]any) (*Txt2ImgResponse, error) {
+	seed := req.Seed
+	if seed <= 0 {
+		seed = randomSeed()
+	}
+
+	c.mutex.RLock()
+	model := c.model
+	c.mutex.RUnlock()
+	if model == "" && strings.Contains(workflow, "{{model}}") {
+		var err error
+		if model, err = c.firstCheckpoint(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	sampler, scheduler := comfySampler(req.SamplerName)
+	values := map[string]any{
+		"prompt":          req.Prompt,
+		"negative_prompt": req.NegativePrompt,
+		"seed":            seed,
+		"steps":           req.Steps,
+		"cfg":             req.CFGScale,
+		"width":           req.Width,
+		"height":          req.Height,
+		"sampler":         sampler,
+		"scheduler":       scheduler,
+		"model":           model,
+	}
+	for name, value := range extra {
+		values[name] = value
+	}
+	prompt, err := fillWorkflow(workflow, values)
+	if err != nil {
+		return nil, err
+	}
+
+	promptID, err := c.queue(ctx, prompt)
+	if err != nil {
+		return nil, err
+	}
+	image, err := c.await(ctx, promptID)
+	if err != nil {
+		return nil, err
+	}
+
+	info, _ := json.Marshal(GenerationInfo{Seed: seed, Model: model, Sampler: sampler})
+	return &Txt2ImgResponse{Images: []string{image}, Info: string(info)}, nil
+}
+
+// CheckHealth implements ImageBackend
+func (c *ComfyUIClient) CheckHealth(ctx context.Context) error {
+	resp, err := c.do(ctx, "GET", "/system_stats", nil)
+	if err != nil {
+		return fmt.Errorf("ComfyUI not available: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// upload sends an image for a workflow to load, returning the name to load
+// it by
+func (c *ComfyUIClient) upload(ctx context.Context, data []byte) (string, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("image", "seemud-variation.png")
+	if err == nil {
+		_, err = part.Write(data)
+	}
+	if err == nil {
+		err = form.WriteField("overwrite", "true")
+	}
+	if err == nil {
+		err = form.Close()
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to build upload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/upload/image", &body)
+	if err != nil {
+		return "", fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := c.send(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var uploaded struct {
+		Name      string `json:"name"`
+		Subfolder string `json:"subfolder"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&uploaded); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if uploaded.Subfolder != "" {
+		return uploaded.Subfolder + "/" + uploaded.Name, nil
+	}
+	return uploaded.Name, nil
+}
+
+// queue sends a filled-in workflow to be run, returning its prompt ID
+func (c *ComfyUIClient) queue(ctx context.Context, prompt map[string]any) (string, error) {
+	body, err := json.Marshal(map[string]any{"prompt": prompt, "client_id": c.clientID})
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal workflow: %w", err)
+	}
+	resp, err := c.do(ctx, "POST", "/prompt", body)
+	if err != nil {
+		return "", fmt.Errorf("failed to queue workflow: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var queued struct {
+		PromptID   string          `json:"prompt_id"`
+		NodeErrors json.RawMessage `json:"node_errors"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&queued); err != nil {
+		return "", fmt.Errorf("failed to decode response: %w", err)
+	}
+	if queued.PromptID == "" {
+		return "", fmt.Errorf("ComfyUI rejected the workflow: %s", queued.NodeErrors)
+	}
+	return queued.PromptID, nil
+}
+
+// comfyHistory is a finished prompt as /history reports it
+type comfyHistory struct {
+	Status struct {
+		Status    string `json:"status_str"`
+		Completed bool   `json:"completed"`
+	} `json:"status"`
+	Outputs map[string]struct {
+		Images []struct {
+			Filename  string `json:"filename"`
+			Subfolder string `json:"subfolder"`
+			Type      string `json:"type"`
+		} `json:"images"`
+	} `json:"outputs"`
+}
+
+// await polls for a prompt to finish and returns its first image in base64
+func (c *ComfyUIClient) await(ctx context.Context, promptID string) (string, error) {
+	ticker := time.NewTicker(comfyPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("gave up waiting for ComfyUI: %w", ctx.Err())
+		case <-ticker.C:
+		}
+
+		resp, err := c.do(ctx, "GET", "/history/"+url.PathEscape(promptID), nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to check on workflow: %w", err)
+		}
+		var history map[string]comfyHistory
+		err = json.NewDecoder(resp.Body).Decode(&history)
+		resp.Body.Close()
+		if err != nil {
+			return "", fmt.Errorf("failed to decode history: %w", err)
+		}
+
+		entry, done := history[promptID]
+		if !done {
+			continue // Still queued or running
+		}
+		if entry.Status.Status == "error" {
+			return "", fmt.Errorf("ComfyUI failed to run the workflow")
+		}
+
+		// Nodes are checked in order so the same one is picked each time
+		nodes := make([]string, 0, len(entry.Outputs))
+		for node := range entry.Outputs {
+			nodes = append(nodes, node)
+		}
+		sort.Strings(nodes)
+		for _, node := range nodes {
+			for _, image := range entry.Outputs[node].Images {
+				return c.fetch(ctx, image.Filename, image.Subfolder, image.Type)
+			}
+		}
+		if entry.Status.Completed {
+			return "", fmt.Errorf("the workflow finished without an image")
+		}
+	}
+}
+
+// fetch downloads an image the server saved, in base64
+func (c *ComfyUIClient) fetch(ctx context.Context, filename, subfolder, kind string) (string, error) {
+	query := url.Values{"filename": {filename}, "subfolder": {subfolder}, "type": {kind}}
+	resp, err := c.do(ctx, "GET", "/view?"+query.Encode(), nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch image: %w", err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", fmt.Errorf("failed to read image: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// firstCheckpoint asks the server which checkpoints it has and returns the
+// first
+func (c *ComfyUIClient) firstCheckpoint(ctx context.Context) (string, error) {
+	resp, err := c.do(ctx, "GET", "/object_info/CheckpointLoaderSimple", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var info map[string]struct {
+		Input struct {
+			Required struct {
+				Checkpoints []json.RawMessage `json:"ckpt_name"`
+			} `json:"required"`
+		} `json:"input"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		return "", fmt.Errorf("failed to decode checkpoints: %w", err)
+	}
+	// ckpt_name is [[names...]] or [[names...], {options}]
+	for _, choices := range info["CheckpointLoaderSimple"].Input.Required.Checkpoints {
+		var names []string
+		if json.Unmarshal(choices, &names) == nil && len(names) > 0 {
+			c.SetModel(names[0])
+			return names[0], nil
+		}
+	}
+	return "", fmt.Errorf("ComfyUI has no checkpoints installed")
+}
+
+// do sends a JSON request, or none, to the server
+func (c *ComfyUIClient) do(ctx context.Context, method, path string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req)
+}
+
+// send adds the credentials to a request and sends it, failing on anything
+// but 200 OK
+func (c *ComfyUIClient) send(req *http.Request) (*http.Response, error) {
+	c.mutex.RLock()
+	applyCredentials(req, c.auth)
+	c.mutex.RUnlock()
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		text, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("API returned status %d: %s", resp.StatusCode, string(text))
+	}
+	return resp, nil
+}
+
+// fillWorkflow parses a workflow with its placeholders replaced by values
+func fillWorkflow(workflow string, values map[string]any) (map[string]any, error) {
+	var graph map[string]any
+	if err := json.Unmarshal([]byte(workflow), &graph); err != nil {
+		return nil, fmt.Errorf("failed to parse workflow: %w", err)
+	}
+
+	var fill func(value any) any
+	fill = func(value any) any {
+		switch v := value.(type) {
+		case string:
+			if strings.HasPrefix(v, "{{") && strings.HasSuffix(v, "}}") {
+				if replacement, exists := values[strings.TrimSuffix(strings.TrimPrefix(v, "{{"), "}}")]; exists {
+					return replacement
+				}
+			}
+			for name, replacement := range values {
+				v = strings.ReplaceAll(v, "{{"+name+"}}", fmt.Sprint(replacement))
+			}
+			return v
+		case map[string]any:
+			for key, inner := range v {
+				v[key] = fill(inner)
+			}
+		case []any:
+			for i, inner := range v {
+				v[i] = fill(inner)
+			}
+		}
+		return value
+	}
+	fill(graph)
+	return graph, nil
+}
+
+// comfySampler returns ComfyUI's sampler and scheduler for a WebUI sampler
+// name, passing through names ComfyUI already uses
+func comfySampler(name string) (string, string) {
+	if pair, exists := comfySamplers[strings.ToLower(strings.TrimSpace(name))]; exists {
+		return pair[0], pair[1]
+	}
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(name)), " ", "_"), "normal"
+}
+
+// randomSeed picks a seed for a request that left it to chance, as ComfyUI
+// needs one given
+func randomSeed() int64 {
+	var b [4]byte
+	rand.Read(b[:])
+	return int64(binary.BigEndian.Uint32(b[:]))
+}
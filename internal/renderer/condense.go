@@ -0,0 +1,144 @@
+package renderer
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/llm"
+)
+
+// condenseInstructions tells the model what kind of prompt to write
+const condenseInstructions = "You turn room descriptions from a text adventure into prompts for an image generator. " +
+	"Reply with a single line of short comma-separated phrases naming the main subjects, materials, lighting and mood. " +
+	"Leave out exits, directions, people, sounds, smells and anything else that can't be seen. " +
+	"Use no more than 60 words and nothing but the prompt."
+
+// maxCondensed bounds how many condensed descriptions are remembered
+const maxCondensed = 500
+
+// CondenserSettings configures condensing room descriptions with a language
+// model before they're drawn. Any OpenAI-compatible chat completions API
+// works, including the one Ollama serves locally.
+type CondenserSettings struct {
+	Enabled   bool   `json:"enabled"`
+	Endpoint  string `json:"endpoint"`   // Base URL, e.g. http://127.0.0.1:11434/v1 for Ollama
+	Model     string `json:"model"`      // e.g. llama3.2, or gpt-4o-mini for OpenAI
+	MinLength int    `json:"min_length"` // Descriptions shorter than this are already tight enough
+}
+
+// DefaultCondenserSettings points at a local Ollama, switched off
+func DefaultCondenserSettings() CondenserSettings {
+	return CondenserSettings{
+		Endpoint:  llm.DefaultEndpoint,
+		Model:     llm.DefaultModel,
+		MinLength: 200,
+	}
+}
+
+// Condenser rewrites long, flowery room descriptions as tight visual
+// prompts, which Stable Diffusion follows far better
+type Condenser struct {
+	mutex    sync.RWMutex
+	settings CondenserSettings
+	apiKey   string // Sent as a bearer token; Ollama needs none
+	cache    map[string]string
+	client   *llm.Client
+}
+
+// NewCondenser creates a condenser with the given settings
+func NewCondenser(settings CondenserSettings) *Condenser {
+	return &Condenser{
+		settings: settings,
+		cache:    make(map[string]string),
+		client:   llm.NewClient(60 * time.Second), // Local models can be slow to load
+	}
+}
+
+// Settings returns the current settings
+func (c *Condenser) Settings() CondenserSettings {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.settings
+}
+
+// SetSettings replaces the settings
+func (c *Condenser) SetSettings(settings CondenserSettings) {
+	settings.Endpoint = strings.TrimRight(strings.TrimSpace(settings.Endpoint), "/")
+	settings.Model = strings.TrimSpace(settings.Model)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.settings = settings
+}
+
+// SetAPIKey sets the key sent to hosted APIs. Empty removes it.
+func (c *Condenser) SetAPIKey(key string) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.apiKey = key
+}
+
+// Active reports whether a description would be condensed
+func (c *Condenser) Active(description string) bool {
+	settings := c.Settings()
+	return settings.Enabled && settings.Endpoint != "" && settings.Model != "" &&
+		len(description) >= settings.MinLength
+}
+
+// Condense returns a room's description rewritten as an image prompt.
+// Descriptions too short to need it, or with condensing off, come back as
+// they are. Results are remembered, as rooms are redrawn with the same text.
+func (c *Condenser) Condense(ctx context.Context, roomName, description string) (string, error) {
+	if !c.Active(description) {
+		return description, nil
+	}
+
+	c.mutex.RLock()
+	settings, apiKey := c.settings, c.apiKey
+	key := settings.Model + "\x00" + roomName + "\x00" + description
+	condensed, cached := c.cache[key]
+	c.mutex.RUnlock()
+	if cached {
+		return condensed, nil
+	}
+
+	reply, err := c.client.Complete(ctx, llm.Endpoint{URL: settings.Endpoint, Model: settings.Model, APIKey: apiKey}, llm.Request{
+		Instructions: condenseInstructions,
+		Prompt:       fmt.Sprintf("Room: %s\n\n%s", roomName, description),
+		Temperature:  0.2,
+	})
+	if err != nil {
+		return "", err
+	}
+	condensed = cleanCondensed(reply)
+	if condensed == "" {
+		return "", fmt.Errorf("empty reply from the model")
+	}
+
+	c.mutex.Lock()
+	if len(c.cache) >= maxCondensed {
+		c.cache = make(map[string]string)
+	}
+	c.cache[key] = condensed
+	c.mutex.Unlock()
+	return condensed, nil
+}
+
+// cleanCondensed tidies what models tend to wrap around the prompt: a
+// label, quotes, or a reply spread over several lines
+func cleanCondensed(reply string) string {
+	var parts []string
+	for _, line := range strings.Split(reply, "\n") {
+		line = strings.TrimSpace(line)
+		line = strings.TrimPrefix(line, "Prompt:")
+		line = strings.Trim(strings.TrimSpace(line), `"'`+"`")
+		line = strings.TrimLeft(line, "-* ")
+		if line != "" {
+			parts = append(parts, strings.TrimRight(line, ",."))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
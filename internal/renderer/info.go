@@ -0,0 +1,23 @@
+package renderer
+
+import "encoding/json"
+
+// GenerationInfo is what the WebUI reports about a finished generation
+type GenerationInfo struct {
+	Seed      int64  `json:"seed"`
+	Model     string `json:"sd_model_name"`
+	ModelHash string `json:"sd_model_hash"`
+	Sampler   string `json:"sampler_name"`
+}
+
+// ParseInfo reads the JSON the WebUI puts in a response's info field.
+// Backends that leave it out give an empty result.
+func (r *Txt2ImgResponse) ParseInfo() GenerationInfo {
+	var info GenerationInfo
+	if r.Info != "" {
+		if err := json.Unmarshal([]byte(r.Info), &info); err != nil {
+			return GenerationInfo{}
+		}
+	}
+	return info
+}
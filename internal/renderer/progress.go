@@ -0,0 +1,51 @@
+package renderer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// GenerationProgress is how far a backend has got with the image it's
+// drawing
+type GenerationProgress struct {
+	Progress float64 `json:"progress"`      // From 0 to 1
+	ETA      float64 `json:"eta_relative"`  // Seconds left, as the backend guesses
+	Preview  string  `json:"current_image"` // Base64 PNG of the image so far, empty if the backend has none
+	State    struct {
+		SamplingStep  int `json:"sampling_step"`
+		SamplingSteps int `json:"sampling_steps"`
+	} `json:"state"`
+}
+
+// ProgressReporter is a backend that can say how far a generation has got
+// while it's running
+type ProgressReporter interface {
+	Progress(ctx context.Context) (GenerationProgress, error)
+}
+
+// Progress implements ProgressReporter, asking the WebUI about the
+// generation it's running, with its live preview if that's turned on
+func (sd *StableDiffusionClient) Progress(ctx context.Context) (GenerationProgress, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", sd.baseURL+"/sdapi/v1/progress?skip_current_image=false", nil)
+	if err != nil {
+		return GenerationProgress{}, fmt.Errorf("failed to create progress request: %w", err)
+	}
+	sd.authorise(req)
+
+	resp, err := sd.client.Do(req)
+	if err != nil {
+		return GenerationProgress{}, fmt.Errorf("failed to check progress: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return GenerationProgress{}, fmt.Errorf("SD API returned status %d", resp.StatusCode)
+	}
+
+	var progress GenerationProgress
+	if err := json.NewDecoder(resp.Body).Decode(&progress); err != nil {
+		return GenerationProgress{}, fmt.Errorf("failed to decode progress: %w", err)
+	}
+	return progress, nil
+}
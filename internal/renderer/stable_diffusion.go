@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"sync"
 	"time"
 )
 
@@ -14,6 +15,9 @@ import (
 type StableDiffusionClient struct {
 	baseURL string
 	client  *http.Client
+
+	authMux sync.RWMutex
+	auth    string // "user:pass" for --api-auth, otherwise a bearer token
 }
 
 // NewStableDiffusionClient creates a new SD client
@@ -26,6 +30,29 @@ func NewStableDiffusionClient(baseURL string) *StableDiffusionClient {
 	}
 }
 
+// SetAuth sets the credentials sent with every request. "user:pass" is sent
+// as basic auth, as the WebUI's --api-auth expects; anything else is sent as
+// a bearer token for proxies. Empty removes them.
+func (sd *StableDiffusionClient) SetAuth(credentials string) {
+	sd.authMux.Lock()
+	defer sd.authMux.Unlock()
+	sd.auth = credentials
+}
+
+// authorise adds the credentials to a request
+func (sd *StableDiffusionClient) authorise(req *http.Request) {
+	sd.authMux.RLock()
+	auth := sd.auth
+	sd.authMux.RUnlock()
+
+	applyCredentials(req, auth)
+}
+
+// Name implements ImageBackend
+func (sd *StableDiffusionClient) Name() string {
+	return BackendStableDiffusion
+}
+
 // Txt2ImgRequest represents a text-to-image generation request
 type Txt2ImgRequest struct {
 	Prompt         string  `json:"prompt"`
@@ -38,15 +65,9 @@ type Txt2ImgRequest struct {
 	SamplerName    string  `json:"sampler_name,omitempty"`
 }
 
-// Txt2ImgResponse represents the API response
-type Txt2ImgResponse struct {
-	Images []string `json:"images"`
-	Info   string   `json:"info"`
-}
-
-// GenerateImage sends a text-to-image request to Stable Diffusion WebUI
-func (sd *StableDiffusionClient) GenerateImage(ctx context.Context, req *Txt2ImgRequest) (*Txt2ImgResponse, error) {
-	// Set defaults if not specified
+// applyDefaults fills in what a request leaves out, the same for every
+// backend so they draw alike
+func (req *Txt2ImgRequest) applyDefaults() {
 	if req.Width == 0 {
 		req.Width = 512
 	}
@@ -62,7 +83,50 @@ func (sd *StableDiffusionClient) GenerateImage(ctx context.Context, req *Txt2Img
 	if req.SamplerName == "" {
 		req.SamplerName = "Euler"
 	}
+}
+
+// Img2ImgRequest represents an image-to-image request, drawing a variation
+// of an existing image
+type Img2ImgRequest struct {
+	Txt2ImgRequest
+	InitImages []string `json:"init_images"` // Base64 images to start from
+	// DenoisingStrength is how far to stray from the image, from 0 for no
+	// change to 1 for a new composition
+	DenoisingStrength float64 `json:"denoising_strength"`
+}
+
+// DefaultDenoisingStrength keeps a room's composition while changing its
+// details
+const DefaultDenoisingStrength = 0.5
+
+// applyDefaults fills in what a request leaves out
+func (req *Img2ImgRequest) applyDefaults() {
+	req.Txt2ImgRequest.applyDefaults()
+	if req.DenoisingStrength <= 0 || req.DenoisingStrength > 1 {
+		req.DenoisingStrength = DefaultDenoisingStrength
+	}
+}
+
+// Txt2ImgResponse represents the API response
+type Txt2ImgResponse struct {
+	Images []string `json:"images"`
+	Info   string   `json:"info"`
+}
 
+// GenerateImage sends a text-to-image request to Stable Diffusion WebUI
+func (sd *StableDiffusionClient) GenerateImage(ctx context.Context, req *Txt2ImgRequest) (*Txt2ImgResponse, error) {
+	req.applyDefaults()
+	return sd.post(ctx, "/sdapi/v1/txt2img", req)
+}
+
+// Img2Img sends an image-to-image request to Stable Diffusion WebUI
+func (sd *StableDiffusionClient) Img2Img(ctx context.Context, req *Img2ImgRequest) (*Txt2ImgResponse, error) {
+	req.applyDefaults()
+	return sd.post(ctx, "/sdapi/v1/img2img", req)
+}
+
+// post sends a generation request to an API path
+func (sd *StableDiffusionClient) post(ctx context.Context, path string, req any) (*Txt2ImgResponse, error) {
 	// Marshal request to JSON
 	reqBody, err := json.Marshal(req)
 	if err != nil {
@@ -70,12 +134,13 @@ func (sd *StableDiffusionClient) GenerateImage(ctx context.Context, req *Txt2Img
 	}
 
 	// Create HTTP request
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", sd.baseURL+"/sdapi/v1/txt2img", bytes.NewBuffer(reqBody))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", sd.baseURL+path, bytes.NewBuffer(reqBody))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
+	sd.authorise(httpReq)
 
 	// Send request
 	resp, err := sd.client.Do(httpReq)
@@ -104,6 +169,7 @@ func (sd *StableDiffusionClient) CheckHealth(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to create health check request: %w", err)
 	}
+	sd.authorise(req)
 
 	resp, err := sd.client.Do(req)
 	if err != nil {
@@ -118,19 +184,17 @@ func (sd *StableDiffusionClient) CheckHealth(ctx context.Context) error {
 	return nil
 }
 
-// RoomImagePrompt generates an optimised prompt for room generation
-func RoomImagePrompt(roomName, description string) string {
+// RoomImagePrompt generates an optimised prompt for room generation in a style
+func RoomImagePrompt(roomName, description string, style StylePreset) string {
 	basePrompt := fmt.Sprintf("Fantasy medieval environment, %s. %s", roomName, description)
 
 	// Add quality and style modifiers
-	stylePrompt := ", highly detailed, atmospheric lighting, fantasy art style, cinematic composition, 8k, masterpiece"
-
-	return basePrompt + stylePrompt
+	return basePrompt + ", " + style.Suffix
 }
 
 // RoomImagePromptWithCustom generates a room prompt with custom user additions
-func RoomImagePromptWithCustom(roomName, description, customAdditions string) string {
-	basePrompt := RoomImagePrompt(roomName, description)
+func RoomImagePromptWithCustom(roomName, description, customAdditions string, style StylePreset) string {
+	basePrompt := RoomImagePrompt(roomName, description, style)
 
 	if customAdditions != "" {
 		return basePrompt + ", " + customAdditions
@@ -141,7 +205,7 @@ func RoomImagePromptWithCustom(roomName, description, customAdditions string) st
 
 // RoomImagePromptWithNeighbours generates a prompt that includes context from neighbouring rooms
 // neighbours is a map of direction -> (roomName, roomDescription)
-func RoomImagePromptWithNeighbours(roomName, description string, neighbours map[string]map[string]string) string {
+func RoomImagePromptWithNeighbours(roomName, description string, neighbours map[string]map[string]string, style StylePreset) string {
 	basePrompt := fmt.Sprintf("Fantasy medieval environment, %s. %s", roomName, description)
 
 	// Add neighbour context to make images cohesive
@@ -174,14 +238,12 @@ func RoomImagePromptWithNeighbours(roomName, description string, neighbours map[
 	}
 
 	// Add quality and style modifiers
-	stylePrompt := ", highly detailed, atmospheric lighting, fantasy art style, cinematic composition, 8k, masterpiece"
-
-	return basePrompt + stylePrompt
+	return basePrompt + ", " + style.Suffix
 }
 
 // RoomImagePromptWithNeighboursAndCustom combines neighbour context with custom additions
-func RoomImagePromptWithNeighboursAndCustom(roomName, description string, neighbours map[string]map[string]string, customAdditions string) string {
-	basePrompt := RoomImagePromptWithNeighbours(roomName, description, neighbours)
+func RoomImagePromptWithNeighboursAndCustom(roomName, description string, neighbours map[string]map[string]string, customAdditions string, style StylePreset) string {
+	basePrompt := RoomImagePromptWithNeighbours(roomName, description, neighbours, style)
 
 	if customAdditions != "" {
 		return basePrompt + ", " + customAdditions
@@ -189,8 +251,3 @@ func RoomImagePromptWithNeighboursAndCustom(roomName, description string, neighb
 
 	return basePrompt
 }
-
-// GetNegativePrompt returns a standard negative prompt for fantasy environments
-func GetNegativePrompt() string {
-	return "blurry, low quality, text, watermark, signature, people, characters, figures, humans, animals, modern objects, cars, buildings, technology"
-}
\ No newline at end of file
@@ -0,0 +1,308 @@
+package renderer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"seemud-gui/internal/safefile"
+)
+
+// DefaultStyle is the style rooms are drawn in unless another is chosen.
+// It's recorded with each cached image, so images drawn in another style
+// can be found.
+const DefaultStyle = "fantasy-art"
+
+// DefaultNegativePrompt keeps people, text and anything modern out of room
+// images, for styles that don't give their own
+const DefaultNegativePrompt = "blurry, low quality, text, watermark, signature, people, characters, figures, humans, animals, modern objects, cars, buildings, technology"
+
+// StylePreset is a named look for room images
+type StylePreset struct {
+	Name string `json:"name"`
+	// Suffix follows the room in every prompt, e.g. "oil painting, thick
+	// brushstrokes"
+	Suffix string `json:"suffix"`
+	// NegativePrompt is what to keep out of the image, DefaultNegativePrompt
+	// if empty
+	NegativePrompt string `json:"negative_prompt,omitempty"`
+	BuiltIn        bool   `json:"built_in"` // Shipped with SeeMUD rather than saved by the player
+}
+
+// Negative returns the preset's negative prompt
+func (p StylePreset) Negative() string {
+	if p.NegativePrompt == "" {
+		return DefaultNegativePrompt
+	}
+	return p.NegativePrompt
+}
+
+// BuiltInStyles are the presets there's always a choice of. A preset the
+// player saves with the same name replaces one.
+var BuiltInStyles = []StylePreset{
+	{
+		Name:   DefaultStyle,
+		Suffix: "highly detailed, atmospheric lighting, fantasy art style, cinematic composition, 8k, masterpiece",
+	},
+	{
+		Name:           "oil-painting",
+		Suffix:         "oil painting on canvas, thick impasto brushstrokes, rich colours, classical composition, museum quality",
+		NegativePrompt: DefaultNegativePrompt + ", photograph, 3d render, smooth gradients",
+	},
+	{
+		Name:           "pixel-art",
+		Suffix:         "pixel art, 16-bit, limited palette, crisp pixels, retro game background",
+		NegativePrompt: DefaultNegativePrompt + ", photorealistic, smooth shading, anti-aliasing, blur",
+	},
+	{
+		Name:           "gritty-realism",
+		Suffix:         "gritty realism, muted colours, grime and wear, overcast natural light, photographic detail, 35mm film",
+		NegativePrompt: DefaultNegativePrompt + ", cartoon, anime, bright saturated colours, clean surfaces",
+	},
+}
+
+// styleKey normalises a style's name, so "Oil Painting" finds oil-painting
+func styleKey(name string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == ' ' || r == '-' || r == '_'
+	}), "-")
+}
+
+// styleFile is how Styles are saved
+type styleFile struct {
+	Presets []StylePreset     `json:"presets"`
+	Zones   map[string]string `json:"zones"` // Zone name -> style
+}
+
+// Styles holds the presets to choose from, the built-in ones and the
+// player's own, and which is chosen for the session and for each zone.
+// Presets and zone choices are saved as JSON; the session's choice lasts
+// until the app is closed.
+type Styles struct {
+	mutex   sync.RWMutex
+	path    string
+	presets map[string]StylePreset // The player's own, keyed by styleKey
+	zones   map[string]string      // Keyed by lowercased zone name
+	session string
+}
+
+// NewStyles creates a style store saving to path, loading any saved
+// presets. An empty path keeps them in memory only.
+func NewStyles(path string) (*Styles, error) {
+	s := &Styles{
+		path:    path,
+		presets: make(map[string]StylePreset),
+		zones:   make(map[string]string),
+	}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := safefile.ReadFile(path, json.Valid)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read styles: %w", err)
+	}
+
+	var saved styleFile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return s, fmt.Errorf("failed to unmarshal styles: %w", err)
+	}
+	for _, preset := range saved.Presets {
+		if key := styleKey(preset.Name); key != "" {
+			preset.Name, preset.BuiltIn = key, false
+			s.presets[key] = preset
+		}
+	}
+	for zone, style := range saved.Zones {
+		s.zones[strings.ToLower(strings.TrimSpace(zone))] = styleKey(style)
+	}
+	return s, nil
+}
+
+// List returns every preset sorted by name, the player's in place of
+// built-in ones they share a name with
+func (s *Styles) List() []StylePreset {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	presets := make([]StylePreset, 0, len(BuiltInStyles)+len(s.presets))
+	for _, preset := range BuiltInStyles {
+		if _, replaced := s.presets[preset.Name]; !replaced {
+			preset.BuiltIn = true
+			presets = append(presets, preset)
+		}
+	}
+	for _, preset := range s.presets {
+		presets = append(presets, preset)
+	}
+	sort.Slice(presets, func(i, j int) bool { return presets[i].Name < presets[j].Name })
+	return presets
+}
+
+// Get returns a preset by name
+func (s *Styles) Get(name string) (StylePreset, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.get(styleKey(name))
+}
+
+// get returns a preset by key; the caller must hold the lock
+func (s *Styles) get(key string) (StylePreset, bool) {
+	if preset, exists := s.presets[key]; exists {
+		return preset, true
+	}
+	for _, preset := range BuiltInStyles {
+		if preset.Name == key {
+			preset.BuiltIn = true
+			return preset, true
+		}
+	}
+	return StylePreset{}, false
+}
+
+// Save adds or replaces one of the player's presets
+func (s *Styles) Save(preset StylePreset) error {
+	preset.Name = styleKey(preset.Name)
+	preset.Suffix = strings.TrimSpace(preset.Suffix)
+	preset.NegativePrompt = strings.TrimSpace(preset.NegativePrompt)
+	preset.BuiltIn = false
+	if preset.Name == "" {
+		return fmt.Errorf("style needs a name")
+	}
+	if preset.Suffix == "" {
+		return fmt.Errorf("style %q needs a prompt suffix", preset.Name)
+	}
+
+	s.mutex.Lock()
+	s.presets[preset.Name] = preset
+	s.mutex.Unlock()
+	return s.save()
+}
+
+// Delete removes one of the player's presets. A built-in preset it
+// replaced comes back; built-in ones can't be deleted.
+func (s *Styles) Delete(name string) error {
+	key := styleKey(name)
+	s.mutex.Lock()
+	if _, exists := s.presets[key]; !exists {
+		s.mutex.Unlock()
+		return fmt.Errorf("no saved style called %q", name)
+	}
+	delete(s.presets, key)
+	s.mutex.Unlock()
+	return s.save()
+}
+
+// SetSession chooses the style for zones without their own until the app
+// is closed; empty goes back to DefaultStyle
+func (s *Styles) SetSession(name string) error {
+	key := styleKey(name)
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.get(key); key != "" && !exists {
+		return fmt.Errorf("unknown style %q", name)
+	}
+	s.session = key
+	return nil
+}
+
+// Session returns the style chosen for the session, DefaultStyle if none
+// was
+func (s *Styles) Session() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	if s.session == "" {
+		return DefaultStyle
+	}
+	return s.session
+}
+
+// SetZone chooses the style a zone is always drawn in; empty goes back to
+// the session's
+func (s *Styles) SetZone(zone, name string) error {
+	zone = strings.ToLower(strings.TrimSpace(zone))
+	if zone == "" {
+		return fmt.Errorf("zone needs a name")
+	}
+	key := styleKey(name)
+
+	s.mutex.Lock()
+	if key == "" {
+		delete(s.zones, zone)
+	} else if _, exists := s.get(key); !exists {
+		s.mutex.Unlock()
+		return fmt.Errorf("unknown style %q", name)
+	} else {
+		s.zones[zone] = key
+	}
+	s.mutex.Unlock()
+	return s.save()
+}
+
+// Zones returns the style chosen for each zone that has one
+func (s *Styles) Zones() map[string]string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	zones := make(map[string]string, len(s.zones))
+	for zone, style := range s.zones {
+		zones[zone] = style
+	}
+	return zones
+}
+
+// For returns the preset a zone is drawn in: its own, or the session's, or
+// DefaultStyle if the chosen one has since been deleted
+func (s *Styles) For(zone string) StylePreset {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	if key, chosen := s.zones[strings.ToLower(strings.TrimSpace(zone))]; chosen {
+		if preset, exists := s.get(key); exists {
+			return preset
+		}
+	}
+	if preset, exists := s.get(s.session); exists {
+		return preset
+	}
+	preset, _ := s.get(DefaultStyle)
+	return preset
+}
+
+// save writes the player's presets and zone choices to disk
+func (s *Styles) save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	s.mutex.RLock()
+	saved := styleFile{Presets: make([]StylePreset, 0, len(s.presets)), Zones: make(map[string]string, len(s.zones))}
+	for _, preset := range s.presets {
+		saved.Presets = append(saved.Presets, preset)
+	}
+	for zone, style := range s.zones {
+		saved.Zones[zone] = style
+	}
+	s.mutex.RUnlock()
+	sort.Slice(saved.Presets, func(i, j int) bool { return saved.Presets[i].Name < saved.Presets[j].Name })
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal styles: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create styles directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write styles: %w", err)
+	}
+	return nil
+}
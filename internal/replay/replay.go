@@ -0,0 +1,117 @@
+package replay
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/parser"
+)
+
+// Line is one line of a recorded session
+type Line struct {
+	Number  int    // Line number in the log
+	Text    string // Without any timestamp
+	Command bool   // Typed by the player rather than sent by the server
+}
+
+// timestamp matches the time session logs put before each line
+var timestamp = regexp.MustCompile(`^\d{2}:\d{2}:\d{2}(?:\.\d+)? `)
+
+// ReadLog reads a session log: a plain transcript, or a timestamped log from
+// "seemud play --log". Lines starting "> " are commands the player sent.
+func ReadLog(r io.Reader) ([]Line, error) {
+	var lines []Line
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for n := 1; scanner.Scan(); n++ {
+		text := strings.TrimRight(scanner.Text(), "\r")
+		if stamp := timestamp.FindString(text); stamp != "" {
+			text = text[len(stamp):]
+			// Session logs indent output to line up with "> ", so a prompt
+			// of "> " isn't mistaken for a command
+			if indented, ok := strings.CutPrefix(text, "  "); ok {
+				lines = append(lines, Line{Number: n, Text: indented})
+				continue
+			}
+		}
+
+		line := Line{Number: n, Text: text}
+		if command, ok := strings.CutPrefix(text, "> "); ok {
+			line.Text, line.Command = command, true
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read log: %w", err)
+	}
+	return lines, nil
+}
+
+// Decision is what the parser and mapper made of one line
+type Decision struct {
+	Line      Line
+	Parsed    *parser.ParsedOutput // Nil for commands
+	Movement  string               // Direction, for movement commands
+	Refused   string               // Direction of the move the line refused
+	RoomID    string               // Set when the line completed a room
+	NewRoom   bool                 // The room hadn't been mapped before
+	RoomCount int                  // Rooms mapped so far
+}
+
+// Session replays a log through the same parser, room tracker and mapper
+// the engine uses, without a connection
+type Session struct {
+	Parser parser.Parser
+	Rooms  *engine.ParsedRoomTracker
+	Mapper *mapper.Mapper
+
+	entered string
+	isNew   bool
+}
+
+// New creates a replay session for a parser dialect
+func New(dialect string) (*Session, error) {
+	p, err := parser.ForDialect(dialect)
+	if err != nil {
+		return nil, err
+	}
+	m := mapper.NewMapper()
+	s := &Session{Parser: p, Rooms: engine.NewParsedRoomTracker(m), Mapper: m}
+	m.OnRoomChange(func(roomID string, isNew bool) {
+		s.entered, s.isNew = roomID, isNew
+	})
+	return s, nil
+}
+
+// Step feeds one line through, in the order the engine would handle it
+func (s *Session) Step(line Line) Decision {
+	decision := Decision{Line: line}
+	if line.Command {
+		if isMovement, direction := mapper.IsMovementCommand(line.Text); isMovement {
+			s.Mapper.OnMovement(direction)
+			decision.Movement = direction
+		}
+	} else {
+		s.entered = ""
+		decision.Parsed = s.Parser.ParseLine(line.Text)
+		if mapper.IsMovementFailure(decision.Parsed.CleanText) {
+			decision.Refused = s.Mapper.CancelMovement()
+		}
+		s.Rooms.HandleParsed(decision.Parsed)
+		decision.RoomID, decision.NewRoom = s.entered, s.isNew
+	}
+	decision.RoomCount = s.Mapper.RoomCount()
+	return decision
+}
+
+// Run replays every line, calling fn with each decision
+func (s *Session) Run(lines []Line, fn func(Decision)) {
+	for _, line := range lines {
+		fn(s.Step(line))
+	}
+}
@@ -0,0 +1,106 @@
+package safefile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("SafeFile")
+
+// BackupSuffix names the previous copy WriteWithBackup keeps beside a file
+const BackupSuffix = ".bak"
+
+// rename puts the new copy in place; tests swap it to fail a write
+var rename = os.Rename
+
+// WriteFile replaces path with data so that a crash leaves either the old
+// file or the new one, never a mix: the data is written to a temporary file
+// in the same directory, synced to disk and renamed over path. A file being
+// replaced keeps its permissions; a new one gets perm.
+func WriteFile(path string, data []byte, perm os.FileMode) error {
+	if info, err := os.Stat(path); err == nil {
+		perm = info.Mode().Perm()
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync %s: %w", filepath.Base(path), err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", filepath.Base(path), err)
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("failed to set permissions on %s: %w", filepath.Base(path), err)
+	}
+	if err := rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace %s: %w", filepath.Base(path), err)
+	}
+	syncDir(filepath.Dir(path))
+	return nil
+}
+
+// WriteWithBackup is WriteFile for files that can't be regenerated: the copy
+// being replaced is kept as path.bak, for ReadFile to fall back on
+func WriteWithBackup(path string, data []byte, perm os.FileMode) error {
+	if _, err := os.Stat(path); err == nil {
+		// A hard link keeps path in place until the new copy replaces it
+		backup := path + BackupSuffix
+		os.Remove(backup)
+		if err := os.Link(path, backup); err != nil {
+			logger.Debug("failed to keep backup", "path", path, "error", err)
+		}
+	}
+	return WriteFile(path, data, perm)
+}
+
+// ReadFile reads path, falling back to the backup WriteWithBackup kept if
+// path is missing or valid reports it damaged. valid may be nil to only
+// fall back on a missing file. If the backup doesn't help either, path's
+// own contents or error are returned, so callers report the original
+// problem.
+func ReadFile(path string, valid func([]byte) bool) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err == nil && (valid == nil || valid(data)) {
+		return data, nil
+	}
+
+	backup, backupErr := os.ReadFile(path + BackupSuffix)
+	if backupErr != nil || (valid != nil && !valid(backup)) {
+		return data, err
+	}
+
+	if err != nil {
+		logger.Warn("file missing, using backup", "path", path, "error", err)
+	} else {
+		logger.Warn("file damaged, using backup", "path", path)
+		// Keep the damaged copy for anyone who wants to look at it
+		if err := os.Rename(path, path+".damaged"); err != nil {
+			logger.Debug("failed to set damaged file aside", "path", path, "error", err)
+		}
+	}
+	return backup, nil
+}
+
+// syncDir flushes a rename to disk. Not every platform can sync a
+// directory, so failures are ignored.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
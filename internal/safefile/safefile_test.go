@@ -0,0 +1,142 @@
+package safefile
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// contents reads a file, failing the test if it can't
+func contents(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+// names lists a directory, to check nothing was left behind
+func names(t *testing.T, dir string) []string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var list []string
+	for _, entry := range entries {
+		list = append(list, entry.Name())
+	}
+	return list
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "map.json")
+
+	if err := WriteFile(path, []byte("first"), 0644); err != nil {
+		t.Fatalf("WriteFile new: %v", err)
+	}
+	if err := WriteFile(path, []byte("second"), 0644); err != nil {
+		t.Fatalf("WriteFile replacing: %v", err)
+	}
+	if got := contents(t, path); got != "second" {
+		t.Errorf("contents = %q, want \"second\"", got)
+	}
+	if got := names(t, dir); len(got) != 1 {
+		t.Errorf("directory holds %v, want only map.json", got)
+	}
+}
+
+func TestFailedWriteKeepsPrevious(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "map.json")
+	if err := WriteFile(path, []byte("previous"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rename = func(from, to string) error { return fmt.Errorf("disk full") }
+	defer func() { rename = os.Rename }()
+
+	if err := WriteFile(path, []byte("next"), 0644); err == nil {
+		t.Error("WriteFile succeeded despite the rename failing")
+	}
+	if got := contents(t, path); got != "previous" {
+		t.Errorf("contents after failed write = %q, want \"previous\"", got)
+	}
+	if got := names(t, dir); len(got) != 1 {
+		t.Errorf("directory holds %v after failed write, want only map.json", got)
+	}
+}
+
+func TestPermissions(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name     string
+		existing os.FileMode // 0 for no file yet
+		perm     os.FileMode
+		want     os.FileMode
+	}{
+		{"new file", 0, 0644, 0644},
+		{"new private file", 0, 0600, 0600},
+		{"kept private", 0600, 0644, 0600},
+		{"kept open", 0640, 0600, 0640},
+	}
+	for i, test := range tests {
+		path := filepath.Join(dir, fmt.Sprintf("file%d", i))
+		if test.existing != 0 {
+			if err := os.WriteFile(path, []byte("old"), test.existing); err != nil {
+				t.Fatal(err)
+			}
+			// WriteFile's mode is masked by the umask
+			if err := os.Chmod(path, test.existing); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := WriteFile(path, []byte("new"), test.perm); err != nil {
+			t.Fatalf("%s: WriteFile: %v", test.name, err)
+		}
+		info, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != test.want {
+			t.Errorf("%s: mode = %o, want %o", test.name, got, test.want)
+		}
+	}
+}
+
+func TestBackupFallback(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "map.json")
+	if err := WriteWithBackup(path, []byte(`{"rooms":1}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteWithBackup(path, []byte(`{"rooms":2}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := contents(t, path+BackupSuffix); got != `{"rooms":1}` {
+		t.Errorf("backup = %q, want the previous copy", got)
+	}
+
+	// A damaged file falls back to the backup and is set aside
+	if err := os.WriteFile(path, []byte(`{"rooms":`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ReadFile(path, json.Valid)
+	if err != nil || string(data) != `{"rooms":1}` {
+		t.Errorf("ReadFile of damaged file = %q, %v, want the backup", data, err)
+	}
+	if _, err := os.Stat(path + ".damaged"); err != nil {
+		t.Errorf("damaged copy not kept: %v", err)
+	}
+
+	// With the file gone too, the backup still answers
+	os.Remove(path)
+	if data, err := ReadFile(path, json.Valid); err != nil || string(data) != `{"rooms":1}` {
+		t.Errorf("ReadFile of missing file = %q, %v, want the backup", data, err)
+	}
+}
@@ -0,0 +1,234 @@
+package schedule
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("Schedule")
+
+// MinInterval is the shortest a repeating command may wait between sends,
+// so a typo can't flood the server
+const MinInterval = time.Second
+
+// Client commands that schedule another, as typed or in an alias:
+// "#delay 5s stand" and "#repeat 10m save". A bare number is seconds.
+const (
+	DelayCommand  = "#delay"
+	RepeatCommand = "#repeat"
+)
+
+// Request asks for a command to be sent later
+type Request struct {
+	Command string
+	Delay   time.Duration // Until it's first sent
+	Repeat  bool          // Send it every Delay until cancelled
+	Source  string        // Who asked, e.g. a script's name; empty for the player
+}
+
+// Parse reads a #delay or #repeat command, reporting false if the line is
+// neither
+func Parse(line string) (Request, bool, error) {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return Request{}, false, nil
+	}
+	keyword := strings.ToLower(fields[0])
+	if keyword != DelayCommand && keyword != RepeatCommand {
+		return Request{}, false, nil
+	}
+	if len(fields) < 3 {
+		return Request{}, true, fmt.Errorf("usage: %s <time> <command>", keyword)
+	}
+
+	delay, err := parseDuration(fields[1])
+	if err != nil {
+		return Request{}, true, err
+	}
+	// The command is everything after the time, spacing and all
+	rest := strings.TrimSpace(line)
+	rest = strings.TrimSpace(rest[len(fields[0]):])
+	command := strings.TrimSpace(rest[len(fields[1]):])
+	return Request{Command: command, Delay: delay, Repeat: keyword == RepeatCommand}, true, nil
+}
+
+// parseDuration reads "5s", "10m" or "1h30m", or a bare number of seconds
+func parseDuration(text string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(text, 64); err == nil {
+		if seconds < 0 {
+			return 0, fmt.Errorf("invalid time %q", text)
+		}
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	duration, err := time.ParseDuration(text)
+	if err != nil || duration < 0 {
+		return 0, fmt.Errorf("invalid time %q, e.g. 5s or 10m", text)
+	}
+	return duration, nil
+}
+
+// Job is a command waiting to be sent
+type Job struct {
+	ID      int64     `json:"id"`
+	Command string    `json:"command"`
+	Due     time.Time `json:"due"`              // When it's next sent
+	Every   int       `json:"every,omitempty"`  // Seconds between sends; 0 sends it once
+	Source  string    `json:"source,omitempty"` // Who asked, e.g. a script's name
+	Fired   int       `json:"fired"`            // How many times it's been sent
+}
+
+// Fired reports a job's command being sent
+type Fired struct {
+	Job   Job    `json:"job"`
+	Error string `json:"error,omitempty"`
+	Done  bool   `json:"done"` // True if the job won't send again
+}
+
+// job is a Job with its timer
+type job struct {
+	Job
+	interval time.Duration
+	timer    *time.Timer
+}
+
+// Scheduler sends commands after a delay or over and over
+type Scheduler struct {
+	mutex     sync.Mutex
+	send      func(string) error
+	jobs      map[int64]*job
+	nextID    int64
+	listeners []func(Fired)
+}
+
+// New creates a scheduler sending commands through send
+func New(send func(string) error) *Scheduler {
+	return &Scheduler{send: send, jobs: make(map[int64]*job)}
+}
+
+// OnFire registers a listener for every command a job sends
+func (s *Scheduler) OnFire(fn func(Fired)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.listeners = append(s.listeners, fn)
+}
+
+// Add schedules a request and returns its job's ID
+func (s *Scheduler) Add(request Request) (int64, error) {
+	request.Command = strings.TrimSpace(request.Command)
+	if request.Command == "" {
+		return 0, fmt.Errorf("nothing to schedule")
+	}
+	if request.Delay < 0 {
+		return 0, fmt.Errorf("can't schedule a command in the past")
+	}
+	if request.Repeat {
+		if request.Delay < MinInterval {
+			return 0, fmt.Errorf("can't repeat more often than every %s", MinInterval)
+		}
+		if nested, isSchedule, _ := Parse(request.Command); isSchedule && nested.Repeat {
+			return 0, fmt.Errorf("a repeating command can't start another")
+		}
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.nextID++
+	added := &job{
+		Job: Job{
+			ID:      s.nextID,
+			Command: request.Command,
+			Due:     time.Now().Add(request.Delay),
+			Source:  request.Source,
+		},
+	}
+	if request.Repeat {
+		added.interval = request.Delay
+		added.Every = int(request.Delay / time.Second)
+	}
+	added.timer = time.AfterFunc(request.Delay, func() { s.fire(added.ID) })
+	s.jobs[added.ID] = added
+
+	logger.Debug("scheduled command", "command", added.Command, "in", request.Delay, "repeat", request.Repeat)
+	return added.ID, nil
+}
+
+// fire sends a job's command and schedules it again if it repeats
+func (s *Scheduler) fire(id int64) {
+	s.mutex.Lock()
+	current, exists := s.jobs[id]
+	if !exists {
+		s.mutex.Unlock()
+		return
+	}
+	current.Fired++
+	done := current.interval == 0
+	if done {
+		delete(s.jobs, id)
+	} else {
+		current.Due = time.Now().Add(current.interval)
+		current.timer.Reset(current.interval)
+	}
+	fired := Fired{Job: current.Job, Done: done}
+	send, listeners := s.send, s.listeners
+	s.mutex.Unlock()
+
+	if err := send(fired.Job.Command); err != nil {
+		logger.Warn("failed to send scheduled command", "command", fired.Job.Command, "error", err)
+		fired.Error = err.Error()
+	}
+	for _, fn := range listeners {
+		fn(fired)
+	}
+}
+
+// Jobs returns the waiting jobs, soonest first
+func (s *Scheduler) Jobs() []Job {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobs := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j.Job)
+	}
+	sort.Slice(jobs, func(i, k int) bool {
+		if !jobs[i].Due.Equal(jobs[k].Due) {
+			return jobs[i].Due.Before(jobs[k].Due)
+		}
+		return jobs[i].ID < jobs[k].ID
+	})
+	return jobs
+}
+
+// Cancel stops a job, returning false if it had already finished
+func (s *Scheduler) Cancel(id int64) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	j, exists := s.jobs[id]
+	if !exists {
+		return false
+	}
+	j.timer.Stop()
+	delete(s.jobs, id)
+	return true
+}
+
+// CancelAll stops every job and returns how many there were
+func (s *Scheduler) CancelAll() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	count := len(s.jobs)
+	for id, j := range s.jobs {
+		j.timer.Stop()
+		delete(s.jobs, id)
+	}
+	return count
+}
@@ -0,0 +1,197 @@
+package script
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/session"
+	"seemud-gui/internal/sessionlog"
+)
+
+var logger = logging.For("Script")
+
+// lineBuffer is how many unread output lines expect can fall behind by
+const lineBuffer = 4096
+
+// Failure is a script step that didn't succeed
+type Failure struct {
+	Step   Step
+	Reason string
+}
+
+func (f *Failure) Error() string {
+	return fmt.Sprintf("line %d (%s): %s", f.Step.Line, f.Step.Kind, f.Reason)
+}
+
+// Runner plays scripts against an engine
+type Runner struct {
+	mud *engine.Engine
+	log *sessionlog.Writer // Nil for none
+
+	lines        chan string
+	failed       chan *Failure
+	disconnected chan struct{}
+	closeOnce    sync.Once
+	stopped      atomic.Bool
+	unsubscribe  []func() // Removes the runner's handlers from the engine
+
+	mutex sync.RWMutex
+	fails []Step // Patterns that abort the script when seen
+}
+
+// NewRunner creates a runner for an engine. The session log (nil for none)
+// should already be attached to the engine; the runner only tells it which
+// commands are secret. Create the runner before connecting so the login
+// banner is captured.
+func NewRunner(mud *engine.Engine, log *sessionlog.Writer) *Runner {
+	r := &Runner{
+		mud:          mud,
+		log:          log,
+		lines:        make(chan string, lineBuffer),
+		failed:       make(chan *Failure, 1),
+		disconnected: make(chan struct{}),
+	}
+	r.unsubscribe = []func(){
+		mud.OnLine(r.onLine),
+		mud.OnStateChange(func(from, to session.State) {
+			if to == session.StateDisconnected || to == session.StateLinkDead {
+				r.closeOnce.Do(func() { close(r.disconnected) })
+			}
+		}),
+	}
+	return r
+}
+
+// onLine checks fail patterns and queues a line of output for expect
+func (r *Runner) onLine(entry output.Entry, parsed *parser.ParsedOutput) {
+	if r.stopped.Load() {
+		return
+	}
+	text := parsed.CleanText
+
+	r.mutex.RLock()
+	for _, step := range r.fails {
+		if step.Pattern.MatchString(text) {
+			select {
+			case r.failed <- &Failure{Step: step, Reason: "output matched: " + text}:
+			default:
+			}
+			break
+		}
+	}
+	r.mutex.RUnlock()
+
+	select {
+	case r.lines <- text:
+	default:
+		logger.Warn("output buffer full, dropping line")
+	}
+}
+
+// Run plays a script, returning a *Failure if a step fails
+func (r *Runner) Run(s *Script) error {
+	timeout := DefaultTimeout
+	for _, step := range s.Steps {
+		var err error
+		switch step.Kind {
+		case StepSend:
+			err = r.send(step)
+		case StepSecret:
+			if r.log != nil {
+				r.log.Hide(step.Text)
+			}
+			err = r.send(step)
+		case StepWait:
+			err = r.wait(step)
+		case StepTimeout:
+			timeout = step.Duration
+		case StepExpect:
+			err = r.expect(step, timeout)
+		case StepFail:
+			r.mutex.Lock()
+			r.fails = append(r.fails, step)
+			r.mutex.Unlock()
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// A fail pattern may have matched during the last step
+	select {
+	case failure := <-r.failed:
+		return failure
+	default:
+		return nil
+	}
+}
+
+// Stop makes the runner ignore further output and removes it from the
+// engine, e.g. once a login script has finished and the player takes over.
+// Every runner must be stopped, even one that never ran.
+func (r *Runner) Stop() {
+	if r.stopped.Swap(true) {
+		return
+	}
+	for _, unsubscribe := range r.unsubscribe {
+		unsubscribe()
+	}
+}
+
+// send sends a step's command
+func (r *Runner) send(step Step) error {
+	if err := r.mud.Input(step.Text); err != nil {
+		return &Failure{Step: step, Reason: err.Error()}
+	}
+	return nil
+}
+
+// wait pauses, stopping early if a fail pattern matches
+func (r *Runner) wait(step Step) error {
+	timer := time.NewTimer(step.Duration)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case failure := <-r.failed:
+		return failure
+	}
+}
+
+// expect consumes output until a line matches. Lines before the match are
+// used up, so consecutive expects match in order.
+func (r *Runner) expect(step Step, timeout time.Duration) error {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case line := <-r.lines:
+			if step.Pattern.MatchString(line) {
+				return nil
+			}
+		case failure := <-r.failed:
+			return failure
+		case <-r.disconnected:
+			// Output may still be waiting from before the connection closed
+			for {
+				select {
+				case line := <-r.lines:
+					if step.Pattern.MatchString(line) {
+						return nil
+					}
+				default:
+					return &Failure{Step: step, Reason: "disconnected before output matched " + step.Text}
+				}
+			}
+		case <-timer.C:
+			return &Failure{Step: step, Reason: fmt.Sprintf("no output matched %s within %s", step.Text, timeout)}
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package script
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is how long expect waits unless the script sets a timeout
+const DefaultTimeout = 10 * time.Second
+
+// Step kinds
+const (
+	StepSend    = "send"    // Send a command, expanding aliases
+	StepSecret  = "secret"  // Send a command without logging it, e.g. a password
+	StepWait    = "wait"    // Pause for a duration
+	StepExpect  = "expect"  // Wait for output matching a pattern
+	StepFail    = "fail"    // Abort if output matches a pattern from here on
+	StepTimeout = "timeout" // Change how long later expects wait
+)
+
+// Step is one line of a bot script
+type Step struct {
+	Line     int
+	Kind     string
+	Text     string         // Command for send and secret, pattern source otherwise
+	Pattern  *regexp.Regexp // For expect and fail
+	Duration time.Duration  // For wait and timeout
+}
+
+// Script is a parsed bot script
+type Script struct {
+	Name  string
+	Steps []Step
+}
+
+// Load reads and parses a script file, expanding vars as Parse does
+func Load(path string, vars map[string]string) (*Script, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open script: %w", err)
+	}
+	defer f.Close()
+	return Parse(path, f, vars)
+}
+
+// Parse reads a script: one step per line, # for comments. Lines not starting
+// with a step keyword are sent as commands, so a plain command list is a
+// valid script. $VAR and ${VAR} are replaced from vars, or else the
+// environment, so passwords needn't live in the file. Arguments may be
+// double-quoted, as in expect "Account:"; a quoted pattern matches literally
+// rather than as a regex.
+func Parse(name string, r io.Reader, vars map[string]string) (*Script, error) {
+	expand := func(text string) string {
+		return os.Expand(text, func(key string) string {
+			if value, ok := vars[key]; ok {
+				return value
+			}
+			return os.Getenv(key)
+		})
+	}
+
+	s := &Script{Name: name}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		step, err := parseStep(n, line, expand)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", name, n, err)
+		}
+		s.Steps = append(s.Steps, step)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+	return s, nil
+}
+
+// parseStep parses a single non-empty, non-comment line
+func parseStep(n int, line string, expand func(string) string) (Step, error) {
+	keyword, rest, _ := strings.Cut(line, " ")
+	rest = strings.TrimSpace(rest)
+	step := Step{Line: n, Kind: strings.ToLower(keyword), Text: rest}
+
+	switch step.Kind {
+	case StepSend, StepSecret:
+		text, _, err := argument(rest)
+		if err != nil {
+			return step, err
+		}
+		step.Text = expand(text)
+	case StepWait, StepTimeout:
+		duration, err := time.ParseDuration(rest)
+		if err != nil || duration < 0 {
+			return step, fmt.Errorf("%s needs a duration like 2s or 500ms", step.Kind)
+		}
+		step.Duration = duration
+	case StepExpect, StepFail:
+		if rest == "" {
+			return step, fmt.Errorf("%s needs a pattern", step.Kind)
+		}
+		text, quoted, err := argument(rest)
+		if err != nil {
+			return step, err
+		}
+		text = expand(text)
+		if quoted {
+			text = regexp.QuoteMeta(text)
+		}
+		pattern, err := regexp.Compile(text)
+		if err != nil {
+			return step, fmt.Errorf("invalid pattern: %w", err)
+		}
+		step.Pattern = pattern
+	default:
+		// A bare command
+		step.Kind = StepSend
+		step.Text = expand(line)
+	}
+	return step, nil
+}
+
+// argument unquotes a double-quoted argument, reporting whether it was quoted
+func argument(rest string) (string, bool, error) {
+	if !strings.HasPrefix(rest, `"`) {
+		return rest, false, nil
+	}
+	text, err := strconv.Unquote(rest)
+	if err != nil {
+		return "", false, fmt.Errorf("unterminated or invalid quoted argument")
+	}
+	return text, true, nil
+}
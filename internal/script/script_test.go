@@ -0,0 +1,31 @@
+package script
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVars(t *testing.T) {
+	t.Setenv("SEEMUD_TEST_PASSWORD", "hunter2")
+	t.Setenv("SEEMUD_TEST_CHARACTER", "from the environment")
+	vars := map[string]string{"SEEMUD_TEST_CHARACTER": "Aragorn"}
+
+	tests := []struct {
+		line string
+		want string
+	}{
+		{"send $SEEMUD_TEST_CHARACTER", "Aragorn"},
+		{"secret ${SEEMUD_TEST_PASSWORD}", "hunter2"},
+		{"say hi $SEEMUD_TEST_UNSET", "say hi "},
+	}
+	for _, test := range tests {
+		s, err := Parse("test", strings.NewReader(test.line), vars)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.line, err)
+			continue
+		}
+		if got := s.Steps[0].Text; got != test.want {
+			t.Errorf("Parse(%q) = %q, want %q", test.line, got, test.want)
+		}
+	}
+}
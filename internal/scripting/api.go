@@ -0,0 +1,270 @@
+package scripting
+
+import (
+	"strings"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"seemud-gui/internal/combat"
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/schedule"
+	"seemud-gui/internal/tick"
+)
+
+// Lua state limits, small enough that a runaway script can't take much
+const (
+	callStackSize   = 120
+	registryMaxSize = 256 * 1024
+)
+
+// safeLibraries are the standard libraries scripts get: nothing that reads
+// files, runs programs or loads other code
+var safeLibraries = []struct {
+	name string
+	open lua.LGFunction
+}{
+	{lua.BaseLibName, lua.OpenBase},
+	{lua.TabLibName, lua.OpenTable},
+	{lua.StringLibName, lua.OpenString},
+	{lua.MathLibName, lua.OpenMath},
+}
+
+// unsafeGlobals are the base library functions that reach outside the
+// sandbox
+var unsafeGlobals = []string{"dofile", "loadfile", "load", "loadstring", "require", "module", "collectgarbage"}
+
+// sandbox makes a Lua state with the safe libraries and the seemud API
+func (s *Scripts) sandbox(sc *script) *lua.LState {
+	L := lua.NewState(lua.Options{
+		SkipOpenLibs:    true,
+		CallStackSize:   callStackSize,
+		RegistryMaxSize: registryMaxSize,
+	})
+	for _, lib := range safeLibraries {
+		L.Push(L.NewFunction(lib.open))
+		L.Push(lua.LString(lib.name))
+		L.Call(1, 0)
+	}
+	for _, name := range unsafeGlobals {
+		L.SetGlobal(name, lua.LNil)
+	}
+
+	api := L.SetFuncs(L.NewTable(), map[string]lua.LGFunction{
+		// seemud.send(command) sends a command as if typed, aliases and all
+		"send": func(L *lua.LState) int {
+			if err := s.mud.Input(L.CheckString(1)); err != nil {
+				L.Push(lua.LFalse)
+				L.Push(lua.LString(err.Error()))
+				return 2
+			}
+			L.Push(lua.LTrue)
+			return 1
+		},
+		// seemud.after(seconds, command) sends a command once after a delay,
+		// returning an ID for seemud.cancel
+		"after": func(L *lua.LState) int {
+			return s.schedule(L, sc, false)
+		},
+		// seemud.every(seconds, command) sends a command over and over until
+		// cancelled or the script is reloaded
+		"every": func(L *lua.LState) int {
+			return s.schedule(L, sc, true)
+		},
+		// seemud.cancel(id) stops a command from seemud.after or seemud.every,
+		// returning false if it had already been sent
+		"cancel": func(L *lua.LState) int {
+			L.Push(lua.LBool(s.mud.Schedule.Cancel(L.CheckInt64(1))))
+			return 1
+		},
+		// seemud.combat() returns the current or last fight: in_combat,
+		// seconds, dps, damage_dealt, damage_taken and opponents, with the
+		// session's kills and deaths
+		"combat": func(L *lua.LState) int {
+			L.Push(combatTable(L, s.mud.Combat.Summary()))
+			return 1
+		},
+		// seemud.tick() returns where the server's tick is: seconds between
+		// ticks (0 until known), remaining until the next, count and synced
+		"tick": func(L *lua.LState) int {
+			L.Push(tickTable(L, s.mud.Ticks.Status()))
+			return 1
+		},
+		// seemud.sync_tick() marks a tick as happening now
+		"sync_tick": func(L *lua.LState) int {
+			s.mud.Ticks.Sync()
+			return 0
+		},
+		// seemud.on(kind, fn) calls fn with each line of that kind, such as
+		// "room_title" or "say", or every line for "line". fn is called at
+		// each tick for "tick".
+		"on": func(L *lua.LState) int {
+			kind := strings.ToLower(L.CheckString(1))
+			handler := L.CheckFunction(2)
+			sc.handlers[kind] = append(sc.handlers[kind], handler)
+			return 0
+		},
+		// seemud.room() returns the current room from the map, or nil
+		"room": func(L *lua.LState) int {
+			room := s.mud.Mapper.GetCurrentRoom()
+			if room == nil {
+				L.Push(lua.LNil)
+				return 1
+			}
+			L.Push(roomTable(L, room))
+			return 1
+		},
+		// seemud.find_rooms(name) returns the mapped rooms with a name
+		"find_rooms": func(L *lua.LState) int {
+			rooms := L.NewTable()
+			for _, room := range s.mud.Mapper.GetGraph().FindRoomsByName(L.CheckString(1)) {
+				rooms.Append(roomTable(L, room))
+			}
+			L.Push(rooms)
+			return 1
+		},
+		// seemud.path_to(room) returns the directions to a room, given by ID
+		// or name, or nil and why not
+		"path_to": func(L *lua.LState) int {
+			path, err := s.mud.Mapper.PathTo(L.CheckString(1))
+			if err != nil {
+				L.Push(lua.LNil)
+				L.Push(lua.LString(err.Error()))
+				return 2
+			}
+			L.Push(stringTable(L, path))
+			return 1
+		},
+		// seemud.generate_image([prompt]) generates the current room's
+		// image, adding prompt to the usual one
+		"generate_image": func(L *lua.LState) int {
+			s.mutex.RLock()
+			generate := s.generate
+			s.mutex.RUnlock()
+			if generate != nil {
+				// Generating takes seconds, far longer than a call may
+				go generate(L.OptString(1, ""))
+			}
+			return 0
+		},
+	})
+	L.SetGlobal("seemud", api)
+
+	// print shows text to the player rather than writing to stdout
+	L.SetGlobal("print", L.NewFunction(func(L *lua.LState) int {
+		parts := make([]string, L.GetTop())
+		for i := range parts {
+			parts[i] = L.ToStringMeta(L.Get(i + 1)).String()
+		}
+		s.mutex.RLock()
+		print := s.print
+		s.mutex.RUnlock()
+		if print != nil {
+			print(sc.name, strings.Join(parts, "\t"))
+		}
+		return 0
+	}))
+	return L
+}
+
+// schedule adds a job for seemud.after or seemud.every, remembering it so
+// it's cancelled along with the script
+func (s *Scripts) schedule(L *lua.LState, sc *script, repeat bool) int {
+	delay := time.Duration(float64(L.CheckNumber(1)) * float64(time.Second))
+	id, err := s.mud.Schedule.Add(schedule.Request{
+		Command: L.CheckString(2),
+		Delay:   delay,
+		Repeat:  repeat,
+		Source:  sc.name,
+	})
+	if err != nil {
+		L.Push(lua.LNil)
+		L.Push(lua.LString(err.Error()))
+		return 2
+	}
+	sc.jobs = append(sc.jobs, id)
+	L.Push(lua.LNumber(id))
+	return 1
+}
+
+// lineTable converts a parsed line for a handler
+func lineTable(L *lua.LState, parsed *parser.ParsedOutput) *lua.LTable {
+	line := L.NewTable()
+	line.RawSetString("type", lua.LString(parsed.Type.String()))
+	line.RawSetString("text", lua.LString(parsed.CleanText))
+	line.RawSetString("raw", lua.LString(parsed.RawText))
+	line.RawSetString("room", lua.LString(parsed.RoomName))
+	line.RawSetString("exits", stringTable(L, parsed.Exits))
+	line.RawSetString("items", stringTable(L, parsed.Items))
+	line.RawSetString("mobs", stringTable(L, parsed.Mobs))
+	line.RawSetString("speaker", lua.LString(parsed.Speaker))
+	line.RawSetString("channel", lua.LString(parsed.Channel))
+	line.RawSetString("message", lua.LString(parsed.Message))
+	line.RawSetString("outgoing", lua.LBool(parsed.Outgoing))
+	line.RawSetString("opponent", lua.LString(parsed.Opponent))
+	line.RawSetString("highlight", lua.LString(parsed.Highlight))
+	return line
+}
+
+// combatTable converts a combat summary
+func combatTable(L *lua.LState, summary combat.Summary) *lua.LTable {
+	table := L.NewTable()
+	table.RawSetString("in_combat", lua.LBool(summary.InCombat))
+	table.RawSetString("seconds", lua.LNumber(summary.Seconds))
+	table.RawSetString("dps", lua.LNumber(summary.DPS))
+	table.RawSetString("damage_dealt", lua.LNumber(summary.DamageDealt))
+	table.RawSetString("damage_taken", lua.LNumber(summary.DamageTaken))
+	table.RawSetString("kills", lua.LNumber(summary.Kills))
+	table.RawSetString("deaths", lua.LNumber(summary.Deaths))
+	opponents := L.NewTable()
+	for _, opponent := range summary.Opponents {
+		entry := L.NewTable()
+		entry.RawSetString("name", lua.LString(opponent.Name))
+		entry.RawSetString("damage_dealt", lua.LNumber(opponent.DamageDealt))
+		entry.RawSetString("damage_taken", lua.LNumber(opponent.DamageTaken))
+		entry.RawSetString("health", lua.LNumber(opponent.Health))
+		entry.RawSetString("condition", lua.LString(opponent.Condition))
+		entry.RawSetString("killed", lua.LBool(opponent.Killed))
+		opponents.Append(entry)
+	}
+	table.RawSetString("opponents", opponents)
+	return table
+}
+
+// tickTable converts the tick's status
+func tickTable(L *lua.LState, status tick.Status) *lua.LTable {
+	table := L.NewTable()
+	table.RawSetString("seconds", lua.LNumber(status.Seconds))
+	table.RawSetString("remaining", lua.LNumber(status.Remaining))
+	table.RawSetString("count", lua.LNumber(status.Count))
+	table.RawSetString("synced", lua.LBool(status.Synced))
+	return table
+}
+
+// roomTable converts a mapped room
+func roomTable(L *lua.LState, room *mapper.Room) *lua.LTable {
+	table := L.NewTable()
+	table.RawSetString("id", lua.LString(room.ID))
+	table.RawSetString("name", lua.LString(room.Name))
+	table.RawSetString("description", lua.LString(room.Description))
+	table.RawSetString("x", lua.LNumber(room.X))
+	table.RawSetString("y", lua.LNumber(room.Y))
+	table.RawSetString("z", lua.LNumber(room.Z))
+	table.RawSetString("visits", lua.LNumber(room.VisitCount))
+	exits := L.NewTable()
+	for direction, target := range room.Exits {
+		exits.RawSetString(direction, lua.LString(target))
+	}
+	table.RawSetString("exits", exits)
+	return table
+}
+
+// stringTable converts a list of strings to a Lua array
+func stringTable(L *lua.LState, values []string) *lua.LTable {
+	table := L.CreateTable(len(values), 0)
+	for _, value := range values {
+		table.Append(lua.LString(value))
+	}
+	return table
+}
@@ -0,0 +1,257 @@
+package scripting
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	lua "github.com/yuin/gopher-lua"
+
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/tick"
+)
+
+var logger = logging.For("Lua")
+
+// callTimeout stops a script that loops forever from holding up the output
+// it was called for
+const callTimeout = time.Second
+
+// AnyLine is the kind of handler called for every line of output, whatever
+// the parser made of it
+const AnyLine = "line"
+
+// Tick is the kind of handler called at each of the server's ticks, seen
+// or predicted
+const Tick = "tick"
+
+// Info describes a loaded script
+type Info struct {
+	Name     string `json:"name"`
+	Handlers int    `json:"handlers"`        // Output handlers registered
+	Error    string `json:"error,omitempty"` // Why the script didn't load, or last failed
+}
+
+// Scripts runs the user's Lua scripts, each in its own sandbox, and calls
+// their handlers as output is parsed
+type Scripts struct {
+	mud *engine.Engine
+	dir string
+
+	mutex    sync.RWMutex
+	loaded   []*script
+	generate func(prompt string)     // Asked to generate the current room's image
+	print    func(name, text string) // Text a script wants shown to the player
+}
+
+// script is one loaded file. Lua states aren't safe to share, so every call
+// into one holds its mutex.
+type script struct {
+	name     string
+	mutex    sync.Mutex
+	state    *lua.LState
+	handlers map[string][]*lua.LFunction // By output type name, AnyLine or Tick
+	jobs     []int64                     // Commands it scheduled, cancelled when it closes
+	err      string
+}
+
+// New creates a script runner for an engine, loading scripts from the .lua
+// files in dir when Load is called
+func New(mud *engine.Engine, dir string) *Scripts {
+	s := &Scripts{mud: mud, dir: dir}
+	mud.OnLine(s.handleLine)
+	mud.Ticks.OnTick(s.handleTick)
+	return s
+}
+
+// OnGenerateImage sets what to do when a script asks for the current room's
+// image to be generated; prompt is added to the usual one
+func (s *Scripts) OnGenerateImage(fn func(prompt string)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.generate = fn
+}
+
+// OnPrint sets where text printed by a script goes
+func (s *Scripts) OnPrint(fn func(name, text string)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.print = fn
+}
+
+// Load stops any running scripts and loads every script in the directory
+// afresh, so it also reloads them. A script that fails to load is listed
+// with its error; the rest still run.
+func (s *Scripts) Load() ([]Info, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read script directory: %w", err)
+	}
+
+	var loaded []*script
+	for _, f := range files {
+		if f.IsDir() || filepath.Ext(f.Name()) != ".lua" {
+			continue
+		}
+		loaded = append(loaded, s.load(filepath.Join(s.dir, f.Name())))
+	}
+	sort.Slice(loaded, func(i, j int) bool { return loaded[i].name < loaded[j].name })
+
+	s.mutex.Lock()
+	previous := s.loaded
+	s.loaded = loaded
+	s.mutex.Unlock()
+	s.closeAll(previous)
+
+	logger.Info("loaded scripts", "count", len(loaded), "dir", s.dir)
+	return s.List(), nil
+}
+
+// load runs a script file's top level in a new sandbox
+func (s *Scripts) load(path string) *script {
+	sc := &script{
+		name:     strings.TrimSuffix(filepath.Base(path), ".lua"),
+		handlers: make(map[string][]*lua.LFunction),
+	}
+	sc.state = s.sandbox(sc)
+
+	source, err := os.Open(path)
+	if err == nil {
+		defer source.Close()
+		err = sc.run(func(L *lua.LState) error {
+			fn, err := L.Load(source, filepath.Base(path))
+			if err != nil {
+				return err
+			}
+			return L.CallByParam(lua.P{Fn: fn, NRet: 0, Protect: true})
+		})
+	}
+	if err != nil {
+		sc.err = errorMessage(err)
+		logger.Warn("failed to load script", "script", sc.name, "error", err)
+	}
+	return sc
+}
+
+// List describes the loaded scripts, sorted by name
+func (s *Scripts) List() []Info {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	infos := make([]Info, len(s.loaded))
+	for i, sc := range s.loaded {
+		sc.mutex.Lock()
+		count := 0
+		for _, handlers := range sc.handlers {
+			count += len(handlers)
+		}
+		infos[i] = Info{Name: sc.name, Handlers: count, Error: sc.err}
+		sc.mutex.Unlock()
+	}
+	return infos
+}
+
+// Close stops every script
+func (s *Scripts) Close() {
+	s.mutex.Lock()
+	loaded := s.loaded
+	s.loaded = nil
+	s.mutex.Unlock()
+	s.closeAll(loaded)
+}
+
+// closeAll closes scripts' Lua states once any call in progress finishes,
+// cancelling the commands they scheduled
+func (s *Scripts) closeAll(scripts []*script) {
+	for _, sc := range scripts {
+		sc.mutex.Lock()
+		sc.state.Close()
+		sc.handlers = nil
+		for _, id := range sc.jobs {
+			s.mud.Schedule.Cancel(id)
+		}
+		sc.jobs = nil
+		sc.mutex.Unlock()
+	}
+}
+
+// handleLine calls every script's handlers for a parsed line
+func (s *Scripts) handleLine(entry output.Entry, parsed *parser.ParsedOutput) {
+	s.mutex.RLock()
+	loaded := s.loaded
+	s.mutex.RUnlock()
+
+	kind := parsed.Type.String()
+	for _, sc := range loaded {
+		sc.mutex.Lock()
+		handlers := append(append([]*lua.LFunction(nil), sc.handlers[kind]...), sc.handlers[AnyLine]...)
+		sc.mutex.Unlock()
+
+		for _, handler := range handlers {
+			err := sc.run(func(L *lua.LState) error {
+				return L.CallByParam(lua.P{Fn: handler, NRet: 0, Protect: true}, lineTable(L, parsed))
+			})
+			if err != nil {
+				logger.Warn("script handler failed", "script", sc.name, "kind", kind, "error", err)
+			}
+		}
+	}
+}
+
+// handleTick calls every script's tick handlers
+func (s *Scripts) handleTick(status tick.Status) {
+	s.mutex.RLock()
+	loaded := s.loaded
+	s.mutex.RUnlock()
+
+	for _, sc := range loaded {
+		sc.mutex.Lock()
+		handlers := append([]*lua.LFunction(nil), sc.handlers[Tick]...)
+		sc.mutex.Unlock()
+
+		for _, handler := range handlers {
+			err := sc.run(func(L *lua.LState) error {
+				return L.CallByParam(lua.P{Fn: handler, NRet: 0, Protect: true}, tickTable(L, status))
+			})
+			if err != nil {
+				logger.Warn("script handler failed", "script", sc.name, "kind", Tick, "error", err)
+			}
+		}
+	}
+}
+
+// run calls into a script's Lua state, giving up after callTimeout
+func (sc *script) run(call func(L *lua.LState) error) error {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+	if sc.handlers == nil {
+		return fmt.Errorf("script %s has been closed", sc.name)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), callTimeout)
+	defer cancel()
+	sc.state.SetContext(ctx)
+	defer sc.state.RemoveContext()
+
+	err := call(sc.state)
+	if err != nil {
+		sc.err = errorMessage(err)
+	}
+	return err
+}
+
+// errorMessage returns a Lua error without its stack trace
+func errorMessage(err error) string {
+	if apiErr, ok := err.(*lua.ApiError); ok && apiErr.Object != nil {
+		return strings.TrimSpace(apiErr.Object.String())
+	}
+	return strings.TrimSpace(err.Error())
+}
@@ -0,0 +1,152 @@
+package session
+
+import (
+	"sync"
+	"time"
+
+	"seemud-gui/internal/parser"
+)
+
+// State is the phase of the connection to the MUD
+type State string
+
+const (
+	StateDisconnected    State = "disconnected"
+	StateConnecting      State = "connecting"
+	StateLoginPrompt     State = "login_prompt"
+	StateCharacterSelect State = "character_select"
+	StateInGame          State = "in_game"
+	StateLinkDead        State = "link_dead"
+)
+
+// transitions lists the states reachable from each state. Disconnected is
+// always reachable so the user can give up at any point.
+var transitions = map[State][]State{
+	StateDisconnected:    {StateConnecting},
+	StateConnecting:      {StateLoginPrompt, StateCharacterSelect, StateInGame, StateLinkDead},
+	StateLoginPrompt:     {StateCharacterSelect, StateInGame, StateLinkDead},
+	StateCharacterSelect: {StateLoginPrompt, StateInGame, StateLinkDead},
+	StateInGame:          {StateLoginPrompt, StateCharacterSelect, StateLinkDead},
+	StateLinkDead:        {StateConnecting},
+}
+
+// ChangeFunc is called after every state change
+type ChangeFunc func(from, to State)
+
+// listener is a registered ChangeFunc, numbered so it can be removed
+type listener struct {
+	id int
+	fn ChangeFunc
+}
+
+// Machine tracks the connection state, replacing ad-hoc "are we in game yet"
+// string sniffing with explicit transitions driven by parser events
+type Machine struct {
+	mutex     sync.RWMutex
+	state     State
+	since     time.Time
+	listeners []listener // In the order registered
+	nextID    int
+}
+
+// NewMachine creates a machine in the Disconnected state
+func NewMachine() *Machine {
+	return &Machine{
+		state: StateDisconnected,
+		since: time.Now(),
+	}
+}
+
+// State returns the current state
+func (m *Machine) State() State {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.state
+}
+
+// Since returns when the current state was entered
+func (m *Machine) Since() time.Time {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.since
+}
+
+// OnChange registers a listener for state changes and returns a function
+// removing it
+func (m *Machine) OnChange(fn ChangeFunc) func() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	id := m.nextID
+	m.nextID++
+	m.listeners = append(m.listeners, listener{id: id, fn: fn})
+
+	return func() {
+		m.mutex.Lock()
+		defer m.mutex.Unlock()
+		listeners := make([]listener, 0, len(m.listeners))
+		for _, registered := range m.listeners {
+			if registered.id != id {
+				listeners = append(listeners, registered)
+			}
+		}
+		m.listeners = listeners
+	}
+}
+
+// CanTransition reports whether the machine may move from one state to another
+func CanTransition(from, to State) bool {
+	if to == StateDisconnected {
+		return from != StateDisconnected
+	}
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition moves to a new state if the move is valid, notifying listeners.
+// It returns false if the state did not change.
+func (m *Machine) Transition(to State) bool {
+	m.mutex.Lock()
+	from := m.state
+	if from == to || !CanTransition(from, to) {
+		m.mutex.Unlock()
+		return false
+	}
+	m.state = to
+	m.since = time.Now()
+	listeners := append([]listener{}, m.listeners...)
+	m.mutex.Unlock()
+
+	// Listeners run outside the lock so they can query the machine
+	for _, registered := range listeners {
+		registered.fn(from, to)
+	}
+	return true
+}
+
+// HandleParsed advances the machine from a parsed line of output
+func (m *Machine) HandleParsed(parsed *parser.ParsedOutput) bool {
+	switch parsed.Type {
+	case parser.TypeLoginPrompt:
+		return m.Transition(StateLoginPrompt)
+	case parser.TypeMenu:
+		return m.Transition(StateCharacterSelect)
+	case parser.TypeRoomTitle, parser.TypeExits:
+		// Seeing a room means the login dance is over
+		return m.Transition(StateInGame)
+	}
+	return false
+}
+
+// IsConnected reports whether the state implies a live connection
+func (s State) IsConnected() bool {
+	switch s {
+	case StateLoginPrompt, StateCharacterSelect, StateInGame:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,22 @@
+package session
+
+import "testing"
+
+func TestOnChangeRemove(t *testing.T) {
+	machine := NewMachine()
+	var first, second int
+	remove := machine.OnChange(func(from, to State) { first++ })
+	machine.OnChange(func(from, to State) { second++ })
+
+	machine.Transition(StateConnecting)
+	remove()
+	remove() // Removing twice is harmless
+	machine.Transition(StateDisconnected)
+
+	if first != 1 {
+		t.Errorf("removed listener called %d times, want 1", first)
+	}
+	if second != 2 {
+		t.Errorf("remaining listener called %d times, want 2", second)
+	}
+}
@@ -0,0 +1,103 @@
+package sessionlog
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/parser"
+	"seemud-gui/internal/session"
+)
+
+// Variant is what a log records of each line from the server
+type Variant string
+
+const (
+	VariantClean Variant = "clean" // Text as the parser cleaned it
+	VariantRaw   Variant = "raw"   // Text as received, with colour codes
+)
+
+// ParseVariant validates a variant name, defaulting to clean
+func ParseVariant(name string) (Variant, error) {
+	switch Variant(strings.ToLower(strings.TrimSpace(name))) {
+	case "", VariantClean:
+		return VariantClean, nil
+	case VariantRaw:
+		return VariantRaw, nil
+	}
+	return "", fmt.Errorf("unknown log format %q (clean or raw)", name)
+}
+
+// masked replaces commands that shouldn't be written down
+const masked = "********"
+
+// Writer writes a timestamped session log in the format replay.ReadLog
+// reads: server output indented by two spaces and commands after "> ".
+// A raw log replays exactly what the parser saw, colour codes and all.
+type Writer struct {
+	mutex   sync.Mutex
+	w       io.Writer
+	variant Variant
+	hidden  map[string]bool
+}
+
+// New creates a log writer
+func New(w io.Writer, variant Variant) *Writer {
+	return &Writer{w: w, variant: variant, hidden: make(map[string]bool)}
+}
+
+// Attach logs an engine's output and the commands it sends. Commands sent
+// at the account prompts are masked, since one of them is a password.
+func (l *Writer) Attach(mud *engine.Engine) {
+	mud.OnLine(l.Output)
+	mud.OnSend(func(command string) {
+		if mud.State() == session.StateLoginPrompt {
+			l.Secret()
+			return
+		}
+		l.Command(command)
+	})
+}
+
+// Hide masks a command whenever it's logged, e.g. a password sent in game
+func (l *Writer) Hide(command string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.hidden[command] = true
+}
+
+// Output logs a line from the server
+func (l *Writer) Output(entry output.Entry, parsed *parser.ParsedOutput) {
+	text := parsed.CleanText
+	if l.variant == VariantRaw {
+		text = strings.TrimRight(entry.Line, "\r\n")
+	}
+	l.write("  ", text)
+}
+
+// Command logs a command sent to the server
+func (l *Writer) Command(command string) {
+	l.mutex.Lock()
+	hidden := l.hidden[command]
+	l.mutex.Unlock()
+	if hidden {
+		command = masked
+	}
+	l.write("> ", command)
+}
+
+// Secret logs that a command was sent without saying what it was
+func (l *Writer) Secret() {
+	l.write("> ", masked)
+}
+
+// write adds a timestamped line
+func (l *Writer) write(prefix, text string) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	fmt.Fprintf(l.w, "%s %s%s\n", time.Now().Format("15:04:05.000"), prefix, text)
+}
@@ -0,0 +1,193 @@
+package sound
+
+import (
+	"context"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/events"
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("Sound")
+
+// maxConcurrent bounds how many sounds may overlap, so combat spam can't
+// spawn hundreds of player processes
+const maxConcurrent = 4
+
+// playTimeout stops runaway players (e.g. a looping MSP music file)
+const playTimeout = 2 * time.Minute
+
+// Cue is the sound played for one event kind
+type Cue struct {
+	File   string `json:"file"`   // Path to the audio file, empty for silence
+	Volume int    `json:"volume"` // 0-100, scaled by the master volume
+	Muted  bool   `json:"muted"`
+}
+
+// Settings configures the sound engine
+type Settings struct {
+	Enabled      bool                `json:"enabled"`
+	MasterVolume int                 `json:"master_volume"`
+	Cues         map[events.Kind]Cue `json:"cues"`
+	MSPEnabled   bool                `json:"msp_enabled"`
+	MSPDirectory string              `json:"msp_directory"` // Where MSP sound files are found
+}
+
+// DefaultSettings returns settings with a silent cue for each supported event
+func DefaultSettings() Settings {
+	cues := make(map[events.Kind]Cue)
+	for _, kind := range []events.Kind{
+		events.KindTell, events.KindCombatStart, events.KindAttacked,
+		events.KindDeath, events.KindLevelUp, events.KindDisconnect,
+	} {
+		cues[kind] = Cue{Volume: 80}
+	}
+
+	return Settings{
+		Enabled:      true,
+		MasterVolume: 100,
+		Cues:         cues,
+		MSPEnabled:   true,
+		MSPDirectory: filepath.Join("cache", "sounds"),
+	}
+}
+
+// Engine plays audio cues for game events and MSP triggers
+type Engine struct {
+	mutex    sync.RWMutex
+	settings Settings
+	player   Player
+	slots    chan struct{}
+}
+
+// NewEngine creates a sound engine
+func NewEngine(player Player, settings Settings) *Engine {
+	return &Engine{
+		settings: settings,
+		player:   player,
+		slots:    make(chan struct{}, maxConcurrent),
+	}
+}
+
+// Settings returns a copy of the current settings
+func (e *Engine) Settings() Settings {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	copied := e.settings
+	copied.Cues = make(map[events.Kind]Cue, len(e.settings.Cues))
+	for kind, cue := range e.settings.Cues {
+		copied.Cues[kind] = cue
+	}
+	return copied
+}
+
+// SetSettings replaces the settings
+func (e *Engine) SetSettings(settings Settings) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	if settings.Cues == nil {
+		settings.Cues = make(map[events.Kind]Cue)
+	}
+	e.settings = settings
+}
+
+// SetCue sets the cue for one event kind
+func (e *Engine) SetCue(kind events.Kind, cue Cue) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.settings.Cues[kind] = cue
+}
+
+// SetMuted mutes or unmutes all sound without losing the cue configuration
+func (e *Engine) SetMuted(muted bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.settings.Enabled = !muted
+}
+
+// HandleEvent plays the cue configured for an event, if any
+func (e *Engine) HandleEvent(event events.Event) {
+	e.mutex.RLock()
+	settings := e.settings
+	cue, hasCue := settings.Cues[event.Kind]
+	e.mutex.RUnlock()
+
+	if !settings.Enabled {
+		return
+	}
+
+	if event.Kind == events.KindSound {
+		if settings.MSPEnabled {
+			e.playMSP(event, settings)
+		}
+		return
+	}
+
+	if !hasCue || cue.Muted || cue.File == "" {
+		return
+	}
+	e.play(cue.File, cue.Volume*settings.MasterVolume/100)
+}
+
+// Preview plays the cue for an event kind even if it is muted
+func (e *Engine) Preview(kind events.Kind) {
+	e.mutex.RLock()
+	cue := e.settings.Cues[kind]
+	master := e.settings.MasterVolume
+	e.mutex.RUnlock()
+
+	if cue.File != "" {
+		e.play(cue.File, cue.Volume*master/100)
+	}
+}
+
+// playMSP plays a file requested by an MSP trigger from the MSP directory
+func (e *Engine) playMSP(event events.Event, settings Settings) {
+	file := event.Fields["file"]
+	if file == "" || strings.EqualFold(file, "off") {
+		return
+	}
+
+	volume := 100
+	if v, err := strconv.Atoi(event.Fields["volume"]); err == nil {
+		volume = v
+	}
+
+	// MSP file names are relative and may include a type subdirectory; never
+	// let them escape the sound directory
+	clean := filepath.Clean("/" + filepath.FromSlash(file))
+	e.play(filepath.Join(settings.MSPDirectory, clean), volume*settings.MasterVolume/100)
+}
+
+// play starts playback in the background if a slot is free
+func (e *Engine) play(path string, volume int) {
+	if volume <= 0 {
+		return
+	}
+	if volume > 100 {
+		volume = 100
+	}
+
+	select {
+	case e.slots <- struct{}{}:
+	default:
+		return // Too many sounds already playing
+	}
+
+	go func() {
+		defer func() { <-e.slots }()
+
+		ctx, cancel := context.WithTimeout(context.Background(), playTimeout)
+		defer cancel()
+
+		if err := e.player.Play(ctx, path, volume); err != nil {
+			logger.Warn("failed to play sound", "path", path, "error", err)
+		}
+	}()
+}
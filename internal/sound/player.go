@@ -0,0 +1,82 @@
+package sound
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"sync"
+)
+
+// Player plays an audio file at a volume from 0 to 100
+type Player interface {
+	Play(ctx context.Context, path string, volume int) error
+}
+
+// SystemPlayer plays audio with whatever command-line player the platform
+// provides: afplay on macOS, paplay/aplay/ffplay on Linux and the .NET
+// SoundPlayer via PowerShell on Windows
+type SystemPlayer struct {
+	once    sync.Once
+	command string
+}
+
+// NewSystemPlayer creates a player for the current platform
+func NewSystemPlayer() *SystemPlayer {
+	return &SystemPlayer{}
+}
+
+// Available reports whether an audio player command was found
+func (p *SystemPlayer) Available() bool {
+	return p.resolve() != ""
+}
+
+func (p *SystemPlayer) resolve() string {
+	p.once.Do(func() {
+		var candidates []string
+		switch runtime.GOOS {
+		case "darwin":
+			candidates = []string{"afplay"}
+		case "windows":
+			candidates = []string{"powershell"}
+		default:
+			candidates = []string{"paplay", "ffplay", "aplay"}
+		}
+		for _, candidate := range candidates {
+			if _, err := exec.LookPath(candidate); err == nil {
+				p.command = candidate
+				return
+			}
+		}
+	})
+	return p.command
+}
+
+// Play implements Player
+func (p *SystemPlayer) Play(ctx context.Context, path string, volume int) error {
+	command := p.resolve()
+	if command == "" {
+		return fmt.Errorf("no audio player found")
+	}
+
+	var args []string
+	switch command {
+	case "afplay":
+		args = []string{"-v", strconv.FormatFloat(float64(volume)/100, 'f', 2, 64), path}
+	case "paplay":
+		// paplay volume is linear with 65536 as 100%
+		args = []string{"--volume=" + strconv.Itoa(volume*65536/100), path}
+	case "ffplay":
+		args = []string{"-nodisp", "-autoexit", "-loglevel", "quiet", "-volume", strconv.Itoa(volume), path}
+	case "aplay":
+		// aplay has no volume control
+		args = []string{"-q", path}
+	case "powershell":
+		// SoundPlayer has no volume control either
+		args = []string{"-NoProfile", "-Command",
+			"(New-Object Media.SoundPlayer '" + path + "').PlaySync()"}
+	}
+
+	return exec.CommandContext(ctx, command, args...).Run()
+}
@@ -0,0 +1,231 @@
+package speech
+
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/parser"
+)
+
+var logger = logging.For("Speech")
+
+// Verbosity controls how much of the output is narrated
+type Verbosity int
+
+const (
+	VerbosityOff     Verbosity = iota
+	VerbosityMinimal           // Room names, tells and combat
+	VerbosityNormal            // Plus descriptions, exits and all chat
+	VerbosityVerbose           // Everything the server sends
+)
+
+// Settings configures narration
+type Settings struct {
+	Enabled   bool      `json:"enabled"`
+	Verbosity Verbosity `json:"verbosity"`
+	Voice     string    `json:"voice"` // Engine-specific voice name, empty for the default
+	Rate      int       `json:"rate"`  // Words per minute, 0 for the engine default
+	// Summarise skips room descriptions already read and announces only
+	// exits that changed and who or what is new since the last visit
+	Summarise bool `json:"summarise"`
+	// ScreenReader hands narration to the screen reader instead of speaking it
+	ScreenReader bool `json:"screen_reader"`
+	// Templates override what an event says, keyed by output type ("tell",
+	// "exits", ...) or "exits_changed". Fields such as {text}, {room},
+	// {speaker} and {message} are filled in; an empty template is silent.
+	Templates map[string]string `json:"templates"`
+}
+
+// DefaultSettings returns narration settings with speech switched off
+func DefaultSettings() Settings {
+	return Settings{
+		Enabled:   false,
+		Verbosity: VerbosityNormal,
+	}
+}
+
+// queueSize bounds how far narration can fall behind before lines are dropped
+const queueSize = 50
+
+// Narrator speaks parsed output according to the verbosity settings.
+// Speech runs on its own goroutine so slow TTS never blocks the output
+// pipeline.
+type Narrator struct {
+	mutex     sync.RWMutex
+	settings  Settings
+	speaker   Speaker
+	queue     chan string
+	cancel    context.CancelFunc // Cancels the utterance in progress
+	memory    *memory
+	onNarrate func(text string)
+}
+
+// NewNarrator creates a narrator and starts its speech worker
+func NewNarrator(speaker Speaker, settings Settings) *Narrator {
+	n := &Narrator{
+		settings: settings,
+		speaker:  speaker,
+		queue:    make(chan string, queueSize),
+		memory:   newMemory(),
+	}
+	go n.run()
+	return n
+}
+
+// Settings returns the current settings
+func (n *Narrator) Settings() Settings {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+	return n.settings
+}
+
+// SetSettings replaces the settings. Disabling narration stops any speech.
+func (n *Narrator) SetSettings(settings Settings) {
+	n.mutex.Lock()
+	n.settings = settings
+	n.mutex.Unlock()
+
+	if !settings.Enabled || settings.Verbosity == VerbosityOff || settings.ScreenReader {
+		n.Stop()
+	}
+}
+
+// OnNarrate registers a callback for narration going to the screen reader
+func (n *Narrator) OnNarrate(fn func(text string)) {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.onNarrate = fn
+}
+
+// Forget clears what summaries remember having narrated, e.g. on
+// connecting to another server
+func (n *Narrator) Forget() {
+	n.mutex.Lock()
+	defer n.mutex.Unlock()
+	n.memory = newMemory()
+}
+
+// Handle narrates a parsed line if the verbosity calls for it
+func (n *Narrator) Handle(parsed *parser.ParsedOutput) {
+	settings := n.Settings()
+	if !settings.Enabled || settings.Verbosity == VerbosityOff {
+		return
+	}
+
+	if !speaks(parsed, settings.Verbosity) {
+		return
+	}
+
+	fields := Fields(parsed)
+	event := parsed.Type.String()
+	if settings.Summarise {
+		n.mutex.Lock()
+		event = n.memory.summarise(parsed, fields)
+		n.mutex.Unlock()
+		if event == "" {
+			return
+		}
+	}
+
+	if text := Render(event, fields, settings.Templates); text != "" {
+		n.narrate(text, settings)
+	}
+}
+
+// Say queues arbitrary text regardless of verbosity, e.g. client messages
+func (n *Narrator) Say(text string) {
+	settings := n.Settings()
+	if !settings.Enabled {
+		return
+	}
+	n.narrate(text, settings)
+}
+
+// narrate speaks text, or passes it to the screen reader
+func (n *Narrator) narrate(text string, settings Settings) {
+	if !settings.ScreenReader {
+		n.enqueue(text)
+		return
+	}
+
+	n.mutex.RLock()
+	onNarrate := n.onNarrate
+	n.mutex.RUnlock()
+	if onNarrate != nil {
+		onNarrate(text)
+	}
+}
+
+// Stop cancels the current utterance and discards anything queued
+func (n *Narrator) Stop() {
+drain:
+	for {
+		select {
+		case <-n.queue:
+		default:
+			break drain
+		}
+	}
+
+	n.mutex.Lock()
+	if n.cancel != nil {
+		n.cancel()
+	}
+	n.mutex.Unlock()
+}
+
+func (n *Narrator) enqueue(text string) {
+	select {
+	case n.queue <- text:
+	default:
+		// Narration is falling behind, drop rather than block
+	}
+}
+
+// run speaks queued text one utterance at a time
+func (n *Narrator) run() {
+	for text := range n.queue {
+		ctx, cancel := context.WithCancel(context.Background())
+
+		n.mutex.Lock()
+		n.cancel = cancel
+		settings := n.settings
+		n.mutex.Unlock()
+
+		if err := n.speaker.Speak(ctx, text, settings.Voice, settings.Rate); err != nil && ctx.Err() == nil {
+			logger.Warn("failed to speak", "error", err)
+		}
+
+		n.mutex.Lock()
+		n.cancel = nil
+		n.mutex.Unlock()
+		cancel()
+	}
+}
+
+// Narration returns the text to speak for a parsed line at a verbosity,
+// or an empty string if the line should be skipped
+func Narration(parsed *parser.ParsedOutput, verbosity Verbosity) string {
+	if !speaks(parsed, verbosity) {
+		return ""
+	}
+	return Render(parsed.Type.String(), Fields(parsed), nil)
+}
+
+// speaks reports whether a parsed line is narrated at a verbosity
+func speaks(parsed *parser.ParsedOutput, verbosity Verbosity) bool {
+	if strings.TrimSpace(parsed.CleanText) == "" || verbosity == VerbosityOff {
+		return false
+	}
+
+	switch parsed.Type {
+	case parser.TypeRoomTitle, parser.TypeTell, parser.TypeCombat:
+		return true
+	case parser.TypeRoomDescription, parser.TypeSay, parser.TypeChannel, parser.TypeMobs, parser.TypeInventory, parser.TypeExits:
+		return verbosity >= VerbosityNormal
+	default:
+		return verbosity >= VerbosityVerbose
+	}
+}
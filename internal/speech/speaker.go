@@ -0,0 +1,124 @@
+package speech
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Speaker speaks text aloud through a text-to-speech engine
+type Speaker interface {
+	// Speak blocks until the text has been spoken or ctx is cancelled
+	Speak(ctx context.Context, text, voice string, rate int) error
+}
+
+// SystemSpeaker uses the platform's built-in TTS command: say on macOS,
+// spd-say or espeak on Linux and System.Speech via PowerShell on Windows
+type SystemSpeaker struct {
+	once    sync.Once
+	command string
+}
+
+// NewSystemSpeaker creates a speaker for the current platform
+func NewSystemSpeaker() *SystemSpeaker {
+	return &SystemSpeaker{}
+}
+
+// Available reports whether a TTS command was found on this system
+func (s *SystemSpeaker) Available() bool {
+	return s.resolve() != ""
+}
+
+// resolve finds the TTS command once, as LookPath is comparatively slow
+func (s *SystemSpeaker) resolve() string {
+	s.once.Do(func() {
+		var candidates []string
+		switch runtime.GOOS {
+		case "darwin":
+			candidates = []string{"say"}
+		case "windows":
+			candidates = []string{"powershell"}
+		default:
+			candidates = []string{"spd-say", "espeak-ng", "espeak"}
+		}
+		for _, candidate := range candidates {
+			if _, err := exec.LookPath(candidate); err == nil {
+				s.command = candidate
+				return
+			}
+		}
+	})
+	return s.command
+}
+
+// Speak implements Speaker
+func (s *SystemSpeaker) Speak(ctx context.Context, text, voice string, rate int) error {
+	command := s.resolve()
+	if command == "" {
+		return fmt.Errorf("no text-to-speech engine found")
+	}
+
+	var args []string
+	switch command {
+	case "say":
+		if voice != "" {
+			args = append(args, "-v", voice)
+		}
+		if rate > 0 {
+			args = append(args, "-r", strconv.Itoa(rate))
+		}
+		args = append(args, text)
+	case "spd-say":
+		// -w waits for speech to finish so the queue stays in order
+		args = append(args, "-w")
+		if voice != "" {
+			args = append(args, "-y", voice)
+		}
+		if rate > 0 {
+			// spd-say takes -100..100, map words per minute around 175
+			args = append(args, "-r", strconv.Itoa(clampRate((rate-175)/2)))
+		}
+		args = append(args, text)
+	case "espeak", "espeak-ng":
+		if voice != "" {
+			args = append(args, "-v", voice)
+		}
+		if rate > 0 {
+			args = append(args, "-s", strconv.Itoa(rate))
+		}
+		args = append(args, text)
+	case "powershell":
+		script := "Add-Type -AssemblyName System.Speech; " +
+			"$s = New-Object System.Speech.Synthesis.SpeechSynthesizer; "
+		if voice != "" {
+			script += "$s.SelectVoice('" + psQuote(voice) + "'); "
+		}
+		if rate > 0 {
+			// SAPI takes -10..10
+			script += fmt.Sprintf("$s.Rate = %d; ", clampRate((rate-175)/2)/10)
+		}
+		script += "$s.Speak('" + psQuote(text) + "')"
+		args = append(args, "-NoProfile", "-Command", script)
+	}
+
+	return exec.CommandContext(ctx, command, args...).Run()
+}
+
+func clampRate(rate int) int {
+	if rate < -100 {
+		return -100
+	}
+	if rate > 100 {
+		return 100
+	}
+	return rate
+}
+
+// psQuote escapes a string for a single-quoted PowerShell literal
+func psQuote(text string) string {
+	return strings.ReplaceAll(text, "'", "''")
+}
@@ -0,0 +1,155 @@
+package speech
+
+import (
+	"sort"
+	"strings"
+
+	"seemud-gui/internal/parser"
+)
+
+// maxRemembered bounds how many description lines are remembered before
+// starting afresh, so a long session can't grow it forever
+const maxRemembered = 5000
+
+// defaultTemplates are what each event says unless the settings override
+// it. Events without one say {text}.
+var defaultTemplates = map[string]string{
+	"room_title":    "{room}",
+	"exits":         "Exits: {exits}",
+	"exits_changed": "Exits changed: {changes}",
+}
+
+// DefaultTemplates returns the spoken templates used for events the
+// settings don't override
+func DefaultTemplates() map[string]string {
+	templates := make(map[string]string, len(defaultTemplates))
+	for event, template := range defaultTemplates {
+		templates[event] = template
+	}
+	return templates
+}
+
+// memory is what has already been narrated, so summaries can skip what the
+// player has heard before. Rooms are known by name, as that's all the
+// parser gives.
+type memory struct {
+	room         string
+	descriptions map[string]bool            // Description lines already read
+	exits        map[string][]string        // Exits last announced, by room
+	entities     map[string]map[string]bool // Who and what was there on the last visit, by room
+	present      map[string]bool            // Who and what is there this visit
+}
+
+func newMemory() *memory {
+	return &memory{
+		descriptions: make(map[string]bool),
+		exits:        make(map[string][]string),
+		entities:     make(map[string]map[string]bool),
+		present:      make(map[string]bool),
+	}
+}
+
+// summarise returns the event to narrate a line as, or "" if the player
+// already knows what it says. fields gains anything the event needs.
+func (m *memory) summarise(parsed *parser.ParsedOutput, fields map[string]string) string {
+	event := parsed.Type.String()
+
+	switch parsed.Type {
+	case parser.TypeRoomTitle:
+		m.entities[m.room] = m.present
+		m.room = parsed.RoomName
+		m.present = make(map[string]bool)
+
+	case parser.TypeRoomDescription:
+		line := strings.TrimSpace(parsed.CleanText)
+		if m.descriptions[line] {
+			return ""
+		}
+		if len(m.descriptions) >= maxRemembered {
+			m.descriptions = make(map[string]bool)
+		}
+		m.descriptions[line] = true
+
+	case parser.TypeExits:
+		exits := append([]string(nil), parsed.Exits...)
+		sort.Strings(exits)
+		previous, known := m.exits[m.room]
+		m.exits[m.room] = exits
+		if !known {
+			return event
+		}
+		added, removed := difference(exits, previous), difference(previous, exits)
+		if len(added) == 0 && len(removed) == 0 {
+			return ""
+		}
+		var changes []string
+		if len(added) > 0 {
+			changes = append(changes, "new "+strings.Join(added, ", "))
+		}
+		if len(removed) > 0 {
+			changes = append(changes, "gone "+strings.Join(removed, ", "))
+		}
+		fields["added"] = strings.Join(added, ", ")
+		fields["removed"] = strings.Join(removed, ", ")
+		fields["changes"] = strings.Join(changes, "; ")
+		return "exits_changed"
+
+	case parser.TypeMobs, parser.TypeInventory:
+		// Only what's arrived since the last visit is news
+		name := strings.TrimSpace(parsed.CleanText)
+		m.present[name] = true
+		if m.entities[m.room][name] {
+			return ""
+		}
+	}
+
+	return event
+}
+
+// difference returns the entries of a missing from b
+func difference(a, b []string) []string {
+	in := make(map[string]bool, len(b))
+	for _, entry := range b {
+		in[entry] = true
+	}
+	var missing []string
+	for _, entry := range a {
+		if !in[entry] {
+			missing = append(missing, entry)
+		}
+	}
+	return missing
+}
+
+// Fields returns the values a spoken template can use for a parsed line
+func Fields(parsed *parser.ParsedOutput) map[string]string {
+	return map[string]string{
+		"text":     strings.TrimSpace(parsed.CleanText),
+		"room":     parsed.RoomName,
+		"exits":    strings.Join(parsed.Exits, ", "),
+		"items":    strings.Join(parsed.Items, ", "),
+		"mobs":     strings.Join(parsed.Mobs, ", "),
+		"speaker":  parsed.Speaker,
+		"channel":  parsed.Channel,
+		"message":  parsed.Message,
+		"opponent": parsed.Opponent,
+	}
+}
+
+// Render fills in an event's template, preferring the one in templates. An
+// event given an empty template there says nothing.
+func Render(event string, fields map[string]string, templates map[string]string) string {
+	template, overridden := templates[event]
+	if !overridden {
+		template = defaultTemplates[event]
+		if template == "" {
+			template = "{text}"
+		}
+	}
+
+	replacements := make([]string, 0, len(fields)*2)
+	for name, value := range fields {
+		replacements = append(replacements, "{"+name+"}", value)
+	}
+	return strings.TrimSpace(strings.NewReplacer(replacements...).Replace(template))
+}
@@ -0,0 +1,184 @@
+package speedwalk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/safefile"
+)
+
+// Route is a named, stored walk such as "home" or "shop run"
+type Route struct {
+	Name string `json:"name"`
+	Path string `json:"path"`          // e.g. "3n 2e u" or "n;n;n;e;e;u"
+	Key  string `json:"key,omitempty"` // Hotkey, e.g. "F5" or "Ctrl+H"
+}
+
+// Expand turns a speedwalk path into individual movement commands. Steps
+// are separated by spaces, commas or semicolons and may start with a repeat
+// count: "3n 2e" becomes n, n, n, e, e.
+func Expand(path string) ([]string, error) {
+	fields := strings.FieldsFunc(path, func(r rune) bool {
+		return unicode.IsSpace(r) || r == ',' || r == ';'
+	})
+
+	var commands []string
+	for _, field := range fields {
+		digits := strings.IndexFunc(field, func(r rune) bool { return !unicode.IsDigit(r) })
+		if digits < 0 {
+			return nil, fmt.Errorf("step %q has no direction", field)
+		}
+
+		count := 1
+		if digits > 0 {
+			n, err := strconv.Atoi(field[:digits])
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("invalid repeat count in %q", field)
+			}
+			count = n
+		}
+
+		direction := strings.ToLower(field[digits:])
+		if _, known := mapper.DirectionOffsets[direction]; !known {
+			return nil, fmt.Errorf("unknown direction %q", direction)
+		}
+
+		for i := 0; i < count; i++ {
+			commands = append(commands, direction)
+		}
+	}
+
+	if len(commands) == 0 {
+		return nil, fmt.Errorf("empty speedwalk")
+	}
+	return commands, nil
+}
+
+// Store holds the user's routes, saved as JSON
+type Store struct {
+	mutex  sync.RWMutex
+	path   string
+	routes map[string]Route // Keyed by lowercased name
+}
+
+// NewStore creates a store saving to path, loading any existing routes
+func NewStore(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		routes: make(map[string]Route),
+	}
+
+	data, err := safefile.ReadFile(path, json.Valid)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read speedwalks: %w", err)
+	}
+
+	var routes []Route
+	if err := json.Unmarshal(data, &routes); err != nil {
+		return s, fmt.Errorf("failed to unmarshal speedwalks: %w", err)
+	}
+	for _, route := range routes {
+		s.routes[strings.ToLower(route.Name)] = route
+	}
+	return s, nil
+}
+
+// List returns all routes sorted by name
+func (s *Store) List() []Route {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	routes := make([]Route, 0, len(s.routes))
+	for _, route := range s.routes {
+		routes = append(routes, route)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return strings.ToLower(routes[i].Name) < strings.ToLower(routes[j].Name)
+	})
+	return routes
+}
+
+// Get returns a route by name
+func (s *Store) Get(name string) (Route, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	route, exists := s.routes[strings.ToLower(strings.TrimSpace(name))]
+	return route, exists
+}
+
+// ByKey returns the route bound to a hotkey
+func (s *Store) ByKey(key string) (Route, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, route := range s.routes {
+		if route.Key != "" && strings.EqualFold(route.Key, key) {
+			return route, true
+		}
+	}
+	return Route{}, false
+}
+
+// Set adds or replaces a route after checking its path expands. Any other
+// route bound to the same key loses the binding.
+func (s *Store) Set(route Route) error {
+	route.Name = strings.TrimSpace(route.Name)
+	route.Key = strings.TrimSpace(route.Key)
+	if route.Name == "" {
+		return fmt.Errorf("speedwalk needs a name")
+	}
+	if _, err := Expand(route.Path); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	if route.Key != "" {
+		for name, other := range s.routes {
+			if strings.EqualFold(other.Key, route.Key) {
+				other.Key = ""
+				s.routes[name] = other
+			}
+		}
+	}
+	s.routes[strings.ToLower(route.Name)] = route
+	s.mutex.Unlock()
+
+	return s.save()
+}
+
+// Delete removes a route
+func (s *Store) Delete(name string) error {
+	s.mutex.Lock()
+	delete(s.routes, strings.ToLower(strings.TrimSpace(name)))
+	s.mutex.Unlock()
+
+	return s.save()
+}
+
+// save writes the routes to disk
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s.List(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal speedwalks: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create speedwalk directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write speedwalks: %w", err)
+	}
+	return nil
+}
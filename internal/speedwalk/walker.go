@@ -0,0 +1,172 @@
+package speedwalk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/pacing"
+)
+
+var logger = logging.For("Speedwalk")
+
+// StepTimeout is how long a walk waits to arrive in a room before giving up
+const StepTimeout = 10 * time.Second
+
+// Walker walks a mapped route a step at a time, waiting to arrive in each
+// room before sending the next direction. A step that ends up somewhere the
+// map didn't expect, such as through a closed door or a one-way exit, stops
+// the walk rather than sending the rest of it into the wrong rooms.
+type Walker struct {
+	mutex     sync.Mutex
+	send      pacing.SendFunc
+	nextID    int64
+	current   *walk
+	listeners []func(pacing.Progress)
+}
+
+// walk is one walk in progress
+type walk struct {
+	id      int64
+	label   string
+	route   *mapper.Walk
+	arrived chan string
+	stop    chan struct{}
+}
+
+// NewWalker creates a walker sending directions through send
+func NewWalker(send pacing.SendFunc) *Walker {
+	return &Walker{send: send}
+}
+
+// OnProgress registers a listener for walk progress, reported the same way
+// as the queue's so speedwalks and walks look alike
+func (w *Walker) OnProgress(fn func(pacing.Progress)) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.listeners = append(w.listeners, fn)
+}
+
+// Start walks a route, pausing delay between arriving and the next step. A
+// walk already running is stopped first. It returns the walk's ID, which
+// progress reports as its batch.
+func (w *Walker) Start(label string, route *mapper.Walk, delay time.Duration) int64 {
+	w.Abort()
+
+	w.mutex.Lock()
+	w.nextID++
+	current := &walk{
+		id:      w.nextID,
+		label:   label,
+		route:   route,
+		arrived: make(chan string, 1),
+		stop:    make(chan struct{}),
+	}
+	w.current = current
+	w.mutex.Unlock()
+
+	go w.run(current, delay)
+	return current.id
+}
+
+// Arrived tells a running walk the player has entered a room
+func (w *Walker) Arrived(roomID string) {
+	w.mutex.Lock()
+	current := w.current
+	w.mutex.Unlock()
+	if current == nil {
+		return
+	}
+
+	// Only the latest room matters; drop one the walk hasn't looked at yet
+	select {
+	case current.arrived <- roomID:
+	default:
+		select {
+		case <-current.arrived:
+		default:
+		}
+		current.arrived <- roomID
+	}
+}
+
+// Abort stops the running walk, reporting whether there was one
+func (w *Walker) Abort() bool {
+	w.mutex.Lock()
+	current := w.current
+	w.current = nil
+	w.mutex.Unlock()
+
+	if current == nil {
+		return false
+	}
+	close(current.stop)
+	return true
+}
+
+// run sends each step and checks where it led
+func (w *Walker) run(current *walk, delay time.Duration) {
+	total := len(current.route.Directions)
+	progress := pacing.Progress{BatchID: current.id, Label: current.label, Total: total}
+	finish := func(cancelled bool, err error) {
+		w.mutex.Lock()
+		if w.current == current {
+			w.current = nil
+		}
+		w.mutex.Unlock()
+
+		progress.Done, progress.Cancelled = true, cancelled
+		if err != nil {
+			progress.Error = err.Error()
+			logger.Warn("walk stopped", "label", current.label, "step", progress.Sent, "error", err)
+		}
+		w.notify(progress)
+	}
+
+	for i, direction := range current.route.Directions {
+		if i > 0 {
+			select {
+			case <-current.stop:
+				finish(true, nil)
+				return
+			case <-time.After(delay):
+			}
+		}
+
+		if err := w.send(direction); err != nil {
+			finish(false, err)
+			return
+		}
+		progress.Sent = i + 1
+		w.notify(progress)
+
+		expected := current.route.Rooms[i]
+		select {
+		case <-current.stop:
+			finish(true, nil)
+			return
+		case <-time.After(StepTimeout):
+			finish(false, fmt.Errorf("didn't arrive anywhere after %q", direction))
+			return
+		case roomID := <-current.arrived:
+			if roomID != expected {
+				finish(false, fmt.Errorf("%q led somewhere the map didn't expect", direction))
+				return
+			}
+		}
+	}
+	finish(false, nil)
+}
+
+// notify reports progress to the listeners
+func (w *Walker) notify(progress pacing.Progress) {
+	w.mutex.Lock()
+	listeners := w.listeners
+	w.mutex.Unlock()
+
+	for _, fn := range listeners {
+		fn(progress)
+	}
+}
@@ -0,0 +1,151 @@
+package statedb
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"seemud-gui/internal/mapper"
+)
+
+// SaveMap replaces a server's saved map
+func (s *DB) SaveMap(serverName string, data *mapper.MapData) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save map: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, table := range []string{"rooms", "exits"} {
+		if _, err := tx.Exec("DELETE FROM "+table+" WHERE server = ?", serverName); err != nil {
+			return fmt.Errorf("failed to save map: %w", err)
+		}
+	}
+	if _, err := tx.Exec(`INSERT INTO maps (server, current_room_id, fingerprint, saved) VALUES (?, ?, ?, ?)
+		ON CONFLICT (server) DO UPDATE SET current_room_id = excluded.current_room_id,
+		fingerprint = excluded.fingerprint, saved = excluded.saved`,
+		serverName, data.CurrentRoomID, data.Fingerprint, formatTime(time.Now())); err != nil {
+		return fmt.Errorf("failed to save map: %w", err)
+	}
+
+	if data.Graph != nil {
+		rooms, err := tx.Prepare(`INSERT INTO rooms (server, id, name, description, x, y, z, exits, visited,
+			visit_count, uncertain, notes, prompt_additions) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+		if err != nil {
+			return fmt.Errorf("failed to save map: %w", err)
+		}
+		defer rooms.Close()
+		for _, room := range data.Graph.Rooms {
+			exits, err := json.Marshal(room.Exits)
+			if err != nil {
+				return fmt.Errorf("failed to marshal exits: %w", err)
+			}
+			if _, err := rooms.Exec(serverName, room.ID, room.Name, room.Description, room.X, room.Y, room.Z,
+				string(exits), formatTime(room.Visited), room.VisitCount, room.Uncertain, room.Notes,
+				room.PromptAdditions); err != nil {
+				return fmt.Errorf("failed to save room: %w", err)
+			}
+		}
+
+		exits, err := tx.Prepare(`INSERT OR REPLACE INTO exits (server, from_id, direction, to_id) VALUES (?, ?, ?, ?)`)
+		if err != nil {
+			return fmt.Errorf("failed to save map: %w", err)
+		}
+		defer exits.Close()
+		for _, exit := range data.Graph.Exits {
+			if _, err := exits.Exec(serverName, exit.From, exit.Direction, exit.To); err != nil {
+				return fmt.Errorf("failed to save exit: %w", err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save map: %w", err)
+	}
+	return nil
+}
+
+// LoadMap reads a server's saved map, returning false if there isn't one
+func (s *DB) LoadMap(serverName string) (*mapper.MapData, bool, error) {
+	data := &mapper.MapData{Version: mapper.MapVersion, ServerName: serverName, Graph: mapper.NewRoomGraph()}
+	var saved string
+	err := s.db.QueryRow("SELECT current_room_id, fingerprint, saved FROM maps WHERE server = ?", serverName).
+		Scan(&data.CurrentRoomID, &data.Fingerprint, &saved)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load map: %w", err)
+	}
+
+	rows, err := s.db.Query(`SELECT id, name, description, x, y, z, exits, visited, visit_count, uncertain,
+		notes, prompt_additions FROM rooms WHERE server = ?`, serverName)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load rooms: %w", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var room mapper.Room
+		var exits, visited string
+		if err := rows.Scan(&room.ID, &room.Name, &room.Description, &room.X, &room.Y, &room.Z, &exits, &visited,
+			&room.VisitCount, &room.Uncertain, &room.Notes, &room.PromptAdditions); err != nil {
+			return nil, false, fmt.Errorf("failed to load room: %w", err)
+		}
+		if err := json.Unmarshal([]byte(exits), &room.Exits); err != nil {
+			return nil, false, fmt.Errorf("failed to read exits of room %s: %w", room.ID, err)
+		}
+		room.Visited = parseTime(visited)
+		data.Graph.PlaceRoom(&room)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to load rooms: %w", err)
+	}
+
+	exits, err := s.db.Query("SELECT from_id, direction, to_id FROM exits WHERE server = ? ORDER BY rowid", serverName)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to load exits: %w", err)
+	}
+	defer exits.Close()
+	for exits.Next() {
+		var exit mapper.Exit
+		if err := exits.Scan(&exit.From, &exit.Direction, &exit.To); err != nil {
+			return nil, false, fmt.Errorf("failed to load exit: %w", err)
+		}
+		data.Graph.Exits = append(data.Graph.Exits, &exit)
+	}
+	if err := exits.Err(); err != nil {
+		return nil, false, fmt.Errorf("failed to load exits: %w", err)
+	}
+	return data, true, nil
+}
+
+// RoomSummary identifies a room in query results
+type RoomSummary struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Notes string `json:"notes"`
+}
+
+// NotedRoomsWithoutImages lists a server's rooms that have notes but no
+// cached image, e.g. to draw the places the player cared enough to annotate
+func (s *DB) NotedRoomsWithoutImages(serverName string) ([]RoomSummary, error) {
+	rows, err := s.db.Query(`SELECT r.id, r.name, r.notes FROM rooms r
+		LEFT JOIN images i ON i.server = r.server AND i.room_id = r.id
+		WHERE r.server = ? AND r.notes != '' AND i.room_id IS NULL
+		ORDER BY r.name`, serverName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rooms: %w", err)
+	}
+	defer rows.Close()
+
+	rooms := []RoomSummary{}
+	for rows.Next() {
+		var room RoomSummary
+		if err := rows.Scan(&room.ID, &room.Name, &room.Notes); err != nil {
+			return nil, fmt.Errorf("failed to query rooms: %w", err)
+		}
+		rooms = append(rooms, room)
+	}
+	return rooms, rows.Err()
+}
@@ -0,0 +1,149 @@
+package statedb
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"seemud-gui/internal/stats"
+	"seemud-gui/internal/trigger"
+)
+
+// Image is a cached image's metadata. Record holds the cache's full index
+// entry; the other fields are copied out of it for querying.
+type Image struct {
+	RoomID  string
+	File    string
+	Prompt  string
+	Created time.Time
+	Record  json.RawMessage
+}
+
+// SaveImages replaces the image metadata recorded for a server
+func (s *DB) SaveImages(serverName string, images []Image) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM images WHERE server = ?", serverName); err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	insert, err := tx.Prepare(`INSERT OR REPLACE INTO images (server, room_id, file, prompt, created, record) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	defer insert.Close()
+	for _, image := range images {
+		if _, err := insert.Exec(serverName, image.RoomID, image.File, image.Prompt, formatTime(image.Created), string(image.Record)); err != nil {
+			return fmt.Errorf("failed to save image: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	return nil
+}
+
+// SaveTriggers replaces the saved triggers
+func (s *DB) SaveTriggers(triggers []trigger.Trigger) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save triggers: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM triggers"); err != nil {
+		return fmt.Errorf("failed to save triggers: %w", err)
+	}
+	insert, err := tx.Prepare(`INSERT OR REPLACE INTO triggers (name, pattern, hook, commands, grp, enabled) VALUES (?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to save triggers: %w", err)
+	}
+	defer insert.Close()
+	for _, t := range triggers {
+		if _, err := insert.Exec(t.Name, t.Pattern, string(t.Hook), t.Commands, t.Group, t.Enabled); err != nil {
+			return fmt.Errorf("failed to save trigger: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save triggers: %w", err)
+	}
+	return nil
+}
+
+// AddCommand records a command sent to a server
+func (s *DB) AddCommand(serverName, command string) error {
+	if _, err := s.db.Exec("INSERT INTO history (server, sent, command) VALUES (?, ?, ?)",
+		serverName, formatTime(time.Now()), command); err != nil {
+		return fmt.Errorf("failed to record command: %w", err)
+	}
+	return nil
+}
+
+// HistoryEntry is a command from the history
+type HistoryEntry struct {
+	Sent    time.Time `json:"sent"`
+	Command string    `json:"command"`
+}
+
+// History returns the most recent commands sent to a server, oldest first
+func (s *DB) History(serverName string, limit int) ([]HistoryEntry, error) {
+	rows, err := s.db.Query(`SELECT sent, command FROM
+		(SELECT id, sent, command FROM history WHERE server = ? ORDER BY id DESC LIMIT ?)
+		ORDER BY id`, serverName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read history: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []HistoryEntry{}
+	for rows.Next() {
+		var entry HistoryEntry
+		var sent string
+		if err := rows.Scan(&sent, &entry.Command); err != nil {
+			return nil, fmt.Errorf("failed to read history: %w", err)
+		}
+		entry.Sent = parseTime(sent)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// AddSession records a finished play session's stats
+func (s *DB) AddSession(serverName string, session stats.Stats) error {
+	if _, err := s.db.Exec(`INSERT INTO sessions (server, started, ended, uptime_seconds, commands_sent, lines_received,
+		rooms_discovered, images_generated, deaths) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		serverName, formatTime(session.StartedAt), formatTime(session.EndedAt), session.UptimeSeconds,
+		session.CommandsSent, session.LinesReceived, session.RoomsDiscovered, session.ImagesGenerated,
+		session.Deaths); err != nil {
+		return fmt.Errorf("failed to record session: %w", err)
+	}
+	return nil
+}
+
+// Sessions returns a server's recorded sessions, newest first
+func (s *DB) Sessions(serverName string, limit int) ([]stats.Stats, error) {
+	rows, err := s.db.Query(`SELECT started, ended, uptime_seconds, commands_sent, lines_received, rooms_discovered,
+		images_generated, deaths FROM sessions WHERE server = ? ORDER BY id DESC LIMIT ?`, serverName, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sessions: %w", err)
+	}
+	defer rows.Close()
+
+	sessions := []stats.Stats{}
+	for rows.Next() {
+		var session stats.Stats
+		var started, ended string
+		if err := rows.Scan(&started, &ended, &session.UptimeSeconds, &session.CommandsSent, &session.LinesReceived,
+			&session.RoomsDiscovered, &session.ImagesGenerated, &session.Deaths); err != nil {
+			return nil, fmt.Errorf("failed to read sessions: %w", err)
+		}
+		session.StartedAt, session.EndedAt = parseTime(started), parseTime(ended)
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,164 @@
+package statedb
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("StateDB")
+
+// DB is the optional single SQLite database for client state: maps, image
+// metadata, triggers, command history and session stats. With it, maps
+// are saved here rather than as JSON files, and everything can be queried
+// together.
+type DB struct {
+	db *sql.DB
+}
+
+// migrations upgrade the schema one version at a time. The database's
+// user_version records how many have run; append new ones, never edit old
+// ones.
+var migrations = []string{
+	`CREATE TABLE maps (
+		server          TEXT PRIMARY KEY,
+		current_room_id TEXT NOT NULL DEFAULT '',
+		saved           TEXT NOT NULL
+	);
+	CREATE TABLE rooms (
+		server           TEXT NOT NULL,
+		id               TEXT NOT NULL,
+		name             TEXT NOT NULL,
+		description      TEXT NOT NULL,
+		x                INTEGER NOT NULL,
+		y                INTEGER NOT NULL,
+		z                INTEGER NOT NULL,
+		exits            TEXT NOT NULL,
+		visited          TEXT NOT NULL,
+		visit_count      INTEGER NOT NULL,
+		uncertain        INTEGER NOT NULL,
+		notes            TEXT NOT NULL DEFAULT '',
+		prompt_additions TEXT NOT NULL DEFAULT '',
+		PRIMARY KEY (server, id)
+	);
+	CREATE TABLE exits (
+		server    TEXT NOT NULL,
+		from_id   TEXT NOT NULL,
+		direction TEXT NOT NULL,
+		to_id     TEXT NOT NULL,
+		PRIMARY KEY (server, from_id, direction)
+	);
+	CREATE TABLE images (
+		server  TEXT NOT NULL,
+		room_id TEXT NOT NULL,
+		file    TEXT NOT NULL,
+		prompt  TEXT NOT NULL DEFAULT '',
+		created TEXT NOT NULL,
+		record  TEXT NOT NULL,
+		PRIMARY KEY (server, room_id)
+	);
+	CREATE TABLE triggers (
+		name     TEXT PRIMARY KEY,
+		pattern  TEXT NOT NULL DEFAULT '',
+		hook     TEXT NOT NULL DEFAULT '',
+		commands TEXT NOT NULL,
+		grp      TEXT NOT NULL DEFAULT '',
+		enabled  INTEGER NOT NULL
+	);
+	CREATE TABLE history (
+		id      INTEGER PRIMARY KEY,
+		server  TEXT NOT NULL,
+		sent    TEXT NOT NULL,
+		command TEXT NOT NULL
+	);
+	CREATE INDEX history_server ON history (server, id);
+	CREATE TABLE sessions (
+		id               INTEGER PRIMARY KEY,
+		server           TEXT NOT NULL,
+		started          TEXT NOT NULL,
+		ended            TEXT NOT NULL,
+		uptime_seconds   INTEGER NOT NULL,
+		commands_sent    INTEGER NOT NULL,
+		lines_received   INTEGER NOT NULL,
+		rooms_discovered INTEGER NOT NULL,
+		images_generated INTEGER NOT NULL,
+		deaths           INTEGER NOT NULL
+	);`,
+	`ALTER TABLE maps ADD COLUMN fingerprint TEXT NOT NULL DEFAULT '';`,
+}
+
+// Open opens or creates the database at path and brings its schema up to
+// date
+func Open(path string) (*DB, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create database directory: %w", err)
+	}
+	db, err := sql.Open("sqlite3", path+"?_journal_mode=WAL&_busy_timeout=5000&_foreign_keys=on")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// SQLite allows one writer; a single connection keeps writes in order
+	db.SetMaxOpenConns(1)
+
+	s := &DB{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database
+func (s *DB) Close() error {
+	return s.db.Close()
+}
+
+// migrate runs any migrations the database hasn't had yet, each in its own
+// transaction
+func (s *DB) migrate() error {
+	var version int
+	if err := s.db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("database schema version %d is newer than this version of SeeMUD supports", version)
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return fmt.Errorf("failed to start migration: %w", err)
+		}
+		if _, err := tx.Exec(migrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate database to version %d: %w", i+1, err)
+		}
+		// PRAGMA doesn't take parameters
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to migrate database to version %d: %w", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("failed to migrate database to version %d: %w", i+1, err)
+		}
+		logger.Info("migrated database", "version", i+1)
+	}
+	return nil
+}
+
+// formatTime stores times as sortable text
+func formatTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+// parseTime reads a stored time, giving the zero time for anything invalid
+func parseTime(value string) time.Time {
+	t, _ := time.Parse(time.RFC3339Nano, value)
+	return t
+}
@@ -0,0 +1,125 @@
+package stats
+
+import (
+	"sync"
+	"time"
+
+	"seemud-gui/internal/events"
+)
+
+// Stats is a snapshot of one play session's counters
+type Stats struct {
+	Active          bool      `json:"active"`
+	StartedAt       time.Time `json:"started_at"`
+	EndedAt         time.Time `json:"ended_at,omitempty"`
+	UptimeSeconds   int64     `json:"uptime_seconds"`
+	CommandsSent    int       `json:"commands_sent"`
+	LinesReceived   int       `json:"lines_received"`
+	RoomsDiscovered int       `json:"rooms_discovered"`
+	ImagesGenerated int       `json:"images_generated"`
+	Deaths          int       `json:"deaths"`
+}
+
+// Tracker counts activity for the current session. Counters reset when a
+// new session starts, and freeze when it ends so the summary stays readable.
+type Tracker struct {
+	mutex sync.RWMutex
+	stats Stats
+}
+
+// NewTracker creates a tracker with no session
+func NewTracker() *Tracker {
+	return &Tracker{}
+}
+
+// Start begins a new session, resetting the counters
+func (t *Tracker) Start() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.stats = Stats{Active: true, StartedAt: time.Now()}
+}
+
+// Resume reopens the last session after a reconnect, keeping its counters
+// and start time
+func (t *Tracker) Resume() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.stats.StartedAt.IsZero() {
+		t.stats = Stats{StartedAt: time.Now()}
+	}
+	t.stats.Active = true
+	t.stats.EndedAt = time.Time{}
+}
+
+// End finishes the session and returns its final stats
+func (t *Tracker) End() Stats {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.stats.Active {
+		t.stats.Active = false
+		t.stats.EndedAt = time.Now()
+	}
+	return t.snapshot()
+}
+
+// CommandSent counts a command sent by the player
+func (t *Tracker) CommandSent() {
+	t.increment(&t.stats.CommandsSent)
+}
+
+// LineReceived counts a line of output
+func (t *Tracker) LineReceived() {
+	t.increment(&t.stats.LinesReceived)
+}
+
+// RoomDiscovered counts a room mapped for the first time
+func (t *Tracker) RoomDiscovered() {
+	t.increment(&t.stats.RoomsDiscovered)
+}
+
+// ImageGenerated counts a newly generated room image
+func (t *Tracker) ImageGenerated() {
+	t.increment(&t.stats.ImagesGenerated)
+}
+
+// HandleEvent counts game events that feed the stats
+func (t *Tracker) HandleEvent(event events.Event) {
+	if event.Kind == events.KindDeath {
+		t.increment(&t.stats.Deaths)
+	}
+}
+
+// increment bumps a counter while a session is active
+func (t *Tracker) increment(counter *int) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	if t.stats.Active {
+		*counter++
+	}
+}
+
+// Snapshot returns the current session's stats
+func (t *Tracker) Snapshot() Stats {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.snapshot()
+}
+
+// snapshot copies the stats with uptime filled in; the caller must hold the lock
+func (t *Tracker) snapshot() Stats {
+	stats := t.stats
+	if stats.StartedAt.IsZero() {
+		return stats
+	}
+
+	end := stats.EndedAt
+	if stats.Active {
+		end = time.Now()
+	}
+	stats.UptimeSeconds = int64(end.Sub(stats.StartedAt).Seconds())
+	return stats
+}
@@ -2,17 +2,26 @@ package telnet
 
 import (
 	"bufio"
+	"crypto/tls"
 	"fmt"
 	"net"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// Options control how a client connects
+type Options struct {
+	TLS   *TLSConfig   `json:"tls,omitempty"`   // nil for plain telnet
+	Proxy *ProxyConfig `json:"proxy,omitempty"` // nil to connect directly
+}
+
 // Client represents a telnet connection to a MUD server
 type Client struct {
 	host       string
 	port       string
+	options    Options
 	conn       net.Conn
 	reader     *bufio.Reader
 	writer     *bufio.Writer
@@ -21,19 +30,52 @@ type Client struct {
 	outputChan chan string
 	inputChan  chan string
 	closeChan  chan bool
+	doneChan   chan struct{} // Closed when the read loop exits
+	dropped    atomic.Int64  // Lines skipped because the output buffer was full
+
+	// Telnet negotiation, sent ahead of queued commands
+	negotiateChan chan []byte
+	negotiator    negotiator
+	msdp          *MSDP
 }
 
 // NewClient creates a new telnet client
 func NewClient(host, port string) *Client {
+	return NewClientWithOptions(host, port, Options{})
+}
+
+// NewClientWithOptions creates a telnet client connecting as opts say,
+// e.g. over TLS. Without a proxy in opts, any set in the environment is
+// used.
+func NewClientWithOptions(host, port string, opts Options) *Client {
+	if opts.Proxy == nil {
+		opts.Proxy = ProxyFromEnvironment()
+	}
 	return &Client{
 		host:       host,
 		port:       port,
+		options:    opts,
 		outputChan: make(chan string, 100),
 		inputChan:  make(chan string, 10),
 		closeChan:  make(chan bool, 1),
+		doneChan:   make(chan struct{}),
+
+		negotiateChan: make(chan []byte, 16),
+		msdp:          NewMSDP(),
 	}
 }
 
+// UseMSDP replaces the client's MSDP handler, so one can be kept across
+// connections. Call it before Connect.
+func (c *Client) UseMSDP(m *MSDP) {
+	c.msdp = m
+}
+
+// MSDP returns the client's MSDP handler
+func (c *Client) MSDP() *MSDP {
+	return c.msdp
+}
+
 // Connect establishes connection to the MUD server
 func (c *Client) Connect() error {
 	c.mutex.Lock()
@@ -44,15 +86,39 @@ func (c *Client) Connect() error {
 	}
 
 	address := net.JoinHostPort(c.host, c.port)
-	conn, err := net.DialTimeout("tcp", address, 10*time.Second)
+	var conn net.Conn
+	var err error
+	if c.options.Proxy != nil {
+		conn, err = c.options.Proxy.dial(address, 10*time.Second)
+	} else {
+		conn, err = net.DialTimeout("tcp", address, 10*time.Second)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to connect to %s: %w", address, err)
 	}
 
+	if c.options.TLS != nil {
+		config, err := c.options.TLS.build(c.host)
+		if err != nil {
+			conn.Close()
+			return fmt.Errorf("invalid TLS settings: %w", err)
+		}
+		secure := tls.Client(conn, config)
+		secure.SetDeadline(time.Now().Add(10 * time.Second))
+		if err := secure.Handshake(); err != nil {
+			conn.Close()
+			return fmt.Errorf("failed TLS handshake with %s: %w", address, err)
+		}
+		secure.SetDeadline(time.Time{})
+		conn = secure
+	}
+
 	c.conn = conn
 	c.reader = bufio.NewReader(conn)
 	c.writer = bufio.NewWriter(conn)
 	c.connected = true
+	c.msdp.attach(c.sendRaw)
+	c.negotiator = negotiator{msdp: c.msdp, reply: c.sendRaw}
 
 	// Start goroutines for reading and writing
 	go c.readLoop()
@@ -104,27 +170,51 @@ func (c *Client) SendCommand(command string) error {
 	}
 }
 
+// sendRaw queues telnet bytes to send as they are
+func (c *Client) sendRaw(data []byte) error {
+	select {
+	case c.negotiateChan <- data:
+		return nil
+	default:
+		return fmt.Errorf("negotiation buffer full")
+	}
+}
+
 // GetOutput returns the output channel for reading server messages
 func (c *Client) GetOutput() <-chan string {
 	return c.outputChan
 }
 
+// Dropped returns how many lines were skipped because nobody was reading
+// the output fast enough
+func (c *Client) Dropped() int64 {
+	return c.dropped.Load()
+}
+
+// Done returns a channel that is closed once the connection has ended,
+// whether by Disconnect or because the server dropped it
+func (c *Client) Done() <-chan struct{} {
+	return c.doneChan
+}
+
 // readLoop continuously reads from the server
 func (c *Client) readLoop() {
 	defer func() {
 		c.mutex.Lock()
 		c.connected = false
 		c.mutex.Unlock()
+		close(c.doneChan)
 	}()
 
 	// Send terminal type negotiation response immediately after connection
 	// This tells WolfMUD we're a VT100 terminal with 80x25 size
 	go func() {
 		time.Sleep(100 * time.Millisecond)
-		if c.writer != nil {
-			// Send a newline to accept defaults and get past terminal negotiation
-			c.writer.WriteString("\n")
-			c.writer.Flush()
+		// Send a newline to accept defaults and get past terminal negotiation.
+		// It goes through writeLoop, the only goroutine that may use the writer.
+		select {
+		case c.inputChan <- "":
+		case <-c.closeChan:
 		}
 	}()
 
@@ -146,8 +236,12 @@ func (c *Client) readLoop() {
 				}
 
 				if n > 0 {
-					// Process the received data
-					data := string(buffer[:n])
+					// Process the received data, less any telnet negotiation
+					text := c.negotiator.filter(buffer[:n])
+					if len(text) == 0 {
+						continue
+					}
+					data := string(text)
 
 					// Split by newlines and send each line
 					lines := strings.Split(data, "\n")
@@ -158,6 +252,7 @@ func (c *Client) readLoop() {
 						case c.outputChan <- line:
 						default:
 							// Output buffer full, skip this line
+							c.dropped.Add(1)
 						}
 					}
 				}
@@ -172,8 +267,13 @@ func (c *Client) writeLoop() {
 		select {
 		case <-c.closeChan:
 			return
+		case data := <-c.negotiateChan:
+			if c.conn != nil && c.IsConnected() {
+				c.writer.Write(data)
+				c.writer.Flush()
+			}
 		case command := <-c.inputChan:
-			if c.conn != nil && c.connected {
+			if c.conn != nil && c.IsConnected() {
 				c.writer.WriteString(command + "\n")
 				c.writer.Flush()
 			}
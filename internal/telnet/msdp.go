@@ -0,0 +1,361 @@
+package telnet
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"seemud-gui/internal/logging"
+)
+
+var logger = logging.For("Telnet")
+
+// Telnet bytes the client negotiates with
+const (
+	se   = 240
+	sb   = 250
+	will = 251
+	wont = 252
+	do   = 253
+	dont = 254
+	iac  = 255
+)
+
+// OptMSDP is the telnet option for MSDP, the Mud Server Data Protocol:
+// https://tintin.mudhalla.net/protocols/msdp/
+const OptMSDP = 69
+
+// MSDP's markers within a subnegotiation
+const (
+	msdpVar        = 1
+	msdpVal        = 2
+	msdpTableOpen  = 3
+	msdpTableClose = 4
+	msdpArrayOpen  = 5
+	msdpArrayClose = 6
+)
+
+// DefaultMSDPReports are the variables asked for as soon as a server agrees
+// to MSDP. Servers ignore the ones they don't know.
+var DefaultMSDPReports = []string{
+	"ROOM", "ROOM_VNUM", "ROOM_NAME", "AREA_NAME",
+	"HEALTH", "HEALTH_MAX", "MANA", "MANA_MAX", "MOVEMENT", "MOVEMENT_MAX",
+	"LEVEL", "EXPERIENCE",
+	"OPPONENT_NAME", "OPPONENT_HEALTH", "OPPONENT_HEALTH_MAX", "OPPONENT_LEVEL",
+}
+
+// MSDPStatus is what the server has reported, with the well-known variables
+// picked out. Numbers a server hasn't sent are 0.
+type MSDPStatus struct {
+	Enabled           bool           `json:"enabled"` // The server agreed to MSDP
+	RoomVnum          string         `json:"room_vnum,omitempty"`
+	RoomName          string         `json:"room_name,omitempty"`
+	RoomArea          string         `json:"room_area,omitempty"` // The zone the room is in
+	Health            int            `json:"health"`
+	HealthMax         int            `json:"health_max"`
+	Mana              int            `json:"mana"`
+	ManaMax           int            `json:"mana_max"`
+	Movement          int            `json:"movement"`
+	MovementMax       int            `json:"movement_max"`
+	Level             int            `json:"level"`
+	Experience        int            `json:"experience"`
+	OpponentName      string         `json:"opponent_name,omitempty"`
+	OpponentHealth    int            `json:"opponent_health"`
+	OpponentHealthMax int            `json:"opponent_health_max"`
+	OpponentLevel     int            `json:"opponent_level"`
+	Variables         map[string]any `json:"variables"` // Every variable: a string, []any or map[string]any
+}
+
+// MSDP keeps the variables a server reports over MSDP and asks it for
+// more. One MSDP outlives the connections it's attached to, so the app can
+// hold on to it; attaching to a new connection forgets the old server's
+// values.
+type MSDP struct {
+	mutex     sync.RWMutex
+	enabled   bool
+	variables map[string]any
+	reports   []string
+	send      func([]byte) error
+	onUpdate  func(updated map[string]any)
+}
+
+// NewMSDP creates an MSDP handler asking for DefaultMSDPReports
+func NewMSDP() *MSDP {
+	return &MSDP{
+		variables: make(map[string]any),
+		reports:   DefaultMSDPReports,
+	}
+}
+
+// SetReports replaces the variables asked for when a server agrees to MSDP
+func (m *MSDP) SetReports(names []string) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.reports = normaliseNames(names)
+}
+
+// OnUpdate registers a callback for each message the server sends, with
+// the variables it reported
+func (m *MSDP) OnUpdate(fn func(updated map[string]any)) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.onUpdate = fn
+}
+
+// Enabled reports whether the current server agreed to MSDP
+func (m *MSDP) Enabled() bool {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	return m.enabled
+}
+
+// Value returns a variable's last reported value
+func (m *MSDP) Value(name string) (any, bool) {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+	value, ok := m.variables[strings.ToUpper(name)]
+	return value, ok
+}
+
+// Status returns everything reported so far
+func (m *MSDP) Status() MSDPStatus {
+	m.mutex.RLock()
+	defer m.mutex.RUnlock()
+
+	status := MSDPStatus{Enabled: m.enabled, Variables: make(map[string]any, len(m.variables))}
+	for name, value := range m.variables {
+		status.Variables[name] = value
+	}
+	text := func(name string) string {
+		value, _ := m.variables[name].(string)
+		return value
+	}
+	number := func(name string) int {
+		n, _ := strconv.Atoi(strings.TrimSpace(text(name)))
+		return n
+	}
+
+	status.RoomVnum, status.RoomName, status.RoomArea = text("ROOM_VNUM"), text("ROOM_NAME"), text("AREA_NAME")
+	// Many servers send the room as a table instead
+	if room, ok := m.variables["ROOM"].(map[string]any); ok {
+		if vnum, ok := room["VNUM"].(string); ok && status.RoomVnum == "" {
+			status.RoomVnum = vnum
+		}
+		if name, ok := room["NAME"].(string); ok && status.RoomName == "" {
+			status.RoomName = name
+		}
+		if area, ok := room["AREA"].(string); ok && status.RoomArea == "" {
+			status.RoomArea = area
+		}
+	}
+	status.Health, status.HealthMax = number("HEALTH"), number("HEALTH_MAX")
+	status.Mana, status.ManaMax = number("MANA"), number("MANA_MAX")
+	status.Movement, status.MovementMax = number("MOVEMENT"), number("MOVEMENT_MAX")
+	status.Level, status.Experience = number("LEVEL"), number("EXPERIENCE")
+	status.OpponentName = text("OPPONENT_NAME")
+	status.OpponentHealth, status.OpponentHealthMax = number("OPPONENT_HEALTH"), number("OPPONENT_HEALTH_MAX")
+	status.OpponentLevel = number("OPPONENT_LEVEL")
+	return status
+}
+
+// Report asks the server to send variables now and whenever they change
+func (m *MSDP) Report(names ...string) error {
+	return m.command("REPORT", names)
+}
+
+// Unreport asks the server to stop sending changes to variables
+func (m *MSDP) Unreport(names ...string) error {
+	return m.command("UNREPORT", names)
+}
+
+// Send asks the server to send variables once
+func (m *MSDP) Send(names ...string) error {
+	return m.command("SEND", names)
+}
+
+// List asks the server for one of its lists, such as "REPORTABLE_VARIABLES"
+func (m *MSDP) List(name string) error {
+	return m.command("LIST", []string{name})
+}
+
+// command sends an MSDP command with its arguments as values
+func (m *MSDP) command(command string, args []string) error {
+	args = normaliseNames(args)
+	if len(args) == 0 {
+		return nil
+	}
+
+	m.mutex.RLock()
+	enabled, send := m.enabled, m.send
+	m.mutex.RUnlock()
+	if !enabled || send == nil {
+		return fmt.Errorf("server has not enabled MSDP")
+	}
+	if err := send(encodeMSDP(command, args)); err != nil {
+		return fmt.Errorf("failed to send MSDP %s: %w", command, err)
+	}
+	return nil
+}
+
+// attach connects MSDP to a new connection, forgetting the last server
+func (m *MSDP) attach(send func([]byte) error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.enabled = false
+	m.variables = make(map[string]any)
+	m.send = send
+}
+
+// enable records that the server agreed to MSDP and asks for the reports
+func (m *MSDP) enable() {
+	m.mutex.Lock()
+	m.enabled = true
+	reports := m.reports
+	m.mutex.Unlock()
+
+	if err := m.Report(reports...); err != nil {
+		logger.Warn("failed to request MSDP reports", "error", err)
+	}
+}
+
+// disable records that the server withdrew MSDP
+func (m *MSDP) disable() {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.enabled = false
+}
+
+// receive takes an MSDP subnegotiation, without its option byte
+func (m *MSDP) receive(data []byte) {
+	variables := decodeMSDP(data)
+
+	m.mutex.Lock()
+	for name, value := range variables {
+		m.variables[name] = value
+	}
+	onUpdate := m.onUpdate
+	m.mutex.Unlock()
+
+	if onUpdate != nil && len(variables) > 0 {
+		onUpdate(variables)
+	}
+}
+
+// normaliseNames upper-cases names and drops blanks
+func normaliseNames(names []string) []string {
+	var result []string
+	for _, name := range names {
+		if name = strings.ToUpper(strings.TrimSpace(name)); name != "" {
+			result = append(result, name)
+		}
+	}
+	return result
+}
+
+// encodeMSDP builds IAC SB MSDP VAR command VAL arg... IAC SE
+func encodeMSDP(command string, args []string) []byte {
+	data := []byte{iac, sb, OptMSDP, msdpVar}
+	data = append(data, escapeIAC(command)...)
+	for _, arg := range args {
+		data = append(data, msdpVal)
+		data = append(data, escapeIAC(arg)...)
+	}
+	return append(data, iac, se)
+}
+
+// escapeIAC doubles any IAC bytes in text sent within a subnegotiation
+func escapeIAC(text string) []byte {
+	return []byte(strings.ReplaceAll(text, "\xff", "\xff\xff"))
+}
+
+// decodeMSDP reads VAR name VAL value pairs. Values are strings, tables
+// (map[string]any) or arrays ([]any), nested to any depth; a variable
+// given several VALs becomes an array.
+func decodeMSDP(data []byte) map[string]any {
+	d := &msdpDecoder{data: data}
+	return d.table(false)
+}
+
+// msdpDecoder walks a subnegotiation
+type msdpDecoder struct {
+	data []byte
+	pos  int
+}
+
+// text reads up to the next marker
+func (d *msdpDecoder) text() string {
+	start := d.pos
+	for d.pos < len(d.data) && d.data[d.pos] > msdpArrayClose {
+		d.pos++
+	}
+	return string(d.data[start:d.pos])
+}
+
+// value reads the value following a VAL
+func (d *msdpDecoder) value() any {
+	if d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case msdpTableOpen:
+			d.pos++
+			return d.table(true)
+		case msdpArrayOpen:
+			d.pos++
+			return d.array()
+		}
+	}
+	return d.text()
+}
+
+// table reads VAR name VAL value pairs, to TABLE_CLOSE when nested
+func (d *msdpDecoder) table(nested bool) map[string]any {
+	table := make(map[string]any)
+	name, values := "", []any(nil)
+	for d.pos < len(d.data) {
+		marker := d.data[d.pos]
+		d.pos++
+		switch marker {
+		case msdpVar:
+			name, values = strings.ToUpper(d.text()), nil
+		case msdpVal:
+			value := d.value()
+			if name == "" {
+				continue
+			}
+			// A second VAL for the same VAR makes its values an array,
+			// even if the first was an array itself
+			values = append(values, value)
+			if len(values) == 1 {
+				table[name] = value
+			} else {
+				table[name] = values
+			}
+		case msdpTableClose:
+			if nested {
+				return table
+			}
+		default:
+			// Stray text or a marker out of place
+			d.text()
+		}
+	}
+	return table
+}
+
+// array reads VAL value... to ARRAY_CLOSE
+func (d *msdpDecoder) array() []any {
+	array := []any{}
+	for d.pos < len(d.data) {
+		marker := d.data[d.pos]
+		d.pos++
+		switch marker {
+		case msdpVal:
+			array = append(array, d.value())
+		case msdpArrayClose:
+			return array
+		default:
+			d.text()
+		}
+	}
+	return array
+}
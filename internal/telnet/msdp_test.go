@@ -0,0 +1,175 @@
+package telnet
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+// msdp builds a subnegotiation's payload from markers and text
+func msdp(parts ...any) []byte {
+	var data []byte
+	for _, part := range parts {
+		switch part := part.(type) {
+		case byte:
+			data = append(data, part)
+		case string:
+			data = append(data, part...)
+		}
+	}
+	return data
+}
+
+// Shorter names for the markers in the tests below
+const (
+	vr  = byte(msdpVar)
+	vl  = byte(msdpVal)
+	to  = byte(msdpTableOpen)
+	tc  = byte(msdpTableClose)
+	ao  = byte(msdpArrayOpen)
+	acl = byte(msdpArrayClose)
+)
+
+func TestDecodeMSDP(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want map[string]any
+	}{
+		{"empty", nil, map[string]any{}},
+		{"string", msdp(vr, "HEALTH", vl, "42"), map[string]any{"HEALTH": "42"}},
+		{"lower-case name", msdp(vr, "health", vl, "42"), map[string]any{"HEALTH": "42"}},
+		{"empty value", msdp(vr, "OPPONENT_NAME", vl), map[string]any{"OPPONENT_NAME": ""}},
+		{"several variables", msdp(vr, "HEALTH", vl, "42", vr, "MANA", vl, "7"), map[string]any{"HEALTH": "42", "MANA": "7"}},
+		{"repeated values", msdp(vr, "LIST", vl, "a", vl, "b", vl, "c"), map[string]any{"LIST": []any{"a", "b", "c"}}},
+		{"array", msdp(vr, "EXITS", vl, ao, vl, "n", vl, "e", acl), map[string]any{"EXITS": []any{"n", "e"}}},
+		{"empty array", msdp(vr, "EXITS", vl, ao, acl), map[string]any{"EXITS": []any{}}},
+		{"table", msdp(vr, "ROOM", vl, to, vr, "VNUM", vl, "3001", vr, "NAME", vl, "Temple", tc), map[string]any{
+			"ROOM": map[string]any{"VNUM": "3001", "NAME": "Temple"},
+		}},
+		{"table in table", msdp(vr, "ROOM", vl, to, vr, "EXITS", vl, to, vr, "N", vl, "3002", tc, vr, "NAME", vl, "Temple", tc), map[string]any{
+			"ROOM": map[string]any{"EXITS": map[string]any{"N": "3002"}, "NAME": "Temple"},
+		}},
+		{"array of tables", msdp(vr, "GROUP", vl, ao, vl, to, vr, "NAME", vl, "Ann", tc, vl, to, vr, "NAME", vl, "Bob", tc, acl), map[string]any{
+			"GROUP": []any{map[string]any{"NAME": "Ann"}, map[string]any{"NAME": "Bob"}},
+		}},
+		{"array in array", msdp(vr, "GRID", vl, ao, vl, ao, vl, "1", acl, vl, ao, vl, "2", acl, acl), map[string]any{
+			"GRID": []any{[]any{"1"}, []any{"2"}},
+		}},
+		{"array repeated", msdp(vr, "X", vl, ao, vl, "a", vl, "b", acl, vl, "c"), map[string]any{
+			"X": []any{[]any{"a", "b"}, "c"},
+		}},
+		{"variable after table", msdp(vr, "ROOM", vl, to, vr, "NAME", vl, "Temple", tc, vr, "HEALTH", vl, "42"), map[string]any{
+			"ROOM": map[string]any{"NAME": "Temple"}, "HEALTH": "42",
+		}},
+
+		// Stray and misplaced markers
+		{"val before any var", msdp(vl, "lost", vr, "HEALTH", vl, "42"), map[string]any{"HEALTH": "42"}},
+		{"var without val", msdp(vr, "HEALTH", vr, "MANA", vl, "7"), map[string]any{"MANA": "7"}},
+		{"text before var", msdp("junk", vr, "HEALTH", vl, "42"), map[string]any{"HEALTH": "42"}},
+		{"stray table close", msdp(tc, vr, "HEALTH", vl, "42", tc), map[string]any{"HEALTH": "42"}},
+		{"stray array close", msdp(acl, vr, "HEALTH", vl, "42"), map[string]any{"HEALTH": "42"}},
+		{"val inside array without value", msdp(vr, "X", vl, ao, vl, acl), map[string]any{"X": []any{""}}},
+		{"var inside array", msdp(vr, "X", vl, ao, vr, "Y", vl, "1", acl), map[string]any{"X": []any{"1"}}},
+
+		// Truncated subnegotiations keep what was complete
+		{"truncated after var", msdp(vr, "HEALTH"), map[string]any{}},
+		{"truncated after val", msdp(vr, "HEALTH", vl), map[string]any{"HEALTH": ""}},
+		{"truncated table", msdp(vr, "ROOM", vl, to, vr, "NAME", vl, "Tem"), map[string]any{
+			"ROOM": map[string]any{"NAME": "Tem"},
+		}},
+		{"truncated array", msdp(vr, "EXITS", vl, ao, vl, "n", vl), map[string]any{"EXITS": []any{"n", ""}}},
+		{"truncated at table open", msdp(vr, "ROOM", vl, to), map[string]any{"ROOM": map[string]any{}}},
+	}
+	for _, test := range tests {
+		got := decodeMSDP(test.data)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: decodeMSDP(%q) = %#v, want %#v", test.name, test.data, got, test.want)
+		}
+	}
+}
+
+// TestNegotiatorSplitMSDP feeds a subnegotiation a byte at a time, as a
+// slow connection might, and with an escaped IAC in a value
+func TestNegotiatorSplitMSDP(t *testing.T) {
+	payload := msdp(vr, "ROOM_NAME", vl, "Fire\xff\xffplace", vr, "HEALTH", vl, "42")
+	stream := append([]byte("before"), iac, sb, OptMSDP)
+	stream = append(stream, payload...)
+	stream = append(stream, iac, se)
+	stream = append(stream, "after"...)
+
+	m := NewMSDP()
+	n := &negotiator{msdp: m, reply: func([]byte) error { return nil }}
+	var text []byte
+	for _, b := range stream {
+		text = append(text, n.filter([]byte{b})...)
+	}
+
+	if string(text) != "beforeafter" {
+		t.Errorf("text = %q, want \"beforeafter\"", text)
+	}
+	if name, _ := m.Value("ROOM_NAME"); name != "Fire\xffplace" {
+		t.Errorf("ROOM_NAME = %q, want %q", name, "Fire\xffplace")
+	}
+	if health, _ := m.Value("HEALTH"); health != "42" {
+		t.Errorf("HEALTH = %q, want \"42\"", health)
+	}
+}
+
+func TestEncodeMSDP(t *testing.T) {
+	got := encodeMSDP("report", []string{"HEALTH", "\xff"})
+	want := []byte{iac, sb, OptMSDP, vr}
+	want = append(want, "report"...)
+	want = append(want, vl)
+	want = append(want, "HEALTH"...)
+	want = append(want, vl, iac, iac, iac, se)
+	if !bytes.Equal(got, want) {
+		t.Errorf("encodeMSDP = %q, want %q", got, want)
+	}
+}
+
+func FuzzDecodeMSDP(f *testing.F) {
+	f.Add([]byte{})
+	f.Add(msdp(vr, "HEALTH", vl, "42"))
+	f.Add(msdp(vr, "ROOM", vl, to, vr, "EXITS", vl, to, vr, "N", vl, "1", tc, tc))
+	f.Add(msdp(vr, "GROUP", vl, ao, vl, to, vr, "NAME", vl, "Ann", tc, acl))
+	f.Add(msdp(vl, vl, vl, to, to, ao, ao))
+	f.Add(msdp(tc, acl, tc, acl, vr))
+	f.Add(bytes.Repeat([]byte{vr, 'X', vl, ao}, 1000))
+	f.Add(bytes.Repeat([]byte{vr, 'X', vl, to}, 1000))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		// Anything a server sends must decode without panicking, into
+		// nothing but strings, arrays and tables
+		for name, value := range decodeMSDP(data) {
+			if name == "" {
+				t.Errorf("decodeMSDP(%q) has an unnamed variable", data)
+			}
+			checkMSDPValue(t, data, value)
+		}
+
+		// And must survive the negotiator, however it's split
+		n := &negotiator{msdp: NewMSDP(), reply: func([]byte) error { return nil }}
+		stream := append([]byte{iac, sb, OptMSDP}, data...)
+		n.filter(stream[:len(stream)/2])
+		n.filter(stream[len(stream)/2:])
+		n.filter([]byte{iac, se})
+	})
+}
+
+// checkMSDPValue checks a decoded value is a string, array or table
+func checkMSDPValue(t *testing.T, data []byte, value any) {
+	t.Helper()
+	switch value := value.(type) {
+	case string:
+	case []any:
+		for _, item := range value {
+			checkMSDPValue(t, data, item)
+		}
+	case map[string]any:
+		for _, item := range value {
+			checkMSDPValue(t, data, item)
+		}
+	default:
+		t.Errorf("decodeMSDP(%q) gave a %T", data, value)
+	}
+}
@@ -0,0 +1,81 @@
+package telnet
+
+// negotiator takes telnet sequences out of the server's output, accepting
+// MSDP and passing its subnegotiations on. Other options go unanswered, as
+// they always have. Its state carries over between reads, since a sequence
+// can be split across them.
+type negotiator struct {
+	msdp     *MSDP
+	reply    func([]byte) error
+	afterIAC bool
+	command  byte // Pending WILL, WONT, DO or DONT
+	inSub    bool
+	sub      []byte // Subnegotiation being read
+}
+
+// maxSubnegotiation stops a server that never ends a subnegotiation from
+// growing it forever
+const maxSubnegotiation = 64 << 10
+
+// filter returns the text in data with telnet sequences removed, handling
+// the sequences as it goes
+func (n *negotiator) filter(data []byte) []byte {
+	text := make([]byte, 0, len(data))
+	for _, b := range data {
+		switch {
+		case n.command != 0:
+			n.option(n.command, b)
+			n.command = 0
+		case n.afterIAC:
+			n.afterIAC = false
+			switch b {
+			case will, wont, do, dont:
+				n.command = b
+			case sb:
+				n.inSub, n.sub = true, n.sub[:0]
+			case se:
+				if n.inSub && len(n.sub) > 0 && n.sub[0] == OptMSDP {
+					n.msdp.receive(n.sub[1:])
+				}
+				n.inSub = false
+			case iac:
+				// An escaped 255 is data
+				if n.inSub {
+					n.sub = append(n.sub, iac)
+				} else {
+					text = append(text, iac)
+				}
+			}
+			// GA and the other commands carry nothing to show
+		case b == iac:
+			n.afterIAC = true
+		case n.inSub:
+			if len(n.sub) < maxSubnegotiation {
+				n.sub = append(n.sub, b)
+			}
+		default:
+			text = append(text, b)
+		}
+	}
+	return text
+}
+
+// option answers the server offering or withdrawing an option
+func (n *negotiator) option(command, option byte) {
+	if option != OptMSDP {
+		return
+	}
+	switch command {
+	case will:
+		if n.msdp.Enabled() {
+			return
+		}
+		if err := n.reply([]byte{iac, do, OptMSDP}); err != nil {
+			logger.Warn("failed to accept MSDP", "error", err)
+			return
+		}
+		n.msdp.enable()
+	case wont:
+		n.msdp.disable()
+	}
+}
@@ -0,0 +1,131 @@
+package telnet
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/proxy"
+)
+
+// ProxyConfig connects through a SOCKS5 or HTTP CONNECT proxy, for players
+// who can't reach MUDs directly
+type ProxyConfig struct {
+	URL      string `json:"url"` // socks5://host:1080 or http://host:3128, optionally with user:password@
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"` // Better kept in the vault than in profiles.json
+}
+
+// ProxyEnvVar sets a proxy URL used by every connection whose options
+// don't name one
+const ProxyEnvVar = "SEEMUD_PROXY"
+
+// ProxyFromEnvironment returns the proxy set in ProxyEnvVar, or nil
+func ProxyFromEnvironment() *ProxyConfig {
+	if value := strings.TrimSpace(os.Getenv(ProxyEnvVar)); value != "" {
+		return &ProxyConfig{URL: value}
+	}
+	return nil
+}
+
+// credentials returns the username and password, those set directly
+// taking precedence over any in the URL
+func (p *ProxyConfig) credentials(proxyURL *url.URL) (string, string) {
+	username, password := p.Username, p.Password
+	if proxyURL.User != nil {
+		if username == "" {
+			username = proxyURL.User.Username()
+		}
+		if password == "" {
+			password, _ = proxyURL.User.Password()
+		}
+	}
+	return username, password
+}
+
+// dial connects to address through the proxy
+func (p *ProxyConfig) dial(address string, timeout time.Duration) (net.Conn, error) {
+	proxyURL, err := url.Parse(p.URL)
+	if err != nil || proxyURL.Host == "" {
+		return nil, fmt.Errorf("invalid proxy URL %q", p.URL)
+	}
+	username, password := p.credentials(proxyURL)
+
+	switch proxyURL.Scheme {
+	case "socks5", "socks5h":
+		var auth *proxy.Auth
+		if username != "" {
+			auth = &proxy.Auth{User: username, Password: password}
+		}
+		dialer, err := proxy.SOCKS5("tcp", proxyURL.Host, auth, &net.Dialer{Timeout: timeout})
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up SOCKS5 proxy: %w", err)
+		}
+		conn, err := dialer.Dial("tcp", address)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect through SOCKS5 proxy %s: %w", proxyURL.Host, err)
+		}
+		return conn, nil
+	case "http":
+		return dialHTTPConnect(proxyURL.Host, address, username, password, timeout)
+	}
+	return nil, fmt.Errorf("unsupported proxy type %q: use socks5 or http", proxyURL.Scheme)
+}
+
+// dialHTTPConnect asks an HTTP proxy to open a tunnel to address
+func dialHTTPConnect(proxyAddress, address, username, password string, timeout time.Duration) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", proxyAddress, timeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to proxy %s: %w", proxyAddress, err)
+	}
+	conn.SetDeadline(time.Now().Add(timeout))
+
+	request := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: address},
+		Host:   address,
+		Header: make(http.Header),
+	}
+	if username != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		request.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+	if err := request.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send CONNECT to proxy: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read proxy response: %w", err)
+	}
+	response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy refused connection to %s: %s", address, response.Status)
+	}
+
+	conn.SetDeadline(time.Time{})
+	// The server may have spoken already; keep what the reader buffered
+	return &bufferedConn{Conn: conn, reader: reader}, nil
+}
+
+// bufferedConn reads through a bufio.Reader that may hold data already read
+// from the connection
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+// Read implements net.Conn
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
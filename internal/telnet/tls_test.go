@@ -0,0 +1,75 @@
+package telnet
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"math/big"
+	"testing"
+	"time"
+)
+
+// selfSigned makes a throwaway certificate for name
+func selfSigned(t *testing.T, name string) *x509.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestPinVerification(t *testing.T) {
+	server := selfSigned(t, "mud.example")
+	attacker := selfSigned(t, "attacker.example")
+	pin := base64.StdEncoding.EncodeToString(PinFor(server))
+
+	tests := []struct {
+		name    string
+		pinOnly bool
+		state   tls.ConnectionState
+		ok      bool
+	}{
+		{"pin only, server's own", true, tls.ConnectionState{PeerCertificates: []*x509.Certificate{server}}, true},
+		{"pin only, pinned appended after another", true, tls.ConnectionState{PeerCertificates: []*x509.Certificate{attacker, server}}, false},
+		{"pin only, no certificate", true, tls.ConnectionState{}, false},
+		{"chain, pinned in verified chain", false, tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{attacker, server},
+			VerifiedChains:   [][]*x509.Certificate{{attacker, server}},
+		}, true},
+		{"chain, pinned sent but not verified", false, tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{attacker, server},
+			VerifiedChains:   [][]*x509.Certificate{{attacker}},
+		}, false},
+	}
+	for _, test := range tests {
+		config, err := (&TLSConfig{Pins: []string{pin}, PinOnly: test.pinOnly}).build("mud.example")
+		if err != nil {
+			t.Fatalf("%s: build: %v", test.name, err)
+		}
+		err = config.VerifyConnection(test.state)
+		if test.ok && err != nil {
+			t.Errorf("%s: got %v, want accepted", test.name, err)
+		} else if !test.ok && err == nil {
+			t.Errorf("%s: accepted, want rejected", test.name)
+		}
+	}
+}
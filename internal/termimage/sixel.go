@@ -0,0 +1,111 @@
+package termimage
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/png"
+	"io"
+)
+
+// writeSixel scales, dithers and encodes a PNG as DEC sixels. Sixel
+// terminals don't decode images themselves.
+func writeSixel(w io.Writer, data []byte, opts Options) error {
+	src, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return fmt.Errorf("image is empty")
+	}
+	scale := float64(opts.PixelWidth) / float64(width)
+	if h := float64(opts.PixelHeight) / float64(height); h < scale {
+		scale = h
+	}
+	if scale > 1 {
+		scale = 1
+	}
+	width, height = max(int(float64(width)*scale), 1), max(int(float64(height)*scale), 1)
+
+	// Nearest-neighbour scaling, then dither to a fixed 216-colour palette
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			scaled.Set(x, y, src.At(bounds.Min.X+int(float64(x)/scale), bounds.Min.Y+int(float64(y)/scale)))
+		}
+	}
+	paletted := image.NewPaletted(scaled.Bounds(), palette.WebSafe)
+	draw.FloydSteinberg.Draw(paletted, paletted.Bounds(), scaled, image.Point{})
+
+	out := bufio.NewWriter(w)
+	// Square pixels, opaque background, then the raster size
+	fmt.Fprintf(out, "\x1bP0;1;0q\"1;1;%d;%d", width, height)
+	for i, c := range paletted.Palette {
+		r, g, b, _ := c.RGBA()
+		fmt.Fprintf(out, "#%d;2;%d;%d;%d", i, percent(r), percent(g), percent(b))
+	}
+
+	// Each band is six rows; every colour in the band is drawn in its own
+	// pass, returning to the band's start with $
+	row := make([]byte, width)
+	for top := 0; top < height; top += 6 {
+		used := make(map[uint8]bool)
+		for y := top; y < top+6 && y < height; y++ {
+			for x := 0; x < width; x++ {
+				used[paletted.ColorIndexAt(x, y)] = true
+			}
+		}
+
+		first := true
+		for index := range paletted.Palette {
+			if !used[uint8(index)] {
+				continue
+			}
+			for x := 0; x < width; x++ {
+				var bits byte
+				for bit := 0; bit < 6 && top+bit < height; bit++ {
+					if paletted.ColorIndexAt(x, top+bit) == uint8(index) {
+						bits |= 1 << bit
+					}
+				}
+				row[x] = '?' + bits
+			}
+			if !first {
+				out.WriteByte('$')
+			}
+			first = false
+			fmt.Fprintf(out, "#%d", index)
+			writeRun(out, row)
+		}
+		out.WriteByte('-')
+	}
+	out.WriteString("\x1b\\\n")
+	return out.Flush()
+}
+
+// writeRun writes sixel characters, compressing repeats as !count
+func writeRun(out *bufio.Writer, row []byte) {
+	for i := 0; i < len(row); {
+		j := i
+		for j < len(row) && row[j] == row[i] {
+			j++
+		}
+		if n := j - i; n > 3 {
+			fmt.Fprintf(out, "!%d%c", n, row[i])
+		} else {
+			out.Write(row[i:j])
+		}
+		i = j
+	}
+}
+
+// percent converts a 16-bit colour channel to the 0-100 range sixels use
+func percent(channel uint32) int {
+	return int(channel * 100 / 0xffff)
+}
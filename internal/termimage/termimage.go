@@ -0,0 +1,120 @@
+package termimage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Protocol is a way of drawing images inside a terminal
+type Protocol string
+
+const (
+	ProtocolNone  Protocol = "none"
+	ProtocolKitty Protocol = "kitty"
+	ProtocolSixel Protocol = "sixel"
+)
+
+// EnvVar overrides detection with kitty, sixel or none
+const EnvVar = "SEEMUD_TERM_IMAGES"
+
+// ParseProtocol reads a protocol name; "auto" or empty detects it
+func ParseProtocol(name string) (Protocol, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "auto":
+		return Detect(), nil
+	case "kitty":
+		return ProtocolKitty, nil
+	case "sixel":
+		return ProtocolSixel, nil
+	case "none", "off":
+		return ProtocolNone, nil
+	}
+	return ProtocolNone, fmt.Errorf("unknown image protocol %q (auto, kitty, sixel or none)", name)
+}
+
+// sixelTerminals are TERM values and prefixes of terminals known to draw sixels
+var sixelTerminals = []string{"foot", "mlterm", "yaft", "contour", "xterm-sixel", "mintty"}
+
+// Detect guesses the terminal's image support from the environment. Asking
+// the terminal directly would need raw mode, which the line editor owns.
+func Detect() Protocol {
+	if forced := strings.ToLower(os.Getenv(EnvVar)); forced != "" && forced != "auto" {
+		if p, err := ParseProtocol(forced); err == nil {
+			return p
+		}
+	}
+
+	term := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+	switch {
+	case os.Getenv("KITTY_WINDOW_ID") != "", term == "xterm-kitty", term == "xterm-ghostty", program == "ghostty":
+		return ProtocolKitty
+	case program == "WezTerm", program == "iTerm.app":
+		// Both draw sixels; WezTerm's kitty support is incomplete
+		return ProtocolSixel
+	}
+	for _, known := range sixelTerminals {
+		if strings.HasPrefix(term, known) {
+			return ProtocolSixel
+		}
+	}
+	return ProtocolNone
+}
+
+// Options size an inline image
+type Options struct {
+	Columns     int // Width in terminal cells, for kitty
+	PixelWidth  int // Width sixel images are scaled to
+	PixelHeight int // Maximum sixel height
+}
+
+// DefaultOptions suit a room image beside 80-column text
+var DefaultOptions = Options{Columns: 48, PixelWidth: 384, PixelHeight: 384}
+
+// Write draws a PNG inline using the protocol
+func Write(w io.Writer, p Protocol, png []byte, opts Options) error {
+	switch p {
+	case ProtocolKitty:
+		return writeKitty(w, png, opts)
+	case ProtocolSixel:
+		return writeSixel(w, png, opts)
+	}
+	return fmt.Errorf("terminal can't show images")
+}
+
+// kittyChunk is the most base64 data kitty accepts per escape sequence
+const kittyChunk = 4096
+
+// writeKitty sends a PNG with the kitty graphics protocol, which the
+// terminal decodes and scales itself
+func writeKitty(w io.Writer, png []byte, opts Options) error {
+	data := base64.StdEncoding.EncodeToString(png)
+	first := true
+	for len(data) > 0 {
+		chunk := data
+		if len(chunk) > kittyChunk {
+			chunk = chunk[:kittyChunk]
+		}
+		data = data[len(chunk):]
+
+		more := 0
+		if len(data) > 0 {
+			more = 1
+		}
+		var err error
+		if first {
+			_, err = fmt.Fprintf(w, "\x1b_Ga=T,f=100,q=2,c=%d,m=%d;%s\x1b\\", opts.Columns, more, chunk)
+			first = false
+		} else {
+			_, err = fmt.Fprintf(w, "\x1b_Gm=%d;%s\x1b\\", more, chunk)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
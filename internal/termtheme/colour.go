@@ -0,0 +1,232 @@
+package termtheme
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// Depth is how many colours the terminal can show
+type Depth int
+
+const (
+	DepthNone      Depth = iota // No colour or styling
+	Depth16                     // The standard and bright ANSI colours
+	Depth256                    // The xterm 256-colour palette
+	DepthTrueColor              // 24-bit colour
+)
+
+// EnvVar overrides detection with none, 16, 256 or truecolor
+const EnvVar = "SEEMUD_COLOURS"
+
+func (d Depth) String() string {
+	switch d {
+	case DepthNone:
+		return "none"
+	case Depth16:
+		return "16"
+	case Depth256:
+		return "256"
+	}
+	return "truecolor"
+}
+
+// ParseDepth reads a colour depth; "auto" or empty detects it
+func ParseDepth(name string) (Depth, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "auto":
+		return DetectDepth(), nil
+	case "none", "off", "mono":
+		return DepthNone, nil
+	case "16", "8", "ansi":
+		return Depth16, nil
+	case "256":
+		return Depth256, nil
+	case "truecolor", "truecolour", "24bit":
+		return DepthTrueColor, nil
+	}
+	return DepthNone, fmt.Errorf("unknown colour depth %q (auto, none, 16, 256 or truecolor)", name)
+}
+
+// DetectDepth guesses the terminal's colours from the environment, honouring
+// NO_COLOR (https://no-color.org)
+func DetectDepth() Depth {
+	if forced := strings.ToLower(os.Getenv(EnvVar)); forced != "" && forced != "auto" {
+		if d, err := ParseDepth(forced); err == nil {
+			return d
+		}
+	}
+	if _, set := os.LookupEnv("NO_COLOR"); set {
+		return DepthNone
+	}
+
+	term := os.Getenv("TERM")
+	switch colorterm := strings.ToLower(os.Getenv("COLORTERM")); {
+	case colorterm == "truecolor" || colorterm == "24bit":
+		return DepthTrueColor
+	case term == "dumb":
+		return DepthNone
+	case strings.Contains(term, "direct"), term == "xterm-kitty", term == "xterm-ghostty":
+		return DepthTrueColor
+	case strings.Contains(term, "256color"):
+		return Depth256
+	case os.Getenv("WT_SESSION") != "":
+		// Windows Terminal doesn't set COLORTERM
+		return DepthTrueColor
+	case term == "" && os.Getenv("TERM_PROGRAM") == "":
+		return DepthNone
+	}
+	return Depth16
+}
+
+// colourKind is how a colour was written in the theme
+type colourKind int
+
+const (
+	colourDefault colourKind = iota // Unset: the terminal's own colour
+	colourIndexed                   // 0-255 palette index, including named colours
+	colourRGB
+)
+
+// Colour is a theme colour, which is drawn at whatever depth the terminal
+// has
+type Colour struct {
+	kind    colourKind
+	index   int
+	r, g, b int
+}
+
+// colourNames are the 16 ANSI colours by name
+var colourNames = map[string]int{
+	"black": 0, "red": 1, "green": 2, "yellow": 3, "blue": 4, "magenta": 5, "cyan": 6, "white": 7,
+	"bright-black": 8, "grey": 8, "gray": 8, "bright-red": 9, "bright-green": 10, "bright-yellow": 11,
+	"bright-blue": 12, "bright-magenta": 13, "bright-cyan": 14, "bright-white": 15,
+}
+
+// ParseColour reads "#rrggbb", a palette index from 0 to 255, a colour name
+// such as "cyan" or "bright-red", or "" for the terminal's default
+func ParseColour(value string) (Colour, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" || value == "default" {
+		return Colour{}, nil
+	}
+	if index, named := colourNames[value]; named {
+		return Colour{kind: colourIndexed, index: index}, nil
+	}
+	if strings.HasPrefix(value, "#") {
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil || len(hex) != 6 {
+			return Colour{}, fmt.Errorf("invalid colour %q", value)
+		}
+		return Colour{kind: colourRGB, r: int(rgb >> 16), g: int(rgb >> 8 & 0xff), b: int(rgb & 0xff)}, nil
+	}
+	if index, err := strconv.Atoi(value); err == nil && index >= 0 && index <= 255 {
+		return Colour{kind: colourIndexed, index: index}, nil
+	}
+	return Colour{}, fmt.Errorf("invalid colour %q (use #rrggbb, 0-255 or a name like cyan)", value)
+}
+
+// sgr returns the SGR parameters drawing the colour at a depth, as a
+// foreground or background, or "" for the default colour
+func (c Colour) sgr(depth Depth, background bool) string {
+	if c.kind == colourDefault || depth == DepthNone {
+		return ""
+	}
+
+	base := 38
+	if background {
+		base = 48
+	}
+	if depth == DepthTrueColor {
+		r, g, b := c.rgb()
+		return fmt.Sprintf("%d;2;%d;%d;%d", base, r, g, b)
+	}
+
+	index := c.index
+	if c.kind == colourRGB {
+		index = nearest256(c.r, c.g, c.b)
+	}
+	if depth == Depth256 {
+		return fmt.Sprintf("%d;5;%d", base, index)
+	}
+
+	if index >= 16 {
+		r, g, b := paletteRGB(index)
+		index = nearest16(r, g, b)
+	}
+	code := 30 + index
+	if index >= 8 {
+		code = 90 + index - 8
+	}
+	if background {
+		code += 10
+	}
+	return strconv.Itoa(code)
+}
+
+// rgb returns the colour's red, green and blue
+func (c Colour) rgb() (int, int, int) {
+	if c.kind == colourRGB {
+		return c.r, c.g, c.b
+	}
+	return paletteRGB(c.index)
+}
+
+// ansi16 are xterm's values for the 16 standard colours
+var ansi16 = [16][3]int{
+	{0, 0, 0}, {205, 0, 0}, {0, 205, 0}, {205, 205, 0}, {0, 0, 238}, {205, 0, 205}, {0, 205, 205}, {229, 229, 229},
+	{127, 127, 127}, {255, 0, 0}, {0, 255, 0}, {255, 255, 0}, {92, 92, 255}, {255, 0, 255}, {0, 255, 255}, {255, 255, 255},
+}
+
+// cubeLevels are the channel values of the 6x6x6 colour cube (16-231)
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+// paletteRGB returns the colour of a 256-colour palette index
+func paletteRGB(index int) (int, int, int) {
+	switch {
+	case index < 16:
+		c := ansi16[index]
+		return c[0], c[1], c[2]
+	case index < 232:
+		index -= 16
+		return cubeLevels[index/36], cubeLevels[index/6%6], cubeLevels[index%6]
+	}
+	grey := 8 + (index-232)*10
+	return grey, grey, grey
+}
+
+// nearest256 finds the closest colour cube or greyscale entry, skipping the
+// 16 standard colours since terminal themes often redefine them
+func nearest256(r, g, b int) int {
+	best, bestDistance := 16, -1
+	for index := 16; index < 256; index++ {
+		pr, pg, pb := paletteRGB(index)
+		if d := distance(r, g, b, pr, pg, pb); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = index, d
+		}
+	}
+	return best
+}
+
+// nearest16 finds the closest standard colour
+func nearest16(r, g, b int) int {
+	best, bestDistance := 0, -1
+	for index, c := range ansi16 {
+		if d := distance(r, g, b, c[0], c[1], c[2]); bestDistance < 0 || d < bestDistance {
+			best, bestDistance = index, d
+		}
+	}
+	return best
+}
+
+// distance is a cheap perceptual distance, weighting green most as the eye
+// does
+func distance(r1, g1, b1, r2, g2, b2 int) int {
+	dr, dg, db := r1-r2, g1-g2, b1-b2
+	return 3*dr*dr + 4*dg*dg + 2*db*db
+}
@@ -0,0 +1,149 @@
+package termtheme
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"seemud-gui/internal/output"
+)
+
+// FileName is the theme file inside the data directory
+const FileName = "theme.json"
+
+// Style is how one output class is drawn. Colours are "#rrggbb", a palette
+// index from 0 to 255 or a name such as "cyan"; true colours are matched to
+// the nearest palette entry on terminals without them.
+type Style struct {
+	FG        string `json:"fg,omitempty"`
+	BG        string `json:"bg,omitempty"`
+	Bold      bool   `json:"bold,omitempty"`
+	Faint     bool   `json:"faint,omitempty"`
+	Italic    bool   `json:"italic,omitempty"`
+	Underline bool   `json:"underline,omitempty"`
+}
+
+// Theme maps the engine's semantic output classes (output.Class*) to styles
+type Theme map[string]Style
+
+// Default is the built-in theme, matching the TUI's colours
+func Default() Theme {
+	return Theme{
+		output.ClassText:            {FG: "#d0d0d0"},
+		output.ClassRoomTitle:       {FG: "#5fd7ff", Bold: true},
+		output.ClassRoomDescription: {FG: "#87d787"},
+		output.ClassExit:            {FG: "#ffaf00", Bold: true},
+		output.ClassInventory:       {FG: "#d787d7"},
+		output.ClassMobs:            {FG: "#afd787"},
+		output.ClassPrompt:          {FG: "#87ff87", Bold: true},
+		output.ClassSystem:          {FG: "#5fafff", Italic: true},
+		output.ClassLogin:           {FG: "#af87ff"},
+		output.ClassChatSay:         {FG: "#87d7d7"},
+		output.ClassChatTell:        {FG: "#ffafd7"},
+		output.ClassChatChannel:     {FG: "#d7afff"},
+		output.ClassCombatAttack:    {FG: "#ffaf87"},
+		output.ClassCombatDamage:    {FG: "#ff5f5f", Bold: true},
+	}
+}
+
+// Load reads a theme file over the default theme, so it only needs the
+// classes it changes. A missing file is the default theme.
+func Load(path string) (Theme, error) {
+	theme := Default()
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return theme, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read theme: %w", err)
+	}
+
+	var styles map[string]Style
+	if err := json.Unmarshal(data, &styles); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal theme: %w", err)
+	}
+	for class, style := range styles {
+		if _, err := style.sequence(DepthTrueColor); err != nil {
+			return nil, fmt.Errorf("invalid style for %s: %w", class, err)
+		}
+		theme[strings.ToLower(class)] = style
+	}
+	return theme, nil
+}
+
+// sequence returns the SGR escape that starts the style at a depth, or ""
+// if it draws nothing
+func (s Style) sequence(depth Depth) (string, error) {
+	if depth == DepthNone {
+		return "", nil
+	}
+
+	var params []string
+	if s.Bold {
+		params = append(params, "1")
+	}
+	if s.Faint {
+		params = append(params, "2")
+	}
+	if s.Italic {
+		params = append(params, "3")
+	}
+	if s.Underline {
+		params = append(params, "4")
+	}
+	for _, c := range []struct {
+		value      string
+		background bool
+	}{{s.FG, false}, {s.BG, true}} {
+		colour, err := ParseColour(c.value)
+		if err != nil {
+			return "", err
+		}
+		if param := colour.sgr(depth, c.background); param != "" {
+			params = append(params, param)
+		}
+	}
+
+	if len(params) == 0 {
+		return "", nil
+	}
+	return "\x1b[" + strings.Join(params, ";") + "m", nil
+}
+
+// Painter draws text in a theme's styles at the terminal's colour depth
+type Painter struct {
+	depth     Depth
+	sequences map[string]string // Keyed by class
+}
+
+// NewPainter prepares a theme for a terminal. Styles with invalid colours,
+// which Load would have rejected, are drawn plain.
+func NewPainter(theme Theme, depth Depth) *Painter {
+	p := &Painter{depth: depth, sequences: make(map[string]string, len(theme))}
+	for class, style := range theme {
+		if sequence, err := style.sequence(depth); err == nil {
+			p.sequences[class] = sequence
+		}
+	}
+	return p
+}
+
+// Depth returns the colour depth the painter draws at
+func (p *Painter) Depth() Depth {
+	return p.depth
+}
+
+// Paint styles text as an output class, falling back to the text style for
+// classes the theme doesn't have
+func (p *Painter) Paint(class, text string) string {
+	sequence, ok := p.sequences[class]
+	if !ok {
+		sequence = p.sequences[output.ClassText]
+	}
+	if sequence == "" {
+		return text
+	}
+	return sequence + text + "\x1b[0m"
+}
@@ -0,0 +1,316 @@
+package tick
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/safefile"
+)
+
+// Bounds on a learned tick length; gaps outside them are missed ticks or
+// messages that weren't ticks
+const (
+	MinLearned = 5 * time.Second
+	MaxLearned = 10 * time.Minute
+)
+
+// Settings say how long a server's tick is and what marks one
+type Settings struct {
+	Seconds int    `json:"seconds"`           // Between ticks; 0 learns it from the gap between two seen
+	Pattern string `json:"pattern,omitempty"` // Regex matched against clean output, e.g. "You feel refreshed"
+}
+
+// compile checks settings and prepares the pattern
+func (s Settings) compile() (*regexp.Regexp, error) {
+	if s.Seconds < 0 {
+		return nil, fmt.Errorf("tick length can't be negative")
+	}
+	if s.Pattern == "" {
+		return nil, nil
+	}
+	regex, err := regexp.Compile(s.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tick pattern: %w", err)
+	}
+	return regex, nil
+}
+
+// Status is where the tick is, for countdowns
+type Status struct {
+	Seconds   int       `json:"seconds"`           // Between ticks, set or learned; 0 until known
+	Last      time.Time `json:"last"`              // When the last tick was seen or expected
+	Next      time.Time `json:"next"`              // When the next is expected; zero until known
+	Remaining int       `json:"remaining"`         // Seconds until Next
+	Synced    bool      `json:"synced"`            // Last was seen in the output or set by the player, not predicted
+	Count     int       `json:"count"`             // Ticks since connecting
+	Pattern   string    `json:"pattern,omitempty"` // What marks a tick on this server
+	Learned   bool      `json:"learned,omitempty"` // Seconds was measured rather than set
+}
+
+// Tracker follows a server's tick, synchronising from the output or the
+// player and predicting the ticks in between. Settings are kept per server
+// since every MUD's heartbeat differs.
+type Tracker struct {
+	mutex    sync.Mutex
+	dir      string
+	server   string
+	settings Settings
+	pattern  *regexp.Regexp
+	learned  time.Duration
+	last     time.Time
+	synced   bool
+	count    int
+	timer    *time.Timer
+	onTick   []func(Status)
+	onChange []func(Status)
+}
+
+// NewTracker creates a tracker storing settings under dir. An empty dir
+// keeps them in memory only.
+func NewTracker(dir string) *Tracker {
+	return &Tracker{dir: dir}
+}
+
+// OnTick registers a listener for each tick, seen or predicted
+func (t *Tracker) OnTick(fn func(Status)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.onTick = append(t.onTick, fn)
+}
+
+// OnChange registers a listener for any change to the status, including
+// ticks, corrections and new settings
+func (t *Tracker) OnChange(fn func(Status)) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.onChange = append(t.onChange, fn)
+}
+
+// Load switches to a server's settings and forgets the last tick
+func (t *Tracker) Load(server string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.server = server
+	t.settings, t.pattern = Settings{}, nil
+	t.reset()
+	if t.dir == "" {
+		return nil
+	}
+
+	data, err := safefile.ReadFile(t.path(), json.Valid)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read tick settings: %w", err)
+	}
+	var settings Settings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to unmarshal tick settings: %w", err)
+	}
+	pattern, err := settings.compile()
+	if err != nil {
+		return err
+	}
+	t.settings, t.pattern = settings, pattern
+	return nil
+}
+
+// path is the current server's settings file; the caller must hold the lock
+func (t *Tracker) path() string {
+	name := t.server
+	if name == "" {
+		name = "default"
+	}
+	return filepath.Join(t.dir, datadir.Namespace(name)+".json")
+}
+
+// Settings returns the current server's settings
+func (t *Tracker) Settings() Settings {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.settings
+}
+
+// SetSettings changes the current server's settings and saves them
+func (t *Tracker) SetSettings(settings Settings) error {
+	pattern, err := settings.compile()
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	t.settings, t.pattern = settings, pattern
+	t.schedule()
+	err = t.save()
+	status, listeners := t.status(), t.onChange
+	t.mutex.Unlock()
+
+	notify(listeners, status)
+	return err
+}
+
+// save writes the settings to disk; the caller must hold the lock
+func (t *Tracker) save() error {
+	if t.dir == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t.settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal tick settings: %w", err)
+	}
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create tick directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(t.path(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write tick settings: %w", err)
+	}
+	return nil
+}
+
+// ProcessLine synchronises the tick if a line of clean output marks one,
+// returning true if it did
+func (t *Tracker) ProcessLine(line string) bool {
+	t.mutex.Lock()
+	matched := t.pattern != nil && t.pattern.MatchString(line)
+	t.mutex.Unlock()
+
+	if matched {
+		t.Sync()
+	}
+	return matched
+}
+
+// Sync records a tick happening now, as seen in the output or told by the
+// player. A tick seen soon after one was predicted corrects the prediction
+// rather than counting again.
+func (t *Tracker) Sync() {
+	t.mutex.Lock()
+	now := time.Now()
+	interval := t.interval()
+	counted := true
+	if !t.synced && !t.last.IsZero() && interval > 0 && now.Sub(t.last) < interval/4 {
+		counted = false
+	} else if t.synced && t.settings.Seconds == 0 {
+		if gap := now.Sub(t.last); gap >= MinLearned && gap <= MaxLearned {
+			t.learned = gap.Round(time.Second)
+		}
+	}
+
+	t.last, t.synced = now, true
+	if counted {
+		t.count++
+	}
+	t.schedule()
+	status, onTick, onChange := t.status(), t.onTick, t.onChange
+	t.mutex.Unlock()
+
+	if counted {
+		notify(onTick, status)
+	}
+	notify(onChange, status)
+}
+
+// predict counts a tick that's due but wasn't seen. A timer that fired as
+// a sync replaced it finds the tick isn't due and does nothing.
+func (t *Tracker) predict() {
+	t.mutex.Lock()
+	interval := t.interval()
+	if interval == 0 || t.last.IsZero() || time.Now().Before(t.last.Add(interval)) {
+		t.mutex.Unlock()
+		return
+	}
+	t.last, t.synced = t.last.Add(interval), false
+	t.count++
+	t.schedule()
+	status, onTick, onChange := t.status(), t.onTick, t.onChange
+	t.mutex.Unlock()
+
+	notify(onTick, status)
+	notify(onChange, status)
+}
+
+// Stop forgets the last tick and stops predicting, e.g. on disconnect
+func (t *Tracker) Stop() {
+	t.mutex.Lock()
+	t.reset()
+	status, listeners := t.status(), t.onChange
+	t.mutex.Unlock()
+
+	notify(listeners, status)
+}
+
+// reset forgets the last tick; the caller must hold the lock
+func (t *Tracker) reset() {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	t.learned = 0
+	t.last, t.synced, t.count = time.Time{}, false, 0
+}
+
+// schedule sets the timer for the next predicted tick; the caller must hold
+// the lock
+func (t *Tracker) schedule() {
+	if t.timer != nil {
+		t.timer.Stop()
+		t.timer = nil
+	}
+	interval := t.interval()
+	if interval == 0 || t.last.IsZero() {
+		return
+	}
+	t.timer = time.AfterFunc(time.Until(t.last.Add(interval)), t.predict)
+}
+
+// interval is the tick length, set or learned; the caller must hold the
+// lock
+func (t *Tracker) interval() time.Duration {
+	if t.settings.Seconds > 0 {
+		return time.Duration(t.settings.Seconds) * time.Second
+	}
+	return t.learned
+}
+
+// Status returns where the tick is
+func (t *Tracker) Status() Status {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.status()
+}
+
+// status builds the status; the caller must hold the lock
+func (t *Tracker) status() Status {
+	interval := t.interval()
+	status := Status{
+		Seconds: int(interval / time.Second),
+		Last:    t.last,
+		Synced:  t.synced,
+		Count:   t.count,
+		Pattern: t.settings.Pattern,
+		Learned: t.settings.Seconds == 0 && t.learned > 0,
+	}
+	if interval > 0 && !t.last.IsZero() {
+		status.Next = t.last.Add(interval)
+		if remaining := time.Until(status.Next); remaining > 0 {
+			status.Remaining = int(remaining.Round(time.Second) / time.Second)
+		}
+	}
+	return status
+}
+
+// notify calls listeners with a status
+func notify(listeners []func(Status), status Status) {
+	for _, fn := range listeners {
+		fn(status)
+	}
+}
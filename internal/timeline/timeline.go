@@ -0,0 +1,125 @@
+package timeline
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// DefaultCapacity is how many visits the timeline keeps; at a room every few
+// seconds that's a long session
+const DefaultCapacity = 10000
+
+// Visit is one entry into a room
+type Visit struct {
+	Seq       int64     `json:"seq"`
+	Time      time.Time `json:"time"`
+	RoomID    string    `json:"room_id"` // Links to the room in the map
+	Name      string    `json:"name"`
+	X         int       `json:"x"`
+	Y         int       `json:"y"`
+	Z         int       `json:"z"`
+	Direction string    `json:"direction,omitempty"` // Exit taken from the previous room, if known
+	New       bool      `json:"new"`                 // First time the room was mapped
+}
+
+// Timeline is a timestamped trail of the rooms entered, oldest first.
+// Once full, the oldest visits are dropped.
+type Timeline struct {
+	mutex    sync.RWMutex
+	visits   []Visit
+	capacity int
+	lastSeq  int64
+}
+
+// New creates a timeline holding at most capacity visits
+func New(capacity int) *Timeline {
+	if capacity <= 0 {
+		capacity = DefaultCapacity
+	}
+	return &Timeline{capacity: capacity}
+}
+
+// Record adds a visit, filling in its sequence number and time if unset,
+// and returns it
+func (t *Timeline) Record(visit Visit) Visit {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.lastSeq++
+	visit.Seq = t.lastSeq
+	if visit.Time.IsZero() {
+		visit.Time = time.Now()
+	}
+
+	t.visits = append(t.visits, visit)
+	if len(t.visits) > t.capacity {
+		// Copy down rather than reslice so the backing array doesn't grow forever
+		t.visits = append(t.visits[:0], t.visits[len(t.visits)-t.capacity:]...)
+	}
+	return visit
+}
+
+// Last returns the most recent visit
+func (t *Timeline) Last() (Visit, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	if len(t.visits) == 0 {
+		return Visit{}, false
+	}
+	return t.visits[len(t.visits)-1], true
+}
+
+// Range returns the visits between from and to inclusive. A zero from or to
+// leaves that end open.
+func (t *Timeline) Range(from, to time.Time) []Visit {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	start := 0
+	if !from.IsZero() {
+		start = sort.Search(len(t.visits), func(i int) bool {
+			return !t.visits[i].Time.Before(from)
+		})
+	}
+	end := len(t.visits)
+	if !to.IsZero() {
+		end = sort.Search(len(t.visits), func(i int) bool {
+			return t.visits[i].Time.After(to)
+		})
+	}
+	if start >= end {
+		return []Visit{}
+	}
+	return append([]Visit{}, t.visits[start:end]...)
+}
+
+// At returns where the player was at a moment: the last visit at or
+// before it
+func (t *Timeline) At(moment time.Time) (Visit, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	i := sort.Search(len(t.visits), func(i int) bool {
+		return t.visits[i].Time.After(moment)
+	})
+	if i == 0 {
+		return Visit{}, false
+	}
+	return t.visits[i-1], true
+}
+
+// Len returns the number of visits kept
+func (t *Timeline) Len() int {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return len(t.visits)
+}
+
+// Clear forgets every visit
+func (t *Timeline) Clear() {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.visits = nil
+}
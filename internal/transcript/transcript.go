@@ -0,0 +1,152 @@
+package transcript
+
+import (
+	"fmt"
+	"html"
+	"strings"
+
+	"seemud-gui/internal/output"
+)
+
+// Format is a transcript output format
+type Format string
+
+const (
+	FormatText     Format = "text"
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+)
+
+// Extension returns the usual file extension for a format
+func (f Format) Extension() string {
+	switch f {
+	case FormatHTML:
+		return ".html"
+	case FormatMarkdown:
+		return ".md"
+	}
+	return ".txt"
+}
+
+// ParseFormat validates a format name, defaulting to plain text
+func ParseFormat(name string) (Format, error) {
+	switch Format(strings.ToLower(strings.TrimSpace(name))) {
+	case "", FormatText, "txt", "plain":
+		return FormatText, nil
+	case FormatHTML, "htm":
+		return FormatHTML, nil
+	case FormatMarkdown, "md":
+		return FormatMarkdown, nil
+	}
+	return "", fmt.Errorf("unknown transcript format %q", name)
+}
+
+// Render renders scrollback entries in the given format
+func Render(entries []output.Entry, format Format) string {
+	switch format {
+	case FormatHTML:
+		return renderHTML(entries)
+	case FormatMarkdown:
+		return renderMarkdown(entries)
+	}
+	return renderText(entries)
+}
+
+func renderText(entries []output.Entry) string {
+	var b strings.Builder
+	for _, entry := range entries {
+		b.WriteString(entry.Event.Text)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// renderHTML produces a standalone page reproducing the server's colours
+func renderHTML(entries []output.Entry) string {
+	var b strings.Builder
+
+	b.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	b.WriteString("<title>SeeMUD transcript</title>\n<style>\n")
+	b.WriteString("body { background: #1a1a2e; color: #e5e5e5; font-family: 'Courier New', monospace; }\n")
+	b.WriteString("pre { white-space: pre-wrap; margin: 0; }\n")
+	b.WriteString(".room_title { color: #e94560; font-weight: bold; }\n")
+	b.WriteString(".exits { color: #cdcd00; }\n")
+	b.WriteString(".tell, .say, .channel { color: #00cdcd; }\n")
+	b.WriteString(".highlight { background: rgba(255, 193, 7, 0.15); }\n")
+	b.WriteString("</style>\n</head>\n<body>\n<pre>\n")
+
+	for _, entry := range entries {
+		class := entry.Event.Type
+		if entry.Event.Highlight != "" {
+			class += " highlight"
+		}
+		fmt.Fprintf(&b, "<span class=\"%s\">", class)
+		for _, span := range entry.Event.Spans {
+			var styles []string
+			if span.FG != "" {
+				styles = append(styles, "color: "+span.FG)
+			}
+			if span.BG != "" {
+				styles = append(styles, "background-color: "+span.BG)
+			}
+			if span.Bold {
+				styles = append(styles, "font-weight: bold")
+			}
+			if span.Italic {
+				styles = append(styles, "font-style: italic")
+			}
+			if span.Underline {
+				styles = append(styles, "text-decoration: underline")
+			}
+
+			if len(styles) == 0 {
+				b.WriteString(html.EscapeString(span.Text))
+			} else {
+				fmt.Fprintf(&b, "<span style=\"%s\">%s</span>", strings.Join(styles, "; "), html.EscapeString(span.Text))
+			}
+		}
+		b.WriteString("</span>\n")
+	}
+
+	b.WriteString("</pre>\n</body>\n</html>\n")
+	return b.String()
+}
+
+// renderMarkdown produces Markdown with rooms as headings and chat as quotes
+func renderMarkdown(entries []output.Entry) string {
+	var b strings.Builder
+
+	for _, entry := range entries {
+		event := entry.Event
+		text := strings.TrimSpace(event.Text)
+		if text == "" {
+			b.WriteString("\n")
+			continue
+		}
+
+		switch event.Type {
+		case "room_title":
+			fmt.Fprintf(&b, "\n### %s\n\n", escapeMarkdown(event.RoomName))
+		case "exits":
+			fmt.Fprintf(&b, "*Exits: %s*\n\n", escapeMarkdown(strings.Join(event.Exits, ", ")))
+		case "say", "tell", "channel":
+			fmt.Fprintf(&b, "> %s\n\n", escapeMarkdown(text))
+		case "prompt":
+			// Prompts are noise in a shared transcript
+		default:
+			// Trailing double space forces a line break without a new paragraph
+			fmt.Fprintf(&b, "%s  \n", escapeMarkdown(text))
+		}
+	}
+
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "#", `\#`,
+	"[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;", "|", `\|`,
+)
+
+func escapeMarkdown(text string) string {
+	return markdownEscaper.Replace(text)
+}
@@ -0,0 +1,185 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"seemud-gui/internal/safefile"
+)
+
+// Alias replaces a typed command matching its pattern with other commands.
+// Commands are separated by semicolons and may refer to regex groups as
+// $1..$9, like trigger commands.
+type Alias struct {
+	Name     string `json:"name"`
+	Pattern  string `json:"pattern"` // Regex matched against the whole typed command
+	Commands string `json:"commands"`
+	Group    string `json:"group,omitempty"`
+	Enabled  bool   `json:"enabled"`
+
+	regex *regexp.Regexp
+}
+
+// compile checks an alias and prepares its regex
+func (a *Alias) compile() error {
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Name == "" {
+		return fmt.Errorf("alias needs a name")
+	}
+	if a.Pattern == "" {
+		return fmt.Errorf("alias needs a pattern")
+	}
+
+	regex, err := regexp.Compile(a.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	a.regex = regex
+	return nil
+}
+
+// SimpleAlias makes an alias the way most MUD clients take one, without a
+// regex: a word such as "gc", or a word and arguments such as "k %1", and
+// commands using %1.. for single arguments and %0 for all of them. If the
+// commands use no arguments, any typed are added to the end, so "gc" for
+// "get coin from" makes "gc corpse" send "get coin from corpse".
+func SimpleAlias(name, commands string) (Alias, error) {
+	name, commands = strings.TrimSpace(name), strings.TrimSpace(commands)
+	if name == "" || commands == "" {
+		return Alias{}, fmt.Errorf("alias needs a name and commands")
+	}
+
+	result := &ImportResult{}
+	importTinTinAlias(result, tintinCommand{Args: []string{name, commands}}, "")
+	if len(result.Aliases) == 0 {
+		return Alias{}, fmt.Errorf("invalid alias: %s", result.Skipped[0].Reason)
+	}
+	return result.Aliases[0], nil
+}
+
+// Aliases holds the aliases and expands typed commands
+type Aliases struct {
+	mutex   sync.RWMutex
+	path    string
+	aliases map[string]*Alias // Keyed by lowercased name
+}
+
+// NewAliases creates an alias set persisting to path (empty for none) and
+// loads any saved aliases
+func NewAliases(path string) (*Aliases, error) {
+	s := &Aliases{path: path, aliases: make(map[string]*Alias)}
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := safefile.ReadFile(path, json.Valid)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return s, fmt.Errorf("failed to read aliases: %w", err)
+	}
+
+	var aliases []Alias
+	if err := json.Unmarshal(data, &aliases); err != nil {
+		return s, fmt.Errorf("failed to unmarshal aliases: %w", err)
+	}
+	for _, a := range aliases {
+		if err := a.compile(); err != nil {
+			logger.Warn("skipping alias", "name", a.Name, "error", err)
+			continue
+		}
+		alias := a
+		s.aliases[strings.ToLower(a.Name)] = &alias
+	}
+	return s, nil
+}
+
+// List returns all aliases sorted by group then name
+func (s *Aliases) List() []Alias {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	aliases := make([]Alias, 0, len(s.aliases))
+	for _, a := range s.aliases {
+		aliases = append(aliases, *a)
+	}
+	sort.Slice(aliases, func(i, j int) bool {
+		if aliases[i].Group != aliases[j].Group {
+			return aliases[i].Group < aliases[j].Group
+		}
+		return strings.ToLower(aliases[i].Name) < strings.ToLower(aliases[j].Name)
+	})
+	return aliases
+}
+
+// Set adds or replaces an alias
+func (s *Aliases) Set(a Alias) error {
+	if err := a.compile(); err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	s.aliases[strings.ToLower(a.Name)] = &a
+	s.mutex.Unlock()
+
+	return s.Save()
+}
+
+// Delete removes an alias
+func (s *Aliases) Delete(name string) error {
+	s.mutex.Lock()
+	delete(s.aliases, strings.ToLower(strings.TrimSpace(name)))
+	s.mutex.Unlock()
+
+	return s.Save()
+}
+
+// Save writes the aliases to disk
+func (s *Aliases) Save() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.List(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal aliases: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return fmt.Errorf("failed to create alias directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(s.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write aliases: %w", err)
+	}
+	return nil
+}
+
+// Expand returns the commands for the first enabled alias (by name) that
+// matches command. Expanded commands are not expanded again, so an alias
+// can safely send a command of the same name.
+func (s *Aliases) Expand(command string) (string, []string, bool) {
+	for _, a := range s.List() {
+		if !a.Enabled {
+			continue
+		}
+		match := a.regex.FindStringSubmatch(command)
+		if match == nil {
+			continue
+		}
+
+		var list []string
+		for _, c := range strings.Split(expandGroups(a.Commands, match), ";") {
+			if c = strings.TrimSpace(c); c != "" {
+				list = append(list, c)
+			}
+		}
+		return a.Name, list, true
+	}
+	return "", nil, false
+}
@@ -0,0 +1,139 @@
+package trigger
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Skipped is something an importer couldn't convert, and why
+type Skipped struct {
+	Kind   string `json:"kind"` // trigger, alias, timer, script...
+	Name   string `json:"name"`
+	Reason string `json:"reason"`
+}
+
+// ImportResult is what an importer converted from another client's files
+type ImportResult struct {
+	Source   string    `json:"source"` // mudlet or tintin
+	Triggers []Trigger `json:"triggers"`
+	Aliases  []Alias   `json:"aliases"`
+	Skipped  []Skipped `json:"skipped"`
+}
+
+// skip records something that couldn't be converted
+func (r *ImportResult) skip(kind, name, reason string) {
+	r.Skipped = append(r.Skipped, Skipped{Kind: kind, Name: name, Reason: reason})
+}
+
+// addTrigger checks a converted trigger compiles before keeping it
+func (r *ImportResult) addTrigger(t Trigger) {
+	if err := t.compile(); err != nil {
+		r.skip("trigger", t.Name, err.Error())
+		return
+	}
+	r.Triggers = append(r.Triggers, t)
+}
+
+// addAlias checks a converted alias compiles before keeping it
+func (r *ImportResult) addAlias(a Alias) {
+	if err := a.compile(); err != nil {
+		r.skip("alias", a.Name, err.Error())
+		return
+	}
+	r.Aliases = append(r.Aliases, a)
+}
+
+// ImportFile converts a Mudlet package (.xml or .mpackage) or a TinTin++
+// script, chosen by extension
+func ImportFile(path string) (*ImportResult, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".xml":
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read package: %w", err)
+		}
+		return ImportMudlet(data)
+	case ".mpackage", ".zip":
+		data, err := readMudletArchive(path)
+		if err != nil {
+			return nil, err
+		}
+		return ImportMudlet(data)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+	return ImportTinTin(string(data)), nil
+}
+
+// readMudletArchive returns the package XML from a zipped .mpackage
+func readMudletArchive(path string) ([]byte, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open package: %w", err)
+	}
+	defer archive.Close()
+
+	for _, f := range archive.File {
+		// config.lua only holds metadata; the package itself is the XML
+		if strings.ToLower(filepath.Ext(f.Name)) != ".xml" {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", f.Name, err)
+		}
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("no package XML in %s", filepath.Base(path))
+}
+
+// Apply adds the converted triggers and aliases, renaming any that clash
+// with existing names. Anything that fails to save is added to Skipped.
+func (r *ImportResult) Apply(triggers *Engine, aliases *Aliases) {
+	taken := make(map[string]bool)
+	for _, t := range triggers.List() {
+		taken[strings.ToLower(t.Name)] = true
+	}
+	for i, t := range r.Triggers {
+		t.Name = uniqueName(t.Name, taken)
+		r.Triggers[i] = t
+		if err := triggers.Set(t); err != nil {
+			r.skip("trigger", t.Name, err.Error())
+		}
+	}
+
+	taken = make(map[string]bool)
+	for _, a := range aliases.List() {
+		taken[strings.ToLower(a.Name)] = true
+	}
+	for i, a := range r.Aliases {
+		a.Name = uniqueName(a.Name, taken)
+		r.Aliases[i] = a
+		if err := aliases.Set(a); err != nil {
+			r.skip("alias", a.Name, err.Error())
+		}
+	}
+}
+
+// uniqueName numbers a name until it doesn't clash, and marks it taken
+func uniqueName(name string, taken map[string]bool) string {
+	unique := name
+	for n := 2; taken[strings.ToLower(unique)]; n++ {
+		unique = name + " " + strconv.Itoa(n)
+	}
+	taken[strings.ToLower(unique)] = true
+	return unique
+}
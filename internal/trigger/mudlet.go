@@ -0,0 +1,340 @@
+package trigger
+
+import (
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// mudletPackage is the part of a Mudlet package XML the importer reads
+type mudletPackage struct {
+	Triggers mudletTrigger `xml:"TriggerPackage"`
+	Aliases  mudletAlias   `xml:"AliasPackage"`
+	Timers   mudletFolder  `xml:"TimerPackage"`
+	Scripts  mudletFolder  `xml:"ScriptPackage"`
+	Keys     mudletFolder  `xml:"KeyPackage"`
+	Actions  mudletFolder  `xml:"ActionPackage"`
+}
+
+// mudletTrigger is a Trigger or TriggerGroup; groups may also match
+type mudletTrigger struct {
+	Active    string          `xml:"isActive,attr"`
+	Folder    string          `xml:"isFolder,attr"`
+	Multiline string          `xml:"isMultiline,attr"`
+	Name      string          `xml:"name"`
+	Script    string          `xml:"script"`
+	Command   string          `xml:"mCommand"`
+	Patterns  []string        `xml:"regexCodeList>string"`
+	Types     []int           `xml:"regexCodePropertyList>integer"`
+	Children  []mudletTrigger `xml:"Trigger"`
+	Groups    []mudletTrigger `xml:"TriggerGroup"`
+}
+
+// mudletAlias is an Alias or AliasGroup
+type mudletAlias struct {
+	Active   string        `xml:"isActive,attr"`
+	Folder   string        `xml:"isFolder,attr"`
+	Name     string        `xml:"name"`
+	Script   string        `xml:"script"`
+	Command  string        `xml:"command"`
+	Regex    string        `xml:"regex"`
+	Children []mudletAlias `xml:"Alias"`
+	Groups   []mudletAlias `xml:"AliasGroup"`
+}
+
+// mudletFolder counts items of a kind the importer doesn't convert
+type mudletFolder struct {
+	Items []struct {
+		Name string `xml:"name"`
+	} `xml:",any"`
+}
+
+// Mudlet pattern types, from regexCodePropertyList
+const (
+	mudletSubstring   = 0
+	mudletRegex       = 1
+	mudletStartOfLine = 2
+	mudletExact       = 3
+)
+
+// ImportMudlet converts the triggers and aliases in a Mudlet package.
+// Only triggers and aliases whose scripts just send commands map cleanly;
+// anything else is reported as skipped.
+func ImportMudlet(data []byte) (*ImportResult, error) {
+	var pkg mudletPackage
+	if err := xml.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse Mudlet package: %w", err)
+	}
+
+	result := &ImportResult{Source: "mudlet"}
+	for _, t := range pkg.Triggers.Children {
+		importMudletTrigger(result, t, "")
+	}
+	for _, g := range pkg.Triggers.Groups {
+		importMudletTrigger(result, g, "")
+	}
+	for _, a := range pkg.Aliases.Children {
+		importMudletAlias(result, a, "")
+	}
+	for _, g := range pkg.Aliases.Groups {
+		importMudletAlias(result, g, "")
+	}
+
+	for kind, folder := range map[string]mudletFolder{
+		"timer": pkg.Timers, "script": pkg.Scripts, "key": pkg.Keys, "button": pkg.Actions,
+	} {
+		for _, item := range folder.Items {
+			result.skip(kind, item.Name, "Mudlet "+kind+"s have no equivalent")
+		}
+	}
+	return result, nil
+}
+
+// importMudletTrigger converts a trigger, or a folder and its contents
+func importMudletTrigger(result *ImportResult, t mudletTrigger, group string) {
+	if t.Folder == "yes" || len(t.Children) > 0 || len(t.Groups) > 0 {
+		// A group with patterns is a filter chain: children only run after
+		// the parent matches, which plain triggers can't express
+		if len(t.Patterns) > 0 {
+			result.skip("trigger", t.Name, "filter chains aren't supported")
+			return
+		}
+		if group != "" {
+			group += "/"
+		}
+		group += t.Name
+		for _, child := range t.Children {
+			importMudletTrigger(result, child, group)
+		}
+		for _, child := range t.Groups {
+			importMudletTrigger(result, child, group)
+		}
+		return
+	}
+
+	if t.Multiline == "yes" {
+		result.skip("trigger", t.Name, "multi-line (AND) triggers aren't supported")
+		return
+	}
+	if len(t.Patterns) == 0 {
+		result.skip("trigger", t.Name, "no patterns")
+		return
+	}
+
+	commands, err := mudletCommands(t.Command, t.Script)
+	if err != nil {
+		result.skip("trigger", t.Name, err.Error())
+		return
+	}
+
+	// Each pattern becomes its own trigger, since Mudlet ORs them
+	for i, pattern := range t.Patterns {
+		kind := mudletSubstring
+		if i < len(t.Types) {
+			kind = t.Types[i]
+		}
+
+		name := t.Name
+		if i > 0 {
+			name += " " + strconv.Itoa(i+1)
+		}
+
+		regex, err := mudletPattern(pattern, kind)
+		if err != nil {
+			result.skip("trigger", name, err.Error())
+			continue
+		}
+		result.addTrigger(Trigger{
+			Name:     name,
+			Pattern:  regex,
+			Commands: commands,
+			Group:    group,
+			Enabled:  t.Active != "no",
+		})
+	}
+}
+
+// importMudletAlias converts an alias, or a folder and its contents
+func importMudletAlias(result *ImportResult, a mudletAlias, group string) {
+	if a.Folder == "yes" || len(a.Children) > 0 || len(a.Groups) > 0 {
+		if group != "" {
+			group += "/"
+		}
+		group += a.Name
+		for _, child := range a.Children {
+			importMudletAlias(result, child, group)
+		}
+		for _, child := range a.Groups {
+			importMudletAlias(result, child, group)
+		}
+		return
+	}
+
+	if a.Regex == "" {
+		result.skip("alias", a.Name, "no pattern")
+		return
+	}
+	if _, err := regexp.Compile(a.Regex); err != nil {
+		result.skip("alias", a.Name, "pattern uses regex features Go doesn't support")
+		return
+	}
+	commands, err := mudletCommands(a.Command, a.Script)
+	if err != nil {
+		result.skip("alias", a.Name, err.Error())
+		return
+	}
+
+	result.addAlias(Alias{
+		Name:     a.Name,
+		Pattern:  a.Regex,
+		Commands: commands,
+		Group:    group,
+		Enabled:  a.Active != "no",
+	})
+}
+
+// mudletPattern converts a pattern of the given type to a Go regex
+func mudletPattern(pattern string, kind int) (string, error) {
+	switch kind {
+	case mudletSubstring:
+		return regexp.QuoteMeta(pattern), nil
+	case mudletStartOfLine:
+		return "^" + regexp.QuoteMeta(pattern), nil
+	case mudletExact:
+		return "^" + regexp.QuoteMeta(pattern) + "$", nil
+	case mudletRegex:
+		// Mudlet uses PCRE; lookarounds and backreferences don't exist in Go
+		if _, err := regexp.Compile(pattern); err != nil {
+			return "", fmt.Errorf("pattern uses regex features Go doesn't support")
+		}
+		return pattern, nil
+	}
+	return "", fmt.Errorf("colour, Lua, prompt and line spacer patterns aren't supported")
+}
+
+// mudletCommands combines a trigger's plain command with the commands its
+// script sends
+func mudletCommands(command, script string) (string, error) {
+	var commands []string
+	if command = strings.TrimSpace(command); command != "" {
+		commands = append(commands, command)
+	}
+
+	sent, err := luaSends(script)
+	if err != nil {
+		return "", err
+	}
+	commands = append(commands, sent...)
+
+	if len(commands) == 0 {
+		return "", fmt.Errorf("sends no commands")
+	}
+	return strings.Join(commands, ";"), nil
+}
+
+// luaStatement matches a send() or sendAll() call
+var luaStatement = regexp.MustCompile(`^(send|sendAll)\s*\((.*)\)$`)
+
+// luaSends converts a script made only of send() and sendAll() calls into
+// commands. matches[n] becomes $n-1, since Mudlet puts the whole match in
+// matches[1]. Any other Lua is an error.
+func luaSends(script string) ([]string, error) {
+	var commands []string
+	for _, line := range strings.Split(script, "\n") {
+		if i := strings.Index(line, "--"); i >= 0 && !strings.ContainsAny(line[:i], `"'`) {
+			line = line[:i]
+		}
+		for _, statement := range strings.Split(line, ";") {
+			statement = strings.TrimSpace(statement)
+			if statement == "" {
+				continue
+			}
+
+			call := luaStatement.FindStringSubmatch(statement)
+			if call == nil {
+				return nil, fmt.Errorf("script is Lua beyond send(): %s", statement)
+			}
+			args, err := luaArgs(call[2])
+			if err != nil {
+				return nil, err
+			}
+			if call[1] == "send" && len(args) > 0 {
+				// send's second argument only controls local echo
+				args = args[:1]
+			}
+			commands = append(commands, args...)
+		}
+	}
+	return commands, nil
+}
+
+// luaArgs parses comma-separated string expressions built from literals,
+// matches[n] and the .. operator. Boolean arguments are dropped.
+func luaArgs(source string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	hasValue := false
+
+	i := 0
+	for i < len(source) {
+		switch c := source[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '"' || c == '\'':
+			end := i + 1
+			for end < len(source) && source[end] != c {
+				if source[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(source) {
+				return nil, fmt.Errorf("unterminated string in script")
+			}
+			inner := source[i+1 : end]
+			if c == '\'' {
+				inner = strings.ReplaceAll(inner, `"`, `\"`)
+			}
+			literal, err := strconv.Unquote(`"` + inner + `"`)
+			if err != nil {
+				literal = source[i+1 : end]
+			}
+			current.WriteString(literal)
+			hasValue = true
+			i = end + 1
+		case strings.HasPrefix(source[i:], ".."):
+			i += 2
+		case strings.HasPrefix(source[i:], "matches["):
+			end := strings.IndexByte(source[i:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated matches[] in script")
+			}
+			n, err := strconv.Atoi(source[i+len("matches[") : i+end])
+			if err != nil || n < 1 || n > 10 {
+				return nil, fmt.Errorf("unsupported capture reference in script")
+			}
+			current.WriteString("$" + strconv.Itoa(n-1))
+			hasValue = true
+			i += end + 1
+		case strings.HasPrefix(source[i:], "true"), strings.HasPrefix(source[i:], "false"):
+			for i < len(source) && source[i] != ',' {
+				i++
+			}
+		case c == ',':
+			if hasValue {
+				args = append(args, current.String())
+			}
+			current.Reset()
+			hasValue = false
+			i++
+		default:
+			return nil, fmt.Errorf("script builds commands from Lua variables")
+		}
+	}
+	if hasValue {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
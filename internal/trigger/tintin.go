@@ -0,0 +1,344 @@
+package trigger
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// tintinCommand is one #command and its arguments from a TinTin++ script
+type tintinCommand struct {
+	Name string
+	Args []string
+	Line int
+}
+
+// ImportTinTin converts the #action and #alias commands in a TinTin++
+// script. #class sets the group; anything using TinTin's own commands,
+// variables or functions is reported as skipped.
+func ImportTinTin(script string) *ImportResult {
+	result := &ImportResult{Source: "tintin"}
+	group := ""
+
+	for _, command := range parseTinTin(script) {
+		name := strings.ToLower(command.Name)
+		switch {
+		case name == "nop":
+			// Comment
+		case tintinIs(name, "class"):
+			if len(command.Args) >= 2 && strings.EqualFold(command.Args[1], "open") {
+				group = command.Args[0]
+			} else if len(command.Args) >= 2 && strings.EqualFold(command.Args[1], "close") {
+				group = ""
+			}
+		case tintinIs(name, "action"):
+			importTinTinAction(result, command, group)
+		case tintinIs(name, "alias"):
+			importTinTinAlias(result, command, group)
+		default:
+			label := "#" + command.Name
+			if len(command.Args) > 0 {
+				label += " {" + command.Args[0] + "}"
+			}
+			result.skip(name, label, fmt.Sprintf("line %d: #%s has no equivalent", command.Line, command.Name))
+		}
+	}
+	return result
+}
+
+// tintinIs reports whether name is full, or an abbreviation TinTin accepts
+func tintinIs(name, full string) bool {
+	return len(name) >= 3 && strings.HasPrefix(full, name)
+}
+
+// importTinTinAction converts #action {pattern} {commands}
+func importTinTinAction(result *ImportResult, command tintinCommand, group string) {
+	if len(command.Args) < 2 {
+		result.skip("trigger", "#action", fmt.Sprintf("line %d: needs a pattern and commands", command.Line))
+		return
+	}
+	pattern, commands := command.Args[0], command.Args[1]
+
+	regex, groups, err := tintinPattern(pattern)
+	if err != nil {
+		result.skip("trigger", pattern, err.Error())
+		return
+	}
+	converted, err := tintinCommands(commands, func(n int) (string, bool) {
+		if n == 0 {
+			return "$0", true
+		}
+		index, exists := groups[n]
+		return "$" + strconv.Itoa(index), exists
+	})
+	if err != nil {
+		result.skip("trigger", pattern, err.Error())
+		return
+	}
+
+	result.addTrigger(Trigger{
+		Name:     pattern,
+		Pattern:  regex,
+		Commands: converted,
+		Group:    group,
+		Enabled:  true,
+	})
+}
+
+// importTinTinAlias converts #alias {name} {commands}. Without wildcards in
+// the name, %0 is every argument and %1.. are single words; if the commands
+// use neither, TinTin appends the arguments, so that's kept too.
+func importTinTinAlias(result *ImportResult, command tintinCommand, group string) {
+	if len(command.Args) < 2 {
+		result.skip("alias", "#alias", fmt.Sprintf("line %d: needs a name and commands", command.Line))
+		return
+	}
+	name, commands := command.Args[0], command.Args[1]
+
+	if strings.Contains(name, "%") {
+		regex, groups, err := tintinPattern(name)
+		if err != nil {
+			result.skip("alias", name, err.Error())
+			return
+		}
+		converted, err := tintinCommands(commands, func(n int) (string, bool) {
+			index, exists := groups[n]
+			return "$" + strconv.Itoa(index), exists
+		})
+		if err != nil {
+			result.skip("alias", name, err.Error())
+			return
+		}
+		result.addAlias(Alias{
+			Name:     name,
+			Pattern:  "^" + strings.TrimSuffix(strings.TrimPrefix(regex, "^"), "$") + "$",
+			Commands: converted,
+			Group:    group,
+			Enabled:  true,
+		})
+		return
+	}
+
+	// Group 1 holds every argument and groups 2.. each word
+	words := 0
+	for _, ref := range tintinRef.FindAllStringSubmatch(commands, -1) {
+		if n, err := strconv.Atoi(ref[1]); err == nil && n > words {
+			words = n
+		}
+	}
+	args := `.*`
+	if words > 0 {
+		args = `(\S+)` + strings.Repeat(`(?:\s+(\S+))?`, words-1) + `.*`
+	}
+	pattern := "^" + regexp.QuoteMeta(name) + `(?:\s+(` + args + `))?$`
+
+	converted, err := tintinCommands(commands, func(n int) (string, bool) {
+		return "$" + strconv.Itoa(n+1), true
+	})
+	if err != nil {
+		result.skip("alias", name, err.Error())
+		return
+	}
+	if !tintinRef.MatchString(commands) {
+		converted += " $1"
+	}
+
+	result.addAlias(Alias{
+		Name:     name,
+		Pattern:  pattern,
+		Commands: converted,
+		Group:    group,
+		Enabled:  true,
+	})
+}
+
+// tintinRef matches %0..%99 references in commands
+var tintinRef = regexp.MustCompile(`%(\d{1,2})`)
+
+// tintinWildcards are TinTin's typed wildcards and what they match
+var tintinWildcards = map[byte]string{
+	'*': `.*?`,
+	'd': `[0-9]+`,
+	'w': `[A-Za-z0-9_]+`,
+	's': `\s+`,
+	'S': `\S+`,
+	'a': `.*?`,
+	'D': `[^0-9]+`,
+	'W': `[^A-Za-z0-9_]+`,
+}
+
+// tintinPattern converts a TinTin pattern to a Go regex, returning which
+// regex group each numbered wildcard landed in. TinTin matches anywhere in
+// the line unless the pattern starts with ^.
+func tintinPattern(pattern string) (string, map[int]int, error) {
+	if strings.ContainsAny(pattern, "{}") {
+		return "", nil, fmt.Errorf("embedded regular expressions aren't supported")
+	}
+
+	var regex strings.Builder
+	groups := make(map[int]int)
+	count, slot := 0, 1
+
+	rest := pattern
+	if strings.HasPrefix(rest, "^") {
+		regex.WriteString("^")
+		rest = rest[1:]
+	}
+	anchorEnd := strings.HasSuffix(rest, "$") && !strings.HasSuffix(rest, `\$`)
+	if anchorEnd {
+		rest = rest[:len(rest)-1]
+	}
+
+	for i := 0; i < len(rest); i++ {
+		if rest[i] != '%' || i+1 >= len(rest) {
+			regex.WriteString(regexp.QuoteMeta(rest[i : i+1]))
+			continue
+		}
+
+		next := rest[i+1]
+		switch {
+		case next >= '0' && next <= '9':
+			end := i + 2
+			if end < len(rest) && rest[end] >= '0' && rest[end] <= '9' {
+				end++
+			}
+			n, _ := strconv.Atoi(rest[i+1 : end])
+			count++
+			groups[n] = count
+			slot = n + 1
+			// A trailing wildcard with nothing after it should take the rest
+			if end == len(rest) {
+				regex.WriteString(`(.*)`)
+			} else {
+				regex.WriteString(`(.*?)`)
+			}
+			i = end - 1
+		case next == '%':
+			regex.WriteString("%")
+			i++
+		default:
+			class, known := tintinWildcards[next]
+			if !known {
+				return "", nil, fmt.Errorf("wildcard %%%c isn't supported", next)
+			}
+			// Typed wildcards fill the next numbered slot
+			count++
+			groups[slot] = count
+			slot++
+			regex.WriteString("(" + class + ")")
+			i++
+		}
+	}
+
+	if anchorEnd {
+		regex.WriteString("$")
+	}
+	return regex.String(), groups, nil
+}
+
+// tintinCommands converts TinTin commands, replacing %n with the regex group
+// ref returns. Commands using TinTin's own commands, variables or functions
+// can't be converted.
+func tintinCommands(commands string, ref func(n int) (string, bool)) (string, error) {
+	if strings.Contains(commands, "#") {
+		return "", fmt.Errorf("commands use TinTin # commands")
+	}
+	if strings.ContainsAny(commands, "$&@{}") {
+		return "", fmt.Errorf("commands use TinTin variables or functions")
+	}
+
+	var missing error
+	converted := tintinRef.ReplaceAllStringFunc(commands, func(match string) string {
+		n, _ := strconv.Atoi(match[1:])
+		group, exists := ref(n)
+		if !exists {
+			missing = fmt.Errorf("commands refer to %s, which the pattern doesn't capture", match)
+		}
+		return group
+	})
+	if missing != nil {
+		return "", missing
+	}
+	return strings.TrimSpace(converted), nil
+}
+
+// parseTinTin splits a script into #commands with their brace-delimited or
+// bare-word arguments. Text outside commands is ignored.
+func parseTinTin(script string) []tintinCommand {
+	var commands []tintinCommand
+	line := 1
+
+	i := 0
+	for i < len(script) {
+		c := script[i]
+		switch {
+		case c == '\n':
+			line++
+			i++
+			continue
+		case c == ' ' || c == '\t' || c == '\r' || c == ';':
+			i++
+			continue
+		case c != '#':
+			// Not a command; skip the rest of the line
+			for i < len(script) && script[i] != '\n' {
+				i++
+			}
+			continue
+		}
+
+		command := tintinCommand{Line: line}
+		i++
+		start := i
+		for i < len(script) && isTinTinNameChar(script[i]) {
+			i++
+		}
+		command.Name = script[start:i]
+
+		// Arguments run until the end of the line or a ; outside braces
+	args:
+		for i < len(script) {
+			switch script[i] {
+			case ' ', '\t', '\r':
+				i++
+			case '\n', ';':
+				break args
+			case '{':
+				depth := 0
+				start := i + 1
+				for i < len(script) {
+					if script[i] == '{' {
+						depth++
+					} else if script[i] == '}' {
+						depth--
+						if depth == 0 {
+							break
+						}
+					} else if script[i] == '\n' {
+						line++
+					}
+					i++
+				}
+				command.Args = append(command.Args, script[start:min(i, len(script))])
+				i++
+			default:
+				start := i
+				for i < len(script) && !strings.ContainsRune(" \t\r\n;", rune(script[i])) {
+					i++
+				}
+				command.Args = append(command.Args, script[start:i])
+			}
+		}
+
+		if command.Name != "" {
+			commands = append(commands, command)
+		}
+	}
+	return commands
+}
+
+// isTinTinNameChar reports whether c can be part of a command name
+func isTinTinNameChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
@@ -0,0 +1,358 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/events"
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/safefile"
+)
+
+var logger = logging.For("Trigger")
+
+// Hook names a lifecycle event automations can react to
+type Hook string
+
+const (
+	HookConnect     Hook = "onConnect"
+	HookDisconnect  Hook = "onDisconnect"
+	HookRoomEnter   Hook = "onRoomEnter"
+	HookChat        Hook = "onChat"
+	HookCombatStart Hook = "onCombatStart"
+	HookTick        Hook = "onTick"
+)
+
+// Hooks lists every hook, for the GUI's hook picker
+var Hooks = []Hook{HookConnect, HookDisconnect, HookRoomEnter, HookChat, HookCombatStart, HookTick}
+
+// HookFor maps a game event to the hook it fires, if any
+func HookFor(kind events.Kind) (Hook, bool) {
+	switch kind {
+	case events.KindConnect:
+		return HookConnect, true
+	case events.KindDisconnect:
+		return HookDisconnect, true
+	case events.KindRoomEnter:
+		return HookRoomEnter, true
+	case events.KindSay, events.KindTell, events.KindChannel:
+		return HookChat, true
+	case events.KindCombatStart:
+		return HookCombatStart, true
+	case events.KindTick:
+		return HookTick, true
+	}
+	return "", false
+}
+
+// Payload is the structured data passed to a hook. Fields hold the event's
+// details, e.g. speaker/message/channel for onChat or room for onRoomEnter.
+type Payload struct {
+	Hook   Hook              `json:"hook"`
+	Kind   events.Kind       `json:"kind"`
+	Time   time.Time         `json:"time"`
+	Text   string            `json:"text"`
+	Fields map[string]string `json:"fields"`
+}
+
+// Trigger sends commands when a line matches its pattern, or when its hook
+// fires. Commands are separated by semicolons and may refer to regex groups
+// as $1..$9 or to payload fields as {name}.
+type Trigger struct {
+	Name     string `json:"name"`
+	Pattern  string `json:"pattern,omitempty"` // Regex matched against clean output lines
+	Hook     Hook   `json:"hook,omitempty"`    // Set instead of Pattern for event hooks
+	Commands string `json:"commands"`
+	Group    string `json:"group,omitempty"`
+	Enabled  bool   `json:"enabled"`
+
+	regex *regexp.Regexp
+}
+
+// FireFunc sends the commands a trigger produced
+type FireFunc func(name string, commands []string)
+
+// Engine holds the triggers and runs them against output and events
+type Engine struct {
+	mutex     sync.RWMutex
+	path      string
+	triggers  map[string]*Trigger // Keyed by lowercased name
+	fire      FireFunc
+	paused    func() bool
+	listeners []func(Payload)
+}
+
+// NewEngine creates a trigger engine persisting to path (empty for none)
+// and loads any saved triggers
+func NewEngine(path string, fire FireFunc) (*Engine, error) {
+	e := &Engine{
+		path:     path,
+		triggers: make(map[string]*Trigger),
+		fire:     fire,
+	}
+	if path == "" {
+		return e, nil
+	}
+
+	data, err := safefile.ReadFile(path, json.Valid)
+	if os.IsNotExist(err) {
+		return e, nil
+	}
+	if err != nil {
+		return e, fmt.Errorf("failed to read triggers: %w", err)
+	}
+
+	var triggers []Trigger
+	if err := json.Unmarshal(data, &triggers); err != nil {
+		return e, fmt.Errorf("failed to unmarshal triggers: %w", err)
+	}
+	for _, t := range triggers {
+		if err := t.compile(); err != nil {
+			logger.Warn("skipping trigger", "name", t.Name, "error", err)
+			continue
+		}
+		trigger := t
+		e.triggers[strings.ToLower(t.Name)] = &trigger
+	}
+	return e, nil
+}
+
+// SetPauseCheck sets a function consulted before firing; while it returns
+// true no triggers run (e.g. the idle monitor's automation pause)
+func (e *Engine) SetPauseCheck(paused func() bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.paused = paused
+}
+
+// OnHook registers a listener receiving every hook payload, so scripts can
+// react to events without defining triggers
+func (e *Engine) OnHook(fn func(Payload)) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+	e.listeners = append(e.listeners, fn)
+}
+
+// compile checks a trigger and prepares its regex
+func (t *Trigger) compile() error {
+	t.Name = strings.TrimSpace(t.Name)
+	if t.Name == "" {
+		return fmt.Errorf("trigger needs a name")
+	}
+	if (t.Pattern == "") == (t.Hook == "") {
+		return fmt.Errorf("trigger needs either a pattern or a hook")
+	}
+	if t.Hook != "" {
+		for _, hook := range Hooks {
+			if hook == t.Hook {
+				return nil
+			}
+		}
+		return fmt.Errorf("unknown hook %q", t.Hook)
+	}
+
+	regex, err := regexp.Compile(t.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %w", err)
+	}
+	t.regex = regex
+	return nil
+}
+
+// List returns all triggers sorted by group then name
+func (e *Engine) List() []Trigger {
+	e.mutex.RLock()
+	defer e.mutex.RUnlock()
+
+	triggers := make([]Trigger, 0, len(e.triggers))
+	for _, t := range e.triggers {
+		triggers = append(triggers, *t)
+	}
+	sort.Slice(triggers, func(i, j int) bool {
+		if triggers[i].Group != triggers[j].Group {
+			return triggers[i].Group < triggers[j].Group
+		}
+		return strings.ToLower(triggers[i].Name) < strings.ToLower(triggers[j].Name)
+	})
+	return triggers
+}
+
+// Set adds or replaces a trigger
+func (e *Engine) Set(t Trigger) error {
+	if err := t.compile(); err != nil {
+		return err
+	}
+
+	e.mutex.Lock()
+	e.triggers[strings.ToLower(t.Name)] = &t
+	e.mutex.Unlock()
+
+	return e.Save()
+}
+
+// Delete removes a trigger
+func (e *Engine) Delete(name string) error {
+	e.mutex.Lock()
+	delete(e.triggers, strings.ToLower(strings.TrimSpace(name)))
+	e.mutex.Unlock()
+
+	return e.Save()
+}
+
+// SetEnabled turns a trigger on or off
+func (e *Engine) SetEnabled(name string, enabled bool) error {
+	e.mutex.Lock()
+	t, exists := e.triggers[strings.ToLower(strings.TrimSpace(name))]
+	if exists {
+		t.Enabled = enabled
+	}
+	e.mutex.Unlock()
+
+	if !exists {
+		return fmt.Errorf("no trigger called %q", name)
+	}
+	return e.Save()
+}
+
+// Save writes the triggers to disk
+func (e *Engine) Save() error {
+	if e.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(e.List(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal triggers: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(e.path), 0755); err != nil {
+		return fmt.Errorf("failed to create trigger directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(e.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write triggers: %w", err)
+	}
+	return nil
+}
+
+// HandleLine runs pattern triggers against a line of clean output
+func (e *Engine) HandleLine(line string) {
+	if e.isPaused() {
+		return
+	}
+
+	e.mutex.RLock()
+	var matched []*Trigger
+	var groups [][]string
+	for _, t := range e.triggers {
+		if !t.Enabled || t.regex == nil {
+			continue
+		}
+		if match := t.regex.FindStringSubmatch(line); match != nil {
+			matched = append(matched, t)
+			groups = append(groups, match)
+		}
+	}
+	e.mutex.RUnlock()
+
+	for i, t := range matched {
+		e.run(t, expandGroups(t.Commands, groups[i]))
+	}
+}
+
+// HandleEvent fires the hook for a game event, notifying listeners and
+// running any hook triggers
+func (e *Engine) HandleEvent(event events.Event) {
+	hook, ok := HookFor(event.Kind)
+	if !ok {
+		return
+	}
+
+	payload := Payload{
+		Hook:   hook,
+		Kind:   event.Kind,
+		Time:   event.Time,
+		Text:   event.Text,
+		Fields: event.Fields,
+	}
+
+	e.mutex.RLock()
+	listeners := e.listeners
+	var matched []*Trigger
+	for _, t := range e.triggers {
+		if t.Enabled && t.Hook == hook {
+			matched = append(matched, t)
+		}
+	}
+	e.mutex.RUnlock()
+
+	for _, fn := range listeners {
+		fn(payload)
+	}
+
+	if e.isPaused() {
+		return
+	}
+	for _, t := range matched {
+		e.run(t, expandFields(t.Commands, payload))
+	}
+}
+
+// isPaused consults the pause check
+func (e *Engine) isPaused() bool {
+	e.mutex.RLock()
+	paused := e.paused
+	e.mutex.RUnlock()
+	return paused != nil && paused()
+}
+
+// run splits expanded commands and fires them
+func (e *Engine) run(t *Trigger, commands string) {
+	var list []string
+	for _, command := range strings.Split(commands, ";") {
+		if command = strings.TrimSpace(command); command != "" {
+			list = append(list, command)
+		}
+	}
+	if len(list) == 0 || e.fire == nil {
+		return
+	}
+
+	logger.Debug("fired", "name", t.Name, "commands", list)
+	e.fire(t.Name, list)
+}
+
+// groupRegex matches $1..$9 references to regex groups
+var groupRegex = regexp.MustCompile(`\$(\d)`)
+
+// expandGroups substitutes regex groups into commands
+func expandGroups(commands string, match []string) string {
+	return groupRegex.ReplaceAllStringFunc(commands, func(ref string) string {
+		n, _ := strconv.Atoi(ref[1:])
+		if n < len(match) {
+			return match[n]
+		}
+		return ""
+	})
+}
+
+// fieldRegex matches {name} references to payload fields
+var fieldRegex = regexp.MustCompile(`\{(\w+)\}`)
+
+// expandFields substitutes payload fields into commands. {text} is the
+// event's text; unknown fields expand to nothing.
+func expandFields(commands string, payload Payload) string {
+	return fieldRegex.ReplaceAllStringFunc(commands, func(ref string) string {
+		name := ref[1 : len(ref)-1]
+		if name == "text" {
+			return payload.Text
+		}
+		return payload.Fields[name]
+	})
+}
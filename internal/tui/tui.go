@@ -0,0 +1,328 @@
+package tui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"seemud-gui/internal/chat"
+	"seemud-gui/internal/engine"
+	"seemud-gui/internal/mapper"
+	"seemud-gui/internal/output"
+	"seemud-gui/internal/parser"
+)
+
+// Layout constants
+const (
+	sideWidth     = 32   // Minimap and vitals column
+	minimapRadius = 3    // Rooms shown each way from the player
+	maxLines      = 2000 // Output lines kept for scrolling back
+	maxChatLines  = 500
+)
+
+// classStyles colour output by the semantic class the engine attaches
+var classStyles = map[string]lipgloss.Style{
+	output.ClassRoomTitle:    lipgloss.NewStyle().Foreground(lipgloss.Color("81")).Bold(true),
+	output.ClassExit:         lipgloss.NewStyle().Foreground(lipgloss.Color("214")).Bold(true),
+	output.ClassInventory:    lipgloss.NewStyle().Foreground(lipgloss.Color("150")),
+	output.ClassMobs:         lipgloss.NewStyle().Foreground(lipgloss.Color("150")),
+	output.ClassPrompt:       lipgloss.NewStyle().Foreground(lipgloss.Color("244")),
+	output.ClassSystem:       lipgloss.NewStyle().Foreground(lipgloss.Color("75")).Italic(true),
+	output.ClassLogin:        lipgloss.NewStyle().Foreground(lipgloss.Color("141")),
+	output.ClassChatSay:      lipgloss.NewStyle().Foreground(lipgloss.Color("116")),
+	output.ClassChatTell:     lipgloss.NewStyle().Foreground(lipgloss.Color("218")),
+	output.ClassChatChannel:  lipgloss.NewStyle().Foreground(lipgloss.Color("183")),
+	output.ClassCombatAttack: lipgloss.NewStyle().Foreground(lipgloss.Color("216")),
+	output.ClassCombatDamage: lipgloss.NewStyle().Foreground(lipgloss.Color("203")).Bold(true),
+}
+
+var (
+	paneStyle  = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("238"))
+	titleStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("245")).Bold(true)
+	echoStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("70"))
+	errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+)
+
+// lineMsg carries a line of MUD output into the UI loop
+type lineMsg struct {
+	entry  output.Entry
+	parsed *parser.ParsedOutput
+	chat   *chat.Message
+}
+
+// tickMsg refreshes the vitals and minimap
+type tickMsg time.Time
+
+// model is the TUI state
+type model struct {
+	mud   *engine.Engine
+	chat  *chat.Capture
+	input textinput.Model
+
+	output   viewport.Model
+	chatView viewport.Model
+	lines    []string
+	chats    []string
+	prompt   string // Last prompt line, shown with the vitals
+
+	history      []string
+	historyIndex int // -1 when not browsing history
+
+	width, height int
+	ready         bool
+}
+
+// Run shows the TUI until the user quits. The engine should already be
+// connecting; the TUI only shows its output and sends input.
+func Run(mud *engine.Engine) error {
+	input := textinput.New()
+	input.Prompt = "> "
+	input.Placeholder = "Enter command... (Ctrl-C to quit)"
+	input.Focus()
+
+	// The up arrow reaches back into earlier sessions on this server
+	history := mud.History.Recent(0)
+	slices.Reverse(history)
+
+	m := &model{
+		mud:          mud,
+		chat:         chat.NewCapture(chat.DefaultBufferSize),
+		input:        input,
+		history:      history,
+		historyIndex: -1,
+	}
+
+	program := tea.NewProgram(m, tea.WithAltScreen(), tea.WithMouseCellMotion())
+	mud.OnLine(func(entry output.Entry, parsed *parser.ParsedOutput) {
+		msg := lineMsg{entry: entry, parsed: parsed}
+		if message, isChat := m.chat.Route(parsed); isChat {
+			msg.chat = &message
+		}
+		program.Send(msg)
+	})
+
+	_, err := program.Run()
+	return err
+}
+
+// Init starts the refresh ticker
+func (m *model) Init() tea.Cmd {
+	return tea.Batch(textinput.Blink, tick())
+}
+
+// tick schedules the next vitals refresh
+func tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg {
+		return tickMsg(t)
+	})
+}
+
+// Update handles keys, resizes and incoming output
+func (m *model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	var cmds []tea.Cmd
+
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+		m.layout()
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyCtrlC, tea.KeyCtrlD:
+			return m, tea.Quit
+		case tea.KeyEnter:
+			m.submit()
+			return m, nil
+		case tea.KeyUp:
+			m.browseHistory(1)
+			return m, nil
+		case tea.KeyDown:
+			m.browseHistory(-1)
+			return m, nil
+		case tea.KeyPgUp, tea.KeyPgDown:
+			var cmd tea.Cmd
+			m.output, cmd = m.output.Update(msg)
+			return m, cmd
+		}
+
+	case tea.MouseMsg:
+		var cmd tea.Cmd
+		m.output, cmd = m.output.Update(msg)
+		return m, cmd
+
+	case lineMsg:
+		m.addLine(msg)
+		return m, nil
+
+	case tickMsg:
+		return m, tick()
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	cmds = append(cmds, cmd)
+	return m, tea.Batch(cmds...)
+}
+
+// submit sends the input line through the engine, expanding aliases
+func (m *model) submit() {
+	command := m.input.Value()
+	m.input.SetValue("")
+	m.historyIndex = -1
+
+	if strings.TrimSpace(command) != "" {
+		m.history = append(m.history, command)
+	}
+	m.mud.RememberCommand(command)
+	m.appendOutput(echoStyle.Render("> " + command))
+
+	if err := m.mud.Input(command); err != nil {
+		m.appendOutput(errorStyle.Render("Error: " + err.Error()))
+	}
+}
+
+// browseHistory moves back (1) or forward (-1) through sent commands
+func (m *model) browseHistory(step int) {
+	index := m.historyIndex + step
+	if index < -1 || index >= len(m.history) {
+		return
+	}
+	m.historyIndex = index
+	if index == -1 {
+		m.input.SetValue("")
+		return
+	}
+	m.input.SetValue(m.history[len(m.history)-1-index])
+	m.input.CursorEnd()
+}
+
+// addLine shows a line of MUD output in the right panes
+func (m *model) addLine(msg lineMsg) {
+	text := msg.parsed.CleanText
+	if msg.parsed.Type == parser.TypePrompt {
+		m.prompt = text
+	}
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+
+	if style, ok := classStyles[msg.entry.Event.Class]; ok {
+		text = style.Render(text)
+	}
+	m.appendOutput(text)
+
+	if msg.chat != nil {
+		line := fmt.Sprintf("%s [%s] %s: %s", msg.chat.Time.Format("15:04"), msg.chat.Channel, msg.chat.Speaker, msg.chat.Text)
+		if style, ok := classStyles[msg.entry.Event.Class]; ok {
+			line = style.Render(line)
+		}
+		m.chats = appendCapped(m.chats, line, maxChatLines)
+		m.chatView.SetContent(strings.Join(m.chats, "\n"))
+		m.chatView.GotoBottom()
+	}
+}
+
+// appendOutput adds a line to the output pane, following the bottom unless
+// the user has scrolled back
+func (m *model) appendOutput(line string) {
+	following := m.output.AtBottom()
+	m.lines = appendCapped(m.lines, line, maxLines)
+	m.output.SetContent(strings.Join(m.lines, "\n"))
+	if following {
+		m.output.GotoBottom()
+	}
+}
+
+// appendCapped appends to a slice, dropping the oldest beyond limit
+func appendCapped(lines []string, line string, limit int) []string {
+	lines = append(lines, line)
+	if len(lines) > limit {
+		lines = lines[len(lines)-limit:]
+	}
+	return lines
+}
+
+// layout sizes the panes to the terminal
+func (m *model) layout() {
+	mainWidth := m.width - sideWidth - 4
+	if mainWidth < 20 {
+		mainWidth = 20
+	}
+	// Two borders per pane, plus the input line
+	body := m.height - 3
+	chatHeight := body / 4
+	outputHeight := body - chatHeight - 4
+
+	if !m.ready {
+		m.output = viewport.New(mainWidth, outputHeight)
+		m.chatView = viewport.New(mainWidth, chatHeight)
+		m.ready = true
+	} else {
+		m.output.Width, m.output.Height = mainWidth, outputHeight
+		m.chatView.Width, m.chatView.Height = mainWidth, chatHeight
+	}
+	m.output.SetContent(strings.Join(m.lines, "\n"))
+	m.output.GotoBottom()
+	m.chatView.SetContent(strings.Join(m.chats, "\n"))
+	m.input.Width = m.width - 4
+}
+
+// View draws the panes
+func (m *model) View() string {
+	if !m.ready {
+		return "Starting..."
+	}
+
+	left := lipgloss.JoinVertical(lipgloss.Left,
+		paneStyle.Render(m.output.View()),
+		paneStyle.Render(titleStyle.Render("Chat")+"\n"+m.chatView.View()),
+	)
+
+	sideHeight := lipgloss.Height(left) - 2
+	mapHeight := minimapRadius*4 + 2
+	minimap := paneStyle.Width(sideWidth - 2).Height(mapHeight).Render(
+		titleStyle.Render("Map") + "\n" + m.mud.Mapper.GetMinimap(minimapRadius).Text(mapper.ASCIIStyle),
+	)
+	vitals := paneStyle.Width(sideWidth - 2).Height(max(sideHeight-mapHeight-2, 1)).Render(m.vitals())
+	right := lipgloss.JoinVertical(lipgloss.Left, minimap, vitals)
+
+	return lipgloss.JoinVertical(lipgloss.Left,
+		lipgloss.JoinHorizontal(lipgloss.Top, left, right),
+		m.input.View(),
+	)
+}
+
+// vitals summarises the connection, prompt and session
+func (m *model) vitals() string {
+	stats := m.mud.Stats.Snapshot()
+	lines := []string{
+		titleStyle.Render("Vitals"),
+		"State:    " + string(m.mud.State()),
+	}
+	if name := m.mud.ServerName(); name != "" {
+		lines = append(lines, "Server:   "+name)
+	}
+	if room := m.mud.Mapper.GetCurrentRoom(); room != nil {
+		lines = append(lines, "Room:     "+room.Name)
+	}
+	if m.prompt != "" {
+		lines = append(lines, "Prompt:   "+m.prompt)
+	}
+	lines = append(lines,
+		"",
+		fmt.Sprintf("Uptime:   %s", time.Duration(stats.UptimeSeconds)*time.Second),
+		fmt.Sprintf("Commands: %d", stats.CommandsSent),
+		fmt.Sprintf("Rooms:    %d new, %d mapped", stats.RoomsDiscovered, m.mud.Mapper.RoomCount()),
+		fmt.Sprintf("Deaths:   %d", stats.Deaths),
+	)
+	if queued := m.mud.Queue.Len(); queued > 0 {
+		lines = append(lines, fmt.Sprintf("Queued:   %d", queued))
+	}
+	return strings.Join(lines, "\n")
+}
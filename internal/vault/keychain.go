@@ -0,0 +1,45 @@
+package vault
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// keychainService is the service name the key is stored under
+const keychainService = "seemud-vault"
+
+// storeKeychainKey saves a vault key in the OS keychain. Entries are keyed by
+// vault path, so separate data directories get separate keys.
+func storeKeychainKey(path string, key []byte) error {
+	if err := keyring.Set(keychainService, path, base64.StdEncoding.EncodeToString(key)); err != nil {
+		return fmt.Errorf("failed to store key in keychain: %w", err)
+	}
+	return nil
+}
+
+// loadKeychainKey reads a vault key from the OS keychain
+func loadKeychainKey(path string) ([]byte, error) {
+	encoded, err := keyring.Get(keychainService, path)
+	if errors.Is(err, keyring.ErrNotFound) {
+		return nil, fmt.Errorf("no vault key in the keychain")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key from keychain: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode keychain key: %w", err)
+	}
+	return key, nil
+}
+
+// deleteKeychainKey removes a vault key from the OS keychain
+func deleteKeychainKey(path string) {
+	if err := keyring.Delete(keychainService, path); err != nil && !errors.Is(err, keyring.ErrNotFound) {
+		logger.Warn("failed to remove key from keychain", "error", err)
+	}
+}
@@ -0,0 +1,396 @@
+package vault
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+
+	"seemud-gui/internal/logging"
+	"seemud-gui/internal/safefile"
+)
+
+var logger = logging.For("Vault")
+
+// Mode says where the vault's key comes from
+type Mode string
+
+const (
+	ModePassphrase Mode = "passphrase" // Derived from a passphrase with scrypt
+	ModeKeychain   Mode = "keychain"   // A random key kept in the OS keychain
+)
+
+// Well-known secret names
+const (
+	SecretSDAuth   = "sd_auth"   // Stable Diffusion API credentials, "user:pass" or a token
+	SecretAPIToken = "api_token" // Bearer token for the headless API
+	SecretLLMKey   = "llm_key"   // API key for the language model condensing image prompts
+	// Password for a proxy whose username is set without one
+	SecretProxyPassword = "proxy_password"
+)
+
+// MUDPassword returns the secret name for a character's password on a server
+func MUDPassword(serverName, character string) string {
+	return "mud:" + serverName + ":" + strings.ToLower(strings.TrimSpace(character))
+}
+
+// ErrLocked is returned when reading or writing secrets before Unlock
+var ErrLocked = errors.New("vault is locked")
+
+// ErrWrongKey is returned when the passphrase or keychain key doesn't
+// decrypt the vault
+var ErrWrongKey = errors.New("wrong passphrase or key")
+
+// fileVersion is the current vault file format
+const fileVersion = 1
+
+// scrypt parameters; N=2^15 takes roughly 100ms on a desktop
+const (
+	scryptN   = 1 << 15
+	scryptR   = 8
+	scryptP   = 1
+	keyLength = 32
+)
+
+// file is the on-disk vault. Only the secrets are encrypted; the header
+// says how to get the key.
+type file struct {
+	Version    int    `json:"version"`
+	Mode       Mode   `json:"mode"`
+	Salt       []byte `json:"salt,omitempty"` // Passphrase mode only
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// Status describes the vault for the settings panel
+type Status struct {
+	Exists bool     `json:"exists"`
+	Locked bool     `json:"locked"`
+	Mode   Mode     `json:"mode,omitempty"`
+	Names  []string `json:"names"` // Secret names, never values
+}
+
+// Vault is an encrypted store for passwords, tokens and API keys. It starts
+// locked and holds decrypted secrets in memory only while unlocked.
+type Vault struct {
+	mutex   sync.RWMutex
+	path    string
+	mode    Mode
+	salt    []byte
+	key     []byte            // nil while locked
+	secrets map[string]string // nil while locked
+}
+
+// New creates a locked vault stored at path
+func New(path string) *Vault {
+	return &Vault{path: path}
+}
+
+// Status reports whether the vault exists and is unlocked
+func (v *Vault) Status() Status {
+	stored, err := v.read()
+
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	status := Status{Locked: v.key == nil, Names: []string{}}
+	if err == nil {
+		status.Exists = true
+		status.Mode = stored.Mode
+	}
+	if v.key != nil {
+		status.Mode = v.mode
+		for name := range v.secrets {
+			status.Names = append(status.Names, name)
+		}
+		sort.Strings(status.Names)
+	}
+	return status
+}
+
+// Exists reports whether a vault file has been created
+func (v *Vault) Exists() bool {
+	_, err := os.Stat(v.path)
+	return err == nil
+}
+
+// Locked reports whether secrets are unavailable
+func (v *Vault) Locked() bool {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+	return v.key == nil
+}
+
+// Unlock opens the vault with a passphrase, creating it if it doesn't exist
+func (v *Vault) Unlock(passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("passphrase is required")
+	}
+
+	if !v.Exists() {
+		salt := make([]byte, 16)
+		if _, err := rand.Read(salt); err != nil {
+			return fmt.Errorf("failed to generate salt: %w", err)
+		}
+		key, err := deriveKey(passphrase, salt)
+		if err != nil {
+			return err
+		}
+		return v.create(ModePassphrase, salt, key)
+	}
+
+	stored, err := v.read()
+	if err != nil {
+		return err
+	}
+	if stored.Mode != ModePassphrase {
+		return fmt.Errorf("vault uses the %s, not a passphrase", stored.Mode)
+	}
+	key, err := deriveKey(passphrase, stored.Salt)
+	if err != nil {
+		return err
+	}
+	return v.open(stored, key)
+}
+
+// UnlockWithKeychain opens the vault with a key kept in the OS keychain,
+// creating both if they don't exist
+func (v *Vault) UnlockWithKeychain() error {
+	if !v.Exists() {
+		key := make([]byte, keyLength)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("failed to generate key: %w", err)
+		}
+		if err := storeKeychainKey(v.path, key); err != nil {
+			return err
+		}
+		return v.create(ModeKeychain, nil, key)
+	}
+
+	stored, err := v.read()
+	if err != nil {
+		return err
+	}
+	if stored.Mode != ModeKeychain {
+		return fmt.Errorf("vault uses a passphrase, not the keychain")
+	}
+	key, err := loadKeychainKey(v.path)
+	if err != nil {
+		return err
+	}
+	return v.open(stored, key)
+}
+
+// Lock forgets the key and decrypted secrets
+func (v *Vault) Lock() {
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+
+	for i := range v.key {
+		v.key[i] = 0
+	}
+	v.key = nil
+	v.secrets = nil
+}
+
+// ChangePassphrase re-encrypts an unlocked vault under a new passphrase,
+// moving it off the keychain if it was there
+func (v *Vault) ChangePassphrase(passphrase string) error {
+	if passphrase == "" {
+		return fmt.Errorf("passphrase is required")
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	v.mutex.Lock()
+	if v.key == nil {
+		v.mutex.Unlock()
+		return ErrLocked
+	}
+	previous := v.mode
+	v.mode, v.salt, v.key = ModePassphrase, salt, key
+	v.mutex.Unlock()
+
+	if err := v.save(); err != nil {
+		return err
+	}
+	if previous == ModeKeychain {
+		deleteKeychainKey(v.path)
+	}
+	return nil
+}
+
+// Get returns a secret
+func (v *Vault) Get(name string) (string, error) {
+	v.mutex.RLock()
+	defer v.mutex.RUnlock()
+
+	if v.key == nil {
+		return "", ErrLocked
+	}
+	value, exists := v.secrets[name]
+	if !exists {
+		return "", fmt.Errorf("no secret named %q", name)
+	}
+	return value, nil
+}
+
+// Set stores a secret and saves the vault
+func (v *Vault) Set(name, value string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return fmt.Errorf("secret needs a name")
+	}
+
+	v.mutex.Lock()
+	if v.key == nil {
+		v.mutex.Unlock()
+		return ErrLocked
+	}
+	v.secrets[name] = value
+	v.mutex.Unlock()
+
+	return v.save()
+}
+
+// Delete removes a secret and saves the vault
+func (v *Vault) Delete(name string) error {
+	v.mutex.Lock()
+	if v.key == nil {
+		v.mutex.Unlock()
+		return ErrLocked
+	}
+	delete(v.secrets, name)
+	v.mutex.Unlock()
+
+	return v.save()
+}
+
+// create starts an empty vault with key and saves it
+func (v *Vault) create(mode Mode, salt, key []byte) error {
+	v.mutex.Lock()
+	v.mode, v.salt, v.key = mode, salt, key
+	v.secrets = make(map[string]string)
+	v.mutex.Unlock()
+
+	return v.save()
+}
+
+// open decrypts a stored vault with key
+func (v *Vault) open(stored *file, key []byte) error {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	// GCM panics on a nonce of the wrong size rather than failing
+	if len(stored.Nonce) != gcm.NonceSize() {
+		return fmt.Errorf("vault is damaged: nonce is %d bytes", len(stored.Nonce))
+	}
+	plaintext, err := gcm.Open(nil, stored.Nonce, stored.Ciphertext, nil)
+	if err != nil {
+		return ErrWrongKey
+	}
+
+	secrets := make(map[string]string)
+	if err := json.Unmarshal(plaintext, &secrets); err != nil {
+		return fmt.Errorf("failed to unmarshal secrets: %w", err)
+	}
+
+	v.mutex.Lock()
+	defer v.mutex.Unlock()
+	v.mode, v.salt, v.key = stored.Mode, stored.Salt, key
+	v.secrets = secrets
+	return nil
+}
+
+// read loads the vault file's header and ciphertext
+func (v *Vault) read() (*file, error) {
+	data, err := os.ReadFile(v.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+
+	var stored file
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal vault: %w", err)
+	}
+	if stored.Version > fileVersion {
+		return nil, fmt.Errorf("vault version %d is newer than this client supports", stored.Version)
+	}
+	return &stored, nil
+}
+
+// save encrypts the secrets with a fresh nonce and writes the vault
+func (v *Vault) save() error {
+	v.mutex.RLock()
+	plaintext, err := json.Marshal(v.secrets)
+	mode, salt, key := v.mode, v.salt, v.key
+	v.mutex.RUnlock()
+	if err != nil {
+		return fmt.Errorf("failed to marshal secrets: %w", err)
+	}
+
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	data, err := json.MarshalIndent(file{
+		Version:    fileVersion,
+		Mode:       mode,
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, nil),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vault: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(v.path), 0700); err != nil {
+		return fmt.Errorf("failed to create vault directory: %w", err)
+	}
+	// A crash can't leave a half-written vault. No backup is kept, since it
+	// would still open with a passphrase the user has since changed.
+	if err := safefile.WriteFile(v.path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write vault: %w", err)
+	}
+	return nil
+}
+
+// deriveKey stretches a passphrase into an AES-256 key
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, scryptN, scryptR, scryptP, keyLength)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
+// newGCM creates an AES-GCM cipher for key
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,148 @@
+package vault
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// sealed creates a vault under passphrase holding one secret, returning
+// its path
+func sealed(t *testing.T, passphrase string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "vault.json")
+	v := New(path)
+	if err := v.Unlock(passphrase); err != nil {
+		t.Fatalf("Unlock creating vault: %v", err)
+	}
+	if err := v.Set(SecretAPIToken, "s3cret"); err != nil {
+		t.Fatalf("Set: %v", err)
+	}
+	return path
+}
+
+// rewrite changes a vault file's stored fields
+func rewrite(t *testing.T, path string, change func(*file)) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored file
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	change(&stored)
+	if data, err = json.Marshal(stored); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	path := sealed(t, "correct horse")
+
+	v := New(path)
+	if !v.Locked() {
+		t.Fatal("new vault is unlocked")
+	}
+	if _, err := v.Get(SecretAPIToken); !errors.Is(err, ErrLocked) {
+		t.Errorf("Get while locked = %v, want ErrLocked", err)
+	}
+	if err := v.Unlock("correct horse"); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+	if value, err := v.Get(SecretAPIToken); err != nil || value != "s3cret" {
+		t.Errorf("Get = %q, %v, want \"s3cret\"", value, err)
+	}
+
+	v.Lock()
+	if _, err := v.Get(SecretAPIToken); !errors.Is(err, ErrLocked) {
+		t.Errorf("Get after Lock = %v, want ErrLocked", err)
+	}
+}
+
+func TestChangePassphrase(t *testing.T) {
+	path := sealed(t, "old")
+	v := New(path)
+	if err := v.Unlock("old"); err != nil {
+		t.Fatal(err)
+	}
+	if err := v.ChangePassphrase("new"); err != nil {
+		t.Fatalf("ChangePassphrase: %v", err)
+	}
+
+	if err := New(path).Unlock("old"); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Unlock with old passphrase = %v, want ErrWrongKey", err)
+	}
+	reopened := New(path)
+	if err := reopened.Unlock("new"); err != nil {
+		t.Fatalf("Unlock with new passphrase: %v", err)
+	}
+	if value, _ := reopened.Get(SecretAPIToken); value != "s3cret" {
+		t.Errorf("Get after change = %q, want \"s3cret\"", value)
+	}
+}
+
+func TestWrongPassphrase(t *testing.T) {
+	path := sealed(t, "correct horse")
+
+	v := New(path)
+	if err := v.Unlock("battery staple"); !errors.Is(err, ErrWrongKey) {
+		t.Errorf("Unlock = %v, want ErrWrongKey", err)
+	}
+	if !v.Locked() {
+		t.Error("vault unlocked with the wrong passphrase")
+	}
+}
+
+func TestDamagedVault(t *testing.T) {
+	tests := []struct {
+		name   string
+		change func(*file)
+	}{
+		{"flipped ciphertext bit", func(f *file) { f.Ciphertext[0] ^= 1 }},
+		{"truncated ciphertext", func(f *file) { f.Ciphertext = f.Ciphertext[:len(f.Ciphertext)-1] }},
+		{"flipped nonce bit", func(f *file) { f.Nonce[0] ^= 1 }},
+		{"short nonce", func(f *file) { f.Nonce = f.Nonce[:4] }},
+		{"changed salt", func(f *file) { f.Salt[0] ^= 1 }},
+		{"newer version", func(f *file) { f.Version = fileVersion + 1 }},
+	}
+	for _, test := range tests {
+		path := sealed(t, "correct horse")
+		rewrite(t, path, test.change)
+
+		v := New(path)
+		if err := v.Unlock("correct horse"); err == nil {
+			t.Errorf("%s: Unlock succeeded, want an error", test.name)
+		}
+		if !v.Locked() {
+			t.Errorf("%s: vault unlocked", test.name)
+		}
+	}
+}
+
+func TestTruncatedFile(t *testing.T) {
+	path := sealed(t, "correct horse")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, length := range []int{0, 1, len(data) / 2, len(data) - 2} {
+		if err := os.WriteFile(path, data[:length], 0600); err != nil {
+			t.Fatal(err)
+		}
+		v := New(path)
+		if err := v.Unlock("correct horse"); err == nil {
+			t.Errorf("Unlock of %d of %d bytes succeeded, want an error", length, len(data))
+		}
+		if !v.Locked() {
+			t.Errorf("vault of %d of %d bytes unlocked", length, len(data))
+		}
+	}
+}
@@ -0,0 +1,295 @@
+package vitals
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"seemud-gui/internal/datadir"
+	"seemud-gui/internal/safefile"
+)
+
+// HistoryLimit is how many readings are kept for graphs; older ones are
+// dropped
+const HistoryLimit = 3600
+
+// Vitals is a reading of the character's health, mana and stamina. A max
+// of 0 means the prompt hasn't shown it yet.
+type Vitals struct {
+	Time       time.Time `json:"time"`
+	HP         int       `json:"hp"`
+	MaxHP      int       `json:"max_hp"`
+	MP         int       `json:"mp"`
+	MaxMP      int       `json:"max_mp"`
+	Stamina    int       `json:"stamina"`
+	MaxStamina int       `json:"max_stamina"`
+}
+
+// same reports whether two readings have the same values
+func (v Vitals) same(other Vitals) bool {
+	v.Time = other.Time
+	return v == other
+}
+
+// Settings say how to read a server's prompt
+type Settings struct {
+	// Pattern is a regex with named groups hp, max_hp, mp, max_mp, stamina
+	// and max_stamina, tried on every line. Empty reads prompts like
+	// "<120/150hp 80/100mp 60/60mv>" or "HP:120/150 SP:80".
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// groups are the named groups a custom pattern may use
+var groups = []string{"hp", "max_hp", "mp", "max_mp", "stamina", "max_stamina"}
+
+// compile checks settings and prepares the pattern
+func (s Settings) compile() (*regexp.Regexp, error) {
+	if s.Pattern == "" {
+		return nil, nil
+	}
+	regex, err := regexp.Compile(s.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid prompt pattern: %w", err)
+	}
+	for _, name := range groups {
+		if regex.SubexpIndex(name) >= 0 {
+			return regex, nil
+		}
+	}
+	return nil, fmt.Errorf("prompt pattern needs a group named one of %s, e.g. (?P<hp>\\d+)", strings.Join(groups, ", "))
+}
+
+// Prompts label numbers right after them, "120/150hp", or before them,
+// "HP:120/150"
+var (
+	valueFirst = regexp.MustCompile(`(?i)(\d+)(?:/(\d+))?(hp|hits|h|mana|mp|sp|m|mv|mov|stam|st|end|v)\b`)
+	labelFirst = regexp.MustCompile(`(?i)\b(hp|hits|mana|mp|sp|mv|mov|stam|st|end)\s*[:=]?\s*(\d+)(?:\s*/\s*(\d+))?`)
+)
+
+// stat maps a prompt's label to the reading it sets
+func stat(label string) string {
+	switch strings.ToLower(label) {
+	case "hp", "hits", "h":
+		return "hp"
+	case "mana", "mp", "sp", "m":
+		return "mp"
+	default:
+		return "stamina"
+	}
+}
+
+// Tracker reads vitals from the prompt and keeps their recent history.
+// Settings are kept per server since every MUD's prompt differs.
+type Tracker struct {
+	mutex    sync.RWMutex
+	dir      string
+	server   string
+	settings Settings
+	pattern  *regexp.Regexp
+	history  []Vitals // Ring of up to HistoryLimit readings
+	start    int      // Index of the oldest reading
+	count    int
+}
+
+// NewTracker creates a tracker storing settings under dir. An empty dir
+// keeps them in memory only.
+func NewTracker(dir string) *Tracker {
+	return &Tracker{dir: dir}
+}
+
+// Load switches to a server's settings and forgets the readings
+func (t *Tracker) Load(server string) error {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	t.server = server
+	t.settings, t.pattern = Settings{}, nil
+	t.start, t.count = 0, 0
+	if t.dir == "" {
+		return nil
+	}
+
+	data, err := safefile.ReadFile(t.path(), json.Valid)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read vitals settings: %w", err)
+	}
+	var settings Settings
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return fmt.Errorf("failed to unmarshal vitals settings: %w", err)
+	}
+	pattern, err := settings.compile()
+	if err != nil {
+		return err
+	}
+	t.settings, t.pattern = settings, pattern
+	return nil
+}
+
+// path is the current server's settings file; the caller must hold the lock
+func (t *Tracker) path() string {
+	name := t.server
+	if name == "" {
+		name = "default"
+	}
+	return filepath.Join(t.dir, datadir.Namespace(name)+".json")
+}
+
+// Settings returns the current server's settings
+func (t *Tracker) Settings() Settings {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.settings
+}
+
+// SetSettings changes how the current server's prompt is read and saves it
+func (t *Tracker) SetSettings(settings Settings) error {
+	settings.Pattern = strings.TrimSpace(settings.Pattern)
+	pattern, err := settings.compile()
+	if err != nil {
+		return err
+	}
+
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.settings, t.pattern = settings, pattern
+	return t.save()
+}
+
+// save writes the settings to disk; the caller must hold the lock
+func (t *Tracker) save() error {
+	if t.dir == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t.settings, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal vitals settings: %w", err)
+	}
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return fmt.Errorf("failed to create vitals directory: %w", err)
+	}
+	if err := safefile.WriteWithBackup(t.path(), data, 0644); err != nil {
+		return fmt.Errorf("failed to write vitals settings: %w", err)
+	}
+	return nil
+}
+
+// ProcessLine reads vitals from a line of clean output, returning the new
+// reading and true if they changed. A custom pattern is tried on every
+// line; otherwise only lines the parser took for a prompt are read.
+func (t *Tracker) ProcessLine(line string, isPrompt bool) (Vitals, bool) {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+
+	values := make(map[string]int)
+	if t.pattern != nil {
+		match := t.pattern.FindStringSubmatch(line)
+		if match == nil {
+			return Vitals{}, false
+		}
+		for _, name := range groups {
+			if i := t.pattern.SubexpIndex(name); i >= 0 && match[i] != "" {
+				values[name], _ = strconv.Atoi(match[i])
+			}
+		}
+	} else if isPrompt {
+		for _, match := range valueFirst.FindAllStringSubmatch(line, -1) {
+			set(values, stat(match[3]), match[1], match[2])
+		}
+		for _, match := range labelFirst.FindAllStringSubmatch(line, -1) {
+			set(values, stat(match[1]), match[2], match[3])
+		}
+	}
+	if len(values) == 0 {
+		return Vitals{}, false
+	}
+
+	// A prompt that leaves out a max, or a whole stat, keeps the last one
+	last, seen := t.last()
+	reading := last
+	reading.Time = time.Now()
+	apply := func(name string, field *int) {
+		if value, ok := values[name]; ok {
+			*field = value
+		}
+	}
+	apply("hp", &reading.HP)
+	apply("max_hp", &reading.MaxHP)
+	apply("mp", &reading.MP)
+	apply("max_mp", &reading.MaxMP)
+	apply("stamina", &reading.Stamina)
+	apply("max_stamina", &reading.MaxStamina)
+
+	if seen && last.same(reading) {
+		return reading, false
+	}
+	t.add(reading)
+	return reading, true
+}
+
+// add keeps a reading, overwriting the oldest once HistoryLimit are kept;
+// the caller must hold the lock
+func (t *Tracker) add(reading Vitals) {
+	if t.history == nil {
+		t.history = make([]Vitals, HistoryLimit)
+	}
+	if t.count < len(t.history) {
+		t.history[(t.start+t.count)%len(t.history)] = reading
+		t.count++
+		return
+	}
+	t.history[t.start] = reading
+	t.start = (t.start + 1) % len(t.history)
+}
+
+// last returns the latest reading, and false if there hasn't been one;
+// the caller must hold the lock
+func (t *Tracker) last() (Vitals, bool) {
+	if t.count == 0 {
+		return Vitals{}, false
+	}
+	return t.history[(t.start+t.count-1)%len(t.history)], true
+}
+
+// set records a stat and its max from a prompt, keeping the first seen
+func set(values map[string]int, name, value, maximum string) {
+	if _, seen := values[name]; seen {
+		return
+	}
+	values[name], _ = strconv.Atoi(value)
+	if maximum != "" {
+		values["max_"+name], _ = strconv.Atoi(maximum)
+	}
+}
+
+// Current returns the latest reading, and false if there hasn't been one
+func (t *Tracker) Current() (Vitals, bool) {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+	return t.last()
+}
+
+// History returns the readings since a time, oldest first. Each is kept
+// only when the values change, so a graph holds each until the next.
+func (t *Tracker) History(since time.Time) []Vitals {
+	t.mutex.RLock()
+	defer t.mutex.RUnlock()
+
+	history := []Vitals{}
+	for i := 0; i < t.count; i++ {
+		reading := t.history[(t.start+i)%len(t.history)]
+		if !reading.Time.Before(since) {
+			history = append(history, reading)
+		}
+	}
+	return history
+}
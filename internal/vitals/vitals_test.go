@@ -0,0 +1,71 @@
+package vitals
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestProcessPrompt(t *testing.T) {
+	tests := []struct {
+		line string
+		want Vitals
+	}{
+		{"<120/150hp 80/100mp 60/60mv>", Vitals{HP: 120, MaxHP: 150, MP: 80, MaxMP: 100, Stamina: 60, MaxStamina: 60}},
+		{"HP:120/150 SP:80", Vitals{HP: 120, MaxHP: 150, MP: 80}},
+		{"[ Hits: 45/90  Mana: 12 ]", Vitals{HP: 45, MaxHP: 90, MP: 12}},
+		{"57h 30m 88v >", Vitals{HP: 57, MP: 30, Stamina: 88}},
+	}
+	for _, test := range tests {
+		got, changed := NewTracker("").ProcessLine(test.line, true)
+		got.Time = time.Time{}
+		if !changed || got != test.want {
+			t.Errorf("ProcessLine(%q) = %+v, %v, want %+v, true", test.line, got, changed, test.want)
+		}
+	}
+}
+
+func TestHistoryKeepsTheLatest(t *testing.T) {
+	tracker := NewTracker("")
+	if _, ok := tracker.Current(); ok {
+		t.Fatal("Current before any prompt reported a reading")
+	}
+
+	// Half again the limit, so the ring wraps part way round
+	readings := HistoryLimit + HistoryLimit/2
+	for hp := 1; hp <= readings; hp++ {
+		if _, changed := tracker.ProcessLine(promptFor(hp), true); !changed {
+			t.Fatalf("reading %d unchanged", hp)
+		}
+	}
+	// A repeat isn't kept
+	if _, changed := tracker.ProcessLine(promptFor(readings), true); changed {
+		t.Error("repeated prompt reported a change")
+	}
+
+	history := tracker.History(time.Time{})
+	if len(history) != HistoryLimit {
+		t.Fatalf("history holds %d readings, want %d", len(history), HistoryLimit)
+	}
+	for i, reading := range history {
+		if want := readings - HistoryLimit + 1 + i; reading.HP != want {
+			t.Fatalf("history[%d].HP = %d, want %d", i, reading.HP, want)
+		}
+	}
+	if current, _ := tracker.Current(); current.HP != readings {
+		t.Errorf("Current().HP = %d, want %d", current.HP, readings)
+	}
+
+	// Switching server forgets the readings
+	if err := tracker.Load("elsewhere"); err != nil {
+		t.Fatal(err)
+	}
+	if got := tracker.History(time.Time{}); len(got) != 0 {
+		t.Errorf("history after Load holds %d readings, want none", len(got))
+	}
+}
+
+// promptFor makes a prompt showing hp
+func promptFor(hp int) string {
+	return fmt.Sprintf("<%d/9999hp>", hp)
+}
@@ -3,6 +3,8 @@ package main
 import (
 	"embed"
 
+	"seemud-gui/internal/logging"
+
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
 	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
@@ -12,6 +14,8 @@ import (
 var assets embed.FS
 
 func main() {
+	logging.Install()
+
 	// Create an instance of the app structure
 	app := NewApp()
 
@@ -21,10 +25,12 @@ func main() {
 		Width:  1024,
 		Height: 768,
 		AssetServer: &assetserver.Options{
-			Assets: assets,
+			Assets:  assets,
+			Handler: app.assetHandler(),
 		},
 		BackgroundColour: &options.RGBA{R: 27, G: 38, B: 54, A: 1},
 		OnStartup:        app.startup,
+		OnShutdown:       app.shutdown,
 		Bind: []interface{}{
 			app,
 		},